@@ -0,0 +1,96 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/goguardian/blox/cluster-state-service/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// unprintedFlags are the flags that are not part of the configuration
+var unprintedFlags = map[string]struct{}{
+	configFileFlag: {},
+	versionFlag:    {},
+}
+
+// secretFlags are the flags whose values are redacted when printed
+var secretFlags = map[string]struct{}{
+	etcdPasswordFlag: {},
+}
+
+const redacted = "<redacted>"
+
+func createConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of cluster-state-service",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration and validate it",
+		Long: `Print the configuration the service would start with, after applying the
+config file, environment variables and flags, in the format of the config file.
+The command fails if the configuration is invalid.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := printConfig(cmd.OutOrStdout(), cmd.Root().PersistentFlags())
+			if err != nil {
+				return err
+			}
+			return config.Validate()
+		},
+	})
+	return configCmd
+}
+
+// printConfig prints the values of the flags that make up the configuration
+// as YAML, keyed by flag name
+func printConfig(w io.Writer, flags *pflag.FlagSet) error {
+	settings := make(map[string]interface{})
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if _, ok := unprintedFlags[flag.Name]; ok || err != nil {
+			return
+		}
+		if _, ok := secretFlags[flag.Name]; ok && flag.Value.String() != "" {
+			settings[flag.Name] = redacted
+			return
+		}
+		switch flag.Value.Type() {
+		case "stringArray":
+			settings[flag.Name], err = flags.GetStringArray(flag.Name)
+		case "int":
+			settings[flag.Name], err = flags.GetInt(flag.Name)
+		case "bool":
+			settings[flag.Name], err = flags.GetBool(flag.Name)
+		default:
+			settings[flag.Name] = flag.Value.String()
+		}
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Could not read the configuration")
+	}
+
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return errors.Wrapf(err, "Could not encode the configuration")
+	}
+	_, err = fmt.Fprint(w, string(out))
+	return err
+}
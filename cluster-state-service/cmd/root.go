@@ -14,45 +14,250 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/goguardian/blox/cluster-state-service/config"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 const (
+	configFileFlag   = "config"
 	queueNameURIFlag = "queue"
 	cssBindFlag      = "bind"
 	etcdEndpointFlag = "etcd-endpoint"
 	versionFlag      = "version"
-)
 
-// RootCmd represents the base command when called without any subcommands
-var RootCmd *cobra.Command
+	stoppedTaskRetentionFlag      = "stopped-task-retention"
+	inactiveInstanceRetentionFlag = "inactive-instance-retention"
+	tombstoneRetentionFlag        = "tombstone-retention"
+	historyMaxEntriesFlag         = "history-max-entries"
+	historyMaxAgeFlag             = "history-max-age"
+	dnsBindFlag                   = "dns-bind"
+	dnsDomainFlag                 = "dns-domain"
+	dnsTTLFlag                    = "dns-ttl"
+	readyMaxPollAgeFlag           = "ready-max-poll-age"
+	readyMaxReconcileFailuresFlag = "ready-max-reconcile-failures"
+	shutdownGracePeriodFlag       = "shutdown-grace-period"
+	reconcileIntervalFlag         = "reconcile-interval"
+	etcdDialTimeoutFlag           = "etcd-dial-timeout"
+	etcdRequestTimeoutFlag        = "etcd-request-timeout"
+	streamIdleTimeoutFlag         = "stream-idle-timeout"
+	sqsVisibilityTimeoutFlag      = "sqs-visibility-timeout"
+	kinesisGetRecordsSizeFlag     = "kinesis-get-records-size"
+	serverReadTimeoutFlag         = "server-read-timeout"
+	logFileFlag                   = "log-file"
+	logLevelFlag                  = "log-level"
+	tlsCertFileFlag               = "tls-cert-file"
+	tlsKeyFileFlag                = "tls-key-file"
+	tlsClientCAFileFlag           = "tls-client-ca-file"
+	apiKeysFileFlag               = "api-keys-file"
+	etcdCAFileFlag                = "etcd-ca-file"
+	etcdCertFileFlag              = "etcd-cert-file"
+	etcdKeyFileFlag               = "etcd-key-file"
+	etcdUsernameFlag              = "etcd-username"
+	etcdPasswordFlag              = "etcd-password"
+	etcdAutoSyncIntervalFlag      = "etcd-auto-sync-interval"
+	etcdKeepAliveIntervalFlag     = "etcd-keepalive-interval"
+	etcdKeepAliveTimeoutFlag      = "etcd-keepalive-timeout"
+	eventBufferDirFlag            = "event-buffer-dir"
+	etcdBreakerMaxFailuresFlag    = "etcd-breaker-max-failures"
+	etcdBreakerProbeIntervalFlag  = "etcd-breaker-probe-interval"
+	etcdBatchSizeFlag             = "etcd-batch-size"
+	etcdBatchLatencyFlag          = "etcd-batch-latency"
+	recordCodecFlag               = "record-codec"
+	migrateRecordCodecFlag        = "migrate-record-codec"
+	readFromViewFlag              = "read-from-view"
+	viewCheckIntervalFlag         = "view-check-interval"
+	compactionIntervalFlag        = "etcd-compaction-interval"
+	compactionRetainRevisionsFlag = "etcd-compaction-retain-revisions"
+	compactionRetainAgeFlag       = "etcd-compaction-retain-age"
+	defragIntervalFlag            = "etcd-defrag-interval"
+
+	defaultTombstoneRetention = 24 * time.Hour
+	defaultHistoryMaxEntries  = 50
+	defaultHistoryMaxAge      = 7 * 24 * time.Hour
+	defaultDNSDomain          = "blox.local"
+	defaultDNSTTL             = 5 * time.Second
+
+	defaultReadyMaxPollAge           = 60 * time.Second
+	defaultReadyMaxReconcileFailures = 3
+
+	defaultShutdownGracePeriod = 30 * time.Second
+
+	defaultReconcileInterval     = 20 * time.Minute
+	defaultEtcdDialTimeout       = 5 * time.Second
+	defaultEtcdRequestTimeout    = 1 * time.Minute
+	defaultStreamIdleTimeout     = 1 * time.Hour
+	defaultSQSVisibilityTimeout  = 10 * time.Second
+	defaultKinesisGetRecordsSize = 100
+	defaultServerReadTimeout     = 10 * time.Second
+	defaultLogFile               = "/var/output/logs/css.log"
+	defaultLogLevel              = "info"
 
-func init() {
-	cobra.OnInitialize(initConfig)
-	RootCmd = createRootCommand()
+	defaultEtcdKeepAliveInterval = 30 * time.Second
+	defaultEtcdKeepAliveTimeout  = 10 * time.Second
+
+	defaultEventBufferDir           = "/var/output/buffer"
+	defaultEtcdBreakerMaxFailures   = 3
+	defaultEtcdBreakerProbeInterval = 5 * time.Second
+	defaultEtcdBatchSize            = 32
+	defaultEtcdBatchLatency         = 10 * time.Millisecond
+	defaultRecordCodec              = "json"
+	defaultReadFromView             = true
+	defaultViewCheckInterval        = 10 * time.Minute
+	defaultCompactionInterval       = 5 * time.Minute
+	defaultCompactionRetainRevs     = 0
+	defaultCompactionRetainAge      = time.Hour
+	defaultDefragInterval           = 24 * time.Hour
+
+	// envPrefix is the prefix of the environment variables that set flags.
+	// For example, CSS_ETCD_ENDPOINT sets --etcd-endpoint.
+	envPrefix = "css"
+)
+
+// Execute runs the command line, starting the service with 'start' once the
+// configuration is loaded unless a subcommand is given
+func Execute(start func() error) error {
+	return createRootCommand(start).Execute()
 }
 
-func createRootCommand() *cobra.Command {
+func createRootCommand(start func() error) *cobra.Command {
+	v := viper.New()
+	var configFile string
+
 	rootCmd := &cobra.Command{
 		// TODO: Fix these messages
 		Use:   "cluster-state-service",
 		Short: "cluster-state-service consumes events from Amazon ECS and provides a local view of the cluster state",
 		Long: `cluster-state-service processes EC2 Container Service events and  creates 
-a localized data store, which provides you a near-real-time view of your cluster state.`,
-		Run: func(cmd *cobra.Command, args []string) {
+a localized data store, which provides you a near-real-time view of your cluster state.
+
+Every flag can also be set in the config file, under the name of the flag, or
+with an environment variable, for example CSS_ETCD_ENDPOINT for --etcd-endpoint.
+Flags take precedence over environment variables, which take precedence over
+the config file.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// flags parsed, so errors from here on are not usage errors
+			cmd.SilenceUsage = true
+			return loadConfig(v, cmd.Root().PersistentFlags(), configFile)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return start()
 		},
 	}
+	rootCmd.PersistentFlags().StringVar(&configFile, configFileFlag, "", "YAML or JSON file to read the configuration from")
 	// TODO: Fix the description
 	rootCmd.PersistentFlags().StringVar(&config.QueueNameURI, queueNameURIFlag, "", "Queue name should be of the form sqs://name or kinesis://name")
 	rootCmd.PersistentFlags().StringVar(&config.CSSBindAddr, cssBindFlag, "", "Cluster State Service listen address")
 	rootCmd.PersistentFlags().StringArrayVar(&config.EtcdEndpoints, etcdEndpointFlag, make([]string, 0), "Etcd node addresses")
 	rootCmd.PersistentFlags().BoolVar(&config.PrintVersion, versionFlag, false, "Print version and exit")
+	rootCmd.PersistentFlags().DurationVar(&config.StoppedTaskRetention, stoppedTaskRetentionFlag, 0, "How long to keep STOPPED tasks after they stopped, for example 1h. 0 keeps them until the reconciler removes them")
+	rootCmd.PersistentFlags().DurationVar(&config.InactiveInstanceRetention, inactiveInstanceRetentionFlag, 0, "How long to keep INACTIVE container instances after their last update. 0 keeps them until the reconciler removes them")
+	rootCmd.PersistentFlags().DurationVar(&config.TombstoneRetention, tombstoneRetentionFlag, defaultTombstoneRetention, "How long to remember purged entities so that late events do not recreate them")
+	rootCmd.PersistentFlags().IntVar(&config.HistoryMaxEntries, historyMaxEntriesFlag, defaultHistoryMaxEntries, "Maximum number of states kept in the history of each task and container instance. 0 disables history")
+	rootCmd.PersistentFlags().DurationVar(&config.HistoryMaxAge, historyMaxAgeFlag, defaultHistoryMaxAge, "How long to keep states in the history of each task and container instance. 0 keeps them regardless of age")
+	rootCmd.PersistentFlags().StringVar(&config.DNSBindAddr, dnsBindFlag, "", "DNS server listen address, for example :8053. The DNS server is disabled if it is not set")
+	rootCmd.PersistentFlags().StringVar(&config.DNSDomain, dnsDomainFlag, defaultDNSDomain, "Domain the DNS server serves the SRV and A records of running tasks under")
+	rootCmd.PersistentFlags().DurationVar(&config.DNSTTL, dnsTTLFlag, defaultDNSTTL, "How long resolvers may cache the records of the DNS server")
+	rootCmd.PersistentFlags().DurationVar(&config.ReadyMaxPollAge, readyMaxPollAgeFlag, defaultReadyMaxPollAge, "How long the event consumer may go without polling its queue successfully before /readyz reports the service as not ready. 0 disables the check")
+	rootCmd.PersistentFlags().IntVar(&config.ReadyMaxReconcileFailures, readyMaxReconcileFailuresFlag, defaultReadyMaxReconcileFailures, "How many reconcile loops in a row may fail before /readyz reports the service as not ready. 0 disables the check")
+	rootCmd.PersistentFlags().DurationVar(&config.ShutdownGracePeriod, shutdownGracePeriodFlag, defaultShutdownGracePeriod, "How long to wait on SIGINT or SIGTERM for the event consumer, the reconciler and HTTP requests to finish before exiting")
+	rootCmd.PersistentFlags().DurationVar(&config.ReconcileInterval, reconcileIntervalFlag, defaultReconcileInterval, "Interval between reconcile loops")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdDialTimeout, etcdDialTimeoutFlag, defaultEtcdDialTimeout, "How long to wait for a connection to etcd")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdRequestTimeout, etcdRequestTimeoutFlag, defaultEtcdRequestTimeout, "How long to wait for an etcd request, including those that list keys by prefix")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdCAFile, etcdCAFileFlag, "", "PEM certificate authorities to verify the certificates of the etcd nodes with. Connections to etcd use TLS if any etcd TLS flag is set")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdCertFile, etcdCertFileFlag, "", "PEM client certificate to present to etcd")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdKeyFile, etcdKeyFileFlag, "", "PEM private key of the etcd client certificate")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdUsername, etcdUsernameFlag, "", "Etcd user to authenticate as")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdPassword, etcdPasswordFlag, "", "Password of the etcd user. Prefer setting it with CSS_ETCD_PASSWORD or the config file")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdAutoSyncInterval, etcdAutoSyncIntervalFlag, 0, "How often to update the etcd endpoints with the members of the etcd cluster. 0 disables auto-sync")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdKeepAliveInterval, etcdKeepAliveIntervalFlag, defaultEtcdKeepAliveInterval, "How often to probe the connection to etcd so that it is not dropped while idle and broken connections are redialed. 0 disables keepalive")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdKeepAliveTimeout, etcdKeepAliveTimeoutFlag, defaultEtcdKeepAliveTimeout, "How long to wait for a response to an etcd keepalive probe")
+	rootCmd.PersistentFlags().StringVar(&config.EventBufferDir, eventBufferDirFlag, defaultEventBufferDir, "Directory of the local write-ahead buffer that events are queued in while etcd is unavailable. Events are not buffered if it is empty")
+	rootCmd.PersistentFlags().IntVar(&config.EtcdBreakerMaxFailures, etcdBreakerMaxFailuresFlag, defaultEtcdBreakerMaxFailures, "How many times in a row applying an event may fail because etcd is unavailable before events are buffered without trying etcd first")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdBreakerProbeInterval, etcdBreakerProbeIntervalFlag, defaultEtcdBreakerProbeInterval, "How often to probe etcd while events are buffered because it is unavailable")
+	rootCmd.PersistentFlags().IntVar(&config.EtcdBatchSize, etcdBatchSizeFlag, defaultEtcdBatchSize, "Maximum number of records written to etcd in a single transaction, 1 writes records one by one")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdBatchLatency, etcdBatchLatencyFlag, defaultEtcdBatchLatency, "How long a record waits for more records to be written to etcd with")
+	rootCmd.PersistentFlags().StringVar(&config.RecordCodec, recordCodecFlag, defaultRecordCodec, "Codec that tasks and container instances are written with, json or flate. Records stored with another codec are read as they are until they are next written")
+	rootCmd.PersistentFlags().BoolVar(&config.MigrateRecordCodec, migrateRecordCodecFlag, false, "Re-encode the records stored with another codec than --record-codec in the background after bootstrapping. Every re-encoded record is streamed as a change")
+	rootCmd.PersistentFlags().BoolVar(&config.ReadFromView, readFromViewFlag, defaultReadFromView, "Serve task and container instance reads from an in-memory view kept current by watching etcd, set to false to read directly from etcd")
+	rootCmd.PersistentFlags().DurationVar(&config.ViewCheckInterval, viewCheckIntervalFlag, defaultViewCheckInterval, "How often the in-memory view is compared to etcd, the view is seeded again if they differ")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdCompactionInterval, compactionIntervalFlag, defaultCompactionInterval, "How often the instance leading compaction compacts the etcd revision history, 0 disables compaction and defragmentation")
+	rootCmd.PersistentFlags().Int64Var(&config.EtcdCompactionRetainRevisions, compactionRetainRevisionsFlag, defaultCompactionRetainRevs, "How many of the latest etcd revisions are kept when compacting, streams can be resumed from entity versions within them")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdCompactionRetainAge, compactionRetainAgeFlag, defaultCompactionRetainAge, "How long etcd revisions are kept when compacting, streams can be resumed from entity versions within it")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdDefragInterval, defragIntervalFlag, defaultDefragInterval, "How often the instance leading compaction defragments etcd members one at a time, 0 disables defragmentation")
+	rootCmd.PersistentFlags().DurationVar(&config.StreamIdleTimeout, streamIdleTimeoutFlag, defaultStreamIdleTimeout, "How long a stream may go without changes before it is closed")
+	rootCmd.PersistentFlags().DurationVar(&config.SQSVisibilityTimeout, sqsVisibilityTimeoutFlag, defaultSQSVisibilityTimeout, "How long a received SQS message is hidden from other consumers while it is processed, in whole seconds")
+	rootCmd.PersistentFlags().IntVar(&config.KinesisGetRecordsSize, kinesisGetRecordsSizeFlag, defaultKinesisGetRecordsSize, "Maximum number of records read from Kinesis in one request")
+	rootCmd.PersistentFlags().DurationVar(&config.ServerReadTimeout, serverReadTimeoutFlag, defaultServerReadTimeout, "How long the server waits to read a request")
+	rootCmd.PersistentFlags().StringVar(&config.LogFile, logFileFlag, defaultLogFile, "File to log to, in addition to the console")
+	rootCmd.PersistentFlags().StringVar(&config.LogLevel, logLevelFlag, defaultLogLevel, "Minimum level of the messages to log: debug, info, warn, error, crit or none")
+	rootCmd.PersistentFlags().StringVar(&config.TLSCertFile, tlsCertFileFlag, "", "PEM certificate to serve the API over TLS with. The API is served over plain HTTP if it is not set")
+	rootCmd.PersistentFlags().StringVar(&config.TLSKeyFile, tlsKeyFileFlag, "", "PEM private key of the TLS certificate")
+	rootCmd.PersistentFlags().StringVar(&config.TLSClientCAFile, tlsClientCAFileFlag, "", "PEM certificate authorities to verify client certificates with. Clients are required to present a certificate if it is set")
+	rootCmd.PersistentFlags().StringVar(&config.APIKeysFile, apiKeysFileFlag, "", "YAML or JSON file of the API keys requests are authenticated with. Requests are not authenticated if it is not set")
+
+	rootCmd.AddCommand(createConfigCommand())
 	return rootCmd
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	viper.AutomaticEnv() // read in environment variables that match
+// loadConfig sets the flags that are not set on the command line from the
+// environment or, failing that, from the config file
+func loadConfig(v *viper.Viper, flags *pflag.FlagSet, configFile string) error {
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	if configFile == "" {
+		configFile = v.GetString(configFileFlag)
+	}
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+		err := v.ReadInConfig()
+		if err != nil {
+			return errors.Wrapf(err, "Could not read config file '%s'", configFile)
+		}
+	}
+
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == configFileFlag || !v.IsSet(flag.Name) {
+			return
+		}
+		err = setFlag(flag, v.Get(flag.Name))
+	})
+	return err
+}
+
+// setFlag sets 'flag' to 'value' read from the environment or the config file.
+// Environment variables set lists as comma separated values.
+func setFlag(flag *pflag.Flag, value interface{}) error {
+	var values []string
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			values = append(values, fmt.Sprint(item))
+		}
+	case string:
+		if flag.Value.Type() == "stringArray" {
+			values = strings.Split(value, ",")
+		} else {
+			values = []string{value}
+		}
+	default:
+		values = []string{fmt.Sprint(value)}
+	}
+
+	for _, val := range values {
+		err := flag.Value.Set(strings.TrimSpace(val))
+		if err != nil {
+			return errors.Wrapf(err, "Invalid value '%v' of %s", value, flag.Name)
+		}
+	}
+	return nil
 }
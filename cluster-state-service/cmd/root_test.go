@@ -14,36 +14,171 @@
 package cmd
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/goguardian/blox/cluster-state-service/config"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
+func noStart() error {
+	return nil
+}
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "css-config")
+	assert.Nil(t, err, "Unexpected error creating config directory")
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	assert.Nil(t, err, "Unexpected error writing config file")
+	return path
+}
+
 func TestRootCommandFailsWithUnknownFlag(t *testing.T) {
-	rootCmd := createRootCommand()
+	rootCmd := createRootCommand(noStart)
 	rootCmd.SetArgs(strings.Split("--unknown", " "))
 	assert.Error(t, rootCmd.Execute(), "Expected error processing an unknown flag")
 }
 
 func TestRootCommandWithSQSName(t *testing.T) {
-	rootCmd := createRootCommand()
+	rootCmd := createRootCommand(noStart)
 	rootCmd.SetArgs(strings.Split("--queue q", " "))
 	assert.NoError(t, rootCmd.Execute(), "Error processing the --queue flag")
-	assert.Equal(t, config.QueueName, "q", "Unexpected queue name set")
+	assert.Equal(t, config.QueueNameURI, "q", "Unexpected queue name set")
 }
 
 func TestRootCommandWithOneEtcdEndpoint(t *testing.T) {
-	rootCmd := createRootCommand()
+	rootCmd := createRootCommand(noStart)
 	rootCmd.SetArgs(strings.Split("--etcd-endpoint e1", " "))
 	assert.NoError(t, rootCmd.Execute(), "Error processing the --etcd-endpoint flag")
 	assert.Equal(t, config.EtcdEndpoints, []string{"e1"}, "Unexpected etcd endpoint set")
 }
 
 func TestRootCommandWithOneMultipleEndpoints(t *testing.T) {
-	rootCmd := createRootCommand()
+	rootCmd := createRootCommand(noStart)
 	rootCmd.SetArgs(strings.Split("--etcd-endpoint e1 --etcd-endpoint e2 --etcd-endpoint e3", " "))
 	assert.NoError(t, rootCmd.Execute(), "Error processing the --etcd-endpoint flag")
 	assert.Equal(t, config.EtcdEndpoints, []string{"e1", "e2", "e3"}, "Unexpected etcd endpoint set")
 }
+
+func TestRootCommandStartsService(t *testing.T) {
+	started := false
+	rootCmd := createRootCommand(func() error {
+		started = true
+		return nil
+	})
+	rootCmd.SetArgs([]string{})
+	assert.NoError(t, rootCmd.Execute(), "Unexpected error executing the root command")
+	assert.True(t, started, "Expected the service to be started")
+}
+
+func TestRootCommandWithYAMLConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "css.yaml", `
+queue: sqs://events
+etcd-endpoint:
+- e1
+- e2
+reconcile-interval: 5m
+kinesis-get-records-size: 500
+`)
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetArgs([]string{"--config", path})
+	assert.NoError(t, rootCmd.Execute(), "Error processing the config file")
+	assert.Equal(t, "sqs://events", config.QueueNameURI, "Unexpected queue name set")
+	assert.Equal(t, []string{"e1", "e2"}, config.EtcdEndpoints, "Unexpected etcd endpoints set")
+	assert.Equal(t, 5*time.Minute, config.ReconcileInterval, "Unexpected reconcile interval set")
+	assert.Equal(t, 500, config.KinesisGetRecordsSize, "Unexpected Kinesis get records size set")
+	assert.Equal(t, defaultStreamIdleTimeout, config.StreamIdleTimeout, "Expected settings missing from the config file to keep their default")
+}
+
+func TestRootCommandWithJSONConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "css.json", `{"queue": "kinesis://events", "history-max-entries": 10}`)
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetArgs([]string{"--config", path})
+	assert.NoError(t, rootCmd.Execute(), "Error processing the config file")
+	assert.Equal(t, "kinesis://events", config.QueueNameURI, "Unexpected queue name set")
+	assert.Equal(t, 10, config.HistoryMaxEntries, "Unexpected history max entries set")
+}
+
+func TestRootCommandWithMissingConfigFile(t *testing.T) {
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetArgs([]string{"--config", "/nonexistent/css.yaml"})
+	assert.Error(t, rootCmd.Execute(), "Expected an error reading a missing config file")
+}
+
+func TestRootCommandWithInvalidConfigFileValue(t *testing.T) {
+	path := writeConfigFile(t, "css.yaml", "reconcile-interval: often\n")
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetArgs([]string{"--config", path})
+	assert.Error(t, rootCmd.Execute(), "Expected an error processing an invalid duration")
+}
+
+func TestRootCommandPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "css.yaml", `
+queue: sqs://file
+bind: :3000
+dns-domain: file.local
+`)
+	os.Setenv("CSS_BIND", ":4000")
+	os.Setenv("CSS_QUEUE", "sqs://env")
+	os.Setenv("CSS_ETCD_ENDPOINT", "e1,e2")
+	defer os.Unsetenv("CSS_BIND")
+	defer os.Unsetenv("CSS_QUEUE")
+	defer os.Unsetenv("CSS_ETCD_ENDPOINT")
+
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetArgs([]string{"--config", path, "--queue", "sqs://flag"})
+	assert.NoError(t, rootCmd.Execute(), "Error processing the configuration")
+	assert.Equal(t, "sqs://flag", config.QueueNameURI, "Expected flags to take precedence")
+	assert.Equal(t, ":4000", config.CSSBindAddr, "Expected environment variables to take precedence over the config file")
+	assert.Equal(t, []string{"e1", "e2"}, config.EtcdEndpoints, "Expected comma separated lists in environment variables")
+	assert.Equal(t, "file.local", config.DNSDomain, "Expected settings from the config file")
+}
+
+func TestConfigPrintCommand(t *testing.T) {
+	rootCmd := createRootCommand(func() error {
+		t.Error("Unexpected start of the service")
+		return nil
+	})
+	out := new(bytes.Buffer)
+	rootCmd.SetOutput(out)
+	rootCmd.SetArgs(strings.Split("config print --queue sqs://events --bind :3000 --etcd-endpoint e1 --history-max-entries 10", " "))
+	assert.NoError(t, rootCmd.Execute(), "Unexpected error printing a valid configuration")
+
+	printed := make(map[string]interface{})
+	err := yaml.Unmarshal(out.Bytes(), &printed)
+	assert.Nil(t, err, "Unexpected error decoding the printed configuration")
+	assert.Equal(t, "sqs://events", printed[queueNameURIFlag], "Unexpected queue printed")
+	assert.Equal(t, []interface{}{"e1"}, printed[etcdEndpointFlag], "Unexpected etcd endpoints printed")
+	assert.Equal(t, 10, printed[historyMaxEntriesFlag], "Unexpected history max entries printed")
+	assert.Equal(t, "20m0s", printed[reconcileIntervalFlag], "Unexpected reconcile interval printed")
+	assert.NotContains(t, printed, configFileFlag, "Unexpected config file printed")
+}
+
+func TestConfigPrintCommandRedactsSecrets(t *testing.T) {
+	rootCmd := createRootCommand(noStart)
+	out := new(bytes.Buffer)
+	rootCmd.SetOutput(out)
+	rootCmd.SetArgs(strings.Split("config print --queue sqs://events --bind :3000 --etcd-endpoint e1 --etcd-username css --etcd-password secret", " "))
+	assert.NoError(t, rootCmd.Execute(), "Unexpected error printing a valid configuration")
+
+	printed := make(map[string]interface{})
+	err := yaml.Unmarshal(out.Bytes(), &printed)
+	assert.Nil(t, err, "Unexpected error decoding the printed configuration")
+	assert.Equal(t, "css", printed[etcdUsernameFlag], "Unexpected etcd username printed")
+	assert.Equal(t, redacted, printed[etcdPasswordFlag], "Expected the etcd password to be redacted")
+	assert.NotContains(t, out.String(), "secret", "Unexpected etcd password printed")
+}
+
+func TestConfigPrintCommandInvalidConfiguration(t *testing.T) {
+	rootCmd := createRootCommand(noStart)
+	rootCmd.SetOutput(new(bytes.Buffer))
+	rootCmd.SetArgs(strings.Split("config print --queue sqs://events", " "))
+	assert.Error(t, rootCmd.Execute(), "Expected an error printing an incomplete configuration")
+}
@@ -13,6 +13,29 @@
 
 package config
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goguardian/blox/cluster-state-service/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	sqsScheme     = "sqs://"
+	kinesisScheme = "kinesis://"
+
+	// maxSQSVisibilityTimeout is the longest visibility timeout SQS supports
+	maxSQSVisibilityTimeout = 12 * time.Hour
+	// maxKinesisGetRecordsSize is the most records a Kinesis GetRecords call returns
+	maxKinesisGetRecordsSize = 10000
+	// maxEtcdBatchSize is the most records written in one etcd transaction,
+	// matching store.MaxBatchSize
+	maxEtcdBatchSize = 42
+)
+
 // EtcdEndpoints represents the etcd servers to connect to.
 var EtcdEndpoints []string
 
@@ -25,3 +48,330 @@ var CSSBindAddr string
 
 // PrintVersion represents the flag to set when printing version information.
 var PrintVersion bool
+
+// StoppedTaskRetention represents how long a STOPPED task is kept in the store
+// after it stopped. A value of zero disables purging of stopped tasks.
+var StoppedTaskRetention time.Duration
+
+// InactiveInstanceRetention represents how long an INACTIVE container instance
+// is kept in the store after its last update. A value of zero disables purging
+// of inactive instances.
+var InactiveInstanceRetention time.Duration
+
+// TombstoneRetention represents how long the tombstone of a purged entity is
+// kept around to reject late events for that entity.
+var TombstoneRetention time.Duration
+
+// HistoryMaxEntries represents the maximum number of states kept in the history
+// of each task and container instance. A value of zero disables history.
+var HistoryMaxEntries int
+
+// HistoryMaxAge represents how long a state is kept in the history of a task or
+// container instance. The latest state is always kept. A value of zero keeps
+// states regardless of their age.
+var HistoryMaxAge time.Duration
+
+// DNSBindAddr represents the address the DNS server listens on for queries
+// of the endpoints of running tasks. The DNS server is disabled if it is empty.
+var DNSBindAddr string
+
+// DNSDomain represents the domain the DNS server serves records under.
+var DNSDomain string
+
+// DNSTTL represents how long resolvers may cache the records of the DNS server.
+var DNSTTL time.Duration
+
+// ReadyMaxPollAge represents how long the event consumer may go without
+// polling its queue successfully before the service is reported as not ready.
+// A value of zero disables the check.
+var ReadyMaxPollAge time.Duration
+
+// ReadyMaxReconcileFailures represents how many reconcile loops in a row may
+// fail before the service is reported as not ready. A value of zero disables
+// the check.
+var ReadyMaxReconcileFailures int
+
+// ShutdownGracePeriod represents how long the service waits, once it receives
+// SIGINT or SIGTERM, for the event consumer, the reconciler and HTTP requests
+// to finish before it exits
+var ShutdownGracePeriod time.Duration
+
+// ReconcileInterval represents the interval between reconcile loops.
+var ReconcileInterval time.Duration
+
+// EtcdDialTimeout represents how long to wait for a connection to etcd.
+var EtcdDialTimeout time.Duration
+
+// EtcdRequestTimeout represents how long to wait for an etcd request. It has to
+// be long enough for list APIs, which read keys by prefix.
+var EtcdRequestTimeout time.Duration
+
+// EtcdCAFile represents the certificate authorities that the certificates of
+// the etcd nodes are verified with. The system's certificate authorities are
+// used if it is empty and TLS is enabled by another etcd TLS setting.
+var EtcdCAFile string
+
+// EtcdCertFile represents the client certificate presented to etcd.
+var EtcdCertFile string
+
+// EtcdKeyFile represents the private key of EtcdCertFile.
+var EtcdKeyFile string
+
+// EtcdUsername represents the etcd user to authenticate as. Requests are not
+// authenticated if it is empty.
+var EtcdUsername string
+
+// EtcdPassword represents the password of EtcdUsername.
+var EtcdPassword string
+
+// EtcdAutoSyncInterval represents how often the etcd endpoints are updated
+// with the members of the etcd cluster. 0 disables auto-sync.
+var EtcdAutoSyncInterval time.Duration
+
+// EtcdKeepAliveInterval represents how often the connection to etcd is probed.
+// 0 disables keepalive.
+var EtcdKeepAliveInterval time.Duration
+
+// EtcdKeepAliveTimeout represents how long to wait for a response to a probe.
+var EtcdKeepAliveTimeout time.Duration
+
+// EtcdBatchSize represents the maximum number of records written to etcd in a
+// single transaction. Records are written one by one if it is 1.
+var EtcdBatchSize int
+
+// EtcdBatchLatency represents how long a record waits for more records to be
+// written to etcd with.
+var EtcdBatchLatency time.Duration
+
+// RecordCodec represents the codec that tasks and container instances are
+// stored with, either json or flate.
+var RecordCodec string
+
+// MigrateRecordCodec represents whether the tasks and container instances
+// stored with another codec are re-encoded with RecordCodec after
+// bootstrapping.
+var MigrateRecordCodec bool
+
+// ReadFromView represents whether tasks and container instances are read from
+// an in-memory view of etcd instead of directly from etcd.
+var ReadFromView bool
+
+// ViewCheckInterval represents how often the in-memory view is checked for
+// consistency with etcd.
+var ViewCheckInterval time.Duration
+
+// EtcdCompactionInterval represents how often the etcd revision history is
+// compacted. Etcd is neither compacted nor defragmented if it is 0.
+var EtcdCompactionInterval time.Duration
+
+// EtcdCompactionRetainRevisions represents how many of the latest revisions
+// are kept when compacting etcd.
+var EtcdCompactionRetainRevisions int64
+
+// EtcdCompactionRetainAge represents how long revisions are kept when
+// compacting etcd.
+var EtcdCompactionRetainAge time.Duration
+
+// EtcdDefragInterval represents how often etcd members are defragmented.
+// Members are not defragmented if it is 0.
+var EtcdDefragInterval time.Duration
+
+// EventBufferDir represents the directory of the local write-ahead buffer that
+// events are queued in while etcd is unavailable. Events are not buffered if
+// it is empty.
+var EventBufferDir string
+
+// EtcdBreakerMaxFailures represents how many times in a row applying an event
+// may fail because etcd is unavailable before the etcd circuit breaker opens.
+var EtcdBreakerMaxFailures int
+
+// EtcdBreakerProbeInterval represents how often etcd is probed while the etcd
+// circuit breaker is open.
+var EtcdBreakerProbeInterval time.Duration
+
+// StreamIdleTimeout represents how long a stream may go without changes before
+// it is closed.
+var StreamIdleTimeout time.Duration
+
+// SQSVisibilityTimeout represents how long a received SQS message is hidden
+// from other consumers while it is processed.
+var SQSVisibilityTimeout time.Duration
+
+// KinesisGetRecordsSize represents the maximum number of records read from
+// Kinesis in one request.
+var KinesisGetRecordsSize int
+
+// ServerReadTimeout represents how long the server waits to read a request.
+var ServerReadTimeout time.Duration
+
+// LogFile represents the file the service logs to.
+var LogFile string
+
+// LogLevel represents the minimum level of the messages the service logs.
+var LogLevel string
+
+// TLSCertFile represents the certificate the server serves TLS with. The
+// server serves plain HTTP if it is empty.
+var TLSCertFile string
+
+// TLSKeyFile represents the private key of TLSCertFile.
+var TLSKeyFile string
+
+// TLSClientCAFile represents the certificate authorities that client
+// certificates are verified with. Clients do not need certificates if it is
+// empty.
+var TLSClientCAFile string
+
+// APIKeysFile represents the file of the API keys that requests are
+// authenticated with. Requests are not authenticated if it is empty.
+var APIKeysFile string
+
+// Validate checks that the configuration is complete and that every setting is
+// in range, returning an error that lists all invalid settings.
+func Validate() error {
+	var problems []string
+	invalid := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if QueueNameURI == "" {
+		invalid("queue is not set")
+	} else if strings.Contains(QueueNameURI, "://") &&
+		!strings.HasPrefix(QueueNameURI, sqsScheme) && !strings.HasPrefix(QueueNameURI, kinesisScheme) {
+		invalid("queue '%s' is neither of the form sqs://name nor kinesis://name", QueueNameURI)
+	}
+	if CSSBindAddr == "" {
+		invalid("bind is not set")
+	}
+	if len(EtcdEndpoints) == 0 {
+		invalid("etcd-endpoint is not set")
+	}
+
+	positive := map[string]time.Duration{
+		"reconcile-interval":   ReconcileInterval,
+		"etcd-dial-timeout":    EtcdDialTimeout,
+		"etcd-request-timeout": EtcdRequestTimeout,
+		"stream-idle-timeout":  StreamIdleTimeout,
+		"server-read-timeout":  ServerReadTimeout,
+		"dns-ttl":              DNSTTL,
+	}
+	for _, name := range sortedKeys(positive) {
+		if positive[name] <= 0 {
+			invalid("%s must be positive, got %s", name, positive[name])
+		}
+	}
+
+	nonNegative := map[string]time.Duration{
+		"stopped-task-retention":      StoppedTaskRetention,
+		"inactive-instance-retention": InactiveInstanceRetention,
+		"tombstone-retention":         TombstoneRetention,
+		"history-max-age":             HistoryMaxAge,
+		"ready-max-poll-age":          ReadyMaxPollAge,
+		"shutdown-grace-period":       ShutdownGracePeriod,
+		"etcd-auto-sync-interval":     EtcdAutoSyncInterval,
+		"etcd-keepalive-interval":     EtcdKeepAliveInterval,
+	}
+	for _, name := range sortedKeys(nonNegative) {
+		if nonNegative[name] < 0 {
+			invalid("%s cannot be negative, got %s", name, nonNegative[name])
+		}
+	}
+	if HistoryMaxEntries < 0 {
+		invalid("history-max-entries cannot be negative, got %d", HistoryMaxEntries)
+	}
+	if ReadyMaxReconcileFailures < 0 {
+		invalid("ready-max-reconcile-failures cannot be negative, got %d", ReadyMaxReconcileFailures)
+	}
+
+	if SQSVisibilityTimeout < 0 || SQSVisibilityTimeout > maxSQSVisibilityTimeout || SQSVisibilityTimeout%time.Second != 0 {
+		invalid("sqs-visibility-timeout must be whole seconds between 0s and %s, got %s", maxSQSVisibilityTimeout, SQSVisibilityTimeout)
+	}
+	if KinesisGetRecordsSize < 1 || KinesisGetRecordsSize > maxKinesisGetRecordsSize {
+		invalid("kinesis-get-records-size must be between 1 and %d, got %d", maxKinesisGetRecordsSize, KinesisGetRecordsSize)
+	}
+
+	if (TLSCertFile == "") != (TLSKeyFile == "") {
+		invalid("tls-cert-file and tls-key-file have to be set together")
+	}
+	if TLSClientCAFile != "" && TLSCertFile == "" {
+		invalid("tls-client-ca-file requires tls-cert-file and tls-key-file")
+	}
+
+	if (EtcdCertFile == "") != (EtcdKeyFile == "") {
+		invalid("etcd-cert-file and etcd-key-file have to be set together")
+	}
+	if EtcdCAFile != "" || EtcdCertFile != "" {
+		for _, endpoint := range EtcdEndpoints {
+			if strings.HasPrefix(endpoint, "http://") {
+				invalid("etcd endpoint '%s' is not https although etcd TLS is configured", endpoint)
+			}
+		}
+	}
+	if EtcdPassword != "" && EtcdUsername == "" {
+		invalid("etcd-password requires etcd-username")
+	}
+	if EtcdKeepAliveInterval > 0 && EtcdKeepAliveTimeout <= 0 {
+		invalid("etcd-keepalive-timeout must be positive when etcd keepalive is enabled, got %s", EtcdKeepAliveTimeout)
+	}
+
+	if EtcdBatchSize < 1 || EtcdBatchSize > maxEtcdBatchSize {
+		invalid("etcd-batch-size must be between 1 and %d, got %d", maxEtcdBatchSize, EtcdBatchSize)
+	}
+	if EtcdBatchSize > 1 && EtcdBatchLatency <= 0 {
+		invalid("etcd-batch-latency must be positive when batching, got %s", EtcdBatchLatency)
+	}
+	if RecordCodec != "json" && RecordCodec != "flate" {
+		invalid("record-codec '%s' is not one of json and flate", RecordCodec)
+	}
+	if ReadFromView && ViewCheckInterval <= 0 {
+		invalid("view-check-interval must be positive when reading from the view, got %s", ViewCheckInterval)
+	}
+
+	if EtcdCompactionInterval < 0 {
+		invalid("etcd-compaction-interval must not be negative, got %s", EtcdCompactionInterval)
+	}
+	if EtcdCompactionInterval > 0 {
+		if EtcdCompactionRetainRevisions < 0 {
+			invalid("etcd-compaction-retain-revisions must not be negative, got %d", EtcdCompactionRetainRevisions)
+		}
+		if EtcdCompactionRetainAge < 0 {
+			invalid("etcd-compaction-retain-age must not be negative, got %s", EtcdCompactionRetainAge)
+		}
+		if EtcdCompactionRetainRevisions == 0 && EtcdCompactionRetainAge == 0 {
+			invalid("etcd-compaction-retain-revisions or etcd-compaction-retain-age has to be set when compacting etcd")
+		}
+		if EtcdDefragInterval < 0 {
+			invalid("etcd-defrag-interval must not be negative, got %s", EtcdDefragInterval)
+		}
+	}
+
+	if EventBufferDir != "" {
+		if EtcdBreakerMaxFailures < 1 {
+			invalid("etcd-breaker-max-failures must be positive, got %d", EtcdBreakerMaxFailures)
+		}
+		if EtcdBreakerProbeInterval <= 0 {
+			invalid("etcd-breaker-probe-interval must be positive, got %s", EtcdBreakerProbeInterval)
+		}
+	}
+
+	if LogFile == "" {
+		invalid("log-file is not set")
+	}
+	if !logger.IsValidLevel(LogLevel) {
+		invalid("log-level '%s' is not one of debug, info, warn, error, crit and none", LogLevel)
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("Invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func sortedKeys(durations map[string]time.Duration) []string {
+	keys := make([]string, 0, len(durations))
+	for key := range durations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setValidConfig() {
+	QueueNameURI = "sqs://events"
+	CSSBindAddr = ":3000"
+	EtcdEndpoints = []string{"localhost:2379"}
+	ReconcileInterval = 20 * time.Minute
+	EtcdDialTimeout = 5 * time.Second
+	EtcdRequestTimeout = time.Minute
+	StreamIdleTimeout = time.Hour
+	ServerReadTimeout = 10 * time.Second
+	DNSTTL = 5 * time.Second
+	SQSVisibilityTimeout = 10 * time.Second
+	KinesisGetRecordsSize = 100
+	LogFile = "/var/output/logs/css.log"
+	LogLevel = "info"
+	TLSCertFile = ""
+	TLSKeyFile = ""
+	TLSClientCAFile = ""
+	EtcdCAFile = ""
+	EtcdCertFile = ""
+	EtcdKeyFile = ""
+	EtcdUsername = ""
+	EtcdPassword = ""
+	EtcdKeepAliveInterval = 30 * time.Second
+	EtcdKeepAliveTimeout = 10 * time.Second
+	EventBufferDir = "/var/output/buffer"
+	EtcdBreakerMaxFailures = 3
+	EtcdBreakerProbeInterval = 5 * time.Second
+	EtcdBatchSize = 32
+	EtcdBatchLatency = 10 * time.Millisecond
+	RecordCodec = "flate"
+	ReadFromView = true
+	ViewCheckInterval = 10 * time.Minute
+	EtcdCompactionInterval = 5 * time.Minute
+	EtcdCompactionRetainRevisions = 0
+	EtcdCompactionRetainAge = time.Hour
+	EtcdDefragInterval = 24 * time.Hour
+}
+
+func TestValidate(t *testing.T) {
+	setValidConfig()
+	assert.Nil(t, Validate(), "Unexpected error validating a valid configuration")
+
+	QueueNameURI = "kinesis://events"
+	assert.Nil(t, Validate(), "Unexpected error validating a Kinesis queue")
+}
+
+func TestValidateInvalidSettings(t *testing.T) {
+	invalidSettings := map[string]func(){
+		"queue":                    func() { QueueNameURI = "" },
+		"queue scheme":             func() { QueueNameURI = "amqp://events" },
+		"bind":                     func() { CSSBindAddr = "" },
+		"etcd-endpoint":            func() { EtcdEndpoints = nil },
+		"reconcile-interval":       func() { ReconcileInterval = 0 },
+		"etcd-request-timeout":     func() { EtcdRequestTimeout = -time.Second },
+		"tombstone-retention":      func() { TombstoneRetention = -time.Hour },
+		"history-max-entries":      func() { HistoryMaxEntries = -1 },
+		"sqs-visibility-timeout":   func() { SQSVisibilityTimeout = 1500 * time.Millisecond },
+		"kinesis-get-records-size": func() { KinesisGetRecordsSize = 10001 },
+		"log-level":                func() { LogLevel = "verbose" },
+		"tls-key-file":             func() { TLSCertFile = "server.crt" },
+		"tls-client-ca-file":       func() { TLSClientCAFile = "ca.crt" },
+		"etcd-key-file":            func() { EtcdCertFile = "client.crt" },
+		"etcd-endpoint scheme": func() {
+			EtcdCAFile = "ca.crt"
+			EtcdEndpoints = []string{"http://localhost:2379"}
+		},
+		"etcd-password":               func() { EtcdPassword = "secret" },
+		"etcd-keepalive-interval":     func() { EtcdKeepAliveInterval = -time.Second },
+		"etcd-keepalive-timeout":      func() { EtcdKeepAliveTimeout = 0 },
+		"etcd-breaker-max-failures":   func() { EtcdBreakerMaxFailures = 0 },
+		"etcd-breaker-probe-interval": func() { EtcdBreakerProbeInterval = 0 },
+		"etcd-batch-size":             func() { EtcdBatchSize = 43 },
+		"etcd-batch-latency":          func() { EtcdBatchLatency = 0 },
+		"record-codec":                func() { RecordCodec = "protobuf" },
+		"view-check-interval":         func() { ViewCheckInterval = 0 },
+		"etcd-compaction-interval":    func() { EtcdCompactionInterval = -time.Minute },
+		"compaction retain revisions": func() { EtcdCompactionRetainRevisions = -1 },
+		"etcd-compaction-retain-age":  func() { EtcdCompactionRetainAge = -time.Hour },
+		"compaction retention":        func() { EtcdCompactionRetainAge = 0 },
+		"etcd-defrag-interval":        func() { EtcdDefragInterval = -time.Hour },
+	}
+	for name, invalidate := range invalidSettings {
+		setValidConfig()
+		invalidate()
+		assert.Error(t, Validate(), "Expected an error validating an invalid %s", name)
+	}
+	setValidConfig()
+}
+
+func TestValidateListsAllInvalidSettings(t *testing.T) {
+	setValidConfig()
+	CSSBindAddr = ""
+	KinesisGetRecordsSize = 0
+
+	err := Validate()
+	assert.Error(t, err, "Expected an error validating an invalid configuration")
+	assert.Contains(t, err.Error(), "bind is not set", "Expected the missing bind address to be reported")
+	assert.Contains(t, err.Error(), "kinesis-get-records-size", "Expected the invalid Kinesis get records size to be reported")
+	setValidConfig()
+}
@@ -14,17 +14,64 @@
 package v1
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 )
 
 type APIs struct {
 	TaskApis              TaskAPIs
 	ContainerInstanceApis ContainerInstanceAPIs
+	ServiceApis           ServiceAPIs
+	TaskDefinitionApis    TaskDefinitionAPIs
+	ClusterApis           ClusterAPIs
+	PlacementApis         PlacementAPIs
+	EndpointApis          EndpointAPIs
+	VersionApis           VersionAPIs
+	ChangeApis            ChangeAPIs
 }
 
-func NewAPIs(stores store.Stores) APIs {
+func NewAPIs(stores store.Stores, revisionStore store.RevisionStore, taskDefinitionLoader loader.TaskDefinitionLoader, hostResolver discovery.HostResolver) APIs {
 	return APIs{
-		TaskApis:              NewTaskAPIs(stores.TaskStore),
-		ContainerInstanceApis: NewContainerInstanceAPIs(stores.ContainerInstanceStore),
+		TaskApis:              NewTaskAPIs(stores.TaskStore, stores.TaskDefinitionStore, taskDefinitionLoader, stores.View),
+		ContainerInstanceApis: NewContainerInstanceAPIs(stores.ContainerInstanceStore, stores.View),
+		ServiceApis:           NewServiceAPIs(stores.ServiceStore),
+		TaskDefinitionApis:    NewTaskDefinitionAPIs(stores.TaskDefinitionStore, taskDefinitionLoader),
+		ClusterApis:           NewClusterAPIs(stores.ClusterStore, stores.TaskStore, stores.ContainerInstanceStore),
+		PlacementApis:         NewPlacementAPIs(stores.ContainerInstanceStore, stores.TaskDefinitionStore, taskDefinitionLoader),
+		EndpointApis: NewEndpointAPIs(stores.TaskStore, stores.ContainerInstanceStore, stores.TaskDefinitionStore,
+			taskDefinitionLoader, hostResolver),
+		VersionApis: NewVersionAPIs(revisionStore),
+		ChangeApis:  NewChangeAPIs(revisionStore),
+	}
+}
+
+// setRevisionHeader sets the etcd revision that a response reflects if it is
+// known. Responses read from the view reflect at least the revision read from
+// the view before them; point-in-time lists reflect exactly the revision they
+// were read at.
+func setRevisionHeader(w http.ResponseWriter, revision int64, known bool) {
+	if known {
+		w.Header().Set(etcdRevisionKey, strconv.FormatInt(revision, 10))
+	}
+}
+
+// atVersion returns the entity version that a list is read at, if any, and
+// whether the parameter 'key' is valid. The parameter is removed from 'query'
+// so that it is not treated as a filter.
+func atVersion(query url.Values, key string) (string, bool) {
+	version, ok := query[key]
+	if !ok {
+		return "", true
+	}
+	delete(query, key)
+	if len(version) != 1 || !regex.IsEntityVersion(version[0]) {
+		return "", false
 	}
+	return version[0], true
 }
@@ -0,0 +1,34 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRevisionHeader(t *testing.T) {
+	responseRecorder := httptest.NewRecorder()
+	setRevisionHeader(responseRecorder, 42, true)
+	assert.Equal(t, "42", responseRecorder.Header().Get(etcdRevisionKey), "Expected the revision of the view")
+}
+
+func TestSetRevisionHeaderNotFromView(t *testing.T) {
+	responseRecorder := httptest.NewRecorder()
+	setRevisionHeader(responseRecorder, 0, false)
+	_, ok := responseRecorder.Header()[etcdRevisionKey]
+	assert.False(t, ok, "Expected no revision when the response is not read from the view")
+}
@@ -0,0 +1,154 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	changeSinceKey = "since"
+	changeLimitKey = "limit"
+
+	defaultChangeLimit = 100
+	maxChangeLimit     = 1000
+
+	changeTypePut    = "put"
+	changeTypeDelete = "delete"
+)
+
+// ChangeAPIs encapsulates the backend datastore with which the change APIs interact
+type ChangeAPIs struct {
+	revisionStore store.RevisionStore
+}
+
+// NewChangeAPIs initializes the ChangeAPIs struct
+func NewChangeAPIs(revisionStore store.RevisionStore) ChangeAPIs {
+	return ChangeAPIs{
+		revisionStore: revisionStore,
+	}
+}
+
+// ListChanges lists a page of task and instance changes, deletes included,
+// after an entity version and the entity version the next page starts after
+func (changeAPIs ChangeAPIs) ListChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	for key := range query {
+		if key != changeSinceKey && key != changeLimitKey {
+			http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	since := query[changeSinceKey]
+	if len(since) != 1 || !regex.IsEntityVersion(since[0]) {
+		http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+		return
+	}
+	sinceVersion, err := strconv.ParseInt(since[0], 10, 64)
+	if err != nil {
+		http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultChangeLimit
+	if values, ok := query[changeLimitKey]; ok {
+		if len(values) != 1 {
+			http.Error(w, invalidLimitClientErrMsg, http.StatusBadRequest)
+			return
+		}
+		limit, err = strconv.Atoi(values[0])
+		if err != nil || limit <= 0 || limit > maxChangeLimit {
+			http.Error(w, invalidLimitClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := changeAPIs.revisionStore.GetChanges(sinceVersion, limit)
+	if err != nil {
+		switch errors.Cause(err).(type) {
+		case types.CompactedEntityVersion:
+			http.Error(w, compactedEntityVersionClientErrMsg, http.StatusGone)
+		case types.OutOfRangeEntityVersion:
+			http.Error(w, outOfRangeEntityVersionClientErrMsg, http.StatusBadRequest)
+		default:
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	extChanges := make([]*models.Change, len(page.Changes))
+	for i := range page.Changes {
+		extChange, err := toChange(page.Changes[i])
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		extChanges[i] = extChange
+	}
+
+	extPage := models.Changes{
+		Items:             extChanges,
+		NextEntityVersion: aws.String(strconv.FormatInt(page.Next, 10)),
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extPage)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+func toChange(change storetypes.Change) (*models.Change, error) {
+	changeType := changeTypePut
+	if change.Deleted {
+		changeType = changeTypeDelete
+	}
+	extChange := &models.Change{
+		Type:          aws.String(changeType),
+		EntityVersion: aws.String(change.Version),
+	}
+
+	switch {
+	case change.Task != nil:
+		extTask, err := ToTask(storetypes.VersionedTask{Task: *change.Task, Version: change.Version})
+		if err != nil {
+			return nil, err
+		}
+		extChange.Task = &extTask
+	case change.ContainerInstance != nil:
+		extInstance, err := ToContainerInstance(storetypes.VersionedContainerInstance{ContainerInstance: *change.ContainerInstance, Version: change.Version})
+		if err != nil {
+			return nil, err
+		}
+		extChange.Instance = &extInstance
+	default:
+		return nil, errors.New("Change holds neither a task nor an instance")
+	}
+	return extChange, nil
+}
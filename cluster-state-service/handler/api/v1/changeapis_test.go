@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const listChangesPrefix = "/v1/changes"
+
+func TestListChanges(t *testing.T) {
+	task := changeTask()
+	instance := changeInstance()
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	revisionStore.EXPECT().GetChanges(int64(10), 5).Return(storetypes.ChangePage{
+		Changes: []storetypes.Change{
+			{Version: "11", Task: &task},
+			{Deleted: true, Version: "12", ContainerInstance: &instance},
+		},
+		Next: 12,
+	}, nil)
+
+	responseRecorder := serveListChanges(t, revisionStore, "?since=10&limit=5")
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(t, responseContentTypeJSON, responseRecorder.Header().Get(responseContentTypeKey), "Http header is invalid")
+	var changes models.Changes
+	err := json.NewDecoder(responseRecorder.Body).Decode(&changes)
+	assert.Nil(t, err, "Unexpected error decoding response body")
+	assert.Equal(t, "12", aws.StringValue(changes.NextEntityVersion), "Expected the entity version the next page starts after")
+	assert.Equal(t, 2, len(changes.Items), "Expected the changes of the task and the instance")
+	assert.Equal(t, changeTypePut, aws.StringValue(changes.Items[0].Type), "Expected the task to be put")
+	assert.Equal(t, "11", aws.StringValue(changes.Items[0].EntityVersion), "Expected the entity version of the task change")
+	assert.Equal(t, taskARN1, aws.StringValue(changes.Items[0].Task.Entity.TaskARN), "Expected the task that was put")
+	assert.Nil(t, changes.Items[0].Instance, "Expected no instance in a task change")
+	assert.Equal(t, changeTypeDelete, aws.StringValue(changes.Items[1].Type), "Expected the instance to be deleted")
+	assert.Equal(t, "12", aws.StringValue(changes.Items[1].EntityVersion), "Expected the entity version of the instance change")
+	assert.Equal(t, instanceARN1, aws.StringValue(changes.Items[1].Instance.Entity.ContainerInstanceARN), "Expected the instance that was deleted")
+}
+
+func TestListChangesDefaultLimit(t *testing.T) {
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	revisionStore.EXPECT().GetChanges(int64(10), defaultChangeLimit).Return(storetypes.ChangePage{Changes: []storetypes.Change{}, Next: 10}, nil)
+
+	responseRecorder := serveListChanges(t, revisionStore, "?since=10")
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	var changes models.Changes
+	err := json.NewDecoder(responseRecorder.Body).Decode(&changes)
+	assert.Nil(t, err, "Unexpected error decoding response body")
+	assert.Empty(t, changes.Items, "Expected no changes")
+	assert.Equal(t, "10", aws.StringValue(changes.NextEntityVersion), "Expected the entity version the next page starts after")
+}
+
+func TestListChangesInvalidQuery(t *testing.T) {
+	for query, errMsg := range map[string]string{
+		"":                          invalidEntityVersionClientErrMsg,
+		"?since=-1":                 invalidEntityVersionClientErrMsg,
+		"?since=abc":                invalidEntityVersionClientErrMsg,
+		"?since=1&since=2":          invalidEntityVersionClientErrMsg,
+		"?since=10&limit=0":         invalidLimitClientErrMsg,
+		"?since=10&limit=1001":      invalidLimitClientErrMsg,
+		"?since=10&limit=abc":       invalidLimitClientErrMsg,
+		"?since=10&cluster=prod":    unsupportedFilterClientErrMsg,
+		"?since=10&limit=1&limit=2": invalidLimitClientErrMsg,
+	} {
+		revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+		responseRecorder := serveListChanges(t, revisionStore, query)
+
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code, "Http response status is invalid for %q", query)
+		assert.Equal(t, errMsg+"\n", responseRecorder.Body.String(), "Unexpected error for %q", query)
+	}
+}
+
+func TestListChangesStoreErrors(t *testing.T) {
+	for _, test := range []struct {
+		err    error
+		status int
+		errMsg string
+	}{
+		{types.NewCompactedEntityVersion(errors.New("Compacted")), http.StatusGone, compactedEntityVersionClientErrMsg},
+		{types.NewOutOfRangeEntityVersion(errors.New("Future revision")), http.StatusBadRequest, outOfRangeEntityVersionClientErrMsg},
+		{errors.New("Error getting changes"), http.StatusInternalServerError, internalServerErrMsg},
+	} {
+		revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+		revisionStore.EXPECT().GetChanges(int64(10), defaultChangeLimit).Return(storetypes.ChangePage{}, test.err)
+
+		responseRecorder := serveListChanges(t, revisionStore, "?since=10")
+
+		assert.Equal(t, test.status, responseRecorder.Code, "Http response status is invalid")
+		assert.Equal(t, test.errMsg+"\n", responseRecorder.Body.String(), "Unexpected error message")
+	}
+}
+
+func changeTask() types.Task {
+	return types.Task{
+		Account: &accountID,
+		Detail: &types.TaskDetail{
+			ClusterARN:           &clusterARN1,
+			ContainerInstanceARN: &instanceARN1,
+			Containers:           []*types.Container{},
+			CreatedAt:            &createdAt,
+			DesiredStatus:        &taskStatus1,
+			LastStatus:           &taskStatus1,
+			Overrides:            &types.Overrides{ContainerOverrides: []*types.ContainerOverrides{}},
+			TaskARN:              &taskARN1,
+			TaskDefinitionARN:    &taskDefinitionARN,
+			UpdatedAt:            &updatedAt1,
+			Version:              &version1,
+		},
+		ID:        &id1,
+		Region:    &region,
+		Resources: []string{taskARN1},
+		Time:      &eventTime,
+	}
+}
+
+func changeInstance() types.ContainerInstance {
+	return types.ContainerInstance{
+		Account: &accountID,
+		Detail: &types.InstanceDetail{
+			AgentConnected:       &agentConnected1,
+			ClusterARN:           &clusterARN1,
+			ContainerInstanceARN: &instanceARN1,
+			RegisteredResources:  []*types.Resource{},
+			RemainingResources:   []*types.Resource{},
+			Status:               &instanceStatus1,
+			Version:              &version1,
+			VersionInfo:          &types.VersionInfo{},
+			UpdatedAt:            &updatedAt1,
+		},
+		ID:        &id1,
+		Region:    &region,
+		Resources: []string{instanceARN1},
+		Time:      &eventTime,
+	}
+}
+
+func serveListChanges(t *testing.T, revisionStore *mocks.MockRevisionStore, query string) *httptest.ResponseRecorder {
+	router := NewRouter(APIs{ChangeApis: NewChangeAPIs(revisionStore)})
+	request, err := http.NewRequest("GET", listChangesPrefix+query, nil)
+	assert.Nil(t, err, "Unexpected error creating list changes request")
+
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	return responseRecorder
+}
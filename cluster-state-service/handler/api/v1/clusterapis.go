@@ -0,0 +1,378 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	clusterKey = "cluster"
+
+	clusterEntityVersionKey = "entityVersion"
+
+	// clusterFilter is the filter that the task and instance stores use to
+	// list the tasks and instances of a cluster
+	clusterFilter = "cluster"
+
+	// clusterSummaryInterval is how often a cluster stream summarizes the
+	// clusters that changed since its last summary
+	clusterSummaryInterval = time.Second
+)
+
+// ClusterAPIs encapsulates the backend datastores with which the cluster APIs interact.
+// Clusters are summarized from their tasks and instances when they are read.
+type ClusterAPIs struct {
+	clusterStore    store.ClusterStore
+	taskStore       store.TaskStore
+	instanceStore   store.ContainerInstanceStore
+	summaryInterval time.Duration
+}
+
+// NewClusterAPIs initializes the ClusterAPIs struct
+func NewClusterAPIs(clusterStore store.ClusterStore, taskStore store.TaskStore, instanceStore store.ContainerInstanceStore) ClusterAPIs {
+	return ClusterAPIs{
+		clusterStore:    clusterStore,
+		taskStore:       taskStore,
+		instanceStore:   instanceStore,
+		summaryInterval: clusterSummaryInterval,
+	}
+}
+
+// GetCluster gets a cluster using its ARN, its region qualified name or its name
+func (clusterAPIs ClusterAPIs) GetCluster(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cluster := vars[clusterKey]
+
+	if len(cluster) == 0 || !regex.IsCluster(cluster) {
+		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	versionedCluster, err := clusterAPIs.clusterStore.GetCluster(cluster)
+	if err != nil {
+		if _, ok := errors.Cause(err).(types.AmbiguousCluster); ok {
+			http.Error(w, ambiguousClusterClientErrMsg, http.StatusBadRequest)
+			return
+		}
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if versionedCluster == nil {
+		http.Error(w, clusterNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	extCluster, err := clusterAPIs.summarizeCluster(*versionedCluster)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extCluster)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListClusters lists all clusters
+func (clusterAPIs ClusterAPIs) ListClusters(w http.ResponseWriter, r *http.Request) {
+	clusters, err := clusterAPIs.clusterStore.ListClusters()
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	// Listing all tasks and instances once is cheaper than listing them once per cluster
+	tasks, err := clusterAPIs.taskStore.ListTasks()
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	instances, err := clusterAPIs.instanceStore.ListContainerInstances()
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	tasksByCluster := make(map[string][]storetypes.VersionedTask)
+	for _, versionedTask := range tasks {
+		if versionedTask.Task.Detail == nil {
+			continue
+		}
+		clusterARN := aws.StringValue(versionedTask.Task.Detail.ClusterARN)
+		tasksByCluster[clusterARN] = append(tasksByCluster[clusterARN], versionedTask)
+	}
+
+	instancesByCluster := make(map[string][]storetypes.VersionedContainerInstance)
+	for _, versionedInstance := range instances {
+		if versionedInstance.ContainerInstance.Detail == nil {
+			continue
+		}
+		clusterARN := aws.StringValue(versionedInstance.ContainerInstance.Detail.ClusterARN)
+		instancesByCluster[clusterARN] = append(instancesByCluster[clusterARN], versionedInstance)
+	}
+
+	extClusterItems := make([]*models.Cluster, len(clusters))
+	for i := range clusters {
+		var clusterARN string
+		if clusters[i].Cluster.Detail != nil {
+			clusterARN = aws.StringValue(clusters[i].Cluster.Detail.ClusterARN)
+		}
+		c, err := ToCluster(clusters[i], tasksByCluster[clusterARN], instancesByCluster[clusterARN])
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		extClusterItems[i] = &c
+	}
+
+	extClusters := models.Clusters{
+		Items: extClusterItems,
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extClusters)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// StreamClusters streams the summary of a cluster when the cluster or one of
+// its tasks or instances changes. Summaries list the tasks and instances of the
+// cluster, so the changes of a cluster are summarized together at most once per
+// summary interval. The entity version of a streamed cluster is the version of
+// its latest change, while its summary is that of the cluster when it is
+// streamed.
+func (clusterAPIs ClusterAPIs) StreamClusters(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	query := r.URL.Query()
+
+	entityVersion := query.Get(clusterEntityVersionKey)
+
+	if entityVersion != "" {
+		if !regex.IsEntityVersion(entityVersion) {
+			http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	clusterRespChan, err := clusterAPIs.clusterStore.StreamClusters(ctx, entityVersion)
+	if err != nil {
+		handleStreamError(w, err)
+		return
+	}
+
+	taskRespChan, err := clusterAPIs.taskStore.StreamTasks(ctx, entityVersion)
+	if err != nil {
+		handleStreamError(w, err)
+		return
+	}
+
+	instanceRespChan, err := clusterAPIs.instanceStore.StreamContainerInstances(ctx, entityVersion)
+	if err != nil {
+		handleStreamError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeStream)
+	w.Header().Set(connectionKey, connectionVal)
+	w.Header().Set(transferEncodingKey, transferEncodingVal)
+
+	// the latest version of each cluster that changed since the last summary
+	pending := make(map[string]string)
+	var summarize <-chan time.Time
+	for {
+		var clusterARN *string
+		var version string
+
+		select {
+		case clusterResp, ok := <-clusterRespChan:
+			if !ok {
+				clusterAPIs.endClusterStream(w, r, flusher, pending)
+				return
+			}
+			if clusterResp.Err != nil || clusterResp.Cluster.Detail == nil {
+				http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+				return
+			}
+			clusterARN, version = clusterResp.Cluster.Detail.ClusterARN, clusterResp.Version
+
+		case taskResp, ok := <-taskRespChan:
+			if !ok {
+				clusterAPIs.endClusterStream(w, r, flusher, pending)
+				return
+			}
+			if taskResp.Err != nil || taskResp.Task.Detail == nil {
+				http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+				return
+			}
+			clusterARN, version = taskResp.Task.Detail.ClusterARN, taskResp.Version
+
+		case instanceResp, ok := <-instanceRespChan:
+			if !ok {
+				clusterAPIs.endClusterStream(w, r, flusher, pending)
+				return
+			}
+			if instanceResp.Err != nil || instanceResp.ContainerInstance.Detail == nil {
+				http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+				return
+			}
+			clusterARN, version = instanceResp.ContainerInstance.Detail.ClusterARN, instanceResp.Version
+
+		case <-summarize:
+			summarize = nil
+			if !clusterAPIs.streamSummaries(w, flusher, pending) {
+				return
+			}
+			continue
+		}
+
+		arn := aws.StringValue(clusterARN)
+		pending[arn] = laterVersion(pending[arn], version)
+		if clusterAPIs.summaryInterval <= 0 {
+			if !clusterAPIs.streamSummaries(w, flusher, pending) {
+				return
+			}
+		} else if summarize == nil {
+			summarize = time.After(clusterAPIs.summaryInterval)
+		}
+	}
+}
+
+// endClusterStream streams the summaries of the clusters that changed since
+// the last summary before ending the stream
+func (clusterAPIs ClusterAPIs) endClusterStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher, pending map[string]string) {
+	if clusterAPIs.streamSummaries(w, flusher, pending) {
+		endStream(w, r, flusher)
+	}
+}
+
+// streamSummaries streams the summaries of the clusters in 'pending', which
+// maps their ARNs to the version they are streamed with, and clears it. It
+// returns false if the stream failed.
+func (clusterAPIs ClusterAPIs) streamSummaries(w http.ResponseWriter, flusher http.Flusher, pending map[string]string) bool {
+	clusterARNs := make([]string, 0, len(pending))
+	for clusterARN := range pending {
+		clusterARNs = append(clusterARNs, clusterARN)
+	}
+	sort.Strings(clusterARNs)
+
+	for _, clusterARN := range clusterARNs {
+		version := pending[clusterARN]
+		delete(pending, clusterARN)
+
+		versionedCluster, err := clusterAPIs.clusterStore.GetCluster(clusterARN)
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return false
+		}
+		// Tasks and instances can be streamed before their cluster is recorded,
+		// in which case the cluster is streamed once it is
+		if versionedCluster == nil {
+			continue
+		}
+		versionedCluster.Version = version
+
+		extCluster, err := clusterAPIs.summarizeCluster(*versionedCluster)
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return false
+		}
+		err = json.NewEncoder(w).Encode(extCluster)
+		if err != nil {
+			http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+			return false
+		}
+	}
+	if len(clusterARNs) > 0 {
+		flusher.Flush()
+	}
+	return true
+}
+
+// laterVersion returns the later of two entity versions. The changes of the
+// cluster, task and instance streams are not ordered with respect to each
+// other, so a cluster is streamed with the latest version of its changes.
+func laterVersion(version string, other string) string {
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return other
+	}
+	o, err := strconv.ParseInt(other, 10, 64)
+	if err != nil || v >= o {
+		return version
+	}
+	return other
+}
+
+// summarizeCluster lists the tasks and instances of a cluster and translates
+// the cluster with their summary
+func (clusterAPIs ClusterAPIs) summarizeCluster(versionedCluster storetypes.VersionedCluster) (models.Cluster, error) {
+	if versionedCluster.Cluster.Detail == nil {
+		return models.Cluster{}, errors.New("Cluster detail cannot be empty")
+	}
+	filters := map[string]string{clusterFilter: aws.StringValue(versionedCluster.Cluster.Detail.ClusterARN)}
+
+	tasks, err := clusterAPIs.taskStore.FilterTasks(filters)
+	if err != nil {
+		return models.Cluster{}, err
+	}
+
+	instances, err := clusterAPIs.instanceStore.FilterContainerInstances(filters)
+	if err != nil {
+		return models.Cluster{}, err
+	}
+
+	return ToCluster(versionedCluster, tasks, instances)
+}
+
+func handleStreamError(w http.ResponseWriter, err error) {
+	if _, ok := errors.Cause(err).(types.OutOfRangeEntityVersion); ok {
+		http.Error(w, outOfRangeEntityVersionClientErrMsg, http.StatusBadRequest)
+		return
+	}
+	http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+}
@@ -0,0 +1,534 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	getClusterPrefix     = "/v1/clusters"
+	listClustersPrefix   = "/v1/clusters"
+	streamClustersPrefix = "/v1/stream/clusters"
+)
+
+var (
+	clusterName2   = "cluster2"
+	clusterARN2    = "arn:aws:ecs:us-east-1:123456789012:cluster/" + clusterName2
+	entityVersion2 = "124"
+)
+
+type ClusterAPIsTestSuite struct {
+	suite.Suite
+	clusterStore         *mocks.MockClusterStore
+	taskStore            *mocks.MockTaskStore
+	instanceStore        *mocks.MockContainerInstanceStore
+	clusterAPIs          ClusterAPIs
+	versionedCluster1    storetypes.VersionedCluster
+	versionedCluster2    storetypes.VersionedCluster
+	versionedTask1       storetypes.VersionedTask
+	versionedInstance1   storetypes.VersionedContainerInstance
+	extCluster1          models.Cluster
+	extCluster2          models.Cluster
+	responseHeaderJSON   http.Header
+	responseHeaderStream http.Header
+
+	// We need a router because some of the apis use mux.Vars() which uses the URL
+	// parameters parsed and stored in a global map in the global context by the router.
+	router *mux.Router
+}
+
+func (suite *ClusterAPIsTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.clusterStore = mocks.NewMockClusterStore(mockCtrl)
+	suite.taskStore = mocks.NewMockTaskStore(mockCtrl)
+	suite.instanceStore = mocks.NewMockContainerInstanceStore(mockCtrl)
+
+	suite.clusterAPIs = NewClusterAPIs(suite.clusterStore, suite.taskStore, suite.instanceStore)
+
+	suite.versionedCluster1 = storetypes.VersionedCluster{
+		Cluster: types.Cluster{
+			Detail: &types.ClusterDetail{
+				ClusterARN:  &clusterARN1,
+				ClusterName: &clusterName1,
+			},
+		},
+		Version: entityVersion,
+	}
+	suite.versionedCluster2 = storetypes.VersionedCluster{
+		Cluster: types.Cluster{
+			Detail: &types.ClusterDetail{
+				ClusterARN:  &clusterARN2,
+				ClusterName: &clusterName2,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.versionedTask1 = storetypes.VersionedTask{
+		Task: types.Task{
+			Detail: &types.TaskDetail{
+				ClusterARN: &clusterARN1,
+				LastStatus: aws.String("RUNNING"),
+				TaskARN:    &taskARN1,
+			},
+		},
+		Version: entityVersion2,
+	}
+
+	suite.versionedInstance1 = storetypes.VersionedContainerInstance{
+		ContainerInstance: types.ContainerInstance{
+			Detail: &types.InstanceDetail{
+				AgentConnected:       aws.Bool(true),
+				ClusterARN:           &clusterARN1,
+				ContainerInstanceARN: &instanceARN1,
+				RegisteredResources: []*types.Resource{
+					{Name: aws.String("CPU"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(2048)},
+					{Name: aws.String("MEMORY"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(3768)},
+				},
+				RemainingResources: []*types.Resource{
+					{Name: aws.String("CPU"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(1024)},
+					{Name: aws.String("MEMORY"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(2744)},
+				},
+				Status: aws.String("ACTIVE"),
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.extCluster1 = models.Cluster{
+		Metadata: &models.Metadata{
+			EntityVersion: aws.String(entityVersion),
+		},
+		Entity: &models.ClusterDetail{
+			ClusterARN:  aws.String(clusterARN1),
+			ClusterName: aws.String(clusterName1),
+			Instances: &models.ClusterInstanceCounts{
+				AgentConnected:    aws.Int64(1),
+				AgentDisconnected: aws.Int64(0),
+				ByStatus:          map[string]int64{"ACTIVE": 1},
+				Total:             aws.Int64(1),
+			},
+			Resources: &models.ClusterResources{
+				RegisteredCPU:    aws.Int64(2048),
+				RegisteredMemory: aws.Int64(3768),
+				RemainingCPU:     aws.Int64(1024),
+				RemainingMemory:  aws.Int64(2744),
+			},
+			Tasks: &models.ClusterTaskCounts{
+				ByLastStatus: map[string]int64{"RUNNING": 1},
+				Total:        aws.Int64(1),
+			},
+		},
+	}
+
+	extCluster2, err := ToCluster(suite.versionedCluster2, nil, nil)
+	if err != nil {
+		suite.T().Error("Cannot setup testSuite: Error when tranlating cluster to external model")
+	}
+	suite.extCluster2 = extCluster2
+
+	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
+	suite.responseHeaderStream = http.Header{
+		responseContentTypeKey:      []string{responseContentTypeStream},
+		responseConnectionKey:       []string{responseConnectionVal},
+		responseTransferEncodingKey: []string{responseTransferEncodingVal},
+	}
+
+	suite.router = suite.getRouter()
+}
+
+func TestClusterAPIsTestSuite(t *testing.T) {
+	suite.Run(t, new(ClusterAPIsTestSuite))
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterReturnsCluster() {
+	filters := map[string]string{clusterFilter: clusterARN1}
+	suite.clusterStore.EXPECT().GetCluster(clusterName1).Return(&suite.versionedCluster1, nil)
+	suite.taskStore.EXPECT().FilterTasks(filters).Return([]storetypes.VersionedTask{suite.versionedTask1}, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(filters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.getClusterRequest(clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	clusterInResponse := models.Cluster{}
+	err := json.NewDecoder(reader).Decode(&clusterInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), suite.extCluster1, clusterInResponse, "Cluster in response is invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterWithClusterARN() {
+	filters := map[string]string{clusterFilter: clusterARN1}
+	suite.clusterStore.EXPECT().GetCluster(clusterARN1).Return(&suite.versionedCluster1, nil)
+	suite.taskStore.EXPECT().FilterTasks(filters).Return([]storetypes.VersionedTask{suite.versionedTask1}, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(filters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.getClusterRequest(clusterARN1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterReturnsNoCluster() {
+	suite.clusterStore.EXPECT().GetCluster(clusterName1).Return(nil, nil)
+
+	request := suite.getClusterRequest(clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, clusterNotFoundClientErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterAmbiguousClusterName() {
+	suite.clusterStore.EXPECT().GetCluster(clusterName1).Return(nil, types.NewAmbiguousCluster(errors.New("Ambiguous cluster")))
+
+	request := suite.getClusterRequest(clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, ambiguousClusterClientErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterStoreReturnsError() {
+	suite.clusterStore.EXPECT().GetCluster(clusterName1).Return(nil, errors.New("Error when getting cluster"))
+
+	request := suite.getClusterRequest(clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestGetClusterTaskStoreReturnsError() {
+	suite.clusterStore.EXPECT().GetCluster(clusterName1).Return(&suite.versionedCluster1, nil)
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return(nil, errors.New("Error when filtering tasks"))
+
+	request := suite.getClusterRequest(clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestListClustersReturnsClusters() {
+	clusterList := []storetypes.VersionedCluster{suite.versionedCluster1, suite.versionedCluster2}
+	suite.clusterStore.EXPECT().ListClusters().Return(clusterList, nil)
+	suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask1}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.listClustersRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	expectedClusters := models.Clusters{
+		Items: []*models.Cluster{&suite.extCluster1, &suite.extCluster2},
+	}
+	suite.validateClustersInListClustersResponse(responseRecorder, expectedClusters)
+}
+
+func (suite *ClusterAPIsTestSuite) TestListClustersReturnsNoClusters() {
+	suite.clusterStore.EXPECT().ListClusters().Return(make([]storetypes.VersionedCluster, 0), nil)
+	suite.taskStore.EXPECT().ListTasks().Return(make([]storetypes.VersionedTask, 0), nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return(make([]storetypes.VersionedContainerInstance, 0), nil)
+
+	request := suite.listClustersRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateClustersInListClustersResponse(responseRecorder, models.Clusters{Items: []*models.Cluster{}})
+}
+
+func (suite *ClusterAPIsTestSuite) TestListClustersStoreReturnsError() {
+	suite.clusterStore.EXPECT().ListClusters().Return(nil, errors.New("Error when listing clusters"))
+
+	request := suite.listClustersRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestListClustersInstanceStoreReturnsError() {
+	suite.clusterStore.EXPECT().ListClusters().Return([]storetypes.VersionedCluster{suite.versionedCluster1}, nil)
+	suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask1}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return(nil, errors.New("Error when listing instances"))
+
+	request := suite.listClustersRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersReturnsClusterOnEachChange() {
+	clusterRespChan := make(chan storetypes.VersionedCluster)
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), "").Return(clusterRespChan, nil)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(taskRespChan, nil)
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), "").Return(instanceRespChan, nil)
+
+	// Summarize every change as it is streamed
+	suite.clusterAPIs.summaryInterval = 0
+	suite.router = suite.getRouter()
+
+	// The cluster is read again for every change, so return a copy each time
+	cluster1, cluster1AfterTaskChange := suite.versionedCluster1, suite.versionedCluster1
+	filters := map[string]string{clusterFilter: clusterARN1}
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().GetCluster(clusterARN1).Return(&cluster1, nil),
+		suite.clusterStore.EXPECT().GetCluster(clusterARN1).Return(&cluster1AfterTaskChange, nil),
+	)
+	suite.taskStore.EXPECT().FilterTasks(filters).Return([]storetypes.VersionedTask{suite.versionedTask1}, nil).Times(2)
+	suite.instanceStore.EXPECT().FilterContainerInstances(filters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil).Times(2)
+
+	go func() {
+		defer close(instanceRespChan)
+		defer close(taskRespChan)
+		defer close(clusterRespChan)
+		clusterRespChan <- suite.versionedCluster1
+		taskRespChan <- suite.versionedTask1
+	}()
+
+	request := suite.streamClustersRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	// The cluster is streamed with the version of the change that triggered it
+	extCluster1AfterTaskChange := suite.extCluster1
+	extCluster1AfterTaskChange.Metadata = &models.Metadata{EntityVersion: aws.String(entityVersion2)}
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateClustersInStreamClustersResponse(responseRecorder, []models.Cluster{suite.extCluster1, extCluster1AfterTaskChange})
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersSummarizesChangesTogether() {
+	clusterRespChan := make(chan storetypes.VersionedCluster)
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), "").Return(clusterRespChan, nil)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(taskRespChan, nil)
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), "").Return(instanceRespChan, nil)
+
+	// The changes are streamed within the summary interval, so the cluster is
+	// summarized once when the stream ends
+	filters := map[string]string{clusterFilter: clusterARN1}
+	suite.clusterStore.EXPECT().GetCluster(clusterARN1).Return(&suite.versionedCluster1, nil)
+	suite.taskStore.EXPECT().FilterTasks(filters).Return([]storetypes.VersionedTask{suite.versionedTask1}, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(filters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	go func() {
+		defer close(instanceRespChan)
+		defer close(taskRespChan)
+		defer close(clusterRespChan)
+		taskRespChan <- suite.versionedTask1
+		clusterRespChan <- suite.versionedCluster1
+	}()
+
+	request := suite.streamClustersRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	// The cluster is streamed with the latest version of its changes
+	extCluster1AfterTaskChange := suite.extCluster1
+	extCluster1AfterTaskChange.Metadata = &models.Metadata{EntityVersion: aws.String(entityVersion2)}
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateClustersInStreamClustersResponse(responseRecorder, []models.Cluster{extCluster1AfterTaskChange})
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersSkipsChangesOfUnknownClusters() {
+	clusterRespChan := make(chan storetypes.VersionedCluster)
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), entityVersion).Return(clusterRespChan, nil)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), entityVersion).Return(taskRespChan, nil)
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), entityVersion).Return(instanceRespChan, nil)
+	suite.clusterStore.EXPECT().GetCluster(clusterARN1).Return(nil, nil)
+
+	go func() {
+		defer close(clusterRespChan)
+		defer close(taskRespChan)
+		defer close(instanceRespChan)
+		instanceRespChan <- suite.versionedInstance1
+	}()
+
+	request := suite.streamClustersRequest("?entityVersion=" + entityVersion)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateClustersInStreamClustersResponse(responseRecorder, []models.Cluster{})
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersWithInvalidEntityVersion() {
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), gomock.Any()).Times(0)
+
+	request := suite.streamClustersRequest("?entityVersion=invalidEntityVersion")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidEntityVersionClientErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersWithCompactedEntityVersion() {
+	clusterRespChan := make(chan storetypes.VersionedCluster)
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), entityVersion).Return(clusterRespChan, nil)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), entityVersion).Return(nil, types.NewOutOfRangeEntityVersion(errors.New("Out of range entity version")))
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), gomock.Any()).Times(0)
+
+	request := suite.streamClustersRequest("?entityVersion=" + entityVersion)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, outOfRangeEntityVersionClientErrMsg)
+}
+
+func (suite *ClusterAPIsTestSuite) TestStreamClustersTaskResponseChannelReturnsError() {
+	clusterRespChan := make(chan storetypes.VersionedCluster)
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	suite.clusterStore.EXPECT().StreamClusters(gomock.Any(), gomock.Any()).Return(clusterRespChan, nil)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), gomock.Any()).Return(taskRespChan, nil)
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), gomock.Any()).Return(instanceRespChan, nil)
+
+	go func() {
+		defer close(clusterRespChan)
+		defer close(taskRespChan)
+		defer close(instanceRespChan)
+		taskRespChan <- storetypes.VersionedTask{Err: errors.New("VersionedTask failure")}
+	}()
+
+	request := suite.streamClustersRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+// Helper functions
+
+func (suite *ClusterAPIsTestSuite) getRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+
+	s.Path(getClusterPath).
+		Methods("GET").
+		HandlerFunc(suite.clusterAPIs.GetCluster)
+
+	s.Path(listClustersPath).Methods("GET").
+		HandlerFunc(suite.clusterAPIs.ListClusters)
+
+	s.Path(streamClustersPath).Methods("GET").
+		HandlerFunc(suite.clusterAPIs.StreamClusters)
+
+	return s
+}
+
+func (suite *ClusterAPIsTestSuite) getClusterRequest(cluster string) *http.Request {
+	url := getClusterPrefix + "/" + cluster
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get cluster request")
+	return request
+}
+
+func (suite *ClusterAPIsTestSuite) listClustersRequest() *http.Request {
+	request, err := http.NewRequest("GET", listClustersPrefix, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list clusters request")
+	return request
+}
+
+func (suite *ClusterAPIsTestSuite) streamClustersRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", streamClustersPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating stream clusters request")
+	return request
+}
+
+func (suite *ClusterAPIsTestSuite) validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderJSON, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderStream, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) validateErrorResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder, errorCode int) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), errorCode, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) decodeErrorResponseAndValidate(responseRecorder *httptest.ResponseRecorder, expectedErrMsg string) {
+	actualMsg := responseRecorder.Body.String()
+	assert.Equal(suite.T(), expectedErrMsg+"\n", actualMsg, "Error message is invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) validateClustersInListClustersResponse(responseRecorder *httptest.ResponseRecorder, expectedClusters models.Clusters) {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	clustersInResponse := new(models.Clusters)
+	err := json.NewDecoder(reader).Decode(clustersInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), expectedClusters, *clustersInResponse, "Clusters in response are invalid")
+}
+
+func (suite *ClusterAPIsTestSuite) validateClustersInStreamClustersResponse(responseRecorder *httptest.ResponseRecorder, expectedClusters []models.Cluster) {
+	scanner := bufio.NewScanner(responseRecorder.Body)
+	clustersInResponse := make([]models.Cluster, 0)
+	for scanner.Scan() {
+		cluster := new(models.Cluster)
+		err := json.Unmarshal([]byte(scanner.Text()), cluster)
+		assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+		clustersInResponse = append(clustersInResponse, *cluster)
+	}
+	assert.Exactly(suite.T(), expectedClusters, clustersInResponse, "Clusters in response is invalid")
+}
@@ -16,7 +16,7 @@ package v1
 var (
 	accountID          = "123456789012"
 	region             = "us-east-1"
-	time               = "2016-10-18T16:52:49Z"
+	eventTime          = "2016-10-18T16:52:49Z"
 	id1                = "4082c1f7-d572-4684-8b3b-a7dd637e8721"
 	instanceARN1       = "arn:aws:ecs:us-east-1:123456789012:container-instance/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
 	clusterName1       = "cluster1"
@@ -34,8 +34,24 @@ var (
 	createdAt          = "2016-10-24T06:07:53.036Z"
 	taskARN1           = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
 	taskARN2           = "arn:aws:ecs:us-east-1:123456789012:task/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
-	taskDefinitionARN  = "arn:aws:ecs:us-east-1:123456789012:task-definition/testTask:1"
+	longInstanceARN    = "arn:aws:ecs:us-east-1:123456789012:container-instance/" + clusterName1 + "/b6b9eace958e4f2aa09c8cf43b76cf97"
+	longTaskARN        = "arn:aws:ecs:us-east-1:123456789012:task/" + clusterName1 + "/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	govCloudTaskARN    = "arn:aws-us-gov:ecs:us-gov-west-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	govCloudClusterARN = "arn:aws-us-gov:ecs:us-gov-west-1:123456789012:cluster/" + clusterName1
+	taskDefinitionARN  = "arn:aws:ecs:us-east-1:123456789012:task-definition/" + taskName + ":1"
+	taskDefinitionARN2 = "arn:aws:ecs:us-east-1:123456789012:task-definition/" + taskName + ":2"
+	taskRevision1      = int64(1)
+	taskRevision2      = int64(2)
+	containerName1     = "web"
+	containerImage1    = "nginx:latest"
+	containerPort1     = int64(80)
 	entityVersion      = "123"
+	serviceName1       = "web-service"
+	serviceARN1        = "arn:aws:ecs:us-east-1:123456789012:service/" + serviceName1
+	longServiceARN     = "arn:aws:ecs:us-east-1:123456789012:service/" + clusterName1 + "/" + serviceName1
+	serviceStatus1     = "ACTIVE"
+	desiredCount1      = int64(2)
+	deploymentID1      = "ecs-svc/9223370564341623665"
 )
 
 const (
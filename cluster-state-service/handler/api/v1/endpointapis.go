@@ -0,0 +1,393 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+)
+
+const (
+	endpointClusterFilter       = "cluster"
+	endpointFamilyFilter        = "family"
+	endpointContainerFilter     = "container"
+	endpointContainerPortFilter = "containerPort"
+
+	runningTaskStatus = "RUNNING"
+	maxPort           = 65535
+)
+
+var (
+	// Using maps because arrays don't support easy lookup
+	supportedEndpointFilters = map[string]string{endpointClusterFilter: "", endpointFamilyFilter: "",
+		endpointContainerFilter: "", endpointContainerPortFilter: ""}
+	supportedPrometheusTargetFilters = map[string]string{endpointClusterFilter: "", endpointFamilyFilter: ""}
+)
+
+// EndpointAPIs encapsulates the backend datastores, the task definition loader
+// and the EC2 host resolver with which the endpoint APIs interact
+type EndpointAPIs struct {
+	taskStore            store.TaskStore
+	instanceStore        store.ContainerInstanceStore
+	taskDefinitionStore  store.TaskDefinitionStore
+	taskDefinitionLoader loader.TaskDefinitionLoader
+	hostResolver         discovery.HostResolver
+}
+
+// NewEndpointAPIs initializes the EndpointAPIs struct
+func NewEndpointAPIs(taskStore store.TaskStore, instanceStore store.ContainerInstanceStore, taskDefinitionStore store.TaskDefinitionStore,
+	taskDefinitionLoader loader.TaskDefinitionLoader, hostResolver discovery.HostResolver) EndpointAPIs {
+	return EndpointAPIs{
+		taskStore:            taskStore,
+		instanceStore:        instanceStore,
+		taskDefinitionStore:  taskDefinitionStore,
+		taskDefinitionLoader: taskDefinitionLoader,
+		hostResolver:         hostResolver,
+	}
+}
+
+// endpointQuery is the cluster and the filter that endpoints are listed with
+type endpointQuery struct {
+	cluster string
+	filter  discovery.Filter
+}
+
+// ListEndpoints lists the endpoints of the containers of running tasks, after applying filters if any
+func (endpointAPIs EndpointAPIs) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	query, errMsg := endpointAPIs.parseQuery(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	endpoints, err := endpointAPIs.listEndpoints(query)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(ToEndpoints(endpoints))
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListPrometheusTargets lists the endpoints of the containers of running
+// tasks that Prometheus should scrape, after applying filters if any, in the
+// format of the Prometheus HTTP service discovery. The container ports to
+// scrape are set by docker labels of the container definitions.
+func (endpointAPIs EndpointAPIs) ListPrometheusTargets(w http.ResponseWriter, r *http.Request) {
+	filters := r.URL.Query()
+	if hasUnsupportedPrometheusTargetFilters(filters) {
+		http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	query, errMsg := endpointAPIs.parseQuery(filters)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := endpointAPIs.loadSnapshot(query, true)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+	groups := discovery.BuildPrometheusTargetGroups(discovery.BuildEndpoints(snapshot, query.filter), snapshot.TaskDefinitions)
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(ToPrometheusTargetGroups(groups))
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// StreamEndpoints streams the endpoints of the containers of running tasks,
+// after applying filters if any. The current endpoints are streamed first and
+// then again each time a task or an instance change changes them.
+func (endpointAPIs EndpointAPIs) StreamEndpoints(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	query, errMsg := endpointAPIs.parseQuery(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	var clusterIdentifier *regex.ClusterIdentifier
+	if query.cluster != "" {
+		identifier, err := regex.ParseCluster(query.cluster)
+		if err != nil {
+			http.Error(w, invalidClusterClientErrMsg, http.StatusBadRequest)
+			return
+		}
+		clusterIdentifier = &identifier
+	}
+
+	taskRespChan, err := endpointAPIs.taskStore.StreamTasks(ctx, "")
+	if err != nil {
+		handleStreamError(w, err)
+		return
+	}
+
+	instanceRespChan, err := endpointAPIs.instanceStore.StreamContainerInstances(ctx, "")
+	if err != nil {
+		handleStreamError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	endpoints, err := endpointAPIs.listEndpoints(query)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeStream)
+	w.Header().Set(connectionKey, connectionVal)
+	w.Header().Set(transferEncodingKey, transferEncodingVal)
+
+	for {
+		err = json.NewEncoder(w).Encode(ToEndpoints(endpoints))
+		if err != nil {
+			http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		flusher.Flush()
+
+		for {
+			var clusterARN *string
+
+			select {
+			case taskResp, ok := <-taskRespChan:
+				if !ok {
+					endStream(w, r, flusher)
+					return
+				}
+				if taskResp.Err != nil || taskResp.Task.Detail == nil {
+					http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+					return
+				}
+				clusterARN = taskResp.Task.Detail.ClusterARN
+
+			case instanceResp, ok := <-instanceRespChan:
+				if !ok {
+					endStream(w, r, flusher)
+					return
+				}
+				if instanceResp.Err != nil || instanceResp.ContainerInstance.Detail == nil {
+					http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+					return
+				}
+				clusterARN = instanceResp.ContainerInstance.Detail.ClusterARN
+			}
+
+			if clusterIdentifier != nil && !clusterIdentifier.Matches(aws.StringValue(clusterARN)) {
+				continue
+			}
+
+			changed, err := endpointAPIs.listEndpoints(query)
+			if err != nil {
+				http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+				return
+			}
+			if !reflect.DeepEqual(endpoints, changed) {
+				endpoints = changed
+				break
+			}
+		}
+	}
+}
+
+// parseQuery validates the filters of an endpoints request. A client error
+// message is returned if they are invalid.
+func (endpointAPIs EndpointAPIs) parseQuery(filters url.Values) (endpointQuery, string) {
+	if hasUnsupportedEndpointFilters(filters) {
+		return endpointQuery{}, unsupportedFilterClientErrMsg
+	}
+
+	if hasRedundantEndpointFilters(filters) {
+		return endpointQuery{}, redundantFilterClientErrMsg
+	}
+
+	query := endpointQuery{
+		cluster: filters.Get(endpointClusterFilter),
+		filter: discovery.Filter{
+			Family:    filters.Get(endpointFamilyFilter),
+			Container: filters.Get(endpointContainerFilter),
+		},
+	}
+
+	if query.cluster != "" && !regex.IsCluster(query.cluster) {
+		return endpointQuery{}, invalidClusterClientErrMsg
+	}
+
+	if query.filter.Family != "" && !regex.IsTaskDefinitionFamily(query.filter.Family) {
+		return endpointQuery{}, invalidTaskDefinitionFamilyClientErrMsg
+	}
+
+	if containerPort := filters.Get(endpointContainerPortFilter); containerPort != "" {
+		port, err := strconv.ParseInt(containerPort, 10, 64)
+		if err != nil || port <= 0 || port > maxPort {
+			return endpointQuery{}, invalidContainerPortClientErrMsg
+		}
+		query.filter.ContainerPort = port
+	}
+
+	return query, ""
+}
+
+// listEndpoints builds the endpoints of the running tasks matching the query
+func (endpointAPIs EndpointAPIs) listEndpoints(query endpointQuery) ([]discovery.Endpoint, error) {
+	snapshot, err := endpointAPIs.loadSnapshot(query, false)
+	if err != nil {
+		return nil, err
+	}
+	return discovery.BuildEndpoints(snapshot, query.filter), nil
+}
+
+// loadSnapshot joins the running tasks with the instances they run on, the
+// EC2 hosts of those instances and their task definitions. Only the task
+// definitions of tasks with their own network interface are loaded unless
+// 'allTaskDefinitions' is set.
+func (endpointAPIs EndpointAPIs) loadSnapshot(query endpointQuery, allTaskDefinitions bool) (discovery.Snapshot, error) {
+	taskFilters := map[string]string{taskStatusFilter: runningTaskStatus}
+	if query.cluster != "" {
+		taskFilters[taskClusterFilter] = query.cluster
+	}
+	versionedTasks, err := endpointAPIs.taskStore.FilterTasks(taskFilters)
+	if err != nil {
+		return discovery.Snapshot{}, err
+	}
+
+	var versionedInstances []storetypes.VersionedContainerInstance
+	if query.cluster != "" {
+		versionedInstances, err = endpointAPIs.instanceStore.FilterContainerInstances(map[string]string{instanceClusterFilter: query.cluster})
+	} else {
+		versionedInstances, err = endpointAPIs.instanceStore.ListContainerInstances()
+	}
+	if err != nil {
+		return discovery.Snapshot{}, err
+	}
+
+	instances := make(map[string]types.ContainerInstance)
+	for _, versionedInstance := range versionedInstances {
+		if versionedInstance.ContainerInstance.Detail == nil {
+			continue
+		}
+		instances[aws.StringValue(versionedInstance.ContainerInstance.Detail.ContainerInstanceARN)] = versionedInstance.ContainerInstance
+	}
+
+	snapshot := discovery.Snapshot{
+		Tasks:           make([]types.Task, 0, len(versionedTasks)),
+		Instances:       instances,
+		TaskDefinitions: make(map[string]types.TaskDefinition),
+	}
+	var ec2Instances []discovery.EC2Instance
+	for _, versionedTask := range versionedTasks {
+		task := versionedTask.Task
+		if !discovery.IsRunning(task) {
+			continue
+		}
+		snapshot.Tasks = append(snapshot.Tasks, task)
+
+		if !discovery.UsesTaskNetworkInterface(task) {
+			if instance, ok := instances[aws.StringValue(task.Detail.ContainerInstanceARN)]; ok && instance.Detail.EC2InstanceID != "" {
+				ec2Instances = append(ec2Instances, discovery.EC2InstanceOf(instance))
+			}
+			if !allTaskDefinitions {
+				continue
+			}
+		}
+
+		taskDefinitionARN := aws.StringValue(task.Detail.TaskDefinitionARN)
+		if _, ok := snapshot.TaskDefinitions[taskDefinitionARN]; ok {
+			continue
+		}
+		family, _, err := regex.GetFamilyAndRevisionFromTaskDefinitionARN(taskDefinitionARN)
+		if err != nil || (query.filter.Family != "" && query.filter.Family != family) {
+			continue
+		}
+		taskDefinition, err := loader.GetOrLoadTaskDefinition(endpointAPIs.taskDefinitionStore, endpointAPIs.taskDefinitionLoader, taskDefinitionARN)
+		if err != nil {
+			return discovery.Snapshot{}, err
+		}
+		if taskDefinition != nil {
+			snapshot.TaskDefinitions[taskDefinitionARN] = taskDefinition.TaskDefinition
+		}
+	}
+
+	snapshot.Hosts, err = endpointAPIs.hostResolver.ResolveHosts(ec2Instances)
+	if err != nil {
+		return discovery.Snapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func hasUnsupportedEndpointFilters(filters map[string][]string) bool {
+	for f := range filters {
+		_, ok := supportedEndpointFilters[f]
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUnsupportedPrometheusTargetFilters(filters map[string][]string) bool {
+	for f := range filters {
+		_, ok := supportedPrometheusTargetFilters[f]
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRedundantEndpointFilters(filters map[string][]string) bool {
+	for _, val := range filters {
+		// Multiple values for a given filter implies that it has been specified multiple times
+		if len(val) > 1 {
+			return true
+		}
+	}
+	return false
+}
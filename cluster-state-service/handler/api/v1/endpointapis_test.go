@@ -0,0 +1,544 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	listEndpointsPrefix         = "/v1/endpoints"
+	streamEndpointsPrefix       = "/v1/stream/endpoints"
+	listPrometheusTargetsPrefix = "/v1/sd/prometheus"
+)
+
+var (
+	endpointEC2InstanceID = "i-0123456789abcdef0"
+	endpointHostIP        = "10.0.1.15"
+	endpointTaskIP        = "10.0.2.42"
+	endpointZone          = "us-east-1a"
+	endpointHostPort      = int64(32768)
+	runningStatus         = "RUNNING"
+)
+
+type EndpointAPIsTestSuite struct {
+	suite.Suite
+	taskStore            *mocks.MockTaskStore
+	instanceStore        *mocks.MockContainerInstanceStore
+	taskDefinitionStore  *mocks.MockTaskDefinitionStore
+	taskDefinitionLoader *mocks.MockTaskDefinitionLoader
+	hostResolver         *mocks.MockHostResolver
+	endpointAPIs         EndpointAPIs
+	bridgeTask           storetypes.VersionedTask
+	awsvpcTask           storetypes.VersionedTask
+	versionedInstance    storetypes.VersionedContainerInstance
+	taskDefinition       storetypes.VersionedTaskDefinition
+	hosts                map[string]discovery.Host
+	bridgeEndpoint       *models.Endpoint
+	awsvpcEndpoint       *models.Endpoint
+	responseHeaderJSON   http.Header
+	responseHeaderStream http.Header
+
+	// We need a router because some of the apis use mux.Vars() which uses the URL
+	// parameters parsed and stored in a global map in the global context by the router.
+	router *mux.Router
+}
+
+func (suite *EndpointAPIsTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.taskStore = mocks.NewMockTaskStore(mockCtrl)
+	suite.instanceStore = mocks.NewMockContainerInstanceStore(mockCtrl)
+	suite.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(mockCtrl)
+	suite.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(mockCtrl)
+	suite.hostResolver = mocks.NewMockHostResolver(mockCtrl)
+
+	suite.endpointAPIs = NewEndpointAPIs(suite.taskStore, suite.instanceStore, suite.taskDefinitionStore,
+		suite.taskDefinitionLoader, suite.hostResolver)
+
+	suite.bridgeTask = storetypes.VersionedTask{
+		Task: types.Task{
+			Detail: &types.TaskDetail{
+				ClusterARN:           &clusterARN1,
+				ContainerInstanceARN: &instanceARN1,
+				Containers: []*types.Container{
+					{
+						LastStatus: &runningStatus,
+						Name:       &containerName1,
+						NetworkBindings: []*types.NetworkBinding{
+							{ContainerPort: &containerPort1, HostPort: &endpointHostPort, Protocol: "tcp"},
+						},
+					},
+				},
+				LastStatus:        &runningStatus,
+				TaskARN:           &taskARN1,
+				TaskDefinitionARN: &taskDefinitionARN,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.awsvpcTask = storetypes.VersionedTask{
+		Task: types.Task{
+			Detail: &types.TaskDetail{
+				Attachments: []*types.Attachment{
+					{
+						Details: []*types.KeyValuePair{
+							{Name: aws.String("privateIPv4Address"), Value: &endpointTaskIP},
+						},
+						Type: aws.String("ElasticNetworkInterface"),
+					},
+				},
+				AvailabilityZone:  endpointZone,
+				ClusterARN:        &clusterARN1,
+				Containers:        []*types.Container{{LastStatus: &runningStatus, Name: &containerName1}},
+				LastStatus:        &runningStatus,
+				LaunchType:        "FARGATE",
+				TaskARN:           &taskARN2,
+				TaskDefinitionARN: &taskDefinitionARN2,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.versionedInstance = storetypes.VersionedContainerInstance{
+		ContainerInstance: types.ContainerInstance{
+			Detail: &types.InstanceDetail{
+				ClusterARN:           &clusterARN1,
+				ContainerInstanceARN: &instanceARN1,
+				EC2InstanceID:        endpointEC2InstanceID,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.taskDefinition = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{
+			Detail: &types.TaskDefinitionDetail{
+				ContainerDefinitions: []*types.ContainerDefinition{
+					{
+						Name:         &containerName1,
+						PortMappings: []*types.PortMapping{{ContainerPort: &containerPort1, Protocol: "tcp"}},
+					},
+				},
+				NetworkMode:       "awsvpc",
+				TaskDefinitionARN: &taskDefinitionARN2,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.hosts = map[string]discovery.Host{endpointEC2InstanceID: {PrivateIP: endpointHostIP, AvailabilityZone: endpointZone}}
+
+	suite.bridgeEndpoint = &models.Endpoint{
+		AvailabilityZone:     endpointZone,
+		ClusterARN:           &clusterARN1,
+		ContainerInstanceARN: instanceARN1,
+		ContainerName:        &containerName1,
+		ContainerPort:        &containerPort1,
+		EC2InstanceID:        endpointEC2InstanceID,
+		Family:               &taskName,
+		HostPort:             &endpointHostPort,
+		PrivateIP:            &endpointHostIP,
+		Protocol:             aws.String("tcp"),
+		TaskARN:              &taskARN1,
+		TaskDefinitionARN:    &taskDefinitionARN,
+	}
+	suite.awsvpcEndpoint = &models.Endpoint{
+		AvailabilityZone:  endpointZone,
+		ClusterARN:        &clusterARN1,
+		ContainerName:     &containerName1,
+		ContainerPort:     &containerPort1,
+		Family:            &taskName,
+		HostPort:          &containerPort1,
+		PrivateIP:         &endpointTaskIP,
+		Protocol:          aws.String("tcp"),
+		TaskARN:           &taskARN2,
+		TaskDefinitionARN: &taskDefinitionARN2,
+	}
+
+	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
+	suite.responseHeaderStream = http.Header{
+		responseContentTypeKey:      []string{responseContentTypeStream},
+		responseConnectionKey:       []string{responseConnectionVal},
+		responseTransferEncodingKey: []string{responseTransferEncodingVal},
+	}
+
+	suite.router = suite.getRouter()
+}
+
+func TestEndpointAPIsTestSuite(t *testing.T) {
+	suite.Run(t, new(EndpointAPIsTestSuite))
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpoints() {
+	tasks := []storetypes.VersionedTask{suite.bridgeTask, suite.awsvpcTask}
+	suite.taskStore.EXPECT().FilterTasks(map[string]string{taskStatusFilter: runningTaskStatus}).Return(tasks, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN2).Return(&suite.taskDefinition, nil)
+	suite.hostResolver.EXPECT().ResolveHosts([]discovery.EC2Instance{{ID: endpointEC2InstanceID, Region: "us-east-1"}}).Return(suite.hosts, nil)
+
+	request := suite.listEndpointsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateEndpointsInListEndpointsResponse(responseRecorder, models.Endpoints{
+		Items: []*models.Endpoint{suite.bridgeEndpoint, suite.awsvpcEndpoint},
+	})
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpointsWithFilters() {
+	taskFilters := map[string]string{taskStatusFilter: runningTaskStatus, taskClusterFilter: clusterName1}
+	instanceFilters := map[string]string{instanceClusterFilter: clusterName1}
+	suite.taskStore.EXPECT().FilterTasks(taskFilters).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(instanceFilters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.hostResolver.EXPECT().ResolveHosts([]discovery.EC2Instance{{ID: endpointEC2InstanceID, Region: "us-east-1"}}).Return(suite.hosts, nil)
+
+	request := suite.listEndpointsRequest("?cluster=" + clusterName1 + "&family=" + taskName + "&container=" + containerName1 + "&containerPort=80")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateEndpointsInListEndpointsResponse(responseRecorder, models.Endpoints{
+		Items: []*models.Endpoint{suite.bridgeEndpoint},
+	})
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpointsFilterMatchesNoEndpoints() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Return(suite.hosts, nil)
+
+	request := suite.listEndpointsRequest("?containerPort=443")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateEndpointsInListEndpointsResponse(responseRecorder, models.Endpoints{Items: []*models.Endpoint{}})
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpointsTaskStoreReturnsError() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return(nil, errors.New("Error when filtering tasks"))
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Times(0)
+
+	request := suite.listEndpointsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpointsHostResolverReturnsError() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Return(nil, errors.New("Error when describing EC2 instances"))
+
+	request := suite.listEndpointsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *EndpointAPIsTestSuite) TestListEndpointsWithInvalidFilters() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+
+	for query, errMsg := range map[string]string{
+		"?cluster=cluster/cluster":    invalidClusterClientErrMsg,
+		"?family=test/task":           invalidTaskDefinitionFamilyClientErrMsg,
+		"?containerPort=http":         invalidContainerPortClientErrMsg,
+		"?containerPort=0":            invalidContainerPortClientErrMsg,
+		"?containerPort=65536":        invalidContainerPortClientErrMsg,
+		"?status=running":             unsupportedFilterClientErrMsg,
+		"?container=web&container=db": redundantFilterClientErrMsg,
+	} {
+		request := suite.listEndpointsRequest(query)
+		responseRecorder := httptest.NewRecorder()
+		suite.router.ServeHTTP(responseRecorder, request)
+
+		suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+		suite.decodeErrorResponseAndValidate(responseRecorder, errMsg)
+	}
+}
+
+func (suite *EndpointAPIsTestSuite) TestStreamEndpointsStreamsEndpointSetChanges() {
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(taskRespChan, nil)
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), "").Return(instanceRespChan, nil)
+
+	stoppedTask := suite.bridgeTask
+	stoppedTaskDetail := *stoppedTask.Task.Detail
+	stoppedTaskDetail.LastStatus = aws.String("STOPPED")
+	stoppedTask.Task.Detail = &stoppedTaskDetail
+
+	taskFilters := map[string]string{taskStatusFilter: runningTaskStatus, taskClusterFilter: clusterName1}
+	instanceFilters := map[string]string{instanceClusterFilter: clusterName1}
+	gomock.InOrder(
+		// Initial endpoints
+		suite.taskStore.EXPECT().FilterTasks(taskFilters).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil),
+		// Instance change that does not change the endpoints
+		suite.taskStore.EXPECT().FilterTasks(taskFilters).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil),
+		// Task change that stops the task
+		suite.taskStore.EXPECT().FilterTasks(taskFilters).Return([]storetypes.VersionedTask{}, nil),
+	)
+	suite.instanceStore.EXPECT().FilterContainerInstances(instanceFilters).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil).Times(3)
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Return(suite.hosts, nil).Times(3)
+
+	otherClusterTask := suite.bridgeTask
+	otherClusterTaskDetail := *otherClusterTask.Task.Detail
+	otherClusterTaskDetail.ClusterARN = aws.String("arn:aws:ecs:us-east-1:123456789012:cluster/other")
+	otherClusterTask.Task.Detail = &otherClusterTaskDetail
+
+	go func() {
+		defer close(instanceRespChan)
+		defer close(taskRespChan)
+		instanceRespChan <- suite.versionedInstance
+		// Changes of other clusters are skipped
+		taskRespChan <- otherClusterTask
+		taskRespChan <- stoppedTask
+	}()
+
+	request := suite.streamEndpointsRequest("?cluster=" + clusterName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateEndpointsInStreamEndpointsResponse(responseRecorder, []models.Endpoints{
+		{Items: []*models.Endpoint{suite.bridgeEndpoint}},
+		{Items: []*models.Endpoint{}},
+	})
+}
+
+func (suite *EndpointAPIsTestSuite) TestStreamEndpointsWithInvalidFilters() {
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), gomock.Any()).Times(0)
+
+	request := suite.streamEndpointsRequest("?containerPort=http")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidContainerPortClientErrMsg)
+}
+
+func (suite *EndpointAPIsTestSuite) TestStreamEndpointsTaskStoreReturnsError() {
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(nil, errors.New("Error when streaming tasks"))
+	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), gomock.Any()).Times(0)
+
+	request := suite.streamEndpointsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *EndpointAPIsTestSuite) TestListPrometheusTargets() {
+	labeledTaskDefinition := storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{
+			Detail: &types.TaskDefinitionDetail{
+				ContainerDefinitions: []*types.ContainerDefinition{
+					{
+						DockerLabels: map[string]string{discovery.PrometheusPortsLabel: "80", discovery.PrometheusPathLabel: "/stats"},
+						Name:         &containerName1,
+					},
+				},
+				TaskDefinitionARN: &taskDefinitionARN,
+			},
+		},
+		Version: entityVersion,
+	}
+	tasks := []storetypes.VersionedTask{suite.bridgeTask, suite.awsvpcTask}
+	suite.taskStore.EXPECT().FilterTasks(map[string]string{taskStatusFilter: runningTaskStatus}).Return(tasks, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&labeledTaskDefinition, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN2).Return(&suite.taskDefinition, nil)
+	suite.hostResolver.EXPECT().ResolveHosts([]discovery.EC2Instance{{ID: endpointEC2InstanceID, Region: "us-east-1"}}).Return(suite.hosts, nil)
+
+	request := suite.listPrometheusTargetsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateTargetGroupsInListPrometheusTargetsResponse(responseRecorder, models.PrometheusTargetGroups{
+		{
+			Labels: map[string]string{
+				"__metrics_path__":             "/stats",
+				"ecs_availability_zone":        endpointZone,
+				"ecs_cluster":                  clusterName1,
+				"ecs_container_name":           containerName1,
+				"ecs_instance_id":              endpointEC2InstanceID,
+				"ecs_task_definition_family":   taskName,
+				"ecs_task_definition_revision": "1",
+			},
+			Targets: []string{endpointHostIP + ":32768"},
+		},
+	})
+}
+
+func (suite *EndpointAPIsTestSuite) TestListPrometheusTargetsWithoutLabels() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return([]storetypes.VersionedTask{suite.awsvpcTask}, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Return([]storetypes.VersionedContainerInstance{}, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN2).Return(&suite.taskDefinition, nil)
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Return(suite.hosts, nil)
+
+	request := suite.listPrometheusTargetsRequest("?cluster=" + clusterName1 + "&family=" + taskName)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	assert.Equal(suite.T(), "[]\n", responseRecorder.Body.String(), "Expected an empty list of target groups")
+}
+
+func (suite *EndpointAPIsTestSuite) TestListPrometheusTargetsTaskDefinitionStoreReturnsError() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Return([]storetypes.VersionedTask{suite.bridgeTask}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{suite.versionedInstance}, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, errors.New("Error when getting task definition"))
+	suite.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Times(0)
+
+	request := suite.listPrometheusTargetsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *EndpointAPIsTestSuite) TestListPrometheusTargetsWithInvalidFilters() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+
+	for query, errMsg := range map[string]string{
+		"?cluster=cluster/cluster": invalidClusterClientErrMsg,
+		"?family=test/task":        invalidTaskDefinitionFamilyClientErrMsg,
+		"?container=web":           unsupportedFilterClientErrMsg,
+		"?family=web&family=db":    redundantFilterClientErrMsg,
+	} {
+		request := suite.listPrometheusTargetsRequest(query)
+		responseRecorder := httptest.NewRecorder()
+		suite.router.ServeHTTP(responseRecorder, request)
+
+		suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+		suite.decodeErrorResponseAndValidate(responseRecorder, errMsg)
+	}
+}
+
+func (suite *EndpointAPIsTestSuite) getRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+
+	s.Path(listEndpointsPath).
+		Methods("GET").
+		HandlerFunc(suite.endpointAPIs.ListEndpoints)
+
+	s.Path(streamEndpointsPath).
+		Methods("GET").
+		HandlerFunc(suite.endpointAPIs.StreamEndpoints)
+
+	s.Path(listPrometheusTargetsPath).
+		Methods("GET").
+		HandlerFunc(suite.endpointAPIs.ListPrometheusTargets)
+
+	return s
+}
+
+func (suite *EndpointAPIsTestSuite) listEndpointsRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", listEndpointsPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list endpoints request")
+	return request
+}
+
+func (suite *EndpointAPIsTestSuite) streamEndpointsRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", streamEndpointsPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating stream endpoints request")
+	return request
+}
+
+func (suite *EndpointAPIsTestSuite) listPrometheusTargetsRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", listPrometheusTargetsPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list Prometheus targets request")
+	return request
+}
+
+func (suite *EndpointAPIsTestSuite) validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderJSON, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderStream, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) validateErrorResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder, errorCode int) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), errorCode, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) decodeErrorResponseAndValidate(responseRecorder *httptest.ResponseRecorder, expectedErrMsg string) {
+	actualMsg := responseRecorder.Body.String()
+	assert.Equal(suite.T(), expectedErrMsg+"\n", actualMsg, "Error message is invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) validateEndpointsInListEndpointsResponse(responseRecorder *httptest.ResponseRecorder, expectedEndpoints models.Endpoints) {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	endpointsInResponse := new(models.Endpoints)
+	err := json.NewDecoder(reader).Decode(endpointsInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), expectedEndpoints, *endpointsInResponse, "Endpoints in response are invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) validateEndpointsInStreamEndpointsResponse(responseRecorder *httptest.ResponseRecorder, expectedEndpoints []models.Endpoints) {
+	scanner := bufio.NewScanner(responseRecorder.Body)
+	endpointsInResponse := make([]models.Endpoints, 0)
+	for scanner.Scan() {
+		endpoints := new(models.Endpoints)
+		err := json.Unmarshal([]byte(scanner.Text()), endpoints)
+		assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+		endpointsInResponse = append(endpointsInResponse, *endpoints)
+	}
+	assert.Exactly(suite.T(), expectedEndpoints, endpointsInResponse, "Endpoints in response are invalid")
+}
+
+func (suite *EndpointAPIsTestSuite) validateTargetGroupsInListPrometheusTargetsResponse(responseRecorder *httptest.ResponseRecorder, expectedTargetGroups models.PrometheusTargetGroups) {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	targetGroupsInResponse := new(models.PrometheusTargetGroups)
+	err := json.NewDecoder(reader).Decode(targetGroupsInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), expectedTargetGroups, *targetGroupsInResponse, "Target groups in response are invalid")
+}
@@ -17,16 +17,35 @@ const (
 	// 4xx error messages
 	instanceNotFoundClientErrMsg             = "Instance not found"
 	taskNotFoundClientErrMsg                 = "Task not found"
+	serviceNotFoundClientErrMsg              = "Service not found"
+	taskDefinitionNotFoundClientErrMsg       = "Task definition not found"
+	clusterNotFoundClientErrMsg              = "Cluster not found"
+	instanceHistoryNotFoundClientErrMsg      = "Instance history not found"
+	taskHistoryNotFoundClientErrMsg          = "Task history not found"
 	invalidStatusClientErrMsg                = "Invalid status"
+	invalidLaunchTypeClientErrMsg            = "Invalid launch type"
 	unsupportedFilterClientErrMsg            = "At least one of the filters provided is unsupported"
 	redundantFilterClientErrMsg              = "At least one of the filters provided is specified multiple times"
 	invalidClusterClientErrMsg               = "Invalid cluster ARN or name"
+	ambiguousClusterClientErrMsg             = "Cluster name matches clusters in more than one account or region"
+	invalidTaskDefinitionFamilyClientErrMsg  = "Invalid task definition family"
+	invalidIncludeClientErrMsg               = "Invalid include"
 	unsupportedFilterCombinationClientErrMsg = "The combination of filters provided are not supported"
 	invalidEntityVersionClientErrMsg         = "Invalid entity version"
 	outOfRangeEntityVersionClientErrMsg      = "Entity version is out of range"
+	compactedEntityVersionClientErrMsg       = "Entity version has been compacted, resync by listing tasks and instances with atVersion=0"
+	invalidLimitClientErrMsg                 = "Invalid limit"
+	invalidPlacementRequestClientErrMsg      = "Invalid placement request"
+	invalidTaskDefinitionARNClientErrMsg     = "Invalid task definition ARN"
+	placementRequirementsClientErrMsg        = "Exactly one of task definition ARN and resources must be provided"
+	placementClusterMismatchClientErrMsg     = "Cluster query parameter does not match the cluster of the placement request"
+	invalidContainerPortClientErrMsg         = "Invalid container port"
 
 	// 5xx error messages
 	internalServerErrMsg = "Unexpected internal server error"
 	encodingServerErrMsg = "Unexpected server error while encoding response"
 	routingServerErrMsg  = "Unexpected server error related to api handler function routing"
+
+	// stream messages
+	shuttingDownServerErrMsg = "Server is shutting down"
 )
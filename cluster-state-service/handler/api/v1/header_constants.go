@@ -21,4 +21,7 @@ const (
 	connectionVal       = "Keep-Alive"
 	transferEncodingKey = "Transfer-Encoding"
 	transferEncodingVal = "chunked"
+	// etcdRevisionKey is set on responses served from the in-memory view to
+	// the etcd revision that they reflect at least
+	etcdRevisionKey = "X-Etcd-Revision"
 )
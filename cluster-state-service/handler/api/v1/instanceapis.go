@@ -36,6 +36,7 @@ const (
 	instanceClusterFilter = "cluster"
 
 	instanceEntityVersionKey = "entityVersion"
+	instanceAtVersionKey     = "atVersion"
 )
 
 var (
@@ -47,12 +48,15 @@ var (
 // ContainerInstanceAPIs encapsulates the backend datastore with which the container instance APIs interact
 type ContainerInstanceAPIs struct {
 	instanceStore store.ContainerInstanceStore
+	// view, when set, serves the instance store's reads
+	view *store.View
 }
 
 // NewContainerInstanceAPIs initializes the ContainerInstanceAPIs struct
-func NewContainerInstanceAPIs(instanceStore store.ContainerInstanceStore) ContainerInstanceAPIs {
+func NewContainerInstanceAPIs(instanceStore store.ContainerInstanceStore, view *store.View) ContainerInstanceAPIs {
 	return ContainerInstanceAPIs{
 		instanceStore: instanceStore,
+		view:          view,
 	}
 }
 
@@ -62,11 +66,17 @@ func (instanceAPIs ContainerInstanceAPIs) GetInstance(w http.ResponseWriter, r *
 	instanceARN := vars[instanceARNKey]
 	cluster := vars[instanceClusterKey]
 
-	if len(instanceARN) == 0 || len(cluster) == 0 || !regex.IsInstanceARN(instanceARN) || !regex.IsClusterName(cluster) {
+	if len(instanceARN) == 0 || len(cluster) == 0 || !regex.IsInstanceARN(instanceARN) || !regex.IsCluster(cluster) {
 		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 
+	if !regex.IsInCluster(instanceARN, cluster) {
+		http.Error(w, instanceNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	revision, fromView := instanceAPIs.view.Revision()
 	instance, err := instanceAPIs.instanceStore.GetContainerInstance(cluster, instanceARN)
 
 	if err != nil {
@@ -86,6 +96,7 @@ func (instanceAPIs ContainerInstanceAPIs) GetInstance(w http.ResponseWriter, r *
 	}
 
 	w.Header().Set(contentTypeKey, contentTypeJSON)
+	setRevisionHeader(w, revision, fromView)
 	w.WriteHeader(http.StatusOK)
 
 	err = json.NewEncoder(w).Encode(extInstance)
@@ -95,10 +106,57 @@ func (instanceAPIs ContainerInstanceAPIs) GetInstance(w http.ResponseWriter, r *
 	}
 }
 
-// ListInstances lists all container instances across all clusters after applying filters, if any
+// GetInstanceHistory gets the state history of an instance using the cluster name to which the instance belongs to and the instance ARN
+func (instanceAPIs ContainerInstanceAPIs) GetInstanceHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	instanceARN := vars[instanceARNKey]
+	cluster := vars[instanceClusterKey]
+
+	if len(instanceARN) == 0 || len(cluster) == 0 || !regex.IsInstanceARN(instanceARN) || !regex.IsCluster(cluster) {
+		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if !regex.IsInCluster(instanceARN, cluster) {
+		http.Error(w, instanceHistoryNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	history, err := instanceAPIs.instanceStore.GetContainerInstanceHistory(cluster, instanceARN)
+
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if len(history) == 0 {
+		http.Error(w, instanceHistoryNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	extHistory := ToContainerInstanceHistory(history)
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extHistory)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListInstances lists all container instances across all clusters after applying filters, if any.
+// With atVersion, the container instances are listed as they were at that entity version.
 func (instanceAPIs ContainerInstanceAPIs) ListInstances(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
+	version, ok := atVersion(query, instanceAtVersionKey)
+	if !ok {
+		http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
 	if instanceAPIs.hasUnsupportedFilters(query) {
 		http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
 		return
@@ -120,34 +178,47 @@ func (instanceAPIs ContainerInstanceAPIs) ListInstances(w http.ResponseWriter, r
 	}
 
 	if cluster != "" {
-		if !regex.IsClusterARN(cluster) && !regex.IsClusterName(cluster) {
+		if !regex.IsCluster(cluster) {
 			http.Error(w, invalidClusterClientErrMsg, http.StatusBadRequest)
 			return
 		}
 	}
 
+	filters := make(map[string]string)
+	if status != "" {
+		filters[instanceStatusFilter] = status
+	}
+	if cluster != "" {
+		filters[instanceClusterFilter] = cluster
+	}
+
 	var instances []storetypes.VersionedContainerInstance
 	var err error
+	revision, knownRevision := instanceAPIs.view.Revision()
 	switch {
-	case status != "" && cluster != "":
-		filters := map[string]string{instanceStatusFilter: status, instanceClusterFilter: cluster}
-		instances, err = instanceAPIs.instanceStore.FilterContainerInstances(filters)
-	case status != "":
-		filters := map[string]string{instanceStatusFilter: status}
-		instances, err = instanceAPIs.instanceStore.FilterContainerInstances(filters)
-	case cluster != "":
-		filters := map[string]string{instanceClusterFilter: cluster}
+	case version != "" && len(filters) > 0:
+		instances, revision, err = instanceAPIs.instanceStore.FilterContainerInstancesAtVersion(filters, version)
+		knownRevision = true
+	case version != "":
+		instances, revision, err = instanceAPIs.instanceStore.ListContainerInstancesAtVersion(version)
+		knownRevision = true
+	case len(filters) > 0:
 		instances, err = instanceAPIs.instanceStore.FilterContainerInstances(filters)
 	default:
 		instances, err = instanceAPIs.instanceStore.ListContainerInstances()
 	}
 
 	if err != nil {
+		if _, ok := errors.Cause(err).(types.OutOfRangeEntityVersion); ok {
+			http.Error(w, outOfRangeEntityVersionClientErrMsg, http.StatusBadRequest)
+			return
+		}
 		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set(contentTypeKey, contentTypeJSON)
+	setRevisionHeader(w, revision, knownRevision)
 	w.WriteHeader(http.StatusOK)
 
 	extInstanceItems := make([]*models.ContainerInstance, len(instances))
@@ -173,7 +244,7 @@ func (instanceAPIs ContainerInstanceAPIs) ListInstances(w http.ResponseWriter, r
 
 // StreamInstances streams container instances that change (status, resources, etc.) across all clusters
 func (instanceAPIs ContainerInstanceAPIs) StreamInstances(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithCancel(context.TODO())
+	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
 	query := r.URL.Query()
@@ -224,8 +295,7 @@ func (instanceAPIs ContainerInstanceAPIs) StreamInstances(w http.ResponseWriter,
 		}
 		flusher.Flush()
 	}
-
-	// TODO: Handle client-side termination (Ctrl+C) using w.(http.CloseNotifier).closeNotify()
+	endStream(w, r, flusher)
 }
 
 func (instanceAPIs ContainerInstanceAPIs) isValidStatus(status string) bool {
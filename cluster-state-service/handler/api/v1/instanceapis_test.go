@@ -73,7 +73,7 @@ func (suite *InstanceAPIsTestSuite) SetupTest() {
 
 	suite.instanceStore = mocks.NewMockContainerInstanceStore(mockCtrl)
 
-	suite.instanceAPIs = NewContainerInstanceAPIs(suite.instanceStore)
+	suite.instanceAPIs = NewContainerInstanceAPIs(suite.instanceStore, nil)
 
 	versionInfo := types.VersionInfo{}
 	instanceDetail := types.InstanceDetail{
@@ -90,7 +90,7 @@ func (suite *InstanceAPIsTestSuite) SetupTest() {
 	suite.instance1 = types.ContainerInstance{
 		ID:        &id1,
 		Account:   &accountID,
-		Time:      &time,
+		Time:      &eventTime,
 		Region:    &region,
 		Resources: []string{instanceARN1},
 		Detail:    &instanceDetail,
@@ -172,6 +172,61 @@ func (suite *InstanceAPIsTestSuite) TestGetInstanceWithoutARN() {
 	suite.decodeErrorResponseAndValidate(responseRecorder, routingServerErrMsg)
 }
 
+func (suite *InstanceAPIsTestSuite) TestGetInstanceHistoryReturnsHistory() {
+	history := []storetypes.ContainerInstanceHistoryEntry{
+		{Version: version1, RecordedAt: updatedAt1, State: suite.instance1.Detail.State()},
+	}
+	suite.instanceStore.EXPECT().GetContainerInstanceHistory(clusterName1, instanceARN1).Return(history, nil)
+
+	request := suite.getInstanceHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	historyInResponse := models.ContainerInstanceHistory{}
+	err := json.NewDecoder(reader).Decode(&historyInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), ToContainerInstanceHistory(history), historyInResponse, "Instance history in response is invalid")
+}
+
+func (suite *InstanceAPIsTestSuite) TestGetInstanceHistoryWithLongInstanceARN() {
+	suite.instanceStore.EXPECT().GetContainerInstanceHistory(clusterName1, longInstanceARN).Return(nil, nil)
+
+	url := getInstancePrefix + "/" + clusterName1 + "/" + longInstanceARN + "/history"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get instance history request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, instanceHistoryNotFoundClientErrMsg)
+}
+
+func (suite *InstanceAPIsTestSuite) TestGetInstanceHistoryReturnsNoHistory() {
+	suite.instanceStore.EXPECT().GetContainerInstanceHistory(clusterName1, instanceARN1).Return(nil, nil)
+
+	request := suite.getInstanceHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, instanceHistoryNotFoundClientErrMsg)
+}
+
+func (suite *InstanceAPIsTestSuite) TestGetInstanceHistoryStoreReturnsError() {
+	suite.instanceStore.EXPECT().GetContainerInstanceHistory(clusterName1, instanceARN1).Return(nil, errors.New("Error when getting instance history"))
+
+	request := suite.getInstanceHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
 func (suite *InstanceAPIsTestSuite) TestListInstancesReturnsInstances() {
 	instanceList := []storetypes.VersionedContainerInstance{suite.versionedInstance1}
 	suite.instanceStore.EXPECT().ListContainerInstances().Return(instanceList, nil)
@@ -466,6 +521,71 @@ func (suite *InstanceAPIsTestSuite) TestListInstancesWithRedundantFilters() {
 	suite.decodeErrorResponseAndValidate(responseRecorder, redundantFilterClientErrMsg)
 }
 
+func (suite *InstanceAPIsTestSuite) TestListInstancesAtVersion() {
+	instanceList := []storetypes.VersionedContainerInstance{suite.versionedInstance1}
+	suite.instanceStore.EXPECT().ListContainerInstancesAtVersion(entityVersion).Return(instanceList, int64(123), nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Times(0)
+
+	request, err := http.NewRequest("GET", listInstancesPrefix+"?atVersion="+entityVersion, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list instances request at a version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(suite.T(), "123", responseRecorder.Header().Get(etcdRevisionKey), "Expected the revision the instances were read at")
+	extInstances := models.ContainerInstances{
+		Items: []*models.ContainerInstance{&suite.extInstance1},
+	}
+	suite.validateInstancesInListOrFilterInstancesResponse(responseRecorder, extInstances)
+}
+
+func (suite *InstanceAPIsTestSuite) TestListInstancesAtVersionWithFilters() {
+	instanceList := []storetypes.VersionedContainerInstance{suite.versionedInstance1}
+	filters := map[string]string{instanceStatusFilter: instanceStatus1, instanceClusterFilter: clusterARN1}
+	suite.instanceStore.EXPECT().FilterContainerInstancesAtVersion(filters, "0").Return(instanceList, int64(130), nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest("GET", listInstancesPrefix+"?atVersion=0&status="+instanceStatus1+"&cluster="+clusterARN1, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter instances request at a version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(suite.T(), "130", responseRecorder.Header().Get(etcdRevisionKey), "Expected the revision the latest instances were read at")
+	extInstances := models.ContainerInstances{
+		Items: []*models.ContainerInstance{&suite.extInstance1},
+	}
+	suite.validateInstancesInListOrFilterInstancesResponse(responseRecorder, extInstances)
+}
+
+func (suite *InstanceAPIsTestSuite) TestListInstancesWithInvalidAtVersion() {
+	suite.instanceStore.EXPECT().ListContainerInstancesAtVersion(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest("GET", listInstancesPrefix+"?atVersion=invalidEntityVersion", nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list instances request with an invalid version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidEntityVersionClientErrMsg)
+}
+
+func (suite *InstanceAPIsTestSuite) TestListInstancesWithCompactedAtVersion() {
+	suite.instanceStore.EXPECT().ListContainerInstancesAtVersion(entityVersion).Return(nil, int64(0), types.NewOutOfRangeEntityVersion(errors.New("Out of range entity version")))
+
+	request, err := http.NewRequest("GET", listInstancesPrefix+"?atVersion="+entityVersion, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list instances request with a compacted version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, outOfRangeEntityVersionClientErrMsg)
+}
+
 func (suite *InstanceAPIsTestSuite) TestStreamInstancesReturnsInstances() {
 	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
 	suite.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), "").Return(instanceRespChan, nil)
@@ -599,6 +719,10 @@ func (suite *InstanceAPIsTestSuite) getRouter() *mux.Router {
 	r := mux.NewRouter().StrictSlash(true)
 	s := r.Path("/v1").Subrouter()
 
+	s.Path(getInstanceHistoryPath).
+		Methods("GET").
+		HandlerFunc(suite.instanceAPIs.GetInstanceHistory)
+
 	s.Path(getInstancePath).
 		Methods("GET").
 		HandlerFunc(suite.instanceAPIs.GetInstance)
@@ -624,6 +748,13 @@ func (suite *InstanceAPIsTestSuite) getInstanceRequest() *http.Request {
 	return request
 }
 
+func (suite *InstanceAPIsTestSuite) getInstanceHistoryRequest() *http.Request {
+	url := getInstancePrefix + "/" + clusterName1 + "/" + instanceARN1 + "/history"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get instance history request")
+	return request
+}
+
 func (suite *InstanceAPIsTestSuite) listInstancesRequest() *http.Request {
 	request, err := http.NewRequest("GET", listInstancesPrefix, nil)
 	assert.Nil(suite.T(), err, "Unexpected error creating list instances request")
@@ -0,0 +1,132 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/placement"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+)
+
+// PlacementAPIs encapsulates the backend datastores and the loader with which the placement APIs interact
+type PlacementAPIs struct {
+	instanceStore        store.ContainerInstanceStore
+	taskDefinitionStore  store.TaskDefinitionStore
+	taskDefinitionLoader loader.TaskDefinitionLoader
+}
+
+// NewPlacementAPIs initializes the PlacementAPIs struct
+func NewPlacementAPIs(instanceStore store.ContainerInstanceStore, taskDefinitionStore store.TaskDefinitionStore, taskDefinitionLoader loader.TaskDefinitionLoader) PlacementAPIs {
+	return PlacementAPIs{
+		instanceStore:        instanceStore,
+		taskDefinitionStore:  taskDefinitionStore,
+		taskDefinitionLoader: taskDefinitionLoader,
+	}
+}
+
+// EvaluatePlacement evaluates which container instances of a cluster can host
+// a task of a task definition, or a task with the given resource requirements,
+// and why the other instances cannot
+func (placementAPIs PlacementAPIs) EvaluatePlacement(w http.ResponseWriter, r *http.Request) {
+	var placementRequest models.PlacementRequest
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, invalidPlacementRequestClientErrMsg, http.StatusBadRequest)
+		return
+	}
+	err = json.Unmarshal(b, &placementRequest)
+	if err != nil {
+		http.Error(w, invalidPlacementRequestClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	err = placementRequest.Validate(nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cluster := *placementRequest.Cluster
+	if !regex.IsCluster(cluster) {
+		http.Error(w, invalidClusterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	// API keys restricted to clusters are authorized by the cluster query
+	// parameter, so the cluster evaluated has to be that cluster
+	if queried, ok := r.URL.Query()[instanceClusterFilter]; ok && (len(queried) != 1 || queried[0] != cluster) {
+		http.Error(w, placementClusterMismatchClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	taskDefinitionARN := placementRequest.TaskDefinitionARN
+	if (taskDefinitionARN == "") == (placementRequest.Resources == nil) {
+		http.Error(w, placementRequirementsClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	var requirements placement.Requirements
+	if taskDefinitionARN != "" {
+		if !regex.IsTaskDefinitionARN(taskDefinitionARN) {
+			http.Error(w, invalidTaskDefinitionARNClientErrMsg, http.StatusBadRequest)
+			return
+		}
+
+		taskDefinition, err := loader.GetOrLoadTaskDefinition(placementAPIs.taskDefinitionStore, placementAPIs.taskDefinitionLoader, taskDefinitionARN)
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+
+		if taskDefinition == nil {
+			http.Error(w, taskDefinitionNotFoundClientErrMsg, http.StatusNotFound)
+			return
+		}
+
+		requirements = placement.RequirementsFromTaskDefinition(taskDefinition.TaskDefinition)
+	} else {
+		requirements = fromPlacementResources(*placementRequest.Resources)
+	}
+
+	filters := map[string]string{instanceClusterFilter: cluster}
+	versionedInstances, err := placementAPIs.instanceStore.FilterContainerInstances(filters)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	instances := make([]types.ContainerInstance, len(versionedInstances))
+	for i := range versionedInstances {
+		instances[i] = versionedInstances[i].ContainerInstance
+	}
+
+	evaluations := placement.Evaluate(requirements, fromPlacementConstraints(placementRequest.Constraints), instances)
+	extEvaluation := ToPlacementEvaluation(cluster, requirements, evaluations)
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extEvaluation)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/goguardian/blox/cluster-state-service/handler/placement"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	evaluatePlacementPrefix = "/v1/placement/evaluate"
+)
+
+var (
+	placementInstanceARN2 = "arn:aws:ecs:us-east-1:123456789012:container-instance/e3f6b0b2-6c4d-4a3f-9c52-0f4cbd5e8a41"
+)
+
+type PlacementAPIsTestSuite struct {
+	suite.Suite
+	instanceStore           *mocks.MockContainerInstanceStore
+	taskDefinitionStore     *mocks.MockTaskDefinitionStore
+	taskDefinitionLoader    *mocks.MockTaskDefinitionLoader
+	placementAPIs           PlacementAPIs
+	versionedInstance1      storetypes.VersionedContainerInstance
+	versionedInstance2      storetypes.VersionedContainerInstance
+	versionedTaskDefinition storetypes.VersionedTaskDefinition
+	clusterFilter           map[string]string
+	responseHeaderJSON      http.Header
+
+	// We need a router because some of the apis use mux.Vars() which uses the URL
+	// parameters parsed and stored in a global map in the global context by the router.
+	router *mux.Router
+}
+
+func (suite *PlacementAPIsTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.instanceStore = mocks.NewMockContainerInstanceStore(mockCtrl)
+	suite.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(mockCtrl)
+	suite.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(mockCtrl)
+
+	suite.placementAPIs = NewPlacementAPIs(suite.instanceStore, suite.taskDefinitionStore, suite.taskDefinitionLoader)
+
+	suite.versionedInstance1 = suite.placementInstance(instanceARN1, 1024, 2048)
+	suite.versionedInstance2 = suite.placementInstance(placementInstanceARN2, 1024, 128)
+
+	suite.versionedTaskDefinition = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{
+			Detail: &types.TaskDefinitionDetail{
+				ContainerDefinitions: []*types.ContainerDefinition{
+					{
+						CPU:          128,
+						Memory:       256,
+						Name:         &containerName1,
+						PortMappings: []*types.PortMapping{{ContainerPort: &containerPort1, HostPort: 8080, Protocol: "tcp"}},
+					},
+				},
+				Family:            &taskName,
+				NetworkMode:       "bridge",
+				Revision:          &taskRevision1,
+				TaskDefinitionARN: &taskDefinitionARN,
+			},
+		},
+		Version: entityVersion,
+	}
+
+	suite.clusterFilter = map[string]string{instanceClusterFilter: clusterName1}
+
+	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
+
+	suite.router = suite.getRouter()
+}
+
+func TestPlacementAPIsTestSuite(t *testing.T) {
+	suite.Run(t, new(PlacementAPIsTestSuite))
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithTaskDefinition() {
+	instances := []storetypes.VersionedContainerInstance{suite.versionedInstance1, suite.versionedInstance2}
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.versionedTaskDefinition, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(gomock.Any()).Times(0)
+	suite.instanceStore.EXPECT().FilterContainerInstances(suite.clusterFilter).Return(instances, nil)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	evaluation := suite.decodePlacementEvaluation(responseRecorder)
+	assert.Equal(suite.T(), clusterName1, aws.StringValue(evaluation.Cluster), "Cluster in response is invalid")
+	assert.Equal(suite.T(), int64(128), aws.Int64Value(evaluation.Requirements.CPU), "CPU requirement in response is invalid")
+	assert.Equal(suite.T(), int64(256), aws.Int64Value(evaluation.Requirements.Memory), "Memory requirement in response is invalid")
+	assert.Len(suite.T(), evaluation.Requirements.Ports, 1, "Port requirements in response are invalid")
+	assert.Len(suite.T(), evaluation.Instances, 2, "Expected an evaluation of every instance in the cluster")
+
+	fits := evaluation.Instances[0]
+	assert.Equal(suite.T(), instanceARN1, aws.StringValue(fits.ContainerInstanceARN), "Instance in response is invalid")
+	assert.True(suite.T(), aws.BoolValue(fits.Fits), "Expected instance to fit the task")
+	assert.Empty(suite.T(), fits.Reasons, "Expected no reasons for an instance that fits the task")
+
+	doesNotFit := evaluation.Instances[1]
+	assert.Equal(suite.T(), placementInstanceARN2, aws.StringValue(doesNotFit.ContainerInstanceARN), "Instance in response is invalid")
+	assert.False(suite.T(), aws.BoolValue(doesNotFit.Fits), "Expected instance not to fit the task")
+	assert.Len(suite.T(), doesNotFit.Reasons, 1, "Expected one reason for an instance that does not fit the task")
+	assert.Equal(suite.T(), placement.ReasonInsufficientMemory, aws.StringValue(doesNotFit.Reasons[0].Code), "Reason in response is invalid")
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementLoadsUncachedTaskDefinition() {
+	gomock.InOrder(
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(nil),
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.versionedTaskDefinition, nil),
+	)
+	suite.instanceStore.EXPECT().FilterContainerInstances(suite.clusterFilter).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	evaluation := suite.decodePlacementEvaluation(responseRecorder)
+	assert.Len(suite.T(), evaluation.Instances, 1, "Expected an evaluation of every instance in the cluster")
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementTaskDefinitionNotFound() {
+	gomock.InOrder(
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(types.NewNotFound(errors.New("Task definition not found"))),
+	)
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, taskDefinitionNotFoundClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementTaskDefinitionStoreReturnsError() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, errors.New("Error when getting task definition"))
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithResourcesAndConstraints() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(gomock.Any()).Times(0)
+	suite.instanceStore.EXPECT().FilterContainerInstances(suite.clusterFilter).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster: aws.String(clusterName1),
+		Constraints: []*models.PlacementConstraint{
+			{Attribute: aws.String("ecs.instance-type"), Value: "m4.large"},
+		},
+		Resources: &models.PlacementResources{
+			CPU:    aws.Int64(512),
+			Memory: aws.Int64(512),
+			Ports:  []*models.PlacementPort{{Port: aws.Int64(22)}},
+		},
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	evaluation := suite.decodePlacementEvaluation(responseRecorder)
+	assert.Equal(suite.T(), placement.ProtocolTCP, evaluation.Requirements.Ports[0].Protocol, "Expected port protocol to default to tcp")
+	assert.Len(suite.T(), evaluation.Instances, 1, "Expected an evaluation of every instance in the cluster")
+	reasons := evaluation.Instances[0].Reasons
+	assert.Len(suite.T(), reasons, 2, "Unexpected reasons for an instance that does not fit the task")
+	assert.Equal(suite.T(), placement.ReasonPortConflict, aws.StringValue(reasons[0].Code), "Reason in response is invalid")
+	assert.Equal(suite.T(), placement.ReasonAttributeConstraint, aws.StringValue(reasons[1].Code), "Reason in response is invalid")
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementInstanceStoreReturnsError() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(suite.clusterFilter).Return(nil, errors.New("Error when filtering instances"))
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:   aws.String(clusterName1),
+		Resources: &models.PlacementResources{CPU: aws.Int64(128), Memory: aws.Int64(128)},
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithBothTaskDefinitionAndResources() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		Resources:         &models.PlacementResources{CPU: aws.Int64(128), Memory: aws.Int64(128)},
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, placementRequirementsClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithNeitherTaskDefinitionNorResources() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{Cluster: aws.String(clusterName1)})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, placementRequirementsClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithInvalidCluster() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String("cluster/invalid"),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidClusterClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithQueryCluster() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.versionedTaskDefinition, nil)
+	suite.instanceStore.EXPECT().FilterContainerInstances(suite.clusterFilter).Return([]storetypes.VersionedContainerInstance{suite.versionedInstance1}, nil)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskDefinitionARN,
+	})
+	request.URL.RawQuery = instanceClusterFilter + "=" + clusterName1
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithMismatchedQueryCluster() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(gomock.Any()).Times(0)
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	for _, query := range []string{
+		instanceClusterFilter + "=" + clusterName2,
+		instanceClusterFilter + "=" + clusterName1 + "&" + instanceClusterFilter + "=" + clusterName2,
+	} {
+		request := suite.evaluatePlacementRequest(models.PlacementRequest{
+			Cluster:           aws.String(clusterName1),
+			TaskDefinitionARN: taskDefinitionARN,
+		})
+		request.URL.RawQuery = query
+		responseRecorder := httptest.NewRecorder()
+		suite.router.ServeHTTP(responseRecorder, request)
+
+		suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+		suite.decodeErrorResponseAndValidate(responseRecorder, placementClusterMismatchClientErrMsg)
+	}
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithInvalidTaskDefinitionARN() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{
+		Cluster:           aws.String(clusterName1),
+		TaskDefinitionARN: taskName,
+	})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidTaskDefinitionARNClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithoutCluster() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request := suite.evaluatePlacementRequest(models.PlacementRequest{TaskDefinitionARN: taskDefinitionARN})
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+}
+
+func (suite *PlacementAPIsTestSuite) TestEvaluatePlacementWithMalformedRequest() {
+	suite.instanceStore.EXPECT().FilterContainerInstances(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest("POST", evaluatePlacementPrefix, bytes.NewBufferString("{"))
+	assert.Nil(suite.T(), err, "Unexpected error creating evaluate placement request")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidPlacementRequestClientErrMsg)
+}
+
+func (suite *PlacementAPIsTestSuite) getRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+
+	s.Path(evaluatePlacementPath).
+		Methods("POST").
+		HandlerFunc(suite.placementAPIs.EvaluatePlacement)
+
+	return s
+}
+
+func (suite *PlacementAPIsTestSuite) placementInstance(arn string, cpu int64, memory int64) storetypes.VersionedContainerInstance {
+	return storetypes.VersionedContainerInstance{
+		ContainerInstance: types.ContainerInstance{
+			Detail: &types.InstanceDetail{
+				AgentConnected: aws.Bool(true),
+				Attributes: []*types.Attribute{
+					{Name: aws.String("ecs.instance-type"), Value: aws.String("t2.micro")},
+				},
+				ClusterARN:           &clusterARN1,
+				ContainerInstanceARN: aws.String(arn),
+				RemainingResources: []*types.Resource{
+					{Name: aws.String(resourceCPUName), Type: aws.String(resourceIntegerType), IntegerValue: aws.Int64(cpu)},
+					{Name: aws.String(resourceMemoryName), Type: aws.String(resourceIntegerType), IntegerValue: aws.Int64(memory)},
+					{Name: aws.String("PORTS"), Type: aws.String(resourceStringSetType), StringSetValue: aws.StringSlice([]string{"22"})},
+				},
+				Status: aws.String(instanceActiveStatus),
+			},
+		},
+		Version: entityVersion,
+	}
+}
+
+func (suite *PlacementAPIsTestSuite) evaluatePlacementRequest(placementRequest models.PlacementRequest) *http.Request {
+	b, err := json.Marshal(placementRequest)
+	assert.Nil(suite.T(), err, "Unexpected error marshaling placement request")
+	request, err := http.NewRequest("POST", evaluatePlacementPrefix, bytes.NewReader(b))
+	assert.Nil(suite.T(), err, "Unexpected error creating evaluate placement request")
+	return request
+}
+
+func (suite *PlacementAPIsTestSuite) decodePlacementEvaluation(responseRecorder *httptest.ResponseRecorder) models.PlacementEvaluation {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	evaluation := models.PlacementEvaluation{}
+	err := json.NewDecoder(reader).Decode(&evaluation)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	return evaluation
+}
+
+func (suite *PlacementAPIsTestSuite) validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderJSON, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *PlacementAPIsTestSuite) validateErrorResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder, errorCode int) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), errorCode, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *PlacementAPIsTestSuite) decodeErrorResponseAndValidate(responseRecorder *httptest.ResponseRecorder, expectedErrMsg string) {
+	actualMsg := responseRecorder.Body.String()
+	assert.Equal(suite.T(), expectedErrMsg+"\n", actualMsg, "Error message is invalid")
+}
@@ -21,20 +21,60 @@ import (
 // TODO: add a map of path and query keys and use the map in task apis instead of hardcoding strings
 var (
 	// Stripping off '^' and '$' from the beginning and end of regexes respectively for the router
-	clusterNameRegex = string(regex.ClusterNameRegex[1 : len(regex.ClusterNameRegex)-1])
-	clusterARNRegex  = string(regex.ClusterARNRegex[1 : len(regex.ClusterARNRegex)-1])
+	clusterRegex     = string(regex.ClusterRegex[1 : len(regex.ClusterRegex)-1])
 	taskARNRegex     = string(regex.TaskARNRegex[1 : len(regex.TaskARNRegex)-1])
 	instanceARNRegex = string(regex.InstanceARNRegex[1 : len(regex.InstanceARNRegex)-1])
+	serviceARNRegex  = string(regex.ServiceARNRegex[1 : len(regex.ServiceARNRegex)-1])
 
-	getTaskPath     = "/tasks/{cluster:" + clusterNameRegex + "}/{arn:" + taskARNRegex + "}"
-	listTasksPath   = "/tasks"
-	streamTasksPath = "/stream/tasks"
+	taskDefinitionARNRegex = string(regex.TaskDefinitionARNRegex[1 : len(regex.TaskDefinitionARNRegex)-1])
 
-	getInstancePath     = "/instances/{cluster:" + clusterNameRegex + "}/{arn:" + instanceARNRegex + "}"
-	listInstancesPath   = "/instances"
-	streamInstancesPath = "/stream/instances"
+	getTaskPath        = "/tasks/{cluster:" + clusterRegex + "}/{arn:" + taskARNRegex + "}"
+	getTaskHistoryPath = getTaskPath + "/history"
+	listTasksPath      = "/tasks"
+	streamTasksPath    = "/stream/tasks"
+
+	getInstancePath        = "/instances/{cluster:" + clusterRegex + "}/{arn:" + instanceARNRegex + "}"
+	getInstanceHistoryPath = getInstancePath + "/history"
+	listInstancesPath      = "/instances"
+	streamInstancesPath    = "/stream/instances"
+
+	getServicePath     = "/services/{cluster:" + clusterRegex + "}/{arn:" + serviceARNRegex + "}"
+	listServicesPath   = "/services"
+	streamServicesPath = "/stream/services"
+
+	getTaskDefinitionPath   = "/taskdefinitions/{arn:" + taskDefinitionARNRegex + "}"
+	listTaskDefinitionsPath = "/taskdefinitions"
+
+	getClusterPath     = "/clusters/{cluster:" + clusterRegex + "}"
+	listClustersPath   = "/clusters"
+	streamClustersPath = "/stream/clusters"
+
+	evaluatePlacementPath = "/placement/evaluate"
+
+	listEndpointsPath   = "/endpoints"
+	streamEndpointsPath = "/stream/endpoints"
+
+	listPrometheusTargetsPath = "/sd/prometheus"
+
+	getVersionsPath = "/versions"
+
+	listChangesPath = "/changes"
 )
 
+// ClusterFilteredPaths returns the paths of the routes that only return the
+// entities of the cluster named by the 'cluster' query parameter, if it is set
+func ClusterFilteredPaths() []string {
+	return []string{
+		"/v1" + listTasksPath,
+		"/v1" + listInstancesPath,
+		"/v1" + listServicesPath,
+		"/v1" + evaluatePlacementPath,
+		"/v1" + listEndpointsPath,
+		"/v1" + streamEndpointsPath,
+		"/v1" + listPrometheusTargetsPath,
+	}
+}
+
 // NewRouter initializes a new router with registered routes redirected to appropriate handler functions
 func NewRouter(apis APIs) *mux.Router {
 	r := mux.NewRouter().StrictSlash(true)
@@ -42,7 +82,13 @@ func NewRouter(apis APIs) *mux.Router {
 
 	// Tasks
 
-	// Get task using cluster name and task ARN
+	// Get task history using cluster and task ARN. Registered before the get
+	// task route because '<id>/history' is also a valid long format ARN suffix.
+	s.Path(getTaskHistoryPath).
+		Methods("GET").
+		HandlerFunc(apis.TaskApis.GetTaskHistory)
+
+	// Get task using cluster and task ARN
 	s.Path(getTaskPath).
 		Methods("GET").
 		HandlerFunc(apis.TaskApis.GetTask)
@@ -59,7 +105,13 @@ func NewRouter(apis APIs) *mux.Router {
 
 	// Instances
 
-	// Get instance using cluster name and instance ARN
+	// Get instance history using cluster and instance ARN. Registered before the get
+	// instance route because '<id>/history' is also a valid long format ARN suffix.
+	s.Path(getInstanceHistoryPath).
+		Methods("GET").
+		HandlerFunc(apis.ContainerInstanceApis.GetInstanceHistory)
+
+	// Get instance using cluster and instance ARN
 	s.Path(getInstancePath).
 		Methods("GET").
 		HandlerFunc(apis.ContainerInstanceApis.GetInstance)
@@ -74,5 +126,89 @@ func NewRouter(apis APIs) *mux.Router {
 		Methods("GET").
 		HandlerFunc(apis.ContainerInstanceApis.StreamInstances)
 
+	// Services
+
+	// Get service using cluster and service ARN
+	s.Path(getServicePath).
+		Methods("GET").
+		HandlerFunc(apis.ServiceApis.GetService)
+
+	// List services
+	s.Path(listServicesPath).
+		Methods("GET").
+		HandlerFunc(apis.ServiceApis.ListServices)
+
+	// Stream services
+	s.Path(streamServicesPath).
+		Methods("GET").
+		HandlerFunc(apis.ServiceApis.StreamServices)
+
+	// Task definitions
+
+	// Get task definition using task definition ARN
+	s.Path(getTaskDefinitionPath).
+		Methods("GET").
+		HandlerFunc(apis.TaskDefinitionApis.GetTaskDefinition)
+
+	// List task definitions
+	s.Path(listTaskDefinitionsPath).
+		Methods("GET").
+		HandlerFunc(apis.TaskDefinitionApis.ListTaskDefinitions)
+
+	// Clusters
+
+	// Get cluster using cluster ARN, region qualified name or name
+	s.Path(getClusterPath).
+		Methods("GET").
+		HandlerFunc(apis.ClusterApis.GetCluster)
+
+	// List clusters
+	s.Path(listClustersPath).
+		Methods("GET").
+		HandlerFunc(apis.ClusterApis.ListClusters)
+
+	// Stream clusters
+	s.Path(streamClustersPath).
+		Methods("GET").
+		HandlerFunc(apis.ClusterApis.StreamClusters)
+
+	// Placement
+
+	// Evaluate which instances of a cluster can host a task
+	s.Path(evaluatePlacementPath).
+		Methods("POST").
+		HandlerFunc(apis.PlacementApis.EvaluatePlacement)
+
+	// Endpoints
+
+	// List endpoints
+	s.Path(listEndpointsPath).
+		Methods("GET").
+		HandlerFunc(apis.EndpointApis.ListEndpoints)
+
+	// Stream endpoints
+	s.Path(streamEndpointsPath).
+		Methods("GET").
+		HandlerFunc(apis.EndpointApis.StreamEndpoints)
+
+	// List endpoints to scrape in the Prometheus HTTP service discovery format
+	s.Path(listPrometheusTargetsPath).
+		Methods("GET").
+		HandlerFunc(apis.EndpointApis.ListPrometheusTargets)
+
+	// Versions
+
+	// Get the range of entity versions that streams can be resumed from
+	s.Path(getVersionsPath).
+		Methods("GET").
+		HandlerFunc(apis.VersionApis.GetVersions)
+
+	// Changes
+
+	// List the changes of tasks and instances after an entity version
+	s.Path(listChangesPath).
+		Methods("GET").
+		HandlerFunc(apis.ChangeApis.ListChanges)
+
 	return s
 }
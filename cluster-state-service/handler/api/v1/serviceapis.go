@@ -0,0 +1,263 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	serviceARNKey     = "arn"
+	serviceClusterKey = "cluster"
+
+	serviceStatusFilter  = "status"
+	serviceClusterFilter = "cluster"
+	serviceNameFilter    = "serviceName"
+
+	serviceEntityVersionKey = "entityVersion"
+)
+
+var (
+	// Using maps because arrays don't support easy lookup
+	supportedServiceFilters  = map[string]string{serviceStatusFilter: "", serviceClusterFilter: "", serviceNameFilter: ""}
+	supportedServiceStatuses = map[string]string{"active": "", "draining": "", "inactive": ""}
+)
+
+// ServiceAPIs encapsulates the backend datastore with which the service APIs interact
+type ServiceAPIs struct {
+	serviceStore store.ServiceStore
+}
+
+// NewServiceAPIs initializes the ServiceAPIs struct
+func NewServiceAPIs(serviceStore store.ServiceStore) ServiceAPIs {
+	return ServiceAPIs{
+		serviceStore: serviceStore,
+	}
+}
+
+// GetService gets a service using the cluster name to which the service belongs to and the service ARN
+func (serviceAPIs ServiceAPIs) GetService(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	serviceARN := vars[serviceARNKey]
+	cluster := vars[serviceClusterKey]
+
+	if len(serviceARN) == 0 || len(cluster) == 0 || !regex.IsServiceARN(serviceARN) || !regex.IsCluster(cluster) {
+		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if !regex.IsInCluster(serviceARN, cluster) {
+		http.Error(w, serviceNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	service, err := serviceAPIs.serviceStore.GetService(cluster, serviceARN)
+
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if service == nil {
+		http.Error(w, serviceNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	extService, err := ToService(*service)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extService)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListServices lists all services across all clusters after applying filters, if any
+func (serviceAPIs ServiceAPIs) ListServices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if serviceAPIs.hasUnsupportedFilters(query) {
+		http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if serviceAPIs.hasRedundantFilters(query) {
+		http.Error(w, redundantFilterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	status := strings.ToLower(query.Get(serviceStatusFilter))
+	cluster := query.Get(serviceClusterFilter)
+	serviceName := query.Get(serviceNameFilter)
+
+	if status != "" {
+		if !serviceAPIs.isValidStatus(status) {
+			http.Error(w, invalidStatusClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if cluster != "" {
+		if !regex.IsCluster(cluster) {
+			http.Error(w, invalidClusterClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var services []storetypes.VersionedService
+	var err error
+
+	// No filters are set. List all services.
+	if status == "" && cluster == "" && serviceName == "" {
+		services, err = serviceAPIs.serviceStore.ListServices()
+	} else { // At least one filter is set. Filter services.
+		filters := map[string]string{
+			serviceStatusFilter:  status,
+			serviceClusterFilter: cluster,
+			serviceNameFilter:    serviceName,
+		}
+		services, err = serviceAPIs.serviceStore.FilterServices(filters)
+	}
+
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	extServiceItems := make([]*models.Service, len(services))
+	for i := range services {
+		s, err := ToService(services[i])
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		extServiceItems[i] = &s
+	}
+
+	extServices := models.Services{
+		Items: extServiceItems,
+	}
+
+	err = json.NewEncoder(w).Encode(extServices)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// StreamServices streams services that change (counts, deployments, events etc.) across all clusters
+func (serviceAPIs ServiceAPIs) StreamServices(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	query := r.URL.Query()
+
+	entityVersion := query.Get(serviceEntityVersionKey)
+
+	if entityVersion != "" {
+		if !regex.IsEntityVersion(entityVersion) {
+			http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	serviceRespChan, err := serviceAPIs.serviceStore.StreamServices(ctx, entityVersion)
+	if err != nil {
+		if _, ok := errors.Cause(err).(types.OutOfRangeEntityVersion); ok {
+			http.Error(w, outOfRangeEntityVersionClientErrMsg, http.StatusBadRequest)
+			return
+		}
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeStream)
+	w.Header().Set(connectionKey, connectionVal)
+	w.Header().Set(transferEncodingKey, transferEncodingVal)
+
+	for serviceResp := range serviceRespChan {
+		if serviceResp.Err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		extService, err := ToService(serviceResp)
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(extService)
+		if err != nil {
+			http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		flusher.Flush()
+	}
+	endStream(w, r, flusher)
+}
+
+func (serviceAPIs ServiceAPIs) isValidStatus(status string) bool {
+	_, ok := supportedServiceStatuses[status]
+	return ok
+}
+
+func (serviceAPIs ServiceAPIs) hasUnsupportedFilters(filters map[string][]string) bool {
+	if len(filters) > len(supportedServiceFilters) {
+		return true
+	}
+
+	for f := range filters {
+		_, ok := supportedServiceFilters[f]
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (serviceAPIs ServiceAPIs) hasRedundantFilters(filters map[string][]string) bool {
+	for _, val := range filters {
+		// Multiple values for a given filter implies that it has been specified multiple times
+		if len(val) > 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,455 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	getServicePrefix     = "/v1/services"
+	listServicesPrefix   = "/v1/services"
+	streamServicesPrefix = "/v1/stream/services"
+)
+
+type ServiceAPIsTestSuite struct {
+	suite.Suite
+	serviceStore         *mocks.MockServiceStore
+	serviceAPIs          ServiceAPIs
+	service1             types.Service
+	versionedService1    storetypes.VersionedService
+	extService1          models.Service
+	responseHeaderJSON   http.Header
+	responseHeaderStream http.Header
+
+	// We need a router because some of the apis use mux.Vars() which uses the URL
+	// parameters parsed and stored in a global map in the global context by the router.
+	router *mux.Router
+}
+
+func (suite *ServiceAPIsTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.serviceStore = mocks.NewMockServiceStore(mockCtrl)
+
+	suite.serviceAPIs = NewServiceAPIs(suite.serviceStore)
+
+	suite.service1 = types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN: &clusterARN1,
+			CreatedAt:  createdAt,
+			Deployments: []*types.Deployment{
+				{
+					ID:             &deploymentID1,
+					DesiredCount:   &desiredCount1,
+					RolloutState:   "COMPLETED",
+					Status:         "PRIMARY",
+					TaskDefinition: taskDefinitionARN,
+				},
+			},
+			DesiredCount: &desiredCount1,
+			Events: []*types.ServiceEvent{
+				{
+					CreatedAt: &updatedAt1,
+					EventName: "SERVICE_STEADY_STATE",
+					EventType: "INFO",
+					ID:        &id1,
+				},
+			},
+			ServiceARN:     &serviceARN1,
+			ServiceName:    &serviceName1,
+			Status:         serviceStatus1,
+			TaskDefinition: taskDefinitionARN,
+			UpdatedAt:      &updatedAt1,
+		},
+	}
+	suite.versionedService1 = storetypes.VersionedService{
+		Service: suite.service1,
+		Version: entityVersion,
+	}
+
+	serviceModel, err := ToService(suite.versionedService1)
+	if err != nil {
+		suite.T().Error("Cannot setup testSuite: Error when tranlating service to external model")
+	}
+	suite.extService1 = serviceModel
+
+	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
+	suite.responseHeaderStream = http.Header{
+		responseContentTypeKey:      []string{responseContentTypeStream},
+		responseConnectionKey:       []string{responseConnectionVal},
+		responseTransferEncodingKey: []string{responseTransferEncodingVal},
+	}
+
+	suite.router = suite.getRouter()
+}
+
+func TestServiceAPIsTestSuite(t *testing.T) {
+	suite.Run(t, new(ServiceAPIsTestSuite))
+}
+
+func (suite *ServiceAPIsTestSuite) TestGetServiceReturnsService() {
+	suite.serviceStore.EXPECT().GetService(clusterName1, serviceARN1).Return(&suite.versionedService1, nil)
+
+	request := suite.getServiceRequest(serviceARN1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	serviceInResponse := models.Service{}
+	err := json.NewDecoder(reader).Decode(&serviceInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), suite.extService1, serviceInResponse, "Service in response is invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) TestGetServiceWithLongServiceARN() {
+	suite.serviceStore.EXPECT().GetService(clusterName1, longServiceARN).Return(&suite.versionedService1, nil)
+
+	request := suite.getServiceRequest(longServiceARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *ServiceAPIsTestSuite) TestGetServiceWithLongServiceARNInAnotherCluster() {
+	suite.serviceStore.EXPECT().GetService(gomock.Any(), gomock.Any()).Times(0)
+
+	url := getServicePrefix + "/otherCluster/" + longServiceARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get service request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, serviceNotFoundClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestGetServiceReturnsNoService() {
+	suite.serviceStore.EXPECT().GetService(clusterName1, serviceARN1).Return(nil, nil)
+
+	request := suite.getServiceRequest(serviceARN1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, serviceNotFoundClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestGetServiceStoreReturnsError() {
+	suite.serviceStore.EXPECT().GetService(clusterName1, serviceARN1).Return(nil, errors.New("Error when getting service"))
+
+	request := suite.getServiceRequest(serviceARN1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesReturnsServices() {
+	serviceList := []storetypes.VersionedService{suite.versionedService1}
+	suite.serviceStore.EXPECT().ListServices().Return(serviceList, nil)
+	suite.serviceStore.EXPECT().FilterServices(gomock.Any()).Times(0)
+
+	request := suite.listServicesRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	extServices := models.Services{
+		Items: []*models.Service{&suite.extService1},
+	}
+	suite.validateServicesInListServicesResponse(responseRecorder, extServices)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesReturnsNoServices() {
+	suite.serviceStore.EXPECT().ListServices().Return(make([]storetypes.VersionedService, 0), nil)
+
+	request := suite.listServicesRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	emptyExtServices := models.Services{
+		Items: []*models.Service{},
+	}
+	suite.validateServicesInListServicesResponse(responseRecorder, emptyExtServices)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesStoreReturnsError() {
+	suite.serviceStore.EXPECT().ListServices().Return(nil, errors.New("Error when listing services"))
+
+	request := suite.listServicesRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithAllFilters() {
+	filters := map[string]string{
+		serviceStatusFilter:  "active",
+		serviceClusterFilter: clusterName1,
+		serviceNameFilter:    serviceName1,
+	}
+	serviceList := []storetypes.VersionedService{suite.versionedService1}
+	suite.serviceStore.EXPECT().ListServices().Times(0)
+	suite.serviceStore.EXPECT().FilterServices(filters).Return(serviceList, nil)
+
+	request := suite.listServicesRequest("?status=ACTIVE&cluster=" + clusterName1 + "&serviceName=" + serviceName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	extServices := models.Services{
+		Items: []*models.Service{&suite.extService1},
+	}
+	suite.validateServicesInListServicesResponse(responseRecorder, extServices)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithServiceNameFilter() {
+	filters := map[string]string{
+		serviceStatusFilter:  "",
+		serviceClusterFilter: "",
+		serviceNameFilter:    serviceName1,
+	}
+	suite.serviceStore.EXPECT().FilterServices(filters).Return(make([]storetypes.VersionedService, 0), nil)
+
+	request := suite.listServicesRequest("?serviceName=" + serviceName1)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithInvalidStatus() {
+	suite.serviceStore.EXPECT().FilterServices(gomock.Any()).Times(0)
+
+	request := suite.listServicesRequest("?status=invalidStatus")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidStatusClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithInvalidCluster() {
+	suite.serviceStore.EXPECT().FilterServices(gomock.Any()).Times(0)
+
+	request := suite.listServicesRequest("?cluster=cluster/cluster")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidClusterClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithUnsupportedFilter() {
+	suite.serviceStore.EXPECT().ListServices().Times(0)
+	suite.serviceStore.EXPECT().FilterServices(gomock.Any()).Times(0)
+
+	request := suite.listServicesRequest("?unsupportedFilter=value")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, unsupportedFilterClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestListServicesWithRedundantFilter() {
+	suite.serviceStore.EXPECT().FilterServices(gomock.Any()).Times(0)
+
+	request := suite.listServicesRequest("?status=active&status=draining")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, redundantFilterClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestStreamServicesReturnsServices() {
+	serviceRespChan := make(chan storetypes.VersionedService)
+	suite.serviceStore.EXPECT().StreamServices(gomock.Any(), "").Return(serviceRespChan, nil)
+	expectedServices := []models.Service{suite.extService1}
+
+	go func() {
+		defer close(serviceRespChan)
+		serviceRespChan <- suite.versionedService1
+	}()
+
+	request := suite.streamServicesRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateServicesInStreamServicesResponse(responseRecorder, expectedServices)
+}
+
+func (suite *ServiceAPIsTestSuite) TestStreamServicesWithValidEntityVersion() {
+	serviceRespChan := make(chan storetypes.VersionedService)
+	suite.serviceStore.EXPECT().StreamServices(gomock.Any(), entityVersion).Return(serviceRespChan, nil)
+
+	go func() {
+		defer close(serviceRespChan)
+	}()
+
+	request := suite.streamServicesRequest("?entityVersion=" + entityVersion)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	suite.validateServicesInStreamServicesResponse(responseRecorder, []models.Service{})
+}
+
+func (suite *ServiceAPIsTestSuite) TestStreamServicesWithInvalidEntityVersion() {
+	suite.serviceStore.EXPECT().StreamServices(gomock.Any(), gomock.Any()).Times(0)
+
+	request := suite.streamServicesRequest("?entityVersion=invalidEntityVersion")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidEntityVersionClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestStreamServicesWithCompactedEntityVersion() {
+	suite.serviceStore.EXPECT().StreamServices(gomock.Any(), entityVersion).Return(nil, types.NewOutOfRangeEntityVersion(errors.New("Out of range entity version")))
+
+	request := suite.streamServicesRequest("?entityVersion=" + entityVersion)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, outOfRangeEntityVersionClientErrMsg)
+}
+
+func (suite *ServiceAPIsTestSuite) TestStreamServicesServiceResponseChannelReturnsError() {
+	serviceRespChan := make(chan storetypes.VersionedService)
+	suite.serviceStore.EXPECT().StreamServices(gomock.Any(), gomock.Any()).Return(serviceRespChan, nil)
+
+	go func() {
+		defer close(serviceRespChan)
+		serviceRespChan <- storetypes.VersionedService{Err: errors.New("VersionedService failure")}
+	}()
+
+	request := suite.streamServicesRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+// Helper functions
+
+func (suite *ServiceAPIsTestSuite) getRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+
+	s.Path(getServicePath).
+		Methods("GET").
+		HandlerFunc(suite.serviceAPIs.GetService)
+
+	s.Path(listServicesPath).Methods("GET").
+		HandlerFunc(suite.serviceAPIs.ListServices)
+
+	s.Path(streamServicesPath).Methods("GET").
+		HandlerFunc(suite.serviceAPIs.StreamServices)
+
+	return s
+}
+
+func (suite *ServiceAPIsTestSuite) getServiceRequest(serviceARN string) *http.Request {
+	url := getServicePrefix + "/" + clusterName1 + "/" + serviceARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get service request")
+	return request
+}
+
+func (suite *ServiceAPIsTestSuite) listServicesRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", listServicesPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list services request")
+	return request
+}
+
+func (suite *ServiceAPIsTestSuite) streamServicesRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", streamServicesPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating stream services request")
+	return request
+}
+
+func (suite *ServiceAPIsTestSuite) validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderJSON, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderStream, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) validateErrorResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder, errorCode int) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), errorCode, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) decodeErrorResponseAndValidate(responseRecorder *httptest.ResponseRecorder, expectedErrMsg string) {
+	actualMsg := responseRecorder.Body.String()
+	assert.Equal(suite.T(), expectedErrMsg+"\n", actualMsg, "Error message is invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) validateServicesInListServicesResponse(responseRecorder *httptest.ResponseRecorder, expectedServices models.Services) {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	servicesInResponse := new(models.Services)
+	err := json.NewDecoder(reader).Decode(servicesInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), expectedServices, *servicesInResponse, "Services in response are invalid")
+}
+
+func (suite *ServiceAPIsTestSuite) validateServicesInStreamServicesResponse(responseRecorder *httptest.ResponseRecorder, expectedServices []models.Service) {
+	scanner := bufio.NewScanner(responseRecorder.Body)
+	servicesInResponse := make([]models.Service, 0)
+	for scanner.Scan() {
+		service := new(models.Service)
+		err := json.Unmarshal([]byte(scanner.Text()), service)
+		assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+		servicesInResponse = append(servicesInResponse, *service)
+	}
+	assert.Exactly(suite.T(), expectedServices, servicesInResponse, "Services in response is invalid")
+}
@@ -0,0 +1,48 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// shutdownKey is the context key of the context that is cancelled when the
+// server shuts down
+type shutdownKey struct{}
+
+// NewShutdownContext returns the context to serve requests with and the
+// function that cancels it when the server shuts down. Streams served with
+// this context end with a final message saying that the server is shutting
+// down, so that clients can tell that from an error and reconnect.
+func NewShutdownContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	return context.WithValue(ctx, shutdownKey{}, ctx), cancel
+}
+
+func isShuttingDown(r *http.Request) bool {
+	shutdownCtx, ok := r.Context().Value(shutdownKey{}).(context.Context)
+	return ok && shutdownCtx.Err() != nil
+}
+
+// endStream ends a stream whose store channels closed, with a final message if
+// they closed because the server is shutting down
+func endStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher) {
+	if !isShuttingDown(r) {
+		return
+	}
+	fmt.Fprintln(w, shuttingDownServerErrMsg)
+	flusher.Flush()
+}
@@ -17,8 +17,10 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
 	"github.com/goguardian/blox/cluster-state-service/handler/regex"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
@@ -32,29 +34,46 @@ const (
 	taskARNKey     = "arn"
 	taskClusterKey = "cluster"
 
-	taskStatusFilter    = "status"
-	taskClusterFilter   = "cluster"
-	taskStartedByFilter = "startedBy"
+	taskStatusFilter      = "status"
+	taskClusterFilter     = "cluster"
+	taskStartedByFilter   = "startedBy"
+	taskLaunchTypeFilter  = "launchType"
+	taskGroupFilter       = "group"
+	taskServiceNameFilter = "serviceName" // shorthand for the group of the tasks started by a service
 
 	taskEntityVersionKey = "entityVersion"
+	taskAtVersionKey     = "atVersion"
+
+	taskIncludeKey        = "include"
+	taskDefinitionInclude = "taskDefinition"
 )
 
 var (
 	// Using maps because arrays don't support easy lookup
 	supportedTaskFilters = map[string]string{taskStatusFilter: "",
-		taskClusterFilter: "", taskStartedByFilter: ""}
-	supportedTaskStatuses = map[string]string{"pending": "", "running": "", "stopped": ""}
+		taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: "",
+		taskServiceNameFilter: ""}
+	supportedTaskStatuses    = map[string]string{"pending": "", "running": "", "stopped": ""}
+	supportedTaskLaunchTypes = map[string]string{"ec2": "", "fargate": ""}
 )
 
-// TaskAPIs encapsulates the backend datastore with which the task APIs interact
+// TaskAPIs encapsulates the backend datastores with which the task APIs interact
+// and the loader used to cache the task definitions embedded in task responses
 type TaskAPIs struct {
-	taskStore store.TaskStore
+	taskStore            store.TaskStore
+	taskDefinitionStore  store.TaskDefinitionStore
+	taskDefinitionLoader loader.TaskDefinitionLoader
+	// view, when set, serves the task store's reads
+	view *store.View
 }
 
 // NewTaskAPIs initializes the TaskAPIs struct
-func NewTaskAPIs(taskStore store.TaskStore) TaskAPIs {
+func NewTaskAPIs(taskStore store.TaskStore, taskDefinitionStore store.TaskDefinitionStore, taskDefinitionLoader loader.TaskDefinitionLoader, view *store.View) TaskAPIs {
 	return TaskAPIs{
-		taskStore: taskStore,
+		taskStore:            taskStore,
+		taskDefinitionStore:  taskDefinitionStore,
+		taskDefinitionLoader: taskDefinitionLoader,
+		view:                 view,
 	}
 }
 
@@ -64,11 +83,23 @@ func (taskAPIs TaskAPIs) GetTask(w http.ResponseWriter, r *http.Request) {
 	taskARN := vars[taskARNKey]
 	cluster := vars[taskClusterKey]
 
-	if len(taskARN) == 0 || len(cluster) == 0 || !regex.IsTaskARN(taskARN) || !regex.IsClusterName(cluster) {
+	if len(taskARN) == 0 || len(cluster) == 0 || !regex.IsTaskARN(taskARN) || !regex.IsCluster(cluster) {
 		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 
+	if !regex.IsInCluster(taskARN, cluster) {
+		http.Error(w, taskNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	includeTaskDefinition, ok := taskAPIs.includesTaskDefinition(r.URL.Query())
+	if !ok {
+		http.Error(w, invalidIncludeClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	revision, fromView := taskAPIs.view.Revision()
 	task, err := taskAPIs.taskStore.GetTask(cluster, taskARN)
 
 	if err != nil {
@@ -81,26 +112,80 @@ func (taskAPIs TaskAPIs) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	extTask, err := taskAPIs.toTask(*task, includeTaskDefinition, make(map[string]*models.TaskDefinitionSummary))
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set(contentTypeKey, contentTypeJSON)
+	setRevisionHeader(w, revision, fromView)
 	w.WriteHeader(http.StatusOK)
 
-	extTask, err := ToTask(*task)
+	err = json.NewEncoder(w).Encode(extTask)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetTaskHistory gets the state history of a task using the cluster name to which the task belongs to and the task ARN
+func (taskAPIs TaskAPIs) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskARN := vars[taskARNKey]
+	cluster := vars[taskClusterKey]
+
+	if len(taskARN) == 0 || len(cluster) == 0 || !regex.IsTaskARN(taskARN) || !regex.IsCluster(cluster) {
+		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if !regex.IsInCluster(taskARN, cluster) {
+		http.Error(w, taskHistoryNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	history, err := taskAPIs.taskStore.GetTaskHistory(cluster, taskARN)
+
 	if err != nil {
 		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(extTask)
+	if len(history) == 0 {
+		http.Error(w, taskHistoryNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	extHistory := ToTaskHistory(history)
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extHistory)
 	if err != nil {
 		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 }
 
-// ListTasks lists all tasks across all clusters after applying filters, if any
+// ListTasks lists all tasks across all clusters after applying filters, if any.
+// With atVersion, the tasks are listed as they were at that entity version.
 func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
+	includeTaskDefinition, ok := taskAPIs.includesTaskDefinition(query)
+	if !ok {
+		http.Error(w, invalidIncludeClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := atVersion(query, taskAtVersionKey)
+	if !ok {
+		http.Error(w, invalidEntityVersionClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
 	if taskAPIs.hasUnsupportedFilters(query) {
 		http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
 		return
@@ -114,6 +199,17 @@ func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 	status := strings.ToLower(query.Get(taskStatusFilter))
 	cluster := query.Get(taskClusterFilter)
 	startedBy := query.Get(taskStartedByFilter)
+	launchType := strings.ToLower(query.Get(taskLaunchTypeFilter))
+	group := query.Get(taskGroupFilter)
+	serviceName := query.Get(taskServiceNameFilter)
+
+	if serviceName != "" {
+		if group != "" {
+			http.Error(w, unsupportedFilterCombinationClientErrMsg, http.StatusBadRequest)
+			return
+		}
+		group = types.ServiceGroupPrefix + serviceName
+	}
 
 	if status != "" {
 		if !taskAPIs.isValidStatus(status) {
@@ -122,8 +218,15 @@ func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if launchType != "" {
+		if !taskAPIs.isValidLaunchType(launchType) {
+			http.Error(w, invalidLaunchTypeClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
 	if cluster != "" {
-		if !regex.IsClusterARN(cluster) && !regex.IsClusterName(cluster) {
+		if !regex.IsCluster(cluster) {
 			http.Error(w, invalidClusterClientErrMsg, http.StatusBadRequest)
 			return
 		}
@@ -131,35 +234,50 @@ func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 
 	var tasks []storetypes.VersionedTask
 	var err error
+	revision, knownRevision := taskAPIs.view.Revision()
 
 	// No filters are set. List all tasks.
-	if status == "" && cluster == "" && startedBy == "" {
-		tasks, err = taskAPIs.taskStore.ListTasks()
+	if status == "" && cluster == "" && startedBy == "" && launchType == "" && group == "" {
+		if version != "" {
+			tasks, revision, err = taskAPIs.taskStore.ListTasksAtVersion(version)
+			knownRevision = true
+		} else {
+			tasks, err = taskAPIs.taskStore.ListTasks()
+		}
 	} else { // At least one filter is set. Filter tasks.
 		filters := map[string]string{
-			taskStatusFilter:    status,
-			taskClusterFilter:   cluster,
-			taskStartedByFilter: startedBy,
+			taskStatusFilter:     status,
+			taskClusterFilter:    cluster,
+			taskStartedByFilter:  startedBy,
+			taskLaunchTypeFilter: launchType,
+			taskGroupFilter:      group,
+		}
+		if version != "" {
+			tasks, revision, err = taskAPIs.taskStore.FilterTasksAtVersion(filters, version)
+			knownRevision = true
+		} else {
+			tasks, err = taskAPIs.taskStore.FilterTasks(filters)
 		}
-		tasks, err = taskAPIs.taskStore.FilterTasks(filters)
 	}
 
 	if err != nil {
-		_, ok := errors.Cause(err).(types.UnsupportedFilterCombination)
-		if ok {
+		if _, ok := errors.Cause(err).(types.UnsupportedFilterCombination); ok {
 			http.Error(w, unsupportedFilterCombinationClientErrMsg, http.StatusBadRequest)
 			return
 		}
+		if _, ok := errors.Cause(err).(types.OutOfRangeEntityVersion); ok {
+			http.Error(w, outOfRangeEntityVersionClientErrMsg, http.StatusBadRequest)
+			return
+		}
 		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(contentTypeKey, contentTypeJSON)
-	w.WriteHeader(http.StatusOK)
-
+	// Tasks commonly share task definitions, so each of them is looked up once per request
+	taskDefinitionSummaries := make(map[string]*models.TaskDefinitionSummary)
 	extTaskItems := make([]*models.Task, len(tasks))
 	for i := range tasks {
-		t, err := ToTask(tasks[i])
+		t, err := taskAPIs.toTask(tasks[i], includeTaskDefinition, taskDefinitionSummaries)
 		if err != nil {
 			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
 			return
@@ -171,6 +289,10 @@ func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 		Items: extTaskItems,
 	}
 
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	setRevisionHeader(w, revision, knownRevision)
+	w.WriteHeader(http.StatusOK)
+
 	err = json.NewEncoder(w).Encode(extTasks)
 	if err != nil {
 		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
@@ -180,7 +302,7 @@ func (taskAPIs TaskAPIs) ListTasks(w http.ResponseWriter, r *http.Request) {
 
 // StreamTasks streams tasks that change (status etc.) across all clusters
 func (taskAPIs TaskAPIs) StreamTasks(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithCancel(context.TODO())
+	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
 	query := r.URL.Query()
@@ -231,8 +353,50 @@ func (taskAPIs TaskAPIs) StreamTasks(w http.ResponseWriter, r *http.Request) {
 		}
 		flusher.Flush()
 	}
+	endStream(w, r, flusher)
+}
 
-	// TODO: Handle client-side termination (Ctrl+C) using w.(http.CloseNotifier).closeNotify()
+// toTask translates the task and, if requested, embeds the summary of its task
+// definition. Summaries are memoized in 'taskDefinitionSummaries' by task
+// definition ARN. Task definitions that no longer exist in ECS are not embedded.
+func (taskAPIs TaskAPIs) toTask(versionedTask storetypes.VersionedTask, includeTaskDefinition bool, taskDefinitionSummaries map[string]*models.TaskDefinitionSummary) (models.Task, error) {
+	extTask, err := ToTask(versionedTask)
+	if err != nil || !includeTaskDefinition {
+		return extTask, err
+	}
+
+	taskDefinitionARN := *extTask.Entity.TaskDefinitionARN
+	summary, ok := taskDefinitionSummaries[taskDefinitionARN]
+	if !ok {
+		taskDefinition, err := loader.GetOrLoadTaskDefinition(taskAPIs.taskDefinitionStore, taskAPIs.taskDefinitionLoader, taskDefinitionARN)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if taskDefinition != nil {
+			summary, err = ToTaskDefinitionSummary(taskDefinition.TaskDefinition)
+			if err != nil {
+				return models.Task{}, err
+			}
+		}
+		taskDefinitionSummaries[taskDefinitionARN] = summary
+	}
+	extTask.Entity.TaskDefinition = summary
+	return extTask, nil
+}
+
+// includesTaskDefinition returns whether task definition summaries should be
+// embedded in task responses and whether the include parameter is valid. The
+// include parameter is removed from 'query' so that it is not treated as a filter.
+func (taskAPIs TaskAPIs) includesTaskDefinition(query url.Values) (bool, bool) {
+	include, ok := query[taskIncludeKey]
+	if !ok {
+		return false, true
+	}
+	delete(query, taskIncludeKey)
+	if len(include) != 1 || include[0] != taskDefinitionInclude {
+		return false, false
+	}
+	return true, true
 }
 
 func (taskAPIs TaskAPIs) isValidStatus(status string) bool {
@@ -240,6 +404,11 @@ func (taskAPIs TaskAPIs) isValidStatus(status string) bool {
 	return ok
 }
 
+func (taskAPIs TaskAPIs) isValidLaunchType(launchType string) bool {
+	_, ok := supportedTaskLaunchTypes[launchType]
+	return ok
+}
+
 func (taskAPIs TaskAPIs) hasUnsupportedFilters(filters map[string][]string) bool {
 	if len(filters) > len(supportedTaskFilters) {
 		return true
@@ -15,6 +15,7 @@ package v1
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -35,12 +36,14 @@ import (
 )
 
 const (
-	getTaskPrefix                = "/v1/tasks"
-	listTasksPrefix              = "/v1/tasks"
-	filterTasksByStatusPrefix    = "/v1/tasks?status="
-	filterTasksByClusterPrefix   = "/v1/tasks?cluster="
-	filterTasksByStartedByPrefix = "/v1/tasks?startedBy="
-	streamTasksPrefix            = "/v1/stream/tasks"
+	getTaskPrefix                 = "/v1/tasks"
+	listTasksPrefix               = "/v1/tasks"
+	filterTasksByStatusPrefix     = "/v1/tasks?status="
+	filterTasksByClusterPrefix    = "/v1/tasks?cluster="
+	filterTasksByStartedByPrefix  = "/v1/tasks?startedBy="
+	filterTasksByLaunchTypePrefix = "/v1/tasks?launchType="
+	filterTasksByGroupPrefix      = "/v1/tasks?group="
+	streamTasksPrefix             = "/v1/stream/tasks"
 
 	filterTasksByStatusQueryValue = "{status:pending|running|stopped}"
 
@@ -52,6 +55,8 @@ const (
 type TaskAPIsTestSuite struct {
 	suite.Suite
 	taskStore            *mocks.MockTaskStore
+	taskDefinitionStore  *mocks.MockTaskDefinitionStore
+	taskDefinitionLoader *mocks.MockTaskDefinitionLoader
 	taskAPIs             TaskAPIs
 	task1                types.Task
 	task2                types.Task
@@ -59,6 +64,7 @@ type TaskAPIsTestSuite struct {
 	versionedTask2       storetypes.VersionedTask
 	extTask1             models.Task
 	extTask2             models.Task
+	taskDefinition       storetypes.VersionedTaskDefinition
 	responseHeaderJSON   http.Header
 	responseHeaderStream http.Header
 
@@ -72,7 +78,10 @@ func (suite *TaskAPIsTestSuite) SetupTest() {
 
 	suite.taskStore = mocks.NewMockTaskStore(mockCtrl)
 
-	suite.taskAPIs = NewTaskAPIs(suite.taskStore)
+	suite.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(mockCtrl)
+	suite.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(mockCtrl)
+
+	suite.taskAPIs = NewTaskAPIs(suite.taskStore, suite.taskDefinitionStore, suite.taskDefinitionLoader, nil)
 
 	overrides := types.Overrides{
 		ContainerOverrides: []*types.ContainerOverrides{},
@@ -96,7 +105,7 @@ func (suite *TaskAPIsTestSuite) SetupTest() {
 		ID:        &id1,
 		Region:    &region,
 		Resources: []string{taskARN1},
-		Time:      &time,
+		Time:      &eventTime,
 	}
 	suite.versionedTask1 = storetypes.VersionedTask{
 		Task: suite.task1,
@@ -119,7 +128,7 @@ func (suite *TaskAPIsTestSuite) SetupTest() {
 		ID:        &id1,
 		Region:    &region,
 		Resources: []string{taskARN2},
-		Time:      &time,
+		Time:      &eventTime,
 	}
 	suite.versionedTask2 = storetypes.VersionedTask{
 		Task: suite.task2,
@@ -132,6 +141,26 @@ func (suite *TaskAPIsTestSuite) SetupTest() {
 	}
 	suite.extTask2 = extTask
 
+	suite.taskDefinition = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{
+			Detail: &types.TaskDefinitionDetail{
+				ContainerDefinitions: []*types.ContainerDefinition{
+					{
+						Essential:    true,
+						Image:        &containerImage1,
+						Name:         &containerName1,
+						PortMappings: []*types.PortMapping{{ContainerPort: &containerPort1}},
+					},
+				},
+				Family:            &taskName,
+				Revision:          &taskRevision1,
+				TaskDefinitionARN: &taskDefinitionARN,
+				UpdatedAt:         &updatedAt1,
+			},
+		},
+		Version: entityVersion,
+	}
+
 	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
 	suite.responseHeaderStream = http.Header{
 		responseContentTypeKey:      []string{responseContentTypeStream},
@@ -167,6 +196,71 @@ func (suite *TaskAPIsTestSuite) TestGetReturnsTask() {
 	assert.Exactly(suite.T(), suite.extTask1, taskInResponse, "Task in response is invalid")
 }
 
+func (suite *TaskAPIsTestSuite) TestGetTaskWithClusterARN() {
+	suite.taskStore.EXPECT().GetTask(clusterARN1, taskARN1).Return(&suite.versionedTask1, nil)
+
+	url := getTaskPrefix + "/" + clusterARN1 + "/" + taskARN1
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskWithRegionQualifiedClusterName() {
+	regionQualifiedCluster := region + ":" + clusterName1
+	suite.taskStore.EXPECT().GetTask(regionQualifiedCluster, taskARN1).Return(&suite.versionedTask1, nil)
+
+	url := getTaskPrefix + "/" + regionQualifiedCluster + "/" + taskARN1
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskWithLongTaskARN() {
+	suite.taskStore.EXPECT().GetTask(clusterName1, longTaskARN).Return(&suite.versionedTask1, nil)
+
+	url := getTaskPrefix + "/" + clusterName1 + "/" + longTaskARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskWithLongTaskARNInOtherCluster() {
+	url := getTaskPrefix + "/otherCluster/" + longTaskARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, taskNotFoundClientErrMsg)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskInGovCloudPartition() {
+	suite.taskStore.EXPECT().GetTask(govCloudClusterARN, govCloudTaskARN).Return(&suite.versionedTask1, nil)
+
+	url := getTaskPrefix + "/" + govCloudClusterARN + "/" + govCloudTaskARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
 func (suite *TaskAPIsTestSuite) TestGetTaskNoTask() {
 	suite.taskStore.EXPECT().GetTask(clusterName1, taskARN1).Return(nil, nil)
 
@@ -189,6 +283,93 @@ func (suite *TaskAPIsTestSuite) TestGetTaskStoreReturnsError() {
 	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
 }
 
+func (suite *TaskAPIsTestSuite) TestGetTaskIncludeTaskDefinitionEmbedsSummary() {
+	suite.taskStore.EXPECT().GetTask(clusterName1, taskARN1).Return(&suite.versionedTask1, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.taskDefinition, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(gomock.Any()).Times(0)
+
+	request := suite.getTaskIncludeTaskDefinitionRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	taskInResponse := models.Task{}
+	err := json.NewDecoder(responseRecorder.Body).Decode(&taskInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	summary := taskInResponse.Entity.TaskDefinition
+	assert.NotNil(suite.T(), summary, "Expected task definition summary in response")
+	assert.Equal(suite.T(), taskName, *summary.Family, "Unexpected task definition family")
+	assert.Equal(suite.T(), taskRevision1, *summary.Revision, "Unexpected task definition revision")
+	assert.Len(suite.T(), summary.ContainerDefinitions, 1, "Unexpected number of container definitions")
+	assert.Equal(suite.T(), containerImage1, *summary.ContainerDefinitions[0].Image, "Unexpected container image")
+	assert.Equal(suite.T(), containerPort1, *summary.ContainerDefinitions[0].PortMappings[0].ContainerPort, "Unexpected container port")
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskIncludeTaskDefinitionLoadsUncachedTaskDefinition() {
+	suite.taskStore.EXPECT().GetTask(clusterName1, taskARN1).Return(&suite.versionedTask1, nil)
+	gomock.InOrder(
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(nil),
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.taskDefinition, nil),
+	)
+
+	request := suite.getTaskIncludeTaskDefinitionRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	taskInResponse := models.Task{}
+	err := json.NewDecoder(responseRecorder.Body).Decode(&taskInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.NotNil(suite.T(), taskInResponse.Entity.TaskDefinition, "Expected task definition summary in response")
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskIncludeTaskDefinitionNotFoundInECS() {
+	suite.taskStore.EXPECT().GetTask(clusterName1, taskARN1).Return(&suite.versionedTask1, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(types.NewNotFound(errors.New("Unable to describe task definition")))
+
+	request := suite.getTaskIncludeTaskDefinitionRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	taskInResponse := models.Task{}
+	err := json.NewDecoder(responseRecorder.Body).Decode(&taskInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), suite.extTask1, taskInResponse, "Expected task without task definition summary")
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskIncludeTaskDefinitionLoaderReturnsError() {
+	suite.taskStore.EXPECT().GetTask(clusterName1, taskARN1).Return(&suite.versionedTask1, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(errors.New("Error when loading task definition"))
+
+	request := suite.getTaskIncludeTaskDefinitionRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskWithInvalidInclude() {
+	suite.taskStore.EXPECT().GetTask(gomock.Any(), gomock.Any()).Times(0)
+
+	url := getTaskPrefix + "/" + clusterName1 + "/" + taskARN1 + "?include=containers"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidIncludeClientErrMsg)
+}
+
 func (suite *TaskAPIsTestSuite) TestGetTaskWithoutTaskARN() {
 	suite.taskStore.EXPECT().GetTask(gomock.Any(), gomock.Any()).Times(0)
 
@@ -203,6 +384,48 @@ func (suite *TaskAPIsTestSuite) TestGetTaskWithoutTaskARN() {
 	suite.decodeErrorResponseAndValidate(responseRecorder, routingServerErrMsg)
 }
 
+func (suite *TaskAPIsTestSuite) TestGetTaskHistoryReturnsHistory() {
+	history := []storetypes.TaskHistoryEntry{
+		{Version: version1, RecordedAt: updatedAt1, State: suite.task1.Detail.State()},
+		{Version: version1 + 1, RecordedAt: updatedAt1, State: suite.task2.Detail.State()},
+	}
+	suite.taskStore.EXPECT().GetTaskHistory(clusterName1, taskARN1).Return(history, nil)
+
+	request := suite.getTaskHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	historyInResponse := models.TaskHistory{}
+	err := json.NewDecoder(reader).Decode(&historyInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), ToTaskHistory(history), historyInResponse, "Task history in response is invalid")
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskHistoryNoHistory() {
+	suite.taskStore.EXPECT().GetTaskHistory(clusterName1, taskARN1).Return([]storetypes.TaskHistoryEntry{}, nil)
+
+	request := suite.getTaskHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, taskHistoryNotFoundClientErrMsg)
+}
+
+func (suite *TaskAPIsTestSuite) TestGetTaskHistoryStoreReturnsError() {
+	suite.taskStore.EXPECT().GetTaskHistory(clusterName1, taskARN1).Return(nil, errors.New("Error when getting task history"))
+
+	request := suite.getTaskHistoryRequest()
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
 func (suite *TaskAPIsTestSuite) TestListTasksReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1, suite.versionedTask2}
 	suite.taskStore.EXPECT().ListTasks().Return(taskList, nil)
@@ -253,7 +476,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksInvalidFilter() {
 func (suite *TaskAPIsTestSuite) TestListTasksBothStatusAndClusterFilter() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: clusterARN1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: clusterARN1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -271,10 +494,80 @@ func (suite *TaskAPIsTestSuite) TestListTasksBothStatusAndClusterFilter() {
 	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
 }
 
+func (suite *TaskAPIsTestSuite) TestListTasksAtVersion() {
+	taskList := []storetypes.VersionedTask{suite.versionedTask1, suite.versionedTask2}
+	suite.taskStore.EXPECT().ListTasksAtVersion(entityVersion).Return(taskList, int64(123), nil)
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	request, err := http.NewRequest("GET", listTasksPrefix+"?atVersion="+entityVersion, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request at a version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(suite.T(), "123", responseRecorder.Header().Get(etcdRevisionKey), "Expected the revision the tasks were read at")
+	extTasks := models.Tasks{
+		Items: []*models.Task{&suite.extTask1, &suite.extTask2},
+	}
+	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksAtVersionWithFilters() {
+	taskList := []storetypes.VersionedTask{suite.versionedTask1}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: clusterARN1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
+	suite.taskStore.EXPECT().FilterTasksAtVersion(filters, "0").Return(taskList, int64(130), nil)
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest("GET", listTasksPrefix+"?atVersion=0&status="+taskStatus1+"&cluster="+clusterARN1, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter tasks request at a version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(suite.T(), "130", responseRecorder.Header().Get(etcdRevisionKey), "Expected the revision the latest tasks were read at")
+	extTasks := models.Tasks{
+		Items: []*models.Task{&suite.extTask1},
+	}
+	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithInvalidAtVersion() {
+	suite.taskStore.EXPECT().ListTasksAtVersion(gomock.Any()).Times(0)
+
+	for _, url := range []string{
+		listTasksPrefix + "?atVersion=invalidEntityVersion",
+		listTasksPrefix + "?atVersion=" + entityVersion + "&atVersion=" + entityVersion,
+	} {
+		request, err := http.NewRequest("GET", url, nil)
+		assert.Nil(suite.T(), err, "Unexpected error creating list tasks request with an invalid version")
+
+		responseRecorder := httptest.NewRecorder()
+		suite.router.ServeHTTP(responseRecorder, request)
+
+		suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+		suite.decodeErrorResponseAndValidate(responseRecorder, invalidEntityVersionClientErrMsg)
+	}
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithCompactedAtVersion() {
+	suite.taskStore.EXPECT().ListTasksAtVersion(entityVersion).Return(nil, int64(0), types.NewOutOfRangeEntityVersion(errors.New("Out of range entity version")))
+
+	request, err := http.NewRequest("GET", listTasksPrefix+"?atVersion="+entityVersion, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request with a compacted version")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, outOfRangeEntityVersionClientErrMsg)
+}
+
 func (suite *TaskAPIsTestSuite) TestListTasksWithStatusFilterReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -287,7 +580,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithStatusFilterReturnsTasks() {
 func (suite *TaskAPIsTestSuite) TestListTasksWithCapitalizedStatusFilterReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -300,7 +593,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithCapitalizedStatusFilterReturnsT
 func (suite *TaskAPIsTestSuite) TestListTasksWithStatusFilterNoTasks() {
 	emptyTaskList := make([]storetypes.VersionedTask, 0)
 
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(emptyTaskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -311,7 +604,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithStatusFilterNoTasks() {
 }
 
 func (suite *TaskAPIsTestSuite) TestListTasksWithStatusFilterStoreReturnsError() {
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(nil, errors.New("Error when filtering tasks"))
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -341,7 +634,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithInvalidStatusFilter() {
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -354,7 +647,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterReturnsTasks()
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterNoTasks() {
 	emptyTaskList := make([]storetypes.VersionedTask, 0)
 
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(emptyTaskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -365,7 +658,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterNoTasks() {
 }
 
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterStoreReturnsError() {
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterName1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(nil, errors.New("Error when filtering tasks"))
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -380,7 +673,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithClusterNameFilterStoreReturnsEr
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterARNFilterReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -393,7 +686,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithClusterARNFilterReturnsTasks()
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterARNFilterNoTasks() {
 	emptyTaskList := make([]storetypes.VersionedTask, 0)
 
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(emptyTaskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -404,7 +697,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithClusterARNFilterNoTasks() {
 }
 
 func (suite *TaskAPIsTestSuite) TestListTasksWithClusterARNFilterStoreReturnsError() {
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: ""}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: clusterARN1, taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(nil, errors.New("Error when filtering tasks"))
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -432,7 +725,7 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithStartedByFilterReturnsTasks() {
 	taskList := []storetypes.VersionedTask{suite.versionedTask1}
 
 	startedBy := "someone"
-	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: "", taskStartedByFilter: startedBy}
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: "", taskStartedByFilter: startedBy, taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
 	suite.taskStore.EXPECT().ListTasks().Times(0)
 
@@ -448,9 +741,97 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithStartedByFilterReturnsTasks() {
 	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
 }
 
+func (suite *TaskAPIsTestSuite) TestListTasksWithLaunchTypeFilterReturnsTasks() {
+	taskList := []storetypes.VersionedTask{suite.versionedTask1}
+
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "fargate", taskGroupFilter: ""}
+	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	request, err := http.NewRequest("GET", filterTasksByLaunchTypePrefix+"FARGATE", nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter tasks by launch type request")
+	responseRecorder := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	extTasks := models.Tasks{
+		Items: []*models.Task{&suite.extTask1},
+	}
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithInvalidLaunchTypeFilter() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	request, err := http.NewRequest("GET", filterTasksByLaunchTypePrefix+"invalidLaunchType", nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter tasks by launch type request")
+	responseRecorder := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidLaunchTypeClientErrMsg)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithGroupFilterReturnsTasks() {
+	taskList := []storetypes.VersionedTask{suite.versionedTask1}
+
+	group := "service:web"
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: group}
+	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	request, err := http.NewRequest("GET", filterTasksByGroupPrefix+group, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter tasks by group request")
+	responseRecorder := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	extTasks := models.Tasks{
+		Items: []*models.Task{&suite.extTask1},
+	}
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithServiceNameFilterReturnsTasks() {
+	taskList := []storetypes.VersionedTask{suite.versionedTask1}
+
+	filters := map[string]string{taskStatusFilter: "", taskClusterFilter: "", taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: "service:web"}
+	suite.taskStore.EXPECT().FilterTasks(filters).Return(taskList, nil)
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	request, err := http.NewRequest("GET", listTasksPrefix+"?serviceName=web", nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating filter tasks by service name request")
+	responseRecorder := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	extTasks := models.Tasks{
+		Items: []*models.Task{&suite.extTask1},
+	}
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	suite.validateTasksInListTasksResponse(responseRecorder, extTasks)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithServiceNameAndGroupFilters() {
+	suite.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest("GET", listTasksPrefix+"?serviceName=web&group=service:web", nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request with service name and group filters")
+	responseRecorder := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, unsupportedFilterCombinationClientErrMsg)
+}
+
 func (suite *TaskAPIsTestSuite) TestListTasksUnsupportedFilterCombination() {
 	startedBy := "someone"
-	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: clusterARN1, taskStartedByFilter: startedBy}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: clusterARN1, taskStartedByFilter: startedBy, taskLaunchTypeFilter: "", taskGroupFilter: ""}
 	suite.taskStore.EXPECT().FilterTasks(filters).Return(nil, types.NewUnsupportedFilterCombination(errors.New("Unsupported filter combination")))
 
 	url := listTasksPrefix + "?status=" + taskStatus1 + "&cluster=" + clusterARN1 + "&startedBy=" + startedBy
@@ -476,6 +857,60 @@ func (suite *TaskAPIsTestSuite) TestListTasksWithRedundantFilter() {
 	suite.decodeErrorResponseAndValidate(responseRecorder, redundantFilterClientErrMsg)
 }
 
+func (suite *TaskAPIsTestSuite) TestListTasksIncludeTaskDefinitionGetsEachTaskDefinitionOnce() {
+	tasks := []storetypes.VersionedTask{suite.versionedTask1, suite.versionedTask2}
+	suite.taskStore.EXPECT().ListTasks().Return(tasks, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.taskDefinition, nil).Times(1)
+
+	url := listTasksPrefix + "?include=taskDefinition"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	tasksInResponse := models.Tasks{}
+	err = json.NewDecoder(responseRecorder.Body).Decode(&tasksInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Len(suite.T(), tasksInResponse.Items, 2, "Unexpected number of tasks in response")
+	for _, task := range tasksInResponse.Items {
+		assert.NotNil(suite.T(), task.Entity.TaskDefinition, "Expected task definition summary in response")
+	}
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksIncludeTaskDefinitionWithFilter() {
+	tasks := []storetypes.VersionedTask{suite.versionedTask1}
+	filters := map[string]string{taskStatusFilter: taskStatus1, taskClusterFilter: "",
+		taskStartedByFilter: "", taskLaunchTypeFilter: "", taskGroupFilter: ""}
+	suite.taskStore.EXPECT().FilterTasks(filters).Return(tasks, nil)
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.taskDefinition, nil)
+
+	url := filterTasksByStatusPrefix + taskStatus1 + "&include=taskDefinition"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *TaskAPIsTestSuite) TestListTasksWithRedundantInclude() {
+	suite.taskStore.EXPECT().ListTasks().Times(0)
+
+	url := listTasksPrefix + "?include=taskDefinition&include=taskDefinition"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request")
+
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidIncludeClientErrMsg)
+}
+
 func (suite *TaskAPIsTestSuite) TestStreamTasksReturnsTasks() {
 	taskRespChan := make(chan storetypes.VersionedTask)
 	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), gomock.Any()).Return(taskRespChan, nil)
@@ -512,6 +947,27 @@ func (suite *TaskAPIsTestSuite) TestStreamTasksNoTasks() {
 	suite.validateTasksInStreamTasksResponse(responseRecorder, emptyTasks)
 }
 
+func (suite *TaskAPIsTestSuite) TestStreamTasksEndsWithMessageOnShutdown() {
+	taskRespChan := make(chan storetypes.VersionedTask)
+	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), gomock.Any()).Return(taskRespChan, nil)
+
+	shutdownCtx, shutdown := NewShutdownContext(context.Background())
+	go func() {
+		defer close(taskRespChan)
+		taskRespChan <- suite.versionedTask1
+		shutdown()
+	}()
+
+	request := suite.streamTasksRequest().WithContext(shutdownCtx)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulStreamResponseHeaderAndStatus(responseRecorder)
+	lines := strings.Split(strings.TrimSpace(responseRecorder.Body.String()), "\n")
+	assert.Len(suite.T(), lines, 2, "Expected a task and a final message in stream")
+	assert.Equal(suite.T(), shuttingDownServerErrMsg, lines[len(lines)-1], "Unexpected final message in stream")
+}
+
 func (suite *TaskAPIsTestSuite) TestStreamTasksWithValidEntityVersion() {
 	taskRespChan := make(chan storetypes.VersionedTask)
 	suite.taskStore.EXPECT().StreamTasks(gomock.Any(), entityVersion).Return(taskRespChan, nil)
@@ -614,6 +1070,20 @@ func (suite *TaskAPIsTestSuite) getTaskRequest() *http.Request {
 	return request
 }
 
+func (suite *TaskAPIsTestSuite) getTaskIncludeTaskDefinitionRequest() *http.Request {
+	url := getTaskPrefix + "/" + clusterName1 + "/" + taskARN1 + "?include=taskDefinition"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task request")
+	return request
+}
+
+func (suite *TaskAPIsTestSuite) getTaskHistoryRequest() *http.Request {
+	url := getTaskPrefix + "/" + clusterName1 + "/" + taskARN1 + "/history"
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task history request")
+	return request
+}
+
 func (suite *TaskAPIsTestSuite) listTasksRequest() *http.Request {
 	request, err := http.NewRequest("GET", listTasksPrefix, nil)
 	assert.Nil(suite.T(), err, "Unexpected error creating list tasks request")
@@ -681,6 +1151,10 @@ func (suite *TaskAPIsTestSuite) getRouter() *mux.Router {
 	r := mux.NewRouter().StrictSlash(true)
 	s := r.Path("/v1").Subrouter()
 
+	s.Path(getTaskHistoryPath).
+		Methods("GET").
+		HandlerFunc(suite.taskAPIs.GetTaskHistory)
+
 	s.Path(getTaskPath).
 		Methods("GET").
 		HandlerFunc(suite.taskAPIs.GetTask)
@@ -0,0 +1,175 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	taskDefinitionARNKey = "arn"
+
+	taskDefinitionFamilyFilter = "family"
+)
+
+var (
+	// Using maps because arrays don't support easy lookup
+	supportedTaskDefinitionFilters = map[string]string{taskDefinitionFamilyFilter: ""}
+)
+
+// TaskDefinitionAPIs encapsulates the backend datastore and the loader with which the task definition APIs interact
+type TaskDefinitionAPIs struct {
+	taskDefinitionStore  store.TaskDefinitionStore
+	taskDefinitionLoader loader.TaskDefinitionLoader
+}
+
+// NewTaskDefinitionAPIs initializes the TaskDefinitionAPIs struct
+func NewTaskDefinitionAPIs(taskDefinitionStore store.TaskDefinitionStore, taskDefinitionLoader loader.TaskDefinitionLoader) TaskDefinitionAPIs {
+	return TaskDefinitionAPIs{
+		taskDefinitionStore:  taskDefinitionStore,
+		taskDefinitionLoader: taskDefinitionLoader,
+	}
+}
+
+// GetTaskDefinition gets a task definition using its ARN. Task definitions
+// that are not cached yet are loaded from ECS.
+func (taskDefinitionAPIs TaskDefinitionAPIs) GetTaskDefinition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskDefinitionARN := vars[taskDefinitionARNKey]
+
+	if len(taskDefinitionARN) == 0 || !regex.IsTaskDefinitionARN(taskDefinitionARN) {
+		http.Error(w, routingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	taskDefinition, err := loader.GetOrLoadTaskDefinition(taskDefinitionAPIs.taskDefinitionStore, taskDefinitionAPIs.taskDefinitionLoader, taskDefinitionARN)
+
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if taskDefinition == nil {
+		http.Error(w, taskDefinitionNotFoundClientErrMsg, http.StatusNotFound)
+		return
+	}
+
+	extTaskDefinition, err := ToTaskDefinition(*taskDefinition)
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extTaskDefinition)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListTaskDefinitions lists all cached task definitions, or the cached revisions of a family if the family filter is set
+func (taskDefinitionAPIs TaskDefinitionAPIs) ListTaskDefinitions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if taskDefinitionAPIs.hasUnsupportedFilters(query) {
+		http.Error(w, unsupportedFilterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if taskDefinitionAPIs.hasRedundantFilters(query) {
+		http.Error(w, redundantFilterClientErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	family := query.Get(taskDefinitionFamilyFilter)
+
+	if family != "" {
+		if !regex.IsTaskDefinitionFamily(family) {
+			http.Error(w, invalidTaskDefinitionFamilyClientErrMsg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var taskDefinitions []storetypes.VersionedTaskDefinition
+	var err error
+
+	if family == "" {
+		taskDefinitions, err = taskDefinitionAPIs.taskDefinitionStore.ListTaskDefinitions()
+	} else {
+		taskDefinitions, err = taskDefinitionAPIs.taskDefinitionStore.ListTaskDefinitionRevisions(family)
+	}
+
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	extTaskDefinitionItems := make([]*models.TaskDefinition, len(taskDefinitions))
+	for i := range taskDefinitions {
+		t, err := ToTaskDefinition(taskDefinitions[i])
+		if err != nil {
+			http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+			return
+		}
+		extTaskDefinitionItems[i] = &t
+	}
+
+	extTaskDefinitions := models.TaskDefinitions{
+		Items: extTaskDefinitionItems,
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extTaskDefinitions)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (taskDefinitionAPIs TaskDefinitionAPIs) hasUnsupportedFilters(filters map[string][]string) bool {
+	if len(filters) > len(supportedTaskDefinitionFilters) {
+		return true
+	}
+
+	for f := range filters {
+		_, ok := supportedTaskDefinitionFilters[f]
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (taskDefinitionAPIs TaskDefinitionAPIs) hasRedundantFilters(filters map[string][]string) bool {
+	for _, val := range filters {
+		// Multiple values for a given filter implies that it has been specified multiple times
+		if len(val) > 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	getTaskDefinitionPrefix   = "/v1/taskdefinitions"
+	listTaskDefinitionsPrefix = "/v1/taskdefinitions"
+)
+
+type TaskDefinitionAPIsTestSuite struct {
+	suite.Suite
+	taskDefinitionStore      *mocks.MockTaskDefinitionStore
+	taskDefinitionLoader     *mocks.MockTaskDefinitionLoader
+	taskDefinitionAPIs       TaskDefinitionAPIs
+	versionedTaskDefinition1 storetypes.VersionedTaskDefinition
+	versionedTaskDefinition2 storetypes.VersionedTaskDefinition
+	extTaskDefinition1       models.TaskDefinition
+	extTaskDefinition2       models.TaskDefinition
+	responseHeaderJSON       http.Header
+
+	// We need a router because some of the apis use mux.Vars() which uses the URL
+	// parameters parsed and stored in a global map in the global context by the router.
+	router *mux.Router
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(mockCtrl)
+	suite.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(mockCtrl)
+
+	suite.taskDefinitionAPIs = NewTaskDefinitionAPIs(suite.taskDefinitionStore, suite.taskDefinitionLoader)
+
+	taskDefinitionDetail1 := types.TaskDefinitionDetail{
+		ContainerDefinitions: []*types.ContainerDefinition{
+			{
+				CPU:          128,
+				DockerLabels: map[string]string{"team": "web"},
+				Essential:    true,
+				Image:        &containerImage1,
+				Memory:       256,
+				Name:         &containerName1,
+				PortMappings: []*types.PortMapping{{ContainerPort: &containerPort1, Protocol: "tcp"}},
+			},
+		},
+		Family:            &taskName,
+		NetworkMode:       "bridge",
+		Revision:          &taskRevision1,
+		Status:            "ACTIVE",
+		TaskDefinitionARN: &taskDefinitionARN,
+		UpdatedAt:         &updatedAt1,
+	}
+	suite.versionedTaskDefinition1 = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{Detail: &taskDefinitionDetail1},
+		Version:        entityVersion,
+	}
+
+	taskDefinitionDetail2 := taskDefinitionDetail1
+	taskDefinitionDetail2.Revision = &taskRevision2
+	taskDefinitionDetail2.TaskDefinitionARN = &taskDefinitionARN2
+	suite.versionedTaskDefinition2 = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{Detail: &taskDefinitionDetail2},
+		Version:        entityVersion,
+	}
+
+	extTaskDefinition, err := ToTaskDefinition(suite.versionedTaskDefinition1)
+	if err != nil {
+		suite.T().Error("Cannot setup testSuite: Error when tranlating task definition to external model")
+	}
+	suite.extTaskDefinition1 = extTaskDefinition
+
+	extTaskDefinition, err = ToTaskDefinition(suite.versionedTaskDefinition2)
+	if err != nil {
+		suite.T().Error("Cannot setup testSuite: Error when tranlating task definition to external model")
+	}
+	suite.extTaskDefinition2 = extTaskDefinition
+
+	suite.responseHeaderJSON = http.Header{responseContentTypeKey: []string{responseContentTypeJSON}}
+
+	suite.router = suite.getRouter()
+}
+
+func TestTaskDefinitionAPIsTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskDefinitionAPIsTestSuite))
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionReturnsCachedTaskDefinition() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.versionedTaskDefinition1, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(gomock.Any()).Times(0)
+
+	request := suite.getTaskDefinitionRequest(taskDefinitionARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	taskDefinitionInResponse := models.TaskDefinition{}
+	err := json.NewDecoder(reader).Decode(&taskDefinitionInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), suite.extTaskDefinition1, taskDefinitionInResponse, "Task definition in response is invalid")
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionLoadsUncachedTaskDefinition() {
+	gomock.InOrder(
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(nil),
+		suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(&suite.versionedTaskDefinition1, nil),
+	)
+
+	request := suite.getTaskDefinitionRequest(taskDefinitionARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionNotFoundInECS() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(types.NewNotFound(errors.New("Unable to describe task definition")))
+
+	request := suite.getTaskDefinitionRequest(taskDefinitionARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusNotFound)
+	suite.decodeErrorResponseAndValidate(responseRecorder, taskDefinitionNotFoundClientErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionLoaderReturnsError() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(taskDefinitionARN).Return(errors.New("Error when loading task definition"))
+
+	request := suite.getTaskDefinitionRequest(taskDefinitionARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionStoreReturnsError() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(taskDefinitionARN).Return(nil, errors.New("Error when getting task definition"))
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinition(gomock.Any()).Times(0)
+
+	request := suite.getTaskDefinitionRequest(taskDefinitionARN)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestGetTaskDefinitionWithoutRevision() {
+	suite.taskDefinitionStore.EXPECT().GetTaskDefinition(gomock.Any()).Times(0)
+
+	request := suite.getTaskDefinitionRequest("arn:aws:ecs:us-east-1:123456789012:task-definition/" + taskName)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsReturnsTaskDefinitions() {
+	taskDefinitions := []storetypes.VersionedTaskDefinition{suite.versionedTaskDefinition1, suite.versionedTaskDefinition2}
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(taskDefinitions, nil)
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitionRevisions(gomock.Any()).Times(0)
+
+	request := suite.listTaskDefinitionsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	extTaskDefinitions := models.TaskDefinitions{
+		Items: []*models.TaskDefinition{&suite.extTaskDefinition1, &suite.extTaskDefinition2},
+	}
+	suite.validateTaskDefinitionsInListTaskDefinitionsResponse(responseRecorder, extTaskDefinitions)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsReturnsNoTaskDefinitions() {
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(make([]storetypes.VersionedTaskDefinition, 0), nil)
+
+	request := suite.listTaskDefinitionsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	emptyExtTaskDefinitions := models.TaskDefinitions{
+		Items: []*models.TaskDefinition{},
+	}
+	suite.validateTaskDefinitionsInListTaskDefinitionsResponse(responseRecorder, emptyExtTaskDefinitions)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsStoreReturnsError() {
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(nil, errors.New("Error when listing task definitions"))
+
+	request := suite.listTaskDefinitionsRequest("")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusInternalServerError)
+	suite.decodeErrorResponseAndValidate(responseRecorder, internalServerErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsWithFamilyFilter() {
+	taskDefinitions := []storetypes.VersionedTaskDefinition{suite.versionedTaskDefinition1, suite.versionedTaskDefinition2}
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Times(0)
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitionRevisions(taskName).Return(taskDefinitions, nil)
+
+	request := suite.listTaskDefinitionsRequest("?family=" + taskName)
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder)
+	extTaskDefinitions := models.TaskDefinitions{
+		Items: []*models.TaskDefinition{&suite.extTaskDefinition1, &suite.extTaskDefinition2},
+	}
+	suite.validateTaskDefinitionsInListTaskDefinitionsResponse(responseRecorder, extTaskDefinitions)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsWithInvalidFamily() {
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitionRevisions(gomock.Any()).Times(0)
+
+	request := suite.listTaskDefinitionsRequest("?family=" + taskName + ":1")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, invalidTaskDefinitionFamilyClientErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsWithUnsupportedFilter() {
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Times(0)
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitionRevisions(gomock.Any()).Times(0)
+
+	request := suite.listTaskDefinitionsRequest("?unsupportedFilter=value")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, unsupportedFilterClientErrMsg)
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) TestListTaskDefinitionsWithRedundantFilter() {
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitionRevisions(gomock.Any()).Times(0)
+
+	request := suite.listTaskDefinitionsRequest("?family=" + taskName + "&family=other")
+	responseRecorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(responseRecorder, request)
+
+	suite.validateErrorResponseHeaderAndStatus(responseRecorder, http.StatusBadRequest)
+	suite.decodeErrorResponseAndValidate(responseRecorder, redundantFilterClientErrMsg)
+}
+
+// Helper functions
+
+func (suite *TaskDefinitionAPIsTestSuite) getRouter() *mux.Router {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+
+	s.Path(getTaskDefinitionPath).
+		Methods("GET").
+		HandlerFunc(suite.taskDefinitionAPIs.GetTaskDefinition)
+
+	s.Path(listTaskDefinitionsPath).
+		Methods("GET").
+		HandlerFunc(suite.taskDefinitionAPIs.ListTaskDefinitions)
+
+	return s
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) getTaskDefinitionRequest(taskDefinitionARN string) *http.Request {
+	url := getTaskDefinitionPrefix + "/" + taskDefinitionARN
+	request, err := http.NewRequest("GET", url, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating get task definition request")
+	return request
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) listTaskDefinitionsRequest(query string) *http.Request {
+	request, err := http.NewRequest("GET", listTaskDefinitionsPrefix+query, nil)
+	assert.Nil(suite.T(), err, "Unexpected error creating list task definitions request")
+	return request
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) validateSuccessfulJSONResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), suite.responseHeaderJSON, h, "Http header is invalid")
+	assert.Equal(suite.T(), http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) validateErrorResponseHeaderAndStatus(responseRecorder *httptest.ResponseRecorder, errorCode int) {
+	h := responseRecorder.Header()
+	assert.NotNil(suite.T(), h, "Unexpected empty header")
+	assert.Equal(suite.T(), errorCode, responseRecorder.Code, "Http response status is invalid")
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) decodeErrorResponseAndValidate(responseRecorder *httptest.ResponseRecorder, expectedErrMsg string) {
+	actualMsg := responseRecorder.Body.String()
+	assert.Equal(suite.T(), expectedErrMsg+"\n", actualMsg, "Error message is invalid")
+}
+
+func (suite *TaskDefinitionAPIsTestSuite) validateTaskDefinitionsInListTaskDefinitionsResponse(responseRecorder *httptest.ResponseRecorder, expectedTaskDefinitions models.TaskDefinitions) {
+	reader := bytes.NewReader(responseRecorder.Body.Bytes())
+	taskDefinitionsInResponse := new(models.TaskDefinitions)
+	err := json.NewDecoder(reader).Decode(taskDefinitionsInResponse)
+	assert.Nil(suite.T(), err, "Unexpected error decoding response body")
+	assert.Exactly(suite.T(), expectedTaskDefinitions, *taskDefinitionsInResponse, "Task definitions in response are invalid")
+}
@@ -19,6 +19,8 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/placement"
 	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
@@ -29,6 +31,12 @@ const (
 	resourceDoubleType    = "DOUBLE"
 	resourceLongType      = "LONG"
 	resourceStringSetType = "STRINGSET"
+
+	resourceCPUName    = "CPU"
+	resourceMemoryName = "MEMORY"
+
+	instanceActiveStatus   = "ACTIVE"
+	instanceDrainingStatus = "DRAINING"
 )
 
 func validateContainerInstance(instance types.ContainerInstance) error {
@@ -88,6 +96,14 @@ func toContainerInstanceResource(r *types.Resource) *models.ContainerInstanceRes
 	return resource
 }
 
+func toContainerInstanceResources(resources []*types.Resource) []*models.ContainerInstanceResource {
+	res := make([]*models.ContainerInstanceResource, len(resources))
+	for i := range resources {
+		res[i] = toContainerInstanceResource(resources[i])
+	}
+	return res
+}
+
 // ToContainerInstance translates a container instance represented by the internal structure (storetypes.VersionedContainerInstance) to it's external representation (models.ContainerInstance)
 func ToContainerInstance(versionedInstance storetypes.VersionedContainerInstance) (models.ContainerInstance, error) {
 	c := versionedInstance.ContainerInstance
@@ -95,15 +111,9 @@ func ToContainerInstance(versionedInstance storetypes.VersionedContainerInstance
 	if err != nil {
 		return models.ContainerInstance{}, err
 	}
-	regRes := make([]*models.ContainerInstanceResource, len(c.Detail.RegisteredResources))
-	for i := range c.Detail.RegisteredResources {
-		regRes[i] = toContainerInstanceResource(c.Detail.RegisteredResources[i])
-	}
+	regRes := toContainerInstanceResources(c.Detail.RegisteredResources)
 
-	remRes := make([]*models.ContainerInstanceResource, len(c.Detail.RemainingResources))
-	for i := range c.Detail.RegisteredResources {
-		remRes[i] = toContainerInstanceResource(c.Detail.RemainingResources[i])
-	}
+	remRes := toContainerInstanceResources(c.Detail.RemainingResources)
 
 	versionInfo := models.ContainerInstanceVersionInfo{
 		AgentHash:     c.Detail.VersionInfo.AgentHash,
@@ -152,9 +162,6 @@ func validateTask(task types.Task) error {
 	if detail.ClusterARN == nil {
 		return errors.New("Task cluster ARN cannot be empty")
 	}
-	if detail.ContainerInstanceARN == nil {
-		return errors.New("Task container instance ARN cannot be empty")
-	}
 	if detail.Containers == nil {
 		return errors.New("Task containers cannot be empty")
 	}
@@ -179,17 +186,10 @@ func validateTask(task types.Task) error {
 	return nil
 }
 
-// ToTask translates a task represented by the internal structure (storetypes.VersionedTask) to it's external representation (models.Task)
-func ToTask(versionedTask storetypes.VersionedTask) (models.Task, error) {
-	t := versionedTask.Task
-	err := validateTask(t)
-	if err != nil {
-		return models.Task{}, err
-	}
-
-	containers := make([]*models.TaskContainer, len(t.Detail.Containers))
-	for i := range t.Detail.Containers {
-		c := t.Detail.Containers[i]
+func toTaskContainers(taskContainers []*types.Container) []*models.TaskContainer {
+	containers := make([]*models.TaskContainer, len(taskContainers))
+	for i := range taskContainers {
+		c := taskContainers[i]
 		containers[i] = &models.TaskContainer{
 			ContainerARN: c.ContainerARN,
 			ExitCode:     c.ExitCode,
@@ -211,6 +211,56 @@ func ToTask(versionedTask storetypes.VersionedTask) (models.Task, error) {
 			containers[i].NetworkBindings = networkBindings
 		}
 	}
+	return containers
+}
+
+func toTaskAttachments(taskAttachments []*types.Attachment) []*models.TaskAttachment {
+	if taskAttachments == nil {
+		return nil
+	}
+	attachments := make([]*models.TaskAttachment, len(taskAttachments))
+	for i := range taskAttachments {
+		a := taskAttachments[i]
+		details := make([]*models.TaskAttachmentDetail, len(a.Details))
+		for j := range a.Details {
+			details[j] = &models.TaskAttachmentDetail{
+				Name:  a.Details[j].Name,
+				Value: a.Details[j].Value,
+			}
+		}
+		attachments[i] = &models.TaskAttachment{
+			Details: details,
+			ID:      a.ID,
+			Status:  a.Status,
+			Type:    a.Type,
+		}
+	}
+	return attachments
+}
+
+func toTaskTags(taskTags []*types.Tag) []*models.TaskTag {
+	if taskTags == nil {
+		return nil
+	}
+	tags := make([]*models.TaskTag, len(taskTags))
+	for i := range taskTags {
+		tags[i] = &models.TaskTag{
+			Key:   taskTags[i].Key,
+			Value: aws.StringValue(taskTags[i].Value),
+		}
+	}
+	return tags
+}
+
+// ToTask translates a task represented by the internal structure (storetypes.VersionedTask) to it's external representation (models.Task)
+func ToTask(versionedTask storetypes.VersionedTask) (models.Task, error) {
+	t := versionedTask.Task
+	err := validateTask(t)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	containers := toTaskContainers(t.Detail.Containers)
 
 	containerOverrides := make([]*models.TaskContainerOverride, len(t.Detail.Overrides.ContainerOverrides))
 	for i := range t.Detail.Overrides.ContainerOverrides {
@@ -242,19 +292,494 @@ func ToTask(versionedTask storetypes.VersionedTask) (models.Task, error) {
 			EntityVersion: &versionedTask.Version,
 		},
 		Entity: &models.TaskDetail{
+			Attachments:          toTaskAttachments(t.Detail.Attachments),
 			ClusterARN:           t.Detail.ClusterARN,
+			Connectivity:         t.Detail.Connectivity,
+			ConnectivityAt:       t.Detail.ConnectivityAt,
 			ContainerInstanceARN: t.Detail.ContainerInstanceARN,
 			Containers:           containers,
+			CPU:                  t.Detail.CPU,
 			CreatedAt:            t.Detail.CreatedAt,
 			DesiredStatus:        t.Detail.DesiredStatus,
+			Group:                t.Detail.Group,
+			HealthStatus:         t.Detail.HealthStatus,
 			LastStatus:           t.Detail.LastStatus,
+			LaunchType:           t.Detail.LaunchType,
+			Memory:               t.Detail.Memory,
 			Overrides:            &overrides,
+			PlatformVersion:      t.Detail.PlatformVersion,
+			PullStartedAt:        t.Detail.PullStartedAt,
+			PullStoppedAt:        t.Detail.PullStoppedAt,
 			StartedAt:            t.Detail.StartedAt,
 			StartedBy:            t.Detail.StartedBy,
+			StopCode:             t.Detail.StopCode,
 			StoppedAt:            t.Detail.StoppedAt,
 			StoppedReason:        t.Detail.StoppedReason,
+			Tags:                 toTaskTags(t.Detail.Tags),
 			TaskARN:              t.Detail.TaskARN,
 			TaskDefinitionARN:    t.Detail.TaskDefinitionARN,
 		},
 	}, nil
 }
+
+// ToTaskHistory translates the history of a task represented by the internal structure ([]storetypes.TaskHistoryEntry) to it's external representation (models.TaskHistory)
+func ToTaskHistory(taskHistory []storetypes.TaskHistoryEntry) models.TaskHistory {
+	items := make([]*models.TaskHistoryEntry, len(taskHistory))
+	for i := range taskHistory {
+		e := taskHistory[i]
+		items[i] = &models.TaskHistoryEntry{
+			RecordedAt: aws.String(e.RecordedAt),
+			State: &models.TaskState{
+				Containers:    toTaskContainers(e.State.Containers),
+				DesiredStatus: e.State.DesiredStatus,
+				HealthStatus:  e.State.HealthStatus,
+				LastStatus:    e.State.LastStatus,
+				StopCode:      e.State.StopCode,
+				StoppedReason: e.State.StoppedReason,
+				UpdatedAt:     aws.StringValue(e.State.UpdatedAt),
+			},
+			Version: aws.Int64(e.Version),
+		}
+	}
+	return models.TaskHistory{
+		Items: items,
+	}
+}
+
+// ToContainerInstanceHistory translates the history of a container instance represented by the internal structure ([]storetypes.ContainerInstanceHistoryEntry) to it's external representation (models.ContainerInstanceHistory)
+func ToContainerInstanceHistory(instanceHistory []storetypes.ContainerInstanceHistoryEntry) models.ContainerInstanceHistory {
+	items := make([]*models.ContainerInstanceHistoryEntry, len(instanceHistory))
+	for i := range instanceHistory {
+		e := instanceHistory[i]
+		items[i] = &models.ContainerInstanceHistoryEntry{
+			RecordedAt: aws.String(e.RecordedAt),
+			State: &models.ContainerInstanceState{
+				AgentConnected:      e.State.AgentConnected,
+				RegisteredResources: toContainerInstanceResources(e.State.RegisteredResources),
+				RemainingResources:  toContainerInstanceResources(e.State.RemainingResources),
+				Status:              e.State.Status,
+				UpdatedAt:           aws.StringValue(e.State.UpdatedAt),
+			},
+			Version: aws.Int64(e.Version),
+		}
+	}
+	return models.ContainerInstanceHistory{
+		Items: items,
+	}
+}
+
+func validateService(service types.Service) error {
+	detail := service.Detail
+	if detail == nil {
+		return errors.New("Service detail cannot be empty")
+	}
+	if detail.ClusterARN == nil {
+		return errors.New("Service cluster ARN cannot be empty")
+	}
+	if detail.ServiceARN == nil {
+		return errors.New("Service ARN cannot be empty")
+	}
+	if detail.ServiceName == nil {
+		return errors.New("Service name cannot be empty")
+	}
+	return nil
+}
+
+func toServiceDeployments(deployments []*types.Deployment) []*models.ServiceDeployment {
+	if deployments == nil {
+		return nil
+	}
+	extDeployments := make([]*models.ServiceDeployment, len(deployments))
+	for i := range deployments {
+		d := deployments[i]
+		extDeployments[i] = &models.ServiceDeployment{
+			CreatedAt:          d.CreatedAt,
+			DesiredCount:       d.DesiredCount,
+			ID:                 d.ID,
+			PendingCount:       d.PendingCount,
+			RolloutState:       d.RolloutState,
+			RolloutStateReason: d.RolloutStateReason,
+			RunningCount:       d.RunningCount,
+			Status:             d.Status,
+			TaskDefinition:     d.TaskDefinition,
+			UpdatedAt:          d.UpdatedAt,
+		}
+	}
+	return extDeployments
+}
+
+func toServiceEvents(events []*types.ServiceEvent) []*models.ServiceEvent {
+	if events == nil {
+		return nil
+	}
+	extEvents := make([]*models.ServiceEvent, len(events))
+	for i := range events {
+		e := events[i]
+		extEvents[i] = &models.ServiceEvent{
+			CreatedAt: aws.StringValue(e.CreatedAt),
+			EventName: e.EventName,
+			EventType: e.EventType,
+			ID:        aws.StringValue(e.ID),
+			Message:   e.Message,
+		}
+	}
+	return extEvents
+}
+
+// ToService translates a service represented by the internal structure (storetypes.VersionedService) to it's external representation (models.Service)
+func ToService(versionedService storetypes.VersionedService) (models.Service, error) {
+	s := versionedService.Service
+	err := validateService(s)
+	if err != nil {
+		return models.Service{}, err
+	}
+
+	return models.Service{
+		Metadata: &models.Metadata{
+			EntityVersion: &versionedService.Version,
+		},
+		Entity: &models.ServiceDetail{
+			ClusterARN:     s.Detail.ClusterARN,
+			CreatedAt:      s.Detail.CreatedAt,
+			Deployments:    toServiceDeployments(s.Detail.Deployments),
+			DesiredCount:   s.Detail.DesiredCount,
+			Events:         toServiceEvents(s.Detail.Events),
+			PendingCount:   s.Detail.PendingCount,
+			RunningCount:   s.Detail.RunningCount,
+			ServiceARN:     s.Detail.ServiceARN,
+			ServiceName:    s.Detail.ServiceName,
+			Status:         s.Detail.Status,
+			TaskDefinition: s.Detail.TaskDefinition,
+			UpdatedAt:      aws.StringValue(s.Detail.UpdatedAt),
+		},
+	}, nil
+}
+
+func validateTaskDefinition(taskDefinition types.TaskDefinition) error {
+	detail := taskDefinition.Detail
+	if detail == nil {
+		return errors.New("Task definition detail cannot be empty")
+	}
+	if detail.Family == nil {
+		return errors.New("Task definition family cannot be empty")
+	}
+	if detail.Revision == nil {
+		return errors.New("Task definition revision cannot be empty")
+	}
+	if detail.TaskDefinitionARN == nil {
+		return errors.New("Task definition ARN cannot be empty")
+	}
+	return nil
+}
+
+func toTaskDefinitionContainers(containerDefinitions []*types.ContainerDefinition) []*models.TaskDefinitionContainer {
+	containers := make([]*models.TaskDefinitionContainer, len(containerDefinitions))
+	for i := range containerDefinitions {
+		c := containerDefinitions[i]
+		containers[i] = &models.TaskDefinitionContainer{
+			CPU:               c.CPU,
+			DockerLabels:      c.DockerLabels,
+			Essential:         c.Essential,
+			Image:             c.Image,
+			Memory:            c.Memory,
+			MemoryReservation: c.MemoryReservation,
+			Name:              c.Name,
+		}
+		if c.PortMappings != nil {
+			portMappings := make([]*models.TaskDefinitionPortMapping, len(c.PortMappings))
+			for j := range c.PortMappings {
+				p := c.PortMappings[j]
+				portMappings[j] = &models.TaskDefinitionPortMapping{
+					ContainerPort: p.ContainerPort,
+					HostPort:      p.HostPort,
+					Protocol:      p.Protocol,
+				}
+			}
+			containers[i].PortMappings = portMappings
+		}
+	}
+	return containers
+}
+
+// ToTaskDefinition translates a task definition represented by the internal structure (storetypes.VersionedTaskDefinition) to it's external representation (models.TaskDefinition)
+func ToTaskDefinition(versionedTaskDefinition storetypes.VersionedTaskDefinition) (models.TaskDefinition, error) {
+	t := versionedTaskDefinition.TaskDefinition
+	err := validateTaskDefinition(t)
+	if err != nil {
+		return models.TaskDefinition{}, err
+	}
+
+	return models.TaskDefinition{
+		Metadata: &models.Metadata{
+			EntityVersion: &versionedTaskDefinition.Version,
+		},
+		Entity: &models.TaskDefinitionDetail{
+			ContainerDefinitions: toTaskDefinitionContainers(t.Detail.ContainerDefinitions),
+			Family:               t.Detail.Family,
+			NetworkMode:          t.Detail.NetworkMode,
+			Revision:             t.Detail.Revision,
+			Status:               t.Detail.Status,
+			TaskDefinitionARN:    t.Detail.TaskDefinitionARN,
+			TaskRoleARN:          t.Detail.TaskRoleARN,
+			UpdatedAt:            aws.StringValue(t.Detail.UpdatedAt),
+		},
+	}, nil
+}
+
+// ToTaskDefinitionSummary translates a task definition represented by the internal structure (types.TaskDefinition) to the summary embedded in tasks (models.TaskDefinitionSummary)
+func ToTaskDefinitionSummary(taskDefinition types.TaskDefinition) (*models.TaskDefinitionSummary, error) {
+	err := validateTaskDefinition(taskDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskDefinitionSummary{
+		ContainerDefinitions: toTaskDefinitionContainers(taskDefinition.Detail.ContainerDefinitions),
+		Family:               taskDefinition.Detail.Family,
+		NetworkMode:          taskDefinition.Detail.NetworkMode,
+		Revision:             taskDefinition.Detail.Revision,
+	}, nil
+}
+
+func validateCluster(cluster types.Cluster) error {
+	detail := cluster.Detail
+	if detail == nil {
+		return errors.New("Cluster detail cannot be empty")
+	}
+	if detail.ClusterARN == nil {
+		return errors.New("Cluster ARN cannot be empty")
+	}
+	if detail.ClusterName == nil {
+		return errors.New("Cluster name cannot be empty")
+	}
+	return nil
+}
+
+func toClusterInstanceCounts(instances []storetypes.VersionedContainerInstance) *models.ClusterInstanceCounts {
+	var total, agentConnected, agentDisconnected int64
+	// Left nil when there are no counts, as the model omits empty counts
+	var byStatus map[string]int64
+	for _, versionedInstance := range instances {
+		detail := versionedInstance.ContainerInstance.Detail
+		if detail == nil {
+			continue
+		}
+		total++
+		if aws.BoolValue(detail.AgentConnected) {
+			agentConnected++
+		} else {
+			agentDisconnected++
+		}
+		if status := aws.StringValue(detail.Status); status != "" {
+			if byStatus == nil {
+				byStatus = make(map[string]int64)
+			}
+			byStatus[status]++
+		}
+	}
+	return &models.ClusterInstanceCounts{
+		AgentConnected:    aws.Int64(agentConnected),
+		AgentDisconnected: aws.Int64(agentDisconnected),
+		ByStatus:          byStatus,
+		Total:             aws.Int64(total),
+	}
+}
+
+func toClusterTaskCounts(tasks []storetypes.VersionedTask) *models.ClusterTaskCounts {
+	var total int64
+	var byLastStatus map[string]int64
+	for _, versionedTask := range tasks {
+		detail := versionedTask.Task.Detail
+		if detail == nil {
+			continue
+		}
+		total++
+		if lastStatus := aws.StringValue(detail.LastStatus); lastStatus != "" {
+			if byLastStatus == nil {
+				byLastStatus = make(map[string]int64)
+			}
+			byLastStatus[lastStatus]++
+		}
+	}
+	return &models.ClusterTaskCounts{
+		ByLastStatus: byLastStatus,
+		Total:        aws.Int64(total),
+	}
+}
+
+// sumResource sums the integer values of the resources named 'name'
+func sumResource(resources []*types.Resource, name string) int64 {
+	var sum int64
+	for _, r := range resources {
+		if r != nil && aws.StringValue(r.Name) == name {
+			sum += aws.Int64Value(r.IntegerValue)
+		}
+	}
+	return sum
+}
+
+// toClusterResources sums the CPU and memory of the instances that tasks can
+// be placed on or are still running on, which are the active and draining ones
+func toClusterResources(instances []storetypes.VersionedContainerInstance) *models.ClusterResources {
+	var registeredCPU, registeredMemory, remainingCPU, remainingMemory int64
+	for _, versionedInstance := range instances {
+		detail := versionedInstance.ContainerInstance.Detail
+		if detail == nil {
+			continue
+		}
+		status := aws.StringValue(detail.Status)
+		if status != instanceActiveStatus && status != instanceDrainingStatus {
+			continue
+		}
+		registeredCPU += sumResource(detail.RegisteredResources, resourceCPUName)
+		registeredMemory += sumResource(detail.RegisteredResources, resourceMemoryName)
+		remainingCPU += sumResource(detail.RemainingResources, resourceCPUName)
+		remainingMemory += sumResource(detail.RemainingResources, resourceMemoryName)
+	}
+	return &models.ClusterResources{
+		RegisteredCPU:    aws.Int64(registeredCPU),
+		RegisteredMemory: aws.Int64(registeredMemory),
+		RemainingCPU:     aws.Int64(remainingCPU),
+		RemainingMemory:  aws.Int64(remainingMemory),
+	}
+}
+
+// ToCluster translates a cluster represented by the internal structure (types.Cluster) to its external representation (models.Cluster),
+// summarizing the tasks and instances of the cluster
+func ToCluster(versionedCluster storetypes.VersionedCluster, tasks []storetypes.VersionedTask, instances []storetypes.VersionedContainerInstance) (models.Cluster, error) {
+	c := versionedCluster.Cluster
+	err := validateCluster(c)
+	if err != nil {
+		return models.Cluster{}, err
+	}
+
+	return models.Cluster{
+		Metadata: &models.Metadata{
+			EntityVersion: &versionedCluster.Version,
+		},
+		Entity: &models.ClusterDetail{
+			ClusterARN:  c.Detail.ClusterARN,
+			ClusterName: c.Detail.ClusterName,
+			Instances:   toClusterInstanceCounts(instances),
+			Resources:   toClusterResources(instances),
+			Tasks:       toClusterTaskCounts(tasks),
+		},
+	}, nil
+}
+
+func fromPlacementResources(resources models.PlacementResources) placement.Requirements {
+	requirements := placement.Requirements{
+		CPU:    aws.Int64Value(resources.CPU),
+		Memory: aws.Int64Value(resources.Memory),
+	}
+	for _, p := range resources.Ports {
+		if p == nil {
+			continue
+		}
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = placement.ProtocolTCP
+		}
+		requirements.Ports = append(requirements.Ports, placement.Port{
+			Port:     aws.Int64Value(p.Port),
+			Protocol: protocol,
+		})
+	}
+	return requirements
+}
+
+func fromPlacementConstraints(constraints []*models.PlacementConstraint) []placement.Constraint {
+	var placementConstraints []placement.Constraint
+	for _, c := range constraints {
+		if c == nil {
+			continue
+		}
+		constraint := placement.Constraint{Attribute: aws.StringValue(c.Attribute)}
+		if c.Value != "" {
+			constraint.Value = aws.String(c.Value)
+		}
+		placementConstraints = append(placementConstraints, constraint)
+	}
+	return placementConstraints
+}
+
+func toPlacementResources(requirements placement.Requirements) *models.PlacementResources {
+	ports := make([]*models.PlacementPort, len(requirements.Ports))
+	for i, p := range requirements.Ports {
+		ports[i] = &models.PlacementPort{
+			Port:     aws.Int64(p.Port),
+			Protocol: p.Protocol,
+		}
+	}
+	return &models.PlacementResources{
+		CPU:    aws.Int64(requirements.CPU),
+		Memory: aws.Int64(requirements.Memory),
+		Ports:  ports,
+	}
+}
+
+func toPlacementReasons(reasons []placement.Reason) []*models.PlacementReason {
+	placementReasons := make([]*models.PlacementReason, len(reasons))
+	for i := range reasons {
+		placementReasons[i] = &models.PlacementReason{
+			Code:    aws.String(reasons[i].Code),
+			Message: aws.String(reasons[i].Message),
+		}
+	}
+	return placementReasons
+}
+
+func ToPlacementEvaluation(cluster string, requirements placement.Requirements, evaluations []placement.Evaluation) models.PlacementEvaluation {
+	instances := make([]*models.PlacementInstance, len(evaluations))
+	for i, e := range evaluations {
+		instances[i] = &models.PlacementInstance{
+			ContainerInstanceARN: e.Instance.Detail.ContainerInstanceARN,
+			EC2InstanceID:        e.Instance.Detail.EC2InstanceID,
+			Fits:                 aws.Bool(e.Fits()),
+			Reasons:              toPlacementReasons(e.Reasons),
+		}
+	}
+	return models.PlacementEvaluation{
+		Cluster:      aws.String(cluster),
+		Instances:    instances,
+		Requirements: toPlacementResources(requirements),
+	}
+}
+
+func toEndpoint(endpoint discovery.Endpoint) *models.Endpoint {
+	return &models.Endpoint{
+		AvailabilityZone:     endpoint.AvailabilityZone,
+		ClusterARN:           aws.String(endpoint.ClusterARN),
+		ContainerInstanceARN: endpoint.ContainerInstanceARN,
+		ContainerName:        aws.String(endpoint.ContainerName),
+		ContainerPort:        aws.Int64(endpoint.ContainerPort),
+		EC2InstanceID:        endpoint.EC2InstanceID,
+		Family:               aws.String(endpoint.Family),
+		HostPort:             aws.Int64(endpoint.HostPort),
+		PrivateIP:            aws.String(endpoint.IP),
+		Protocol:             aws.String(endpoint.Protocol),
+		TaskARN:              aws.String(endpoint.TaskARN),
+		TaskDefinitionARN:    aws.String(endpoint.TaskDefinitionARN),
+	}
+}
+
+func ToEndpoints(endpoints []discovery.Endpoint) models.Endpoints {
+	items := make([]*models.Endpoint, len(endpoints))
+	for i := range endpoints {
+		items[i] = toEndpoint(endpoints[i])
+	}
+	return models.Endpoints{
+		Items: items,
+	}
+}
+
+func ToPrometheusTargetGroups(groups []discovery.TargetGroup) models.PrometheusTargetGroups {
+	extGroups := make(models.PrometheusTargetGroups, len(groups))
+	for i := range groups {
+		extGroups[i] = &models.PrometheusTargetGroup{
+			Labels:  groups[i].Labels,
+			Targets: groups[i].Targets,
+		}
+	}
+	return extGroups
+}
@@ -76,7 +76,7 @@ func (suite *TranslateTestSuite) SetupTest() {
 	suite.instance = types.ContainerInstance{
 		ID:        &id1,
 		Account:   &accountID,
-		Time:      &time,
+		Time:      &eventTime,
 		Region:    &region,
 		Resources: []string{instanceARN1},
 		Detail:    &instanceDetail,
@@ -139,7 +139,7 @@ func (suite *TranslateTestSuite) SetupTest() {
 	suite.task = types.Task{
 		ID:        &id1,
 		Account:   &accountID,
-		Time:      &time,
+		Time:      &eventTime,
 		Region:    &region,
 		Resources: []string{taskARN1},
 		Detail:    &taskDetail,
@@ -346,8 +346,11 @@ func (suite *TranslateTestSuite) TestToTaskEmptyClusterARN() {
 func (suite *TranslateTestSuite) TestToTaskEmptyContainerInstanceARN() {
 	versionedTask := suite.versionedTask
 	versionedTask.Task.Detail.ContainerInstanceARN = nil
-	_, err := ToTask(versionedTask)
-	assert.NotNil(suite.T(), err, "Expected error when translating task with empty container instance ARN")
+	versionedTask.Task.Detail.LaunchType = "FARGATE"
+	task, err := ToTask(versionedTask)
+	assert.Nil(suite.T(), err, "Unexpected error when translating a Fargate task without a container instance ARN")
+	assert.Nil(suite.T(), task.Entity.ContainerInstanceARN, "Expected no container instance ARN for a Fargate task")
+	assert.Equal(suite.T(), "FARGATE", task.Entity.LaunchType, "Invalid launch type in translated task")
 }
 
 func (suite *TranslateTestSuite) TestToTaskEmptyContainers() {
@@ -398,3 +401,64 @@ func (suite *TranslateTestSuite) TestToTaskEmptyTaskDefinitionARN() {
 	_, err := ToTask(versionedTask)
 	assert.NotNil(suite.T(), err, "Expected error when translating task with empty task definition ARN")
 }
+
+func (suite *TranslateTestSuite) TestToClusterEmptyDetail() {
+	versionedCluster := storetypes.VersionedCluster{Version: entityVersion}
+	_, err := ToCluster(versionedCluster, nil, nil)
+	assert.NotNil(suite.T(), err, "Expected error when translating cluster with empty detail")
+}
+
+func (suite *TranslateTestSuite) TestToClusterSummarizesInstancesAndTasks() {
+	versionedCluster := storetypes.VersionedCluster{
+		Cluster: types.Cluster{
+			Detail: &types.ClusterDetail{
+				ClusterARN:  &clusterARN1,
+				ClusterName: &clusterName1,
+			},
+		},
+		Version: entityVersion,
+	}
+	instance := func(status string, agentConnected bool, registeredCPU int64, remainingCPU int64) storetypes.VersionedContainerInstance {
+		cpu := "CPU"
+		return storetypes.VersionedContainerInstance{
+			ContainerInstance: types.ContainerInstance{
+				Detail: &types.InstanceDetail{
+					AgentConnected:      &agentConnected,
+					ClusterARN:          &clusterARN1,
+					RegisteredResources: []*types.Resource{{Name: &cpu, IntegerValue: &registeredCPU}},
+					RemainingResources:  []*types.Resource{{Name: &cpu, IntegerValue: &remainingCPU}},
+					Status:              &status,
+				},
+			},
+		}
+	}
+	task := func(lastStatus string) storetypes.VersionedTask {
+		return storetypes.VersionedTask{
+			Task: types.Task{
+				Detail: &types.TaskDetail{
+					ClusterARN: &clusterARN1,
+					LastStatus: &lastStatus,
+				},
+			},
+		}
+	}
+	instances := []storetypes.VersionedContainerInstance{
+		instance("ACTIVE", true, 1024, 512),
+		instance("DRAINING", false, 1024, 1024),
+		instance("INACTIVE", false, 1024, 1024),
+	}
+	tasks := []storetypes.VersionedTask{task("RUNNING"), task("RUNNING"), task("PENDING")}
+
+	cluster, err := ToCluster(versionedCluster, tasks, instances)
+	assert.Nil(suite.T(), err, "Unexpected error when translating cluster")
+	assert.Equal(suite.T(), int64(3), *cluster.Entity.Instances.Total, "Invalid instance count")
+	assert.Equal(suite.T(), int64(1), *cluster.Entity.Instances.AgentConnected, "Invalid agent connected count")
+	assert.Equal(suite.T(), int64(2), *cluster.Entity.Instances.AgentDisconnected, "Invalid agent disconnected count")
+	assert.Equal(suite.T(), map[string]int64{"ACTIVE": 1, "DRAINING": 1, "INACTIVE": 1}, cluster.Entity.Instances.ByStatus, "Invalid instance counts by status")
+	assert.Equal(suite.T(), int64(3), *cluster.Entity.Tasks.Total, "Invalid task count")
+	assert.Equal(suite.T(), map[string]int64{"RUNNING": 2, "PENDING": 1}, cluster.Entity.Tasks.ByLastStatus, "Invalid task counts by last status")
+	// Inactive instances are not part of the resources of the cluster
+	assert.Equal(suite.T(), int64(2048), *cluster.Entity.Resources.RegisteredCPU, "Invalid registered CPU")
+	assert.Equal(suite.T(), int64(1536), *cluster.Entity.Resources.RemainingCPU, "Invalid remaining CPU")
+	assert.Equal(suite.T(), int64(0), *cluster.Entity.Resources.RegisteredMemory, "Invalid registered memory")
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+)
+
+// VersionAPIs encapsulates the backend datastore with which the version APIs interact
+type VersionAPIs struct {
+	revisionStore store.RevisionStore
+}
+
+// NewVersionAPIs initializes the VersionAPIs struct
+func NewVersionAPIs(revisionStore store.RevisionStore) VersionAPIs {
+	return VersionAPIs{
+		revisionStore: revisionStore,
+	}
+}
+
+// GetVersions gets the oldest entity version that streams can be resumed
+// from, since older versions have been compacted, and the current one
+func (versionAPIs VersionAPIs) GetVersions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := versionAPIs.revisionStore.GetRevisionRange()
+	if err != nil {
+		http.Error(w, internalServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	extVersions := models.Versions{
+		OldestEntityVersion:  aws.String(strconv.FormatInt(revisions.Oldest, 10)),
+		CurrentEntityVersion: aws.String(strconv.FormatInt(revisions.Current, 10)),
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(extVersions)
+	if err != nil {
+		http.Error(w, encodingServerErrMsg, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/swagger/v1/generated/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const getVersionsPrefix = "/v1/versions"
+
+func TestGetVersions(t *testing.T) {
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 30, Current: 42}, nil)
+
+	responseRecorder := serveGetVersions(t, revisionStore)
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(t, responseContentTypeJSON, responseRecorder.Header().Get(responseContentTypeKey), "Http header is invalid")
+	var versions models.Versions
+	err := json.NewDecoder(responseRecorder.Body).Decode(&versions)
+	assert.Nil(t, err, "Unexpected error decoding response body")
+	assert.Equal(t, "30", aws.StringValue(versions.OldestEntityVersion), "Expected the oldest entity version")
+	assert.Equal(t, "42", aws.StringValue(versions.CurrentEntityVersion), "Expected the current entity version")
+}
+
+func TestGetVersionsStoreReturnsError(t *testing.T) {
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{}, errors.New("Error getting revisions"))
+
+	responseRecorder := serveGetVersions(t, revisionStore)
+
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code, "Http response status is invalid")
+	assert.Equal(t, internalServerErrMsg+"\n", responseRecorder.Body.String(), "Expected an internal server error")
+}
+
+func serveGetVersions(t *testing.T, revisionStore *mocks.MockRevisionStore) *httptest.ResponseRecorder {
+	router := NewRouter(APIs{VersionApis: NewVersionAPIs(revisionStore)})
+	request, err := http.NewRequest("GET", getVersionsPrefix, nil)
+	assert.Nil(t, err, "Unexpected error creating get versions request")
+
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	return responseRecorder
+}
@@ -0,0 +1,296 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package auth authenticates requests to the cluster state service with API
+// keys, sent as bearer tokens or in the X-API-Key header, and authorizes them
+// by the scopes and clusters of their key.
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ReadScope allows getting and listing entities
+	ReadScope = "read"
+	// StreamScope allows streaming entities
+	StreamScope = "stream"
+	// MetricsScope allows scraping metrics
+	MetricsScope = "metrics"
+
+	authorizationKey = "Authorization"
+	bearerPrefix     = "Bearer "
+	apiKeyKey        = "X-API-Key"
+	authenticateKey  = "WWW-Authenticate"
+	authenticateVal  = `Bearer realm="cluster-state-service"`
+
+	streamPathPrefix = "/v1/stream/"
+	clusterKey       = "cluster"
+
+	missingKeyErrMsg       = "Missing API key"
+	invalidKeyErrMsg       = "Invalid API key"
+	missingScopeErrMsg     = "API key does not have the '%s' scope"
+	missingClusterErrMsg   = "API key is restricted to clusters, the request has to specify one"
+	unfilteredPathErrMsg   = "API key is restricted to clusters, '%s' does not filter by cluster"
+	forbiddenClusterErrMsg = "API key does not allow access to cluster '%s'"
+)
+
+var supportedScopes = map[string]struct{}{
+	ReadScope:    {},
+	StreamScope:  {},
+	MetricsScope: {},
+}
+
+// Key is an API key and what it allows its holders to do
+type Key struct {
+	// Name identifies the holder of the key in logs
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	// Scopes are the kinds of requests the key allows
+	Scopes []string `yaml:"scopes"`
+	// Clusters, if any, restrict the key to requests that specify one of them
+	// as a cluster ARN, region qualified name or name. A name allows the
+	// clusters of that name in all accounts and regions.
+	Clusters []string `yaml:"clusters"`
+}
+
+type keysFile struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// LoadKeys reads API keys from the YAML or JSON file at 'path', which has a
+// 'keys' list of keys
+func LoadKeys(path string) ([]Key, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read API keys file '%s'", path)
+	}
+	var file keysFile
+	err = yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not decode API keys file '%s'", path)
+	}
+	return file.Keys, nil
+}
+
+// Paths tells the middleware which paths are served without authentication,
+// which path serves metrics and which paths filter by the cluster query
+// parameter. Keys restricted to clusters are only allowed on the paths that
+// have a cluster in the path or filter by cluster.
+type Paths struct {
+	Public          []string
+	Metrics         string
+	ClusterFiltered []string
+}
+
+type principal struct {
+	name     string
+	key      []byte
+	scopes   map[string]struct{}
+	clusters []regex.ClusterIdentifier
+}
+
+// Middleware authenticates the requests to a router by their API key and
+// authorizes them by the scopes and clusters of the key
+type Middleware struct {
+	principals           []principal
+	router               *mux.Router
+	publicPaths          map[string]struct{}
+	metricsPath          string
+	clusterFilteredPaths map[string]struct{}
+}
+
+// NewMiddleware initializes a middleware that allows the requests to 'router'
+// and to the metrics path that present one of 'keys'
+func NewMiddleware(keys []Key, router *mux.Router, paths Paths) (Middleware, error) {
+	if len(keys) == 0 {
+		return Middleware{}, errors.New("At least one API key is required")
+	}
+	if router == nil {
+		return Middleware{}, errors.New("Router is not initialized")
+	}
+
+	principals := make([]principal, 0, len(keys))
+	seen := make(map[string]struct{})
+	for i, key := range keys {
+		if key.Name == "" {
+			return Middleware{}, errors.Errorf("API key %d has no name", i)
+		}
+		if key.Key == "" {
+			return Middleware{}, errors.Errorf("API key '%s' is empty", key.Name)
+		}
+		if _, ok := seen[key.Key]; ok {
+			return Middleware{}, errors.Errorf("API key '%s' is the same as another key", key.Name)
+		}
+		seen[key.Key] = struct{}{}
+
+		p := principal{name: key.Name, key: []byte(key.Key), scopes: make(map[string]struct{})}
+		for _, scope := range key.Scopes {
+			if _, ok := supportedScopes[scope]; !ok {
+				return Middleware{}, errors.Errorf("API key '%s' has unsupported scope '%s'", key.Name, scope)
+			}
+			p.scopes[scope] = struct{}{}
+		}
+		for _, cluster := range key.Clusters {
+			identifier, err := regex.ParseCluster(cluster)
+			if err != nil {
+				return Middleware{}, errors.Wrapf(err, "API key '%s' has an invalid cluster", key.Name)
+			}
+			p.clusters = append(p.clusters, identifier)
+		}
+		principals = append(principals, p)
+	}
+
+	publicPaths := make(map[string]struct{})
+	for _, path := range paths.Public {
+		publicPaths[path] = struct{}{}
+	}
+
+	clusterFilteredPaths := make(map[string]struct{})
+	for _, path := range paths.ClusterFiltered {
+		clusterFilteredPaths[path] = struct{}{}
+	}
+
+	return Middleware{
+		principals:           principals,
+		router:               router,
+		publicPaths:          publicPaths,
+		metricsPath:          paths.Metrics,
+		clusterFilteredPaths: clusterFilteredPaths,
+	}, nil
+}
+
+func (middleware Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if _, ok := middleware.publicPaths[r.URL.Path]; ok {
+		next(w, r)
+		return
+	}
+
+	key := credentials(r)
+	if key == "" {
+		unauthorized(w, missingKeyErrMsg)
+		return
+	}
+	p, ok := middleware.authenticate(key)
+	if !ok {
+		unauthorized(w, invalidKeyErrMsg)
+		return
+	}
+
+	scope := middleware.scope(r)
+	if _, ok := p.scopes[scope]; !ok {
+		http.Error(w, fmt.Sprintf(missingScopeErrMsg, scope), http.StatusForbidden)
+		return
+	}
+
+	if len(p.clusters) > 0 && scope != MetricsScope {
+		clusters, ok := middleware.clusters(r)
+		if !ok {
+			http.Error(w, fmt.Sprintf(unfilteredPathErrMsg, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		if len(clusters) == 0 {
+			http.Error(w, missingClusterErrMsg, http.StatusForbidden)
+			return
+		}
+		for _, cluster := range clusters {
+			if !p.allows(cluster) {
+				http.Error(w, fmt.Sprintf(forbiddenClusterErrMsg, cluster), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	next(w, r)
+}
+
+// credentials returns the API key of a request, sent either as a bearer token
+// or in the X-API-Key header
+func credentials(r *http.Request) string {
+	if authorization := r.Header.Get(authorizationKey); strings.HasPrefix(authorization, bearerPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix))
+	}
+	return r.Header.Get(apiKeyKey)
+}
+
+// authenticate finds the principal with API key 'key', comparing keys in
+// constant time so that keys cannot be guessed from response times
+func (middleware Middleware) authenticate(key string) (principal, bool) {
+	var found principal
+	ok := false
+	for _, p := range middleware.principals {
+		if subtle.ConstantTimeCompare(p.key, []byte(key)) == 1 {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+func (middleware Middleware) scope(r *http.Request) string {
+	switch {
+	case r.URL.Path == middleware.metricsPath:
+		return MetricsScope
+	case strings.HasPrefix(r.URL.Path, streamPathPrefix):
+		return StreamScope
+	default:
+		return ReadScope
+	}
+}
+
+// clusters returns the clusters a request specifies, in its path or as
+// cluster filters. It returns false if the request can specify neither, in
+// which case its response is not restricted to any cluster.
+func (middleware Middleware) clusters(r *http.Request) ([]string, bool) {
+	var match mux.RouteMatch
+	if middleware.router.Match(r, &match) {
+		if cluster, ok := match.Vars[clusterKey]; ok {
+			return []string{cluster}, true
+		}
+	}
+	if _, ok := middleware.clusterFilteredPaths[r.URL.Path]; !ok {
+		return nil, false
+	}
+	return r.URL.Query()[clusterKey], true
+}
+
+// allows returns whether the principal may access cluster 'cluster'. A
+// cluster is allowed if an allowed cluster of the same name does not restrict
+// the account and region or restricts them to those of the cluster.
+func (p principal) allows(cluster string) bool {
+	requested, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range p.clusters {
+		if allowed.Name == requested.Name &&
+			(allowed.Account == "" || allowed.Account == requested.Account) &&
+			(allowed.Region == "" || allowed.Region == requested.Region) {
+			return true
+		}
+	}
+	return false
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set(authenticateKey, authenticateVal)
+	http.Error(w, msg, http.StatusUnauthorized)
+}
@@ -0,0 +1,208 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	readKey       = "read-key"
+	streamKey     = "stream-key"
+	restrictedKey = "restricted-key"
+	metricsPath   = "/metrics"
+	healthPath    = "/healthz"
+	prodARN       = "arn:aws:ecs:us-east-1:123456789012:cluster/prod"
+	prodWestARN   = "arn:aws:ecs:us-west-2:123456789012:cluster/prod"
+)
+
+var testKeys = []Key{
+	{Name: "reader", Key: readKey, Scopes: []string{ReadScope}},
+	{Name: "streamer", Key: streamKey, Scopes: []string{ReadScope, StreamScope, MetricsScope}},
+	{Name: "prod", Key: restrictedKey, Scopes: []string{ReadScope, StreamScope, MetricsScope}, Clusters: []string{"us-east-1:prod", "staging"}},
+}
+
+func testRouter() *mux.Router {
+	return v1.NewRouter(v1.APIs{})
+}
+
+func testPaths() Paths {
+	return Paths{Public: []string{healthPath}, Metrics: metricsPath, ClusterFiltered: v1.ClusterFilteredPaths()}
+}
+
+func serve(t *testing.T, path string, header string, key string) (int, bool) {
+	middleware, err := NewMiddleware(testKeys, testRouter(), testPaths())
+	assert.Nil(t, err, "Unexpected error initializing middleware")
+
+	request := httptest.NewRequest("GET", path, nil)
+	if header != "" {
+		request.Header.Set(header, key)
+	}
+	recorder := httptest.NewRecorder()
+	served := false
+	middleware.ServeHTTP(recorder, request, func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+	return recorder.Code, served
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "css-auth")
+	assert.Nil(t, err, "Unexpected error creating directory")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+keys:
+- name: dashboard
+  key: secret
+  scopes: [read, stream]
+  clusters: [prod]
+`), 0600)
+	assert.Nil(t, err, "Unexpected error writing keys file")
+
+	keys, err := LoadKeys(path)
+	assert.Nil(t, err, "Unexpected error loading keys")
+	assert.Equal(t, []Key{{Name: "dashboard", Key: "secret", Scopes: []string{ReadScope, StreamScope}, Clusters: []string{"prod"}}}, keys, "Unexpected keys loaded")
+
+	_, err = LoadKeys(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err, "Expected an error loading a missing keys file")
+}
+
+func TestNewMiddlewareInvalidKeys(t *testing.T) {
+	invalidKeys := map[string][]Key{
+		"no keys":           nil,
+		"no name":           {{Key: readKey, Scopes: []string{ReadScope}}},
+		"empty key":         {{Name: "reader", Scopes: []string{ReadScope}}},
+		"duplicate key":     {{Name: "a", Key: readKey}, {Name: "b", Key: readKey}},
+		"unsupported scope": {{Name: "writer", Key: readKey, Scopes: []string{"write"}}},
+		"invalid cluster":   {{Name: "reader", Key: readKey, Clusters: []string{"not/a/cluster"}}},
+	}
+	for name, keys := range invalidKeys {
+		_, err := NewMiddleware(keys, testRouter(), Paths{})
+		assert.Error(t, err, "Expected an error initializing middleware with %s", name)
+	}
+}
+
+func TestMiddlewareAuthenticates(t *testing.T) {
+	code, served := serve(t, "/v1/tasks", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code, "Expected requests without a key to be unauthorized")
+	assert.False(t, served, "Unexpected request served")
+
+	code, served = serve(t, "/v1/tasks", apiKeyKey, "unknown-key")
+	assert.Equal(t, http.StatusUnauthorized, code, "Expected requests with an unknown key to be unauthorized")
+	assert.False(t, served, "Unexpected request served")
+
+	_, served = serve(t, "/v1/tasks", authorizationKey, bearerPrefix+readKey)
+	assert.True(t, served, "Expected requests with a bearer token to be served")
+
+	_, served = serve(t, "/v1/tasks", apiKeyKey, readKey)
+	assert.True(t, served, "Expected requests with an API key to be served")
+
+	_, served = serve(t, healthPath, "", "")
+	assert.True(t, served, "Expected requests to public paths to be served without a key")
+}
+
+func TestMiddlewareAuthorizesScopes(t *testing.T) {
+	code, served := serve(t, "/v1/stream/tasks", apiKeyKey, readKey)
+	assert.Equal(t, http.StatusForbidden, code, "Expected streams to require the stream scope")
+	assert.False(t, served, "Unexpected request served")
+
+	code, served = serve(t, metricsPath, apiKeyKey, readKey)
+	assert.Equal(t, http.StatusForbidden, code, "Expected metrics to require the metrics scope")
+	assert.False(t, served, "Unexpected request served")
+
+	_, served = serve(t, "/v1/stream/tasks", apiKeyKey, streamKey)
+	assert.True(t, served, "Expected streams to be served with the stream scope")
+
+	_, served = serve(t, metricsPath, apiKeyKey, streamKey)
+	assert.True(t, served, "Expected metrics to be served with the metrics scope")
+}
+
+func TestMiddlewareAuthorizesClusters(t *testing.T) {
+	allowed := []string{
+		"/v1/tasks/" + prodARN + "/arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5",
+		"/v1/tasks?cluster=us-east-1:prod",
+		"/v1/tasks?cluster=" + prodARN,
+		"/v1/tasks?cluster=staging",
+		"/v1/tasks?cluster=" + prodWestARN[:len(prodWestARN)-len("prod")] + "staging",
+		"/v1/instances?cluster=staging",
+		"/v1/services?cluster=staging",
+		"/v1/endpoints?cluster=staging",
+		"/v1/stream/endpoints?cluster=staging",
+		"/v1/sd/prometheus?cluster=staging",
+		"/v1/clusters/staging",
+		metricsPath,
+	}
+	for _, path := range allowed {
+		_, served := serve(t, path, apiKeyKey, restrictedKey)
+		assert.True(t, served, "Expected request to %s to be served", path)
+	}
+
+	forbidden := []string{
+		"/v1/tasks",
+		"/v1/tasks?cluster=prod",
+		"/v1/tasks?cluster=" + prodWestARN,
+		"/v1/tasks?cluster=us-east-1:prod&cluster=dev",
+		"/v1/tasks/" + prodWestARN + "/arn:aws:ecs:us-west-2:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5",
+	}
+	for _, path := range forbidden {
+		code, served := serve(t, path, apiKeyKey, restrictedKey)
+		assert.Equal(t, http.StatusForbidden, code, "Expected request to %s to be forbidden", path)
+		assert.False(t, served, "Unexpected request to %s served", path)
+	}
+}
+
+func TestMiddlewareForbidsRoutesWithoutClusterFilter(t *testing.T) {
+	unfiltered := []string{
+		"/v1/clusters?cluster=staging",
+		"/v1/stream/clusters?cluster=staging",
+		"/v1/stream/tasks?cluster=staging",
+		"/v1/stream/instances?cluster=staging",
+		"/v1/stream/services?cluster=staging",
+		"/v1/taskdefinitions?cluster=staging",
+		"/v1/changes?cluster=staging",
+	}
+	for _, path := range unfiltered {
+		code, served := serve(t, path, apiKeyKey, restrictedKey)
+		assert.Equal(t, http.StatusForbidden, code, "Expected request to %s to be forbidden", path)
+		assert.False(t, served, "Unexpected request to %s served", path)
+
+		_, served = serve(t, path, apiKeyKey, streamKey)
+		assert.True(t, served, "Expected request to %s to be served with an unrestricted key", path)
+	}
+}
+
+func TestMiddlewareAuthorizesPlacementCluster(t *testing.T) {
+	router := testRouter()
+	middleware, err := NewMiddleware(testKeys, router, testPaths())
+	assert.Nil(t, err, "Unexpected error initializing middleware")
+
+	request := httptest.NewRequest("POST", "/v1/placement/evaluate?cluster=us-east-1:prod",
+		strings.NewReader(`{"cluster":"dev","resources":{"cpu":128,"memory":256}}`))
+	request.Header.Set(apiKeyKey, restrictedKey)
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, request, router.ServeHTTP)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code, "Expected evaluating placement in a cluster other than the authorized one to be denied")
+}
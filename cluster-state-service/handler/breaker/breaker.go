@@ -0,0 +1,159 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package breaker implements a circuit breaker that stops requests to a
+// dependency after it failed repeatedly, and probes the dependency until it
+// recovers.
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// Closed lets requests through
+	Closed State = iota
+	// Open stops requests until a probe of the dependency succeeds
+	Open
+	// HalfOpen lets requests through after a probe succeeded. The next
+	// request closes the breaker if it succeeds and opens it if it fails.
+	HalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Probe returns an error if the dependency guarded by a breaker is still unavailable
+type Probe func() error
+
+// Breaker is a circuit breaker. It opens after 'maxFailures' consecutive
+// failures and, while open, probes the dependency every 'probeInterval'.
+type Breaker struct {
+	lock          sync.RWMutex
+	name          string
+	state         State
+	failures      int
+	maxFailures   int
+	probe         Probe
+	probeInterval time.Duration
+	onChange      func(State)
+}
+
+// New initializes a closed breaker guarding dependency 'name'. 'onChange' is
+// called with the new state whenever the state changes, and may be nil.
+func New(name string, maxFailures int, probe Probe, probeInterval time.Duration, onChange func(State)) (*Breaker, error) {
+	if maxFailures < 1 {
+		return nil, errors.Errorf("The maximum number of failures has to be positive")
+	}
+	if probe == nil {
+		return nil, errors.Errorf("The probe is not initialized")
+	}
+	if probeInterval <= 0 {
+		return nil, errors.Errorf("The probe interval has to be positive")
+	}
+	if onChange == nil {
+		onChange = func(State) {}
+	}
+	return &Breaker{
+		name:          name,
+		maxFailures:   maxFailures,
+		probe:         probe,
+		probeInterval: probeInterval,
+		onChange:      onChange,
+	}, nil
+}
+
+// State returns the state of the breaker
+func (breaker *Breaker) State() State {
+	breaker.lock.RLock()
+	defer breaker.lock.RUnlock()
+	return breaker.state
+}
+
+// Allow returns true if requests may be sent to the dependency
+func (breaker *Breaker) Allow() bool {
+	return breaker.State() != Open
+}
+
+// Success records a request that succeeded, closing the breaker
+func (breaker *Breaker) Success() {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+	breaker.failures = 0
+	breaker.setState(Closed)
+}
+
+// Failure records a request that failed because the dependency is
+// unavailable, opening the breaker if it failed too many times in a row
+func (breaker *Breaker) Failure() {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+	breaker.failures++
+	if breaker.state == HalfOpen || breaker.failures >= breaker.maxFailures {
+		breaker.setState(Open)
+	}
+}
+
+// Run probes the dependency while the breaker is open until 'ctx' is done.
+// The breaker is half-open once a probe succeeds.
+func (breaker *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(breaker.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if breaker.State() != Open {
+				continue
+			}
+			err := breaker.probe()
+			if err != nil {
+				log.Debugf("Probe of %s failed: %v", breaker.name, err)
+				continue
+			}
+			breaker.lock.Lock()
+			if breaker.state == Open {
+				breaker.setState(HalfOpen)
+			}
+			breaker.lock.Unlock()
+		}
+	}
+}
+
+// setState changes the state of the breaker. The lock has to be held.
+func (breaker *Breaker) setState(state State) {
+	if breaker.state == state {
+		return
+	}
+	log.Infof("Circuit breaker of %s is %s, it was %s", breaker.name, state, breaker.state)
+	breaker.state = state
+	breaker.onChange(state)
+}
@@ -0,0 +1,94 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testProbeInterval = 10 * time.Millisecond
+
+func TestNewInvalidArguments(t *testing.T) {
+	probe := func() error { return nil }
+	_, err := New("etcd", 0, probe, time.Second, nil)
+	assert.Error(t, err, "Expected an error when the maximum number of failures is not positive")
+	_, err = New("etcd", 1, nil, time.Second, nil)
+	assert.Error(t, err, "Expected an error when the probe is nil")
+	_, err = New("etcd", 1, probe, 0, nil)
+	assert.Error(t, err, "Expected an error when the probe interval is not positive")
+}
+
+func TestBreakerOpensAfterMaxFailures(t *testing.T) {
+	var states []State
+	breaker, err := New("etcd", 2, func() error { return nil }, time.Second, func(state State) {
+		states = append(states, state)
+	})
+	assert.Nil(t, err, "Unexpected error creating breaker")
+
+	breaker.Failure()
+	assert.True(t, breaker.Allow(), "Expected breaker to stay closed after one failure")
+	breaker.Success()
+	breaker.Failure()
+	assert.True(t, breaker.Allow(), "Expected success to reset consecutive failures")
+	breaker.Failure()
+	assert.False(t, breaker.Allow(), "Expected breaker to open after two failures in a row")
+	assert.Equal(t, []State{Open}, states, "Unexpected state changes")
+}
+
+func TestBreakerProbesWhileOpen(t *testing.T) {
+	var lock sync.Mutex
+	probeErr := errors.New("etcd is down")
+	probe := func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		return probeErr
+	}
+	breaker, err := New("etcd", 1, probe, testProbeInterval, nil)
+	assert.Nil(t, err, "Unexpected error creating breaker")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go breaker.Run(ctx)
+
+	breaker.Failure()
+	time.Sleep(5 * testProbeInterval)
+	assert.Equal(t, Open, breaker.State(), "Expected breaker to stay open while the probe fails")
+
+	lock.Lock()
+	probeErr = nil
+	lock.Unlock()
+	assert.True(t, waitForState(breaker, HalfOpen), "Expected breaker to be half-open once the probe succeeds")
+
+	breaker.Failure()
+	assert.Equal(t, Open, breaker.State(), "Expected a failure to open a half-open breaker")
+	assert.True(t, waitForState(breaker, HalfOpen), "Expected breaker to be half-open once the probe succeeds")
+	breaker.Success()
+	assert.Equal(t, Closed, breaker.State(), "Expected a success to close a half-open breaker")
+}
+
+func waitForState(breaker *Breaker, state State) bool {
+	for i := 0; i < 100; i++ {
+		if breaker.State() == state {
+			return true
+		}
+		time.Sleep(testProbeInterval)
+	}
+	return false
+}
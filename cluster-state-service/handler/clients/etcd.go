@@ -14,14 +14,81 @@
 package clients
 
 import (
+	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
+	log "github.com/cihub/seelog"
 	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/pkg/tlsutil"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
+const (
+	// keepAliveKey is read to keep the connection to etcd busy. It does not
+	// need to exist, nor does the user need permission to read it.
+	keepAliveKey = "keepalive"
+)
+
+// EtcdConfig represents how to connect and authenticate to etcd
+type EtcdConfig struct {
+	// Endpoints are the etcd node addresses
+	Endpoints []string
+	// DialTimeout is how long to wait for a connection
+	DialTimeout time.Duration
+
+	// CAFile verifies the certificates of the etcd nodes. The system's
+	// certificate authorities are used if it is empty.
+	CAFile string
+	// CertFile and KeyFile are the client certificate presented to etcd
+	CertFile string
+	KeyFile  string
+
+	// Username and Password authenticate to etcd with RBAC
+	Username string
+	Password string
+
+	// AutoSyncInterval is how often the endpoints are updated with the
+	// cluster's members. 0 disables auto-sync.
+	AutoSyncInterval time.Duration
+	// KeepAliveInterval is how often an idle connection is probed. 0 disables
+	// keepalive.
+	KeepAliveInterval time.Duration
+	// KeepAliveTimeout is how long to wait for a response to a probe
+	KeepAliveTimeout time.Duration
+}
+
+// tlsEnabled returns true if any of the TLS files is set
+func (cfg EtcdConfig) tlsEnabled() bool {
+	return cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != ""
+}
+
+// tlsConfig builds the TLS configuration of the client
+func (cfg EtcdConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pool, err := tlsutil.NewCertPool([]string{cfg.CAFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not load etcd CA file '%s'", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("Etcd client certificate and key have to be set together")
+		}
+		cert, err := tlsutil.NewCert(cfg.CertFile, cfg.KeyFile, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not load etcd client certificate '%s'", cfg.CertFile)
+		}
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+	return tlsConfig, nil
+}
+
 // EtcdInterface defines etcd methods that are used in the project to enable mocking
 type EtcdInterface interface {
 	// Close shuts down the client's etcd connections.
@@ -52,28 +119,75 @@ type EtcdInterface interface {
 
 	// Delete deletes a key, or optionally using WithRange(end), [key, end).
 	Delete(ctx context.Context, key string, opts ...etcd.OpOption) (*etcd.DeleteResponse, error)
+
+	// Txn creates a transaction, whose operations are applied atomically if
+	// all of its comparisons succeed.
+	Txn(ctx context.Context) etcd.Txn
 }
 
 var _ EtcdInterface = (*etcd.Client)(nil)
 
-const (
-	dialTimeout = 5 * time.Second
-)
-
-// NewEtcdClient initializes an etcd client
-func NewEtcdClient(endpoints []string) (*etcd.Client, error) {
+// NewEtcdClient initializes an etcd client with the connection,
+// authentication and keepalive settings in 'cfg'
+func NewEtcdClient(cfg EtcdConfig) (*etcd.Client, error) {
 	//TODO: attach a lease TTL
-	if len(endpoints) == 0 {
+	if len(cfg.Endpoints) == 0 {
 		return nil, fmt.Errorf("etcd endpoints cannot be empty")
 	}
-	etcd, err := etcd.New(etcd.Config{
-		Endpoints:   endpoints,
-		DialTimeout: dialTimeout,
-	})
+	if cfg.Password != "" && cfg.Username == "" {
+		return nil, errors.New("Etcd password cannot be set without a username")
+	}
+	etcdConfig := etcd.Config{
+		Endpoints:        cfg.Endpoints,
+		DialTimeout:      cfg.DialTimeout,
+		AutoSyncInterval: cfg.AutoSyncInterval,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+	}
+	if cfg.tlsEnabled() {
+		for _, endpoint := range cfg.Endpoints {
+			if strings.HasPrefix(endpoint, "http://") {
+				return nil, errors.Errorf("Etcd endpoint '%s' is not https although etcd TLS is configured", endpoint)
+			}
+		}
+		tlsConfig, err := cfg.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		etcdConfig.TLS = tlsConfig
+	}
 
+	client, err := etcd.New(etcdConfig)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Etcd connection error connecting to '%v'", endpoints)
+		return nil, errors.Wrapf(err, "Etcd connection error connecting to '%v'", cfg.Endpoints)
+	}
+
+	if cfg.KeepAliveInterval > 0 {
+		go keepAlive(client, cfg.KeepAliveInterval, cfg.KeepAliveTimeout)
 	}
 
-	return etcd, nil
+	return client, nil
+}
+
+// keepAlive reads 'keepAliveKey' every 'interval' until the client is closed.
+// The client in use has no gRPC keepalive, so the read keeps idle connections
+// from being dropped by load balancers and firewalls and makes the client
+// notice and redial broken ones.
+func keepAlive(client *etcd.Client, interval time.Duration, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.Ctx().Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(client.Ctx(), timeout)
+			_, err := client.Get(ctx, keepAliveKey, etcd.WithCountOnly())
+			cancel()
+			// A permission error is a response all the same
+			if err != nil && err != rpctypes.ErrPermissionDenied && client.Ctx().Err() == nil {
+				log.Warnf("Etcd keepalive failed: %v", err)
+			}
+		}
+	}
 }
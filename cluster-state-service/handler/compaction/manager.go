@@ -0,0 +1,232 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compaction
+
+import (
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/goguardian/blox/cluster-state-service/handler/election"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	// leaderKeyPrefix is the prefix of the election that decides which
+	// instance compacts etcd
+	leaderKeyPrefix = "css/compaction/leader"
+	// defragmentTimeout bounds defragmenting a single etcd member, which
+	// rewrites its whole database
+	defragmentTimeout = 10 * time.Minute
+)
+
+// Retention defines the etcd revision history that is kept when compacting.
+// Revisions that are within either window are kept. A zero value disables
+// the corresponding window.
+type Retention struct {
+	Revisions int64
+	Age       time.Duration
+}
+
+// IsEnabled returns true if at least one window of revisions is kept
+func (retention Retention) IsEnabled() bool {
+	return retention.Revisions > 0 || retention.Age > 0
+}
+
+// Schedule defines how often etcd is compacted and defragmented. A zero
+// defragmentation interval disables defragmentation.
+type Schedule struct {
+	CompactionInterval      time.Duration
+	DefragmentationInterval time.Duration
+}
+
+// etcdMaintainer is the part of the etcd client that compacts and
+// defragments etcd
+type etcdMaintainer interface {
+	Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error)
+	Defragment(ctx context.Context, endpoint string) (*clientv3.DefragmentResponse, error)
+	Endpoints() []string
+}
+
+// revisionSample is the current etcd revision at a point in time
+type revisionSample struct {
+	at       time.Time
+	revision int64
+}
+
+// Manager compacts the etcd revision history down to its retention and
+// defragments etcd members on a schedule. Instances elect a leader through
+// etcd so that only one of them compacts and defragments at a time.
+type Manager struct {
+	etcd           etcdMaintainer
+	revisionStore  store.RevisionStore
+	campaign       election.Campaign
+	retention      Retention
+	schedule       Schedule
+	requestTimeout time.Duration
+	now            func() time.Time
+
+	// samples are the revisions observed while leading, oldest first, that
+	// the revision to compact to is looked up in when retaining an age
+	samples []revisionSample
+}
+
+// NewManager initializes a manager that compacts and defragments etcd through
+// 'client' while this instance leads compaction
+func NewManager(client *clientv3.Client, revisionStore store.RevisionStore, retention Retention, schedule Schedule, requestTimeout time.Duration) (*Manager, error) {
+	if client == nil {
+		return nil, errors.New("Etcd client is not initialized")
+	}
+	return newManager(client, revisionStore, election.NewCampaign(client, leaderKeyPrefix), retention, schedule, requestTimeout)
+}
+
+func newManager(etcd etcdMaintainer, revisionStore store.RevisionStore, campaign election.Campaign, retention Retention, schedule Schedule, requestTimeout time.Duration) (*Manager, error) {
+	if revisionStore == nil {
+		return nil, errors.New("Revision store is not initialized")
+	}
+	if retention.Revisions < 0 || retention.Age < 0 || !retention.IsEnabled() {
+		return nil, errors.Errorf("Invalid retention specified for compaction: %+v", retention)
+	}
+	if schedule.CompactionInterval <= 0 || schedule.DefragmentationInterval < 0 {
+		return nil, errors.Errorf("Invalid schedule specified for compaction: %+v", schedule)
+	}
+	if requestTimeout <= 0 {
+		return nil, errors.New("Etcd request timeout has to be positive")
+	}
+	return &Manager{
+		etcd:           etcd,
+		revisionStore:  revisionStore,
+		campaign:       campaign,
+		retention:      retention,
+		schedule:       schedule,
+		requestTimeout: requestTimeout,
+		now:            time.Now,
+	}, nil
+}
+
+// Run campaigns to lead compaction and compacts and defragments etcd while
+// leading, until 'ctx' is done
+func (manager *Manager) Run(ctx context.Context) {
+	election.Lead(ctx, manager.campaign, "etcd compaction", func(lost <-chan struct{}) {
+		manager.lead(ctx, lost)
+	})
+}
+
+// lead compacts and defragments etcd until 'ctx' is done or leadership is lost
+func (manager *Manager) lead(ctx context.Context, lost <-chan struct{}) {
+	manager.samples = nil
+	compactions := time.NewTicker(manager.schedule.CompactionInterval)
+	defer compactions.Stop()
+	var defragmentations <-chan time.Time
+	if manager.schedule.DefragmentationInterval > 0 {
+		ticker := time.NewTicker(manager.schedule.DefragmentationInterval)
+		defer ticker.Stop()
+		defragmentations = ticker.C
+	}
+
+	for {
+		select {
+		case <-compactions.C:
+			if err := manager.Compact(ctx); err != nil {
+				log.Warnf("Could not compact etcd: %+v", err)
+			}
+		case <-defragmentations:
+			manager.Defragment(ctx)
+		case <-lost:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Compact compacts etcd to the oldest revision that has to be retained, if it
+// has not been compacted that far yet
+func (manager *Manager) Compact(ctx context.Context) error {
+	revisions, err := manager.revisionStore.GetRevisionRange()
+	if err != nil {
+		return err
+	}
+	now := manager.now()
+	if manager.retention.Age > 0 {
+		manager.samples = append(manager.samples, revisionSample{at: now, revision: revisions.Current})
+	}
+
+	revision, ok := manager.retainedRevision(now, revisions.Current)
+	if !ok || revision <= revisions.Oldest {
+		return nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, manager.requestTimeout)
+	defer cancel()
+	_, err = manager.etcd.Compact(reqCtx, revision, clientv3.WithCompactPhysical())
+	if err == rpctypes.ErrCompacted {
+		// compacted further by another client in the meantime
+		return nil
+	}
+	metrics.ObserveCompaction(revision, err)
+	if err != nil {
+		return errors.Wrapf(err, "Could not compact etcd to revision %d", revision)
+	}
+	log.Infof("Compacted etcd to revision %d", revision)
+	return nil
+}
+
+// retainedRevision returns the oldest revision within the retention at 'now'.
+// It returns false while the revision at the start of the retained age is
+// not known yet.
+func (manager *Manager) retainedRevision(now time.Time, current int64) (int64, bool) {
+	revision := current
+	if manager.retention.Revisions > 0 {
+		revision = current - manager.retention.Revisions
+	}
+
+	if manager.retention.Age > 0 {
+		// the newest sample taken before the retained age started was the
+		// current revision when it started, and everything after it is kept
+		cutoff := now.Add(-manager.retention.Age)
+		i := 0
+		for i < len(manager.samples) && !manager.samples[i].at.After(cutoff) {
+			i++
+		}
+		if i == 0 {
+			return 0, false
+		}
+		if sampled := manager.samples[i-1].revision; sampled < revision {
+			revision = sampled
+		}
+		manager.samples = manager.samples[i-1:]
+	}
+	return revision, revision > 0
+}
+
+// Defragment defragments the etcd members one at a time, since each of them
+// stops serving requests while it is defragmented
+func (manager *Manager) Defragment(ctx context.Context) {
+	for _, endpoint := range manager.etcd.Endpoints() {
+		reqCtx, cancel := context.WithTimeout(ctx, defragmentTimeout)
+		_, err := manager.etcd.Defragment(reqCtx, endpoint)
+		cancel()
+		metrics.ObserveDefragmentation(endpoint, err)
+		if err != nil {
+			log.Warnf("Could not defragment etcd member '%s': %+v", endpoint, err)
+			continue
+		}
+		log.Infof("Defragmented etcd member '%s'", endpoint)
+	}
+}
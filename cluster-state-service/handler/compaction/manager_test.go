@@ -0,0 +1,215 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compaction
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var testSchedule = Schedule{CompactionInterval: time.Minute, DefragmentationInterval: time.Hour}
+
+type fakeEtcdMaintainer struct {
+	lock          sync.Mutex
+	endpoints     []string
+	compactErr    error
+	compactions   []int64
+	defragmentErr map[string]error
+	defragmented  []string
+}
+
+func (etcd *fakeEtcdMaintainer) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	etcd.lock.Lock()
+	defer etcd.lock.Unlock()
+	if etcd.compactErr != nil {
+		return nil, etcd.compactErr
+	}
+	etcd.compactions = append(etcd.compactions, rev)
+	return &clientv3.CompactResponse{}, nil
+}
+
+func (etcd *fakeEtcdMaintainer) Defragment(ctx context.Context, endpoint string) (*clientv3.DefragmentResponse, error) {
+	etcd.lock.Lock()
+	defer etcd.lock.Unlock()
+	etcd.defragmented = append(etcd.defragmented, endpoint)
+	return &clientv3.DefragmentResponse{}, etcd.defragmentErr[endpoint]
+}
+
+func (etcd *fakeEtcdMaintainer) Endpoints() []string {
+	return etcd.endpoints
+}
+
+func (etcd *fakeEtcdMaintainer) compacted() []int64 {
+	etcd.lock.Lock()
+	defer etcd.lock.Unlock()
+	return append([]int64(nil), etcd.compactions...)
+}
+
+func noCampaign(ctx context.Context) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("Not campaigning")
+}
+
+func newTestManager(t *testing.T, retention Retention) (*Manager, *fakeEtcdMaintainer, *mocks.MockRevisionStore) {
+	etcd := &fakeEtcdMaintainer{}
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	manager, err := newManager(etcd, revisionStore, noCampaign, retention, testSchedule, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the manager")
+	return manager, etcd, revisionStore
+}
+
+func TestNewManagerNilClient(t *testing.T) {
+	_, err := NewManager(nil, mocks.NewMockRevisionStore(gomock.NewController(t)), Retention{Revisions: 1}, testSchedule, time.Minute)
+	assert.Error(t, err, "Expected an error when the etcd client is nil")
+}
+
+func TestNewManagerInvalidSettings(t *testing.T) {
+	revisionStore := mocks.NewMockRevisionStore(gomock.NewController(t))
+	etcd := &fakeEtcdMaintainer{}
+
+	_, err := newManager(etcd, nil, noCampaign, Retention{Revisions: 1}, testSchedule, time.Minute)
+	assert.Error(t, err, "Expected an error when the revision store is nil")
+	_, err = newManager(etcd, revisionStore, noCampaign, Retention{}, testSchedule, time.Minute)
+	assert.Error(t, err, "Expected an error when nothing is retained")
+	_, err = newManager(etcd, revisionStore, noCampaign, Retention{Revisions: -1, Age: time.Hour}, testSchedule, time.Minute)
+	assert.Error(t, err, "Expected an error when the retained revisions are negative")
+	_, err = newManager(etcd, revisionStore, noCampaign, Retention{Revisions: 1}, Schedule{}, time.Minute)
+	assert.Error(t, err, "Expected an error when the compaction interval is not set")
+	_, err = newManager(etcd, revisionStore, noCampaign, Retention{Revisions: 1}, testSchedule, 0)
+	assert.Error(t, err, "Expected an error when the request timeout is not set")
+}
+
+func TestCompactRetainsRevisions(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 30})
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: 100}, nil)
+
+	err := manager.Compact(context.Background())
+	assert.Nil(t, err, "Unexpected error compacting")
+	assert.Equal(t, []int64{70}, etcd.compacted(), "Expected the last 30 revisions to be retained")
+}
+
+func TestCompactAlreadyCompacted(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 30})
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 80, Current: 100}, nil)
+
+	err := manager.Compact(context.Background())
+	assert.Nil(t, err, "Unexpected error compacting")
+	assert.Empty(t, etcd.compacted(), "Expected no compaction when etcd is compacted past the retention")
+}
+
+func TestCompactTooFewRevisions(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 30})
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: 20}, nil)
+
+	err := manager.Compact(context.Background())
+	assert.Nil(t, err, "Unexpected error compacting")
+	assert.Empty(t, etcd.compacted(), "Expected no compaction when all revisions are retained")
+}
+
+func TestCompactRetainsAge(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Age: time.Hour})
+	start := time.Now()
+	compactAt := func(offset time.Duration, current int64) {
+		manager.now = func() time.Time { return start.Add(offset) }
+		revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: current}, nil)
+		err := manager.Compact(context.Background())
+		assert.Nil(t, err, "Unexpected error compacting")
+	}
+
+	compactAt(0, 100)
+	compactAt(30*time.Minute, 150)
+	assert.Empty(t, etcd.compacted(), "Expected no compaction before the retained age has passed")
+
+	compactAt(61*time.Minute, 200)
+	compactAt(95*time.Minute, 250)
+	assert.Equal(t, []int64{100, 150}, etcd.compacted(), "Expected the revisions of the last hour to be retained")
+}
+
+func TestCompactRetainsBothWindows(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 10, Age: time.Hour})
+	start := time.Now()
+	manager.samples = []revisionSample{{at: start.Add(-2 * time.Hour), revision: 50}}
+	manager.now = func() time.Time { return start }
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: 55}, nil)
+
+	err := manager.Compact(context.Background())
+	assert.Nil(t, err, "Unexpected error compacting")
+	assert.Equal(t, []int64{45}, etcd.compacted(), "Expected the larger window to be retained")
+}
+
+func TestCompactErrors(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 30})
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{}, errors.New("Get failed"))
+	err := manager.Compact(context.Background())
+	assert.Error(t, err, "Expected an error when the revisions cannot be read")
+
+	etcd.compactErr = errors.New("Compact failed")
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: 100}, nil)
+	err = manager.Compact(context.Background())
+	assert.Error(t, err, "Expected an error when compacting fails")
+}
+
+func TestDefragmentEachMember(t *testing.T) {
+	manager, etcd, _ := newTestManager(t, Retention{Revisions: 30})
+	etcd.endpoints = []string{"etcd1:2379", "etcd2:2379"}
+	etcd.defragmentErr = map[string]error{"etcd1:2379": errors.New("Defragment failed")}
+
+	manager.Defragment(context.Background())
+	assert.Equal(t, etcd.endpoints, etcd.defragmented, "Expected every member to be defragmented")
+}
+
+func TestRunCompactsWhileLeading(t *testing.T) {
+	manager, etcd, revisionStore := newTestManager(t, Retention{Revisions: 30})
+	manager.schedule = Schedule{CompactionInterval: time.Millisecond}
+	revisionStore.EXPECT().GetRevisionRange().Return(storetypes.RevisionRange{Oldest: 1, Current: 100}, nil).AnyTimes()
+
+	lost := make(chan struct{})
+	campaigns := make(chan struct{}, 2)
+	resigned := make(chan struct{}, 2)
+	manager.campaign = func(ctx context.Context) (<-chan struct{}, func(), error) {
+		campaigns <- struct{}{}
+		if len(campaigns) > 1 {
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		}
+		return lost, func() { resigned <- struct{}{} }, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.Run(ctx)
+		close(done)
+	}()
+	for len(etcd.compacted()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(lost)
+	<-resigned
+	for len(campaigns) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	assert.Equal(t, int64(70), etcd.compacted()[0], "Expected etcd to be compacted while leading")
+}
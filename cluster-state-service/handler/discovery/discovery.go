@@ -0,0 +1,262 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package discovery builds the endpoints at which the containers of running
+// tasks can be reached from the network bindings of the tasks and the hosts
+// they run on.
+package discovery
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+)
+
+const (
+	runningStatus = "RUNNING"
+
+	eniAttachmentType         = "ElasticNetworkInterface"
+	eniPrivateIPDetail        = "privateIPv4Address"
+	availabilityZoneAttribute = "ecs.availability-zone"
+
+	defaultProtocol = "tcp"
+)
+
+// Endpoint is a host and port at which a container of a running task can be reached
+type Endpoint struct {
+	ClusterARN           string
+	TaskARN              string
+	TaskDefinitionARN    string
+	Family               string
+	ContainerName        string
+	ContainerPort        int64
+	HostPort             int64
+	Protocol             string
+	IP                   string
+	AvailabilityZone     string
+	ContainerInstanceARN string
+	EC2InstanceID        string
+}
+
+// Filter narrows the endpoints down to the ones of a task definition family,
+// a container and a container port. Empty fields match every endpoint.
+type Filter struct {
+	Family        string
+	Container     string
+	ContainerPort int64
+}
+
+// Host is the address and the availability zone of an EC2 instance
+type Host struct {
+	PrivateIP        string
+	AvailabilityZone string
+}
+
+// Snapshot is the state that the endpoints are built from
+type Snapshot struct {
+	// Tasks to build endpoints for. Tasks that are not running are skipped.
+	Tasks []types.Task
+	// Instances are the container instances the tasks run on, keyed by ARN
+	Instances map[string]types.ContainerInstance
+	// Hosts are the EC2 instances of the container instances, keyed by ID
+	Hosts map[string]Host
+	// TaskDefinitions are the task definitions of the tasks, keyed by ARN.
+	// Only the ones of tasks with their own network interface are required:
+	// the ports of their containers are reachable on the task's private IP
+	// and have no network bindings.
+	TaskDefinitions map[string]types.TaskDefinition
+}
+
+// UsesTaskNetworkInterface returns true if the task has an elastic network
+// interface attached, i.e. uses the awsvpc network mode
+func UsesTaskNetworkInterface(task types.Task) bool {
+	return taskPrivateIP(task) != ""
+}
+
+// IsRunning returns true if the task is running
+func IsRunning(task types.Task) bool {
+	return task.Detail != nil && aws.StringValue(task.Detail.LastStatus) == runningStatus
+}
+
+// BuildEndpoints returns the endpoints of the running containers of the
+// running tasks in the snapshot that match the filter, ordered by task ARN,
+// container name and container port. Endpoints without a known host IP are skipped.
+func BuildEndpoints(snapshot Snapshot, filter Filter) []Endpoint {
+	endpoints := []Endpoint{}
+	for _, task := range snapshot.Tasks {
+		if !IsRunning(task) {
+			continue
+		}
+		taskDefinitionARN := aws.StringValue(task.Detail.TaskDefinitionARN)
+		family, _, err := regex.GetFamilyAndRevisionFromTaskDefinitionARN(taskDefinitionARN)
+		if err != nil || (filter.Family != "" && filter.Family != family) {
+			continue
+		}
+
+		base := Endpoint{
+			ClusterARN:           aws.StringValue(task.Detail.ClusterARN),
+			TaskARN:              aws.StringValue(task.Detail.TaskARN),
+			TaskDefinitionARN:    taskDefinitionARN,
+			Family:               family,
+			AvailabilityZone:     task.Detail.AvailabilityZone,
+			ContainerInstanceARN: aws.StringValue(task.Detail.ContainerInstanceARN),
+		}
+		if instance, ok := snapshot.Instances[base.ContainerInstanceARN]; ok && instance.Detail != nil {
+			base.EC2InstanceID = instance.Detail.EC2InstanceID
+			if base.AvailabilityZone == "" {
+				base.AvailabilityZone = instanceAvailabilityZone(instance)
+			}
+		}
+
+		var taskEndpoints []Endpoint
+		if ip := taskPrivateIP(task); ip != "" {
+			base.IP = ip
+			taskEndpoints = taskNetworkInterfaceEndpoints(base, task, snapshot.TaskDefinitions[taskDefinitionARN])
+		} else {
+			host, ok := snapshot.Hosts[base.EC2InstanceID]
+			if !ok || host.PrivateIP == "" {
+				continue
+			}
+			base.IP = host.PrivateIP
+			if host.AvailabilityZone != "" {
+				base.AvailabilityZone = host.AvailabilityZone
+			}
+			taskEndpoints = networkBindingEndpoints(base, task)
+		}
+
+		for _, endpoint := range taskEndpoints {
+			if filter.Container != "" && filter.Container != endpoint.ContainerName {
+				continue
+			}
+			if filter.ContainerPort != 0 && filter.ContainerPort != endpoint.ContainerPort {
+				continue
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	SortEndpoints(endpoints)
+	return endpoints
+}
+
+// SortEndpoints orders endpoints by task ARN, container name, container port and protocol
+func SortEndpoints(endpoints []Endpoint) {
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.TaskARN != b.TaskARN {
+			return a.TaskARN < b.TaskARN
+		}
+		if a.ContainerName != b.ContainerName {
+			return a.ContainerName < b.ContainerName
+		}
+		if a.ContainerPort != b.ContainerPort {
+			return a.ContainerPort < b.ContainerPort
+		}
+		return a.Protocol < b.Protocol
+	})
+}
+
+// networkBindingEndpoints returns an endpoint for every network binding of the running containers of a task
+func networkBindingEndpoints(base Endpoint, task types.Task) []Endpoint {
+	var endpoints []Endpoint
+	for _, container := range task.Detail.Containers {
+		if container == nil || aws.StringValue(container.LastStatus) != runningStatus {
+			continue
+		}
+		for _, binding := range container.NetworkBindings {
+			if binding == nil || binding.HostPort == nil {
+				continue
+			}
+			endpoint := base
+			endpoint.ContainerName = aws.StringValue(container.Name)
+			endpoint.ContainerPort = aws.Int64Value(binding.ContainerPort)
+			endpoint.HostPort = aws.Int64Value(binding.HostPort)
+			endpoint.Protocol = protocolOrDefault(binding.Protocol)
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// taskNetworkInterfaceEndpoints returns an endpoint for every port mapping of
+// the running containers of a task with its own network interface, on which
+// the host port is the container port
+func taskNetworkInterfaceEndpoints(base Endpoint, task types.Task, taskDefinition types.TaskDefinition) []Endpoint {
+	if taskDefinition.Detail == nil {
+		return nil
+	}
+	running := make(map[string]struct{})
+	for _, container := range task.Detail.Containers {
+		if container != nil && aws.StringValue(container.LastStatus) == runningStatus {
+			running[aws.StringValue(container.Name)] = struct{}{}
+		}
+	}
+
+	var endpoints []Endpoint
+	for _, containerDefinition := range taskDefinition.Detail.ContainerDefinitions {
+		if containerDefinition == nil {
+			continue
+		}
+		name := aws.StringValue(containerDefinition.Name)
+		if _, ok := running[name]; !ok {
+			continue
+		}
+		for _, portMapping := range containerDefinition.PortMappings {
+			if portMapping == nil || portMapping.ContainerPort == nil {
+				continue
+			}
+			endpoint := base
+			endpoint.ContainerName = name
+			endpoint.ContainerPort = aws.Int64Value(portMapping.ContainerPort)
+			endpoint.HostPort = endpoint.ContainerPort
+			endpoint.Protocol = protocolOrDefault(portMapping.Protocol)
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+func taskPrivateIP(task types.Task) string {
+	if task.Detail == nil {
+		return ""
+	}
+	for _, attachment := range task.Detail.Attachments {
+		if attachment == nil || aws.StringValue(attachment.Type) != eniAttachmentType {
+			continue
+		}
+		for _, detail := range attachment.Details {
+			if detail != nil && aws.StringValue(detail.Name) == eniPrivateIPDetail {
+				return aws.StringValue(detail.Value)
+			}
+		}
+	}
+	return ""
+}
+
+func instanceAvailabilityZone(instance types.ContainerInstance) string {
+	for _, attribute := range instance.Detail.Attributes {
+		if attribute != nil && aws.StringValue(attribute.Name) == availabilityZoneAttribute {
+			return aws.StringValue(attribute.Value)
+		}
+	}
+	return ""
+}
+
+func protocolOrDefault(protocol string) string {
+	if protocol == "" {
+		return defaultProtocol
+	}
+	return protocol
+}
@@ -0,0 +1,207 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	clusterARN        = "arn:aws:ecs:us-east-1:123456789012:cluster/default"
+	instanceARN       = "arn:aws:ecs:us-east-1:123456789012:container-instance/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
+	taskARN1          = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	taskARN2          = "arn:aws:ecs:us-east-1:123456789012:task/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
+	webTaskDefinition = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"
+	apiTaskDefinition = "arn:aws:ecs:us-east-1:123456789012:task-definition/api:3"
+	ec2InstanceID     = "i-0123456789abcdef0"
+	hostIP            = "10.0.1.15"
+	hostZone          = "us-east-1a"
+	taskIP            = "10.0.2.42"
+	taskZone          = "us-east-1b"
+)
+
+func bridgeTask(arn string, lastStatus string, containers ...*types.Container) types.Task {
+	return types.Task{
+		Detail: &types.TaskDetail{
+			ClusterARN:           aws.String(clusterARN),
+			ContainerInstanceARN: aws.String(instanceARN),
+			Containers:           containers,
+			LastStatus:           aws.String(lastStatus),
+			TaskARN:              aws.String(arn),
+			TaskDefinitionARN:    aws.String(webTaskDefinition),
+		},
+	}
+}
+
+func container(name string, lastStatus string, bindings ...*types.NetworkBinding) *types.Container {
+	return &types.Container{
+		LastStatus:      aws.String(lastStatus),
+		Name:            aws.String(name),
+		NetworkBindings: bindings,
+	}
+}
+
+func binding(containerPort int64, hostPort int64, protocol string) *types.NetworkBinding {
+	return &types.NetworkBinding{
+		ContainerPort: aws.Int64(containerPort),
+		HostPort:      aws.Int64(hostPort),
+		Protocol:      protocol,
+	}
+}
+
+func snapshot(tasks ...types.Task) Snapshot {
+	return Snapshot{
+		Tasks: tasks,
+		Instances: map[string]types.ContainerInstance{
+			instanceARN: {
+				Detail: &types.InstanceDetail{
+					ContainerInstanceARN: aws.String(instanceARN),
+					EC2InstanceID:        ec2InstanceID,
+				},
+			},
+		},
+		Hosts: map[string]Host{ec2InstanceID: {PrivateIP: hostIP, AvailabilityZone: hostZone}},
+	}
+}
+
+func TestBuildEndpointsFromNetworkBindings(t *testing.T) {
+	task := bridgeTask(taskARN1, runningStatus,
+		container("web", runningStatus, binding(80, 32768, "tcp"), binding(53, 32769, "")),
+		container("sidecar", runningStatus))
+
+	endpoints := BuildEndpoints(snapshot(task), Filter{})
+	expected := Endpoint{
+		ClusterARN:           clusterARN,
+		TaskARN:              taskARN1,
+		TaskDefinitionARN:    webTaskDefinition,
+		Family:               "web",
+		ContainerName:        "web",
+		ContainerPort:        53,
+		HostPort:             32769,
+		Protocol:             "tcp",
+		IP:                   hostIP,
+		AvailabilityZone:     hostZone,
+		ContainerInstanceARN: instanceARN,
+		EC2InstanceID:        ec2InstanceID,
+	}
+	assert.Len(t, endpoints, 2, "Expected an endpoint per network binding")
+	assert.Equal(t, expected, endpoints[0], "Unexpected endpoint")
+	assert.Equal(t, int64(80), endpoints[1].ContainerPort, "Expected endpoints ordered by container port")
+	assert.Equal(t, int64(32768), endpoints[1].HostPort, "Unexpected host port")
+}
+
+func TestBuildEndpointsSkipsStoppedTasksAndContainers(t *testing.T) {
+	stoppedTask := bridgeTask(taskARN1, "STOPPED", container("web", "STOPPED", binding(80, 32768, "tcp")))
+	stoppedContainer := bridgeTask(taskARN2, runningStatus, container("web", "STOPPED", binding(80, 32768, "tcp")))
+
+	endpoints := BuildEndpoints(snapshot(stoppedTask, stoppedContainer), Filter{})
+	assert.Empty(t, endpoints, "Expected no endpoints for stopped tasks and containers")
+}
+
+func TestBuildEndpointsSkipsUnknownHosts(t *testing.T) {
+	task := bridgeTask(taskARN1, runningStatus, container("web", runningStatus, binding(80, 32768, "tcp")))
+	s := snapshot(task)
+	s.Hosts = map[string]Host{}
+
+	endpoints := BuildEndpoints(s, Filter{})
+	assert.Empty(t, endpoints, "Expected no endpoints for tasks on hosts without a known IP")
+}
+
+func TestBuildEndpointsFallsBackToInstanceAvailabilityZoneAttribute(t *testing.T) {
+	task := bridgeTask(taskARN1, runningStatus, container("web", runningStatus, binding(80, 32768, "tcp")))
+	s := snapshot(task)
+	s.Hosts = map[string]Host{ec2InstanceID: {PrivateIP: hostIP}}
+	instance := s.Instances[instanceARN]
+	instance.Detail.Attributes = []*types.Attribute{{Name: aws.String(availabilityZoneAttribute), Value: aws.String(hostZone)}}
+
+	endpoints := BuildEndpoints(s, Filter{})
+	assert.Len(t, endpoints, 1, "Expected an endpoint per network binding")
+	assert.Equal(t, hostZone, endpoints[0].AvailabilityZone, "Expected availability zone from instance attributes")
+}
+
+func TestBuildEndpointsFromTaskNetworkInterface(t *testing.T) {
+	task := types.Task{
+		Detail: &types.TaskDetail{
+			Attachments: []*types.Attachment{
+				{
+					Type: aws.String(eniAttachmentType),
+					Details: []*types.KeyValuePair{
+						{Name: aws.String("networkInterfaceId"), Value: aws.String("eni-0123456789abcdef0")},
+						{Name: aws.String(eniPrivateIPDetail), Value: aws.String(taskIP)},
+					},
+				},
+			},
+			AvailabilityZone:  taskZone,
+			ClusterARN:        aws.String(clusterARN),
+			Containers:        []*types.Container{container("api", runningStatus)},
+			LastStatus:        aws.String(runningStatus),
+			LaunchType:        "FARGATE",
+			TaskARN:           aws.String(taskARN1),
+			TaskDefinitionARN: aws.String(apiTaskDefinition),
+		},
+	}
+	taskDefinition := types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			ContainerDefinitions: []*types.ContainerDefinition{
+				{Name: aws.String("api"), PortMappings: []*types.PortMapping{{ContainerPort: aws.Int64(8080)}}},
+				{Name: aws.String("init"), PortMappings: []*types.PortMapping{{ContainerPort: aws.Int64(9000)}}},
+			},
+			NetworkMode: "awsvpc",
+		},
+	}
+	assert.True(t, UsesTaskNetworkInterface(task), "Expected task to use its own network interface")
+
+	s := Snapshot{
+		Tasks:           []types.Task{task},
+		TaskDefinitions: map[string]types.TaskDefinition{apiTaskDefinition: taskDefinition},
+	}
+	endpoints := BuildEndpoints(s, Filter{})
+	assert.Len(t, endpoints, 1, "Expected an endpoint per port mapping of running containers")
+	assert.Equal(t, Endpoint{
+		ClusterARN:        clusterARN,
+		TaskARN:           taskARN1,
+		TaskDefinitionARN: apiTaskDefinition,
+		Family:            "api",
+		ContainerName:     "api",
+		ContainerPort:     8080,
+		HostPort:          8080,
+		Protocol:          "tcp",
+		IP:                taskIP,
+		AvailabilityZone:  taskZone,
+	}, endpoints[0], "Unexpected endpoint")
+}
+
+func TestBuildEndpointsWithFilter(t *testing.T) {
+	web := bridgeTask(taskARN1, runningStatus,
+		container("web", runningStatus, binding(80, 32768, "tcp"), binding(443, 32769, "tcp")),
+		container("metrics", runningStatus, binding(9100, 32770, "tcp")))
+	api := bridgeTask(taskARN2, runningStatus, container("web", runningStatus, binding(80, 32771, "tcp")))
+	api.Detail.TaskDefinitionARN = aws.String(apiTaskDefinition)
+	s := snapshot(web, api)
+
+	endpoints := BuildEndpoints(s, Filter{Family: "web"})
+	assert.Len(t, endpoints, 3, "Expected endpoints of the family only")
+
+	endpoints = BuildEndpoints(s, Filter{Family: "web", Container: "web"})
+	assert.Len(t, endpoints, 2, "Expected endpoints of the container only")
+
+	endpoints = BuildEndpoints(s, Filter{Container: "web", ContainerPort: 80})
+	assert.Len(t, endpoints, 2, "Expected endpoints of the container port only")
+	assert.Equal(t, taskARN1, endpoints[0].TaskARN, "Expected endpoints ordered by task ARN")
+	assert.Equal(t, taskARN2, endpoints[1].TaskARN, "Expected endpoints ordered by task ARN")
+}
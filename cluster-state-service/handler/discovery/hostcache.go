@@ -0,0 +1,200 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package discovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HostCacheTTL is how long the address of an EC2 instance is cached for.
+	// Private IPs do not change during the lifetime of an instance, so the TTL
+	// only bounds how long terminated instances are kept around. Expired
+	// addresses are removed whenever hosts are resolved.
+	HostCacheTTL = time.Hour
+
+	instanceIDFilter = "instance-id"
+	// describeInstancesBatchSize is the maximum number of values in a DescribeInstances filter
+	describeInstancesBatchSize = 200
+)
+
+// EC2Instance identifies an EC2 instance by its ID and the region it runs in
+type EC2Instance struct {
+	ID     string
+	Region string
+}
+
+// EC2InstanceOf returns the EC2 instance that container instance 'instance'
+// runs on. Its region is the one the container instance is stored under, which
+// is the region in the container instance ARN.
+func EC2InstanceOf(instance types.ContainerInstance) EC2Instance {
+	ec2Instance := EC2Instance{ID: instance.Detail.EC2InstanceID}
+	_, region, err := regex.GetAccountAndRegionFromARN(aws.StringValue(instance.Detail.ContainerInstanceARN))
+	if err == nil {
+		ec2Instance.Region = region
+	}
+	return ec2Instance
+}
+
+// EC2ClientFactory returns an EC2 client for 'region', or for the region of
+// the service if 'region' is empty
+type EC2ClientFactory func(region string) ec2iface.EC2API
+
+// HostResolver defines the interface to resolve the addresses of EC2 instances
+type HostResolver interface {
+	// ResolveHosts returns the hosts of the given EC2 instances, keyed by ID.
+	// Instances that EC2 does not know about are left out.
+	ResolveHosts(ec2Instances []EC2Instance) (map[string]Host, error)
+}
+
+type cachedHost struct {
+	host      Host
+	expiresAt time.Time
+}
+
+// ec2HostCache implements the HostResolver interface by describing the EC2
+// instances that are not cached yet with a client for the region they run in
+type ec2HostCache struct {
+	newClient EC2ClientFactory
+	ttl       time.Duration
+	now       func() time.Time
+
+	lock    *sync.Mutex
+	hosts   map[string]cachedHost
+	clients map[string]ec2iface.EC2API
+}
+
+// NewEC2HostCache returns a HostResolver that caches the hosts it describes
+// for 'ttl', describing them with clients created by 'newClient'
+func NewEC2HostCache(newClient EC2ClientFactory, ttl time.Duration) (HostResolver, error) {
+	if newClient == nil {
+		return nil, errors.New("EC2 client factory cannot be nil")
+	}
+	return ec2HostCache{
+		newClient: newClient,
+		ttl:       ttl,
+		now:       time.Now,
+		lock:      &sync.Mutex{},
+		hosts:     make(map[string]cachedHost),
+		clients:   make(map[string]ec2iface.EC2API),
+	}, nil
+}
+
+func (cache ec2HostCache) ResolveHosts(ec2Instances []EC2Instance) (map[string]Host, error) {
+	hosts := make(map[string]Host)
+	uncached := make(map[string][]string)
+	seen := make(map[string]struct{})
+
+	cache.lock.Lock()
+	now := cache.now()
+	for id, cached := range cache.hosts {
+		if !now.Before(cached.expiresAt) {
+			delete(cache.hosts, id)
+		}
+	}
+	for _, instance := range ec2Instances {
+		if _, ok := seen[instance.ID]; ok || instance.ID == "" {
+			continue
+		}
+		seen[instance.ID] = struct{}{}
+		if cached, ok := cache.hosts[instance.ID]; ok {
+			hosts[instance.ID] = cached.host
+			continue
+		}
+		uncached[instance.Region] = append(uncached[instance.Region], instance.ID)
+	}
+	cache.lock.Unlock()
+
+	for region, ids := range uncached {
+		client, err := cache.client(region)
+		if err != nil {
+			return nil, err
+		}
+		for start := 0; start < len(ids); start += describeInstancesBatchSize {
+			end := start + describeInstancesBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			described, err := describeHosts(client, ids[start:end])
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error describing EC2 instances in region '%s'", region)
+			}
+
+			cache.lock.Lock()
+			expiresAt := cache.now().Add(cache.ttl)
+			for id, host := range described {
+				cache.hosts[id] = cachedHost{host: host, expiresAt: expiresAt}
+				hosts[id] = host
+			}
+			cache.lock.Unlock()
+		}
+	}
+
+	return hosts, nil
+}
+
+// client returns the EC2 client for 'region', creating it on first use
+func (cache ec2HostCache) client(region string) (ec2iface.EC2API, error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if client, ok := cache.clients[region]; ok {
+		return client, nil
+	}
+	client := cache.newClient(region)
+	if client == nil {
+		return nil, errors.Errorf("Could not create an EC2 client for region '%s'", region)
+	}
+	cache.clients[region] = client
+	return client, nil
+}
+
+// describeHosts describes the EC2 instances with the given IDs. A filter is
+// used instead of instance IDs so that unknown instances are left out instead
+// of failing the whole request.
+func describeHosts(ec2Client ec2iface.EC2API, ec2InstanceIDs []string) (map[string]Host, error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(instanceIDFilter),
+				Values: aws.StringSlice(ec2InstanceIDs),
+			},
+		},
+	}
+
+	hosts := make(map[string]Host)
+	err := ec2Client.DescribeInstancesPages(input, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				host := Host{PrivateIP: aws.StringValue(instance.PrivateIpAddress)}
+				if instance.Placement != nil {
+					host.AvailabilityZone = aws.StringValue(instance.Placement.AvailabilityZone)
+				}
+				hosts[aws.StringValue(instance.InstanceId)] = host
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error describing EC2 instances")
+	}
+	return hosts, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package discovery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const hostRegion = "eu-west-1"
+
+// fakeEC2Client describes the instances it knows about and records the
+// instance IDs it is asked to describe
+type fakeEC2Client struct {
+	ec2iface.EC2API
+	instances map[string]*ec2.Instance
+	err       error
+	described [][]string
+}
+
+func (client *fakeEC2Client) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	if client.err != nil {
+		return client.err
+	}
+	ids := aws.StringValueSlice(input.Filters[0].Values)
+	client.described = append(client.described, ids)
+	reservation := &ec2.Reservation{}
+	for _, id := range ids {
+		if instance, ok := client.instances[id]; ok {
+			reservation.Instances = append(reservation.Instances, instance)
+		}
+	}
+	fn(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}, true)
+	return nil
+}
+
+// fakeEC2ClientFactory returns the fake client of each region
+func fakeEC2ClientFactory(clients map[string]*fakeEC2Client) EC2ClientFactory {
+	return func(region string) ec2iface.EC2API {
+		return clients[region]
+	}
+}
+
+func newFakeEC2Client() *fakeEC2Client {
+	return &fakeEC2Client{
+		instances: map[string]*ec2.Instance{
+			ec2InstanceID: {
+				InstanceId:       aws.String(ec2InstanceID),
+				Placement:        &ec2.Placement{AvailabilityZone: aws.String(hostZone)},
+				PrivateIpAddress: aws.String(hostIP),
+			},
+		},
+	}
+}
+
+func TestNewEC2HostCacheNilClientFactory(t *testing.T) {
+	_, err := NewEC2HostCache(nil, HostCacheTTL)
+	assert.Error(t, err, "Expected an error when EC2 client factory is nil")
+}
+
+func TestEC2InstanceOf(t *testing.T) {
+	instance := types.ContainerInstance{
+		Detail: &types.InstanceDetail{
+			ContainerInstanceARN: aws.String("arn:aws:ecs:eu-west-1:123456789012:container-instance/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"),
+			EC2InstanceID:        ec2InstanceID,
+		},
+	}
+	assert.Equal(t, EC2Instance{ID: ec2InstanceID, Region: hostRegion}, EC2InstanceOf(instance), "Expected the region of the container instance ARN")
+}
+
+func TestResolveHostsDescribesUncachedInstancesOnce(t *testing.T) {
+	client := newFakeEC2Client()
+	resolver, err := NewEC2HostCache(fakeEC2ClientFactory(map[string]*fakeEC2Client{hostRegion: client}), HostCacheTTL)
+	assert.Nil(t, err, "Unexpected error when creating host cache")
+
+	hosts, err := resolver.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}, {ID: ec2InstanceID, Region: hostRegion}, {ID: "i-unknown", Region: hostRegion}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	assert.Equal(t, map[string]Host{ec2InstanceID: {PrivateIP: hostIP, AvailabilityZone: hostZone}}, hosts, "Unexpected hosts")
+	assert.Equal(t, [][]string{{ec2InstanceID, "i-unknown"}}, client.described, "Expected each instance to be described once")
+
+	hosts, err = resolver.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	assert.Len(t, hosts, 1, "Expected cached host")
+	assert.Len(t, client.described, 1, "Expected cached host not to be described again")
+}
+
+func TestResolveHostsDescribesInstancesInTheirRegion(t *testing.T) {
+	client := newFakeEC2Client()
+	otherClient := newFakeEC2Client()
+	created := 0
+	factory := fakeEC2ClientFactory(map[string]*fakeEC2Client{hostRegion: client, "us-west-2": otherClient})
+	resolver, err := NewEC2HostCache(func(region string) ec2iface.EC2API {
+		created++
+		return factory(region)
+	}, HostCacheTTL)
+	assert.Nil(t, err, "Unexpected error when creating host cache")
+
+	_, err = resolver.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}, {ID: "i-west", Region: "us-west-2"}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	_, err = resolver.ResolveHosts([]EC2Instance{{ID: "i-west-2", Region: "us-west-2"}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+
+	assert.Equal(t, [][]string{{ec2InstanceID}}, client.described, "Expected the instance to be described in its region")
+	assert.Equal(t, [][]string{{"i-west"}, {"i-west-2"}}, otherClient.described, "Expected the instances to be described in their region")
+	assert.Equal(t, 2, created, "Expected one client per region")
+}
+
+func TestResolveHostsDescribesExpiredInstances(t *testing.T) {
+	client := newFakeEC2Client()
+	resolver, err := NewEC2HostCache(fakeEC2ClientFactory(map[string]*fakeEC2Client{hostRegion: client}), time.Minute)
+	assert.Nil(t, err, "Unexpected error when creating host cache")
+	cache := resolver.(ec2HostCache)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err = cache.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+
+	now = now.Add(2 * time.Minute)
+	hosts, err := cache.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	assert.Len(t, hosts, 1, "Expected host to be resolved")
+	assert.Len(t, client.described, 2, "Expected expired host to be described again")
+}
+
+func TestResolveHostsRemovesExpiredInstances(t *testing.T) {
+	client := newFakeEC2Client()
+	resolver, err := NewEC2HostCache(fakeEC2ClientFactory(map[string]*fakeEC2Client{hostRegion: client}), time.Minute)
+	assert.Nil(t, err, "Unexpected error when creating host cache")
+	cache := resolver.(ec2HostCache)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err = cache.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}})
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	assert.Len(t, cache.hosts, 1, "Expected the host to be cached")
+
+	now = now.Add(2 * time.Minute)
+	_, err = cache.ResolveHosts(nil)
+	assert.Nil(t, err, "Unexpected error when resolving hosts")
+	assert.Empty(t, cache.hosts, "Expected the expired host to be removed although it was not resolved again")
+}
+
+func TestResolveHostsDescribeReturnsError(t *testing.T) {
+	client := newFakeEC2Client()
+	client.err = errors.New("Error describing instances")
+	resolver, err := NewEC2HostCache(fakeEC2ClientFactory(map[string]*fakeEC2Client{hostRegion: client}), HostCacheTTL)
+	assert.Nil(t, err, "Unexpected error when creating host cache")
+
+	_, err = resolver.ResolveHosts([]EC2Instance{{ID: ec2InstanceID, Region: hostRegion}})
+	assert.Error(t, err, "Expected an error when EC2 returns an error")
+}
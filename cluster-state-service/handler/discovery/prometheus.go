@@ -0,0 +1,128 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package discovery
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+)
+
+const (
+	// PrometheusPortsLabel is the docker label of a container definition that
+	// lists the comma separated container ports Prometheus should scrape
+	PrometheusPortsLabel = "blox.prometheus.ports"
+	// PrometheusPathLabel is the docker label of a container definition that
+	// sets the path Prometheus should scrape metrics from, /metrics by default
+	PrometheusPathLabel = "blox.prometheus.path"
+
+	metricsPathLabel            = "__metrics_path__"
+	clusterLabel                = "ecs_cluster"
+	taskDefinitionFamilyLabel   = "ecs_task_definition_family"
+	taskDefinitionRevisionLabel = "ecs_task_definition_revision"
+	containerNameLabel          = "ecs_container_name"
+	instanceIDLabel             = "ecs_instance_id"
+	availabilityZoneLabel       = "ecs_availability_zone"
+)
+
+// TargetGroup is a group of Prometheus targets sharing the same labels, in
+// the format of the Prometheus HTTP service discovery
+type TargetGroup struct {
+	Targets []string
+	Labels  map[string]string
+}
+
+// BuildPrometheusTargetGroups returns a target group per container of a task
+// with the endpoints of the container ports listed in the PrometheusPortsLabel
+// docker label of the container definition. Endpoints of task definitions
+// missing from 'taskDefinitions' are skipped. The groups are in the order of
+// the endpoints.
+func BuildPrometheusTargetGroups(endpoints []Endpoint, taskDefinitions map[string]types.TaskDefinition) []TargetGroup {
+	groups := []TargetGroup{}
+	index := make(map[string]int)
+	for _, endpoint := range endpoints {
+		containerDefinition := findContainerDefinition(taskDefinitions[endpoint.TaskDefinitionARN], endpoint.ContainerName)
+		if containerDefinition == nil {
+			continue
+		}
+		if _, ok := prometheusPorts(containerDefinition)[endpoint.ContainerPort]; !ok {
+			continue
+		}
+
+		target := net.JoinHostPort(endpoint.IP, strconv.FormatInt(endpoint.HostPort, 10))
+		key := endpoint.TaskARN + "/" + endpoint.ContainerName
+		if i, ok := index[key]; ok {
+			groups[i].Targets = append(groups[i].Targets, target)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, TargetGroup{
+			Targets: []string{target},
+			Labels:  prometheusLabels(endpoint, containerDefinition),
+		})
+	}
+	return groups
+}
+
+func prometheusLabels(endpoint Endpoint, containerDefinition *types.ContainerDefinition) map[string]string {
+	labels := map[string]string{
+		taskDefinitionFamilyLabel: endpoint.Family,
+		containerNameLabel:        endpoint.ContainerName,
+	}
+	if cluster, err := regex.GetClusterNameFromARN(endpoint.ClusterARN); err == nil {
+		labels[clusterLabel] = cluster
+	}
+	if _, revision, err := regex.GetFamilyAndRevisionFromTaskDefinitionARN(endpoint.TaskDefinitionARN); err == nil {
+		labels[taskDefinitionRevisionLabel] = strconv.FormatInt(revision, 10)
+	}
+	if endpoint.EC2InstanceID != "" {
+		labels[instanceIDLabel] = endpoint.EC2InstanceID
+	}
+	if endpoint.AvailabilityZone != "" {
+		labels[availabilityZoneLabel] = endpoint.AvailabilityZone
+	}
+	if path := strings.TrimSpace(containerDefinition.DockerLabels[PrometheusPathLabel]); path != "" {
+		labels[metricsPathLabel] = path
+	}
+	return labels
+}
+
+// prometheusPorts parses the PrometheusPortsLabel docker label of a container
+// definition. Ports that are not numbers are ignored.
+func prometheusPorts(containerDefinition *types.ContainerDefinition) map[int64]struct{} {
+	ports := make(map[int64]struct{})
+	for _, value := range strings.Split(containerDefinition.DockerLabels[PrometheusPortsLabel], ",") {
+		port, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err == nil {
+			ports[port] = struct{}{}
+		}
+	}
+	return ports
+}
+
+func findContainerDefinition(taskDefinition types.TaskDefinition, name string) *types.ContainerDefinition {
+	if taskDefinition.Detail == nil {
+		return nil
+	}
+	for _, containerDefinition := range taskDefinition.Detail.ContainerDefinitions {
+		if containerDefinition != nil && aws.StringValue(containerDefinition.Name) == name {
+			return containerDefinition
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func labeledTaskDefinition(labels map[string]string) map[string]types.TaskDefinition {
+	return map[string]types.TaskDefinition{
+		webTaskDefinition: {
+			Detail: &types.TaskDefinitionDetail{
+				ContainerDefinitions: []*types.ContainerDefinition{
+					{Name: aws.String("web"), DockerLabels: labels},
+					{Name: aws.String("sidecar")},
+				},
+				TaskDefinitionARN: aws.String(webTaskDefinition),
+			},
+		},
+	}
+}
+
+func prometheusEndpoints() []Endpoint {
+	return BuildEndpoints(snapshot(
+		bridgeTask(taskARN1, runningStatus,
+			container("web", runningStatus, binding(80, 32768, "tcp"), binding(9090, 32769, "tcp")),
+			container("sidecar", runningStatus, binding(9090, 32770, "tcp"))),
+		bridgeTask(taskARN2, runningStatus,
+			container("web", runningStatus, binding(80, 32771, "tcp"), binding(9090, 32772, "tcp"))),
+	), Filter{})
+}
+
+func TestBuildPrometheusTargetGroups(t *testing.T) {
+	taskDefinitions := labeledTaskDefinition(map[string]string{PrometheusPortsLabel: "9090", PrometheusPathLabel: "/stats"})
+	groups := BuildPrometheusTargetGroups(prometheusEndpoints(), taskDefinitions)
+
+	labels := map[string]string{
+		metricsPathLabel:            "/stats",
+		clusterLabel:                "default",
+		taskDefinitionFamilyLabel:   "web",
+		taskDefinitionRevisionLabel: "1",
+		containerNameLabel:          "web",
+		instanceIDLabel:             ec2InstanceID,
+		availabilityZoneLabel:       hostZone,
+	}
+	assert.Equal(t, []TargetGroup{
+		{Targets: []string{hostIP + ":32769"}, Labels: labels},
+		{Targets: []string{hostIP + ":32772"}, Labels: labels},
+	}, groups, "Unexpected target groups")
+}
+
+func TestBuildPrometheusTargetGroupsMultiplePorts(t *testing.T) {
+	taskDefinitions := labeledTaskDefinition(map[string]string{PrometheusPortsLabel: " 80, 9090,invalid"})
+	groups := BuildPrometheusTargetGroups(prometheusEndpoints(), taskDefinitions)
+
+	assert.Len(t, groups, 2, "Expected a target group per task container")
+	assert.Equal(t, []string{hostIP + ":32768", hostIP + ":32769"}, groups[0].Targets, "Unexpected targets")
+	_, ok := groups[0].Labels[metricsPathLabel]
+	assert.False(t, ok, "Expected no metrics path without a path label")
+}
+
+func TestBuildPrometheusTargetGroupsWithoutLabels(t *testing.T) {
+	groups := BuildPrometheusTargetGroups(prometheusEndpoints(), labeledTaskDefinition(nil))
+	assert.NotNil(t, groups, "Expected an empty list of target groups")
+	assert.Empty(t, groups, "Expected no target groups without port labels")
+
+	groups = BuildPrometheusTargetGroups(prometheusEndpoints(), nil)
+	assert.Empty(t, groups, "Expected no target groups without task definitions")
+}
@@ -0,0 +1,214 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Subset of the DNS wire format (RFC 1035) needed to answer single question
+// A and SRV (RFC 2782) queries
+
+const (
+	headerLength = 12
+	// maxUDPMessageLength is the maximum length of a response sent over UDP
+	// to clients that do not advertise a larger buffer size
+	maxUDPMessageLength = 512
+	maxLabelLength      = 63
+	maxNameLength       = 255
+
+	typeA   = uint16(1)
+	typeSRV = uint16(33)
+	typeANY = uint16(255)
+
+	classINET = uint16(1)
+	classANY  = uint16(255)
+
+	rcodeSuccess        = uint16(0)
+	rcodeFormatError    = uint16(1)
+	rcodeServerFailure  = uint16(2)
+	rcodeNameError      = uint16(3)
+	rcodeNotImplemented = uint16(4)
+	rcodeRefused        = uint16(5)
+
+	flagResponse      = uint16(1 << 15)
+	flagAuthoritative = uint16(1 << 10)
+	flagTruncated     = uint16(1 << 9)
+	flagRecursion     = uint16(1 << 8)
+	opcodeMask        = uint16(0xF << 11)
+
+	// questionNamePointer is a compression pointer to the name of the
+	// question, which always directly follows the header
+	questionNamePointer = uint16(0xC000 | headerLength)
+)
+
+// question is the question of a DNS query
+type question struct {
+	id    uint16
+	flags uint16
+	name  string
+	qtype uint16
+	class uint16
+	// raw is the question section as it was received
+	raw []byte
+}
+
+// resource is an answer or additional record of a DNS response
+type resource struct {
+	name  string
+	rtype uint16
+	ttl   uint32
+	// One of
+	ip  net.IP
+	srv *srv
+}
+
+type srv struct {
+	priority uint16
+	weight   uint16
+	port     uint16
+	target   string
+}
+
+// parseQuestion parses a DNS query with a single question. The header is
+// returned along with the error if the query is malformed after the header,
+// so that the error can be answered.
+func parseQuestion(message []byte) (question, error) {
+	if len(message) < headerLength {
+		return question{}, errors.New("DNS message is shorter than its header")
+	}
+	q := question{
+		id:    binary.BigEndian.Uint16(message[0:2]),
+		flags: binary.BigEndian.Uint16(message[2:4]),
+	}
+	if q.flags&flagResponse != 0 {
+		return q, errors.New("DNS message is not a query")
+	}
+	if count := binary.BigEndian.Uint16(message[4:6]); count != 1 {
+		return q, errors.Errorf("DNS query has %d questions", count)
+	}
+
+	offset := headerLength
+	var labels []string
+	for {
+		if offset >= len(message) {
+			return q, errors.New("DNS question name is truncated")
+		}
+		length := int(message[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > maxLabelLength {
+			return q, errors.New("DNS question name is compressed or has an invalid label")
+		}
+		if offset+length > len(message) {
+			return q, errors.New("DNS question name is truncated")
+		}
+		labels = append(labels, string(message[offset:offset+length]))
+		offset += length
+	}
+	if offset+4 > len(message) {
+		return q, errors.New("DNS question is truncated")
+	}
+	q.name = strings.Join(labels, ".")
+	if len(q.name) > maxNameLength {
+		return q, errors.New("DNS question name is too long")
+	}
+	q.qtype = binary.BigEndian.Uint16(message[offset : offset+2])
+	q.class = binary.BigEndian.Uint16(message[offset+2 : offset+4])
+	q.raw = message[headerLength : offset+4]
+	return q, nil
+}
+
+// buildResponse builds the response to a question. If the response is longer
+// than 'maxLength', the records are left out and the response is marked as
+// truncated so that the client retries over TCP.
+func buildResponse(q question, rcode uint16, answers []resource, additionals []resource, maxLength int) []byte {
+	flags := flagResponse | flagAuthoritative | (q.flags & (opcodeMask | flagRecursion)) | rcode
+	message := buildMessage(q, flags, answers, additionals)
+	if maxLength > 0 && len(message) > maxLength {
+		message = buildMessage(q, flags|flagTruncated, nil, nil)
+	}
+	return message
+}
+
+func buildMessage(q question, flags uint16, answers []resource, additionals []resource) []byte {
+	questions := uint16(0)
+	if q.raw != nil {
+		questions = 1
+	}
+	message := make([]byte, headerLength, maxUDPMessageLength)
+	binary.BigEndian.PutUint16(message[0:2], q.id)
+	binary.BigEndian.PutUint16(message[2:4], flags)
+	binary.BigEndian.PutUint16(message[4:6], questions)
+	binary.BigEndian.PutUint16(message[6:8], uint16(len(answers)))
+	binary.BigEndian.PutUint16(message[10:12], uint16(len(additionals)))
+	message = append(message, q.raw...)
+	for _, r := range answers {
+		message = appendResource(message, q, r)
+	}
+	for _, r := range additionals {
+		message = appendResource(message, q, r)
+	}
+	return message
+}
+
+func appendResource(message []byte, q question, r resource) []byte {
+	if q.raw != nil && strings.EqualFold(r.name, q.name) {
+		message = appendUint16(message, questionNamePointer)
+	} else {
+		message = appendName(message, r.name)
+	}
+	message = appendUint16(message, r.rtype)
+	message = appendUint16(message, classINET)
+	message = appendUint32(message, r.ttl)
+
+	var data []byte
+	switch r.rtype {
+	case typeA:
+		data = r.ip.To4()
+	case typeSRV:
+		data = appendUint16(data, r.srv.priority)
+		data = appendUint16(data, r.srv.weight)
+		data = appendUint16(data, r.srv.port)
+		// Names in SRV records must not be compressed
+		data = appendName(data, r.srv.target)
+	}
+	message = appendUint16(message, uint16(len(data)))
+	return append(message, data...)
+}
+
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,183 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRecord is a decoded answer or additional record of a response
+type testRecord struct {
+	name  string
+	rtype uint16
+	ttl   uint32
+	value string
+}
+
+type testResponse struct {
+	id          uint16
+	rcode       uint16
+	truncated   bool
+	answers     []testRecord
+	additionals []testRecord
+}
+
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	query := make([]byte, headerLength)
+	binary.BigEndian.PutUint16(query[0:2], id)
+	binary.BigEndian.PutUint16(query[2:4], flagRecursion)
+	binary.BigEndian.PutUint16(query[4:6], 1)
+	query = appendName(query, name)
+	query = appendUint16(query, qtype)
+	return appendUint16(query, classINET)
+}
+
+func readName(t *testing.T, message []byte, offset int) (string, int) {
+	var labels []string
+	end := -1
+	for {
+		length := int(message[offset])
+		if length&0xC0 == 0xC0 {
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(message[offset:offset+2]) & 0x3FFF)
+			continue
+		}
+		offset++
+		if length == 0 {
+			break
+		}
+		labels = append(labels, string(message[offset:offset+length]))
+		offset += length
+	}
+	if end < 0 {
+		end = offset
+	}
+	return strings.Join(labels, "."), end
+}
+
+func readRecords(t *testing.T, message []byte, offset int, count int) ([]testRecord, int) {
+	var records []testRecord
+	for i := 0; i < count; i++ {
+		var r testRecord
+		r.name, offset = readName(t, message, offset)
+		r.rtype = binary.BigEndian.Uint16(message[offset : offset+2])
+		r.ttl = binary.BigEndian.Uint32(message[offset+4 : offset+8])
+		length := int(binary.BigEndian.Uint16(message[offset+8 : offset+10]))
+		data := message[offset+10 : offset+10+length]
+		switch r.rtype {
+		case typeA:
+			r.value = net.IP(data).String()
+		case typeSRV:
+			target, _ := readName(t, data, 6)
+			r.value = fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4]),
+				binary.BigEndian.Uint16(data[4:6]), target)
+		}
+		records = append(records, r)
+		offset += 10 + length
+	}
+	return records, offset
+}
+
+func parseResponse(t *testing.T, message []byte) testResponse {
+	assert.True(t, len(message) >= headerLength, "Response is shorter than its header")
+	flags := binary.BigEndian.Uint16(message[2:4])
+	assert.True(t, flags&flagResponse != 0, "Expected response flag to be set")
+	response := testResponse{
+		id:        binary.BigEndian.Uint16(message[0:2]),
+		rcode:     flags & 0xF,
+		truncated: flags&flagTruncated != 0,
+	}
+	offset := headerLength
+	if binary.BigEndian.Uint16(message[4:6]) == 1 {
+		_, offset = readName(t, message, offset)
+		offset += 4
+	}
+	response.answers, offset = readRecords(t, message, offset, int(binary.BigEndian.Uint16(message[6:8])))
+	response.additionals, _ = readRecords(t, message, offset, int(binary.BigEndian.Uint16(message[10:12])))
+	return response
+}
+
+func TestParseQuestion(t *testing.T) {
+	q, err := parseQuestion(buildQuery(42, "_web._tcp.web.default.blox.local", typeSRV))
+	assert.Nil(t, err, "Unexpected error parsing query")
+	assert.Equal(t, uint16(42), q.id, "Unexpected query ID")
+	assert.Equal(t, "_web._tcp.web.default.blox.local", q.name, "Unexpected question name")
+	assert.Equal(t, typeSRV, q.qtype, "Unexpected question type")
+	assert.Equal(t, classINET, q.class, "Unexpected question class")
+}
+
+func TestParseQuestionMalformedQueries(t *testing.T) {
+	_, err := parseQuestion([]byte{0, 1, 0})
+	assert.Error(t, err, "Expected an error parsing a query shorter than its header")
+
+	query := buildQuery(1, "web.default.blox.local", typeA)
+	_, err = parseQuestion(query[:len(query)-3])
+	assert.Error(t, err, "Expected an error parsing a truncated query")
+
+	binary.BigEndian.PutUint16(query[4:6], 2)
+	_, err = parseQuestion(query)
+	assert.Error(t, err, "Expected an error parsing a query with more than one question")
+
+	response := buildQuery(1, "web.default.blox.local", typeA)
+	binary.BigEndian.PutUint16(response[2:4], flagResponse)
+	_, err = parseQuestion(response)
+	assert.Error(t, err, "Expected an error parsing a response")
+}
+
+func TestBuildResponse(t *testing.T) {
+	q, err := parseQuestion(buildQuery(7, "_web._tcp.web.default.blox.local", typeSRV))
+	assert.Nil(t, err, "Unexpected error parsing query")
+
+	answers := []resource{{
+		name:  q.name,
+		rtype: typeSRV,
+		ttl:   5,
+		srv:   &srv{priority: 1, weight: 1, port: 32768, target: "task1.web.default.blox.local"},
+	}}
+	additionals := []resource{{name: "task1.web.default.blox.local", rtype: typeA, ttl: 5, ip: net.ParseIP("10.0.1.15")}}
+
+	response := parseResponse(t, buildResponse(q, rcodeSuccess, answers, additionals, maxUDPMessageLength))
+	assert.Equal(t, testResponse{
+		id:          7,
+		rcode:       rcodeSuccess,
+		answers:     []testRecord{{name: q.name, rtype: typeSRV, ttl: 5, value: "1 1 32768 task1.web.default.blox.local"}},
+		additionals: []testRecord{{name: "task1.web.default.blox.local", rtype: typeA, ttl: 5, value: "10.0.1.15"}},
+	}, response, "Unexpected response")
+}
+
+func TestBuildResponseTruncatesLongResponses(t *testing.T) {
+	q, err := parseQuestion(buildQuery(7, "web.default.blox.local", typeA))
+	assert.Nil(t, err, "Unexpected error parsing query")
+
+	var answers []resource
+	for i := 0; i < 100; i++ {
+		answers = append(answers, resource{name: q.name, rtype: typeA, ttl: 5, ip: net.IPv4(10, 0, 0, byte(i))})
+	}
+
+	response := parseResponse(t, buildResponse(q, rcodeSuccess, answers, nil, maxUDPMessageLength))
+	assert.True(t, response.truncated, "Expected response longer than the maximum length to be truncated")
+	assert.Empty(t, response.answers, "Expected records to be left out of truncated responses")
+
+	response = parseResponse(t, buildResponse(q, rcodeSuccess, answers, nil, maxMessageLength))
+	assert.False(t, response.truncated, "Unexpected truncated response")
+	assert.Len(t, response.answers, 100, "Expected all records in response")
+}
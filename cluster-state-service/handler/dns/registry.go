@@ -0,0 +1,285 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	taskStatusFilter  = "status"
+	runningTaskStatus = "RUNNING"
+)
+
+// registry keeps the endpoints of the running tasks up to date by following
+// the task and container instance streams. It is updated by a single
+// goroutine running sync and read by the goroutines answering queries.
+type registry struct {
+	taskStore            store.TaskStore
+	instanceStore        store.ContainerInstanceStore
+	taskDefinitionStore  store.TaskDefinitionStore
+	taskDefinitionLoader loader.TaskDefinitionLoader
+	hostResolver         discovery.HostResolver
+
+	// Only accessed by the goroutine running sync
+	tasks     map[string]types.Task
+	instances map[string]types.ContainerInstance
+
+	lock      *sync.RWMutex
+	ready     bool
+	endpoints map[string][]discovery.Endpoint
+}
+
+func newRegistry(stores store.Stores, taskDefinitionLoader loader.TaskDefinitionLoader, hostResolver discovery.HostResolver) *registry {
+	return &registry{
+		taskStore:            stores.TaskStore,
+		instanceStore:        stores.ContainerInstanceStore,
+		taskDefinitionStore:  stores.TaskDefinitionStore,
+		taskDefinitionLoader: taskDefinitionLoader,
+		hostResolver:         hostResolver,
+		tasks:                make(map[string]types.Task),
+		instances:            make(map[string]types.ContainerInstance),
+		lock:                 &sync.RWMutex{},
+		endpoints:            make(map[string][]discovery.Endpoint),
+	}
+}
+
+// sync loads the running tasks and then applies the changes of the task and
+// container instance streams until the context is done or a stream fails
+func (r *registry) sync(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Streams are opened before loading so that no change is missed
+	taskRespChan, err := r.taskStore.StreamTasks(ctx, "")
+	if err != nil {
+		return errors.Wrapf(err, "Error streaming tasks")
+	}
+	instanceRespChan, err := r.instanceStore.StreamContainerInstances(ctx, "")
+	if err != nil {
+		return errors.Wrapf(err, "Error streaming container instances")
+	}
+
+	err = r.load()
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case taskResp, ok := <-taskRespChan:
+			if !ok {
+				return errors.New("Task stream closed")
+			}
+			if taskResp.Err != nil {
+				return errors.Wrapf(taskResp.Err, "Error streaming tasks")
+			}
+			err = r.updateTask(taskResp.Task)
+		case instanceResp, ok := <-instanceRespChan:
+			if !ok {
+				return errors.New("Container instance stream closed")
+			}
+			if instanceResp.Err != nil {
+				return errors.Wrapf(instanceResp.Err, "Error streaming container instances")
+			}
+			err = r.updateInstance(instanceResp.ContainerInstance)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// load replaces the registry with the running tasks in the data store
+func (r *registry) load() error {
+	versionedTasks, err := r.taskStore.FilterTasks(map[string]string{taskStatusFilter: runningTaskStatus})
+	if err != nil {
+		return errors.Wrapf(err, "Error loading running tasks")
+	}
+	versionedInstances, err := r.instanceStore.ListContainerInstances()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading container instances")
+	}
+
+	r.tasks = make(map[string]types.Task)
+	for _, versionedTask := range versionedTasks {
+		if discovery.IsRunning(versionedTask.Task) {
+			r.tasks[aws.StringValue(versionedTask.Task.Detail.TaskARN)] = versionedTask.Task
+		}
+	}
+	r.instances = make(map[string]types.ContainerInstance)
+	for _, versionedInstance := range versionedInstances {
+		if versionedInstance.ContainerInstance.Detail != nil {
+			r.instances[aws.StringValue(versionedInstance.ContainerInstance.Detail.ContainerInstanceARN)] = versionedInstance.ContainerInstance
+		}
+	}
+
+	tasks := make([]types.Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		tasks = append(tasks, task)
+	}
+	endpoints, err := r.buildEndpoints(tasks)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.endpoints = endpoints
+	r.ready = true
+	return nil
+}
+
+func (r *registry) updateTask(task types.Task) error {
+	if task.Detail == nil {
+		return nil
+	}
+	taskARN := aws.StringValue(task.Detail.TaskARN)
+	if !discovery.IsRunning(task) {
+		delete(r.tasks, taskARN)
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		delete(r.endpoints, taskARN)
+		return nil
+	}
+
+	r.tasks[taskARN] = task
+	return r.rebuild([]types.Task{task})
+}
+
+// updateInstance rebuilds the endpoints of the tasks running on an instance,
+// since they are reached at the address of the instance
+func (r *registry) updateInstance(instance types.ContainerInstance) error {
+	if instance.Detail == nil {
+		return nil
+	}
+	instanceARN := aws.StringValue(instance.Detail.ContainerInstanceARN)
+	r.instances[instanceARN] = instance
+
+	var tasks []types.Task
+	for _, task := range r.tasks {
+		if aws.StringValue(task.Detail.ContainerInstanceARN) == instanceARN {
+			tasks = append(tasks, task)
+		}
+	}
+	return r.rebuild(tasks)
+}
+
+// rebuild replaces the endpoints of the given tasks
+func (r *registry) rebuild(tasks []types.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	endpoints, err := r.buildEndpoints(tasks)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, task := range tasks {
+		taskARN := aws.StringValue(task.Detail.TaskARN)
+		if taskEndpoints, ok := endpoints[taskARN]; ok {
+			r.endpoints[taskARN] = taskEndpoints
+		} else {
+			delete(r.endpoints, taskARN)
+		}
+	}
+	return nil
+}
+
+// buildEndpoints builds the endpoints of the given tasks, keyed by task ARN
+func (r *registry) buildEndpoints(tasks []types.Task) (map[string][]discovery.Endpoint, error) {
+	snapshot := discovery.Snapshot{
+		Tasks:           tasks,
+		Instances:       r.instances,
+		TaskDefinitions: make(map[string]types.TaskDefinition),
+	}
+	var ec2Instances []discovery.EC2Instance
+	for _, task := range tasks {
+		if !discovery.UsesTaskNetworkInterface(task) {
+			if instance, ok := r.instances[aws.StringValue(task.Detail.ContainerInstanceARN)]; ok {
+				ec2Instances = append(ec2Instances, discovery.EC2InstanceOf(instance))
+			}
+			continue
+		}
+
+		taskDefinitionARN := aws.StringValue(task.Detail.TaskDefinitionARN)
+		if _, ok := snapshot.TaskDefinitions[taskDefinitionARN]; ok || !regex.IsTaskDefinitionARN(taskDefinitionARN) {
+			continue
+		}
+		taskDefinition, err := loader.GetOrLoadTaskDefinition(r.taskDefinitionStore, r.taskDefinitionLoader, taskDefinitionARN)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error getting task definition '%s'", taskDefinitionARN)
+		}
+		if taskDefinition != nil {
+			snapshot.TaskDefinitions[taskDefinitionARN] = taskDefinition.TaskDefinition
+		}
+	}
+
+	hosts, err := r.hostResolver.ResolveHosts(ec2Instances)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Hosts = hosts
+
+	endpoints := make(map[string][]discovery.Endpoint)
+	for _, endpoint := range discovery.BuildEndpoints(snapshot, discovery.Filter{}) {
+		endpoints[endpoint.TaskARN] = append(endpoints[endpoint.TaskARN], endpoint)
+	}
+	return endpoints, nil
+}
+
+// isReady returns true once the running tasks have been loaded
+func (r *registry) isReady() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.ready
+}
+
+// lookup returns the endpoints of the running tasks of a task definition
+// family in a cluster, ordered by task ARN. Names are compared case
+// insensitively since DNS names are.
+func (r *registry) lookup(cluster string, family string) []discovery.Endpoint {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var endpoints []discovery.Endpoint
+	for _, taskEndpoints := range r.endpoints {
+		for _, endpoint := range taskEndpoints {
+			if !strings.EqualFold(endpoint.Family, family) {
+				continue
+			}
+			clusterName, err := regex.GetClusterNameFromARN(endpoint.ClusterARN)
+			if err != nil || !strings.EqualFold(clusterName, cluster) {
+				continue
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	discovery.SortEndpoints(endpoints)
+	return endpoints
+}
@@ -0,0 +1,200 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	clusterARN        = "arn:aws:ecs:us-east-1:123456789012:cluster/default"
+	instanceARN       = "arn:aws:ecs:us-east-1:123456789012:container-instance/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
+	taskARN1          = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	taskARN2          = "arn:aws:ecs:us-east-1:123456789012:task/default/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	taskDefinitionARN = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"
+	ec2InstanceID     = "i-0123456789abcdef0"
+	hostIP            = "10.0.1.15"
+	runningStatus     = "RUNNING"
+)
+
+type registryMockContext struct {
+	mockCtrl             *gomock.Controller
+	taskStore            *mocks.MockTaskStore
+	instanceStore        *mocks.MockContainerInstanceStore
+	taskDefinitionStore  *mocks.MockTaskDefinitionStore
+	taskDefinitionLoader *mocks.MockTaskDefinitionLoader
+	hostResolver         *mocks.MockHostResolver
+	stores               store.Stores
+}
+
+func newRegistryMockContext(t *testing.T) *registryMockContext {
+	context := registryMockContext{}
+	context.mockCtrl = gomock.NewController(t)
+	context.taskStore = mocks.NewMockTaskStore(context.mockCtrl)
+	context.instanceStore = mocks.NewMockContainerInstanceStore(context.mockCtrl)
+	context.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(context.mockCtrl)
+	context.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(context.mockCtrl)
+	context.hostResolver = mocks.NewMockHostResolver(context.mockCtrl)
+	context.stores = store.Stores{
+		TaskStore:              context.taskStore,
+		ContainerInstanceStore: context.instanceStore,
+		TaskDefinitionStore:    context.taskDefinitionStore,
+	}
+	return &context
+}
+
+func (context *registryMockContext) registry() *registry {
+	return newRegistry(context.stores, context.taskDefinitionLoader, context.hostResolver)
+}
+
+func runningTask(taskARN string, hostPort int64) types.Task {
+	return types.Task{
+		Detail: &types.TaskDetail{
+			ClusterARN:           aws.String(clusterARN),
+			ContainerInstanceARN: aws.String(instanceARN),
+			Containers: []*types.Container{
+				{
+					LastStatus: aws.String(runningStatus),
+					Name:       aws.String("web"),
+					NetworkBindings: []*types.NetworkBinding{
+						{ContainerPort: aws.Int64(80), HostPort: aws.Int64(hostPort), Protocol: "tcp"},
+					},
+				},
+			},
+			LastStatus:        aws.String(runningStatus),
+			TaskARN:           aws.String(taskARN),
+			TaskDefinitionARN: aws.String(taskDefinitionARN),
+		},
+	}
+}
+
+func containerInstance() types.ContainerInstance {
+	return types.ContainerInstance{
+		Detail: &types.InstanceDetail{
+			ClusterARN:           aws.String(clusterARN),
+			ContainerInstanceARN: aws.String(instanceARN),
+			EC2InstanceID:        ec2InstanceID,
+		},
+	}
+}
+
+func (context *registryMockContext) expectLoad(tasks ...types.Task) {
+	versionedTasks := make([]storetypes.VersionedTask, len(tasks))
+	for i := range tasks {
+		versionedTasks[i] = storetypes.VersionedTask{Task: tasks[i]}
+	}
+	instances := []storetypes.VersionedContainerInstance{{ContainerInstance: containerInstance()}}
+	context.taskStore.EXPECT().FilterTasks(map[string]string{taskStatusFilter: runningTaskStatus}).Return(versionedTasks, nil)
+	context.instanceStore.EXPECT().ListContainerInstances().Return(instances, nil)
+	context.hostResolver.EXPECT().ResolveHosts(gomock.Any()).Return(map[string]discovery.Host{ec2InstanceID: {PrivateIP: hostIP}}, nil).AnyTimes()
+}
+
+func TestRegistryLoad(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	context.expectLoad(runningTask(taskARN2, 32769), runningTask(taskARN1, 32768))
+
+	r := context.registry()
+	assert.False(t, r.isReady(), "Expected registry not to be ready before loading")
+	err := r.load()
+	assert.Nil(t, err, "Unexpected error loading registry")
+	assert.True(t, r.isReady(), "Expected registry to be ready after loading")
+
+	endpoints := r.lookup("DEFAULT", "Web")
+	assert.Len(t, endpoints, 2, "Expected endpoints of both tasks")
+	assert.Equal(t, taskARN1, endpoints[0].TaskARN, "Expected endpoints ordered by task ARN")
+	assert.Equal(t, hostIP, endpoints[0].IP, "Unexpected endpoint IP")
+	assert.Empty(t, r.lookup("other", "web"), "Expected no endpoints in other clusters")
+	assert.Empty(t, r.lookup("default", "api"), "Expected no endpoints of other families")
+}
+
+func TestRegistryLoadTaskStoreReturnsError(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	context.taskStore.EXPECT().FilterTasks(gomock.Any()).Return(nil, errors.New("Error filtering tasks"))
+
+	r := context.registry()
+	err := r.load()
+	assert.Error(t, err, "Expected an error when task store returns an error")
+	assert.False(t, r.isReady(), "Expected registry not to be ready after failing to load")
+}
+
+func TestRegistryUpdateTask(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	context.expectLoad(runningTask(taskARN1, 32768))
+
+	r := context.registry()
+	err := r.load()
+	assert.Nil(t, err, "Unexpected error loading registry")
+
+	err = r.updateTask(runningTask(taskARN2, 32769))
+	assert.Nil(t, err, "Unexpected error updating task")
+	assert.Len(t, r.lookup("default", "web"), 2, "Expected endpoints of started task")
+
+	stoppedTask := runningTask(taskARN1, 32768)
+	stoppedTask.Detail.LastStatus = aws.String("STOPPED")
+	err = r.updateTask(stoppedTask)
+	assert.Nil(t, err, "Unexpected error updating task")
+	endpoints := r.lookup("default", "web")
+	assert.Len(t, endpoints, 1, "Expected endpoints of stopped task to be removed")
+	assert.Equal(t, taskARN2, endpoints[0].TaskARN, "Unexpected endpoint")
+}
+
+func TestRegistrySyncFollowsTaskStream(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	taskRespChan := make(chan storetypes.VersionedTask)
+	instanceRespChan := make(chan storetypes.VersionedContainerInstance)
+	context.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(taskRespChan, nil)
+	context.instanceStore.EXPECT().StreamContainerInstances(gomock.Any(), "").Return(instanceRespChan, nil)
+	context.expectLoad()
+
+	r := context.registry()
+	go func() {
+		defer close(taskRespChan)
+		taskRespChan <- storetypes.VersionedTask{Task: runningTask(taskARN1, 32768)}
+		instanceRespChan <- storetypes.VersionedContainerInstance{ContainerInstance: containerInstance()}
+	}()
+
+	err := r.sync(ctx())
+	assert.Error(t, err, "Expected an error when the task stream closes")
+	assert.Len(t, r.lookup("default", "web"), 1, "Expected endpoints of streamed task")
+}
+
+func TestRegistrySyncStreamReturnsError(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	context.taskStore.EXPECT().StreamTasks(gomock.Any(), "").Return(nil, errors.New("Error streaming tasks"))
+	context.taskStore.EXPECT().FilterTasks(gomock.Any()).Times(0)
+
+	err := context.registry().sync(ctx())
+	assert.Error(t, err, "Expected an error when task store returns an error")
+}
+
+func ctx() context.Context {
+	return context.Background()
+}
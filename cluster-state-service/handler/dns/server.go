@@ -0,0 +1,334 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package dns serves SRV and A records for the endpoints of running tasks, so
+// that services that can only find their dependencies through DNS can find
+// the ones running on ECS.
+//
+// Names have the form
+//
+//	_<container>._<protocol>.<family>.<cluster>.<domain>  SRV records of the endpoints of a container
+//	<task ID>.<family>.<cluster>.<domain>                 A record of a task, the target of its SRV records
+//	<family>.<cluster>.<domain>                           A records of all running tasks of a family
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SyncRetryDuration is how long to wait before following the task and
+	// container instance streams again after they fail
+	SyncRetryDuration = 5 * time.Second
+
+	tcpTimeout       = 10 * time.Second
+	maxMessageLength = 65535
+
+	srvPriority = 1
+	srvWeight   = 1
+)
+
+// Config configures the DNS server
+type Config struct {
+	BindAddr string
+	Domain   string
+	TTL      time.Duration
+}
+
+// Server answers DNS queries over UDP and TCP with the endpoints of running tasks
+type Server struct {
+	ctx      context.Context
+	config   Config
+	domain   string
+	ttl      uint32
+	registry *registry
+}
+
+// NewServer initializes a DNS server. It does not listen until Start is called.
+func NewServer(ctx context.Context, stores store.Stores, taskDefinitionLoader loader.TaskDefinitionLoader,
+	hostResolver discovery.HostResolver, config Config) (*Server, error) {
+	if config.BindAddr == "" {
+		return nil, errors.New("The DNS server listen address is not set")
+	}
+	if hostResolver == nil {
+		return nil, errors.New("Host resolver cannot be nil")
+	}
+	domain := strings.ToLower(strings.Trim(config.Domain, "."))
+	if domain == "" {
+		return nil, errors.New("The DNS domain is not set")
+	}
+	if config.TTL < 0 {
+		return nil, errors.Errorf("Invalid DNS TTL: %s", config.TTL.String())
+	}
+	return &Server{
+		ctx:      ctx,
+		config:   config,
+		domain:   domain,
+		ttl:      uint32(config.TTL / time.Second),
+		registry: newRegistry(stores, taskDefinitionLoader, hostResolver),
+	}, nil
+}
+
+// Start listens on the configured address over UDP and TCP and serves queries
+// and follows the task stream in the background until the context is done
+func (server *Server) Start() error {
+	udpConn, err := net.ListenPacket("udp", server.config.BindAddr)
+	if err != nil {
+		return errors.Wrapf(err, "Could not listen for DNS queries over UDP")
+	}
+	tcpListener, err := net.Listen("tcp", server.config.BindAddr)
+	if err != nil {
+		udpConn.Close()
+		return errors.Wrapf(err, "Could not listen for DNS queries over TCP")
+	}
+
+	go server.syncRegistry()
+	go server.serveUDP(udpConn)
+	go server.serveTCP(tcpListener)
+	go func() {
+		<-server.ctx.Done()
+		udpConn.Close()
+		tcpListener.Close()
+	}()
+	log.Infof("Serving DNS records under %s on %s", server.domain, server.config.BindAddr)
+	return nil
+}
+
+func (server *Server) syncRegistry() {
+	for {
+		err := server.registry.sync(server.ctx)
+		if err != nil {
+			log.Warnf("Error following tasks for DNS records: %v", err)
+		}
+		select {
+		case <-server.ctx.Done():
+			return
+		case <-time.After(SyncRetryDuration):
+		}
+	}
+}
+
+func (server *Server) serveUDP(conn net.PacketConn) {
+	buffer := make([]byte, maxMessageLength)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if server.ctx.Err() == nil {
+				log.Errorf("Error reading DNS query over UDP: %v", err)
+			}
+			return
+		}
+		response := server.answer(buffer[:n], maxUDPMessageLength)
+		if response == nil {
+			continue
+		}
+		_, err = conn.WriteTo(response, addr)
+		if err != nil {
+			log.Debugf("Error writing DNS response over UDP: %v", err)
+		}
+	}
+}
+
+func (server *Server) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if server.ctx.Err() == nil {
+				log.Errorf("Error accepting DNS connection over TCP: %v", err)
+			}
+			return
+		}
+		go server.serveTCPConn(conn)
+	}
+}
+
+// serveTCPConn answers the length prefixed queries of a connection until the
+// client closes it or stays idle for too long
+func (server *Server) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpTimeout))
+		var length uint16
+		err := binary.Read(conn, binary.BigEndian, &length)
+		if err != nil {
+			return
+		}
+		query := make([]byte, length)
+		_, err = io.ReadFull(conn, query)
+		if err != nil {
+			return
+		}
+		response := server.answer(query, maxMessageLength)
+		if response == nil {
+			return
+		}
+		_, err = conn.Write(append(appendUint16(nil, uint16(len(response))), response...))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// answer answers a query. Nil is returned if the query is too short to be answered.
+func (server *Server) answer(query []byte, maxLength int) []byte {
+	q, err := parseQuestion(query)
+	if err != nil {
+		if len(query) < headerLength {
+			return nil
+		}
+		return buildResponse(question{id: q.id, flags: q.flags}, rcodeFormatError, nil, nil, maxLength)
+	}
+	if q.flags&opcodeMask != 0 {
+		return buildResponse(q, rcodeNotImplemented, nil, nil, maxLength)
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(q.name, "."))
+	if (q.class != classINET && q.class != classANY) || !strings.HasSuffix(name, "."+server.domain) {
+		return buildResponse(q, rcodeRefused, nil, nil, maxLength)
+	}
+	if !server.registry.isReady() {
+		return buildResponse(q, rcodeServerFailure, nil, nil, maxLength)
+	}
+
+	labels := strings.Split(strings.TrimSuffix(name, "."+server.domain), ".")
+	var answers, additionals []resource
+	var found bool
+	switch len(labels) {
+	case 2:
+		answers, found = server.familyRecords(q, labels[0], labels[1])
+	case 3:
+		answers, found = server.taskRecords(q, labels[0], labels[1], labels[2])
+	case 4:
+		answers, additionals, found = server.serviceRecords(q, labels[0], labels[1], labels[2], labels[3])
+	}
+	if !found {
+		return buildResponse(q, rcodeNameError, nil, nil, maxLength)
+	}
+	return buildResponse(q, rcodeSuccess, answers, additionals, maxLength)
+}
+
+// familyRecords answers <family>.<cluster> with the IPs of the running tasks of the family
+func (server *Server) familyRecords(q question, family string, cluster string) ([]resource, bool) {
+	endpoints := server.registry.lookup(cluster, family)
+	if len(endpoints) == 0 {
+		return nil, false
+	}
+	if !wantsType(q, typeA) {
+		return nil, true
+	}
+	return server.addressRecords(q.name, endpoints), true
+}
+
+// taskRecords answers <task ID>.<family>.<cluster> with the IP of the task
+func (server *Server) taskRecords(q question, taskID string, family string, cluster string) ([]resource, bool) {
+	var endpoints []discovery.Endpoint
+	for _, endpoint := range server.registry.lookup(cluster, family) {
+		if strings.EqualFold(getTaskID(endpoint.TaskARN), taskID) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, false
+	}
+	if !wantsType(q, typeA) {
+		return nil, true
+	}
+	return server.addressRecords(q.name, endpoints), true
+}
+
+// serviceRecords answers _<container>._<protocol>.<family>.<cluster> with an
+// SRV record per endpoint and the A records of the targets of those records
+func (server *Server) serviceRecords(q question, containerLabel string, protocolLabel string, family string, cluster string) ([]resource, []resource, bool) {
+	if !strings.HasPrefix(containerLabel, "_") || !strings.HasPrefix(protocolLabel, "_") {
+		return nil, nil, false
+	}
+	container, protocol := containerLabel[1:], protocolLabel[1:]
+
+	var endpoints []discovery.Endpoint
+	for _, endpoint := range server.registry.lookup(cluster, family) {
+		if strings.EqualFold(endpoint.ContainerName, container) && strings.EqualFold(endpoint.Protocol, protocol) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, nil, false
+	}
+	if !wantsType(q, typeSRV) {
+		return nil, nil, true
+	}
+
+	var answers, additionals []resource
+	targets := make(map[string]struct{})
+	for _, endpoint := range endpoints {
+		target := strings.Join([]string{strings.ToLower(getTaskID(endpoint.TaskARN)), family, cluster, server.domain}, ".")
+		answers = append(answers, resource{
+			name:  q.name,
+			rtype: typeSRV,
+			ttl:   server.ttl,
+			srv: &srv{
+				priority: srvPriority,
+				weight:   srvWeight,
+				port:     uint16(endpoint.HostPort),
+				target:   target,
+			},
+		})
+		if _, ok := targets[target]; ok {
+			continue
+		}
+		targets[target] = struct{}{}
+		additionals = append(additionals, server.addressRecords(target, []discovery.Endpoint{endpoint})...)
+	}
+	return answers, additionals, true
+}
+
+// addressRecords returns an A record for each distinct IP of the endpoints
+func (server *Server) addressRecords(name string, endpoints []discovery.Endpoint) []resource {
+	ips := make(map[string]net.IP)
+	for _, endpoint := range endpoints {
+		if ip := net.ParseIP(endpoint.IP).To4(); ip != nil {
+			ips[ip.String()] = ip
+		}
+	}
+	keys := make([]string, 0, len(ips))
+	for key := range ips {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]resource, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, resource{name: name, rtype: typeA, ttl: server.ttl, ip: ips[key]})
+	}
+	return records
+}
+
+func wantsType(q question, rtype uint16) bool {
+	return q.qtype == rtype || q.qtype == typeANY
+}
+
+// getTaskID returns the ID of a task, the last part of its ARN
+func getTaskID(taskARN string) string {
+	return taskARN[strings.LastIndex(taskARN, "/")+1:]
+}
@@ -0,0 +1,179 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDomain = "blox.local"
+	taskID1    = "271022c0-f894-4aa2-b063-25bae55088d5"
+	taskID2    = "e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+)
+
+func (context *registryMockContext) server(t *testing.T) *Server {
+	server, err := NewServer(ctx(), context.stores, context.taskDefinitionLoader, context.hostResolver,
+		Config{BindAddr: "127.0.0.1:0", Domain: testDomain + ".", TTL: 5 * time.Second})
+	assert.Nil(t, err, "Unexpected error creating DNS server")
+	return server
+}
+
+func (context *registryMockContext) loadedServer(t *testing.T) *Server {
+	context.expectLoad(runningTask(taskARN1, 32768), runningTask(taskARN2, 32769))
+	server := context.server(t)
+	err := server.registry.load()
+	assert.Nil(t, err, "Unexpected error loading registry")
+	return server
+}
+
+func TestNewServerInvalidConfig(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := NewServer(ctx(), context.stores, context.taskDefinitionLoader, context.hostResolver, Config{Domain: testDomain})
+	assert.Error(t, err, "Expected an error when the listen address is not set")
+
+	_, err = NewServer(ctx(), context.stores, context.taskDefinitionLoader, context.hostResolver, Config{BindAddr: ":53", Domain: "."})
+	assert.Error(t, err, "Expected an error when the domain is not set")
+
+	_, err = NewServer(ctx(), context.stores, context.taskDefinitionLoader, nil, Config{BindAddr: ":53", Domain: testDomain})
+	assert.Error(t, err, "Expected an error when the host resolver is nil")
+
+	_, err = NewServer(ctx(), context.stores, context.taskDefinitionLoader, context.hostResolver,
+		Config{BindAddr: ":53", Domain: testDomain, TTL: -time.Second})
+	assert.Error(t, err, "Expected an error when the TTL is negative")
+}
+
+func TestAnswerServiceRecords(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.loadedServer(t)
+
+	response := parseResponse(t, server.answer(buildQuery(7, "_web._tcp.web.default.blox.local", typeSRV), maxUDPMessageLength))
+	assert.Equal(t, uint16(7), response.id, "Unexpected response ID")
+	assert.Equal(t, rcodeSuccess, response.rcode, "Unexpected response code")
+	assert.Equal(t, []testRecord{
+		{name: "_web._tcp.web.default.blox.local", rtype: typeSRV, ttl: 5, value: "1 1 32768 " + taskID1 + ".web.default.blox.local"},
+		{name: "_web._tcp.web.default.blox.local", rtype: typeSRV, ttl: 5, value: "1 1 32769 " + taskID2 + ".web.default.blox.local"},
+	}, response.answers, "Unexpected SRV records")
+	assert.Equal(t, []testRecord{
+		{name: taskID1 + ".web.default.blox.local", rtype: typeA, ttl: 5, value: hostIP},
+		{name: taskID2 + ".web.default.blox.local", rtype: typeA, ttl: 5, value: hostIP},
+	}, response.additionals, "Unexpected additional records")
+}
+
+func TestAnswerAddressRecords(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.loadedServer(t)
+
+	response := parseResponse(t, server.answer(buildQuery(1, "web.default.blox.local", typeA), maxUDPMessageLength))
+	assert.Equal(t, rcodeSuccess, response.rcode, "Unexpected response code")
+	assert.Equal(t, []testRecord{{name: "web.default.blox.local", rtype: typeA, ttl: 5, value: hostIP}}, response.answers,
+		"Expected a single A record for tasks sharing a host")
+
+	response = parseResponse(t, server.answer(buildQuery(1, taskID2+".Web.Default.blox.local.", typeA), maxUDPMessageLength))
+	assert.Equal(t, rcodeSuccess, response.rcode, "Unexpected response code")
+	assert.Len(t, response.answers, 1, "Expected an A record for the task")
+}
+
+func TestAnswerNoData(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.loadedServer(t)
+
+	response := parseResponse(t, server.answer(buildQuery(1, "web.default.blox.local", typeSRV), maxUDPMessageLength))
+	assert.Equal(t, rcodeSuccess, response.rcode, "Expected success for a known name of another type")
+	assert.Empty(t, response.answers, "Expected no records for a known name of another type")
+}
+
+func TestAnswerUnknownNames(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.loadedServer(t)
+
+	for _, name := range []string{
+		"api.default.blox.local",
+		"_web._udp.web.default.blox.local",
+		"web._tcp.web.default.blox.local",
+		"unknown.web.default.blox.local",
+		"default.blox.local",
+	} {
+		response := parseResponse(t, server.answer(buildQuery(1, name, typeANY), maxUDPMessageLength))
+		assert.Equal(t, rcodeNameError, response.rcode, "Expected NXDOMAIN for %s", name)
+	}
+
+	response := parseResponse(t, server.answer(buildQuery(1, "example.com", typeA), maxUDPMessageLength))
+	assert.Equal(t, rcodeRefused, response.rcode, "Expected queries outside the domain to be refused")
+}
+
+func TestAnswerRegistryNotReady(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.server(t)
+
+	response := parseResponse(t, server.answer(buildQuery(1, "web.default.blox.local", typeA), maxUDPMessageLength))
+	assert.Equal(t, rcodeServerFailure, response.rcode, "Expected a server failure before the registry is loaded")
+}
+
+func TestAnswerMalformedQuery(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.server(t)
+
+	assert.Nil(t, server.answer([]byte{0, 1}, maxUDPMessageLength), "Expected no response to a query shorter than its header")
+
+	query := buildQuery(3, "web.default.blox.local", typeA)
+	response := parseResponse(t, server.answer(query[:len(query)-2], maxUDPMessageLength))
+	assert.Equal(t, uint16(3), response.id, "Unexpected response ID")
+	assert.Equal(t, rcodeFormatError, response.rcode, "Expected a format error for a truncated query")
+}
+
+func TestServeUDP(t *testing.T) {
+	context := newRegistryMockContext(t)
+	defer context.mockCtrl.Finish()
+	server := context.loadedServer(t)
+
+	ctx, cancel := contextWithCancel()
+	defer cancel()
+	server.ctx = ctx
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error listening over UDP")
+	defer conn.Close()
+	go server.serveUDP(conn)
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	assert.Nil(t, err, "Unexpected error dialing DNS server")
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = client.Write(buildQuery(9, "_web._tcp.web.default.blox.local", typeSRV))
+	assert.Nil(t, err, "Unexpected error writing query")
+
+	buffer := make([]byte, maxUDPMessageLength)
+	n, err := client.Read(buffer)
+	assert.Nil(t, err, "Unexpected error reading response")
+	response := parseResponse(t, buffer[:n])
+	assert.Equal(t, uint16(9), response.id, "Unexpected response ID")
+	assert.Len(t, response.answers, 2, "Expected SRV records of both tasks")
+}
+
+func contextWithCancel() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}
@@ -0,0 +1,86 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package election elects, through etcd, the one instance of the cluster
+// state service that does work that instances must not do concurrently.
+package election
+
+import (
+	"os"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	// campaignRetryInterval is how long an instance waits before campaigning
+	// again after campaigning failed
+	campaignRetryInterval = 10 * time.Second
+)
+
+// Campaign blocks until this instance leads or 'ctx' is done. Leadership is
+// lost when the returned channel is closed and given up by calling the
+// returned function.
+type Campaign func(ctx context.Context) (<-chan struct{}, func(), error)
+
+// NewCampaign campaigns in the etcd election with key prefix 'keyPrefix',
+// whose leadership is tied to a session lease that expires if the leader
+// stops renewing it
+func NewCampaign(client *clientv3.Client, keyPrefix string) Campaign {
+	return func(ctx context.Context) (<-chan struct{}, func(), error) {
+		session, err := concurrency.NewSession(client, concurrency.WithContext(ctx))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Could not open an etcd session")
+		}
+		hostname, _ := os.Hostname()
+		election := concurrency.NewElection(session, keyPrefix)
+		if err := election.Campaign(ctx, hostname); err != nil {
+			session.Close()
+			return nil, nil, errors.Wrapf(err, "Could not campaign in the election '%s'", keyPrefix)
+		}
+		return session.Done(), func() { session.Close() }, nil
+	}
+}
+
+// Lead campaigns with 'campaign' and calls 'lead' whenever this instance
+// leads, until 'ctx' is done. 'lead' has to return once 'ctx' is done or the
+// lost channel it is called with is closed. 'role' names what is led in logs.
+func Lead(ctx context.Context, campaign Campaign, role string, lead func(lost <-chan struct{})) {
+	for {
+		lost, resign, err := campaign(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnf("Could not campaign to lead %s: %+v", role, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(campaignRetryInterval):
+			}
+			continue
+		}
+
+		log.Infof("Leading %s", role)
+		lead(lost)
+		resign()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Infof("Lost the lead of %s", role)
+	}
+}
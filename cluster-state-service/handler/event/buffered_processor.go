@@ -0,0 +1,183 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/goguardian/blox/cluster-state-service/handler/breaker"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// drainInterval is how often buffered events are drained
+	drainInterval = time.Second
+)
+
+// EventBuffer defines methods of a durable first-in, first-out queue of events
+type EventBuffer interface {
+	Append(record []byte) error
+	Peek() ([]byte, bool, error)
+	Remove() error
+	Len() int
+}
+
+// BufferedProcessor is a processor that keeps accepting events while etcd is
+// unavailable. Events are applied by a processor while the etcd circuit
+// breaker is closed and nothing is buffered. Otherwise, and when applying them
+// fails because etcd is unavailable, they are appended to a durable buffer,
+// which is drained in order once etcd is available again. ProcessEvent
+// returns nil only once an event is applied or buffered, so that the consumer
+// does not acknowledge events that could still be lost.
+type BufferedProcessor struct {
+	// lock is held for reading while events are processed, which may happen
+	// concurrently, and for writing while a buffered event is drained
+	lock      sync.RWMutex
+	processor Processor
+	buffer    EventBuffer
+	breaker   *breaker.Breaker
+}
+
+// NewBufferedProcessor initializes a processor that applies events with
+// 'processor', buffering them in 'buffer' while 'etcdBreaker' is open
+func NewBufferedProcessor(processor Processor, buffer EventBuffer, etcdBreaker *breaker.Breaker) (*BufferedProcessor, error) {
+	if processor == nil {
+		return nil, errors.Errorf("The event processor is not initialized")
+	}
+	if buffer == nil {
+		return nil, errors.Errorf("The event buffer is not initialized")
+	}
+	if etcdBreaker == nil {
+		return nil, errors.Errorf("The etcd circuit breaker is not initialized")
+	}
+	metrics.SetBufferedEvents(buffer.Len())
+	return &BufferedProcessor{
+		processor: processor,
+		buffer:    buffer,
+		breaker:   etcdBreaker,
+	}, nil
+}
+
+// ProcessEvent applies an event, or buffers it if etcd is unavailable or
+// events are already buffered
+func (processor *BufferedProcessor) ProcessEvent(event string) error {
+	processor.lock.RLock()
+	defer processor.lock.RUnlock()
+
+	// events are applied directly only if that keeps them in order
+	if processor.buffer.Len() == 0 && processor.breaker.Allow() {
+		err := processor.processor.ProcessEvent(event)
+		if !store.IsUnavailable(err) {
+			if err == nil {
+				processor.breaker.Success()
+			}
+			return err
+		}
+		processor.breaker.Failure()
+		log.Warnf("Buffering event because etcd is unavailable: %v", err)
+	}
+
+	_, metricsType, err := parseEvent(event)
+	if err != nil {
+		return err
+	}
+	err = processor.buffer.Append([]byte(event))
+	if err != nil {
+		return errors.Wrapf(err, "Could not buffer event")
+	}
+	metrics.ObserveEvent(metricsType, metrics.EventBuffered, time.Time{})
+	metrics.SetBufferedEvents(processor.buffer.Len())
+	return nil
+}
+
+// Buffered returns the number of events waiting to be applied
+func (processor *BufferedProcessor) Buffered() int {
+	processor.lock.RLock()
+	defer processor.lock.RUnlock()
+	return processor.buffer.Len()
+}
+
+// Drain applies buffered events in order whenever the etcd circuit breaker
+// lets requests through, until 'ctx' is done
+func (processor *BufferedProcessor) Drain(ctx context.Context) {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			processor.drain(ctx)
+		}
+	}
+}
+
+// drain applies buffered events until the buffer is empty, the breaker opens
+// or 'ctx' is done
+func (processor *BufferedProcessor) drain(ctx context.Context) {
+	for ctx.Err() == nil && processor.breaker.Allow() {
+		if !processor.drainNext() {
+			return
+		}
+	}
+}
+
+// drainNext applies the oldest buffered event, returning false if there is
+// none or it has to be retried later
+func (processor *BufferedProcessor) drainNext() bool {
+	processor.lock.Lock()
+	defer processor.lock.Unlock()
+
+	record, ok, err := processor.buffer.Peek()
+	if err != nil {
+		log.Errorf("Could not read buffered event: %+v", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	event := string(record)
+	err = processor.processor.ProcessEvent(event)
+	if store.IsUnavailable(err) {
+		processor.breaker.Failure()
+		return false
+	}
+	processor.breaker.Success()
+	if err != nil {
+		// the event cannot be applied no matter how often it is retried,
+		// and keeping it would hold back the events buffered after it
+		log.Errorf("Dropping buffered event '%s' that could not be applied: %+v", event, err)
+		_, metricsType, _ := parseEvent(event)
+		if metricsType == "" {
+			metricsType = unknownMetricsType
+		}
+		metrics.ObserveEvent(metricsType, metrics.EventDropped, time.Time{})
+	}
+
+	err = processor.buffer.Remove()
+	if err != nil {
+		// the event is applied again once removing it succeeds, which
+		// the version checks of the stores make harmless
+		log.Errorf("Could not remove applied event from buffer: %+v", err)
+		return false
+	}
+	metrics.SetBufferedEvents(processor.buffer.Len())
+	return true
+}
@@ -0,0 +1,166 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package event
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/breaker"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/goguardian/blox/cluster-state-service/handler/wal"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	firstBufferedEvent  = `{"detail-type":"ECS Task State Change","time":"1"}`
+	secondBufferedEvent = `{"detail-type":"ECS Task State Change","time":"2"}`
+)
+
+var (
+	errUnavailable = errors.Wrap(context.DeadlineExceeded, "Etcd is down")
+	backgroundCtx  = context.Background()
+)
+
+type bufferedProcessorTestContext struct {
+	mockCtrl  *gomock.Controller
+	inner     *mocks.MockProcessor
+	buffer    *wal.Log
+	dir       string
+	breaker   *breaker.Breaker
+	processor *BufferedProcessor
+}
+
+func newBufferedProcessorTestContext(t *testing.T, maxFailures int) *bufferedProcessorTestContext {
+	context := bufferedProcessorTestContext{}
+	context.mockCtrl = gomock.NewController(t)
+	context.inner = mocks.NewMockProcessor(context.mockCtrl)
+
+	var err error
+	context.dir, err = ioutil.TempDir("", "buffer")
+	assert.Nil(t, err, "Unexpected error creating buffer directory")
+	context.buffer, err = wal.Open(context.dir)
+	assert.Nil(t, err, "Unexpected error opening buffer")
+	context.breaker, err = breaker.New("etcd", maxFailures, func() error { return nil }, time.Second, nil)
+	assert.Nil(t, err, "Unexpected error creating breaker")
+	context.processor, err = NewBufferedProcessor(context.inner, context.buffer, context.breaker)
+	assert.Nil(t, err, "Unexpected error creating buffered processor")
+	return &context
+}
+
+func (context *bufferedProcessorTestContext) finish() {
+	context.mockCtrl.Finish()
+	context.buffer.Close()
+	os.RemoveAll(context.dir)
+}
+
+func TestNewBufferedProcessorInvalidArguments(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 1)
+	defer context.finish()
+
+	_, err := NewBufferedProcessor(nil, context.buffer, context.breaker)
+	assert.Error(t, err, "Expected an error when the processor is nil")
+	_, err = NewBufferedProcessor(context.inner, nil, context.breaker)
+	assert.Error(t, err, "Expected an error when the buffer is nil")
+	_, err = NewBufferedProcessor(context.inner, context.buffer, nil)
+	assert.Error(t, err, "Expected an error when the breaker is nil")
+}
+
+func TestBufferedProcessorAppliesEventsWhileEtcdIsAvailable(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 1)
+	defer context.finish()
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(nil)
+	assert.Nil(t, context.processor.ProcessEvent(firstBufferedEvent), "Unexpected error processing event")
+	assert.Equal(t, 0, context.processor.Buffered(), "Unexpected buffered event")
+}
+
+func TestBufferedProcessorReturnsErrorsOtherThanUnavailability(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 1)
+	defer context.finish()
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errors.New("Invalid task"))
+	assert.Error(t, context.processor.ProcessEvent(firstBufferedEvent), "Expected an error when the event cannot be applied")
+	assert.Equal(t, 0, context.processor.Buffered(), "Unexpected buffered event")
+	assert.True(t, context.breaker.Allow(), "Expected breaker to stay closed")
+}
+
+func TestBufferedProcessorBuffersEventsWhileEtcdIsUnavailable(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 1)
+	defer context.finish()
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errUnavailable)
+	assert.Nil(t, context.processor.ProcessEvent(firstBufferedEvent), "Unexpected error buffering event")
+	assert.False(t, context.breaker.Allow(), "Expected breaker to open")
+
+	// the breaker is open, so events are buffered without being applied
+	assert.Nil(t, context.processor.ProcessEvent(secondBufferedEvent), "Unexpected error buffering event")
+	assert.Error(t, context.processor.ProcessEvent("invalid"), "Expected an error buffering an invalid event")
+	assert.Equal(t, 2, context.processor.Buffered(), "Unexpected number of buffered events")
+}
+
+func TestBufferedProcessorKeepsEventsInOrderWhileDraining(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 2)
+	defer context.finish()
+
+	// the breaker stays closed, but the event is buffered, so the next
+	// one has to be buffered behind it
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errUnavailable)
+	assert.Nil(t, context.processor.ProcessEvent(firstBufferedEvent), "Unexpected error buffering event")
+	assert.Nil(t, context.processor.ProcessEvent(secondBufferedEvent), "Unexpected error buffering event")
+
+	gomock.InOrder(
+		context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(nil),
+		context.inner.EXPECT().ProcessEvent(secondBufferedEvent).Return(nil),
+	)
+	context.processor.drain(backgroundCtx)
+	assert.Equal(t, 0, context.processor.Buffered(), "Expected buffer to be drained")
+}
+
+func TestBufferedProcessorDrainStopsWhileEtcdIsUnavailable(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 2)
+	defer context.finish()
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errUnavailable)
+	assert.Nil(t, context.processor.ProcessEvent(firstBufferedEvent), "Unexpected error buffering event")
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errUnavailable)
+	context.processor.drain(backgroundCtx)
+	assert.Equal(t, 1, context.processor.Buffered(), "Expected event to stay buffered")
+	assert.False(t, context.breaker.Allow(), "Expected breaker to open")
+
+	context.processor.drain(backgroundCtx)
+	assert.Equal(t, 1, context.processor.Buffered(), "Expected event to stay buffered while the breaker is open")
+}
+
+func TestBufferedProcessorDrainDropsEventsThatCannotBeApplied(t *testing.T) {
+	context := newBufferedProcessorTestContext(t, 2)
+	defer context.finish()
+
+	context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errUnavailable)
+	assert.Nil(t, context.processor.ProcessEvent(firstBufferedEvent), "Unexpected error buffering event")
+	assert.Nil(t, context.processor.ProcessEvent(secondBufferedEvent), "Unexpected error buffering event")
+
+	gomock.InOrder(
+		context.inner.EXPECT().ProcessEvent(firstBufferedEvent).Return(errors.New("Invalid task")),
+		context.inner.EXPECT().ProcessEvent(secondBufferedEvent).Return(nil),
+	)
+	context.processor.drain(backgroundCtx)
+	assert.Equal(t, 0, context.processor.Buffered(), "Expected buffer to be drained")
+}
@@ -14,10 +14,60 @@
 package event
 
 import (
+	"sync"
+	"time"
+
 	"golang.org/x/net/context"
 )
 
+// maxConcurrentEvents bounds the number of events of a poll that are
+// processed at the same time. Processing events concurrently lets the store
+// apply them to etcd in batches.
+const maxConcurrentEvents = 64
+
 // Consumer defines methods to consume events from a queue
 type Consumer interface {
 	PollForEvents(ctx context.Context)
+	// LastPoll returns when the queue was last polled successfully, or the
+	// zero time if it has not been yet
+	LastPoll() time.Time
+}
+
+// pollStatus records when a consumer last polled its queue successfully
+type pollStatus struct {
+	lock sync.RWMutex
+	last time.Time
+}
+
+func (status *pollStatus) polled() {
+	status.lock.Lock()
+	defer status.lock.Unlock()
+	status.last = time.Now()
+}
+
+func (status *pollStatus) lastPoll() time.Time {
+	status.lock.RLock()
+	defer status.lock.RUnlock()
+	return status.last
+}
+
+// processConcurrently calls 'process' for each of 'count' events, processing
+// up to maxConcurrentEvents at a time, and returns once all were processed.
+// Events may be processed in any order, the store keeps the newest version
+// of each record regardless.
+func processConcurrently(count int, process func(i int)) {
+	slots := make(chan struct{}, maxConcurrentEvents)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			process(i)
+		}(i)
+	}
+	wg.Wait()
 }
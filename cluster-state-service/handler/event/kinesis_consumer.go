@@ -18,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
 	log "github.com/cihub/seelog"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"time"
@@ -27,7 +28,6 @@ const (
 	kinesisWaitTimeSeconds   = 10
 	kinesisStartingShardId   = "shardId-000000000000"
 	kinesisShardIteratorType = kinesis.ShardIteratorTypeTrimHorizon
-	kinesisGetRecordsSize    = 100
 )
 
 type kinesisEventConsumer struct {
@@ -35,9 +35,13 @@ type kinesisEventConsumer struct {
 	streamName string
 	processor  Processor
 	iterator   *string
+	batchSize  int
+	status     *pollStatus
 }
 
-func NewKinesisConsumer(kinesis kinesisiface.KinesisAPI, processor Processor, streamName string) (Consumer, error) {
+// NewKinesisConsumer initializes a consumer of the events of Kinesis stream
+// 'streamName' that reads up to 'batchSize' records at a time
+func NewKinesisConsumer(kinesis kinesisiface.KinesisAPI, processor Processor, streamName string, batchSize int) (Consumer, error) {
 	if kinesis == nil {
 		return nil, errors.Errorf("The Kinesis API interface is not initialized")
 	}
@@ -52,6 +56,8 @@ func NewKinesisConsumer(kinesis kinesisiface.KinesisAPI, processor Processor, st
 		kinesis:    kinesis,
 		streamName: streamName,
 		processor:  processor,
+		batchSize:  batchSize,
+		status:     &pollStatus{},
 	}, nil
 }
 
@@ -66,6 +72,10 @@ func (kinesisConsumer *kinesisEventConsumer) PollForEvents(ctx context.Context)
 	}
 }
 
+func (kinesisConsumer *kinesisEventConsumer) LastPoll() time.Time {
+	return kinesisConsumer.status.lastPoll()
+}
+
 func (kinesisConsumer *kinesisEventConsumer) pollForMessages() {
 	if kinesisConsumer.iterator == nil {
 		iteratorRequest := &kinesis.GetShardIteratorInput{
@@ -82,7 +92,7 @@ func (kinesisConsumer *kinesisEventConsumer) pollForMessages() {
 	}
 
 	recordsRequest := &kinesis.GetRecordsInput{
-		Limit:         aws.Int64(kinesisGetRecordsSize),
+		Limit:         aws.Int64(int64(kinesisConsumer.batchSize)),
 		ShardIterator: kinesisConsumer.iterator,
 	}
 	recordsResponse, err := kinesisConsumer.kinesis.GetRecords(recordsRequest)
@@ -91,11 +101,17 @@ func (kinesisConsumer *kinesisEventConsumer) pollForMessages() {
 		kinesisConsumer.iterator = nil
 		return
 	}
+	kinesisConsumer.status.polled()
 
-	for _, record := range recordsResponse.Records {
-		kinesisConsumer.processor.ProcessEvent(string(record.Data[:]))
+	if recordsResponse.MillisBehindLatest != nil {
+		metrics.SetKinesisMillisBehindLatest(aws.Int64Value(recordsResponse.MillisBehindLatest))
 	}
 
+	records := recordsResponse.Records
+	processConcurrently(len(records), func(i int) {
+		kinesisConsumer.processor.ProcessEvent(string(records[i].Data[:]))
+	})
+
 	kinesisConsumer.iterator = recordsResponse.NextShardIterator
 	if len(recordsResponse.Records) == 0 {
 		time.Sleep(kinesisWaitTimeSeconds * time.Second)
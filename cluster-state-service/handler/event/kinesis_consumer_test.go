@@ -20,11 +20,13 @@ import (
 	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
+	"sync/atomic"
 	"testing"
 )
 
 const (
 	streamName          = "test"
+	getRecordsSize      = 100
 	kinesisMessageBody1 = "messageBody"
 	kinesisMessageBody2 = "messageBody2"
 )
@@ -108,7 +110,7 @@ func TestNewConsumerNilKinesis(t *testing.T) {
 	context := NewConsumerMockKinesisContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewKinesisConsumer(nil, context.processor, streamName)
+	_, err := NewKinesisConsumer(nil, context.processor, streamName, getRecordsSize)
 	if err == nil {
 		t.Error("Expected an error when kinesis is nil")
 	}
@@ -118,7 +120,7 @@ func TestNewConsumerKinesisNilProcessor(t *testing.T) {
 	context := NewConsumerMockKinesisContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewKinesisConsumer(context.kinesisClient, nil, streamName)
+	_, err := NewKinesisConsumer(context.kinesisClient, nil, streamName, getRecordsSize)
 	if err == nil {
 		t.Error("Expected an error when processor is nil")
 	}
@@ -128,7 +130,7 @@ func TestNewConsumerKinesisEmptyQueueName(t *testing.T) {
 	context := NewConsumerMockKinesisContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewKinesisConsumer(context.kinesisClient, context.processor, "")
+	_, err := NewKinesisConsumer(context.kinesisClient, context.processor, "", getRecordsSize)
 	if err == nil {
 		t.Error("Expected an error when stream name is empty")
 	}
@@ -140,7 +142,7 @@ func TestPollForKinesisEventsSingleMessages(t *testing.T) {
 
 	mockContext.kinesisClient.EXPECT().GetShardIterator(gomock.Eq(mockContext.getShardIteratorInput)).Return(mockContext.getShardIteratorOutput, nil)
 
-	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName)
+	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName, getRecordsSize)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -165,7 +167,7 @@ func TestPollForKinesisEventsShardIteratorFailsOnce(t *testing.T) {
 	mockContext.kinesisClient.EXPECT().GetShardIterator(gomock.Eq(mockContext.getShardIteratorInput)).Return(nil, errors.New("Shard iterator call failed."))
 	mockContext.kinesisClient.EXPECT().GetShardIterator(gomock.Eq(mockContext.getShardIteratorInput)).Return(mockContext.getShardIteratorOutput, nil)
 
-	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName)
+	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName, getRecordsSize)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -187,7 +189,7 @@ func TestPollForKinesisEventsGetRecordsFailsOnce(t *testing.T) {
 
 	mockContext.kinesisClient.EXPECT().GetShardIterator(gomock.Eq(mockContext.getShardIteratorInput)).Return(mockContext.getShardIteratorOutput, nil)
 
-	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName)
+	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName, getRecordsSize)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -203,6 +205,9 @@ func TestPollForKinesisEventsGetRecordsFailsOnce(t *testing.T) {
 	})
 
 	c.PollForEvents(ctx)
+	if c.LastPoll().IsZero() {
+		t.Error("Consumer should record successful polls")
+	}
 }
 
 func TestPollForKinesisEventsReceiveTwoMessages(t *testing.T) {
@@ -211,25 +216,23 @@ func TestPollForKinesisEventsReceiveTwoMessages(t *testing.T) {
 
 	mockContext.kinesisClient.EXPECT().GetShardIterator(gomock.Eq(mockContext.getShardIteratorInput)).Return(mockContext.getShardIteratorOutput, nil)
 
-	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName)
+	c, err := NewKinesisConsumer(mockContext.kinesisClient, mockContext.processor, streamName, getRecordsSize)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	pollCount := 0
+	var processed int32
 
 	mockContext.kinesisClient.EXPECT().GetRecords(mockContext.getRecordsInput).Return(mockContext.getRecordsTwoMessagesOutput, nil)
 	mockContext.processor.EXPECT().ProcessEvent(kinesisMessageBody1).Return(nil).Do(func(x interface{}) {
-		pollCount++
-		if pollCount == 2 {
+		if atomic.AddInt32(&processed, 1) == 2 {
 			cancel()
 		}
 	})
 	mockContext.processor.EXPECT().ProcessEvent(kinesisMessageBody2).Return(nil).Do(func(x interface{}) {
-		pollCount++
-		if pollCount == 2 {
+		if atomic.AddInt32(&processed, 1) == 2 {
 			cancel()
 		}
 	})
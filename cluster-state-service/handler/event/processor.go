@@ -15,20 +15,43 @@ package event
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 	"github.com/pkg/errors"
 )
 
 // Unmarshal event message json by type
 type eventType struct {
-	Type string `json:"detail-type"`
+	Type   string       `json:"detail-type"`
+	Time   string       `json:"time"`
+	Detail *eventDetail `json:"detail"`
+}
+
+// Unmarshal the cluster of the resource that the event is about
+type eventDetail struct {
+	ClusterARN string `json:"clusterArn"`
 }
 
 // Detail-type in the event stream message must match one of these strings
 const (
-	taskType              = "ECS Task State Change"
-	containerInstanceType = "ECS Container Instance State Change"
+	taskType                  = "ECS Task State Change"
+	containerInstanceType     = "ECS Container Instance State Change"
+	serviceActionType         = "ECS Service Action"
+	deploymentStateChangeType = "ECS Deployment State Change"
+)
+
+const unknownMetricsType = "unknown"
+
+var (
+	// metricsTypes maps the detail-type of recognized events to the type they are labeled with in metrics
+	metricsTypes = map[string]string{
+		taskType:                  "task",
+		containerInstanceType:     "container_instance",
+		serviceActionType:         "service_action",
+		deploymentStateChangeType: "deployment_state_change",
+	}
 )
 
 // Processor defines methods to process events
@@ -48,17 +71,47 @@ func NewProcessor(stores store.Stores) Processor {
 
 // ProcessEvent takes an event JSON, unmarhsals and stores it in the datastore
 func (processor eventProcessor) ProcessEvent(event string) error {
+	et, metricsType, err := parseEvent(event)
+	if err != nil {
+		return err
+	}
+
+	err = processor.applyEvent(et, event)
+	if err != nil {
+		metrics.ObserveEvent(metricsType, metrics.EventFailed, time.Time{})
+		return err
+	}
+	metrics.ObserveEvent(metricsType, metrics.EventApplied, et.emittedAt())
+	return nil
+}
+
+// parseEvent determines the type of an event JSON, returning an error if it is
+// not an event of a recognized type
+func parseEvent(event string) (eventType, string, error) {
+	var et eventType
 	if event == "" {
-		return errors.New("Event cannot be empty")
+		metrics.ObserveEvent(unknownMetricsType, metrics.EventInvalid, time.Time{})
+		return et, "", errors.New("Event cannot be empty")
 	}
 
 	// Determine the type of event based on the detail-type in the message
-	var et eventType
 	err := json.Unmarshal([]byte(event), &et)
 	if err != nil {
-		return errors.Wrapf(err, "Error unmarshaling event '%s' in the processor", event)
+		metrics.ObserveEvent(unknownMetricsType, metrics.EventInvalid, time.Time{})
+		return et, "", errors.Wrapf(err, "Error unmarshaling event '%s' in the processor", event)
 	}
 
+	metricsType, ok := metricsTypes[et.Type]
+	if !ok {
+		metrics.ObserveEvent(unknownMetricsType, metrics.EventUnrecognized, time.Time{})
+		return et, "", errors.Errorf("Unrecognized task type: %v", et.Type)
+	}
+	return et, metricsType, nil
+}
+
+// applyEvent stores an event of a recognized type in the datastore
+func (processor eventProcessor) applyEvent(et eventType, event string) error {
+	var err error
 	switch et.Type {
 	case taskType:
 		err = processor.stores.TaskStore.AddTask(event)
@@ -72,9 +125,39 @@ func (processor eventProcessor) ProcessEvent(event string) error {
 			return err
 		}
 
+	case serviceActionType:
+		err = processor.stores.ServiceStore.AddServiceActionEvent(event)
+		if err != nil {
+			return err
+		}
+
+	case deploymentStateChangeType:
+		err = processor.stores.ServiceStore.AddDeploymentStateChangeEvent(event)
+		if err != nil {
+			return err
+		}
+
 	default:
 		return errors.Errorf("Unrecognized task type: %v", et.Type)
 	}
 
+	// Clusters are recorded once their first event is processed, so that
+	// they do not have to wait for the reconciler to be listed
+	if et.Detail != nil && et.Detail.ClusterARN != "" {
+		err = processor.stores.ClusterStore.EnsureCluster(et.Detail.ClusterARN)
+		if err != nil {
+			return errors.Wrapf(err, "Error recording cluster '%s' of event", et.Detail.ClusterARN)
+		}
+	}
+
 	return nil
 }
+
+// emittedAt returns the time ECS emitted the event at, or the zero time if it is unknown
+func (et eventType) emittedAt() time.Time {
+	t, err := time.Parse(time.RFC3339, et.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -24,10 +24,12 @@ import (
 
 const (
 	unknownEventType = "unknown"
+	clusterARN       = "arn:aws:ecs:us-east-1:123456789012:cluster/test"
 )
 
 type event struct {
-	DetailType string `json:"detail-type"`
+	DetailType string       `json:"detail-type"`
+	Detail     *eventDetail `json:"detail,omitempty"`
 }
 
 type processorMockContext struct {
@@ -35,6 +37,8 @@ type processorMockContext struct {
 	stores        store.Stores
 	taskStore     *mocks.MockTaskStore
 	instanceStore *mocks.MockContainerInstanceStore
+	serviceStore  *mocks.MockServiceStore
+	clusterStore  *mocks.MockClusterStore
 }
 
 func NewProcessorMockContext(t *testing.T) *processorMockContext {
@@ -43,10 +47,14 @@ func NewProcessorMockContext(t *testing.T) *processorMockContext {
 
 	context.taskStore = mocks.NewMockTaskStore(context.mockCtrl)
 	context.instanceStore = mocks.NewMockContainerInstanceStore(context.mockCtrl)
+	context.serviceStore = mocks.NewMockServiceStore(context.mockCtrl)
+	context.clusterStore = mocks.NewMockClusterStore(context.mockCtrl)
 
 	context.stores = store.Stores{
 		TaskStore:              context.taskStore,
 		ContainerInstanceStore: context.instanceStore,
+		ServiceStore:           context.serviceStore,
+		ClusterStore:           context.clusterStore,
 	}
 
 	return &context
@@ -145,6 +153,76 @@ func TestProcessEventTaskEvent(t *testing.T) {
 	}
 }
 
+func TestProcessEventTaskEventEnsuresCluster(t *testing.T) {
+	context := NewProcessorMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	p := NewProcessor(context.stores)
+
+	e := event{
+		DetailType: taskType,
+		Detail:     &eventDetail{ClusterARN: clusterARN},
+	}
+	eventjson, _ := json.Marshal(e)
+
+	gomock.InOrder(
+		context.taskStore.EXPECT().AddTask(string(eventjson)).Return(nil),
+		context.clusterStore.EXPECT().EnsureCluster(clusterARN).Return(nil),
+	)
+
+	err := p.ProcessEvent(string(eventjson))
+
+	if err != nil {
+		t.Error("Unexpected error in ProcessEvent")
+	}
+}
+
+func TestProcessEventTaskEventFailsDoesNotEnsureCluster(t *testing.T) {
+	context := NewProcessorMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	p := NewProcessor(context.stores)
+
+	e := event{
+		DetailType: taskType,
+		Detail:     &eventDetail{ClusterARN: clusterARN},
+	}
+	eventjson, _ := json.Marshal(e)
+
+	context.taskStore.EXPECT().AddTask(string(eventjson)).Return(errors.New("AddTask failed"))
+	context.clusterStore.EXPECT().EnsureCluster(gomock.Any()).Times(0)
+
+	err := p.ProcessEvent(string(eventjson))
+
+	if err == nil {
+		t.Error("Expected ProcessEvent to return an error when AddTask fails")
+	}
+}
+
+func TestProcessEventInstanceEventEnsureClusterFails(t *testing.T) {
+	context := NewProcessorMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	p := NewProcessor(context.stores)
+
+	e := event{
+		DetailType: containerInstanceType,
+		Detail:     &eventDetail{ClusterARN: clusterARN},
+	}
+	eventjson, _ := json.Marshal(e)
+
+	gomock.InOrder(
+		context.instanceStore.EXPECT().AddContainerInstance(string(eventjson)).Return(nil),
+		context.clusterStore.EXPECT().EnsureCluster(clusterARN).Return(errors.New("EnsureCluster failed")),
+	)
+
+	err := p.ProcessEvent(string(eventjson))
+
+	if err == nil {
+		t.Error("Expected ProcessEvent to return an error when EnsureCluster fails")
+	}
+}
+
 func TestProcessEventInstanceEventFails(t *testing.T) {
 	context := NewProcessorMockContext(t)
 	defer context.mockCtrl.Finish()
@@ -184,3 +262,43 @@ func TestProcessEventInstanceEvent(t *testing.T) {
 		t.Error("Unexpected error in ProcessEvent")
 	}
 }
+
+func TestProcessEventServiceActionEvent(t *testing.T) {
+	context := NewProcessorMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	p := NewProcessor(context.stores)
+
+	e := event{
+		DetailType: serviceActionType,
+	}
+	eventjson, _ := json.Marshal(e)
+
+	context.serviceStore.EXPECT().AddServiceActionEvent(string(eventjson)).Return(nil)
+
+	err := p.ProcessEvent(string(eventjson))
+
+	if err != nil {
+		t.Error("Unexpected error in ProcessEvent")
+	}
+}
+
+func TestProcessEventDeploymentStateChangeEventFails(t *testing.T) {
+	context := NewProcessorMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	p := NewProcessor(context.stores)
+
+	e := event{
+		DetailType: deploymentStateChangeType,
+	}
+	eventjson, _ := json.Marshal(e)
+
+	context.serviceStore.EXPECT().AddDeploymentStateChangeEvent(string(eventjson)).Return(errors.New("AddDeploymentStateChangeEvent failed"))
+
+	err := p.ProcessEvent(string(eventjson))
+
+	if err == nil {
+		t.Error("Expected ProcessEvent to return an error when AddDeploymentStateChangeEvent fails")
+	}
+}
@@ -21,23 +21,29 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	log "github.com/cihub/seelog"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
 const (
 	sqsErrorSleepInterval = 500 * time.Millisecond
-	sqsVisibilityTimeout  = 10
 	sqsWaitTimeSeconds    = 10
+	// sqsMaxMessages is the most messages SQS returns per receive
+	sqsMaxMessages = 10
 )
 
 type sqsEventConsumer struct {
-	sqs       sqsiface.SQSAPI
-	queueURL  string
-	processor Processor
+	sqs               sqsiface.SQSAPI
+	queueURL          string
+	processor         Processor
+	visibilityTimeout time.Duration
+	status            *pollStatus
 }
 
-func NewSQSConsumer(sqs sqsiface.SQSAPI, processor Processor, queueName string) (Consumer, error) {
+// NewSQSConsumer initializes a consumer of the events of SQS queue 'queueName'
+// that hides the messages it receives from other consumers for 'visibilityTimeout'
+func NewSQSConsumer(sqs sqsiface.SQSAPI, processor Processor, queueName string, visibilityTimeout time.Duration) (Consumer, error) {
 	if sqs == nil {
 		return nil, errors.Errorf("The SQS API interface is not initialized")
 	}
@@ -54,9 +60,11 @@ func NewSQSConsumer(sqs sqsiface.SQSAPI, processor Processor, queueName string)
 	}
 
 	return &sqsEventConsumer{
-		sqs:       sqs,
-		queueURL:  sqsQueueURL,
-		processor: processor,
+		sqs:               sqs,
+		queueURL:          sqsQueueURL,
+		processor:         processor,
+		visibilityTimeout: visibilityTimeout,
+		status:            &pollStatus{},
 	}, nil
 }
 
@@ -99,6 +107,10 @@ func (sqsConsumer sqsEventConsumer) PollForEvents(ctx context.Context) {
 	}
 }
 
+func (sqsConsumer sqsEventConsumer) LastPoll() time.Time {
+	return sqsConsumer.status.lastPoll()
+}
+
 func (sqsConsumer sqsEventConsumer) logQueueStats(ctx context.Context) error {
 	params := &sqs.GetQueueAttributesInput{
 		QueueUrl: aws.String(sqsConsumer.queueURL),
@@ -116,15 +128,17 @@ func (sqsConsumer sqsEventConsumer) logQueueStats(ctx context.Context) error {
 		prop := resp.Attributes[attrib]
 		i, _ := strconv.Atoi(*prop)
 		log.Infof("SQS attribute[%s] = %d", attrib, i)
+		metrics.SetSQSQueueMessages(attrib, i)
 	}
 	return nil
 }
 
 func (sqsConsumer sqsEventConsumer) pollForMessages() {
 	receiveMessageInput := &sqs.ReceiveMessageInput{
-		QueueUrl:          aws.String(sqsConsumer.queueURL),
-		VisibilityTimeout: aws.Int64(sqsVisibilityTimeout),
-		WaitTimeSeconds:   aws.Int64(sqsWaitTimeSeconds),
+		QueueUrl:            aws.String(sqsConsumer.queueURL),
+		VisibilityTimeout:   aws.Int64(int64(sqsConsumer.visibilityTimeout / time.Second)),
+		WaitTimeSeconds:     aws.Int64(sqsWaitTimeSeconds),
+		MaxNumberOfMessages: aws.Int64(sqsMaxMessages),
 	}
 
 	output, err := sqsConsumer.sqs.ReceiveMessage(receiveMessageInput)
@@ -139,6 +153,7 @@ func (sqsConsumer sqsEventConsumer) pollForMessages() {
 
 		return
 	}
+	sqsConsumer.status.polled()
 
 	if output == nil || output.Messages == nil {
 		return
@@ -147,19 +162,24 @@ func (sqsConsumer sqsEventConsumer) pollForMessages() {
 	sqsConsumer.processMessages(output.Messages)
 }
 
+// processMessages processes messages concurrently, deleting each of them only
+// once the processor applied it or, if it buffers events, durably buffered it.
+// Messages that could not be processed are received again once their
+// visibility timeout expires.
 func (sqsConsumer sqsEventConsumer) processMessages(messages []*sqs.Message) {
-	for _, message := range messages {
+	processConcurrently(len(messages), func(i int) {
+		message := messages[i]
 		err := sqsConsumer.processEvent(message)
 		if err != nil {
 			log.Errorf("Could not process message: %v: %+v", message, err)
-			continue
+			return
 		}
 
 		err = sqsConsumer.deleteEvent(message)
 		if err != nil {
 			log.Errorf("Could not delete message %v: %+v", message, err)
 		}
-	}
+	})
 }
 
 func (sqsConsumer sqsEventConsumer) processEvent(message *sqs.Message) error {
@@ -16,6 +16,7 @@ package event
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
@@ -31,6 +32,8 @@ const (
 	messageBody    = "messageBody"
 	messageBody2   = "messageBody2"
 	queueName      = "event_stream"
+
+	visibilityTimeout = 10 * time.Second
 )
 
 type consumerMockContext struct {
@@ -72,9 +75,10 @@ func NewConsumerMockContext(t *testing.T) *consumerMockContext {
 	}
 
 	context.receiveMessageInput = &sqs.ReceiveMessageInput{
-		QueueUrl:          aws.String(queueUrl),
-		VisibilityTimeout: aws.Int64(sqsVisibilityTimeout),
-		WaitTimeSeconds:   aws.Int64(sqsWaitTimeSeconds),
+		QueueUrl:            aws.String(queueUrl),
+		VisibilityTimeout:   aws.Int64(10),
+		WaitTimeSeconds:     aws.Int64(sqsWaitTimeSeconds),
+		MaxNumberOfMessages: aws.Int64(sqsMaxMessages),
 	}
 
 	context.receiveMessageOutput = &sqs.ReceiveMessageOutput{
@@ -98,7 +102,7 @@ func TestNewConsumerNilSQS(t *testing.T) {
 	context := NewConsumerMockContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewSQSConsumer(nil, context.processor, queueName)
+	_, err := NewSQSConsumer(nil, context.processor, queueName, visibilityTimeout)
 	if err == nil {
 		t.Error("Expected an error when sqs is nil")
 	}
@@ -108,7 +112,7 @@ func TestNewConsumerNilProcessor(t *testing.T) {
 	context := NewConsumerMockContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewSQSConsumer(context.sqsClient, nil, queueName)
+	_, err := NewSQSConsumer(context.sqsClient, nil, queueName, visibilityTimeout)
 	if err == nil {
 		t.Error("Expected an error when processor is nil")
 	}
@@ -118,7 +122,7 @@ func TestNewConsumerEmptyQueueName(t *testing.T) {
 	context := NewConsumerMockContext(t)
 	defer context.mockCtrl.Finish()
 
-	_, err := NewSQSConsumer(context.sqsClient, context.processor, "")
+	_, err := NewSQSConsumer(context.sqsClient, context.processor, "", visibilityTimeout)
 	if err == nil {
 		t.Error("Expected an error when queueue name is empty")
 	}
@@ -130,7 +134,7 @@ func TestNewConsumerGetQueueUrlFails(t *testing.T) {
 
 	context.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(context.getQueueUrlInput)).Return(nil, errors.New(""))
 
-	_, err := NewSQSConsumer(context.sqsClient, context.processor, queueName)
+	_, err := NewSQSConsumer(context.sqsClient, context.processor, queueName, visibilityTimeout)
 
 	if err == nil {
 		t.Error("Expected an error when getQueueUrl fails")
@@ -143,7 +147,7 @@ func TestNewConsumerGetQueueUrlIsEmpty(t *testing.T) {
 
 	context.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(context.getQueueUrlInput)).Return(&sqs.GetQueueUrlOutput{}, nil)
 
-	_, err := NewSQSConsumer(context.sqsClient, context.processor, queueName)
+	_, err := NewSQSConsumer(context.sqsClient, context.processor, queueName, visibilityTimeout)
 
 	if err == nil {
 		t.Error("Expected an error when getQueueUrl output is empty")
@@ -156,7 +160,7 @@ func TestNewConsumer(t *testing.T) {
 
 	context.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(context.getQueueUrlInput)).Return(context.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(context.sqsClient, context.processor, queueName)
+	c, err := NewSQSConsumer(context.sqsClient, context.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -173,7 +177,7 @@ func TestPollForEventsReceiveMessageFails(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -191,6 +195,9 @@ func TestPollForEventsReceiveMessageFails(t *testing.T) {
 	})
 
 	c.PollForEvents(ctx)
+	if !c.LastPoll().IsZero() {
+		t.Error("Consumer should not record failed polls")
+	}
 }
 
 func TestPollForEventsReceiveMessageOutputNil(t *testing.T) {
@@ -199,7 +206,7 @@ func TestPollForEventsReceiveMessageOutputNil(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -216,6 +223,9 @@ func TestPollForEventsReceiveMessageOutputNil(t *testing.T) {
 	})
 
 	c.PollForEvents(ctx)
+	if c.LastPoll().IsZero() {
+		t.Error("Consumer should record successful polls")
+	}
 }
 
 func TestPollForEventsReceiveMessageOutputMessagesNil(t *testing.T) {
@@ -224,7 +234,7 @@ func TestPollForEventsReceiveMessageOutputMessagesNil(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -251,7 +261,7 @@ func TestPollForEventsFirstProcessEventFails(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -279,7 +289,7 @@ func TestPollForEventsFirstDeleteEventFails(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -308,7 +318,7 @@ func TestPollForEventsReceiveTwoMessages(t *testing.T) {
 
 	mockContext.sqsClient.EXPECT().GetQueueUrl(gomock.Eq(mockContext.getQueueUrlInput)).Return(mockContext.getQueueUrlOutput, nil)
 
-	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName)
+	c, err := NewSQSConsumer(mockContext.sqsClient, mockContext.processor, queueName, visibilityTimeout)
 
 	if err != nil {
 		t.Errorf("Unexpected error when calling NewConsumer: %+v", err)
@@ -0,0 +1,183 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package health serves the liveness and the readiness of the cluster state
+// service. The service is ready when all of its readiness checks pass.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/clients"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EtcdCheckTimeout is how long the etcd check waits for etcd to respond
+	EtcdCheckTimeout = 2 * time.Second
+
+	// etcdCheckKey is the key the etcd check reads. It does not have to exist.
+	etcdCheckKey = "health"
+
+	okStatus     = "ok"
+	failedStatus = "failed"
+
+	contentTypeKey  = "Content-Type"
+	contentTypeJSON = "application/json; charset=UTF-8"
+)
+
+// Check returns an error describing why a dependency of the service is not
+// healthy, or nil if it is
+type Check func() error
+
+// Status is the response of the liveness and readiness endpoints
+type Status struct {
+	Status string        `json:"status"`
+	Checks []CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the outcome of a readiness check
+type CheckStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the service
+type Checker struct {
+	lock   sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker initializes a checker without readiness checks
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// AddCheck adds a readiness check. Checks run in the order they are added.
+func (checker *Checker) AddCheck(name string, check Check) {
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+	checker.checks = append(checker.checks, namedCheck{name: name, check: check})
+}
+
+// Ready runs the readiness checks and returns the status of the service and
+// whether all checks passed
+func (checker *Checker) Ready() (Status, bool) {
+	checker.lock.RLock()
+	checks := checker.checks
+	checker.lock.RUnlock()
+
+	status := Status{Status: okStatus, Checks: make([]CheckStatus, 0, len(checks))}
+	ready := true
+	for _, c := range checks {
+		checkStatus := CheckStatus{Name: c.name, Status: okStatus}
+		if err := c.check(); err != nil {
+			checkStatus.Status = failedStatus
+			checkStatus.Error = err.Error()
+			status.Status = failedStatus
+			ready = false
+		}
+		status.Checks = append(status.Checks, checkStatus)
+	}
+	return status, ready
+}
+
+// ServeLiveness responds that the service is alive. The service is alive as
+// long as it can respond.
+func (checker *Checker) ServeLiveness(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, Status{Status: okStatus})
+}
+
+// ServeReadiness responds with the outcome of the readiness checks, with
+// status 503 if any of them failed
+func (checker *Checker) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	status, ready := checker.Ready()
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeStatus(w, code, status)
+}
+
+func writeStatus(w http.ResponseWriter, code int, status Status) {
+	w.Header().Set(contentTypeKey, contentTypeJSON)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+// Flag is a condition that turns true once, such as bootstrapping having finished
+type Flag struct {
+	lock   sync.RWMutex
+	set    bool
+	errMsg string
+}
+
+// NewFlag initializes a flag whose check fails with 'errMsg' until the flag is set
+func NewFlag(errMsg string) *Flag {
+	return &Flag{errMsg: errMsg}
+}
+
+// Set sets the flag
+func (flag *Flag) Set() {
+	flag.lock.Lock()
+	defer flag.lock.Unlock()
+	flag.set = true
+}
+
+// Check fails until the flag is set
+func (flag *Flag) Check() error {
+	flag.lock.RLock()
+	defer flag.lock.RUnlock()
+	if !flag.set {
+		return errors.New(flag.errMsg)
+	}
+	return nil
+}
+
+// NewEtcdCheck returns a check that fails if etcd does not respond to a read within EtcdCheckTimeout
+func NewEtcdCheck(etcdInterface clients.EtcdInterface) Check {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), EtcdCheckTimeout)
+		defer cancel()
+		_, err := etcdInterface.Get(ctx, etcdCheckKey)
+		if err != nil {
+			return errors.Wrapf(err, "Etcd is unreachable")
+		}
+		return nil
+	}
+}
+
+// NewRecencyCheck returns a check that fails if 'last' returns a time more than
+// 'maxAge' ago. The check fails with 'errMsg' if 'last' returns the zero time.
+func NewRecencyCheck(last func() time.Time, maxAge time.Duration, errMsg string) Check {
+	return func() error {
+		t := last()
+		if t.IsZero() {
+			return errors.New(errMsg)
+		}
+		if age := time.Since(t); age > maxAge {
+			return errors.Errorf("%s: last succeeded %s ago", errMsg, age.Truncate(time.Second).String())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc) (int, Status) {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/", nil))
+
+	var status Status
+	err := json.NewDecoder(recorder.Body).Decode(&status)
+	assert.Nil(t, err, "Unexpected error decoding status")
+	assert.Equal(t, contentTypeJSON, recorder.Header().Get(contentTypeKey), "Unexpected content type")
+	return recorder.Code, status
+}
+
+func TestServeLiveness(t *testing.T) {
+	checker := NewChecker()
+	checker.AddCheck("failing", func() error { return errors.New("Dependency is down") })
+
+	code, status := serve(t, checker.ServeLiveness)
+	assert.Equal(t, http.StatusOK, code, "Expected the service to be alive even if it is not ready")
+	assert.Equal(t, Status{Status: okStatus}, status, "Unexpected liveness status")
+}
+
+func TestServeReadinessAllChecksPass(t *testing.T) {
+	checker := NewChecker()
+	checker.AddCheck("first", func() error { return nil })
+	checker.AddCheck("second", func() error { return nil })
+
+	code, status := serve(t, checker.ServeReadiness)
+	assert.Equal(t, http.StatusOK, code, "Expected the service to be ready")
+	assert.Equal(t, Status{
+		Status: okStatus,
+		Checks: []CheckStatus{{Name: "first", Status: okStatus}, {Name: "second", Status: okStatus}},
+	}, status, "Unexpected readiness status")
+}
+
+func TestServeReadinessReportsFailedCheck(t *testing.T) {
+	checker := NewChecker()
+	checker.AddCheck("first", func() error { return nil })
+	checker.AddCheck("second", func() error { return errors.New("Dependency is down") })
+
+	code, status := serve(t, checker.ServeReadiness)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "Expected the service not to be ready")
+	assert.Equal(t, Status{
+		Status: failedStatus,
+		Checks: []CheckStatus{
+			{Name: "first", Status: okStatus},
+			{Name: "second", Status: failedStatus, Error: "Dependency is down"},
+		},
+	}, status, "Unexpected readiness status")
+}
+
+func TestFlag(t *testing.T) {
+	flag := NewFlag("Not set yet")
+	err := flag.Check()
+	assert.EqualError(t, err, "Not set yet", "Expected check to fail before the flag is set")
+
+	flag.Set()
+	assert.Nil(t, flag.Check(), "Unexpected error after the flag is set")
+}
+
+func TestRecencyCheck(t *testing.T) {
+	var last time.Time
+	check := NewRecencyCheck(func() time.Time { return last }, time.Minute, "Never succeeded")
+
+	assert.EqualError(t, check(), "Never succeeded", "Expected check to fail if it never succeeded")
+
+	last = time.Now().Add(-2 * time.Minute)
+	assert.Error(t, check(), "Expected check to fail if it last succeeded too long ago")
+
+	last = time.Now()
+	assert.Nil(t, check(), "Unexpected error if it succeeded recently")
+}
+
+func TestEtcdCheck(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	etcd := mocks.NewMockEtcdInterface(mockCtrl)
+	check := NewEtcdCheck(etcd)
+
+	etcd.EXPECT().Get(gomock.Any(), etcdCheckKey).Return(&clientv3.GetResponse{}, nil)
+	assert.Nil(t, check(), "Unexpected error when etcd responds")
+
+	etcd.EXPECT().Get(gomock.Any(), etcdCheckKey).Return(nil, errors.New("context deadline exceeded"))
+	assert.Error(t, check(), "Expected an error when etcd does not respond")
+}
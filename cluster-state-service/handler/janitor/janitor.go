@@ -0,0 +1,218 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/goguardian/blox/cluster-state-service/handler/election"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	JanitorDuration = 5 * time.Minute
+
+	// leaderKeyPrefix is the prefix of the election that decides which
+	// instance purges expired entities
+	leaderKeyPrefix = "css/janitor/leader"
+
+	taskStoppedStatus      = "stopped"
+	instanceInactiveStatus = "inactive"
+)
+
+// Retention defines how long stopped and inactive entities are kept in the
+// store. A zero duration disables purging of the corresponding entities.
+type Retention struct {
+	StoppedTask      time.Duration
+	InactiveInstance time.Duration
+	Tombstone        time.Duration
+}
+
+// IsEnabled returns true if at least one kind of entity is purged
+func (retention Retention) IsEnabled() bool {
+	return retention.StoppedTask > 0 || retention.InactiveInstance > 0
+}
+
+// Janitor periodically purges STOPPED tasks and INACTIVE container instances
+// that have outlived their retention and expires the tombstones left behind.
+// Instances elect a leader through etcd so that only one of them purges at a
+// time.
+type Janitor struct {
+	campaign       election.Campaign
+	taskStore      store.TaskStore
+	instanceStore  store.ContainerInstanceStore
+	tombstoneStore store.TombstoneStore
+	retention      Retention
+	tickerDuration time.Duration
+	ctx            context.Context
+	now            func() time.Time
+}
+
+// NewJanitor initializes a janitor that purges expired entities while this
+// instance leads the janitor election on 'client'
+func NewJanitor(ctx context.Context, client *clientv3.Client, stores store.Stores, retention Retention, tickerDuration time.Duration) (*Janitor, error) {
+	if client == nil {
+		return nil, errors.New("Failed to initialize Janitor. Etcd client is not initialized.")
+	}
+	return newJanitor(ctx, election.NewCampaign(client, leaderKeyPrefix), stores, retention, tickerDuration)
+}
+
+func newJanitor(ctx context.Context, campaign election.Campaign, stores store.Stores, retention Retention, tickerDuration time.Duration) (*Janitor, error) {
+	if stores.TaskStore == nil || stores.ContainerInstanceStore == nil || stores.TombstoneStore == nil {
+		return nil, errors.New("Failed to initialize Janitor. Stores are not initialized.")
+	}
+	if tickerDuration <= 0 {
+		return nil, fmt.Errorf("Invalid duration specified for running the janitor: %s", tickerDuration.String())
+	}
+	if retention.StoppedTask < 0 || retention.InactiveInstance < 0 || retention.Tombstone < 0 {
+		return nil, fmt.Errorf("Invalid retention specified for the janitor: %+v", retention)
+	}
+	return &Janitor{
+		campaign:       campaign,
+		taskStore:      stores.TaskStore,
+		instanceStore:  stores.ContainerInstanceStore,
+		tombstoneStore: stores.TombstoneStore,
+		retention:      retention,
+		tickerDuration: tickerDuration,
+		ctx:            ctx,
+		now:            time.Now,
+	}, nil
+}
+
+// Run campaigns to lead the janitor and purges expired entities while leading,
+// until the context of the janitor is done
+func (janitor *Janitor) Run() {
+	election.Lead(janitor.ctx, janitor.campaign, "the janitor", janitor.lead)
+}
+
+// lead purges expired entities until the context of the janitor is done or
+// leadership is lost
+func (janitor *Janitor) lead(lost <-chan struct{}) {
+	ticker := time.NewTicker(janitor.tickerDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := janitor.RunOnce()
+			if err != nil {
+				log.Warnf("Error purging expired entities: %v", err)
+			}
+		case <-lost:
+			return
+		case <-janitor.ctx.Done():
+			return
+		}
+	}
+}
+
+func (janitor *Janitor) RunOnce() error {
+	now := janitor.now()
+
+	if janitor.retention.StoppedTask > 0 {
+		err := janitor.purgeStoppedTasks(now.Add(-janitor.retention.StoppedTask))
+		if err != nil {
+			return errors.Wrapf(err, "Failed to purge stopped tasks.")
+		}
+	}
+
+	if janitor.retention.InactiveInstance > 0 {
+		err := janitor.purgeInactiveInstances(now.Add(-janitor.retention.InactiveInstance))
+		if err != nil {
+			return errors.Wrapf(err, "Failed to purge inactive container instances.")
+		}
+	}
+
+	deleted, err := janitor.tombstoneStore.DeleteTombstonesPurgedBefore(now.Add(-janitor.retention.Tombstone))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete expired tombstones.")
+	}
+	log.Debugf("Deleted %d expired tombstones", deleted)
+	return nil
+}
+
+func (janitor *Janitor) purgeStoppedTasks(stoppedBefore time.Time) error {
+	tasks, err := janitor.taskStore.ListTasks()
+	if err != nil {
+		return err
+	}
+
+	for _, versionedTask := range tasks {
+		detail := versionedTask.Task.Detail
+		if detail == nil || strings.ToLower(aws.StringValue(detail.LastStatus)) != taskStoppedStatus {
+			continue
+		}
+
+		// Tasks loaded by the reconciler before they were stopped may not
+		// have stoppedAt set. Fall back to the last time they were updated.
+		stoppedAt := detail.StoppedAt
+		if stoppedAt == "" {
+			stoppedAt = aws.StringValue(detail.UpdatedAt)
+		}
+		if !isBefore(stoppedAt, stoppedBefore) {
+			continue
+		}
+
+		// Not handling returned error because we want as many purge operations to succeed as possible.
+		clusterARN := aws.StringValue(detail.ClusterARN)
+		taskARN := aws.StringValue(detail.TaskARN)
+		if err := janitor.taskStore.PurgeTask(clusterARN, taskARN, aws.Int64Value(detail.Version)); err != nil {
+			log.Infof("Error purging task '%s' belonging to cluster '%s' from data store: %v",
+				taskARN, clusterARN, err)
+		}
+	}
+	return nil
+}
+
+func (janitor *Janitor) purgeInactiveInstances(updatedBefore time.Time) error {
+	instances, err := janitor.instanceStore.ListContainerInstances()
+	if err != nil {
+		return err
+	}
+
+	for _, versionedInstance := range instances {
+		detail := versionedInstance.ContainerInstance.Detail
+		if detail == nil || strings.ToLower(aws.StringValue(detail.Status)) != instanceInactiveStatus {
+			continue
+		}
+		if !isBefore(aws.StringValue(detail.UpdatedAt), updatedBefore) {
+			continue
+		}
+
+		// Not handling returned error because we want as many purge operations to succeed as possible.
+		clusterARN := aws.StringValue(detail.ClusterARN)
+		instanceARN := aws.StringValue(detail.ContainerInstanceARN)
+		if err := janitor.instanceStore.PurgeContainerInstance(clusterARN, instanceARN, aws.Int64Value(detail.Version)); err != nil {
+			log.Infof("Error purging container instance '%s' belonging to cluster '%s' from data store: %v",
+				instanceARN, clusterARN, err)
+		}
+	}
+	return nil
+}
+
+// isBefore returns true if timestamp parses and is before t. Entities with
+// timestamps that cannot be parsed are never purged.
+func isBefore(timestamp string, t time.Time) bool {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+	return parsed.Before(t)
+}
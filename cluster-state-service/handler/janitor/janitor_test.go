@@ -0,0 +1,254 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	netcontext "golang.org/x/net/context"
+)
+
+const (
+	clusterARN  = "arn:aws:ecs:us-east-1:123456789012:cluster/test"
+	taskARN1    = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	taskARN2    = "arn:aws:ecs:us-east-1:123456789012:task/345022c0-f894-4aa2-b063-25bae55088d5"
+	instanceARN = "arn:aws:ecs:us-east-1:123456789012:container-instance/4b6d45ea-a4b4-4269-9d04-3af6ddfdc597"
+)
+
+type JanitorTestSuite struct {
+	suite.Suite
+	taskStore      *mocks.MockTaskStore
+	instanceStore  *mocks.MockContainerInstanceStore
+	tombstoneStore *mocks.MockTombstoneStore
+	now            time.Time
+	janitor        *Janitor
+}
+
+func (suite *JanitorTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+	suite.taskStore = mocks.NewMockTaskStore(mockCtrl)
+	suite.instanceStore = mocks.NewMockContainerInstanceStore(mockCtrl)
+	suite.tombstoneStore = mocks.NewMockTombstoneStore(mockCtrl)
+	suite.now = time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	stores := store.Stores{
+		TaskStore:              suite.taskStore,
+		ContainerInstanceStore: suite.instanceStore,
+		TombstoneStore:         suite.tombstoneStore,
+	}
+	retention := Retention{
+		StoppedTask:      time.Hour,
+		InactiveInstance: time.Hour,
+		Tombstone:        24 * time.Hour,
+	}
+	var err error
+	suite.janitor, err = newJanitor(context.TODO(), noCampaign, stores, retention, JanitorDuration)
+	assert.Nil(suite.T(), err, "Cannot setup testSuite: Unexpected error when calling NewJanitor")
+	suite.janitor.now = func() time.Time { return suite.now }
+}
+
+func TestJanitorTestSuite(t *testing.T) {
+	suite.Run(t, new(JanitorTestSuite))
+}
+
+func noCampaign(ctx netcontext.Context) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("Not campaigning")
+}
+
+func (suite *JanitorTestSuite) TestNewJanitorNilClient() {
+	_, err := NewJanitor(context.TODO(), nil, suite.stores(), Retention{StoppedTask: time.Hour}, JanitorDuration)
+	assert.Error(suite.T(), err, "Expected an error when the etcd client is nil")
+}
+
+func (suite *JanitorTestSuite) TestNewJanitorNilStores() {
+	_, err := newJanitor(context.TODO(), noCampaign, store.Stores{}, Retention{StoppedTask: time.Hour}, JanitorDuration)
+	assert.Error(suite.T(), err, "Expected an error when stores are not initialized")
+}
+
+func (suite *JanitorTestSuite) TestNewJanitorInvalidDuration() {
+	_, err := newJanitor(context.TODO(), noCampaign, suite.stores(), Retention{StoppedTask: time.Hour}, 0)
+	assert.Error(suite.T(), err, "Expected an error when ticker duration is invalid")
+}
+
+func (suite *JanitorTestSuite) TestNewJanitorNegativeRetention() {
+	_, err := newJanitor(context.TODO(), noCampaign, suite.stores(), Retention{StoppedTask: -time.Hour}, JanitorDuration)
+	assert.Error(suite.T(), err, "Expected an error when retention is negative")
+}
+
+func (suite *JanitorTestSuite) TestRetentionIsEnabled() {
+	assert.False(suite.T(), Retention{Tombstone: time.Hour}.IsEnabled(), "Retention should be disabled")
+	assert.True(suite.T(), Retention{StoppedTask: time.Hour}.IsEnabled(), "Retention should be enabled")
+	assert.True(suite.T(), Retention{InactiveInstance: time.Hour}.IsEnabled(), "Retention should be enabled")
+}
+
+func (suite *JanitorTestSuite) TestRunOnceListTasksFails() {
+	suite.taskStore.EXPECT().ListTasks().Return(nil, errors.New("Error listing tasks"))
+
+	err := suite.janitor.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when listing tasks fails")
+}
+
+func (suite *JanitorTestSuite) TestRunOnceListInstancesFails() {
+	suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return(nil, errors.New("Error listing instances"))
+
+	err := suite.janitor.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when listing instances fails")
+}
+
+func (suite *JanitorTestSuite) TestRunOnceDeleteTombstonesFails() {
+	suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{}, nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return([]storetypes.VersionedContainerInstance{}, nil)
+	suite.tombstoneStore.EXPECT().DeleteTombstonesPurgedBefore(gomock.Any()).Return(0, errors.New("Error deleting tombstones"))
+
+	err := suite.janitor.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when deleting tombstones fails")
+}
+
+func (suite *JanitorTestSuite) TestRunOncePurgesExpiredEntities() {
+	expired := suite.now.Add(-2 * time.Hour).Format(time.RFC3339)
+	recent := suite.now.Add(-time.Minute).Format(time.RFC3339)
+	tasks := []storetypes.VersionedTask{
+		suite.task(taskARN1, "STOPPED", expired, 3),
+		suite.task(taskARN2, "STOPPED", recent, 3),
+		suite.task(taskARN2, "RUNNING", expired, 2),
+	}
+	instances := []storetypes.VersionedContainerInstance{
+		suite.instance("INACTIVE", expired, 5),
+		suite.instance("ACTIVE", expired, 5),
+	}
+
+	suite.taskStore.EXPECT().ListTasks().Return(tasks, nil)
+	suite.taskStore.EXPECT().PurgeTask(clusterARN, taskARN1, int64(3)).Return(nil)
+	suite.instanceStore.EXPECT().ListContainerInstances().Return(instances, nil)
+	suite.instanceStore.EXPECT().PurgeContainerInstance(clusterARN, instanceARN, int64(5)).Return(errors.New("Error purging instance"))
+	suite.tombstoneStore.EXPECT().DeleteTombstonesPurgedBefore(suite.now.Add(-24*time.Hour)).Return(1, nil)
+
+	err := suite.janitor.RunOnce()
+	assert.NoError(suite.T(), err, "Unexpected error purging expired entities")
+}
+
+func (suite *JanitorTestSuite) TestRunOnceStoppedTaskWithoutStoppedAt() {
+	task := suite.task(taskARN1, "STOPPED", "", 3)
+	task.Task.Detail.UpdatedAt = aws.String(suite.now.Add(-2 * time.Hour).Format(time.RFC3339))
+
+	suite.janitor.retention.InactiveInstance = 0
+	suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{task}, nil)
+	suite.taskStore.EXPECT().PurgeTask(clusterARN, taskARN1, int64(3)).Return(nil)
+	suite.tombstoneStore.EXPECT().DeleteTombstonesPurgedBefore(gomock.Any()).Return(0, nil)
+
+	err := suite.janitor.RunOnce()
+	assert.NoError(suite.T(), err, "Unexpected error purging a stopped task without stoppedAt")
+}
+
+func (suite *JanitorTestSuite) TestRunPurgesOnlyWhileLeading() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lost := make(chan struct{})
+	campaigns := make(chan struct{}, 2)
+	campaign := func(ctx netcontext.Context) (<-chan struct{}, func(), error) {
+		campaigns <- struct{}{}
+		if len(campaigns) > 1 {
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		}
+		return lost, func() {}, nil
+	}
+	janitor, err := newJanitor(ctx, campaign, suite.stores(), Retention{Tombstone: time.Hour}, time.Millisecond)
+	assert.Nil(suite.T(), err, "Unexpected error when calling newJanitor")
+
+	purged := make(chan struct{}, 1)
+	suite.tombstoneStore.EXPECT().DeleteTombstonesPurgedBefore(gomock.Any()).Do(func(time.Time) {
+		select {
+		case purged <- struct{}{}:
+		default:
+		}
+	}).Return(0, nil).MinTimes(1)
+
+	done := make(chan struct{})
+	go func() {
+		janitor.Run()
+		close(done)
+	}()
+	<-purged
+	close(lost)
+	for len(campaigns) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func (suite *JanitorTestSuite) TestRunDoesNotPurgeWithoutLeading() {
+	ctx, cancel := context.WithCancel(context.Background())
+	janitor, err := newJanitor(ctx, noCampaign, suite.stores(), Retention{Tombstone: time.Hour}, time.Millisecond)
+	assert.Nil(suite.T(), err, "Unexpected error when calling newJanitor")
+	suite.tombstoneStore.EXPECT().DeleteTombstonesPurgedBefore(gomock.Any()).Times(0)
+
+	done := make(chan struct{})
+	go func() {
+		janitor.Run()
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+}
+
+func (suite *JanitorTestSuite) stores() store.Stores {
+	return store.Stores{
+		TaskStore:              suite.taskStore,
+		ContainerInstanceStore: suite.instanceStore,
+		TombstoneStore:         suite.tombstoneStore,
+	}
+}
+
+func (suite *JanitorTestSuite) task(taskARN string, status string, stoppedAt string, version int64) storetypes.VersionedTask {
+	return storetypes.VersionedTask{
+		Task: types.Task{
+			Detail: &types.TaskDetail{
+				ClusterARN: aws.String(clusterARN),
+				TaskARN:    aws.String(taskARN),
+				LastStatus: aws.String(status),
+				StoppedAt:  stoppedAt,
+				Version:    aws.Int64(version),
+			},
+		},
+	}
+}
+
+func (suite *JanitorTestSuite) instance(status string, updatedAt string, version int64) storetypes.VersionedContainerInstance {
+	return storetypes.VersionedContainerInstance{
+		ContainerInstance: types.ContainerInstance{
+			Detail: &types.InstanceDetail{
+				ClusterARN:           aws.String(clusterARN),
+				ContainerInstanceARN: aws.String(instanceARN),
+				Status:               aws.String(status),
+				UpdatedAt:            aws.String(updatedAt),
+				Version:              aws.Int64(version),
+			},
+		},
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+// unmatchedRoute is the route of requests that match no route of the router
+const unmatchedRoute = "unmatched"
+
+// HTTPMiddleware records the latency of the requests to the routes of a router
+type HTTPMiddleware struct {
+	router *mux.Router
+}
+
+// NewHTTPMiddleware initializes a middleware that records the latency of the
+// requests to the routes of 'router'. Requests are labeled with the path
+// template of the route they match, so that the labels are bounded.
+func NewHTTPMiddleware(router *mux.Router) HTTPMiddleware {
+	return HTTPMiddleware{
+		router: router,
+	}
+}
+
+func (middleware HTTPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	route := unmatchedRoute
+	var match mux.RouteMatch
+	if middleware.router.Match(r, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+
+	rw, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		rw = negroni.NewResponseWriter(w)
+	}
+	next(rw, r)
+
+	code := rw.Status()
+	if code == 0 {
+		code = http.StatusOK
+	}
+	ObserveHTTPRequest(route, r.Method, code, start)
+}
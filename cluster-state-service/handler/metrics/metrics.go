@@ -0,0 +1,323 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metrics defines the Prometheus metrics of the cluster state service
+// and serves them in the Prometheus exposition format.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "css"
+
+	// Outcomes of processed events
+	EventApplied      = "applied"
+	EventInvalid      = "invalid"
+	EventUnrecognized = "unrecognized"
+	EventFailed       = "failed"
+	EventBuffered     = "buffered"
+	EventDropped      = "dropped"
+
+	// Entities and kinds of drift between the data store and ECS corrected by the reconciler
+	TaskEntity              = "task"
+	ContainerInstanceEntity = "container_instance"
+	ServiceEntity           = "service"
+	DriftMissing            = "missing"
+	DriftDeleted            = "deleted"
+
+	successOutcome = "success"
+	errorOutcome   = "error"
+)
+
+var (
+	eventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_total",
+		Help:      "Events consumed from the event stream by type and outcome.",
+	}, []string{"type", "outcome"})
+
+	eventLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_lag_seconds",
+		Help:      "Time between an event being emitted by ECS and being applied to the data store.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"type"})
+
+	stmTransactionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stm_transactions_total",
+		Help:      "Software transactional memory transactions against etcd by outcome.",
+	}, []string{"outcome"})
+
+	stmConflictsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stm_conflicts_total",
+		Help:      "Software transactional memory transactions retried because of conflicting writes.",
+	})
+
+	etcdRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "etcd_request_duration_seconds",
+		Help:      "Latency of etcd requests by operation and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconcile loops by outcome.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"outcome"})
+
+	reconcileDriftTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_drift_total",
+		Help:      "Records the reconciler found missing from or deleted in ECS but present in the data store, by entity.",
+	}, []string{"entity", "kind"})
+
+	openStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "open_streams",
+		Help:      "Watches on the data store currently streaming changes.",
+	})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	sqsQueueMessages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sqs_queue_messages",
+		Help:      "Approximate number of messages in the SQS event queue by attribute.",
+	}, []string{"attribute"})
+
+	kinesisMillisBehindLatest = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kinesis_millis_behind_latest",
+		Help:      "Milliseconds the Kinesis event stream consumer is behind the tip of the stream.",
+	})
+
+	batchRecords = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "batch_records",
+		Help:      "Records applied to etcd in a single batched transaction.",
+		Buckets:   []float64{1, 2, 4, 8, 16, 32, 64},
+	})
+
+	batchFallbacksTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "batch_fallbacks_total",
+		Help:      "Batches with records applied one by one because of conflicting writes.",
+	})
+
+	bufferedEvents = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "buffered_events",
+		Help:      "Events in the local write-ahead buffer waiting to be applied to the data store.",
+	})
+
+	etcdCircuitState = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "etcd_circuit_state",
+		Help:      "State of the circuit breaker guarding etcd: 0 closed, 1 open, 2 half-open.",
+	})
+
+	viewRevision = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "view_revision",
+		Help:      "Etcd revision reflected by the in-memory view that serves reads.",
+	})
+
+	viewChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "view_checks_total",
+		Help:      "Consistency checks of the in-memory view against etcd by outcome.",
+	}, []string{"outcome"})
+
+	viewInconsistenciesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "view_inconsistencies_total",
+		Help:      "Records of the in-memory view found to differ from etcd.",
+	})
+
+	etcdCompactedRevision = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "etcd_compacted_revision",
+		Help:      "Etcd revision that etcd was last compacted to by this instance.",
+	})
+
+	etcdCompactionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "etcd_compactions_total",
+		Help:      "Compactions of the etcd revision history by outcome.",
+	}, []string{"outcome"})
+
+	etcdDefragmentationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "etcd_defragmentations_total",
+		Help:      "Defragmentations of etcd members by endpoint and outcome.",
+	}, []string{"endpoint", "outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		eventsTotal,
+		eventLag,
+		stmTransactionsTotal,
+		stmConflictsTotal,
+		etcdRequestDuration,
+		reconcileDuration,
+		reconcileDriftTotal,
+		openStreams,
+		httpRequestDuration,
+		sqsQueueMessages,
+		kinesisMillisBehindLatest,
+		batchRecords,
+		batchFallbacksTotal,
+		bufferedEvents,
+		etcdCircuitState,
+		viewRevision,
+		viewChecksTotal,
+		viewInconsistenciesTotal,
+		etcdCompactedRevision,
+		etcdCompactionsTotal,
+		etcdDefragmentationsTotal,
+	)
+}
+
+// Handler returns the handler that serves the metrics
+func Handler() http.Handler {
+	return prometheus.Handler()
+}
+
+// ObserveEvent records the outcome of processing an event of type
+// 'eventType'. The lag of applied events emitted at 'emittedAt' is recorded
+// as well, unless the time they were emitted at is unknown.
+func ObserveEvent(eventType string, outcome string, emittedAt time.Time) {
+	eventsTotal.WithLabelValues(eventType, outcome).Inc()
+	if outcome == EventApplied && !emittedAt.IsZero() {
+		eventLag.WithLabelValues(eventType).Observe(time.Since(emittedAt).Seconds())
+	}
+}
+
+// ObserveSTMTransaction records a transaction that was attempted 'attempts' times
+func ObserveSTMTransaction(attempts int, err error) {
+	stmTransactionsTotal.WithLabelValues(outcome(err)).Inc()
+	if attempts > 1 {
+		stmConflictsTotal.Add(float64(attempts - 1))
+	}
+}
+
+// ObserveEtcdRequest records the latency of an etcd request that started at 'start'
+func ObserveEtcdRequest(operation string, start time.Time, err error) {
+	etcdRequestDuration.WithLabelValues(operation, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveReconcile records the duration of a reconcile loop that started at 'start'
+func ObserveReconcile(start time.Time, err error) {
+	reconcileDuration.WithLabelValues(outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// AddDrift records 'count' records of entity 'entity' that the reconciler
+// found out of sync with ECS
+func AddDrift(entity string, kind string, count int) {
+	if count > 0 {
+		reconcileDriftTotal.WithLabelValues(entity, kind).Add(float64(count))
+	}
+}
+
+// StreamOpened records a data store stream being opened. The returned
+// function records it being closed.
+func StreamOpened() func() {
+	openStreams.Inc()
+	return openStreams.Dec
+}
+
+// ObserveHTTPRequest records the latency of an HTTP request to 'route' that started at 'start'
+func ObserveHTTPRequest(route string, method string, code int, start time.Time) {
+	httpRequestDuration.WithLabelValues(route, method, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+}
+
+// SetSQSQueueMessages records the value of an approximate number of messages attribute of the SQS queue
+func SetSQSQueueMessages(attribute string, count int) {
+	sqsQueueMessages.WithLabelValues(attribute).Set(float64(count))
+}
+
+// SetKinesisMillisBehindLatest records how far behind the tip of the stream the Kinesis consumer is
+func SetKinesisMillisBehindLatest(millis int64) {
+	kinesisMillisBehindLatest.Set(float64(millis))
+}
+
+// ObserveBatch records a batch of 'records' records applied to etcd, some of
+// which were applied one by one if 'fellBack' is set
+func ObserveBatch(records int, fellBack bool) {
+	batchRecords.Observe(float64(records))
+	if fellBack {
+		batchFallbacksTotal.Inc()
+	}
+}
+
+// SetBufferedEvents records the number of events waiting in the write-ahead buffer
+func SetBufferedEvents(count int) {
+	bufferedEvents.Set(float64(count))
+}
+
+// SetEtcdCircuitState records the state of the circuit breaker guarding etcd
+func SetEtcdCircuitState(state int) {
+	etcdCircuitState.Set(float64(state))
+}
+
+// SetViewRevision records the etcd revision reflected by the in-memory view
+func SetViewRevision(revision int64) {
+	viewRevision.Set(float64(revision))
+}
+
+// ObserveViewCheck records a consistency check of the in-memory view that found
+// 'inconsistencies' records differing from etcd
+func ObserveViewCheck(inconsistencies int) {
+	if inconsistencies > 0 {
+		viewChecksTotal.WithLabelValues(errorOutcome).Inc()
+		viewInconsistenciesTotal.Add(float64(inconsistencies))
+		return
+	}
+	viewChecksTotal.WithLabelValues(successOutcome).Inc()
+}
+
+// ObserveCompaction records compacting etcd to 'revision'
+func ObserveCompaction(revision int64, err error) {
+	etcdCompactionsTotal.WithLabelValues(outcome(err)).Inc()
+	if err == nil {
+		etcdCompactedRevision.Set(float64(revision))
+	}
+}
+
+// ObserveDefragmentation records defragmenting the etcd member at 'endpoint'
+func ObserveDefragmentation(endpoint string, err error) {
+	etcdDefragmentationsTotal.WithLabelValues(endpoint, outcome(err)).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return errorOutcome
+	}
+	return successOutcome
+}
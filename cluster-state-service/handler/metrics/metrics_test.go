@@ -0,0 +1,130 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/negroni"
+)
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	err := counter.Write(m)
+	assert.Nil(t, err, "Unexpected error reading counter")
+	return m.GetCounter().GetValue()
+}
+
+func sampleCount(t *testing.T, histogram prometheus.Histogram) uint64 {
+	m := &dto.Metric{}
+	err := histogram.Write(m)
+	assert.Nil(t, err, "Unexpected error reading histogram")
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestObserveEvent(t *testing.T) {
+	applied := counterValue(t, eventsTotal.WithLabelValues("task", EventApplied))
+	failed := counterValue(t, eventsTotal.WithLabelValues("task", EventFailed))
+	lags := sampleCount(t, eventLag.WithLabelValues("task"))
+
+	ObserveEvent("task", EventApplied, time.Now().Add(-time.Second))
+	ObserveEvent("task", EventApplied, time.Time{})
+	ObserveEvent("task", EventFailed, time.Now())
+
+	assert.Equal(t, applied+2, counterValue(t, eventsTotal.WithLabelValues("task", EventApplied)), "Expected applied events to be counted")
+	assert.Equal(t, failed+1, counterValue(t, eventsTotal.WithLabelValues("task", EventFailed)), "Expected failed events to be counted")
+	assert.Equal(t, lags+1, sampleCount(t, eventLag.WithLabelValues("task")),
+		"Expected the lag of applied events with a known emit time only to be observed")
+}
+
+func TestObserveSTMTransaction(t *testing.T) {
+	conflicts := counterValue(t, stmConflictsTotal)
+	failed := counterValue(t, stmTransactionsTotal.WithLabelValues(errorOutcome))
+
+	ObserveSTMTransaction(1, nil)
+	ObserveSTMTransaction(3, nil)
+	ObserveSTMTransaction(1, errors.New("Error committing transaction"))
+
+	assert.Equal(t, conflicts+2, counterValue(t, stmConflictsTotal), "Expected retried attempts to be counted as conflicts")
+	assert.Equal(t, failed+1, counterValue(t, stmTransactionsTotal.WithLabelValues(errorOutcome)), "Expected failed transactions to be counted")
+}
+
+func TestAddDrift(t *testing.T) {
+	missing := counterValue(t, reconcileDriftTotal.WithLabelValues(TaskEntity, DriftMissing))
+
+	AddDrift(TaskEntity, DriftMissing, 3)
+	AddDrift(TaskEntity, DriftMissing, 0)
+
+	assert.Equal(t, missing+3, counterValue(t, reconcileDriftTotal.WithLabelValues(TaskEntity, DriftMissing)), "Unexpected drift count")
+}
+
+func TestStreamOpened(t *testing.T) {
+	m := &dto.Metric{}
+	openStreams.Write(m)
+	open := m.GetGauge().GetValue()
+
+	closed := StreamOpened()
+	openStreams.Write(m)
+	assert.Equal(t, open+1, m.GetGauge().GetValue(), "Expected the stream to be counted as open")
+
+	closed()
+	openStreams.Write(m)
+	assert.Equal(t, open, m.GetGauge().GetValue(), "Expected the stream to be counted as closed")
+}
+
+func TestHTTPMiddlewareLabelsRequestsWithRouteTemplates(t *testing.T) {
+	r := mux.NewRouter().StrictSlash(true)
+	s := r.Path("/v1").Subrouter()
+	s.Path("/tasks/{cluster}").Methods("GET").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	n := negroni.New()
+	n.Use(NewHTTPMiddleware(s))
+	n.UseHandler(s)
+
+	route := sampleCount(t, httpRequestDuration.WithLabelValues("/v1/tasks/{cluster}", "GET", "404"))
+	unmatched := sampleCount(t, httpRequestDuration.WithLabelValues(unmatchedRoute, "GET", "404"))
+
+	for _, path := range []string{"/v1/tasks/default", "/v1/tasks/other", "/v2/tasks"} {
+		request, err := http.NewRequest("GET", path, nil)
+		assert.Nil(t, err, "Unexpected error creating request")
+		n.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	assert.Equal(t, route+2, sampleCount(t, httpRequestDuration.WithLabelValues("/v1/tasks/{cluster}", "GET", "404")),
+		"Expected requests to be labeled with the route template")
+	assert.Equal(t, unmatched+1, sampleCount(t, httpRequestDuration.WithLabelValues(unmatchedRoute, "GET", "404")),
+		"Expected requests matching no route to be labeled as unmatched")
+}
+
+func TestHandlerServesMetrics(t *testing.T) {
+	ObserveEvent("task", EventApplied, time.Now())
+
+	request, err := http.NewRequest("GET", "/metrics", nil)
+	assert.Nil(t, err, "Unexpected error creating request")
+	responseRecorder := httptest.NewRecorder()
+	Handler().ServeHTTP(responseRecorder, request)
+
+	assert.Equal(t, http.StatusOK, responseRecorder.Code, "Unexpected status code")
+	assert.Contains(t, responseRecorder.Body.String(), `css_events_total{outcome="applied",type="task"}`, "Expected event counts to be served")
+}
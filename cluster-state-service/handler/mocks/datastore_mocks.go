@@ -67,6 +67,17 @@ func (_mr *_MockDataStoreRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0)
 }
 
+func (_m *MockDataStore) DeleteIfUnmodified(_param0 string, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "DeleteIfUnmodified", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockDataStoreRecorder) DeleteIfUnmodified(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteIfUnmodified", arg0, arg1)
+}
+
 func (_m *MockDataStore) Get(_param0 string) (map[string]types.Entity, error) {
 	ret := _m.ctrl.Call(_m, "Get", _param0)
 	ret0, _ := ret[0].(map[string]types.Entity)
@@ -99,6 +110,18 @@ func (_mr *_MockDataStoreRecorder) GetWithPrefix(arg0 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetWithPrefix", arg0)
 }
 
+func (_m *MockDataStore) GetWithPrefixAtRevision(_param0 string, _param1 int64) (map[string]types.Entity, int64, error) {
+	ret := _m.ctrl.Call(_m, "GetWithPrefixAtRevision", _param0, _param1)
+	ret0, _ := ret[0].(map[string]types.Entity)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockDataStoreRecorder) GetWithPrefixAtRevision(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetWithPrefixAtRevision", arg0, arg1)
+}
+
 func (_m *MockDataStore) NewSTMRepeatable(_param0 context.Context, _param1 *clientv3.Client, _param2 func(concurrency.STM) error) (*clientv3.TxnResponse, error) {
 	ret := _m.ctrl.Call(_m, "NewSTMRepeatable", _param0, _param1, _param2)
 	ret0, _ := ret[0].(*clientv3.TxnResponse)
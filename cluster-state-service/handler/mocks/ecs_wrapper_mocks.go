@@ -54,6 +54,29 @@ func (_mr *_MockECSWrapperRecorder) DescribeContainerInstances(arg0, arg1 interf
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeContainerInstances", arg0, arg1)
 }
 
+func (_m *MockECSWrapper) DescribeServices(_param0 *string, _param1 []*string) ([]types.Service, []string, error) {
+	ret := _m.ctrl.Call(_m, "DescribeServices", _param0, _param1)
+	ret0, _ := ret[0].([]types.Service)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockECSWrapperRecorder) DescribeServices(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeServices", arg0, arg1)
+}
+
+func (_m *MockECSWrapper) DescribeTaskDefinition(_param0 *string) (types.TaskDefinition, error) {
+	ret := _m.ctrl.Call(_m, "DescribeTaskDefinition", _param0)
+	ret0, _ := ret[0].(types.TaskDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockECSWrapperRecorder) DescribeTaskDefinition(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DescribeTaskDefinition", arg0)
+}
+
 func (_m *MockECSWrapper) DescribeTasks(_param0 *string, _param1 []*string) ([]types.Task, []string, error) {
 	ret := _m.ctrl.Call(_m, "DescribeTasks", _param0, _param1)
 	ret0, _ := ret[0].([]types.Task)
@@ -88,6 +111,17 @@ func (_mr *_MockECSWrapperRecorder) ListAllContainerInstances(arg0 interface{})
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllContainerInstances", arg0)
 }
 
+func (_m *MockECSWrapper) ListAllServices(_param0 *string) ([]*string, error) {
+	ret := _m.ctrl.Call(_m, "ListAllServices", _param0)
+	ret0, _ := ret[0].([]*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockECSWrapperRecorder) ListAllServices(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllServices", arg0)
+}
+
 func (_m *MockECSWrapper) ListTasksWithDesiredStatus(_param0 *string, _param1 *string) ([]*string, error) {
 	ret := _m.ctrl.Call(_m, "ListTasksWithDesiredStatus", _param0, _param1)
 	ret0, _ := ret[0].([]*string)
@@ -101,6 +101,16 @@ func (_mr *_MockEtcdInterfaceRecorder) Put(arg0, arg1, arg2 interface{}, arg3 ..
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Put", _s...)
 }
 
+func (_m *MockEtcdInterface) Txn(_param0 context.Context) clientv3.Txn {
+	ret := _m.ctrl.Call(_m, "Txn", _param0)
+	ret0, _ := ret[0].(clientv3.Txn)
+	return ret0
+}
+
+func (_mr *_MockEtcdInterfaceRecorder) Txn(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Txn", arg0)
+}
+
 func (_m *MockEtcdInterface) Watch(_param0 context.Context, _param1 string, _param2 ...clientv3.OpOption) clientv3.WatchChan {
 	_s := []interface{}{_param0, _param1}
 	for _, _x := range _param2 {
@@ -64,6 +64,17 @@ func (_mr *_MockContainerInstanceStoreRecorder) GetContainerInstance(arg0, arg1
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetContainerInstance", arg0, arg1)
 }
 
+func (_m *MockContainerInstanceStore) GetContainerInstanceHistory(cluster string, instanceARN string) ([]types.ContainerInstanceHistoryEntry, error) {
+	ret := _m.ctrl.Call(_m, "GetContainerInstanceHistory", cluster, instanceARN)
+	ret0, _ := ret[0].([]types.ContainerInstanceHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockContainerInstanceStoreRecorder) GetContainerInstanceHistory(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetContainerInstanceHistory", arg0, arg1)
+}
+
 func (_m *MockContainerInstanceStore) ListContainerInstances() ([]types.VersionedContainerInstance, error) {
 	ret := _m.ctrl.Call(_m, "ListContainerInstances")
 	ret0, _ := ret[0].([]types.VersionedContainerInstance)
@@ -86,6 +97,30 @@ func (_mr *_MockContainerInstanceStoreRecorder) FilterContainerInstances(arg0 in
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "FilterContainerInstances", arg0)
 }
 
+func (_m *MockContainerInstanceStore) ListContainerInstancesAtVersion(entityVersion string) ([]types.VersionedContainerInstance, int64, error) {
+	ret := _m.ctrl.Call(_m, "ListContainerInstancesAtVersion", entityVersion)
+	ret0, _ := ret[0].([]types.VersionedContainerInstance)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockContainerInstanceStoreRecorder) ListContainerInstancesAtVersion(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListContainerInstancesAtVersion", arg0)
+}
+
+func (_m *MockContainerInstanceStore) FilterContainerInstancesAtVersion(filterMap map[string]string, entityVersion string) ([]types.VersionedContainerInstance, int64, error) {
+	ret := _m.ctrl.Call(_m, "FilterContainerInstancesAtVersion", filterMap, entityVersion)
+	ret0, _ := ret[0].([]types.VersionedContainerInstance)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockContainerInstanceStoreRecorder) FilterContainerInstancesAtVersion(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FilterContainerInstancesAtVersion", arg0, arg1)
+}
+
 func (_m *MockContainerInstanceStore) StreamContainerInstances(ctx context.Context, entityVersion string) (chan types.VersionedContainerInstance, error) {
 	ret := _m.ctrl.Call(_m, "StreamContainerInstances", ctx, entityVersion)
 	ret0, _ := ret[0].(chan types.VersionedContainerInstance)
@@ -106,3 +141,13 @@ func (_m *MockContainerInstanceStore) DeleteContainerInstance(cluster string, in
 func (_mr *_MockContainerInstanceStoreRecorder) DeleteContainerInstance(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteContainerInstance", arg0, arg1)
 }
+
+func (_m *MockContainerInstanceStore) PurgeContainerInstance(cluster string, instanceARN string, version int64) error {
+	ret := _m.ctrl.Call(_m, "PurgeContainerInstance", cluster, instanceARN, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockContainerInstanceStoreRecorder) PurgeContainerInstance(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PurgeContainerInstance", arg0, arg1, arg2)
+}
@@ -64,6 +64,17 @@ func (_mr *_MockTaskStoreRecorder) GetTask(arg0, arg1 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTask", arg0, arg1)
 }
 
+func (_m *MockTaskStore) GetTaskHistory(cluster string, taskARN string) ([]types.TaskHistoryEntry, error) {
+	ret := _m.ctrl.Call(_m, "GetTaskHistory", cluster, taskARN)
+	ret0, _ := ret[0].([]types.TaskHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockTaskStoreRecorder) GetTaskHistory(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTaskHistory", arg0, arg1)
+}
+
 func (_m *MockTaskStore) ListTasks() ([]types.VersionedTask, error) {
 	ret := _m.ctrl.Call(_m, "ListTasks")
 	ret0, _ := ret[0].([]types.VersionedTask)
@@ -86,6 +97,30 @@ func (_mr *_MockTaskStoreRecorder) FilterTasks(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "FilterTasks", arg0)
 }
 
+func (_m *MockTaskStore) ListTasksAtVersion(entityVersion string) ([]types.VersionedTask, int64, error) {
+	ret := _m.ctrl.Call(_m, "ListTasksAtVersion", entityVersion)
+	ret0, _ := ret[0].([]types.VersionedTask)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockTaskStoreRecorder) ListTasksAtVersion(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListTasksAtVersion", arg0)
+}
+
+func (_m *MockTaskStore) FilterTasksAtVersion(filterMap map[string]string, entityVersion string) ([]types.VersionedTask, int64, error) {
+	ret := _m.ctrl.Call(_m, "FilterTasksAtVersion", filterMap, entityVersion)
+	ret0, _ := ret[0].([]types.VersionedTask)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockTaskStoreRecorder) FilterTasksAtVersion(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FilterTasksAtVersion", arg0, arg1)
+}
+
 func (_m *MockTaskStore) StreamTasks(ctx context.Context, entityVersion string) (chan types.VersionedTask, error) {
 	ret := _m.ctrl.Call(_m, "StreamTasks", ctx, entityVersion)
 	ret0, _ := ret[0].(chan types.VersionedTask)
@@ -106,3 +141,13 @@ func (_m *MockTaskStore) DeleteTask(cluster string, taskARN string) error {
 func (_mr *_MockTaskStoreRecorder) DeleteTask(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteTask", arg0, arg1)
 }
+
+func (_m *MockTaskStore) PurgeTask(cluster string, taskARN string, version int64) error {
+	ret := _m.ctrl.Call(_m, "PurgeTask", cluster, taskARN, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockTaskStoreRecorder) PurgeTask(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PurgeTask", arg0, arg1, arg2)
+}
@@ -0,0 +1,248 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package placement evaluates which container instances of a cluster can host
+// a task, using the resources that the instances report as remaining.
+package placement
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+)
+
+// Reasons why an instance cannot host a task
+const (
+	ReasonInstanceNotActive   = "INSTANCE_NOT_ACTIVE"
+	ReasonAgentDisconnected   = "AGENT_DISCONNECTED"
+	ReasonInsufficientCPU     = "INSUFFICIENT_CPU"
+	ReasonInsufficientMemory  = "INSUFFICIENT_MEMORY"
+	ReasonPortConflict        = "PORT_CONFLICT"
+	ReasonAttributeConstraint = "ATTRIBUTE_CONSTRAINT"
+)
+
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+
+	instanceActiveStatus = "ACTIVE"
+	awsvpcNetworkMode    = "awsvpc"
+	hostNetworkMode      = "host"
+
+	cpuResource      = "CPU"
+	memoryResource   = "MEMORY"
+	tcpPortsResource = "PORTS"
+	udpPortsResource = "PORTS_UDP"
+)
+
+// Port is a host port that a task reserves on the instance it is placed on
+type Port struct {
+	Port     int64
+	Protocol string
+}
+
+// Requirements are the resources that a task reserves on the instance it is placed on
+type Requirements struct {
+	CPU    int64
+	Memory int64
+	Ports  []Port
+}
+
+// Constraint requires an instance to have the attribute named Attribute and,
+// if Value is set, requires the attribute to have that value
+type Constraint struct {
+	Attribute string
+	Value     *string
+}
+
+// Reason explains why an instance cannot host a task
+type Reason struct {
+	Code    string
+	Message string
+}
+
+// Evaluation is the result of evaluating whether an instance can host a task
+type Evaluation struct {
+	Instance types.ContainerInstance
+	Reasons  []Reason
+}
+
+// Fits returns true if the instance can host the task
+func (evaluation Evaluation) Fits() bool {
+	return len(evaluation.Reasons) == 0
+}
+
+// RequirementsFromTaskDefinition returns the resources that a task of the task
+// definition reserves. Containers reserve their hard memory limit, or their
+// soft limit if they do not have a hard one. Dynamic host ports and the ports
+// of tasks with their own network interface do not reserve instance ports.
+func RequirementsFromTaskDefinition(taskDefinition types.TaskDefinition) Requirements {
+	var requirements Requirements
+	if taskDefinition.Detail == nil {
+		return requirements
+	}
+	networkMode := taskDefinition.Detail.NetworkMode
+	for _, container := range taskDefinition.Detail.ContainerDefinitions {
+		if container == nil {
+			continue
+		}
+		requirements.CPU += container.CPU
+		if container.Memory > 0 {
+			requirements.Memory += container.Memory
+		} else {
+			requirements.Memory += container.MemoryReservation
+		}
+		if networkMode == awsvpcNetworkMode {
+			continue
+		}
+		for _, portMapping := range container.PortMappings {
+			if portMapping == nil {
+				continue
+			}
+			hostPort := portMapping.HostPort
+			if networkMode == hostNetworkMode {
+				hostPort = aws.Int64Value(portMapping.ContainerPort)
+			}
+			if hostPort == 0 {
+				continue
+			}
+			protocol := portMapping.Protocol
+			if protocol == "" {
+				protocol = ProtocolTCP
+			}
+			requirements.Ports = append(requirements.Ports, Port{Port: hostPort, Protocol: protocol})
+		}
+	}
+	return requirements
+}
+
+// Evaluate evaluates whether each of the instances can host a task with the
+// requirements that is subject to the constraints. Evaluations are ordered by
+// instance ARN.
+func Evaluate(requirements Requirements, constraints []Constraint, instances []types.ContainerInstance) []Evaluation {
+	evaluations := make([]Evaluation, 0, len(instances))
+	for _, instance := range instances {
+		if instance.Detail == nil {
+			continue
+		}
+		evaluations = append(evaluations, Evaluation{
+			Instance: instance,
+			Reasons:  evaluateInstance(requirements, constraints, instance.Detail),
+		})
+	}
+	sort.Slice(evaluations, func(i, j int) bool {
+		return aws.StringValue(evaluations[i].Instance.Detail.ContainerInstanceARN) <
+			aws.StringValue(evaluations[j].Instance.Detail.ContainerInstanceARN)
+	})
+	return evaluations
+}
+
+func evaluateInstance(requirements Requirements, constraints []Constraint, instance *types.InstanceDetail) []Reason {
+	var reasons []Reason
+
+	if status := aws.StringValue(instance.Status); status != instanceActiveStatus {
+		reasons = append(reasons, Reason{
+			Code:    ReasonInstanceNotActive,
+			Message: fmt.Sprintf("Instance status is '%s'", status),
+		})
+	}
+	if !aws.BoolValue(instance.AgentConnected) {
+		reasons = append(reasons, Reason{
+			Code:    ReasonAgentDisconnected,
+			Message: "Instance agent is not connected",
+		})
+	}
+
+	if remaining := integerResource(instance.RemainingResources, cpuResource); remaining < requirements.CPU {
+		reasons = append(reasons, Reason{
+			Code:    ReasonInsufficientCPU,
+			Message: fmt.Sprintf("Requires %d CPU units, %d remaining", requirements.CPU, remaining),
+		})
+	}
+	if remaining := integerResource(instance.RemainingResources, memoryResource); remaining < requirements.Memory {
+		reasons = append(reasons, Reason{
+			Code:    ReasonInsufficientMemory,
+			Message: fmt.Sprintf("Requires %d MiB of memory, %d MiB remaining", requirements.Memory, remaining),
+		})
+	}
+
+	// The port resources of an instance list the ports that are reserved on it
+	reservedPorts := map[string]map[string]struct{}{
+		ProtocolTCP: stringSetResource(instance.RemainingResources, tcpPortsResource),
+		ProtocolUDP: stringSetResource(instance.RemainingResources, udpPortsResource),
+	}
+	for _, port := range requirements.Ports {
+		if _, ok := reservedPorts[port.Protocol][strconv.FormatInt(port.Port, 10)]; ok {
+			reasons = append(reasons, Reason{
+				Code:    ReasonPortConflict,
+				Message: fmt.Sprintf("Port %d/%s is already reserved", port.Port, port.Protocol),
+			})
+		}
+	}
+
+	for _, constraint := range constraints {
+		if !satisfiesConstraint(instance.Attributes, constraint) {
+			reasons = append(reasons, Reason{
+				Code:    ReasonAttributeConstraint,
+				Message: constraintMessage(constraint),
+			})
+		}
+	}
+
+	return reasons
+}
+
+func integerResource(resources []*types.Resource, name string) int64 {
+	var value int64
+	for _, r := range resources {
+		if r != nil && aws.StringValue(r.Name) == name {
+			value += aws.Int64Value(r.IntegerValue)
+		}
+	}
+	return value
+}
+
+func stringSetResource(resources []*types.Resource, name string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, r := range resources {
+		if r == nil || aws.StringValue(r.Name) != name {
+			continue
+		}
+		for _, v := range r.StringSetValue {
+			set[aws.StringValue(v)] = struct{}{}
+		}
+	}
+	return set
+}
+
+func satisfiesConstraint(attributes []*types.Attribute, constraint Constraint) bool {
+	for _, attribute := range attributes {
+		if attribute == nil || aws.StringValue(attribute.Name) != constraint.Attribute {
+			continue
+		}
+		if constraint.Value == nil || aws.StringValue(attribute.Value) == aws.StringValue(constraint.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func constraintMessage(constraint Constraint) string {
+	if constraint.Value == nil {
+		return fmt.Sprintf("Instance does not have attribute '%s'", constraint.Attribute)
+	}
+	return fmt.Sprintf("Instance does not have attribute '%s' with value '%s'", constraint.Attribute, aws.StringValue(constraint.Value))
+}
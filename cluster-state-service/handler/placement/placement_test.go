@@ -0,0 +1,168 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	instanceARN1 = "arn:aws:ecs:us-east-1:123456789012:container-instance/1"
+	instanceARN2 = "arn:aws:ecs:us-east-1:123456789012:container-instance/2"
+)
+
+func instance(arn string, cpu int64, memory int64, tcpPorts ...string) types.ContainerInstance {
+	return types.ContainerInstance{
+		Detail: &types.InstanceDetail{
+			AgentConnected: aws.Bool(true),
+			Attributes: []*types.Attribute{
+				{Name: aws.String("ecs.instance-type"), Value: aws.String("t2.micro")},
+				{Name: aws.String("com.amazonaws.ecs.capability.privileged-container")},
+			},
+			ContainerInstanceARN: aws.String(arn),
+			RemainingResources: []*types.Resource{
+				{Name: aws.String("CPU"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(cpu)},
+				{Name: aws.String("MEMORY"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(memory)},
+				{Name: aws.String("PORTS"), Type: aws.String("STRINGSET"), StringSetValue: aws.StringSlice(tcpPorts)},
+			},
+			Status: aws.String("ACTIVE"),
+		},
+	}
+}
+
+func reasonCodes(evaluation Evaluation) []string {
+	codes := make([]string, 0, len(evaluation.Reasons))
+	for _, reason := range evaluation.Reasons {
+		codes = append(codes, reason.Code)
+	}
+	return codes
+}
+
+func TestRequirementsFromTaskDefinition(t *testing.T) {
+	taskDefinition := types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			NetworkMode: "bridge",
+			ContainerDefinitions: []*types.ContainerDefinition{
+				{
+					CPU:               256,
+					Memory:            512,
+					MemoryReservation: 128,
+					PortMappings: []*types.PortMapping{
+						{ContainerPort: aws.Int64(80), HostPort: 8080},
+						// Dynamic host port
+						{ContainerPort: aws.Int64(443)},
+					},
+				},
+				{
+					CPU:               128,
+					MemoryReservation: 256,
+					PortMappings: []*types.PortMapping{
+						{ContainerPort: aws.Int64(53), HostPort: 53, Protocol: "udp"},
+					},
+				},
+			},
+		},
+	}
+
+	requirements := RequirementsFromTaskDefinition(taskDefinition)
+	assert.Equal(t, Requirements{
+		CPU:    384,
+		Memory: 768,
+		Ports:  []Port{{Port: 8080, Protocol: ProtocolTCP}, {Port: 53, Protocol: ProtocolUDP}},
+	}, requirements, "Unexpected requirements")
+}
+
+func TestRequirementsFromTaskDefinitionHostNetworkMode(t *testing.T) {
+	taskDefinition := types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			NetworkMode: "host",
+			ContainerDefinitions: []*types.ContainerDefinition{
+				{PortMappings: []*types.PortMapping{{ContainerPort: aws.Int64(80)}}},
+			},
+		},
+	}
+
+	requirements := RequirementsFromTaskDefinition(taskDefinition)
+	assert.Equal(t, []Port{{Port: 80, Protocol: ProtocolTCP}}, requirements.Ports, "Expected container ports to be reserved in host network mode")
+}
+
+func TestRequirementsFromTaskDefinitionAWSVPCNetworkMode(t *testing.T) {
+	taskDefinition := types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			NetworkMode: "awsvpc",
+			ContainerDefinitions: []*types.ContainerDefinition{
+				{PortMappings: []*types.PortMapping{{ContainerPort: aws.Int64(80), HostPort: 80}}},
+			},
+		},
+	}
+
+	requirements := RequirementsFromTaskDefinition(taskDefinition)
+	assert.Empty(t, requirements.Ports, "Expected no instance ports to be reserved in awsvpc network mode")
+}
+
+func TestEvaluateInstanceFits(t *testing.T) {
+	requirements := Requirements{CPU: 256, Memory: 512, Ports: []Port{{Port: 80, Protocol: ProtocolTCP}}}
+
+	evaluations := Evaluate(requirements, nil, []types.ContainerInstance{instance(instanceARN1, 256, 512, "22")})
+	assert.Len(t, evaluations, 1, "Expected one evaluation")
+	assert.True(t, evaluations[0].Fits(), "Expected instance to fit the task")
+}
+
+func TestEvaluateInsufficientResourcesAndPortConflict(t *testing.T) {
+	requirements := Requirements{CPU: 512, Memory: 1024, Ports: []Port{{Port: 80, Protocol: ProtocolTCP}, {Port: 80, Protocol: ProtocolUDP}}}
+
+	evaluations := Evaluate(requirements, nil, []types.ContainerInstance{instance(instanceARN1, 256, 512, "22", "80")})
+	assert.False(t, evaluations[0].Fits(), "Expected instance not to fit the task")
+	assert.Equal(t, []string{ReasonInsufficientCPU, ReasonInsufficientMemory, ReasonPortConflict}, reasonCodes(evaluations[0]), "Unexpected reasons")
+}
+
+func TestEvaluateInactiveAndDisconnectedInstance(t *testing.T) {
+	i := instance(instanceARN1, 1024, 1024)
+	i.Detail.Status = aws.String("DRAINING")
+	i.Detail.AgentConnected = aws.Bool(false)
+
+	evaluations := Evaluate(Requirements{}, nil, []types.ContainerInstance{i})
+	assert.Equal(t, []string{ReasonInstanceNotActive, ReasonAgentDisconnected}, reasonCodes(evaluations[0]), "Unexpected reasons")
+}
+
+func TestEvaluateAttributeConstraints(t *testing.T) {
+	i := instance(instanceARN1, 1024, 1024)
+
+	constraints := []Constraint{
+		{Attribute: "com.amazonaws.ecs.capability.privileged-container"},
+		{Attribute: "ecs.instance-type", Value: aws.String("t2.micro")},
+	}
+	evaluations := Evaluate(Requirements{}, constraints, []types.ContainerInstance{i})
+	assert.True(t, evaluations[0].Fits(), "Expected instance to satisfy the constraints")
+
+	constraints = []Constraint{
+		{Attribute: "ecs.instance-type", Value: aws.String("m4.large")},
+		{Attribute: "ecs.availability-zone"},
+	}
+	evaluations = Evaluate(Requirements{}, constraints, []types.ContainerInstance{i})
+	assert.Equal(t, []string{ReasonAttributeConstraint, ReasonAttributeConstraint}, reasonCodes(evaluations[0]), "Unexpected reasons")
+}
+
+func TestEvaluateOrdersByInstanceARN(t *testing.T) {
+	instances := []types.ContainerInstance{instance(instanceARN2, 0, 0), instance(instanceARN1, 0, 0), {}}
+
+	evaluations := Evaluate(Requirements{}, nil, instances)
+	assert.Len(t, evaluations, 2, "Expected instances without detail to be skipped")
+	assert.Equal(t, instanceARN1, aws.StringValue(evaluations[0].Instance.Detail.ContainerInstanceARN), "Expected evaluations ordered by instance ARN")
+	assert.Equal(t, instanceARN2, aws.StringValue(evaluations[1].Instance.Detail.ContainerInstanceARN), "Expected evaluations ordered by instance ARN")
+}
@@ -0,0 +1,82 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+// ClusterLoader defines the interface to load clusters from ECS and to merge
+// them with the clusters in the data store.
+type ClusterLoader interface {
+	LoadClusters() error
+}
+
+// clusterLoader implements the ClusterLoader interface.
+type clusterLoader struct {
+	clusterStore store.ClusterStore
+	ecsWrapper   ECSWrapper
+}
+
+func NewClusterLoader(clusterStore store.ClusterStore, ecsClient ecsiface.ECSAPI) ClusterLoader {
+	return clusterLoader{
+		clusterStore: clusterStore,
+		ecsWrapper:   NewECSWrapper(ecsClient),
+	}
+}
+
+// LoadClusters adds the clusters listed by ECS that are not in the data store
+// yet and deletes the ones that ECS does not list anymore
+func (loader clusterLoader) LoadClusters() error {
+	clusters, err := loader.clusterStore.ListClusters()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading clusters from data store")
+	}
+	localState := make(map[string]struct{})
+	for _, versionedCluster := range clusters {
+		localState[aws.StringValue(versionedCluster.Cluster.Detail.ClusterARN)] = struct{}{}
+	}
+
+	clusterARNs, err := loader.ecsWrapper.ListAllClusters()
+	if err != nil {
+		return errors.Wrapf(err, "Error listing clusters from ECS")
+	}
+	ecsState := make(map[string]struct{})
+	for _, cluster := range clusterARNs {
+		clusterARN := aws.StringValue(cluster)
+		ecsState[clusterARN] = struct{}{}
+		if _, ok := localState[clusterARN]; ok {
+			continue
+		}
+		err := loader.clusterStore.AddCluster(clusterARN)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to add cluster '%s'", clusterARN)
+		}
+	}
+
+	for clusterARN := range localState {
+		if _, ok := ecsState[clusterARN]; ok {
+			continue
+		}
+		// Not handling returned error because we want as many cleanup operations to succeed as possible.
+		if err := loader.clusterStore.DeleteCluster(clusterARN); err != nil {
+			log.Infof("Error deleting cluster '%s' from data store", clusterARN)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	loadedClusterARN1   = "arn:aws:ecs:us-east-1:123456789012:cluster/cluster1"
+	loadedClusterARN2   = "arn:aws:ecs:us-east-1:123456789012:cluster/cluster2"
+	redundantClusterARN = "arn:aws:ecs:us-east-1:123456789012:cluster/red-un-da-nt"
+)
+
+type ClusterLoaderTestSuite struct {
+	suite.Suite
+	clusterStore              *mocks.MockClusterStore
+	ecsWrapper                *mocks.MockECSWrapper
+	clusterLoader             ClusterLoader
+	clusterARNList            []*string
+	versionedCluster          storetypes.VersionedCluster
+	redundantVersionedCluster storetypes.VersionedCluster
+}
+
+func (suite *ClusterLoaderTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.clusterStore = mocks.NewMockClusterStore(mockCtrl)
+	suite.ecsWrapper = mocks.NewMockECSWrapper(mockCtrl)
+
+	suite.clusterLoader = clusterLoader{
+		clusterStore: suite.clusterStore,
+		ecsWrapper:   suite.ecsWrapper,
+	}
+
+	suite.clusterARNList = []*string{&loadedClusterARN1, &loadedClusterARN2}
+
+	suite.versionedCluster = storetypes.VersionedCluster{
+		Cluster: types.Cluster{
+			Detail: &types.ClusterDetail{
+				ClusterARN: &loadedClusterARN1,
+			},
+		},
+		Version: "123",
+	}
+	suite.redundantVersionedCluster = storetypes.VersionedCluster{
+		Cluster: types.Cluster{
+			Detail: &types.ClusterDetail{
+				ClusterARN: &redundantClusterARN,
+			},
+		},
+		Version: "123",
+	}
+}
+
+func TestClusterLoaderTestSuite(t *testing.T) {
+	suite.Run(t, new(ClusterLoaderTestSuite))
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersStoreListReturnsError() {
+	suite.clusterStore.EXPECT().ListClusters().Return(nil, errors.New("Error while listing clusters"))
+	suite.ecsWrapper.EXPECT().ListAllClusters().Times(0)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when listing clusters")
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersListAllClustersReturnsError() {
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().ListClusters().Return(make([]storetypes.VersionedCluster, 0), nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(nil, errors.New("Error while listing all clusters")),
+	)
+	suite.clusterStore.EXPECT().AddCluster(gomock.Any()).Times(0)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Error(suite.T(), err, "Expected an error when ecs returns an error when listing clusters")
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersStoreAddReturnsError() {
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().ListClusters().Return(make([]storetypes.VersionedCluster, 0), nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.clusterStore.EXPECT().AddCluster(loadedClusterARN1).Return(errors.New("Error while adding cluster")),
+	)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when adding cluster")
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersAddsClustersNotInStore() {
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().ListClusters().Return([]storetypes.VersionedCluster{suite.versionedCluster}, nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.clusterStore.EXPECT().AddCluster(loadedClusterARN2).Return(nil),
+	)
+	suite.clusterStore.EXPECT().DeleteCluster(gomock.Any()).Times(0)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Nil(suite.T(), err, "Unexpected error when loading clusters")
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersRedundantEntriesInLocalStore() {
+	clusters := []storetypes.VersionedCluster{suite.versionedCluster, suite.redundantVersionedCluster}
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().ListClusters().Return(clusters, nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return([]*string{&loadedClusterARN1}, nil),
+		// Expect delete of the cluster that ECS does not list
+		suite.clusterStore.EXPECT().DeleteCluster(redundantClusterARN).Return(nil),
+	)
+	suite.clusterStore.EXPECT().AddCluster(gomock.Any()).Times(0)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Nil(suite.T(), err, "Unexpected error when loading clusters")
+}
+
+func (suite *ClusterLoaderTestSuite) TestLoadClustersDeleteReturnsError() {
+	clusters := []storetypes.VersionedCluster{suite.redundantVersionedCluster}
+	gomock.InOrder(
+		suite.clusterStore.EXPECT().ListClusters().Return(clusters, nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(make([]*string, 0), nil),
+		suite.clusterStore.EXPECT().DeleteCluster(redundantClusterARN).Return(errors.New("Error while deleting cluster")),
+	)
+
+	err := suite.clusterLoader.LoadClusters()
+	assert.Nil(suite.T(), err, "Unexpected error when deleting a cluster fails")
+}
@@ -15,6 +15,7 @@ package loader
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
@@ -24,6 +25,7 @@ import (
 const (
 	describeInstancesPageSize = 100
 	describeTasksPageSize     = 100
+	describeServicesPageSize  = 10
 )
 
 // ECSWrapper defines methods to access wrapper methods to call ECS APIs
@@ -33,6 +35,9 @@ type ECSWrapper interface {
 	DescribeTasks(clusterARN *string, taskARNs []*string) ([]types.Task, []string, error)
 	ListAllContainerInstances(clusterARN *string) ([]*string, error)
 	DescribeContainerInstances(clusterARN *string, instanceARNs []*string) ([]types.ContainerInstance, []string, error)
+	ListAllServices(clusterARN *string) ([]*string, error)
+	DescribeServices(clusterARN *string, serviceARNs []*string) ([]types.Service, []string, error)
+	DescribeTaskDefinition(taskDefinitionARN *string) (types.TaskDefinition, error)
 }
 
 type clientWrapper struct {
@@ -219,3 +224,95 @@ func (wrapper clientWrapper) DescribeContainerInstances(clusterARN *string, inst
 	}
 	return instances, failedInstanceARNS, nil
 }
+
+// ListAllServices retrieves a list of all service ARNS in the cluster identified by 'clusterARN' by making one or more calls to ECS
+func (wrapper clientWrapper) ListAllServices(clusterARN *string) ([]*string, error) {
+	var serviceARNs []*string
+	var nextToken *string
+	nextToken = nil
+	for {
+		s, n, err := wrapper.listServices(clusterARN, nextToken)
+		if err != nil {
+			return nil, err
+		}
+		serviceARNs = append(serviceARNs, s...)
+		if aws.StringValue(n) == "" {
+			break
+		}
+		nextToken = n
+	}
+	return serviceARNs, nil
+}
+
+func (wrapper clientWrapper) listServices(clusterARN *string, nextToken *string) ([]*string, *string, error) {
+	if aws.StringValue(clusterARN) == "" {
+		return nil, nil, errors.New("Failed to list ECS services. Error: Cluster cannot be empty")
+	}
+
+	in := ecs.ListServicesInput{
+		Cluster:   clusterARN,
+		NextToken: nextToken,
+	}
+
+	resp, err := wrapper.client.ListServices(&in)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to list ECS services.")
+	}
+
+	return resp.ServiceArns, resp.NextToken, nil
+}
+
+// DescribeServices desribes all services identified by 'serviceARNs' belonging to cluster identified by 'clusterARN'
+func (wrapper clientWrapper) DescribeServices(clusterARN *string, serviceARNs []*string) ([]types.Service, []string, error) {
+	if aws.StringValue(clusterARN) == "" {
+		return nil, nil, errors.New("Failed to describe ECS services. Error: Cluster cannot be empty")
+	}
+	services := make([]types.Service, 0)
+	failedServiceARNs := make([]string, 0)
+
+	for i := 0; i < len(serviceARNs); i += describeServicesPageSize {
+		high := i + describeServicesPageSize
+		if high > len(serviceARNs) {
+			high = len(serviceARNs)
+		}
+		in := ecs.DescribeServicesInput{
+			Cluster:  clusterARN,
+			Services: serviceARNs[i:high],
+		}
+
+		resp, err := wrapper.client.DescribeServices(&in)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed to describe ECS services.")
+		}
+		for i := range resp.Services {
+			services = append(services, ToService(*resp.Services[i]))
+		}
+		for i := range resp.Failures {
+			failedServiceARNs = append(failedServiceARNs, aws.StringValue(resp.Failures[i].Arn))
+		}
+	}
+	return services, failedServiceARNs, nil
+}
+
+// DescribeTaskDefinition describes the task definition identified by 'taskDefinitionARN'.
+// A types.NotFound error is returned if ECS does not know the task definition.
+func (wrapper clientWrapper) DescribeTaskDefinition(taskDefinitionARN *string) (types.TaskDefinition, error) {
+	if aws.StringValue(taskDefinitionARN) == "" {
+		return types.TaskDefinition{}, errors.New("Failed to describe ECS task definition. Error: Task definition cannot be empty")
+	}
+	in := ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: taskDefinitionARN,
+	}
+
+	resp, err := wrapper.client.DescribeTaskDefinition(&in)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == ecs.ErrCodeClientException {
+			return types.TaskDefinition{}, types.NewNotFound(errors.Wrapf(err, "ECS task definition '%s' not found.", aws.StringValue(taskDefinitionARN)))
+		}
+		return types.TaskDefinition{}, errors.Wrapf(err, "Failed to describe ECS task definition.")
+	}
+	if resp.TaskDefinition == nil {
+		return types.TaskDefinition{}, errors.Errorf("Failed to describe ECS task definition. Error: No task definition returned for '%s'", aws.StringValue(taskDefinitionARN))
+	}
+	return ToTaskDefinition(*resp.TaskDefinition), nil
+}
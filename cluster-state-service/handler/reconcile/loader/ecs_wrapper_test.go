@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
@@ -34,6 +35,8 @@ var (
 	ecsInstanceARN1 = "arn:aws:ecs:us-east-1:123456789012:container-instance/b6b9eace-958e-4f2a-a09c-8cf43b76cf97"
 	ecsInstanceARN2 = "arn:aws:ecs:us-east-1:123456789012:container-instance/ab345dfe-6578-2eab-c671-72847ffe8122"
 	ecsNextToken    = "eyJuZXh0VG9rZW4iOiBudWxsLCAiYm90b190cnVuY2F0ZV9hbW91bnQiOiAxfQ=="
+
+	ecsTaskDefinitionARN1 = "arn:aws:ecs:us-east-1:123456789012:task-definition/testTask:1"
 )
 
 type ECSWrapperTestSuite struct {
@@ -286,6 +289,56 @@ func (suite *ECSWrapperTestSuite) DescribeTasks() {
 	assert.Equal(suite.T(), expectedFailures, failures, "Failures received on describing tasks does not match expected failures")
 }
 
+func (suite *ECSWrapperTestSuite) TestDescribeTaskDefinitionECSDescribeTaskDefinitionReturnsError() {
+	in := ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: &ecsTaskDefinitionARN1,
+	}
+	suite.mockECSClient.EXPECT().DescribeTaskDefinition(&in).Return(nil, errors.New("Error while describing task definition"))
+
+	_, err := suite.ecsWrapper.DescribeTaskDefinition(&ecsTaskDefinitionARN1)
+
+	assert.Error(suite.T(), err, "Expected an error when ecs client returns an error when describing task definition")
+	_, ok := err.(types.NotFound)
+	assert.False(suite.T(), ok, "Expected an error other than not found when ecs client returns an error")
+}
+
+func (suite *ECSWrapperTestSuite) TestDescribeTaskDefinitionECSDescribeTaskDefinitionReturnsClientException() {
+	in := ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: &ecsTaskDefinitionARN1,
+	}
+	clientException := awserr.New(ecs.ErrCodeClientException, "Unable to describe task definition.", nil)
+	suite.mockECSClient.EXPECT().DescribeTaskDefinition(&in).Return(nil, clientException)
+
+	_, err := suite.ecsWrapper.DescribeTaskDefinition(&ecsTaskDefinitionARN1)
+
+	_, ok := err.(types.NotFound)
+	assert.True(suite.T(), ok, "Expected a not found error when ecs does not know the task definition")
+}
+
+func (suite *ECSWrapperTestSuite) TestDescribeTaskDefinition() {
+	family := "testTask"
+	revision := int64(1)
+	in := ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: &ecsTaskDefinitionARN1,
+	}
+	resp := ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &ecs.TaskDefinition{
+			ContainerDefinitions: []*ecs.ContainerDefinition{},
+			Family:               &family,
+			Revision:             &revision,
+			TaskDefinitionArn:    &ecsTaskDefinitionARN1,
+		},
+	}
+	suite.mockECSClient.EXPECT().DescribeTaskDefinition(&in).Return(&resp, nil)
+
+	taskDefinition, err := suite.ecsWrapper.DescribeTaskDefinition(&ecsTaskDefinitionARN1)
+
+	assert.Nil(suite.T(), err, "Unexpected error when describing task definition")
+	assert.Equal(suite.T(), ecsTaskDefinitionARN1, *taskDefinition.Detail.TaskDefinitionARN, "Unexpected task definition ARN")
+	assert.Equal(suite.T(), family, *taskDefinition.Detail.Family, "Unexpected task definition family")
+	assert.Equal(suite.T(), revision, *taskDefinition.Detail.Revision, "Unexpected task definition revision")
+}
+
 func (suite *ECSWrapperTestSuite) TestListAllContainerInstancesECSListContainerInstancesWithoutTokenReturnsError() {
 	in := ecs.ListContainerInstancesInput{
 		Cluster: &ecsClusterARN1,
@@ -19,6 +19,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	log "github.com/cihub/seelog"
@@ -93,6 +94,9 @@ func (loader instanceLoader) LoadContainerInstances() error {
 	// Get a list of keys to delete from the local store.
 	keys := getInstanceKeysNotInECS(localState, ecsState)
 	log.Debugf("Instances to delete: %v", keys)
+	// Records in ECS that are not in the local store were missed by the event stream
+	metrics.AddDrift(metrics.ContainerInstanceEntity, metrics.DriftMissing, len(getInstanceKeysNotInECS(ecsState, localState)))
+	metrics.AddDrift(metrics.ContainerInstanceEntity, metrics.DriftDeleted, len(keys))
 	for _, key := range keys {
 		// Not handling returned error because we want as many cleanup operations to succeed as possible.
 		if err := loader.instanceStore.DeleteContainerInstance(key.clusterARN, key.instanceARN); err != nil {
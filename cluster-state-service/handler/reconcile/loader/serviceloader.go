@@ -0,0 +1,185 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+// ServiceLoader defines the interface to load services from the data store
+// and ECS and to merge the same.
+type ServiceLoader interface {
+	LoadServices() error
+}
+
+// serviceLoader implements the ServiceLoader interface.
+type serviceLoader struct {
+	serviceStore store.ServiceStore
+	ecsWrapper   ECSWrapper
+}
+
+// serviceARNLookup maps service ARNs to a struct. This is to facilitate easy lookup
+// of service ARNs.
+type serviceARNLookup map[string]struct{}
+
+// clusterARNsToServices maps cluster ARNs to the serviceARNLookup map. This is to
+// faciliate easy lookup of cluster ARNs to service ARNs.
+type clusterARNsToServices map[string]serviceARNLookup
+
+// serviceKeyToDelete is a wrapper for service and cluster ARNs to delete.
+type serviceKeyToDelete struct {
+	serviceARN string
+	clusterARN string
+}
+
+func NewServiceLoader(serviceStore store.ServiceStore, ecsClient ecsiface.ECSAPI) ServiceLoader {
+	return serviceLoader{
+		serviceStore: serviceStore,
+		ecsWrapper:   NewECSWrapper(ecsClient),
+	}
+}
+
+// LoadServices retrieves all services belonging to all clusters in ECS and loads them into data store
+func (loader serviceLoader) LoadServices() error {
+	// Construct a map of clusters to services for services in local data store.
+	localState, err := loader.loadLocalClusterStateFromStore()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading services from data store")
+	}
+	clusterARNs, err := loader.ecsWrapper.ListAllClusters()
+	if err != nil {
+		return errors.Wrapf(err, "Error listing clusters from ECS")
+	}
+	ecsState := make(clusterARNsToServices)
+	for _, cluster := range clusterARNs {
+		services, err := loader.getServicesFromECS(cluster)
+		if err != nil {
+			return errors.Wrapf(err,
+				"Error getting services from ECS for cluster '%s'", aws.StringValue(cluster))
+		}
+		clusterARN := aws.StringValue(cluster)
+		// Add the cluster ARN to the lookup map.
+		ecsState[clusterARN] = make(serviceARNLookup)
+		for _, service := range services {
+			err := loader.putService(service)
+			if err != nil {
+				return err
+			}
+			// Populate the entries for the cluster ARN in the lookup map.
+			ecsState[clusterARN][aws.StringValue(service.Detail.ServiceARN)] = struct{}{}
+		}
+	}
+	// Get a list of keys to delete from the local store.
+	keys := getServiceKeysNotInECS(localState, ecsState)
+	log.Debugf("Services to delete: %v", keys)
+	// Records in ECS that are not in the local store were missed by the event stream
+	metrics.AddDrift(metrics.ServiceEntity, metrics.DriftMissing, len(getServiceKeysNotInECS(ecsState, localState)))
+	metrics.AddDrift(metrics.ServiceEntity, metrics.DriftDeleted, len(keys))
+	for _, key := range keys {
+		// Not handling returned error because we want as many cleanup operations to succeed as possible.
+		if err := loader.serviceStore.DeleteService(key.clusterARN, key.serviceARN); err != nil {
+			log.Infof("Error deleting service '%s' belonging to cluster '%s' from data store",
+				key.serviceARN, key.clusterARN)
+		}
+	}
+	return nil
+}
+
+// loadLocalClusterStateFromStore loads service records from local store into a
+// map for easy lookup and comparison
+func (loader serviceLoader) loadLocalClusterStateFromStore() (clusterARNsToServices, error) {
+	services, err := loader.serviceStore.ListServices()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error loading services from store")
+	}
+
+	state := make(clusterARNsToServices)
+	for _, versionedService := range services {
+		clusterARN := aws.StringValue(versionedService.Service.Detail.ClusterARN)
+		if _, ok := state[clusterARN]; !ok {
+			state[clusterARN] = make(serviceARNLookup)
+		}
+		state[clusterARN][aws.StringValue(versionedService.Service.Detail.ServiceARN)] = struct{}{}
+	}
+
+	return state, nil
+}
+
+// getServicesFromECS gets a list of services from ECS for the specified cluster.
+// The ECS ListServices method does not return inactive services, which are
+// therefore deleted from the data store.
+func (loader serviceLoader) getServicesFromECS(cluster *string) ([]types.Service, error) {
+	var services []types.Service
+	serviceARNs, err := loader.ecsWrapper.ListAllServices(cluster)
+	if err != nil {
+		return services, errors.Wrapf(err,
+			"Error listing all services for cluster '%s'", aws.StringValue(cluster))
+	}
+	if len(serviceARNs) == 0 {
+		return services, nil
+	}
+	services, failedServiceARNs, err := loader.ecsWrapper.DescribeServices(cluster, serviceARNs)
+	if err != nil {
+		return services, errors.Wrapf(err,
+			"Error describing services for cluster '%s'", aws.StringValue(cluster))
+	}
+	if len(failedServiceARNs) != 0 {
+		// If we're unable to describe listed services, just print the list out.
+		// Since we treat ECS as the source of truth, it should be fine to make this assumption.
+		log.Infof("Failed to describe listed services: %s", strings.Join(failedServiceARNs[:], " "))
+	}
+	return services, nil
+}
+
+// putService puts the service record to the data store
+func (loader serviceLoader) putService(service types.Service) error {
+	s, err := json.Marshal(service)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to marshal service JSON")
+	}
+	serviceJSON := string(s)
+	err = loader.serviceStore.AddService(serviceJSON)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to add service '%s'", serviceJSON)
+	}
+	return nil
+}
+
+// getServiceKeysNotInECS gets a list of service keys to delete from the local store. This is
+// the set of keys that are in the local store, but not in ECS
+func getServiceKeysNotInECS(localState, ecsState clusterARNsToServices) []serviceKeyToDelete {
+	var serviceKeysNotInECS []serviceKeyToDelete
+	for clusterARN, serviceRecords := range localState {
+		// Services of clusters that are not in ECS are all deleted
+		ecsServiceRecords := ecsState[clusterARN]
+		for serviceARN := range serviceRecords {
+			if _, ok := ecsServiceRecords[serviceARN]; !ok {
+				serviceKeysNotInECS = append(serviceKeysNotInECS, serviceKeyToDelete{
+					serviceARN: serviceARN,
+					clusterARN: clusterARN,
+				})
+			}
+		}
+	}
+	return serviceKeysNotInECS
+}
@@ -0,0 +1,185 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	serviceClusterARN1           = "arn:aws:ecs:us-east-1:123456789012:cluster/cluster1"
+	serviceClusterARN2           = "arn:aws:ecs:us-east-1:123456789012:cluster/cluster2"
+	serviceARN1                  = "arn:aws:ecs:us-east-1:123456789012:service/web"
+	serviceName1                 = "web"
+	redundantClusterARNOfService = "arn:aws:ecs:us-east-1:123456789012:cluster/red-un-da-nt"
+	redundantServiceARN          = "arn:aws:ecs:us-east-1:123456789012:service/red-un-da-nt"
+)
+
+type ServiceLoaderTestSuite struct {
+	suite.Suite
+	serviceStore              *mocks.MockServiceStore
+	ecsWrapper                *mocks.MockECSWrapper
+	serviceLoader             ServiceLoader
+	clusterARNList            []*string
+	service                   types.Service
+	versionedService          storetypes.VersionedService
+	redundantVersionedService storetypes.VersionedService
+	serviceJSON               string
+}
+
+func (suite *ServiceLoaderTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.serviceStore = mocks.NewMockServiceStore(mockCtrl)
+
+	suite.ecsWrapper = mocks.NewMockECSWrapper(mockCtrl)
+
+	suite.serviceLoader = serviceLoader{
+		serviceStore: suite.serviceStore,
+		ecsWrapper:   suite.ecsWrapper,
+	}
+
+	suite.clusterARNList = []*string{&serviceClusterARN1, &serviceClusterARN2}
+
+	suite.service = types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN:  &serviceClusterARN1,
+			ServiceARN:  &serviceARN1,
+			ServiceName: &serviceName1,
+			Status:      "ACTIVE",
+		},
+	}
+	suite.versionedService = storetypes.VersionedService{
+		Service: suite.service,
+		Version: "123",
+	}
+
+	s, err := json.Marshal(suite.service)
+	assert.Nil(suite.T(), err, "Cannot setup testSuite: Unexpected error when marshaling service")
+	suite.serviceJSON = string(s)
+
+	suite.redundantVersionedService = storetypes.VersionedService{
+		Service: types.Service{
+			Detail: &types.ServiceDetail{
+				ClusterARN: &redundantClusterARNOfService,
+				ServiceARN: &redundantServiceARN,
+			},
+		},
+		Version: "123",
+	}
+}
+
+func TestServiceLoaderTestSuite(t *testing.T) {
+	suite.Run(t, new(ServiceLoaderTestSuite))
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesStoreListReturnsError() {
+	suite.serviceStore.EXPECT().ListServices().Return(nil, errors.New("Error while listing services"))
+	suite.ecsWrapper.EXPECT().ListAllClusters().Times(0)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when listing services")
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesListAllServicesReturnsError() {
+	gomock.InOrder(
+		suite.serviceStore.EXPECT().ListServices().Return(make([]storetypes.VersionedService, 0), nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[0]).Return(nil, errors.New("Error while listing all services")),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[1]).Times(0),
+		suite.ecsWrapper.EXPECT().DescribeServices(gomock.Any(), gomock.Any()).Times(0),
+	)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Error(suite.T(), err, "Expected an error when ecs returns an error when listing all services in a cluster")
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesDescribeServicesReturnsError() {
+	serviceARNList := []*string{&serviceARN1}
+
+	gomock.InOrder(
+		suite.serviceStore.EXPECT().ListServices().Return(make([]storetypes.VersionedService, 0), nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[0]).Return(serviceARNList, nil),
+		suite.ecsWrapper.EXPECT().DescribeServices(suite.clusterARNList[0], serviceARNList).Return(nil, nil, errors.New("Error while describing services")),
+	)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Error(suite.T(), err, "Expected an error when ecs returns an error when describing services")
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesStoreReturnsError() {
+	serviceARNList := []*string{&serviceARN1}
+	serviceList := []types.Service{suite.service}
+
+	gomock.InOrder(
+		suite.serviceStore.EXPECT().ListServices().Return(make([]storetypes.VersionedService, 0), nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[0]).Return(serviceARNList, nil),
+		suite.ecsWrapper.EXPECT().DescribeServices(suite.clusterARNList[0], serviceARNList).Return(serviceList, nil, nil),
+		suite.serviceStore.EXPECT().AddService(suite.serviceJSON).Return(errors.New("Error while adding service to store")),
+	)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when adding service")
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesLocalStoreSameAsECS() {
+	serviceARNList := []*string{&serviceARN1}
+	serviceList := []types.Service{suite.service}
+	serviceListInStore := []storetypes.VersionedService{suite.versionedService}
+
+	suite.serviceStore.EXPECT().DeleteService(gomock.Any(), gomock.Any()).Times(0)
+	gomock.InOrder(
+		suite.serviceStore.EXPECT().ListServices().Return(serviceListInStore, nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[0]).Return(serviceARNList, nil),
+		suite.ecsWrapper.EXPECT().DescribeServices(suite.clusterARNList[0], serviceARNList).Return(serviceList, nil, nil),
+		suite.serviceStore.EXPECT().AddService(suite.serviceJSON).Return(nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[1]).Return([]*string{}, nil),
+		suite.ecsWrapper.EXPECT().DescribeServices(suite.clusterARNList[1], gomock.Any()).Times(0),
+	)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Nil(suite.T(), err, "Unexpected error when loading services")
+}
+
+func (suite *ServiceLoaderTestSuite) TestLoadServicesRedundantEntriesInLocalStore() {
+	serviceARNList := []*string{&serviceARN1}
+	serviceList := []types.Service{suite.service}
+	serviceListInStore := []storetypes.VersionedService{suite.versionedService, suite.redundantVersionedService}
+
+	gomock.InOrder(
+		suite.serviceStore.EXPECT().ListServices().Return(serviceListInStore, nil),
+		suite.ecsWrapper.EXPECT().ListAllClusters().Return(suite.clusterARNList, nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[0]).Return(serviceARNList, nil),
+		suite.ecsWrapper.EXPECT().DescribeServices(suite.clusterARNList[0], serviceARNList).Return(serviceList, nil, nil),
+		suite.serviceStore.EXPECT().AddService(suite.serviceJSON).Return(nil),
+		suite.ecsWrapper.EXPECT().ListAllServices(suite.clusterARNList[1]).Return([]*string{}, nil),
+		// Expect delete service for the service of the cluster that is not in ECS
+		suite.serviceStore.EXPECT().DeleteService(redundantClusterARNOfService, redundantServiceARN).Return(nil),
+	)
+
+	err := suite.serviceLoader.LoadServices()
+	assert.Nil(suite.T(), err, "Unexpected error when loading services")
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	log "github.com/cihub/seelog"
+	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+// TaskDefinitionLoader defines the interface to load the task definitions
+// referenced by tasks in the data store from ECS. Task definitions are
+// immutable, so each of them is described at most once while it is cached.
+type TaskDefinitionLoader interface {
+	LoadTaskDefinitions() error
+	LoadTaskDefinition(taskDefinitionARN string) error
+}
+
+// taskDefinitionLoader implements the TaskDefinitionLoader interface.
+type taskDefinitionLoader struct {
+	taskStore           store.TaskStore
+	taskDefinitionStore store.TaskDefinitionStore
+	ecsWrapper          ECSWrapper
+}
+
+func NewTaskDefinitionLoader(taskStore store.TaskStore, taskDefinitionStore store.TaskDefinitionStore, ecsClient ecsiface.ECSAPI) TaskDefinitionLoader {
+	return taskDefinitionLoader{
+		taskStore:           taskStore,
+		taskDefinitionStore: taskDefinitionStore,
+		ecsWrapper:          NewECSWrapper(ecsClient),
+	}
+}
+
+// LoadTaskDefinitions caches the task definitions referenced by tasks in the
+// data store that are not cached yet and evicts the ones no task references
+func (loader taskDefinitionLoader) LoadTaskDefinitions() error {
+	tasks, err := loader.taskStore.ListTasks()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading tasks from data store")
+	}
+	referenced := make(map[string]struct{})
+	for _, versionedTask := range tasks {
+		if versionedTask.Task.Detail == nil {
+			continue
+		}
+		if arn := aws.StringValue(versionedTask.Task.Detail.TaskDefinitionARN); arn != "" {
+			referenced[arn] = struct{}{}
+		}
+	}
+
+	taskDefinitions, err := loader.taskDefinitionStore.ListTaskDefinitions()
+	if err != nil {
+		return errors.Wrapf(err, "Error loading task definitions from data store")
+	}
+	cached := make(map[string]struct{})
+	for _, versionedTaskDefinition := range taskDefinitions {
+		cached[aws.StringValue(versionedTaskDefinition.TaskDefinition.Detail.TaskDefinitionARN)] = struct{}{}
+	}
+
+	for arn := range referenced {
+		if _, ok := cached[arn]; ok {
+			continue
+		}
+		err := loader.LoadTaskDefinition(arn)
+		if err != nil {
+			if _, ok := errors.Cause(err).(types.NotFound); ok {
+				log.Infof("Task definition '%s' referenced by tasks not found in ECS", arn)
+				continue
+			}
+			return err
+		}
+	}
+
+	for arn := range cached {
+		if _, ok := referenced[arn]; ok {
+			continue
+		}
+		// Not handling returned error because we want as many cleanup operations to succeed as possible.
+		if err := loader.taskDefinitionStore.DeleteTaskDefinition(arn); err != nil {
+			log.Infof("Error deleting task definition '%s' from data store", arn)
+		}
+	}
+	return nil
+}
+
+// LoadTaskDefinition describes the task definition with ARN 'taskDefinitionARN'
+// and caches it in the data store
+func (loader taskDefinitionLoader) LoadTaskDefinition(taskDefinitionARN string) error {
+	taskDefinition, err := loader.ecsWrapper.DescribeTaskDefinition(aws.String(taskDefinitionARN))
+	if err != nil {
+		return errors.Wrapf(err, "Error describing task definition '%s'", taskDefinitionARN)
+	}
+	t, err := json.Marshal(taskDefinition)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to marshal task definition JSON")
+	}
+	taskDefinitionJSON := string(t)
+	err = loader.taskDefinitionStore.AddTaskDefinition(taskDefinitionJSON)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to add task definition '%s'", taskDefinitionJSON)
+	}
+	return nil
+}
+
+// GetOrLoadTaskDefinition gets the task definition with ARN 'taskDefinitionARN'
+// from the cache, loading it from ECS if it is not cached yet. A nil task
+// definition is returned if ECS does not know about it either.
+func GetOrLoadTaskDefinition(taskDefinitionStore store.TaskDefinitionStore, taskDefinitionLoader TaskDefinitionLoader, taskDefinitionARN string) (*storetypes.VersionedTaskDefinition, error) {
+	taskDefinition, err := taskDefinitionStore.GetTaskDefinition(taskDefinitionARN)
+	if err != nil || taskDefinition != nil {
+		return taskDefinition, err
+	}
+
+	err = taskDefinitionLoader.LoadTaskDefinition(taskDefinitionARN)
+	if err != nil {
+		if _, ok := errors.Cause(err).(types.NotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return taskDefinitionStore.GetTaskDefinition(taskDefinitionARN)
+}
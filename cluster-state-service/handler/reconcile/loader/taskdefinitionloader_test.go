@@ -0,0 +1,209 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	cachedTaskDefinitionFamily   = "web"
+	cachedTaskDefinitionRevision = int64(1)
+	cachedTaskDefinitionARN      = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"
+	redundantTaskDefinitionARN   = "arn:aws:ecs:us-east-1:123456789012:task-definition/red-un-da-nt:1"
+	taskOfTaskDefinitionARN      = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+)
+
+type TaskDefinitionLoaderTestSuite struct {
+	suite.Suite
+	taskStore                        *mocks.MockTaskStore
+	taskDefinitionStore              *mocks.MockTaskDefinitionStore
+	ecsWrapper                       *mocks.MockECSWrapper
+	taskDefinitionLoader             TaskDefinitionLoader
+	taskDefinition                   types.TaskDefinition
+	versionedTask                    storetypes.VersionedTask
+	versionedTaskDefinition          storetypes.VersionedTaskDefinition
+	redundantVersionedTaskDefinition storetypes.VersionedTaskDefinition
+	taskDefinitionJSON               string
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+
+	suite.taskStore = mocks.NewMockTaskStore(mockCtrl)
+	suite.taskDefinitionStore = mocks.NewMockTaskDefinitionStore(mockCtrl)
+	suite.ecsWrapper = mocks.NewMockECSWrapper(mockCtrl)
+
+	suite.taskDefinitionLoader = taskDefinitionLoader{
+		taskStore:           suite.taskStore,
+		taskDefinitionStore: suite.taskDefinitionStore,
+		ecsWrapper:          suite.ecsWrapper,
+	}
+
+	suite.versionedTask = storetypes.VersionedTask{
+		Task: types.Task{
+			Detail: &types.TaskDetail{
+				TaskARN:           &taskOfTaskDefinitionARN,
+				TaskDefinitionARN: &cachedTaskDefinitionARN,
+			},
+		},
+		Version: "123",
+	}
+
+	suite.taskDefinition = types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			ContainerDefinitions: []*types.ContainerDefinition{},
+			Family:               &cachedTaskDefinitionFamily,
+			Revision:             &cachedTaskDefinitionRevision,
+			TaskDefinitionARN:    &cachedTaskDefinitionARN,
+		},
+	}
+	suite.versionedTaskDefinition = storetypes.VersionedTaskDefinition{
+		TaskDefinition: suite.taskDefinition,
+		Version:        "123",
+	}
+
+	t, err := json.Marshal(suite.taskDefinition)
+	assert.Nil(suite.T(), err, "Cannot setup testSuite: Unexpected error when marshaling task definition")
+	suite.taskDefinitionJSON = string(t)
+
+	suite.redundantVersionedTaskDefinition = storetypes.VersionedTaskDefinition{
+		TaskDefinition: types.TaskDefinition{
+			Detail: &types.TaskDefinitionDetail{
+				TaskDefinitionARN: &redundantTaskDefinitionARN,
+			},
+		},
+		Version: "123",
+	}
+}
+
+func TestTaskDefinitionLoaderTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskDefinitionLoaderTestSuite))
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsTaskStoreListReturnsError() {
+	suite.taskStore.EXPECT().ListTasks().Return(nil, errors.New("Error while listing tasks"))
+	suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when listing tasks")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsStoreListReturnsError() {
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(nil, errors.New("Error while listing task definitions")),
+	)
+	suite.ecsWrapper.EXPECT().DescribeTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when listing task definitions")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsDescribeTaskDefinitionReturnsError() {
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(make([]storetypes.VersionedTaskDefinition, 0), nil),
+		suite.ecsWrapper.EXPECT().DescribeTaskDefinition(&cachedTaskDefinitionARN).Return(types.TaskDefinition{}, errors.New("Error while describing task definition")),
+	)
+	suite.taskDefinitionStore.EXPECT().AddTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Error(suite.T(), err, "Expected an error when ecs returns an error when describing task definition")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsTaskDefinitionNotFoundInECS() {
+	notFound := types.NewNotFound(errors.New("Task definition not found"))
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(make([]storetypes.VersionedTaskDefinition, 0), nil),
+		suite.ecsWrapper.EXPECT().DescribeTaskDefinition(&cachedTaskDefinitionARN).Return(types.TaskDefinition{}, notFound),
+	)
+	suite.taskDefinitionStore.EXPECT().AddTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Nil(suite.T(), err, "Unexpected error when a task definition is not found in ECS")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsStoreReturnsError() {
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(make([]storetypes.VersionedTaskDefinition, 0), nil),
+		suite.ecsWrapper.EXPECT().DescribeTaskDefinition(&cachedTaskDefinitionARN).Return(suite.taskDefinition, nil),
+		suite.taskDefinitionStore.EXPECT().AddTaskDefinition(suite.taskDefinitionJSON).Return(errors.New("Error while adding task definition to store")),
+	)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Error(suite.T(), err, "Expected an error when store returns an error when adding task definition")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsLoadsUncachedTaskDefinitions() {
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(make([]storetypes.VersionedTaskDefinition, 0), nil),
+		suite.ecsWrapper.EXPECT().DescribeTaskDefinition(&cachedTaskDefinitionARN).Return(suite.taskDefinition, nil),
+		suite.taskDefinitionStore.EXPECT().AddTaskDefinition(suite.taskDefinitionJSON).Return(nil),
+	)
+	suite.taskDefinitionStore.EXPECT().DeleteTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Nil(suite.T(), err, "Unexpected error when loading task definitions")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsSkipsCachedTaskDefinitions() {
+	tasks := []storetypes.VersionedTask{suite.versionedTask, suite.versionedTask}
+	taskDefinitions := []storetypes.VersionedTaskDefinition{suite.versionedTaskDefinition}
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return(tasks, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(taskDefinitions, nil),
+	)
+	suite.ecsWrapper.EXPECT().DescribeTaskDefinition(gomock.Any()).Times(0)
+	suite.taskDefinitionStore.EXPECT().DeleteTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Nil(suite.T(), err, "Unexpected error when loading task definitions")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinitionsRedundantEntriesInLocalStore() {
+	taskDefinitions := []storetypes.VersionedTaskDefinition{suite.versionedTaskDefinition, suite.redundantVersionedTaskDefinition}
+	gomock.InOrder(
+		suite.taskStore.EXPECT().ListTasks().Return([]storetypes.VersionedTask{suite.versionedTask}, nil),
+		suite.taskDefinitionStore.EXPECT().ListTaskDefinitions().Return(taskDefinitions, nil),
+		// Expect delete of the task definition that no task references
+		suite.taskDefinitionStore.EXPECT().DeleteTaskDefinition(redundantTaskDefinitionARN).Return(nil),
+	)
+	suite.ecsWrapper.EXPECT().DescribeTaskDefinition(gomock.Any()).Times(0)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinitions()
+	assert.Nil(suite.T(), err, "Unexpected error when loading task definitions")
+}
+
+func (suite *TaskDefinitionLoaderTestSuite) TestLoadTaskDefinition() {
+	gomock.InOrder(
+		suite.ecsWrapper.EXPECT().DescribeTaskDefinition(&cachedTaskDefinitionARN).Return(suite.taskDefinition, nil),
+		suite.taskDefinitionStore.EXPECT().AddTaskDefinition(suite.taskDefinitionJSON).Return(nil),
+	)
+
+	err := suite.taskDefinitionLoader.LoadTaskDefinition(cachedTaskDefinitionARN)
+	assert.Nil(suite.T(), err, "Unexpected error when loading task definition")
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	log "github.com/cihub/seelog"
@@ -98,6 +99,9 @@ func (loader taskLoader) LoadTasks() error {
 	// Get a list of keys to delete from the local store.
 	keys := getTaskKeysNotInECS(localState, ecsState)
 	log.Debugf("Tasks to delete: %v", keys)
+	// Records in ECS that are not in the local store were missed by the event stream
+	metrics.AddDrift(metrics.TaskEntity, metrics.DriftMissing, len(getTaskKeysNotInECS(ecsState, localState)))
+	metrics.AddDrift(metrics.TaskEntity, metrics.DriftDeleted, len(keys))
 	for _, key := range keys {
 		// Not handling returned error because we want as many cleanup operations to succeed as possible.
 		if err := loader.taskStore.DeleteTask(key.clusterARN, key.taskARN); err != nil {
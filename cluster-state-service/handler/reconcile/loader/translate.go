@@ -25,16 +25,21 @@ const (
 	timeLayout = "2006-01-02T15:04:05Z"
 )
 
-// ToTask tranlates an ECS task to the internal task type
+// ToTask tranlates an ECS task to the internal task type. The ECS API version
+// the reconciler is built against does not return launchType, cpu, memory,
+// platformVersion, healthStatus, stopCode, connectivity, the image pull times
+// or tags, so those are only populated from task state change events.
 func ToTask(ecsTask ecs.Task) types.Task {
 	createdAt := ecsTask.CreatedAt.Format(timeLayout)
 	updatedAt := currentTime()
 	taskDetail := types.TaskDetail{
+		Attachments:          toAttachments(ecsTask.Attachments),
 		ClusterARN:           ecsTask.ClusterArn,
 		ContainerInstanceARN: ecsTask.ContainerInstanceArn,
 		Containers:           toContainers(ecsTask.Containers),
 		CreatedAt:            &createdAt,
 		DesiredStatus:        ecsTask.DesiredStatus,
+		Group:                aws.StringValue(ecsTask.Group),
 		LastStatus:           ecsTask.LastStatus,
 		Overrides:            toOverrides(ecsTask.Overrides),
 		StartedBy:            aws.StringValue(ecsTask.StartedBy),
@@ -57,6 +62,35 @@ func ToTask(ecsTask ecs.Task) types.Task {
 	}
 }
 
+func toAttachments(ecsAttachments []*ecs.Attachment) []*types.Attachment {
+	if len(ecsAttachments) == 0 {
+		return nil
+	}
+	attachments := make([]*types.Attachment, len(ecsAttachments))
+	for i := range ecsAttachments {
+		ecsAttachment := ecsAttachments[i]
+		attachments[i] = &types.Attachment{
+			Details: toKeyValuePairs(ecsAttachment.Details),
+			ID:      ecsAttachment.Id,
+			Status:  ecsAttachment.Status,
+			Type:    ecsAttachment.Type,
+		}
+	}
+	return attachments
+}
+
+func toKeyValuePairs(ecsKeyValuePairs []*ecs.KeyValuePair) []*types.KeyValuePair {
+	keyValuePairs := make([]*types.KeyValuePair, len(ecsKeyValuePairs))
+	for i := range ecsKeyValuePairs {
+		ecsKVP := ecsKeyValuePairs[i]
+		keyValuePairs[i] = &types.KeyValuePair{
+			Name:  ecsKVP.Name,
+			Value: ecsKVP.Value,
+		}
+	}
+	return keyValuePairs
+}
+
 func toContainers(ecsContainers []*ecs.Container) []*types.Container {
 	containers := make([]*types.Container, len(ecsContainers))
 	for i := range ecsContainers {
@@ -190,6 +224,134 @@ func toVersionInfo(ecsVersionInfo *ecs.VersionInfo) *types.VersionInfo {
 	}
 }
 
+// ToService tranlates an ECS service to the internal service type. The ECS
+// API version the reconciler is built against does not return the rollout
+// state of deployments, so it is only populated from deployment state change
+// events.
+func ToService(ecsService ecs.Service) types.Service {
+	updatedAt := currentTime()
+	serviceDetail := types.ServiceDetail{
+		ClusterARN:     ecsService.ClusterArn,
+		Deployments:    toDeployments(ecsService.Deployments),
+		DesiredCount:   ecsService.DesiredCount,
+		Events:         toServiceEvents(ecsService.Events),
+		PendingCount:   ecsService.PendingCount,
+		RunningCount:   ecsService.RunningCount,
+		ServiceARN:     ecsService.ServiceArn,
+		ServiceName:    ecsService.ServiceName,
+		Status:         aws.StringValue(ecsService.Status),
+		TaskDefinition: aws.StringValue(ecsService.TaskDefinition),
+		UpdatedAt:      &updatedAt,
+	}
+	if ecsService.CreatedAt != nil {
+		serviceDetail.CreatedAt = ecsService.CreatedAt.Format(timeLayout)
+	}
+	return types.Service{
+		Detail: &serviceDetail,
+	}
+}
+
+func toDeployments(ecsDeployments []*ecs.Deployment) []*types.Deployment {
+	if len(ecsDeployments) == 0 {
+		return nil
+	}
+	deployments := make([]*types.Deployment, len(ecsDeployments))
+	for i := range ecsDeployments {
+		ecsDeployment := ecsDeployments[i]
+		deployments[i] = &types.Deployment{
+			DesiredCount:   ecsDeployment.DesiredCount,
+			ID:             ecsDeployment.Id,
+			PendingCount:   ecsDeployment.PendingCount,
+			RunningCount:   ecsDeployment.RunningCount,
+			Status:         aws.StringValue(ecsDeployment.Status),
+			TaskDefinition: aws.StringValue(ecsDeployment.TaskDefinition),
+		}
+		if ecsDeployment.CreatedAt != nil {
+			deployments[i].CreatedAt = ecsDeployment.CreatedAt.Format(timeLayout)
+		}
+		if ecsDeployment.UpdatedAt != nil {
+			deployments[i].UpdatedAt = ecsDeployment.UpdatedAt.Format(timeLayout)
+		}
+	}
+	return deployments
+}
+
+// toServiceEvents translates the most recent ECS service events, which ECS
+// returns ordered from newest to oldest
+func toServiceEvents(ecsEvents []*ecs.ServiceEvent) []*types.ServiceEvent {
+	if len(ecsEvents) == 0 {
+		return nil
+	}
+	if len(ecsEvents) > types.MaxServiceEvents {
+		ecsEvents = ecsEvents[:types.MaxServiceEvents]
+	}
+	events := make([]*types.ServiceEvent, len(ecsEvents))
+	for i := range ecsEvents {
+		ecsEvent := ecsEvents[i]
+		events[i] = &types.ServiceEvent{
+			ID:      ecsEvent.Id,
+			Message: aws.StringValue(ecsEvent.Message),
+		}
+		if ecsEvent.CreatedAt != nil {
+			createdAt := ecsEvent.CreatedAt.Format(timeLayout)
+			events[i].CreatedAt = &createdAt
+		}
+	}
+	return events
+}
+
+func ToTaskDefinition(ecsTaskDefinition ecs.TaskDefinition) types.TaskDefinition {
+	updatedAt := currentTime()
+	return types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			ContainerDefinitions: toContainerDefinitions(ecsTaskDefinition.ContainerDefinitions),
+			Family:               ecsTaskDefinition.Family,
+			NetworkMode:          aws.StringValue(ecsTaskDefinition.NetworkMode),
+			Revision:             ecsTaskDefinition.Revision,
+			Status:               aws.StringValue(ecsTaskDefinition.Status),
+			TaskDefinitionARN:    ecsTaskDefinition.TaskDefinitionArn,
+			TaskRoleARN:          aws.StringValue(ecsTaskDefinition.TaskRoleArn),
+			UpdatedAt:            &updatedAt,
+		},
+	}
+}
+
+func toContainerDefinitions(ecsContainerDefinitions []*ecs.ContainerDefinition) []*types.ContainerDefinition {
+	containerDefinitions := make([]*types.ContainerDefinition, len(ecsContainerDefinitions))
+	for i := range ecsContainerDefinitions {
+		ecsContainerDefinition := ecsContainerDefinitions[i]
+		containerDefinitions[i] = &types.ContainerDefinition{
+			CPU:               aws.Int64Value(ecsContainerDefinition.Cpu),
+			DockerLabels:      aws.StringValueMap(ecsContainerDefinition.DockerLabels),
+			Essential:         aws.BoolValue(ecsContainerDefinition.Essential),
+			Image:             ecsContainerDefinition.Image,
+			Memory:            aws.Int64Value(ecsContainerDefinition.Memory),
+			MemoryReservation: aws.Int64Value(ecsContainerDefinition.MemoryReservation),
+			Name:              ecsContainerDefinition.Name,
+			PortMappings:      toPortMappings(ecsContainerDefinition.PortMappings),
+		}
+		if len(containerDefinitions[i].DockerLabels) == 0 {
+			containerDefinitions[i].DockerLabels = nil
+		}
+	}
+	return containerDefinitions
+}
+
+func toPortMappings(ecsPortMappings []*ecs.PortMapping) []*types.PortMapping {
+	if len(ecsPortMappings) == 0 {
+		return nil
+	}
+	portMappings := make([]*types.PortMapping, len(ecsPortMappings))
+	for i := range ecsPortMappings {
+		portMappings[i] = &types.PortMapping{
+			ContainerPort: ecsPortMappings[i].ContainerPort,
+			HostPort:      aws.Int64Value(ecsPortMappings[i].HostPort),
+			Protocol:      aws.StringValue(ecsPortMappings[i].Protocol),
+		}
+	}
+	return portMappings
+}
+
 func currentTime() string {
 	return time.Now().Format(timeLayout)
 }
@@ -17,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	"github.com/stretchr/testify/assert"
@@ -216,3 +217,194 @@ func (suite *TranslateTestSuite) TestToTask() {
 	expectedTask.Detail.UpdatedAt = &updatedAt
 	assert.Equal(suite.T(), expectedTask, task, "Translated task does not match expected task")
 }
+
+func (suite *TranslateTestSuite) TestToTaskWithGroupAndAttachments() {
+	group := "service:web"
+	attachmentID := "a1b2c3d4-5678-90ab-cdef-11111EXAMPLE"
+	attachmentType := "ElasticNetworkInterface"
+	attachmentStatus := "ATTACHED"
+	detailName := "privateIPv4Address"
+	detailValue := "10.0.1.23"
+
+	ecsTask := suite.ecsTask
+	ecsTask.Group = &group
+	ecsTask.Attachments = []*ecs.Attachment{
+		&ecs.Attachment{
+			Details: []*ecs.KeyValuePair{
+				&ecs.KeyValuePair{Name: &detailName, Value: &detailValue},
+			},
+			Id:     &attachmentID,
+			Status: &attachmentStatus,
+			Type:   &attachmentType,
+		},
+	}
+
+	task := ToTask(ecsTask)
+
+	assert.Equal(suite.T(), group, task.Detail.Group, "Translated task group does not match expected group")
+	expectedAttachments := []*types.Attachment{
+		&types.Attachment{
+			Details: []*types.KeyValuePair{
+				&types.KeyValuePair{Name: &detailName, Value: &detailValue},
+			},
+			ID:     &attachmentID,
+			Status: &attachmentStatus,
+			Type:   &attachmentType,
+		},
+	}
+	assert.Equal(suite.T(), expectedAttachments, task.Detail.Attachments, "Translated task attachments do not match expected attachments")
+}
+
+func (suite *TranslateTestSuite) TestToService() {
+	serviceARN := "arn:aws:ecs:us-east-1:123456789012:service/web"
+	serviceName := "web"
+	status := "ACTIVE"
+	taskDefinitionARN := "arn:aws:ecs:us-east-1:123456789012:task-definition/web:2"
+	deploymentID := "ecs-svc/9223370564341623665"
+	deploymentStatus := "PRIMARY"
+	eventID := "e1f2a3b4-5678-90ab-cdef-11111EXAMPLE"
+	eventMessage := "(service web) has reached a steady state."
+	desiredCount := int64(2)
+	runningCount := int64(1)
+	pendingCount := int64(1)
+	createdAt := "2017-11-21T00:00:00Z"
+	ecsCreatedAt, err := time.Parse(timeLayout, createdAt)
+	assert.Nil(suite.T(), err, "Unexpected error when parsing time")
+
+	ecsService := ecs.Service{
+		ClusterArn: &suite.clusterARN,
+		CreatedAt:  &ecsCreatedAt,
+		Deployments: []*ecs.Deployment{
+			&ecs.Deployment{
+				CreatedAt:      &ecsCreatedAt,
+				DesiredCount:   &desiredCount,
+				Id:             &deploymentID,
+				Status:         &deploymentStatus,
+				TaskDefinition: &taskDefinitionARN,
+				UpdatedAt:      &ecsCreatedAt,
+			},
+		},
+		DesiredCount: &desiredCount,
+		Events: []*ecs.ServiceEvent{
+			&ecs.ServiceEvent{
+				CreatedAt: &ecsCreatedAt,
+				Id:        &eventID,
+				Message:   &eventMessage,
+			},
+		},
+		PendingCount:   &pendingCount,
+		RunningCount:   &runningCount,
+		ServiceArn:     &serviceARN,
+		ServiceName:    &serviceName,
+		Status:         &status,
+		TaskDefinition: &taskDefinitionARN,
+	}
+
+	service := ToService(ecsService)
+
+	// TODO: Mock out Time.Now() and avoid this hack
+	updatedAt := *service.Detail.UpdatedAt
+	expectedService := types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN: &suite.clusterARN,
+			CreatedAt:  createdAt,
+			Deployments: []*types.Deployment{
+				&types.Deployment{
+					CreatedAt:      createdAt,
+					DesiredCount:   &desiredCount,
+					ID:             &deploymentID,
+					Status:         deploymentStatus,
+					TaskDefinition: taskDefinitionARN,
+					UpdatedAt:      createdAt,
+				},
+			},
+			DesiredCount: &desiredCount,
+			Events: []*types.ServiceEvent{
+				&types.ServiceEvent{
+					CreatedAt: &createdAt,
+					ID:        &eventID,
+					Message:   eventMessage,
+				},
+			},
+			PendingCount:   &pendingCount,
+			RunningCount:   &runningCount,
+			ServiceARN:     &serviceARN,
+			ServiceName:    &serviceName,
+			Status:         status,
+			TaskDefinition: taskDefinitionARN,
+			UpdatedAt:      &updatedAt,
+		},
+	}
+	assert.Equal(suite.T(), expectedService, service, "Translated service does not match expected service")
+}
+
+func (suite *TranslateTestSuite) TestToTaskDefinition() {
+	taskDefinitionARN := "arn:aws:ecs:us-east-1:123456789012:task-definition/web:3"
+	family := "web"
+	revision := int64(3)
+	status := "ACTIVE"
+	networkMode := "awsvpc"
+	containerName := "nginx"
+	image := "nginx:1.13"
+	containerPort := int64(80)
+	protocol := "tcp"
+	team := "frontend"
+
+	ecsTaskDefinition := ecs.TaskDefinition{
+		ContainerDefinitions: []*ecs.ContainerDefinition{
+			&ecs.ContainerDefinition{
+				Cpu:               aws.Int64(256),
+				DockerLabels:      map[string]*string{"team": &team},
+				Essential:         aws.Bool(true),
+				Image:             &image,
+				MemoryReservation: aws.Int64(128),
+				Name:              &containerName,
+				PortMappings: []*ecs.PortMapping{
+					&ecs.PortMapping{
+						ContainerPort: &containerPort,
+						HostPort:      &containerPort,
+						Protocol:      &protocol,
+					},
+				},
+			},
+		},
+		Family:            &family,
+		NetworkMode:       &networkMode,
+		Revision:          &revision,
+		Status:            &status,
+		TaskDefinitionArn: &taskDefinitionARN,
+	}
+
+	taskDefinition := ToTaskDefinition(ecsTaskDefinition)
+
+	// TODO: Mock out Time.Now() and avoid this hack
+	updatedAt := *taskDefinition.Detail.UpdatedAt
+	expectedTaskDefinition := types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			ContainerDefinitions: []*types.ContainerDefinition{
+				&types.ContainerDefinition{
+					CPU:               256,
+					DockerLabels:      map[string]string{"team": team},
+					Essential:         true,
+					Image:             &image,
+					MemoryReservation: 128,
+					Name:              &containerName,
+					PortMappings: []*types.PortMapping{
+						&types.PortMapping{
+							ContainerPort: &containerPort,
+							HostPort:      containerPort,
+							Protocol:      protocol,
+						},
+					},
+				},
+			},
+			Family:            &family,
+			NetworkMode:       networkMode,
+			Revision:          &revision,
+			Status:            status,
+			TaskDefinitionARN: &taskDefinitionARN,
+			UpdatedAt:         &updatedAt,
+		},
+	}
+	assert.Equal(suite.T(), expectedTaskDefinition, taskDefinition, "Translated task definition does not match expected task definition")
+}
@@ -20,23 +20,27 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
 	log "github.com/cihub/seelog"
 	"github.com/pkg/errors"
 )
 
-// ReconcileDuration specifies the interval between each reconcile loop
-const ReconcileDuration = 20 * time.Minute
-
 type Reconciler struct {
-	taskLoader     loader.TaskLoader
-	instanceLoader loader.ContainerInstanceLoader
-	ticker         *time.Ticker
-	tickerDuration time.Duration
-	ctx            context.Context
-	inProgress     bool
-	inProgressLock sync.RWMutex
+	clusterLoader        loader.ClusterLoader
+	taskLoader           loader.TaskLoader
+	taskDefinitionLoader loader.TaskDefinitionLoader
+	instanceLoader       loader.ContainerInstanceLoader
+	serviceLoader        loader.ServiceLoader
+	ticker               *time.Ticker
+	tickerDuration       time.Duration
+	ctx                  context.Context
+	inProgress           bool
+	inProgressLock       sync.RWMutex
+	finished             chan struct{}
+	consecutiveFailures  int
+	failuresLock         sync.RWMutex
 }
 
 func NewReconciler(ctx context.Context, stores store.Stores, ecsClient *ecs.ECS, tickerDuration time.Duration) (*Reconciler, error) {
@@ -48,11 +52,14 @@ func NewReconciler(ctx context.Context, stores store.Stores, ecsClient *ecs.ECS,
 		return reconciler, fmt.Errorf("Invalid duration specified for running the reconciler: %s", tickerDuration.String())
 	}
 	return &Reconciler{
-		taskLoader:     loader.NewTaskLoader(stores.TaskStore, ecsClient),
-		instanceLoader: loader.NewContainerInstanceLoader(stores.ContainerInstanceStore, ecsClient),
-		tickerDuration: tickerDuration,
-		ctx:            ctx,
-		inProgress:     false,
+		clusterLoader:        loader.NewClusterLoader(stores.ClusterStore, ecsClient),
+		taskLoader:           loader.NewTaskLoader(stores.TaskStore, ecsClient),
+		taskDefinitionLoader: loader.NewTaskDefinitionLoader(stores.TaskStore, stores.TaskDefinitionStore, ecsClient),
+		instanceLoader:       loader.NewContainerInstanceLoader(stores.ContainerInstanceStore, ecsClient),
+		serviceLoader:        loader.NewServiceLoader(stores.ServiceStore, ecsClient),
+		tickerDuration:       tickerDuration,
+		ctx:                  ctx,
+		inProgress:           false,
 	}, nil
 }
 
@@ -61,12 +68,18 @@ func (reconciler *Reconciler) Run() {
 	for {
 		select {
 		case <-reconciler.ticker.C:
-			if reconciler.isInProgress() {
+			// the loop is started before its goroutine, so that Wait cannot
+			// miss it once the context is done
+			started, err := reconciler.start()
+			if err != nil {
+				continue
+			}
+			if !started {
 				log.Info("Reconcile loop in progress, skipping")
 				continue
 			}
 			go func() {
-				err := reconciler.RunOnce()
+				err := reconciler.run()
 				if err != nil {
 					log.Warnf("Error reconciling: %v", err)
 				}
@@ -78,31 +91,129 @@ func (reconciler *Reconciler) Run() {
 	}
 }
 
-// RunOnce loads all existing ECS tasks and instances into the datastore
+// RunOnce loads all existing ECS clusters, tasks, the task definitions they
+// reference, instances and services into the datastore
 func (reconciler *Reconciler) RunOnce() error {
-	reconciler.setInProgress(true)
-	defer reconciler.setInProgress(false)
-
-	log.Infof("Reconciler loading tasks and instances")
-	// TODO: Pass in context everywhere so that cancelling the context cancels any outstanding
-	// requests as well
-	err := reconciler.taskLoader.LoadTasks()
+	started, err := reconciler.start()
 	if err != nil {
-		return errors.Wrapf(err, "Failed to reconcile. Could not load tasks.")
+		return err
+	}
+	if !started {
+		return errors.New("A reconcile loop is already in progress")
 	}
+	return reconciler.run()
+}
+
+// run runs a reconcile loop that was started with start
+func (reconciler *Reconciler) run() error {
+	defer reconciler.finish()
+
+	start := time.Now()
+	err := reconciler.load()
+	metrics.ObserveReconcile(start, err)
+	reconciler.recordOutcome(err)
+	return err
+}
+
+// Wait waits for an in-progress reconcile loop to finish. It returns false if
+// 'ctx' is done first, in which case the loop is abandoned.
+func (reconciler *Reconciler) Wait(ctx context.Context) bool {
+	reconciler.inProgressLock.RLock()
+	finished := reconciler.finished
+	reconciler.inProgressLock.RUnlock()
+
+	if finished == nil {
+		return true
+	}
+	select {
+	case <-finished:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ConsecutiveFailures returns how many reconcile loops in a row have failed
+func (reconciler *Reconciler) ConsecutiveFailures() int {
+	reconciler.failuresLock.RLock()
+	defer reconciler.failuresLock.RUnlock()
+
+	return reconciler.consecutiveFailures
+}
+
+func (reconciler *Reconciler) recordOutcome(err error) {
+	reconciler.failuresLock.Lock()
+	defer reconciler.failuresLock.Unlock()
 
-	err = reconciler.instanceLoader.LoadContainerInstances()
 	if err != nil {
-		return errors.Wrapf(err, "Failed to reconcile. Could not load container instances.")
+		reconciler.consecutiveFailures++
+	} else {
+		reconciler.consecutiveFailures = 0
+	}
+}
+
+func (reconciler *Reconciler) load() error {
+
+	log.Infof("Reconciler loading clusters, tasks, task definitions, instances and services")
+	// The loaders do not take a context, so a loop stops once the context is
+	// done only between loaders
+	steps := []struct {
+		load func() error
+		what string
+	}{
+		{reconciler.clusterLoader.LoadClusters, "clusters"},
+		{reconciler.taskLoader.LoadTasks, "tasks"},
+		{reconciler.taskDefinitionLoader.LoadTaskDefinitions, "task definitions"},
+		{reconciler.instanceLoader.LoadContainerInstances, "container instances"},
+		{reconciler.serviceLoader.LoadServices, "services"},
+	}
+	for _, step := range steps {
+		if err := reconciler.ctxErr(); err != nil {
+			return errors.Wrapf(err, "Failed to reconcile. Stopped before loading %s.", step.what)
+		}
+		if err := step.load(); err != nil {
+			return errors.Wrapf(err, "Failed to reconcile. Could not load %s.", step.what)
+		}
 	}
 	return nil
 }
 
-func (reconciler *Reconciler) setInProgress(val bool) {
+// start marks a reconcile loop as in progress. It returns false if one already
+// is, and an error if the context of the reconciler is done. Checking the
+// context under the lock that Wait reads the loop with guarantees that Wait,
+// once the context is done, either sees the loop or no loop is started.
+func (reconciler *Reconciler) start() (bool, error) {
+	reconciler.inProgressLock.Lock()
+	defer reconciler.inProgressLock.Unlock()
+
+	if err := reconciler.ctxErr(); err != nil {
+		return false, errors.Wrapf(err, "Reconciler is stopped")
+	}
+	if reconciler.inProgress {
+		return false, nil
+	}
+	reconciler.inProgress = true
+	reconciler.finished = make(chan struct{})
+	return true, nil
+}
+
+// finish marks the reconcile loop in progress as finished
+func (reconciler *Reconciler) finish() {
 	reconciler.inProgressLock.Lock()
 	defer reconciler.inProgressLock.Unlock()
 
-	reconciler.inProgress = val
+	reconciler.inProgress = false
+	if reconciler.finished != nil {
+		close(reconciler.finished)
+		reconciler.finished = nil
+	}
+}
+
+func (reconciler *Reconciler) ctxErr() error {
+	if reconciler.ctx == nil {
+		return nil
+	}
+	return reconciler.ctx.Err()
 }
 
 func (reconciler *Reconciler) isInProgress() bool {
@@ -27,70 +27,169 @@ import (
 
 type ReconcilerTestSuite struct {
 	suite.Suite
-	taskLoader     *mocks.MockTaskLoader
-	instanceLoader *mocks.MockContainerInstanceLoader
+	clusterLoader        *mocks.MockClusterLoader
+	taskLoader           *mocks.MockTaskLoader
+	taskDefinitionLoader *mocks.MockTaskDefinitionLoader
+	instanceLoader       *mocks.MockContainerInstanceLoader
+	serviceLoader        *mocks.MockServiceLoader
 }
 
 func (suite *ReconcilerTestSuite) SetupTest() {
 	mockCtrl := gomock.NewController(suite.T())
+	suite.clusterLoader = mocks.NewMockClusterLoader(mockCtrl)
 	suite.taskLoader = mocks.NewMockTaskLoader(mockCtrl)
+	suite.taskDefinitionLoader = mocks.NewMockTaskDefinitionLoader(mockCtrl)
 	suite.instanceLoader = mocks.NewMockContainerInstanceLoader(mockCtrl)
+	suite.serviceLoader = mocks.NewMockServiceLoader(mockCtrl)
 }
 
 func TestReconcilerTestSuite(t *testing.T) {
 	suite.Run(t, new(ReconcilerTestSuite))
 }
 
+func (suite *ReconcilerTestSuite) TestRunLoadClustersReturnsError() {
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+	}
+
+	suite.clusterLoader.EXPECT().LoadClusters().Return(errors.New("Error while loading clusters"))
+	err := reconciler.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when load clusters returns an error")
+}
+
 func (suite *ReconcilerTestSuite) TestRunLoadTasksReturnsError() {
 	reconciler := Reconciler{
-		taskLoader:     suite.taskLoader,
-		instanceLoader: suite.instanceLoader,
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
 	}
 
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
 	suite.taskLoader.EXPECT().LoadTasks().Return(errors.New("Error while loading tasks"))
 	err := reconciler.RunOnce()
 	assert.Error(suite.T(), err, "Expected an error when load tasks returns an error")
 }
 
+func (suite *ReconcilerTestSuite) TestRunLoadTaskDefinitionsReturnsError() {
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+	}
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
+	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(errors.New("Error while loading task definitions"))
+
+	err := reconciler.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when load task definitions returns an error")
+}
+
 func (suite *ReconcilerTestSuite) TestRunLoadInstancesReturnsError() {
 	reconciler := Reconciler{
-		taskLoader:     suite.taskLoader,
-		instanceLoader: suite.instanceLoader,
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
 	}
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
 	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil)
 	suite.instanceLoader.EXPECT().LoadContainerInstances().Return(errors.New("Error while loading instance"))
 
 	err := reconciler.RunOnce()
 	assert.Error(suite.T(), err, "Expected an error when load instances returns an error")
 }
 
+func (suite *ReconcilerTestSuite) TestRunLoadServicesReturnsError() {
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+	}
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
+	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil)
+	suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil)
+	suite.serviceLoader.EXPECT().LoadServices().Return(errors.New("Error while loading services"))
+
+	err := reconciler.RunOnce()
+	assert.Error(suite.T(), err, "Expected an error when load services returns an error")
+}
+
 func (suite *ReconcilerTestSuite) TestRun() {
 	reconciler := Reconciler{
-		taskLoader:     suite.taskLoader,
-		instanceLoader: suite.instanceLoader,
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
 	}
 	verifyInProgress := func() {
 		assert.True(suite.T(), reconciler.isInProgress(), "Reconcile operation should be in progress")
 	}
+	suite.clusterLoader.EXPECT().LoadClusters().Do(verifyInProgress).Return(nil)
 	suite.taskLoader.EXPECT().LoadTasks().Do(verifyInProgress).Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Do(verifyInProgress).Return(nil)
 	suite.instanceLoader.EXPECT().LoadContainerInstances().Do(verifyInProgress).Return(nil)
+	suite.serviceLoader.EXPECT().LoadServices().Do(verifyInProgress).Return(nil)
 
 	err := reconciler.RunOnce()
 	assert.Nil(suite.T(), err, "Unexpected error when performing bootstrapping")
 	assert.False(suite.T(), reconciler.isInProgress(), "Reconcile operation should not be in progress")
 }
 
+func (suite *ReconcilerTestSuite) TestConsecutiveFailures() {
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+	}
+	gomock.InOrder(
+		suite.clusterLoader.EXPECT().LoadClusters().Return(errors.New("Error while loading clusters")),
+		suite.clusterLoader.EXPECT().LoadClusters().Return(errors.New("Error while loading clusters")),
+		suite.clusterLoader.EXPECT().LoadClusters().Return(nil),
+	)
+	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil)
+	suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil)
+	suite.serviceLoader.EXPECT().LoadServices().Return(nil)
+
+	reconciler.RunOnce()
+	reconciler.RunOnce()
+	assert.Equal(suite.T(), 2, reconciler.ConsecutiveFailures(), "Expected failed reconcile loops to be counted")
+
+	err := reconciler.RunOnce()
+	assert.Nil(suite.T(), err, "Unexpected error when reconciling")
+	assert.Equal(suite.T(), 0, reconciler.ConsecutiveFailures(), "Expected a successful reconcile loop to reset the failures")
+}
+
 func (suite *ReconcilerTestSuite) TestOverlappingRunInvocationsAreSkipped() {
 	ctx, cancel := context.WithCancel(context.TODO())
 	tickerDuration := 10 * time.Millisecond
 	reconciler := Reconciler{
-		taskLoader:     suite.taskLoader,
-		instanceLoader: suite.instanceLoader,
-		ctx:            ctx,
-		tickerDuration: tickerDuration,
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+		ctx:                  ctx,
+		tickerDuration:       tickerDuration,
 	}
 
-	// verifyInProgress will be invoked by the LoadContainerInstances, in reconciler.Run()
+	// verifyInProgress will be invoked by the LoadServices, in reconciler.Run()
 	// This will cause reconciler.RunOnce() to be blocked because of the time.Sleep() call in
 	// this method, which should result in reconciler.ticker's ticks being missed.
 	// If there was a bug and the ticks were processed and resulted in reconciler.RunOnce() to
@@ -101,8 +200,11 @@ func (suite *ReconcilerTestSuite) TestOverlappingRunInvocationsAreSkipped() {
 		time.Sleep(3 * tickerDuration)
 		cancel()
 	}
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
 	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
-	suite.instanceLoader.EXPECT().LoadContainerInstances().Do(verifyInProgress).Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil)
+	suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil)
+	suite.serviceLoader.EXPECT().LoadServices().Do(verifyInProgress).Return(nil)
 	reconciler.Run()
 	select {
 	case <-ctx.Done():
@@ -113,10 +215,13 @@ func (suite *ReconcilerTestSuite) TestMultipleRunInvocations() {
 	ctx, cancel := context.WithCancel(context.TODO())
 	tickerDuration := 10 * time.Millisecond
 	reconciler := Reconciler{
-		taskLoader:     suite.taskLoader,
-		instanceLoader: suite.instanceLoader,
-		ctx:            ctx,
-		tickerDuration: tickerDuration,
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+		ctx:                  ctx,
+		tickerDuration:       tickerDuration,
 	}
 
 	verifyInProgress := func() {
@@ -124,14 +229,89 @@ func (suite *ReconcilerTestSuite) TestMultipleRunInvocations() {
 		cancel()
 	}
 	gomock.InOrder(
+		suite.clusterLoader.EXPECT().LoadClusters().Return(nil),
 		suite.taskLoader.EXPECT().LoadTasks().Return(nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil),
 		suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil),
+		suite.serviceLoader.EXPECT().LoadServices().Return(nil),
+		suite.clusterLoader.EXPECT().LoadClusters().Return(nil),
 		suite.taskLoader.EXPECT().LoadTasks().Return(nil),
+		suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil),
+		suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil),
 		// Stop the Run() method by cancelling the context during its second invocation
-		suite.instanceLoader.EXPECT().LoadContainerInstances().Do(verifyInProgress).Return(nil),
+		suite.serviceLoader.EXPECT().LoadServices().Do(verifyInProgress).Return(nil),
 	)
 	reconciler.Run()
 	select {
 	case <-ctx.Done():
 	}
 }
+
+func (suite *ReconcilerTestSuite) TestWait() {
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+	}
+	assert.True(suite.T(), reconciler.Wait(context.TODO()), "Expected no reconcile loop to wait for")
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
+	suite.taskLoader.EXPECT().LoadTasks().Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Return(nil)
+	suite.instanceLoader.EXPECT().LoadContainerInstances().Return(nil)
+	suite.serviceLoader.EXPECT().LoadServices().Do(func() {
+		close(loading)
+		<-release
+	}).Return(nil)
+	go reconciler.RunOnce()
+	<-loading
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(suite.T(), reconciler.Wait(ctx), "Expected the in-progress reconcile loop to be abandoned")
+
+	close(release)
+	assert.True(suite.T(), reconciler.Wait(context.TODO()), "Expected the in-progress reconcile loop to finish")
+	assert.False(suite.T(), reconciler.isInProgress(), "Reconcile operation should not be in progress")
+}
+
+func (suite *ReconcilerTestSuite) TestRunOnceAfterContextIsDone() {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+		ctx:                  ctx,
+	}
+
+	suite.clusterLoader.EXPECT().LoadClusters().Times(0)
+	err := reconciler.RunOnce()
+	assert.Error(suite.T(), err, "Expected no reconcile loop to start once the reconciler is stopped")
+	assert.True(suite.T(), reconciler.Wait(context.TODO()), "Expected no reconcile loop to wait for")
+}
+
+func (suite *ReconcilerTestSuite) TestRunOnceStopsBetweenLoaders() {
+	ctx, cancel := context.WithCancel(context.TODO())
+	reconciler := Reconciler{
+		clusterLoader:        suite.clusterLoader,
+		taskLoader:           suite.taskLoader,
+		taskDefinitionLoader: suite.taskDefinitionLoader,
+		instanceLoader:       suite.instanceLoader,
+		serviceLoader:        suite.serviceLoader,
+		ctx:                  ctx,
+	}
+
+	suite.clusterLoader.EXPECT().LoadClusters().Return(nil)
+	suite.taskLoader.EXPECT().LoadTasks().Do(cancel).Return(nil)
+	suite.taskDefinitionLoader.EXPECT().LoadTaskDefinitions().Times(0)
+	err := reconciler.RunOnce()
+	assert.Error(suite.T(), err, "Expected the reconcile loop to stop once the reconciler is stopped")
+	assert.False(suite.T(), reconciler.isInProgress(), "Reconcile operation should not be in progress")
+}
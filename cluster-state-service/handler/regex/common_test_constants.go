@@ -17,20 +17,45 @@ const (
 	validClusterName = "clust_er-1"
 	validClusterARN  = "arn:aws:ecs:us-east-1:123456789123:cluster/" + validClusterName
 
+	validChinaClusterARN       = "arn:aws-cn:ecs:cn-north-1:123456789123:cluster/" + validClusterName
+	validGovCloudClusterARN    = "arn:aws-us-gov:ecs:us-gov-west-1:123456789123:cluster/" + validClusterName
+	invalidPartitionClusterARN = "arn:aws-xx:ecs:us-east-1:123456789123:cluster/" + validClusterName
+
+	validRegion                        = "us-east-1"
+	validAccount                       = "123456789123"
+	validRegionQualifiedClusterName    = validRegion + ":" + validClusterName
+	invalidRegionQualifiedClusterName  = "us_east:" + validClusterName
 	invalidClusterName                 = "cluster1/cluster1"
 	invalidClusterARNWithNoName        = "arn:aws:ecs:us-east-1:123456789123:cluster/"
 	invalidClusterARNWithInvalidName   = "arn:aws:ecs:us-east-1:123456789123:cluster/" + invalidClusterName
 	invalidClusterARNWithInvalidPrefix = "arn/cluster"
 
-	validTaskARN                    = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
-	invalidTaskARNWithNoID          = "arn:aws:ecs:us-east-1:123456789123:task/"
-	invalidTaskARNWithInvalidID     = "arn:aws:ecs:us-east-1:123456789123:task/271022c0-f894-4aa2-b063-25bae55088d5/-"
-	invalidTaskARNWithInvalidPrefix = "arn/task"
+	validTaskARN                         = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	invalidTaskARNWithNoID               = "arn:aws:ecs:us-east-1:123456789123:task/"
+	invalidTaskARNWithInvalidID          = "arn:aws:ecs:us-east-1:123456789123:task/271022c0-f894-4aa2-b063-25bae55088d5/-"
+	invalidTaskARNWithInvalidPrefix      = "arn/task"
+	validLongTaskARN                     = "arn:aws:ecs:us-east-1:123456789012:task/" + validClusterName + "/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	validChinaTaskARN                    = "arn:aws-cn:ecs:cn-north-1:123456789012:task/" + validClusterName + "/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	validGovCloudTaskARN                 = "arn:aws-us-gov:ecs:us-gov-west-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	invalidTaskARNWithInvalidPartition   = "arn:aws-xx:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	invalidTaskARNWithInvalidClusterName = "arn:aws:ecs:us-east-1:123456789012:task/-cluster/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
 
 	validInstanceARN                    = "arn:aws:ecs:us-east-1:123456789123:container-instance/4b6d45ea-a4b4-4269-9d04-3af6ddfdc597"
 	invalidInstanceARNWithNoID          = "arn:aws:ecs:us-east-1:123456789123:container-instance/"
 	invalidInstanceARNWithInvalidID     = "arn:aws:ecs:us-east-1:123456789123:container-instance/4b6d45ea-a4b4-4269-9d04-3af6ddfdc597/-"
 	invalidInstanceARNWithInvalidPrefix = "arn/container-instance"
+	validLongInstanceARN                = "arn:aws:ecs:us-east-1:123456789123:container-instance/" + validClusterName + "/4b6d45ea-a4b4-4269-9d04-3af6ddfdc597"
+
+	validServiceName                   = "web-service"
+	validServiceARN                    = "arn:aws:ecs:us-east-1:123456789123:service/" + validServiceName
+	validLongServiceARN                = "arn:aws:ecs:us-east-1:123456789123:service/" + validClusterName + "/" + validServiceName
+	invalidServiceARNWithNoName        = "arn:aws:ecs:us-east-1:123456789123:service/"
+	invalidServiceARNWithInvalidPrefix = "arn/service"
+
+	validTaskDefinitionFamily                = "web-app"
+	validTaskDefinitionARN                   = "arn:aws:ecs:us-east-1:123456789123:task-definition/" + validTaskDefinitionFamily + ":12"
+	invalidTaskDefinitionARNWithNoRevision   = "arn:aws:ecs:us-east-1:123456789123:task-definition/" + validTaskDefinitionFamily
+	invalidTaskDefinitionFamilyWithSeparator = "web:app"
 
 	validEntityVersion                      = "123"
 	invalidEntityVersionFloatingPointNumber = "123.123"
@@ -18,8 +18,42 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
+const (
+	arnSeparator                 = ":"
+	regionQualifiedNameSeparator = ":"
+	arnRegionIndex               = 3
+	arnAccountIndex              = 4
+	minARNParts                  = 6
+	resourceSeparator            = "/"
+	longARNResourceParts         = 3
+	revisionSeparator            = ":"
+)
+
+// ClusterIdentifier identifies a cluster by its name and, when they are known,
+// the region and account that the cluster belongs to
+type ClusterIdentifier struct {
+	Account string
+	Region  string
+	Name    string
+}
+
+// Matches returns true if the cluster with ARN 'clusterARN' is identified by the identifier
+func (identifier ClusterIdentifier) Matches(clusterARN string) bool {
+	name, err := GetClusterNameFromARN(clusterARN)
+	if err != nil || name != identifier.Name {
+		return false
+	}
+	account, region, err := GetAccountAndRegionFromARN(clusterARN)
+	if err != nil {
+		return false
+	}
+	return (identifier.Account == "" || identifier.Account == account) &&
+		(identifier.Region == "" || identifier.Region == region)
+}
+
 // GetClusterNameFromARN extracts the cluster name from a cluster ARN
 func GetClusterNameFromARN(clusterARN string) (string, error) {
 	if len(clusterARN) == 0 {
@@ -41,6 +75,129 @@ func GetClusterNameFromARN(clusterARN string) (string, error) {
 	return clusterName, nil
 }
 
+// GetClusterFromARN returns the identifier of the cluster with ARN 'clusterARN'
+func GetClusterFromARN(clusterARN string) (ClusterIdentifier, error) {
+	name, err := GetClusterNameFromARN(clusterARN)
+	if err != nil {
+		return ClusterIdentifier{}, err
+	}
+	account, region, err := GetAccountAndRegionFromARN(clusterARN)
+	if err != nil {
+		return ClusterIdentifier{}, err
+	}
+	return ClusterIdentifier{Account: account, Region: region, Name: name}, nil
+}
+
+// ParseCluster returns the identifier of a cluster specified as a cluster ARN,
+// a region qualified cluster name (region:name) or a cluster name
+func ParseCluster(cluster string) (ClusterIdentifier, error) {
+	if IsClusterARN(cluster) {
+		return GetClusterFromARN(cluster)
+	}
+
+	if IsRegionQualifiedClusterName(cluster) {
+		parts := strings.SplitN(cluster, regionQualifiedNameSeparator, 2)
+		return ClusterIdentifier{Region: parts[0], Name: parts[1]}, nil
+	}
+
+	if IsClusterName(cluster) {
+		return ClusterIdentifier{Name: cluster}, nil
+	}
+
+	return ClusterIdentifier{}, fmt.Errorf("Invalid cluster: %s", cluster)
+}
+
+// GetAccountAndRegionFromARN returns the account and the region of the resource with ARN 'arn'
+func GetAccountAndRegionFromARN(arn string) (string, string, error) {
+	parts := strings.Split(arn, arnSeparator)
+	if len(parts) < minARNParts || parts[0] != "arn" {
+		return "", "", fmt.Errorf("Invalid ARN: %s", arn)
+	}
+
+	account := parts[arnAccountIndex]
+	region := parts[arnRegionIndex]
+	if account == "" || region == "" {
+		return "", "", fmt.Errorf("ARN does not contain an account and a region: %s", arn)
+	}
+	return account, region, nil
+}
+
+// GetClusterNameFromTaskARN extracts the cluster name from a task ARN in the
+// long format (task/<cluster>/<id>). Task ARNs in the short format do not
+// contain the cluster name and result in an error.
+func GetClusterNameFromTaskARN(taskARN string) (string, error) {
+	if !IsTaskARN(taskARN) {
+		return "", fmt.Errorf("Invalid task ARN: %s", taskARN)
+	}
+	return getClusterNameFromResourceARN(taskARN)
+}
+
+// GetClusterNameFromInstanceARN extracts the cluster name from a container
+// instance ARN in the long format (container-instance/<cluster>/<id>)
+func GetClusterNameFromInstanceARN(instanceARN string) (string, error) {
+	if !IsInstanceARN(instanceARN) {
+		return "", fmt.Errorf("Invalid instance ARN: %s", instanceARN)
+	}
+	return getClusterNameFromResourceARN(instanceARN)
+}
+
+// GetClusterNameFromServiceARN extracts the cluster name from a service ARN in
+// the long format (service/<cluster>/<name>)
+func GetClusterNameFromServiceARN(serviceARN string) (string, error) {
+	if !IsServiceARN(serviceARN) {
+		return "", fmt.Errorf("Invalid service ARN: %s", serviceARN)
+	}
+	return getClusterNameFromResourceARN(serviceARN)
+}
+
+// GetServiceNameFromARN extracts the service name from a service ARN in either
+// the short (service/<name>) or the long (service/<cluster>/<name>) format
+func GetServiceNameFromARN(serviceARN string) (string, error) {
+	if !IsServiceARN(serviceARN) {
+		return "", fmt.Errorf("Invalid service ARN: %s", serviceARN)
+	}
+	return serviceARN[strings.LastIndex(serviceARN, resourceSeparator)+1:], nil
+}
+
+// GetFamilyAndRevisionFromTaskDefinitionARN extracts the family and the
+// revision from a task definition ARN (task-definition/<family>:<revision>)
+func GetFamilyAndRevisionFromTaskDefinitionARN(taskDefinitionARN string) (string, int64, error) {
+	if !IsTaskDefinitionARN(taskDefinitionARN) {
+		return "", 0, fmt.Errorf("Invalid task definition ARN: %s", taskDefinitionARN)
+	}
+	resource := taskDefinitionARN[strings.LastIndex(taskDefinitionARN, resourceSeparator)+1:]
+	separator := strings.LastIndex(resource, revisionSeparator)
+	revision, err := strconv.ParseInt(resource[separator+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid revision in task definition ARN: %s", taskDefinitionARN)
+	}
+	return resource[:separator], revision, nil
+}
+
+// IsInCluster returns false if 'arn' is a task, container instance or service ARN in the
+// long format that names a cluster other than the one specified as 'cluster'.
+// ARNs in the short format do not name a cluster and are always considered to
+// be in the cluster.
+func IsInCluster(arn string, cluster string) bool {
+	clusterName, err := getClusterNameFromResourceARN(arn)
+	if err != nil {
+		return true
+	}
+	identifier, err := ParseCluster(cluster)
+	if err != nil {
+		return false
+	}
+	return identifier.Name == clusterName
+}
+
+func getClusterNameFromResourceARN(arn string) (string, error) {
+	parts := strings.Split(arn, resourceSeparator)
+	if len(parts) != longARNResourceParts {
+		return "", fmt.Errorf("ARN is not in the long format: %s", arn)
+	}
+	return parts[1], nil
+}
+
 // GetEntityVersion extracts the entity version as an int.
 func GetEntityVersion(entityVersion string) (int64, error) {
 	if !IsEntityVersion(entityVersion) {
@@ -53,4 +210,4 @@ func GetEntityVersion(entityVersion string) (int64, error) {
 	}
 
 	return value, nil
-}
\ No newline at end of file
+}
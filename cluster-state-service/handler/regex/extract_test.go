@@ -46,6 +46,147 @@ func TestGetClusterNameFromARN(t *testing.T) {
 	assert.Equal(t, validClusterName, c, "Invalid cluster name retrieved from ARN")
 }
 
+func TestGetClusterFromARNInvalidARN(t *testing.T) {
+	_, err := GetClusterFromARN(validClusterName)
+	assert.NotNil(t, err, "Expected an error when retrieving cluster from a cluster name")
+}
+
+func TestGetClusterFromARN(t *testing.T) {
+	c, err := GetClusterFromARN(validClusterARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster from ARN")
+	assert.Equal(t, ClusterIdentifier{Account: validAccount, Region: validRegion, Name: validClusterName}, c,
+		"Invalid cluster retrieved from ARN")
+}
+
+func TestGetClusterFromARNChinaPartition(t *testing.T) {
+	c, err := GetClusterFromARN(validChinaClusterARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster from ARN in the aws-cn partition")
+	assert.Equal(t, ClusterIdentifier{Account: validAccount, Region: "cn-north-1", Name: validClusterName}, c,
+		"Invalid cluster retrieved from ARN")
+}
+
+func TestGetClusterNameFromTaskARNInvalidARN(t *testing.T) {
+	_, err := GetClusterNameFromTaskARN(invalidTaskARNWithNoID)
+	assert.NotNil(t, err, "Expected an error when retrieving cluster name from an invalid task ARN")
+}
+
+func TestGetClusterNameFromTaskARNShortFormat(t *testing.T) {
+	_, err := GetClusterNameFromTaskARN(validTaskARN)
+	assert.NotNil(t, err, "Expected an error when retrieving cluster name from a task ARN in the short format")
+}
+
+func TestGetClusterNameFromTaskARN(t *testing.T) {
+	c, err := GetClusterNameFromTaskARN(validLongTaskARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster name from task ARN")
+	assert.Equal(t, validClusterName, c, "Invalid cluster name retrieved from task ARN")
+
+	c, err = GetClusterNameFromTaskARN(validChinaTaskARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster name from task ARN in the aws-cn partition")
+	assert.Equal(t, validClusterName, c, "Invalid cluster name retrieved from task ARN")
+}
+
+func TestGetClusterNameFromInstanceARN(t *testing.T) {
+	c, err := GetClusterNameFromInstanceARN(validLongInstanceARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster name from instance ARN")
+	assert.Equal(t, validClusterName, c, "Invalid cluster name retrieved from instance ARN")
+
+	_, err = GetClusterNameFromInstanceARN(validInstanceARN)
+	assert.NotNil(t, err, "Expected an error when retrieving cluster name from an instance ARN in the short format")
+}
+
+func TestGetClusterNameFromServiceARN(t *testing.T) {
+	c, err := GetClusterNameFromServiceARN(validLongServiceARN)
+	assert.Nil(t, err, "Unexpected error when retrieving cluster name from service ARN")
+	assert.Equal(t, validClusterName, c, "Invalid cluster name retrieved from service ARN")
+
+	_, err = GetClusterNameFromServiceARN(validServiceARN)
+	assert.NotNil(t, err, "Expected an error when retrieving cluster name from a service ARN in the short format")
+}
+
+func TestGetServiceNameFromARNInvalidARN(t *testing.T) {
+	_, err := GetServiceNameFromARN(invalidServiceARNWithNoName)
+	assert.NotNil(t, err, "Expected an error when retrieving service name from an invalid service ARN")
+}
+
+func TestGetServiceNameFromARN(t *testing.T) {
+	name, err := GetServiceNameFromARN(validServiceARN)
+	assert.Nil(t, err, "Unexpected error when retrieving service name from service ARN")
+	assert.Equal(t, validServiceName, name, "Invalid service name retrieved from service ARN")
+
+	name, err = GetServiceNameFromARN(validLongServiceARN)
+	assert.Nil(t, err, "Unexpected error when retrieving service name from service ARN in the long format")
+	assert.Equal(t, validServiceName, name, "Invalid service name retrieved from service ARN")
+}
+
+func TestGetFamilyAndRevisionFromTaskDefinitionARN(t *testing.T) {
+	family, revision, err := GetFamilyAndRevisionFromTaskDefinitionARN(validTaskDefinitionARN)
+	assert.Nil(t, err, "Unexpected error when retrieving family and revision from task definition ARN")
+	assert.Equal(t, validTaskDefinitionFamily, family, "Invalid family retrieved from task definition ARN")
+	assert.Equal(t, int64(12), revision, "Invalid revision retrieved from task definition ARN")
+
+	_, _, err = GetFamilyAndRevisionFromTaskDefinitionARN(invalidTaskDefinitionARNWithNoRevision)
+	assert.NotNil(t, err, "Expected an error when retrieving family and revision from an invalid task definition ARN")
+}
+
+func TestParseClusterInvalidCluster(t *testing.T) {
+	_, err := ParseCluster(invalidClusterName)
+	assert.NotNil(t, err, "Expected an error when parsing an invalid cluster")
+}
+
+func TestParseClusterName(t *testing.T) {
+	c, err := ParseCluster(validClusterName)
+	assert.Nil(t, err, "Unexpected error when parsing cluster name")
+	assert.Equal(t, ClusterIdentifier{Name: validClusterName}, c, "Invalid cluster parsed from cluster name")
+}
+
+func TestParseClusterRegionQualifiedName(t *testing.T) {
+	c, err := ParseCluster(validRegionQualifiedClusterName)
+	assert.Nil(t, err, "Unexpected error when parsing region qualified cluster name")
+	assert.Equal(t, ClusterIdentifier{Region: validRegion, Name: validClusterName}, c,
+		"Invalid cluster parsed from region qualified cluster name")
+}
+
+func TestParseClusterARN(t *testing.T) {
+	c, err := ParseCluster(validClusterARN)
+	assert.Nil(t, err, "Unexpected error when parsing cluster ARN")
+	assert.Equal(t, ClusterIdentifier{Account: validAccount, Region: validRegion, Name: validClusterName}, c,
+		"Invalid cluster parsed from cluster ARN")
+}
+
+func TestGetAccountAndRegionFromARNInvalidARN(t *testing.T) {
+	_, _, err := GetAccountAndRegionFromARN(invalidTaskARNWithInvalidPrefix)
+	assert.NotNil(t, err, "Expected an error when retrieving account and region from an invalid ARN")
+}
+
+func TestGetAccountAndRegionFromARN(t *testing.T) {
+	account, region, err := GetAccountAndRegionFromARN(validInstanceARN)
+	assert.Nil(t, err, "Unexpected error when retrieving account and region from ARN")
+	assert.Equal(t, validAccount, account, "Invalid account retrieved from ARN")
+	assert.Equal(t, validRegion, region, "Invalid region retrieved from ARN")
+}
+
+func TestClusterIdentifierMatches(t *testing.T) {
+	assert.True(t, ClusterIdentifier{Name: validClusterName}.Matches(validClusterARN),
+		"Expected cluster name to match cluster ARN")
+	assert.True(t, ClusterIdentifier{Region: validRegion, Name: validClusterName}.Matches(validClusterARN),
+		"Expected region qualified cluster name to match cluster ARN")
+	assert.False(t, ClusterIdentifier{Region: "us-west-2", Name: validClusterName}.Matches(validClusterARN),
+		"Expected cluster in another region not to match cluster ARN")
+	assert.False(t, ClusterIdentifier{Account: "000000000000", Region: validRegion, Name: validClusterName}.Matches(validClusterARN),
+		"Expected cluster in another account not to match cluster ARN")
+	assert.False(t, ClusterIdentifier{Name: "other"}.Matches(validClusterARN),
+		"Expected cluster with another name not to match cluster ARN")
+}
+
+func TestIsInCluster(t *testing.T) {
+	assert.True(t, IsInCluster(validTaskARN, "other"), "Task ARN in the short format should be in any cluster")
+	assert.True(t, IsInCluster(validLongTaskARN, validClusterName), "Task ARN should be in the cluster it names")
+	assert.True(t, IsInCluster(validLongTaskARN, validClusterARN), "Task ARN should be in the cluster it names")
+	assert.True(t, IsInCluster(validLongTaskARN, validRegionQualifiedClusterName), "Task ARN should be in the cluster it names")
+	assert.False(t, IsInCluster(validLongTaskARN, "other"), "Task ARN should not be in a cluster other than the one it names")
+	assert.False(t, IsInCluster(validLongTaskARN, invalidClusterName), "Task ARN should not be in an invalid cluster")
+}
+
 func TestGetEntityVersionNonNumber(t *testing.T) {
 	_, err := GetEntityVersion(invalidEntityVersionNonNumber)
 	assert.NotNil(t, err, "Expected an error when retrieving a non-number entity version")
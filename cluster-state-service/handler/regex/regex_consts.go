@@ -14,10 +14,46 @@
 package regex
 
 const (
-	clusterNameRegexWithoutStart = "[a-zA-Z][a-zA-Z0-9_-]{1,254}$"
-	ClusterNameRegex             = "^" + clusterNameRegexWithoutStart
-	ClusterARNRegex              = "^(arn:aws:ecs:)([\\-\\w]+):[0-9]{12}:(cluster)/" + clusterNameRegexWithoutStart
-	ClusterNameAsARNSuffixRegex  = "/" + clusterNameRegexWithoutStart
-	TaskARNRegex                 = "^(arn:aws:ecs):([\\-\\w]+):[0-9]{12}:(task)\\/[\\-\\w]+$"
-	InstanceARNRegex             = "^(arn:aws:ecs:)([\\-\\w]+):[0-9]{12}:(container\\-instance)\\/[\\-\\w]+$"
+	// partitionRegexWithoutAnchors matches the standard, China and GovCloud AWS partitions
+	partitionRegexWithoutAnchors                  = "aws(?:-cn|-us-gov)?"
+	ecsARNPrefixRegexWithoutAnchors               = "arn:" + partitionRegexWithoutAnchors + ":ecs:[\\-\\w]+:[0-9]{12}:"
+	clusterNameRegexWithoutAnchors                = "[a-zA-Z][a-zA-Z0-9_-]{1,254}"
+	clusterNameRegexWithoutStart                  = clusterNameRegexWithoutAnchors + "$"
+	clusterARNRegexWithoutAnchors                 = ecsARNPrefixRegexWithoutAnchors + "cluster/" + clusterNameRegexWithoutAnchors
+	regionRegexWithoutAnchors                     = "[a-z]{2}(?:-[a-z]+)+-[0-9]+"
+	regionQualifiedClusterNameRegexWithoutAnchors = regionRegexWithoutAnchors + ":" + clusterNameRegexWithoutAnchors
+
+	// Resource IDs in the long ARN format are prefixed with the name of the
+	// cluster, for example task/default/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f
+	resourceIDRegexWithoutAnchors = "(?:" + clusterNameRegexWithoutAnchors + "/)?[\\-\\w]+"
+
+	taskDefinitionFamilyRegexWithoutAnchors = "[a-zA-Z0-9_-]{1,255}"
+)
+
+const (
+	ClusterNameRegex            = "^" + clusterNameRegexWithoutStart
+	ClusterARNRegex             = "^" + clusterARNRegexWithoutAnchors + "$"
+	ClusterNameAsARNSuffixRegex = "/" + clusterNameRegexWithoutStart
+
+	// TaskARNRegex, InstanceARNRegex and ServiceARNRegex match both the short and
+	// the long ARN formats. They have no capturing groups so that they can be
+	// used in routes.
+	TaskARNRegex     = "^" + ecsARNPrefixRegexWithoutAnchors + "task/" + resourceIDRegexWithoutAnchors + "$"
+	InstanceARNRegex = "^" + ecsARNPrefixRegexWithoutAnchors + "container-instance/" + resourceIDRegexWithoutAnchors + "$"
+	ServiceARNRegex  = "^" + ecsARNPrefixRegexWithoutAnchors + "service/" + resourceIDRegexWithoutAnchors + "$"
+
+	// TaskDefinitionARNRegex matches the ARN of a revision of a task definition
+	// family, for example task-definition/web:3. It has no capturing groups so
+	// that it can be used in routes.
+	TaskDefinitionARNRegex    = "^" + ecsARNPrefixRegexWithoutAnchors + "task-definition/" + taskDefinitionFamilyRegexWithoutAnchors + ":[0-9]+$"
+	TaskDefinitionFamilyRegex = "^" + taskDefinitionFamilyRegexWithoutAnchors + "$"
+
+	// RegionQualifiedClusterNameRegex matches a cluster name prefixed with the
+	// region of the cluster, for example us-east-1:default
+	RegionQualifiedClusterNameRegex = "^" + regionQualifiedClusterNameRegexWithoutAnchors + "$"
+
+	// ClusterRegex matches a cluster ARN, a region qualified cluster name or a
+	// cluster name. It has no capturing groups so that it can be used in routes.
+	ClusterRegex = "^(?:" + clusterARNRegexWithoutAnchors + "|" +
+		regionQualifiedClusterNameRegexWithoutAnchors + "|" + clusterNameRegexWithoutAnchors + ")$"
 )
@@ -38,6 +38,24 @@ func IsClusterARN(clusterARN string) bool {
 	return false
 }
 
+// IsRegionQualifiedClusterName validates a cluster name qualified with a region against the region qualified cluster name regex
+func IsRegionQualifiedClusterName(cluster string) bool {
+	validCluster := regexp.MustCompile(RegionQualifiedClusterNameRegex)
+	if validCluster.MatchString(cluster) {
+		return true
+	}
+	return false
+}
+
+// IsCluster validates that a cluster is either a cluster ARN, a region qualified cluster name or a cluster name
+func IsCluster(cluster string) bool {
+	validCluster := regexp.MustCompile(ClusterRegex)
+	if validCluster.MatchString(cluster) {
+		return true
+	}
+	return false
+}
+
 // IsTaskARN validates a task ARN against the task ARN regex
 func IsTaskARN(taskARN string) bool {
 	validTaskARN := regexp.MustCompile(TaskARNRegex)
@@ -56,6 +74,33 @@ func IsInstanceARN(instanceARN string) bool {
 	return false
 }
 
+// IsServiceARN validates a service ARN against the service ARN regex
+func IsServiceARN(serviceARN string) bool {
+	validServiceARN := regexp.MustCompile(ServiceARNRegex)
+	if validServiceARN.MatchString(serviceARN) {
+		return true
+	}
+	return false
+}
+
+// IsTaskDefinitionARN validates a task definition ARN against the task definition ARN regex
+func IsTaskDefinitionARN(taskDefinitionARN string) bool {
+	validTaskDefinitionARN := regexp.MustCompile(TaskDefinitionARNRegex)
+	if validTaskDefinitionARN.MatchString(taskDefinitionARN) {
+		return true
+	}
+	return false
+}
+
+// IsTaskDefinitionFamily validates a task definition family against the task definition family regex
+func IsTaskDefinitionFamily(family string) bool {
+	validTaskDefinitionFamily := regexp.MustCompile(TaskDefinitionFamilyRegex)
+	if validTaskDefinitionFamily.MatchString(family) {
+		return true
+	}
+	return false
+}
+
 // IsEntityVersion validates an entity version as a positive integer
 func IsEntityVersion(entityVersion string) bool {
 	value, err := strconv.ParseInt(entityVersion, 10, 64)
@@ -59,6 +59,21 @@ func TestIsClusterARN(t *testing.T) {
 	assert.True(t, isValid, "Valid cluster ARN should satisfy regex")
 }
 
+func TestIsClusterARNChinaPartition(t *testing.T) {
+	isValid := IsClusterARN(validChinaClusterARN)
+	assert.True(t, isValid, "Valid cluster ARN in the aws-cn partition should satisfy regex")
+}
+
+func TestIsClusterARNGovCloudPartition(t *testing.T) {
+	isValid := IsClusterARN(validGovCloudClusterARN)
+	assert.True(t, isValid, "Valid cluster ARN in the aws-us-gov partition should satisfy regex")
+}
+
+func TestIsClusterARNInvalidPartition(t *testing.T) {
+	isValid := IsClusterARN(invalidPartitionClusterARN)
+	assert.False(t, isValid, "Cluster ARN with an unknown partition should not satisfy regex")
+}
+
 func TestIsTaskARNEmptyARN(t *testing.T) {
 	isValid := IsTaskARN("")
 	assert.False(t, isValid, "Empty task ARN should not satisfy regex")
@@ -84,6 +99,26 @@ func TestIsTaskARN(t *testing.T) {
 	assert.True(t, isValid, "Valid task ARN should satisfy regex")
 }
 
+func TestIsTaskARNLongFormat(t *testing.T) {
+	isValid := IsTaskARN(validLongTaskARN)
+	assert.True(t, isValid, "Valid task ARN in the long format should satisfy regex")
+}
+
+func TestIsTaskARNLongFormatInvalidClusterName(t *testing.T) {
+	isValid := IsTaskARN(invalidTaskARNWithInvalidClusterName)
+	assert.False(t, isValid, "Task ARN in the long format with an invalid cluster name should not satisfy regex")
+}
+
+func TestIsTaskARNOtherPartitions(t *testing.T) {
+	assert.True(t, IsTaskARN(validChinaTaskARN), "Valid task ARN in the aws-cn partition should satisfy regex")
+	assert.True(t, IsTaskARN(validGovCloudTaskARN), "Valid task ARN in the aws-us-gov partition should satisfy regex")
+}
+
+func TestIsTaskARNInvalidPartition(t *testing.T) {
+	isValid := IsTaskARN(invalidTaskARNWithInvalidPartition)
+	assert.False(t, isValid, "Task ARN with an unknown partition should not satisfy regex")
+}
+
 func TestIsInstanceARNEmptyARN(t *testing.T) {
 	isValid := IsInstanceARN("")
 	assert.False(t, isValid, "Empty instance ARN should not satisfy regex")
@@ -109,6 +144,47 @@ func TestIsInstanceARN(t *testing.T) {
 	assert.True(t, isValid, "Valid instance ARN should satisfy regex")
 }
 
+func TestIsInstanceARNLongFormat(t *testing.T) {
+	isValid := IsInstanceARN(validLongInstanceARN)
+	assert.True(t, isValid, "Valid instance ARN in the long format should satisfy regex")
+}
+
+func TestIsServiceARNNoNameInARN(t *testing.T) {
+	isValid := IsServiceARN(invalidServiceARNWithNoName)
+	assert.False(t, isValid, "Service ARN with no name should not satisfy regex")
+}
+
+func TestIsServiceARNInvalidPrefixInARN(t *testing.T) {
+	isValid := IsServiceARN(invalidServiceARNWithInvalidPrefix)
+	assert.False(t, isValid, "Service ARN with invalid prefix should not satisfy regex")
+}
+
+func TestIsServiceARN(t *testing.T) {
+	isValid := IsServiceARN(validServiceARN)
+	assert.True(t, isValid, "Valid service ARN should satisfy regex")
+
+	isValid = IsServiceARN(validLongServiceARN)
+	assert.True(t, isValid, "Valid service ARN in the long format should satisfy regex")
+}
+
+func TestIsTaskDefinitionARNNoRevisionInARN(t *testing.T) {
+	isValid := IsTaskDefinitionARN(invalidTaskDefinitionARNWithNoRevision)
+	assert.False(t, isValid, "Task definition ARN with no revision should not satisfy regex")
+}
+
+func TestIsTaskDefinitionARN(t *testing.T) {
+	isValid := IsTaskDefinitionARN(validTaskDefinitionARN)
+	assert.True(t, isValid, "Valid task definition ARN should satisfy regex")
+}
+
+func TestIsTaskDefinitionFamily(t *testing.T) {
+	isValid := IsTaskDefinitionFamily(validTaskDefinitionFamily)
+	assert.True(t, isValid, "Valid task definition family should satisfy regex")
+
+	isValid = IsTaskDefinitionFamily(invalidTaskDefinitionFamilyWithSeparator)
+	assert.False(t, isValid, "Task definition family with a revision separator should not satisfy regex")
+}
+
 func TestIsEntityVersionEmptyVersion(t *testing.T) {
 	isValid := IsEntityVersion("")
 	assert.False(t, isValid, "Empty entity version should not satisfy method")
@@ -132,4 +208,38 @@ func TestIsEntityVersionNonNumber(t *testing.T) {
 func TestIsEntityVersion(t *testing.T) {
 	isValid := IsEntityVersion(validEntityVersion)
 	assert.True(t, isValid, "Valid entity version should satisfy method")
-}
\ No newline at end of file
+}
+
+func TestIsRegionQualifiedClusterNameEmptyName(t *testing.T) {
+	isValid := IsRegionQualifiedClusterName("")
+	assert.False(t, isValid, "Empty region qualified cluster name should not satisfy regex")
+}
+
+func TestIsRegionQualifiedClusterNameWithoutRegion(t *testing.T) {
+	isValid := IsRegionQualifiedClusterName(validClusterName)
+	assert.False(t, isValid, "Cluster name without region should not satisfy regex")
+}
+
+func TestIsRegionQualifiedClusterNameInvalidRegion(t *testing.T) {
+	isValid := IsRegionQualifiedClusterName(invalidRegionQualifiedClusterName)
+	assert.False(t, isValid, "Region qualified cluster name with invalid region should not satisfy regex")
+}
+
+func TestIsRegionQualifiedClusterName(t *testing.T) {
+	isValid := IsRegionQualifiedClusterName(validRegionQualifiedClusterName)
+	assert.True(t, isValid, "Valid region qualified cluster name should satisfy regex")
+}
+
+func TestIsCluster(t *testing.T) {
+	assert.True(t, IsCluster(validClusterName), "Cluster name should satisfy cluster regex")
+	assert.True(t, IsCluster(validRegionQualifiedClusterName), "Region qualified cluster name should satisfy cluster regex")
+	assert.True(t, IsCluster(validClusterARN), "Cluster ARN should satisfy cluster regex")
+	assert.True(t, IsCluster(validGovCloudClusterARN), "Cluster ARN in the aws-us-gov partition should satisfy cluster regex")
+}
+
+func TestIsClusterInvalidCluster(t *testing.T) {
+	assert.False(t, IsCluster(""), "Empty cluster should not satisfy cluster regex")
+	assert.False(t, IsCluster(invalidClusterName), "Invalid cluster name should not satisfy cluster regex")
+	assert.False(t, IsCluster(invalidRegionQualifiedClusterName), "Invalid region qualified cluster name should not satisfy cluster regex")
+	assert.False(t, IsCluster(invalidClusterARNWithInvalidName), "Invalid cluster ARN should not satisfy cluster regex")
+}
@@ -15,57 +15,136 @@ package run
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	log "github.com/cihub/seelog"
 	"github.com/pkg/errors"
 
+	"github.com/goguardian/blox/cluster-state-service/config"
 	"github.com/goguardian/blox/cluster-state-service/handler/api/v1"
+	"github.com/goguardian/blox/cluster-state-service/handler/auth"
+	"github.com/goguardian/blox/cluster-state-service/handler/breaker"
 	"github.com/goguardian/blox/cluster-state-service/handler/clients"
+	"github.com/goguardian/blox/cluster-state-service/handler/compaction"
+	"github.com/goguardian/blox/cluster-state-service/handler/discovery"
+	"github.com/goguardian/blox/cluster-state-service/handler/dns"
 	"github.com/goguardian/blox/cluster-state-service/handler/event"
+	"github.com/goguardian/blox/cluster-state-service/handler/health"
+	"github.com/goguardian/blox/cluster-state-service/handler/janitor"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/reconcile"
+	"github.com/goguardian/blox/cluster-state-service/handler/reconcile/loader"
 	"github.com/goguardian/blox/cluster-state-service/handler/store"
+	"github.com/goguardian/blox/cluster-state-service/handler/wal"
 	"github.com/urfave/negroni"
 	"strings"
 )
 
 const (
-	serverReadTimeout = 10 * time.Second
-	kinesisPrefix     = "kinesis://"
-	sqsPrefix         = "sqs://"
+	kinesisPrefix = "kinesis://"
+	sqsPrefix     = "sqs://"
+	metricsPath   = "/metrics"
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+
+	bootstrapCheck = "bootstrap"
+	etcdCheck      = "etcd"
+	consumerCheck  = "consumer"
+	reconcileCheck = "reconcile"
 )
 
 // StartClusterStateService starts the Cluster State Service. It creates an ETCD
 // client, a data store using this client and an event processor to process
 // events from the provided queue. It also starts the RESTful server and blocks on
 // the listen method of the same to listen to requests that query for task and
-// instance state from the store.
+// instance state from the store. The server listens while the store is
+// bootstrapped, reporting the service as not ready until that completes.
+// On SIGINT or SIGTERM, the service stops consuming events, finishes or
+// abandons an in-progress reconcile loop, ends open streams and drains the
+// server within the shutdown grace period before closing the etcd client.
 func StartClusterStateService(queueNameURI string, bindAddr string, etcdEndpoints []string) error {
 	if bindAddr == "" {
 		return fmt.Errorf("The cluster state service listen address is not set")
 	}
 
-	etcdClient, err := clients.NewEtcdClient(etcdEndpoints)
+	etcdClient, err := clients.NewEtcdClient(clients.EtcdConfig{
+		Endpoints:         etcdEndpoints,
+		DialTimeout:       config.EtcdDialTimeout,
+		CAFile:            config.EtcdCAFile,
+		CertFile:          config.EtcdCertFile,
+		KeyFile:           config.EtcdKeyFile,
+		Username:          config.EtcdUsername,
+		Password:          config.EtcdPassword,
+		AutoSyncInterval:  config.EtcdAutoSyncInterval,
+		KeepAliveInterval: config.EtcdKeepAliveInterval,
+		KeepAliveTimeout:  config.EtcdKeepAliveTimeout,
+	})
 	if err != nil {
 		return errors.Wrapf(err, "Could not start etcd")
 	}
 	defer etcdClient.Close()
 
 	// initialize the datastore
-	datastore, err := store.NewDataStore(etcdClient)
+	datastore, err := store.NewDataStore(etcdClient, store.Timeouts{
+		Request:    config.EtcdRequestTimeout,
+		StreamIdle: config.StreamIdleTimeout,
+	})
 	if err != nil {
 		return errors.Wrapf(err, "Could not initialize the datastore")
 	}
 
-	etcdTXStore, err := store.NewEtcdTXStore(etcdClient)
+	etcdTXStore, err := store.NewEtcdTXStore(etcdClient, config.EtcdRequestTimeout)
 	if err != nil {
 		return errors.Wrapf(err, "Could not initialize the etcd transactional store")
 	}
 
+	revisionStore, err := store.NewRevisionStore(etcdClient, config.EtcdRequestTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "Could not initialize the revision store")
+	}
+
+	migrated, err := store.MigrateLegacyKeys(datastore, etcdTXStore)
+	if err != nil {
+		return errors.Wrapf(err, "Could not migrate the store to the current key layout")
+	}
+	if migrated > 0 {
+		log.Infof("Migrated %d keys to the current key layout", migrated)
+	}
+
 	// initialize services
-	stores, err := store.NewStores(datastore, etcdTXStore)
+	historyLimits := store.HistoryLimits{
+		MaxEntries: config.HistoryMaxEntries,
+		MaxAge:     config.HistoryMaxAge,
+	}
+	// the record applier stops last, once nothing writes records anymore
+	applierCtx, stopApplier := context.WithCancel(context.Background())
+	defer stopApplier()
+	recordApplier, err := newRecordApplier(applierCtx, etcdTXStore)
+	if err != nil {
+		return errors.Wrapf(err, "Could not initialize the record applier")
+	}
+	codec, err := store.NewCodec(config.RecordCodec)
+	if err != nil {
+		return errors.Wrapf(err, "Could not initialize the record codec")
+	}
+	var view *store.View
+	if config.ReadFromView {
+		view, err = store.NewView(etcdClient, config.EtcdRequestTimeout, config.ViewCheckInterval)
+		if err != nil {
+			return errors.Wrapf(err, "Could not initialize the view")
+		}
+	}
+	stores, err := store.NewStores(datastore, etcdTXStore, recordApplier, codec, view, historyLimits)
 	if err != nil {
 		return errors.Wrapf(err, "Could not initialize stores")
 	}
@@ -78,56 +157,350 @@ func StartClusterStateService(queueNameURI string, bindAddr string, etcdEndpoint
 	ecsClient := clients.NewECSClient(awsSession)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	recon, err := reconcile.NewReconciler(ctx, stores, ecsClient, reconcile.ReconcileDuration)
-	if err != nil {
-		return errors.Wrapf(err, "Could not start reconciler")
+	if view != nil {
+		go view.Run(ctx)
 	}
-	err = recon.RunOnce()
+	recon, err := reconcile.NewReconciler(ctx, stores, ecsClient, config.ReconcileInterval)
 	if err != nil {
-		return errors.Wrapf(err, "Error bootstrapping")
+		return errors.Wrapf(err, "Could not start reconciler")
 	}
-	log.Infof("Bootstrapping completed")
-	go recon.Run()
 
 	// initialize apis
-	apis := v1.NewAPIs(stores)
+	taskDefinitionLoader := loader.NewTaskDefinitionLoader(stores.TaskStore, stores.TaskDefinitionStore, ecsClient)
+	newEC2Client := func(region string) ec2iface.EC2API {
+		return clients.NewEC2Client(awsSession, region)
+	}
+	hostResolver, err := discovery.NewEC2HostCache(newEC2Client, discovery.HostCacheTTL)
+	if err != nil {
+		return errors.Wrapf(err, "Could not initialize the EC2 host cache")
+	}
+	apis := v1.NewAPIs(stores, revisionStore, taskDefinitionLoader, hostResolver)
 
-	// start event processor
+	// initialize event consumer, it starts polling once bootstrapping completed
 	processor := event.NewProcessor(stores)
+	if config.EventBufferDir != "" {
+		bufferedProcessor, err := newBufferedProcessor(ctx, processor, etcdClient)
+		if err != nil {
+			return errors.Wrapf(err, "Could not initialize the event buffer")
+		}
+		processor = bufferedProcessor
+	}
+	consumer, err := newConsumer(awsSession, processor, queueNameURI)
+	if err != nil {
+		return errors.Wrapf(err, "Could not start the consumer")
+	}
 
-	if strings.HasPrefix(queueNameURI, kinesisPrefix) {
-		kinesisClient := clients.NewKinesisClient(awsSession)
+	// start server before bootstrapping, so that load balancers can tell a
+	// bootstrapping service from one that is down
+	bootstrapped := health.NewFlag("Bootstrap reconciliation has not finished")
+	checker := health.NewChecker()
+	checker.AddCheck(bootstrapCheck, bootstrapped.Check)
+	checker.AddCheck(etcdCheck, health.NewEtcdCheck(etcdClient))
+	if config.ReadyMaxPollAge > 0 {
+		checker.AddCheck(consumerCheck, health.NewRecencyCheck(consumer.LastPoll, config.ReadyMaxPollAge,
+			"The event consumer has not polled its queue successfully"))
+	}
+	if config.ReadyMaxReconcileFailures > 0 {
+		checker.AddCheck(reconcileCheck, func() error {
+			failures := recon.ConsecutiveFailures()
+			if failures >= config.ReadyMaxReconcileFailures {
+				return errors.Errorf("Reconcile failed %d times in a row", failures)
+			}
+			return nil
+		})
+	}
+
+	router := v1.NewRouter(apis)
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, metrics.Handler())
+	mux.HandleFunc(livenessPath, checker.ServeLiveness)
+	mux.HandleFunc(readinessPath, checker.ServeReadiness)
+	mux.Handle("/", router)
 
-		// start event consumer
-		consumer, err := event.NewKinesisConsumer(kinesisClient, processor, strings.TrimPrefix(queueNameURI, kinesisPrefix))
+	n := negroni.Classic()
+	n.Use(metrics.NewHTTPMiddleware(router))
+	if config.APIKeysFile != "" {
+		keys, err := auth.LoadKeys(config.APIKeysFile)
 		if err != nil {
-			return errors.Wrapf(err, "Could not start the consumer")
+			return errors.Wrapf(err, "Could not load the API keys")
 		}
+		authMiddleware, err := auth.NewMiddleware(keys, router, auth.Paths{
+			Public:          []string{livenessPath, readinessPath},
+			Metrics:         metricsPath,
+			ClusterFiltered: v1.ClusterFilteredPaths(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Could not initialize authentication")
+		}
+		n.Use(authMiddleware)
+		if config.TLSCertFile == "" {
+			log.Warnf("API keys are sent in plain text because TLS is not configured")
+		}
+	}
+	n.UseHandler(mux)
 
-		go consumer.PollForEvents(ctx)
-	} else {
-		sqsClient := clients.NewSQSClient(awsSession)
+	// streams are served with a context that is cancelled on shutdown, so
+	// that they end before the server is drained
+	serveCtx, stopStreams := v1.NewShutdownContext(context.Background())
+	defer stopStreams()
+	s := &http.Server{
+		Addr:        bindAddr,
+		Handler:     n,
+		ReadTimeout: config.ServerReadTimeout,
+		BaseContext: func(net.Listener) context.Context { return serveCtx },
+	}
 
-		// start event consumer
-		consumer, err := event.NewSQSConsumer(sqsClient, processor, strings.TrimPrefix(queueNameURI, sqsPrefix))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return errors.Wrapf(err, "Could not listen on %s", bindAddr)
+	}
+	if config.TLSCertFile != "" {
+		tlsConfig, err := serverTLSConfig(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile)
 		if err != nil {
-			return errors.Wrapf(err, "Could not start the consumer")
+			listener.Close()
+			return errors.Wrapf(err, "Could not configure TLS")
 		}
+		s.TLSConfig = tlsConfig
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	defer s.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- s.Serve(listener)
+	}()
 
-		go consumer.PollForEvents(ctx)
+	bootstrapErr := make(chan error, 1)
+	go func() {
+		bootstrapErr <- recon.RunOnce()
+	}()
+	select {
+	case err = <-bootstrapErr:
+		if err != nil {
+			return errors.Wrapf(err, "Error bootstrapping")
+		}
+	case sig := <-signals:
+		log.Infof("Received %s while bootstrapping, abandoning bootstrap", sig)
+		graceCtx, cancelGrace := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+		defer cancelGrace()
+		cancel()
+		err = shutdownServer(graceCtx, s, stopStreams)
+		waitForReconciler(recon)
+		return err
+	case err = <-serverErr:
+		return errors.Wrapf(err, "Could not serve requests")
+	}
+	bootstrapped.Set()
+	log.Infof("Bootstrapping completed")
+	go recon.Run()
+	if config.MigrateRecordCodec {
+		go migrateRecordCodec(ctx, etcdTXStore, codec)
 	}
 
-	// start server
-	router := v1.NewRouter(apis)
+	retention := janitor.Retention{
+		StoppedTask:      config.StoppedTaskRetention,
+		InactiveInstance: config.InactiveInstanceRetention,
+		Tombstone:        config.TombstoneRetention,
+	}
+	if retention.IsEnabled() {
+		j, err := janitor.NewJanitor(ctx, etcdClient, stores, retention, janitor.JanitorDuration)
+		if err != nil {
+			return errors.Wrapf(err, "Could not start janitor")
+		}
+		go j.Run()
+	}
 
-	n := negroni.Classic()
-	n.UseHandler(router)
+	if config.EtcdCompactionInterval > 0 {
+		manager, err := compaction.NewManager(etcdClient, revisionStore, compaction.Retention{
+			Revisions: config.EtcdCompactionRetainRevisions,
+			Age:       config.EtcdCompactionRetainAge,
+		}, compaction.Schedule{
+			CompactionInterval:      config.EtcdCompactionInterval,
+			DefragmentationInterval: config.EtcdDefragInterval,
+		}, config.EtcdRequestTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "Could not start the compaction manager")
+		}
+		go manager.Run(ctx)
+	}
 
-	s := &http.Server{
-		Addr:        bindAddr,
-		Handler:     n,
-		ReadTimeout: serverReadTimeout,
+	if config.DNSBindAddr != "" {
+		dnsConfig := dns.Config{
+			BindAddr: config.DNSBindAddr,
+			Domain:   config.DNSDomain,
+			TTL:      config.DNSTTL,
+		}
+		dnsServer, err := dns.NewServer(ctx, stores, taskDefinitionLoader, hostResolver, dnsConfig)
+		if err != nil {
+			return errors.Wrapf(err, "Could not initialize the DNS server")
+		}
+		err = dnsServer.Start()
+		if err != nil {
+			return errors.Wrapf(err, "Could not start the DNS server")
+		}
+	}
+
+	// start event consumer. It has a context of its own so that it can be
+	// stopped before anything else on shutdown.
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	defer stopConsumer()
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		consumer.PollForEvents(consumerCtx)
+	}()
+
+	select {
+	case sig := <-signals:
+		log.Infof("Received %s, shutting down", sig)
+	case err = <-serverErr:
+		return errors.Wrapf(err, "Could not serve requests")
 	}
 
-	return s.ListenAndServe()
+	graceCtx, cancelGrace := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+	defer cancelGrace()
+
+	// the consumer stops polling once it processed its current batch
+	stopConsumer()
+	select {
+	case <-consumerDone:
+	case <-graceCtx.Done():
+		log.Warnf("Event consumer did not finish processing its current batch within the grace period")
+	}
+
+	// stop the reconciler, the janitor, the compaction manager and the DNS server
+	cancel()
+	if !recon.Wait(graceCtx) {
+		log.Warnf("Reconcile loop did not finish within the grace period")
+	}
+
+	err = shutdownServer(graceCtx, s, stopStreams)
+	waitForReconciler(recon)
+	return err
+}
+
+// waitForReconciler waits for an in-progress reconcile loop to stop, so that
+// the etcd client is not closed while it writes. The loaders do not take a
+// context, so once the reconciler is stopped a loop stops after its current
+// loader.
+func waitForReconciler(recon *reconcile.Reconciler) {
+	recon.Wait(context.Background())
+}
+
+// shutdownServer ends open streams with a final message and then waits for
+// the remaining requests to finish, until 'ctx' is done
+func shutdownServer(ctx context.Context, s *http.Server, stopStreams context.CancelFunc) error {
+	stopStreams()
+	err := s.Shutdown(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "Could not drain the HTTP server")
+	}
+	log.Infof("Cluster state service stopped")
+	return nil
+}
+
+// serverTLSConfig loads the certificate the server serves TLS with and, if
+// 'clientCAFile' is set, requires clients to present a certificate issued by
+// one of its certificate authorities
+func serverTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not load the TLS certificate '%s'", certFile)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read the client certificate authorities '%s'", clientCAFile)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("No certificates found in '%s'", clientCAFile)
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// newRecordApplier creates the applier that records of events are written to
+// etcd with. Records are written in batches unless the batch size is 1. The
+// batch applier runs until 'ctx' is done.
+func newRecordApplier(ctx context.Context, etcdTXStore store.EtcdTXStore) (store.RecordApplier, error) {
+	if config.EtcdBatchSize == 1 {
+		return store.NewRecordApplier(etcdTXStore)
+	}
+	batchApplier, err := store.NewBatchApplier(etcdTXStore, store.BatchLimits{
+		Size:    config.EtcdBatchSize,
+		Latency: config.EtcdBatchLatency,
+	}, config.EtcdRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	go batchApplier.Run(ctx)
+	return batchApplier, nil
+}
+
+// migrateRecordCodec re-encodes the records stored with a codec other than
+// 'codec' in the background, giving up once 'ctx' is done
+func migrateRecordCodec(ctx context.Context, etcdTXStore store.EtcdTXStore, codec store.Codec) {
+	migrated, err := store.MigrateRecordCodec(ctx, etcdTXStore, codec, config.EtcdRequestTimeout)
+	if err != nil && ctx.Err() == nil {
+		log.Errorf("Could not migrate records to the %s codec: %+v", codec.Name(), err)
+	}
+	if migrated > 0 {
+		log.Infof("Migrated %d records to the %s codec", migrated, codec.Name())
+	}
+}
+
+// newBufferedProcessor wraps 'processor' so that events are buffered in
+// EventBufferDir while etcd is unavailable, and drains the buffer in the
+// background until 'ctx' is done
+func newBufferedProcessor(ctx context.Context, processor event.Processor, etcdClient clients.EtcdInterface) (event.Processor, error) {
+	buffer, err := wal.Open(config.EventBufferDir)
+	if err != nil {
+		return nil, err
+	}
+	etcdBreaker, err := breaker.New(etcdCheck, config.EtcdBreakerMaxFailures, breaker.Probe(health.NewEtcdCheck(etcdClient)),
+		config.EtcdBreakerProbeInterval, func(state breaker.State) {
+			metrics.SetEtcdCircuitState(int(state))
+		})
+	if err != nil {
+		buffer.Close()
+		return nil, err
+	}
+	bufferedProcessor, err := event.NewBufferedProcessor(processor, buffer, etcdBreaker)
+	if err != nil {
+		buffer.Close()
+		return nil, err
+	}
+	if buffered := bufferedProcessor.Buffered(); buffered > 0 {
+		log.Infof("Draining %d events buffered while etcd was unavailable", buffered)
+	}
+	go etcdBreaker.Run(ctx)
+	go func() {
+		bufferedProcessor.Drain(ctx)
+		buffer.Close()
+	}()
+	return bufferedProcessor, nil
+}
+
+// newConsumer creates the Kinesis or SQS consumer of the events of the queue
+// with URI 'queueNameURI', depending on its scheme
+func newConsumer(awsSession *session.Session, processor event.Processor, queueNameURI string) (event.Consumer, error) {
+	if strings.HasPrefix(queueNameURI, kinesisPrefix) {
+		kinesisClient := clients.NewKinesisClient(awsSession)
+		return event.NewKinesisConsumer(kinesisClient, processor, strings.TrimPrefix(queueNameURI, kinesisPrefix), config.KinesisGetRecordsSize)
+	}
+	sqsClient := clients.NewSQSClient(awsSession)
+	return event.NewSQSConsumer(sqsClient, processor, strings.TrimPrefix(queueNameURI, sqsPrefix), config.SQSVisibilityTimeout)
 }
@@ -0,0 +1,409 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxBatchSize bounds the number of records in a batch. Each record
+	// reads and writes up to three keys, and etcd accepts up to 128
+	// operations per transaction by default.
+	MaxBatchSize = 42
+)
+
+// BatchLimits bound the batches of a BatchApplier
+type BatchLimits struct {
+	// Size is the maximum number of records in a batch
+	Size int
+	// Latency is how long a record waits for more records to be batched with
+	Latency time.Duration
+}
+
+func (limits BatchLimits) validate() error {
+	if limits.Size < 1 || limits.Size > MaxBatchSize {
+		return errors.Errorf("Batch size has to be between 1 and %d", MaxBatchSize)
+	}
+	if limits.Latency <= 0 {
+		return errors.New("Batch latency has to be positive")
+	}
+	return nil
+}
+
+// BatchApplier applies records that are added concurrently in batches. The
+// records of a batch are grouped by key, keeping only the newest version of
+// each, and are written in a single etcd transaction that succeeds only if
+// none of the keys the batch read were modified in the meantime. If one was,
+// each record is applied in a transaction of its own instead.
+type BatchApplier struct {
+	kv             batchKV
+	fallback       RecordApplier
+	limits         BatchLimits
+	requestTimeout time.Duration
+	requests       chan batchRequest
+	stopped        chan struct{}
+}
+
+// batchRequest is a record waiting to be applied in a batch
+type batchRequest struct {
+	applier *STMApplier
+	version int64
+	done    chan error
+}
+
+// batchRecord is the newest version of a record in a batch, along with the
+// requests of all versions of it
+type batchRecord struct {
+	applier  *STMApplier
+	version  int64
+	requests []batchRequest
+}
+
+func (record *batchRecord) finish(err error) {
+	for _, request := range record.requests {
+		request.done <- err
+	}
+}
+
+// NewBatchApplier initializes a batch applier that waits up to
+// 'requestTimeout' for each batch to be written. Records are only applied
+// while Run is running.
+func NewBatchApplier(ts EtcdTXStore, limits BatchLimits, requestTimeout time.Duration) (*BatchApplier, error) {
+	if ts == nil {
+		return nil, errors.New("Etcd transactional store is not initialized")
+	}
+	fallback, err := NewRecordApplier(ts)
+	if err != nil {
+		return nil, err
+	}
+	return newBatchApplier(etcdBatchKV{kv: ts.GetV3Client()}, fallback, limits, requestTimeout)
+}
+
+func newBatchApplier(kv batchKV, fallback RecordApplier, limits BatchLimits, requestTimeout time.Duration) (*BatchApplier, error) {
+	if err := limits.validate(); err != nil {
+		return nil, err
+	}
+	if requestTimeout <= 0 {
+		return nil, errors.New("Etcd request timeout has to be positive")
+	}
+	return &BatchApplier{
+		kv:             kv,
+		fallback:       fallback,
+		limits:         limits,
+		requestTimeout: requestTimeout,
+		requests:       make(chan batchRequest),
+		stopped:        make(chan struct{}),
+	}, nil
+}
+
+// ApplyRecord adds the record of 'applier' to the next batch, returning once
+// the batch is applied
+func (batchApplier *BatchApplier) ApplyRecord(applier *STMApplier) error {
+	err := applier.validateApplier()
+	if err != nil {
+		return err
+	}
+	version, err := applier.record.GetVersion(applier.recordJSON)
+	if err != nil {
+		return errors.Wrapf(err, "Error retrieving the version of the record to batch")
+	}
+
+	request := batchRequest{
+		applier: applier,
+		version: version,
+		done:    make(chan error, 1),
+	}
+	select {
+	case batchApplier.requests <- request:
+	case <-batchApplier.stopped:
+		return errors.New("The batch applier is stopped")
+	}
+	return <-request.done
+}
+
+// Run collects records into batches and applies them until 'ctx' is done. A
+// batch is applied once it is full or its first record has waited for the
+// batch latency.
+func (batchApplier *BatchApplier) Run(ctx context.Context) {
+	defer close(batchApplier.stopped)
+	for {
+		var batch []batchRequest
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-batchApplier.requests:
+			batch = append(batch, request)
+		}
+
+		timer := time.NewTimer(batchApplier.limits.Latency)
+	collect:
+		for len(batch) < batchApplier.limits.Size {
+			select {
+			case request := <-batchApplier.requests:
+				batch = append(batch, request)
+			case <-timer.C:
+				break collect
+			case <-ctx.Done():
+				break collect
+			}
+		}
+		timer.Stop()
+
+		batchApplier.apply(batch)
+	}
+}
+
+// apply applies a batch, falling back to applying its records one by one if
+// the keys it read were modified before it was written
+func (batchApplier *BatchApplier) apply(batch []batchRequest) {
+	records := coalesce(batch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchApplier.requestTimeout)
+	defer cancel()
+	pending, err := batchApplier.applyBatch(ctx, records)
+	metrics.ObserveBatch(len(batch), len(pending) > 0)
+	if err != nil {
+		for _, record := range records {
+			record.finish(err)
+		}
+		return
+	}
+
+	for _, record := range pending {
+		record.finish(batchApplier.fallback.ApplyRecord(record.applier))
+	}
+}
+
+// applyBatch writes the records of a batch in a single transaction, returning
+// the records that have to be applied one by one instead. The other records
+// are finished.
+func (batchApplier *BatchApplier) applyBatch(ctx context.Context, records []*batchRecord) ([]*batchRecord, error) {
+	reads, err := batchApplier.kv.read(ctx, readKeys(records))
+	if err != nil {
+		return nil, err
+	}
+
+	var writes []batchWrite
+	var applied []*batchRecord
+	var pending []*batchRecord
+	for _, record := range records {
+		view := newBatchView(reads)
+		err := record.applier.applyRecord(view)
+		switch {
+		case view.unread:
+			pending = append(pending, record)
+		case err != nil:
+			record.finish(err)
+		default:
+			writes = append(writes, view.writes...)
+			applied = append(applied, record)
+		}
+	}
+
+	if len(writes) > 0 {
+		committed, err := batchApplier.kv.write(ctx, reads, writes)
+		if err != nil {
+			for _, record := range applied {
+				record.finish(err)
+			}
+			return pending, nil
+		}
+		if !committed {
+			return append(applied, pending...), nil
+		}
+	}
+	for _, record := range applied {
+		record.finish(nil)
+	}
+	return pending, nil
+}
+
+// coalesce groups the requests of a batch by key, keeping the newest version
+// of each record. The history entries of the versions it supersedes are
+// recorded along with it.
+func coalesce(batch []batchRequest) []*batchRecord {
+	byKey := make(map[string]*batchRecord)
+	var records []*batchRecord
+	for _, request := range batch {
+		record, ok := byKey[request.applier.recordKey]
+		if !ok {
+			applier := *request.applier
+			record = &batchRecord{
+				applier: &applier,
+				version: request.version,
+			}
+			byKey[applier.recordKey] = record
+			records = append(records, record)
+		} else if request.version > record.version {
+			applier := *request.applier
+			applier.supersededHistory = record.applier.supersededHistory
+			if record.applier.historyKey != "" {
+				applier.supersededHistory = append(applier.supersededHistory, record.applier.historyEntry)
+			}
+			record.applier = &applier
+			record.version = request.version
+		} else if request.version < record.version && request.applier.historyKey != "" {
+			record.applier.supersededHistory = append(record.applier.supersededHistory, request.applier.historyEntry)
+		}
+		record.requests = append(record.requests, request)
+	}
+
+	for _, record := range records {
+		history := record.applier.supersededHistory
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].Version < history[j].Version
+		})
+	}
+	return records
+}
+
+// readKeys returns the keys the appliers of 'records' read
+func readKeys(records []*batchRecord) []string {
+	var keys []string
+	for _, record := range records {
+		for _, key := range []string{record.applier.recordKey, record.applier.tombstoneKey, record.applier.historyKey} {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// batchRead is the value of a key read for a batch and the revision it was
+// last modified at, which is 0 if the key does not exist
+type batchRead struct {
+	value       string
+	modRevision int64
+}
+
+// batchWrite is a put or a delete of a key in a batch
+type batchWrite struct {
+	key    string
+	value  string
+	delete bool
+}
+
+// batchView is the view of the store that a record of a batch is applied
+// against. It reads the values read for the batch and collects writes.
+type batchView struct {
+	reads   map[string]batchRead
+	writes  []batchWrite
+	written map[string]int
+	// unread is set when a key that was not read for the batch is read
+	unread bool
+}
+
+func newBatchView(reads map[string]batchRead) *batchView {
+	return &batchView{
+		reads:   reads,
+		written: make(map[string]int),
+	}
+}
+
+func (view *batchView) Get(key string) string {
+	if i, ok := view.written[key]; ok {
+		return view.writes[i].value
+	}
+	read, ok := view.reads[key]
+	if !ok {
+		view.unread = true
+	}
+	return read.value
+}
+
+func (view *batchView) Put(key, val string, opts ...clientv3.OpOption) {
+	view.write(batchWrite{key: key, value: val})
+}
+
+func (view *batchView) Del(key string) {
+	view.write(batchWrite{key: key, delete: true})
+}
+
+func (view *batchView) write(write batchWrite) {
+	if i, ok := view.written[write.key]; ok {
+		view.writes[i] = write
+		return
+	}
+	view.written[write.key] = len(view.writes)
+	view.writes = append(view.writes, write)
+}
+
+// batchKV reads and writes the keys of batches
+type batchKV interface {
+	// read reads 'keys' at a single revision
+	read(ctx context.Context, keys []string) (map[string]batchRead, error)
+	// write writes 'writes' if none of the keys in 'reads' were modified
+	// since they were read, returning false if one was
+	write(ctx context.Context, reads map[string]batchRead, writes []batchWrite) (bool, error)
+}
+
+// etcdBatchKV reads and writes the keys of batches in etcd transactions
+type etcdBatchKV struct {
+	kv clientv3.KV
+}
+
+func (etcdKV etcdBatchKV) read(ctx context.Context, keys []string) (map[string]batchRead, error) {
+	ops := make([]clientv3.Op, len(keys))
+	for i, key := range keys {
+		ops[i] = clientv3.OpGet(key)
+	}
+	start := time.Now()
+	resp, err := etcdKV.kv.Txn(ctx).Then(ops...).Commit()
+	metrics.ObserveEtcdRequest(txnOperation, start, err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read batch from etcd")
+	}
+
+	reads := make(map[string]batchRead, len(keys))
+	for i, key := range keys {
+		var read batchRead
+		if kvs := resp.Responses[i].GetResponseRange().Kvs; len(kvs) > 0 {
+			read.value = string(kvs[0].Value)
+			read.modRevision = kvs[0].ModRevision
+		}
+		reads[key] = read
+	}
+	return reads, nil
+}
+
+func (etcdKV etcdBatchKV) write(ctx context.Context, reads map[string]batchRead, writes []batchWrite) (bool, error) {
+	cmps := make([]clientv3.Cmp, 0, len(reads))
+	for key, read := range reads {
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(key), "=", read.modRevision))
+	}
+	ops := make([]clientv3.Op, len(writes))
+	for i, write := range writes {
+		if write.delete {
+			ops[i] = clientv3.OpDelete(write.key)
+		} else {
+			ops[i] = clientv3.OpPut(write.key, write.value)
+		}
+	}
+
+	start := time.Now()
+	resp, err := etcdKV.kv.Txn(ctx).If(cmps...).Then(ops...).Commit()
+	metrics.ObserveEtcdRequest(txnOperation, start, err)
+	if err != nil {
+		return false, errors.Wrapf(err, "Could not write batch to etcd")
+	}
+	return resp.Succeeded, nil
+}
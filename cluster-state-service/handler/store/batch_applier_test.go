@@ -0,0 +1,305 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBatchLimits = BatchLimits{
+	Size:    MaxBatchSize,
+	Latency: time.Hour,
+}
+
+// fakeBatchKV keeps the keys of batches in memory
+type fakeBatchKV struct {
+	lock     sync.Mutex
+	values   map[string]batchRead
+	revision int64
+	readErr  error
+	// conflict makes writes fail as if a key was modified after it was read
+	conflict bool
+	writes   int
+}
+
+func newFakeBatchKV() *fakeBatchKV {
+	return &fakeBatchKV{
+		values: make(map[string]batchRead),
+	}
+}
+
+func (kv *fakeBatchKV) put(key string, value string) {
+	kv.revision++
+	kv.values[key] = batchRead{value: value, modRevision: kv.revision}
+}
+
+func (kv *fakeBatchKV) read(ctx context.Context, keys []string) (map[string]batchRead, error) {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+	if kv.readErr != nil {
+		return nil, kv.readErr
+	}
+	reads := make(map[string]batchRead, len(keys))
+	for _, key := range keys {
+		reads[key] = kv.values[key]
+	}
+	return reads, nil
+}
+
+func (kv *fakeBatchKV) write(ctx context.Context, reads map[string]batchRead, writes []batchWrite) (bool, error) {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+	if kv.conflict {
+		return false, nil
+	}
+	for key, read := range reads {
+		if kv.values[key].modRevision != read.modRevision {
+			return false, nil
+		}
+	}
+	kv.writes++
+	for _, write := range writes {
+		if write.delete {
+			delete(kv.values, write.key)
+		} else {
+			kv.put(write.key, write.value)
+		}
+	}
+	return true, nil
+}
+
+// fakeRecordApplier records the appliers it applies records of
+type fakeRecordApplier struct {
+	lock    sync.Mutex
+	applied []*STMApplier
+	err     error
+}
+
+func (recordApplier *fakeRecordApplier) ApplyRecord(applier *STMApplier) error {
+	recordApplier.lock.Lock()
+	defer recordApplier.lock.Unlock()
+	recordApplier.applied = append(recordApplier.applied, applier)
+	return recordApplier.err
+}
+
+func TestNewBatchApplierNilEtcdTXStore(t *testing.T) {
+	_, err := NewBatchApplier(nil, testBatchLimits, time.Second)
+	assert.Error(t, err, "Expected an error when etcd transactional store is nil")
+}
+
+func TestNewBatchApplierInvalidLimits(t *testing.T) {
+	invalidLimits := []BatchLimits{
+		{Size: 0, Latency: time.Second},
+		{Size: MaxBatchSize + 1, Latency: time.Second},
+		{Size: 1, Latency: 0},
+	}
+	for _, limits := range invalidLimits {
+		_, err := newBatchApplier(newFakeBatchKV(), &fakeRecordApplier{}, limits, time.Second)
+		assert.Error(t, err, "Expected an error with invalid batch limits %+v", limits)
+	}
+
+	_, err := newBatchApplier(newFakeBatchKV(), &fakeRecordApplier{}, testBatchLimits, 0)
+	assert.Error(t, err, "Expected an error with a request timeout of 0")
+}
+
+func TestBatchApplierAppliesRecordsInOneWrite(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.put("key2", generateRecordWithVersion(t, 1))
+	fallback := &fakeRecordApplier{}
+	batchApplier, err := newBatchApplier(kv, fallback, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	batch := []batchRequest{
+		newBatchRequest(t, "key1", 1),
+		newBatchRequest(t, "key2", 2),
+	}
+	batchApplier.apply(batch)
+
+	assertBatchFinished(t, batch, nil)
+	assert.Equal(t, 1, kv.writes, "Expected the records to be written in one transaction")
+	assert.Equal(t, generateRecordWithVersion(t, 1), kv.values["key1"].value, "Unexpected record of key1")
+	assert.Equal(t, generateRecordWithVersion(t, 2), kv.values["key2"].value, "Unexpected record of key2")
+	assert.Empty(t, fallback.applied, "Unexpected records applied one by one")
+}
+
+func TestBatchApplierKeepsNewestVersionOfRecord(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.put("history", generateHistory(t, 1))
+	kv.put("key", generateRecordWithVersion(t, 1))
+	batchApplier, err := newBatchApplier(kv, &fakeRecordApplier{}, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	batch := []batchRequest{
+		newBatchRequestWithHistory(t, "key", 2),
+		newBatchRequestWithHistory(t, "key", 4),
+		newBatchRequestWithHistory(t, "key", 3),
+	}
+	batchApplier.apply(batch)
+
+	assertBatchFinished(t, batch, nil)
+	assert.Equal(t, generateRecordWithVersion(t, 4), kv.values["key"].value, "Expected the newest version of the record")
+
+	entries, err := unmarshalHistory(kv.values["history"].value)
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	versions := make([]int64, len(entries))
+	for i, entry := range entries {
+		versions[i] = entry.Version
+	}
+	assert.Equal(t, []int64{2, 3, 4}, versions, "Expected the history of superseded versions in order")
+}
+
+func TestBatchApplierFallsBackOnConflict(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.conflict = true
+	fallback := &fakeRecordApplier{}
+	batchApplier, err := newBatchApplier(kv, fallback, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	batch := []batchRequest{
+		newBatchRequest(t, "key1", 1),
+		newBatchRequest(t, "key2", 1),
+	}
+	batchApplier.apply(batch)
+
+	assertBatchFinished(t, batch, nil)
+	assert.Len(t, fallback.applied, 2, "Expected each record to be applied one by one")
+}
+
+func TestBatchApplierFallbackErrors(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.conflict = true
+	fallback := &fakeRecordApplier{err: errors.New("Error applying record")}
+	batchApplier, err := newBatchApplier(kv, fallback, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	batch := []batchRequest{newBatchRequest(t, "key", 1)}
+	batchApplier.apply(batch)
+
+	assertBatchFinished(t, batch, fallback.err)
+}
+
+func TestBatchApplierReadError(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.readErr = errors.New("Error reading keys")
+	fallback := &fakeRecordApplier{}
+	batchApplier, err := newBatchApplier(kv, fallback, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	batch := []batchRequest{
+		newBatchRequest(t, "key1", 1),
+		newBatchRequest(t, "key2", 1),
+	}
+	batchApplier.apply(batch)
+
+	assertBatchFinished(t, batch, kv.readErr)
+	assert.Empty(t, fallback.applied, "Unexpected records applied one by one")
+}
+
+func TestBatchApplierRecordError(t *testing.T) {
+	kv := newFakeBatchKV()
+	kv.put("key1", "invalidJSON")
+	batchApplier, err := newBatchApplier(kv, &fakeRecordApplier{}, testBatchLimits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	invalid := newBatchRequest(t, "key1", 1)
+	valid := newBatchRequest(t, "key2", 1)
+	batchApplier.apply([]batchRequest{invalid, valid})
+
+	assert.Error(t, <-invalid.done, "Expected an error applying a record over an invalid one")
+	assert.NoError(t, <-valid.done, "Unexpected error applying a valid record")
+	assert.Equal(t, generateRecordWithVersion(t, 1), kv.values["key2"].value, "Unexpected record of key2")
+}
+
+func TestBatchApplierRun(t *testing.T) {
+	kv := newFakeBatchKV()
+	limits := BatchLimits{Size: 2, Latency: time.Hour}
+	batchApplier, err := newBatchApplier(kv, &fakeRecordApplier{}, limits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		batchApplier.Run(ctx)
+		close(stopped)
+	}()
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"key1", "key2"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			err := batchApplier.ApplyRecord(newBatchRequest(t, key, 1).applier)
+			assert.NoError(t, err, "Unexpected error applying record")
+		}(key)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, kv.writes, "Expected a full batch to be written at once")
+
+	cancel()
+	<-stopped
+	err = batchApplier.ApplyRecord(newBatchRequest(t, "key3", 1).applier)
+	assert.Error(t, err, "Expected an error applying a record once the batch applier is stopped")
+}
+
+func TestBatchApplierRunAppliesPartialBatchAfterLatency(t *testing.T) {
+	kv := newFakeBatchKV()
+	limits := BatchLimits{Size: MaxBatchSize, Latency: time.Millisecond}
+	batchApplier, err := newBatchApplier(kv, &fakeRecordApplier{}, limits, time.Second)
+	assert.NoError(t, err, "Unexpected error creating batch applier")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go batchApplier.Run(ctx)
+
+	err = batchApplier.ApplyRecord(newBatchRequest(t, "key", 1).applier)
+	assert.NoError(t, err, "Unexpected error applying record")
+	assert.Equal(t, 1, kv.writes, "Expected the partial batch to be written")
+}
+
+func newBatchRequest(t *testing.T, key string, version int64) batchRequest {
+	return batchRequest{
+		applier: &STMApplier{
+			record:     SampleRecord{},
+			recordKey:  key,
+			recordJSON: generateRecordWithVersion(t, version),
+		},
+		version: version,
+		done:    make(chan error, 1),
+	}
+}
+
+func newBatchRequestWithHistory(t *testing.T, key string, version int64) batchRequest {
+	request := newBatchRequest(t, key, version)
+	request.applier.historyKey = "history"
+	request.applier.historyEntry = generateHistoryEntry(t, version)
+	request.applier.historyLimits = testHistoryLimits
+	return request
+}
+
+func assertBatchFinished(t *testing.T, batch []batchRequest, expected error) {
+	for _, request := range batch {
+		select {
+		case err := <-request.done:
+			assert.Equal(t, expected, errors.Cause(err), "Unexpected result of record %s", request.applier.recordKey)
+		default:
+			t.Errorf("Record %s was not finished", request.applier.recordKey)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	clusterEntityKeyPrefix = "ecs/cluster/"
+)
+
+// ClusterStore defines methods to access the clusters in the datastore
+type ClusterStore interface {
+	AddCluster(clusterARN string) error
+	EnsureCluster(clusterARN string) error
+	GetCluster(cluster string) (*storetypes.VersionedCluster, error)
+	ListClusters() ([]storetypes.VersionedCluster, error)
+	StreamClusters(ctx context.Context, entityVersion string) (chan storetypes.VersionedCluster, error)
+	DeleteCluster(clusterARN string) error
+}
+
+type eventClusterStore struct {
+	datastore DataStore
+	// knownClusters records the keys of the clusters that are known to be in
+	// the datastore, so that events do not read the cluster every time
+	knownClusters *sync.Map
+}
+
+// NewClusterStore initializes the eventClusterStore struct
+func NewClusterStore(ds DataStore) (ClusterStore, error) {
+	if ds == nil {
+		return nil, errors.New("Datastore is not initialized")
+	}
+	return eventClusterStore{
+		datastore:     ds,
+		knownClusters: &sync.Map{},
+	}, nil
+}
+
+// AddCluster adds the cluster with ARN 'clusterARN' to the datastore
+func (clusterStore eventClusterStore) AddCluster(clusterARN string) error {
+	key, clusterJSON, err := clusterStore.marshalClusterAndGenerateKey(clusterARN)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Cluster store adding cluster: %s", clusterARN)
+
+	err = clusterStore.datastore.Add(key, clusterJSON)
+	if err != nil {
+		return err
+	}
+	clusterStore.knownClusters.Store(key, struct{}{})
+	return nil
+}
+
+// EnsureCluster adds the cluster with ARN 'clusterARN' to the datastore if it
+// is not there yet. Unlike AddCluster, it does not write to the datastore when
+// the cluster exists, so that it can be called for every event.
+func (clusterStore eventClusterStore) EnsureCluster(clusterARN string) error {
+	key, clusterJSON, err := clusterStore.marshalClusterAndGenerateKey(clusterARN)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := clusterStore.knownClusters.Load(key); ok {
+		return nil
+	}
+
+	resp, err := clusterStore.datastore.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if len(resp) == 0 {
+		log.Debugf("Cluster store adding cluster: %s", clusterARN)
+		err = clusterStore.datastore.Add(key, clusterJSON)
+		if err != nil {
+			return err
+		}
+	}
+	clusterStore.knownClusters.Store(key, struct{}{})
+	return nil
+}
+
+// GetCluster gets the cluster specified as a cluster ARN, a region qualified
+// cluster name or a cluster name. An AmbiguousCluster error is returned if
+// the cluster name matches clusters in more than one account or region.
+func (clusterStore eventClusterStore) GetCluster(cluster string) (*storetypes.VersionedCluster, error) {
+	identifier, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cluster ARNs identify a single cluster, which is stored under a single key
+	if identifier.Account != "" {
+		key, err := generateClusterKey(cluster)
+		if err != nil {
+			return nil, err
+		}
+		return clusterStore.getClusterByKey(key)
+	}
+
+	clusters, err := clusterStore.ListClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched *storetypes.VersionedCluster
+	for i := range clusters {
+		if !identifier.Matches(aws.StringValue(clusters[i].Cluster.Detail.ClusterARN)) {
+			continue
+		}
+		if matched != nil {
+			return nil, types.NewAmbiguousCluster(errors.Errorf(
+				"Cluster '%s' matches clusters in more than one account or region", cluster))
+		}
+		matched = &clusters[i]
+	}
+	return matched, nil
+}
+
+// ListClusters lists all the clusters existing in the datastore, ordered by ARN
+func (clusterStore eventClusterStore) ListClusters() ([]storetypes.VersionedCluster, error) {
+	clusters, err := clusterStore.getClustersByKeyPrefix(clusterEntityKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return aws.StringValue(clusters[i].Cluster.Detail.ClusterARN) < aws.StringValue(clusters[j].Cluster.Detail.ClusterARN)
+	})
+	return clusters, nil
+}
+
+// StreamClusters streams all changes in the cluster keyspace into a channel
+func (clusterStore eventClusterStore) StreamClusters(ctx context.Context, entityVersion string) (chan storetypes.VersionedCluster, error) {
+	clusterStoreCtx, cancel := context.WithCancel(ctx) // go routine clusterStore.pipeBetweenChannels() handles canceling this context
+
+	dsChan, err := clusterStore.datastore.StreamWithPrefix(clusterStoreCtx, clusterEntityKeyPrefix, entityVersion)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	clusterRespChan := make(chan storetypes.VersionedCluster) // go routine clusterStore.pipeBetweenChannels() handles closing of this channel
+	go clusterStore.pipeBetweenChannels(clusterStoreCtx, cancel, dsChan, clusterRespChan)
+	return clusterRespChan, nil
+}
+
+// DeleteCluster deletes the cluster with ARN 'clusterARN' from the datastore
+func (clusterStore eventClusterStore) DeleteCluster(clusterARN string) error {
+	key, err := generateClusterKey(clusterARN)
+	if err != nil {
+		return err
+	}
+	clusterStore.knownClusters.Delete(key)
+	numKeysDeleted, err := clusterStore.datastore.Delete(key)
+	log.Debugf("Deleted '%d' key(s) from the store for cluster '%s'", numKeysDeleted, clusterARN)
+	return err
+}
+
+func (clusterStore eventClusterStore) marshalClusterAndGenerateKey(clusterARN string) (string, string, error) {
+	key, err := generateClusterKey(clusterARN)
+	if err != nil {
+		return "", "", err
+	}
+
+	clusterName, err := regex.GetClusterNameFromARN(clusterARN)
+	if err != nil {
+		return "", "", err
+	}
+
+	cluster := types.Cluster{
+		Detail: &types.ClusterDetail{
+			ClusterARN:  aws.String(clusterARN),
+			ClusterName: aws.String(clusterName),
+		},
+	}
+	clusterJSON, err := json.Marshal(cluster)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Error marshaling cluster '%s'", clusterARN)
+	}
+	return key, string(clusterJSON), nil
+}
+
+func (clusterStore eventClusterStore) pipeBetweenChannels(ctx context.Context, cancel context.CancelFunc, dsChan chan map[string]storetypes.Entity, clusterRespChan chan storetypes.VersionedCluster) {
+	defer close(clusterRespChan)
+	defer cancel()
+
+	for {
+		select {
+		case resp, ok := <-dsChan:
+			if !ok {
+				return
+			}
+			for _, entity := range resp {
+				var versionedCluster storetypes.VersionedCluster
+				cluster, err := clusterStore.unmarshalCluster(entity.Value)
+				if err != nil {
+					versionedCluster.Err = err
+					clusterRespChan <- versionedCluster
+					return
+				}
+				versionedCluster.Cluster = cluster
+				versionedCluster.Version = entity.Version
+				clusterRespChan <- versionedCluster
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (clusterStore eventClusterStore) getClusterByKey(key string) (*storetypes.VersionedCluster, error) {
+	resp, err := clusterStore.datastore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) == 0 {
+		return nil, nil
+	}
+
+	if len(resp) > 1 {
+		return nil, errors.Errorf("Multiple entries exist in the datastore with key %v", key)
+	}
+
+	var versionedCluster storetypes.VersionedCluster
+	for _, entity := range resp {
+		versionedCluster.Cluster, err = clusterStore.unmarshalCluster(entity.Value)
+		versionedCluster.Version = entity.Version
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	return &versionedCluster, nil
+}
+
+func (clusterStore eventClusterStore) getClustersByKeyPrefix(key string) ([]storetypes.VersionedCluster, error) {
+	resp, err := clusterStore.datastore.GetWithPrefix(key)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]storetypes.VersionedCluster, 0, len(resp))
+	for _, entity := range resp {
+		cluster, err := clusterStore.unmarshalCluster(entity.Value)
+		if err != nil {
+			return nil, err
+		}
+		if cluster.Detail == nil {
+			continue
+		}
+		clusters = append(clusters, storetypes.VersionedCluster{
+			Cluster: cluster,
+			Version: entity.Version,
+		})
+	}
+	return clusters, nil
+}
+
+func (clusterStore eventClusterStore) unmarshalCluster(val string) (types.Cluster, error) {
+	var cluster types.Cluster
+	err := json.Unmarshal([]byte(val), &cluster)
+	if err != nil {
+		return cluster, errors.Wrapf(err, "Error unmarshaling cluster '%s'", val)
+	}
+
+	return cluster, nil
+}
+
+// generateClusterKey returns the key of the cluster with ARN 'clusterARN',
+// '<prefix><account>/<region>/<cluster name>/<ARN>'
+func generateClusterKey(clusterARN string) (string, error) {
+	identifier, err := regex.GetClusterFromARN(clusterARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating cluster key")
+	}
+	return generateEntityKey(clusterEntityKeyPrefix, identifier, clusterARN)
+}
@@ -0,0 +1,301 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	otherRegion              = "us-west-2"
+	clusterARN1InOtherRegion = "arn:aws:ecs:" + otherRegion + ":" + accountID + ":cluster/" + clusterName1
+)
+
+type clusterStoreMockContext struct {
+	mockCtrl     *gomock.Controller
+	datastore    *mocks.MockDataStore
+	cluster1     types.Cluster
+	clusterJSON1 string
+	clusterKey1  string
+	entities     map[string]storetypes.Entity
+}
+
+func NewClusterStoreMockContext(t *testing.T) *clusterStoreMockContext {
+	context := clusterStoreMockContext{}
+	context.mockCtrl = gomock.NewController(t)
+	context.datastore = mocks.NewMockDataStore(context.mockCtrl)
+
+	context.cluster1 = cluster(clusterARN1, clusterName1)
+	context.clusterJSON1 = marshalCluster(t, context.cluster1)
+	context.clusterKey1 = clusterEntityKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + clusterARN1
+
+	clusterKey2 := clusterEntityKeyPrefix + accountID + "/" + region + "/" + clusterName2 + "/" + clusterARN2
+
+	context.entities = map[string]storetypes.Entity{
+		clusterKey2:         setupEntity(clusterKey2, marshalCluster(t, cluster(clusterARN2, clusterName2)), entityVersion),
+		context.clusterKey1: setupEntity(context.clusterKey1, context.clusterJSON1, entityVersion),
+	}
+
+	return &context
+}
+
+func TestClusterStoreNilDatastore(t *testing.T) {
+	_, err := NewClusterStore(nil)
+	assert.Error(t, err, "Expected an error when datastore is nil")
+}
+
+func TestAddClusterInvalidClusterARN(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := clusterStore(t, context).AddCluster("invalidARN")
+	assert.Error(t, err, "Expected an error when cluster ARN is invalid in AddCluster")
+}
+
+func TestAddClusterDataStoreAddReturnsError(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Add(context.clusterKey1, context.clusterJSON1).Return(errors.New("Add failed"))
+
+	err := clusterStore(t, context).AddCluster(clusterARN1)
+	assert.Error(t, err, "Expected an error when datastore add fails")
+}
+
+func TestAddCluster(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Add(context.clusterKey1, context.clusterJSON1).Return(nil)
+
+	err := clusterStore(t, context).AddCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when adding cluster")
+}
+
+func TestEnsureClusterAddsMissingClusterOnce(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	gomock.InOrder(
+		context.datastore.EXPECT().Get(context.clusterKey1).Return(map[string]storetypes.Entity{}, nil),
+		context.datastore.EXPECT().Add(context.clusterKey1, context.clusterJSON1).Return(nil),
+	)
+
+	store := clusterStore(t, context)
+	err := store.EnsureCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when ensuring cluster")
+	err = store.EnsureCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when ensuring known cluster")
+}
+
+func TestEnsureClusterExistingCluster(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.clusterKey1: context.entities[context.clusterKey1]}
+	context.datastore.EXPECT().Get(context.clusterKey1).Return(resp, nil)
+	context.datastore.EXPECT().Add(gomock.Any(), gomock.Any()).Times(0)
+
+	err := clusterStore(t, context).EnsureCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when ensuring existing cluster")
+}
+
+func TestEnsureClusterDataStoreGetReturnsError(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Get(context.clusterKey1).Return(nil, errors.New("Get failed"))
+	context.datastore.EXPECT().Add(gomock.Any(), gomock.Any()).Times(0)
+
+	err := clusterStore(t, context).EnsureCluster(clusterARN1)
+	assert.Error(t, err, "Expected an error when datastore get fails")
+}
+
+func TestEnsureClusterAfterDeleteCluster(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	gomock.InOrder(
+		context.datastore.EXPECT().Add(context.clusterKey1, context.clusterJSON1).Return(nil),
+		context.datastore.EXPECT().Delete(context.clusterKey1).Return(int64(1), nil),
+		context.datastore.EXPECT().Get(context.clusterKey1).Return(map[string]storetypes.Entity{}, nil),
+		context.datastore.EXPECT().Add(context.clusterKey1, context.clusterJSON1).Return(nil),
+	)
+
+	store := clusterStore(t, context)
+	assert.NoError(t, store.AddCluster(clusterARN1), "Unexpected error when adding cluster")
+	assert.NoError(t, store.DeleteCluster(clusterARN1), "Unexpected error when deleting cluster")
+	assert.NoError(t, store.EnsureCluster(clusterARN1), "Unexpected error when ensuring deleted cluster")
+}
+
+func TestGetClusterByARN(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.clusterKey1: context.entities[context.clusterKey1]}
+	context.datastore.EXPECT().Get(context.clusterKey1).Return(resp, nil)
+
+	cluster, err := clusterStore(t, context).GetCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when getting cluster")
+	assert.Equal(t, context.cluster1, cluster.Cluster, "Unexpected cluster")
+	assert.Equal(t, entityVersion, cluster.Version, "Unexpected cluster version")
+}
+
+func TestGetClusterByARNNotFound(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Get(context.clusterKey1).Return(map[string]storetypes.Entity{}, nil)
+
+	cluster, err := clusterStore(t, context).GetCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when getting cluster that does not exist")
+	assert.Nil(t, cluster, "Expected a nil cluster when it does not exist")
+}
+
+func TestGetClusterByName(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(clusterEntityKeyPrefix).Return(context.entities, nil)
+
+	cluster, err := clusterStore(t, context).GetCluster(clusterName1)
+	assert.NoError(t, err, "Unexpected error when getting cluster by name")
+	assert.Equal(t, context.cluster1, cluster.Cluster, "Unexpected cluster")
+}
+
+func TestGetClusterByNameInMultipleRegions(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	otherKey := clusterEntityKeyPrefix + accountID + "/" + otherRegion + "/" + clusterName1 + "/" + clusterARN1InOtherRegion
+	context.entities[otherKey] = setupEntity(otherKey, marshalCluster(t, cluster(clusterARN1InOtherRegion, clusterName1)), entityVersion)
+	context.datastore.EXPECT().GetWithPrefix(clusterEntityKeyPrefix).Return(context.entities, nil).Times(2)
+
+	_, err := clusterStore(t, context).GetCluster(clusterName1)
+	assert.Error(t, err, "Expected an error when cluster name matches clusters in multiple regions")
+	_, ok := err.(types.AmbiguousCluster)
+	assert.True(t, ok, "Expected error of type AmbiguousCluster")
+
+	cluster, err := clusterStore(t, context).GetCluster(otherRegion + ":" + clusterName1)
+	assert.NoError(t, err, "Unexpected error when getting cluster by region qualified name")
+	assert.Equal(t, clusterARN1InOtherRegion, aws.StringValue(cluster.Cluster.Detail.ClusterARN), "Unexpected cluster")
+}
+
+func TestGetClusterInvalidCluster(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := clusterStore(t, context).GetCluster("cluster/name")
+	assert.Error(t, err, "Expected an error when cluster is invalid")
+}
+
+func TestListClustersOrderedByARN(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(clusterEntityKeyPrefix).Return(context.entities, nil)
+
+	clusters, err := clusterStore(t, context).ListClusters()
+	assert.NoError(t, err, "Unexpected error when listing clusters")
+	assert.Len(t, clusters, 2, "Expected two clusters")
+	assert.Equal(t, clusterARN1, aws.StringValue(clusters[0].Cluster.Detail.ClusterARN), "Expected clusters ordered by ARN")
+	assert.Equal(t, clusterARN2, aws.StringValue(clusters[1].Cluster.Detail.ClusterARN), "Expected clusters ordered by ARN")
+}
+
+func TestListClustersDataStoreReturnsError(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(clusterEntityKeyPrefix).Return(nil, errors.New("GetWithPrefix failed"))
+
+	_, err := clusterStore(t, context).ListClusters()
+	assert.Error(t, err, "Expected an error when datastore GetWithPrefix fails")
+}
+
+func TestStreamClustersDataStoreStreamReturnsError(t *testing.T) {
+	ctx := NewClusterStoreMockContext(t)
+	defer ctx.mockCtrl.Finish()
+
+	tstCtx := context.Background()
+	ctx.datastore.EXPECT().StreamWithPrefix(gomock.Any(), clusterEntityKeyPrefix, entityVersion).Return(nil, errors.New("StreamWithPrefix failed"))
+
+	_, err := clusterStore(t, ctx).StreamClusters(tstCtx, entityVersion)
+	assert.Error(t, err, "Expected an error when datastore StreamWithPrefix returns an error")
+}
+
+func TestStreamClusters(t *testing.T) {
+	ctx := NewClusterStoreMockContext(t)
+	defer ctx.mockCtrl.Finish()
+
+	tstCtx := context.Background()
+	dsChan := make(chan map[string]storetypes.Entity)
+	defer close(dsChan)
+	ctx.datastore.EXPECT().StreamWithPrefix(gomock.Any(), clusterEntityKeyPrefix, "").Return(dsChan, nil)
+
+	clusterRespChan, err := clusterStore(t, ctx).StreamClusters(tstCtx, "")
+	assert.NoError(t, err, "Unexpected error when calling stream clusters")
+
+	go func() {
+		dsChan <- map[string]storetypes.Entity{ctx.clusterKey1: ctx.entities[ctx.clusterKey1]}
+	}()
+	clusterResp := <-clusterRespChan
+	assert.NoError(t, clusterResp.Err, "Unexpected error in cluster response")
+	assert.Equal(t, ctx.cluster1, clusterResp.Cluster, "Unexpected cluster in stream")
+	assert.Equal(t, entityVersion, clusterResp.Version, "Unexpected cluster version in stream")
+}
+
+func TestDeleteCluster(t *testing.T) {
+	context := NewClusterStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Delete(context.clusterKey1).Return(int64(1), nil)
+
+	err := clusterStore(t, context).DeleteCluster(clusterARN1)
+	assert.NoError(t, err, "Unexpected error when deleting cluster")
+}
+
+func clusterStore(t *testing.T, context *clusterStoreMockContext) ClusterStore {
+	clusterStore, err := NewClusterStore(context.datastore)
+	if err != nil {
+		t.Error("Unexpected error when calling NewClusterStore")
+	}
+	return clusterStore
+}
+
+func cluster(clusterARN string, clusterName string) types.Cluster {
+	return types.Cluster{
+		Detail: &types.ClusterDetail{
+			ClusterARN:  aws.String(clusterARN),
+			ClusterName: aws.String(clusterName),
+		},
+	}
+}
+
+func marshalCluster(t *testing.T, cluster types.Cluster) string {
+	clusterJSON, err := json.Marshal(cluster)
+	if err != nil {
+		t.Error("Failed to marshal cluster: ", err)
+	}
+	return string(clusterJSON)
+}
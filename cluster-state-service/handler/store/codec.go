@@ -0,0 +1,164 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+// Records are stored either as the raw JSON of the events they were read from
+// or encoded by a codec. An encoded record starts with a header made of
+// recordMagic, the ID of its codec and the version of the record as a
+// big-endian int64, followed by the record as encoded by the codec. JSON
+// never starts with recordMagic, so values without a header, such as records
+// written before codecs were introduced, tombstones and histories, are read
+// as they are.
+
+const (
+	// CodecJSON stores records as the raw JSON of their events
+	CodecJSON = "json"
+	// CodecFlate stores records as DEFLATE compressed JSON behind a header
+	CodecFlate = "flate"
+
+	recordMagic        = byte(0)
+	flateCodecID       = byte(1)
+	recordHeaderLength = 10
+)
+
+// Codec encodes records before they are written to the store. Records are
+// decoded by the codec identified by their header when they are read.
+type Codec interface {
+	// Name returns the name of the codec
+	Name() string
+	// Encode encodes the JSON of a record with version 'version'
+	Encode(recordJSON string, version int64) (string, error)
+}
+
+// NewCodec returns the codec named 'name'
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case CodecJSON:
+		return jsonCodec{}, nil
+	case CodecFlate:
+		return flateCodec{}, nil
+	default:
+		return nil, errors.Errorf("Unknown codec '%s'", name)
+	}
+}
+
+// jsonCodec stores records as they are. Records without a header stay readable
+// by versions of the service from before codecs, at the cost of decoding the
+// whole record to read its version.
+type jsonCodec struct{}
+
+func (codec jsonCodec) Name() string {
+	return CodecJSON
+}
+
+func (codec jsonCodec) Encode(recordJSON string, version int64) (string, error) {
+	return recordJSON, nil
+}
+
+// flateCodec stores records as DEFLATE compressed JSON
+type flateCodec struct{}
+
+func (codec flateCodec) Name() string {
+	return CodecFlate
+}
+
+func (codec flateCodec) Encode(recordJSON string, version int64) (string, error) {
+	var buf bytes.Buffer
+	buf.Write(recordHeader(flateCodecID, version))
+
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not initialize the flate codec")
+	}
+	_, err = writer.Write([]byte(recordJSON))
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not compress record")
+	}
+	return buf.String(), nil
+}
+
+func recordHeader(codecID byte, version int64) []byte {
+	header := make([]byte, recordHeaderLength)
+	header[0] = recordMagic
+	header[1] = codecID
+	binary.BigEndian.PutUint64(header[2:], uint64(version))
+	return header
+}
+
+// hasRecordHeader returns true if 'value' is a record encoded by a codec
+func hasRecordHeader(value string) bool {
+	return len(value) > 0 && value[0] == recordMagic
+}
+
+// storedCodec returns the name of the codec that 'value' is stored with
+func storedCodec(value string) (string, error) {
+	if !hasRecordHeader(value) {
+		return CodecJSON, nil
+	}
+	if len(value) < recordHeaderLength {
+		return "", errors.New("Record header is truncated")
+	}
+	switch value[1] {
+	case flateCodecID:
+		return CodecFlate, nil
+	default:
+		return "", errors.Errorf("Unknown codec ID %d in record header", value[1])
+	}
+}
+
+// decodeRecord returns the JSON of the record stored as 'value'
+func decodeRecord(value string) (string, error) {
+	codec, err := storedCodec(value)
+	if err != nil {
+		return "", err
+	}
+	switch codec {
+	case CodecFlate:
+		reader := flate.NewReader(bytes.NewReader([]byte(value[recordHeaderLength:])))
+		defer reader.Close()
+		recordJSON, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return "", errors.Wrapf(err, "Could not decompress record")
+		}
+		return string(recordJSON), nil
+	default:
+		return value, nil
+	}
+}
+
+// decodeRecordVersion returns the version of the record stored as 'value'.
+// The version of an encoded record is read from its header without decoding
+// the record.
+func decodeRecordVersion(record types.Record, value string) (int64, error) {
+	if !hasRecordHeader(value) {
+		return record.GetVersion(value)
+	}
+	if _, err := storedCodec(value); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64([]byte(value[2:recordHeaderLength]))), nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// codecMigrationPageSize is the number of records read at a time while
+	// migrating records to a codec
+	codecMigrationPageSize = 500
+)
+
+// codecMigrationPrefixes are the prefixes of the keys of encoded records, along
+// with the kind of record stored under each
+var codecMigrationPrefixes = []struct {
+	keyPrefix string
+	record    types.Record
+}{
+	{taskKeyPrefix, types.Task{}},
+	{instanceKeyPrefix, types.ContainerInstance{}},
+}
+
+// MigrateRecordCodec re-encodes the tasks and container instances stored with
+// a codec other than 'codec', so that records written before the codec was
+// configured are stored compactly as well. A record that changes while it is
+// migrated is left as it is, since it was written with 'codec'. It returns the
+// number of records that were migrated and is safe to run more than once and
+// alongside writes.
+func MigrateRecordCodec(ctx context.Context, etcdTXStore EtcdTXStore, codec Codec, requestTimeout time.Duration) (int, error) {
+	if etcdTXStore == nil {
+		return 0, errors.New("Etcd transactional store is not initialized")
+	}
+	kv := etcdCodecMigrationKV{
+		kv:             etcdTXStore.GetV3Client(),
+		requestTimeout: requestTimeout,
+	}
+	return migrateRecordCodec(ctx, kv, codec, codecMigrationPageSize)
+}
+
+func migrateRecordCodec(ctx context.Context, kv codecMigrationKV, codec Codec, pageSize int) (int, error) {
+	if codec == nil {
+		return 0, errors.New("Codec is not initialized")
+	}
+
+	migrated := 0
+	for _, prefix := range codecMigrationPrefixes {
+		fromKey := prefix.keyPrefix
+		for {
+			records, err := kv.list(ctx, prefix.keyPrefix, fromKey, pageSize)
+			if err != nil {
+				return migrated, err
+			}
+
+			for _, record := range records {
+				ok, err := migrateRecord(ctx, kv, codec, prefix.record, record)
+				if err != nil {
+					log.Warnf("Not migrating record '%s' to codec %s: %v", record.key, codec.Name(), err)
+					continue
+				}
+				if ok {
+					migrated++
+				}
+			}
+
+			if len(records) < pageSize {
+				break
+			}
+			fromKey = records[len(records)-1].key + "\x00"
+		}
+	}
+	return migrated, nil
+}
+
+// migrateRecord re-encodes 'stored' with 'codec' unless it already is,
+// returning true if it was replaced
+func migrateRecord(ctx context.Context, kv codecMigrationKV, codec Codec, record types.Record, stored storedRecord) (bool, error) {
+	storedWith, err := storedCodec(stored.value)
+	if err != nil {
+		return false, err
+	}
+	if storedWith == codec.Name() {
+		return false, nil
+	}
+
+	recordJSON, err := decodeRecord(stored.value)
+	if err != nil {
+		return false, err
+	}
+	version, err := decodeRecordVersion(record, stored.value)
+	if err != nil {
+		return false, err
+	}
+	value, err := codec.Encode(recordJSON, version)
+	if err != nil {
+		return false, err
+	}
+	return kv.replace(ctx, stored.key, value, stored.modRevision)
+}
+
+// storedRecord is a record as it is stored, along with the revision it was
+// last modified at
+type storedRecord struct {
+	key         string
+	value       string
+	modRevision int64
+}
+
+// codecMigrationKV reads and replaces the records migrated to a codec
+type codecMigrationKV interface {
+	// list returns up to 'limit' records with keys starting with 'keyPrefix',
+	// from 'fromKey' on, in key order
+	list(ctx context.Context, keyPrefix string, fromKey string, limit int) ([]storedRecord, error)
+	// replace stores 'value' at 'key' if the key was last modified at
+	// 'modRevision', returning false if it was modified since
+	replace(ctx context.Context, key string, value string, modRevision int64) (bool, error)
+}
+
+// etcdCodecMigrationKV reads and replaces the records migrated to a codec in
+// etcd
+type etcdCodecMigrationKV struct {
+	kv             clientv3.KV
+	requestTimeout time.Duration
+}
+
+func (etcdKV etcdCodecMigrationKV) list(ctx context.Context, keyPrefix string, fromKey string, limit int) ([]storedRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdKV.requestTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := etcdKV.kv.Get(ctx, fromKey,
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(keyPrefix)),
+		clientv3.WithLimit(int64(limit)))
+	metrics.ObserveEtcdRequest(getWithPrefixOperation, start, err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not list records with prefix '%s'", keyPrefix)
+	}
+
+	records := make([]storedRecord, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		records[i] = storedRecord{
+			key:         string(kv.Key),
+			value:       string(kv.Value),
+			modRevision: kv.ModRevision,
+		}
+	}
+	return records, nil
+}
+
+func (etcdKV etcdCodecMigrationKV) replace(ctx context.Context, key string, value string, modRevision int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdKV.requestTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := etcdKV.kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(clientv3.OpPut(key, value)).
+		Commit()
+	metrics.ObserveEtcdRequest(txnOperation, start, err)
+	if err != nil {
+		return false, errors.Wrapf(err, "Could not replace record '%s'", key)
+	}
+	return resp.Succeeded, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCodecMigrationKV keeps the records migrated to a codec in memory
+type fakeCodecMigrationKV struct {
+	records map[string]storedRecord
+	listErr error
+	// modified are the keys that are modified before they are replaced
+	modified map[string]bool
+	lists    int
+}
+
+func newFakeCodecMigrationKV(values map[string]string) *fakeCodecMigrationKV {
+	kv := &fakeCodecMigrationKV{
+		records:  make(map[string]storedRecord),
+		modified: make(map[string]bool),
+	}
+	for key, value := range values {
+		kv.records[key] = storedRecord{key: key, value: value, modRevision: 1}
+	}
+	return kv
+}
+
+func (kv *fakeCodecMigrationKV) list(ctx context.Context, keyPrefix string, fromKey string, limit int) ([]storedRecord, error) {
+	kv.lists++
+	if kv.listErr != nil {
+		return nil, kv.listErr
+	}
+	var keys []string
+	for key := range kv.records {
+		if strings.HasPrefix(key, keyPrefix) && key >= fromKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	records := make([]storedRecord, len(keys))
+	for i, key := range keys {
+		records[i] = kv.records[key]
+	}
+	return records, nil
+}
+
+func (kv *fakeCodecMigrationKV) replace(ctx context.Context, key string, value string, modRevision int64) (bool, error) {
+	if kv.modified[key] || kv.records[key].modRevision != modRevision {
+		return false, nil
+	}
+	kv.records[key] = storedRecord{key: key, value: value, modRevision: modRevision + 1}
+	return true, nil
+}
+
+const (
+	migrationTaskJSON     = `{"detail":{"version":3}}`
+	migrationInstanceJSON = `{"detail":{"version":4}}`
+)
+
+func TestMigrateRecordCodecNilCodec(t *testing.T) {
+	_, err := migrateRecordCodec(context.Background(), newFakeCodecMigrationKV(nil), nil, codecMigrationPageSize)
+	assert.Error(t, err, "Expected an error when codec is nil")
+}
+
+func TestMigrateRecordCodecEncodesRecords(t *testing.T) {
+	encodedTask, err := flateCodec{}.Encode(migrationTaskJSON, 3)
+	assert.NoError(t, err, "Unexpected error encoding record")
+	kv := newFakeCodecMigrationKV(map[string]string{
+		taskKeyPrefix + "a":     migrationTaskJSON,
+		taskKeyPrefix + "b":     encodedTask,
+		taskKeyPrefix + "c":     migrationTaskJSON,
+		instanceKeyPrefix + "a": migrationInstanceJSON,
+		historyKeyPrefix + "a":  "[]",
+	})
+
+	migrated, err := migrateRecordCodec(context.Background(), kv, flateCodec{}, 2)
+	assert.NoError(t, err, "Unexpected error migrating records")
+	assert.Equal(t, 3, migrated, "Expected the records stored as JSON to be migrated")
+
+	for key, record := range kv.records {
+		if key == historyKeyPrefix+"a" {
+			assert.Equal(t, "[]", record.value, "Unexpected migration of a history")
+			continue
+		}
+		codec, err := storedCodec(record.value)
+		assert.NoError(t, err, "Unexpected error reading the codec of %s", key)
+		assert.Equal(t, CodecFlate, codec, "Expected %s to be migrated", key)
+	}
+	decoded, err := decodeRecord(kv.records[instanceKeyPrefix+"a"].value)
+	assert.NoError(t, err, "Unexpected error decoding migrated record")
+	assert.Equal(t, migrationInstanceJSON, decoded, "Unexpected migrated record")
+	version, err := decodeRecordVersion(SampleRecord{}, kv.records[instanceKeyPrefix+"a"].value)
+	assert.NoError(t, err, "Unexpected error decoding the version of the migrated record")
+	assert.Equal(t, int64(4), version, "Unexpected version of the migrated record")
+}
+
+func TestMigrateRecordCodecBackToJSON(t *testing.T) {
+	encodedTask, err := flateCodec{}.Encode(migrationTaskJSON, 3)
+	assert.NoError(t, err, "Unexpected error encoding record")
+	kv := newFakeCodecMigrationKV(map[string]string{taskKeyPrefix + "a": encodedTask})
+
+	migrated, err := migrateRecordCodec(context.Background(), kv, jsonCodec{}, codecMigrationPageSize)
+	assert.NoError(t, err, "Unexpected error migrating records")
+	assert.Equal(t, 1, migrated, "Expected the encoded record to be migrated")
+	assert.Equal(t, migrationTaskJSON, kv.records[taskKeyPrefix+"a"].value, "Expected the record to be stored as JSON")
+}
+
+func TestMigrateRecordCodecSkipsModifiedAndInvalidRecords(t *testing.T) {
+	kv := newFakeCodecMigrationKV(map[string]string{
+		taskKeyPrefix + "a": migrationTaskJSON,
+		taskKeyPrefix + "b": "invalidJSON",
+		taskKeyPrefix + "c": migrationTaskJSON,
+	})
+	kv.modified[taskKeyPrefix+"a"] = true
+
+	migrated, err := migrateRecordCodec(context.Background(), kv, flateCodec{}, codecMigrationPageSize)
+	assert.NoError(t, err, "Unexpected error migrating records")
+	assert.Equal(t, 1, migrated, "Expected only the unmodified valid record to be migrated")
+	assert.Equal(t, migrationTaskJSON, kv.records[taskKeyPrefix+"a"].value, "Unexpected migration of a modified record")
+	assert.Equal(t, "invalidJSON", kv.records[taskKeyPrefix+"b"].value, "Unexpected migration of an invalid record")
+}
+
+func TestMigrateRecordCodecListError(t *testing.T) {
+	kv := newFakeCodecMigrationKV(nil)
+	kv.listErr = errors.New("List failed")
+
+	_, err := migrateRecordCodec(context.Background(), kv, flateCodec{}, codecMigrationPageSize)
+	assert.Error(t, err, "Expected an error when listing records fails")
+}
+
+func TestMigrateRecordCodecNilEtcdTXStore(t *testing.T) {
+	_, err := MigrateRecordCodec(context.Background(), nil, flateCodec{}, testTimeouts.Request)
+	assert.Error(t, err, "Expected an error when etcd transactional store is nil")
+}
@@ -0,0 +1,89 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCodec(t *testing.T) {
+	for _, name := range []string{CodecJSON, CodecFlate} {
+		codec, err := NewCodec(name)
+		assert.NoError(t, err, "Unexpected error creating codec %s", name)
+		assert.Equal(t, name, codec.Name(), "Unexpected codec name")
+	}
+
+	_, err := NewCodec("protobuf")
+	assert.Error(t, err, "Expected an error creating an unknown codec")
+}
+
+func TestJSONCodecStoresRecordAsIs(t *testing.T) {
+	recordJSON := generateRecordWithVersion(t, 3)
+	encoded, err := jsonCodec{}.Encode(recordJSON, 3)
+	assert.NoError(t, err, "Unexpected error encoding record")
+	assert.Equal(t, recordJSON, encoded, "Expected the record to be stored as is")
+
+	decoded, err := decodeRecord(encoded)
+	assert.NoError(t, err, "Unexpected error decoding record")
+	assert.Equal(t, recordJSON, decoded, "Unexpected decoded record")
+}
+
+func TestFlateCodecRoundTrip(t *testing.T) {
+	recordJSON := `{"detail":{"version":3,"containers":[` + strings.Repeat(`{"name":"container"},`, 50) + `{}]}}`
+	encoded, err := flateCodec{}.Encode(recordJSON, 3)
+	assert.NoError(t, err, "Unexpected error encoding record")
+	assert.True(t, len(encoded) < len(recordJSON), "Expected the encoded record to be smaller")
+
+	codec, err := storedCodec(encoded)
+	assert.NoError(t, err, "Unexpected error reading the codec of the record")
+	assert.Equal(t, CodecFlate, codec, "Unexpected codec of the record")
+
+	decoded, err := decodeRecord(encoded)
+	assert.NoError(t, err, "Unexpected error decoding record")
+	assert.Equal(t, recordJSON, decoded, "Unexpected decoded record")
+}
+
+func TestDecodeRecordVersionFromHeader(t *testing.T) {
+	// the version in the header is used without looking at the record
+	encoded, err := flateCodec{}.Encode("not a record", 7)
+	assert.NoError(t, err, "Unexpected error encoding record")
+
+	version, err := decodeRecordVersion(SampleRecord{}, encoded)
+	assert.NoError(t, err, "Unexpected error decoding the version of the record")
+	assert.Equal(t, int64(7), version, "Unexpected version of the record")
+}
+
+func TestDecodeRecordVersionWithoutHeader(t *testing.T) {
+	version, err := decodeRecordVersion(SampleRecord{}, generateRecordWithVersion(t, 5))
+	assert.NoError(t, err, "Unexpected error decoding the version of the record")
+	assert.Equal(t, int64(5), version, "Unexpected version of the record")
+}
+
+func TestDecodeRecordInvalidHeader(t *testing.T) {
+	invalid := map[string]string{
+		"truncated header": string([]byte{recordMagic, flateCodecID, 0}),
+		"unknown codec":    string(recordHeader(42, 1)) + "record",
+		"corrupt payload":  string(recordHeader(flateCodecID, 1)) + "record",
+	}
+	for name, value := range invalid {
+		_, err := decodeRecord(value)
+		assert.Error(t, err, "Expected an error decoding a record with %s", name)
+	}
+
+	_, err := decodeRecordVersion(SampleRecord{}, string(recordHeader(42, 1)))
+	assert.Error(t, err, "Expected an error decoding the version of a record with an unknown codec")
+}
@@ -17,7 +17,9 @@ var (
 	clusterName1  = "cluster1"
 	clusterName2  = "cluster2"
 	clusterName3  = "cluster3"
-	clusterARN1   = "arn:aws:ecs:us-east-1:123456789123:cluster/" + clusterName1
-	clusterARN2   = "arn:aws:ecs:us-east-1:123456789123:cluster/" + clusterName2
+	accountID     = "123456789123"
+	region        = "us-east-1"
+	clusterARN1   = "arn:aws:ecs:" + region + ":" + accountID + ":cluster/" + clusterName1
+	clusterARN2   = "arn:aws:ecs:" + region + ":" + accountID + ":cluster/" + clusterName2
 	entityVersion = "123"
 )
@@ -18,9 +18,11 @@ import (
 	"time"
 
 	"github.com/goguardian/blox/cluster-state-service/handler/clients"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/goguardian/blox/cluster-state-service/handler/regex"
 	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	"github.com/pkg/errors"
@@ -28,33 +30,50 @@ import (
 )
 
 const (
-	// requestTimeout is timeout set when calling etcd APIs.
-	// This timeout is set to 1 minute to support list APIs
-	// with prefix match
-	requestTimeout    = 1 * time.Minute
-	streamIdleTimeout = 1 * time.Hour
+	// Operations that etcd request latencies are labeled with
+	putOperation           = "put"
+	getOperation           = "get"
+	getWithPrefixOperation = "get_prefix"
+	deleteOperation        = "delete"
+	txnOperation           = "txn"
 )
 
 // DataStore defines methods to access the database
 type DataStore interface {
 	GetWithPrefix(keyPrefix string) (map[string]storetypes.Entity, error)
+	GetWithPrefixAtRevision(keyPrefix string, revision int64) (map[string]storetypes.Entity, int64, error)
 	Get(key string) (map[string]storetypes.Entity, error)
 	Add(key string, value string) error
 	StreamWithPrefix(ctx context.Context, keyPrefix string, entityVersion string) (chan map[string]storetypes.Entity, error)
 	Delete(key string) (int64, error)
+	DeleteIfUnmodified(key string, version string) (bool, error)
+}
+
+// Timeouts bound how long the data store waits on etcd
+type Timeouts struct {
+	// Request is the timeout of etcd requests. It has to be long enough to
+	// support list APIs with prefix match.
+	Request time.Duration
+	// StreamIdle is how long a stream may go without changes before it is closed
+	StreamIdle time.Duration
 }
 
 type etcdDataStore struct {
 	etcdInterface clients.EtcdInterface
+	timeouts      Timeouts
 }
 
 // NewDataStore initializes the etcdDataStore struct
-func NewDataStore(etcdInterface clients.EtcdInterface) (DataStore, error) {
+func NewDataStore(etcdInterface clients.EtcdInterface, timeouts Timeouts) (DataStore, error) {
 	if etcdInterface == nil {
 		return nil, errors.Errorf("Invalid etcd input")
 	}
+	if timeouts.Request <= 0 || timeouts.StreamIdle <= 0 {
+		return nil, errors.Errorf("Invalid timeouts: %+v", timeouts)
+	}
 	return &etcdDataStore{
 		etcdInterface: etcdInterface,
+		timeouts:      timeouts,
 	}, nil
 }
 
@@ -68,8 +87,10 @@ func (datastore etcdDataStore) Add(key string, value string) error {
 		return errors.Errorf("Value cannot be empty while adding data into datastore")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	start := time.Now()
 	_, err := datastore.etcdInterface.Put(ctx, key, value)
+	metrics.ObserveEtcdRequest(putOperation, start, err)
 	defer cancel()
 
 	if err != nil {
@@ -85,15 +106,52 @@ func (datastore etcdDataStore) GetWithPrefix(keyPrefix string) (map[string]store
 		return nil, errors.New("Key prefix cannot be empty while getting data from datastore by prefix")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	start := time.Now()
 	resp, err := datastore.etcdInterface.Get(ctx, keyPrefix, clientv3.WithPrefix())
+	metrics.ObserveEtcdRequest(getWithPrefixOperation, start, err)
 	defer cancel()
 
 	if err != nil {
 		return nil, handleEtcdError(err)
 	}
 
-	return handleGetResponse(resp), nil
+	return handleGetResponse(resp)
+}
+
+// GetWithPrefixAtRevision returns a map of the key-value pairs where the key
+// starts with keyPrefix as they were at etcd revision 'revision', along with
+// that revision. Revision 0 reads the latest state and returns the revision it
+// was read at.
+func (datastore etcdDataStore) GetWithPrefixAtRevision(keyPrefix string, revision int64) (map[string]storetypes.Entity, int64, error) {
+	if len(keyPrefix) == 0 {
+		return nil, 0, errors.New("Key prefix cannot be empty while getting data from datastore by prefix")
+	}
+	if revision < 0 {
+		return nil, 0, errors.Errorf("Invalid revision %d while getting data from datastore by prefix", revision)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	start := time.Now()
+	resp, err := datastore.etcdInterface.Get(ctx, keyPrefix, clientv3.WithPrefix(), clientv3.WithRev(revision))
+	metrics.ObserveEtcdRequest(getWithPrefixOperation, start, err)
+	defer cancel()
+
+	if err != nil {
+		if err == rpctypes.ErrCompacted || err == rpctypes.ErrFutureRev {
+			return nil, 0, types.NewOutOfRangeEntityVersion(err)
+		}
+		return nil, 0, handleEtcdError(err)
+	}
+
+	if revision == 0 && resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+	}
+	kv, err := handleGetResponse(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return kv, revision, nil
 }
 
 // Get returns a map with one key-value pair where the key matches the provided key
@@ -102,15 +160,17 @@ func (datastore etcdDataStore) Get(key string) (map[string]storetypes.Entity, er
 		return nil, errors.New("Key cannot be empty while getting data from datastore by key")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	start := time.Now()
 	resp, err := datastore.etcdInterface.Get(ctx, key)
+	metrics.ObserveEtcdRequest(getOperation, start, err)
 	defer cancel()
 
 	if err != nil {
 		return nil, handleEtcdError(err)
 	}
 
-	return handleGetResponse(resp), nil
+	return handleGetResponse(resp)
 }
 
 // StreamWithPrefix starts a go routine that streams key-value pairs whose keys start with keyPrefix into the channel returned
@@ -148,8 +208,10 @@ func (datastore etcdDataStore) Delete(key string) (int64, error) {
 		return 0, errors.New("Key cannot be empty while deleting data from datastore by key")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	start := time.Now()
 	resp, err := datastore.etcdInterface.Delete(ctx, key)
+	metrics.ObserveEtcdRequest(deleteOperation, start, err)
 	defer cancel()
 
 	if err != nil {
@@ -162,8 +224,35 @@ func (datastore etcdDataStore) Delete(key string) (int64, error) {
 	return resp.Deleted, nil
 }
 
+// DeleteIfUnmodified deletes the key-value pair with the provided key if it was
+// last modified at entity version 'version'. It returns false if the key was
+// modified or deleted since.
+func (datastore etcdDataStore) DeleteIfUnmodified(key string, version string) (bool, error) {
+	if len(key) == 0 {
+		return false, errors.New("Key cannot be empty while deleting data from datastore by key")
+	}
+	modRevision, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return false, errors.Wrapf(err, "Invalid version '%s' of key '%s'", version, key)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), datastore.timeouts.Request)
+	defer cancel()
+	start := time.Now()
+	resp, err := datastore.etcdInterface.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+	metrics.ObserveEtcdRequest(txnOperation, start, err)
+	if err != nil {
+		return false, handleEtcdError(err)
+	}
+	return resp.Succeeded, nil
+}
+
 func (datastore etcdDataStore) stream(ctx context.Context, keyPrefix string, entityVersion string, kvChan chan map[string]storetypes.Entity) {
 	defer close(kvChan)
+	defer metrics.StreamOpened()()
 
 	etcdCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -178,7 +267,7 @@ func (datastore etcdDataStore) stream(ctx context.Context, keyPrefix string, ent
 	}
 
 	watchChan := datastore.etcdInterface.Watch(etcdCtx, keyPrefix, clientv3.WithPrefix(), clientv3.WithRev(revision))
-	streamIdleTimer := time.NewTimer(streamIdleTimeout)
+	streamIdleTimer := time.NewTimer(datastore.timeouts.StreamIdle)
 	defer streamIdleTimer.Stop()
 
 	for {
@@ -187,7 +276,7 @@ func (datastore etcdDataStore) stream(ctx context.Context, keyPrefix string, ent
 			if !ok {
 				return
 			}
-			resetStreamIdleTimer(streamIdleTimer)
+			resetStreamIdleTimer(streamIdleTimer, datastore.timeouts.StreamIdle)
 			for _, ev := range event.Events {
 				// Skip empty events, such as Etcd deletes.
 				// TODO: Look into whether we should return something here.
@@ -195,9 +284,14 @@ func (datastore etcdDataStore) stream(ctx context.Context, keyPrefix string, ent
 					continue
 				}
 
+				value, err := decodeRecord(string(ev.Kv.Value))
+				if err != nil {
+					log.Errorf("Skipping undecodable value of key %s in stream: %+v", ev.Kv.Key, err)
+					continue
+				}
 				entity := storetypes.Entity{
 					Key: string(ev.Kv.Key),
-					Value: string(ev.Kv.Value),
+					Value: value,
 					Version: strconv.FormatInt(ev.Kv.ModRevision, 10),
 				}
 				kv := map[string]storetypes.Entity{string(ev.Kv.Key): entity}
@@ -214,33 +308,39 @@ func (datastore etcdDataStore) stream(ctx context.Context, keyPrefix string, ent
 	}
 }
 
-func resetStreamIdleTimer(t *time.Timer) {
+func resetStreamIdleTimer(t *time.Timer, timeout time.Duration) {
 	if !t.Stop() {
 		<-t.C
 	}
-	t.Reset(streamIdleTimeout)
+	t.Reset(timeout)
 }
 
-func handleGetResponse(resp *clientv3.GetResponse) map[string]storetypes.Entity {
+// handleGetResponse returns the entities in 'resp', decoding records that
+// are stored encoded
+func handleGetResponse(resp *clientv3.GetResponse) (map[string]storetypes.Entity, error) {
 	kv := make(map[string]storetypes.Entity)
 
 	if resp == nil || resp.Kvs == nil {
-		return kv
+		return kv, nil
 	}
 
 	// response.Key = The object's key in Etcd.
 	// response.Value = The object's value in Etcd.
 	// response.ModRevision = The object's last modification identifier in Etcd (incrementing integer for every change in Etcd).
 	for _, response := range resp.Kvs {
+		value, err := decodeRecord(string(response.Value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not decode the value of key %s", response.Key)
+		}
 		entity := storetypes.Entity{
 			Key: string(response.Key),
-			Value: string(response.Value),
+			Value: value,
 			Version: strconv.FormatInt(response.ModRevision, 10),
 		}
 		kv[string(response.Key)] = entity
 	}
 
-	return kv
+	return kv, nil
 }
 
 func handleEtcdError(err error) error {
@@ -23,6 +23,7 @@ import (
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	etcd "github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
 	mvccpb "github.com/coreos/etcd/mvcc/mvccpb"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
@@ -40,6 +41,8 @@ const (
 	anotherVersion = int64(124)
 )
 
+var testTimeouts = Timeouts{Request: time.Minute, StreamIdle: time.Hour}
+
 type DataStoreTestSuite struct {
 	suite.Suite
 	etcdInterface *mocks.MockEtcdInterface
@@ -51,6 +54,7 @@ func (testSuite *DataStoreTestSuite) SetupTest() {
 	testSuite.etcdInterface = mocks.NewMockEtcdInterface(mockCtrl)
 	testSuite.datastore = &etcdDataStore{
 		etcdInterface: testSuite.etcdInterface,
+		timeouts:      testTimeouts,
 	}
 }
 
@@ -59,10 +63,15 @@ func TestDataStoreTestSuite(t *testing.T) {
 }
 
 func (testSuite *DataStoreTestSuite) TestNewDataStoreEmptyEtcd() {
-	_, err := NewDataStore(nil)
+	_, err := NewDataStore(nil, testTimeouts)
 	assert.Error(testSuite.T(), err, "Expected an error when etcd client is nil")
 }
 
+func (testSuite *DataStoreTestSuite) TestNewDataStoreInvalidTimeouts() {
+	_, err := NewDataStore(testSuite.etcdInterface, Timeouts{Request: time.Minute})
+	assert.Error(testSuite.T(), err, "Expected an error when the stream idle timeout is not set")
+}
+
 func (testSuite *DataStoreTestSuite) TestAddEmptyKey() {
 	err := testSuite.datastore.Add("", "test")
 	assert.Error(testSuite.T(), err, "Expected an error when key is nil")
@@ -158,6 +167,60 @@ func (testSuite *DataStoreTestSuite) TestGetWithPrefixEtcd() {
 	}
 }
 
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevisionEmptyKey() {
+	_, _, err := testSuite.datastore.GetWithPrefixAtRevision("", version)
+	assert.Error(testSuite.T(), err, "Expected an error when key is empty")
+}
+
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevisionNegativeRevision() {
+	_, _, err := testSuite.datastore.GetWithPrefixAtRevision(key, -1)
+	assert.Error(testSuite.T(), err, "Expected an error when revision is negative")
+}
+
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevisionOutOfRange() {
+	for _, etcdErr := range []error{rpctypes.ErrCompacted, rpctypes.ErrFutureRev} {
+		testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(nil, etcdErr)
+
+		_, _, err := testSuite.datastore.GetWithPrefixAtRevision(key, version)
+		assert.Error(testSuite.T(), err, "Expected an error when etcd get fails with %v", etcdErr)
+		assert.IsType(testSuite.T(), types.OutOfRangeEntityVersion{}, err, "Expected the error to be of type OutOfRangeEntityVersion")
+	}
+}
+
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevisionEtcdGetFails() {
+	testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(nil, errors.New("Get failed"))
+
+	_, _, err := testSuite.datastore.GetWithPrefixAtRevision(key, version)
+	assert.Error(testSuite.T(), err, "Expected an error when etcd get fails")
+}
+
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevision() {
+	getResp := etcd.GetResponse{
+		Header: &etcdserverpb.ResponseHeader{Revision: anotherVersion},
+		Kvs: []*mvccpb.KeyValue{{
+			Key:         []byte(key),
+			Value:       []byte(value),
+			ModRevision: version,
+		}},
+	}
+	testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(&getResp, nil)
+
+	resp, revision, err := testSuite.datastore.GetWithPrefixAtRevision(key, version)
+	assert.Nil(testSuite.T(), err, "Unexpected error when etcd get returns results")
+	assert.Equal(testSuite.T(), version, revision, "Expected the requested revision")
+	assert.Exactly(testSuite.T(), storetypes.Entity{Key: key, Value: value, Version: strconv.FormatInt(version, 10)}, resp[key], "Expected the entity read at the revision")
+}
+
+func (testSuite *DataStoreTestSuite) TestGetWithPrefixAtRevisionLatest() {
+	getResp := etcd.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: anotherVersion}}
+	testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(&getResp, nil)
+
+	resp, revision, err := testSuite.datastore.GetWithPrefixAtRevision(key, 0)
+	assert.Nil(testSuite.T(), err, "Unexpected error when etcd get returns empty")
+	assert.Empty(testSuite.T(), resp, "Expected an empty map")
+	assert.Equal(testSuite.T(), anotherVersion, revision, "Expected the revision the latest state was read at")
+}
+
 func (testSuite *DataStoreTestSuite) TestGetEmptyKey() {
 	_, err := testSuite.datastore.Get("")
 	assert.Error(testSuite.T(), err, "Expected an error when key is nil")
@@ -222,6 +285,35 @@ func (testSuite *DataStoreTestSuite) TestGetEtcd() {
 	}
 }
 
+func (testSuite *DataStoreTestSuite) TestGetEtcdDecodesRecord() {
+	encoded, err := flateCodec{}.Encode(value, version)
+	assert.Nil(testSuite.T(), err, "Unexpected error encoding value")
+	var getResp etcd.GetResponse
+	getResp.Kvs = []*mvccpb.KeyValue{{
+		Key:         []byte(key),
+		Value:       []byte(encoded),
+		ModRevision: version,
+	}}
+	testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key).Return(&getResp, nil)
+
+	resp, err := testSuite.datastore.Get(key)
+	assert.Nil(testSuite.T(), err, "Unexpected error when etcd get returns an encoded record")
+	assert.Exactly(testSuite.T(), value, resp[key].Value, "Expected the record to be decoded")
+}
+
+func (testSuite *DataStoreTestSuite) TestGetEtcdUndecodableRecord() {
+	var getResp etcd.GetResponse
+	getResp.Kvs = []*mvccpb.KeyValue{{
+		Key:         []byte(key),
+		Value:       []byte{recordMagic, 42},
+		ModRevision: version,
+	}}
+	testSuite.etcdInterface.EXPECT().Get(gomock.Any(), key).Return(&getResp, nil)
+
+	_, err := testSuite.datastore.Get(key)
+	assert.Error(testSuite.T(), err, "Expected an error when etcd get returns an undecodable record")
+}
+
 func (testSuite *DataStoreTestSuite) TestStreamWithPrefixEmptyKeyPrefix() {
 	ctx := context.Background()
 	_, err := testSuite.datastore.StreamWithPrefix(ctx, "", "")
@@ -311,7 +403,7 @@ func (testSuite *DataStoreTestSuite) TestStreamWithPrefixStreamTimeout() {
 	assert.Nil(testSuite.T(), err, "Unexpected error when setting up streaming")
 	assert.NotNil(testSuite.T(), dsChan, "Expected valid channel for streaming")
 
-	time.Sleep(streamIdleTimeout)
+	time.Sleep(testTimeouts.StreamIdle)
 
 	_, ok := <-dsChan
 	assert.False(testSuite.T(), ok, "Expected dschan to be closed")
@@ -348,6 +440,68 @@ func (testSuite *DataStoreTestSuite) TestDeleteEtcd() {
 	assert.Equal(testSuite.T(), resp, int64(1), "Mismatch between expected and returned number of deleted keys")
 }
 
+func (testSuite *DataStoreTestSuite) TestDeleteIfUnmodifiedInvalidVersion() {
+	testSuite.etcdInterface.EXPECT().Txn(gomock.Any()).Times(0)
+
+	_, err := testSuite.datastore.DeleteIfUnmodified(key, "invalidVersion")
+	assert.Error(testSuite.T(), err, "Expected an error when the version is not an etcd revision")
+}
+
+func (testSuite *DataStoreTestSuite) TestDeleteIfUnmodifiedTxnFails() {
+	txn := &fakeTxn{err: errors.New("Txn failed")}
+	testSuite.etcdInterface.EXPECT().Txn(gomock.Any()).Return(txn)
+
+	_, err := testSuite.datastore.DeleteIfUnmodified(key, "3")
+	assert.Error(testSuite.T(), err, "Expected an error when the etcd transaction fails")
+}
+
+func (testSuite *DataStoreTestSuite) TestDeleteIfUnmodified() {
+	txn := &fakeTxn{resp: &etcd.TxnResponse{Succeeded: true}}
+	testSuite.etcdInterface.EXPECT().Txn(gomock.Any()).Return(txn)
+
+	ok, err := testSuite.datastore.DeleteIfUnmodified(key, "3")
+	assert.Nil(testSuite.T(), err, "Unexpected error deleting an unmodified key")
+	assert.True(testSuite.T(), ok, "Expected the unmodified key to be deleted")
+	assert.Equal(testSuite.T(), []etcd.Cmp{etcd.Compare(etcd.ModRevision(key), "=", 3)}, txn.cmps, "Expected the delete to compare the mod revision of the key")
+	assert.Equal(testSuite.T(), []etcd.Op{etcd.OpDelete(key)}, txn.ops, "Expected the transaction to delete the key")
+}
+
+func (testSuite *DataStoreTestSuite) TestDeleteIfUnmodifiedKeyModified() {
+	txn := &fakeTxn{resp: &etcd.TxnResponse{Succeeded: false}}
+	testSuite.etcdInterface.EXPECT().Txn(gomock.Any()).Return(txn)
+
+	ok, err := testSuite.datastore.DeleteIfUnmodified(key, "3")
+	assert.Nil(testSuite.T(), err, "Unexpected error deleting a modified key")
+	assert.False(testSuite.T(), ok, "Expected a modified key not to be deleted")
+}
+
+// fakeTxn records the comparisons and operations of an etcd transaction and
+// commits it with a canned response
+type fakeTxn struct {
+	cmps []etcd.Cmp
+	ops  []etcd.Op
+	resp *etcd.TxnResponse
+	err  error
+}
+
+func (txn *fakeTxn) If(cs ...etcd.Cmp) etcd.Txn {
+	txn.cmps = append(txn.cmps, cs...)
+	return txn
+}
+
+func (txn *fakeTxn) Then(ops ...etcd.Op) etcd.Txn {
+	txn.ops = append(txn.ops, ops...)
+	return txn
+}
+
+func (txn *fakeTxn) Else(ops ...etcd.Op) etcd.Txn {
+	return txn
+}
+
+func (txn *fakeTxn) Commit() (*etcd.TxnResponse, error) {
+	return txn.resp, txn.err
+}
+
 func addToWatchChanAndReadFromDataChan(watchChan chan etcd.WatchResponse, dsChan chan map[string]storetypes.Entity) map[string]storetypes.Entity {
 	var dsVal map[string]storetypes.Entity
 
@@ -15,10 +15,15 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 // EtcdTXStore defines methods to support etcd's STM
@@ -28,21 +33,66 @@ type EtcdTXStore interface {
 }
 
 type etcdTransactionalStore struct {
-	v3Client *clientv3.Client
+	v3Client       *clientv3.Client
+	requestTimeout time.Duration
 }
 
-// NewEtcdTXStore initializs the etcdTransactionalStore struct
-func NewEtcdTXStore(v3Client *clientv3.Client) (EtcdTXStore, error) {
+// NewEtcdTXStore initializs the etcdTransactionalStore struct. Transactions
+// fail if they do not commit within 'requestTimeout'.
+func NewEtcdTXStore(v3Client *clientv3.Client, requestTimeout time.Duration) (EtcdTXStore, error) {
 	if v3Client == nil {
 		return nil, errors.Errorf("Etcd client in not initialized")
 	}
+	if requestTimeout <= 0 {
+		return nil, errors.Errorf("Etcd request timeout has to be positive")
+	}
 	return &etcdTransactionalStore{
-		v3Client: v3Client,
+		v3Client:       v3Client,
+		requestTimeout: requestTimeout,
 	}, nil
 }
 
+// NewSTMRepeatable runs 'apply' in a repeatable read transaction, retrying it
+// until it commits without conflicting writes. Errors of the etcd client are
+// returned rather than panicking, and the transaction is abandoned once the
+// request timeout expires, so that an unavailable etcd fails it instead of
+// blocking it.
 func (ts etcdTransactionalStore) NewSTMRepeatable(ctx context.Context, v3Client *clientv3.Client, apply func(concurrency.STM) error) (*clientv3.TxnResponse, error) {
-	return concurrency.NewSTMRepeatable(ctx, v3Client, apply)
+	ctx, cancel := context.WithTimeout(ctx, ts.requestTimeout)
+	defer cancel()
+	attempts := 0
+	start := time.Now()
+	resp, err := concurrency.NewSTMRepeatable(ctx, v3Client, func(stm concurrency.STM) error {
+		attempts++
+		return apply(stm)
+	})
+	metrics.ObserveEtcdRequest(txnOperation, start, err)
+	metrics.ObserveSTMTransaction(attempts, err)
+	return resp, err
+}
+
+// IsUnavailable returns true if 'err' was caused by etcd being unreachable or
+// unable to serve requests, rather than by the request itself
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	switch cause {
+	case context.DeadlineExceeded, clientv3.ErrNoAvailableEndpoints,
+		rpctypes.ErrNoLeader, rpctypes.ErrTimeout, rpctypes.ErrTimeoutDueToLeaderFail,
+		rpctypes.ErrStopped, rpctypes.ErrUnhealthy, rpctypes.ErrNotCapable:
+		return true
+	}
+	code := grpc.Code(cause)
+	if etcdErr, ok := cause.(rpctypes.EtcdError); ok {
+		code = etcdErr.Code()
+	}
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
 }
 
 func (ts etcdTransactionalStore) GetV3Client() *clientv3.Client {
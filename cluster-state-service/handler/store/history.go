@@ -0,0 +1,140 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	historyKeyPrefix = entityKeyPrefix + "history/"
+)
+
+// HistoryLimits bounds the state history kept for every task and container
+// instance. Entries beyond MaxEntries or older than MaxAge are dropped when a
+// new entry is recorded. A zero MaxEntries disables history and a zero MaxAge
+// keeps entries regardless of their age.
+type HistoryLimits struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+// IsEnabled returns true if state history is recorded
+func (limits HistoryLimits) IsEnabled() bool {
+	return limits.MaxEntries > 0
+}
+
+func (limits HistoryLimits) validate() error {
+	if limits.MaxEntries < 0 {
+		return errors.Errorf("Invalid maximum number of history entries: %d", limits.MaxEntries)
+	}
+	if limits.MaxAge < 0 {
+		return errors.Errorf("Invalid maximum age of history entries: %s", limits.MaxAge.String())
+	}
+	return nil
+}
+
+// historyEntry is a single version of the state of a task or container
+// instance. State holds the JSON of types.TaskState or types.InstanceState.
+type historyEntry struct {
+	Version    int64           `json:"version"`
+	RecordedAt string          `json:"recordedAt"`
+	State      json.RawMessage `json:"state"`
+}
+
+// historyKey returns the key of the state history of the record stored at
+// recordKey. For example, the history of 'ecs/task/cluster/arn' is stored
+// at 'ecs/history/task/cluster/arn'.
+func historyKey(recordKey string) string {
+	return historyKeyPrefix + strings.TrimPrefix(recordKey, entityKeyPrefix)
+}
+
+// appendHistory appends entry to the history in historyJSON, ordered from
+// oldest to newest, and drops the entries that fall outside of limits
+func appendHistory(historyJSON string, entry historyEntry, limits HistoryLimits, now time.Time) (string, error) {
+	entries := []historyEntry{}
+	if historyJSON != "" {
+		var err error
+		entries, err = unmarshalHistory(historyJSON)
+		if err != nil {
+			return "", err
+		}
+	}
+	entries = append(entries, entry)
+
+	if limits.MaxAge > 0 {
+		cutoff := now.Add(-limits.MaxAge)
+		first := 0
+		for first < len(entries)-1 {
+			recordedAt, err := time.Parse(time.RFC3339, entries[first].RecordedAt)
+			if err == nil && !recordedAt.Before(cutoff) {
+				break
+			}
+			first++
+		}
+		entries = entries[first:]
+	}
+	if len(entries) > limits.MaxEntries {
+		entries = entries[len(entries)-limits.MaxEntries:]
+	}
+
+	historyBytes, err := json.Marshal(entries)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error marshaling history")
+	}
+	return string(historyBytes), nil
+}
+
+func newHistoryEntry(version int64, recordedAt time.Time, state interface{}) (historyEntry, error) {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return historyEntry{}, errors.Wrapf(err, "Error marshaling history state")
+	}
+	return historyEntry{
+		Version:    version,
+		RecordedAt: recordedAt.UTC().Format(time.RFC3339),
+		State:      stateJSON,
+	}, nil
+}
+
+// getHistory returns the history stored at key. An empty history is returned
+// if no history has been recorded.
+func getHistory(ds DataStore, key string) ([]historyEntry, error) {
+	resp, err := ds.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) > 1 {
+		return nil, errors.Errorf("Multiple entries exist in the datastore with key %v", key)
+	}
+
+	for _, entity := range resp {
+		return unmarshalHistory(entity.Value)
+	}
+	return []historyEntry{}, nil
+}
+
+func unmarshalHistory(historyJSON string) ([]historyEntry, error) {
+	var entries []historyEntry
+	err := json.Unmarshal([]byte(historyJSON), &entries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshaling history '%s'", historyJSON)
+	}
+	return entries, nil
+}
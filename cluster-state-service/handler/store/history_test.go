@@ -0,0 +1,113 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testHistoryLimits = HistoryLimits{
+	MaxEntries: 3,
+	MaxAge:     time.Hour,
+}
+
+func TestHistoryKey(t *testing.T) {
+	assert.Equal(t, "ecs/history/task/cluster/arn", historyKey("ecs/task/cluster/arn"), "Unexpected history key")
+}
+
+func TestHistoryLimitsValidate(t *testing.T) {
+	assert.NoError(t, HistoryLimits{}.validate(), "Unexpected error validating disabled history limits")
+	assert.Error(t, HistoryLimits{MaxEntries: -1}.validate(), "Expected an error when max entries is negative")
+	assert.Error(t, HistoryLimits{MaxAge: -time.Hour}.validate(), "Expected an error when max age is negative")
+}
+
+func TestAppendHistoryToEmptyHistory(t *testing.T) {
+	historyJSON, err := appendHistory("", generateHistoryEntry(t, 1), testHistoryLimits, time.Now())
+	assert.NoError(t, err, "Unexpected error appending to empty history")
+
+	entries, err := unmarshalHistory(historyJSON)
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, 1, "Unexpected number of history entries")
+	assert.Equal(t, int64(1), entries[0].Version, "Unexpected history entry version")
+}
+
+func TestAppendHistoryInvalidHistory(t *testing.T) {
+	_, err := appendHistory("invalidJSON", generateHistoryEntry(t, 1), testHistoryLimits, time.Now())
+	assert.Error(t, err, "Expected an error when appending to invalid history")
+}
+
+func TestAppendHistoryTrimsToMaxEntries(t *testing.T) {
+	historyJSON := ""
+	var err error
+	for version := int64(1); version <= 5; version++ {
+		historyJSON, err = appendHistory(historyJSON, generateHistoryEntry(t, version), testHistoryLimits, time.Now())
+		assert.NoError(t, err, "Unexpected error appending to history")
+	}
+
+	entries, err := unmarshalHistory(historyJSON)
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, testHistoryLimits.MaxEntries, "Expected history to be trimmed to max entries")
+	assert.Equal(t, int64(3), entries[0].Version, "Expected the oldest entries to be dropped")
+	assert.Equal(t, int64(5), entries[2].Version, "Expected the newest entry to be last")
+}
+
+func TestAppendHistoryTrimsToMaxAge(t *testing.T) {
+	now := time.Now()
+	old, err := newHistoryEntry(1, now.Add(-2*time.Hour), map[string]string{})
+	assert.NoError(t, err, "Unexpected error creating history entry")
+	recent, err := newHistoryEntry(2, now.Add(-time.Minute), map[string]string{})
+	assert.NoError(t, err, "Unexpected error creating history entry")
+
+	historyJSON, err := appendHistory("", old, testHistoryLimits, now)
+	assert.NoError(t, err, "Unexpected error appending to history")
+	historyJSON, err = appendHistory(historyJSON, recent, testHistoryLimits, now)
+	assert.NoError(t, err, "Unexpected error appending to history")
+
+	entries, err := unmarshalHistory(historyJSON)
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, 1, "Expected entries older than max age to be dropped")
+	assert.Equal(t, int64(2), entries[0].Version, "Unexpected history entry version")
+}
+
+func TestAppendHistoryKeepsLatestEntryOlderThanMaxAge(t *testing.T) {
+	now := time.Now()
+	old, err := newHistoryEntry(1, now.Add(-2*time.Hour), map[string]string{})
+	assert.NoError(t, err, "Unexpected error creating history entry")
+
+	historyJSON, err := appendHistory("", old, testHistoryLimits, now)
+	assert.NoError(t, err, "Unexpected error appending to history")
+
+	entries, err := unmarshalHistory(historyJSON)
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, 1, "Expected the latest entry to be kept")
+}
+
+func generateHistoryEntry(t *testing.T, version int64) historyEntry {
+	entry, err := newHistoryEntry(version, time.Now(), map[string]int64{"version": version})
+	if err != nil {
+		t.Error("Failed to create history entry: ", err)
+	}
+	return entry
+}
+
+func generateHistory(t *testing.T, version int64) string {
+	historyJSON, err := appendHistory("", generateHistoryEntry(t, version), testHistoryLimits, time.Now())
+	if err != nil {
+		t.Error("Failed to generate history: ", err)
+	}
+	return historyJSON
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/goguardian/blox/cluster-state-service/handler/regex"
@@ -40,29 +41,52 @@ var (
 type ContainerInstanceStore interface {
 	AddContainerInstance(instance string) error
 	GetContainerInstance(cluster string, instanceARN string) (*storetypes.VersionedContainerInstance, error)
+	GetContainerInstanceHistory(cluster string, instanceARN string) ([]storetypes.ContainerInstanceHistoryEntry, error)
 	ListContainerInstances() ([]storetypes.VersionedContainerInstance, error)
 	FilterContainerInstances(filterMap map[string]string) ([]storetypes.VersionedContainerInstance, error)
+	ListContainerInstancesAtVersion(entityVersion string) ([]storetypes.VersionedContainerInstance, int64, error)
+	FilterContainerInstancesAtVersion(filterMap map[string]string, entityVersion string) ([]storetypes.VersionedContainerInstance, int64, error)
 	StreamContainerInstances(ctx context.Context, entityVersion string) (chan storetypes.VersionedContainerInstance, error)
 	DeleteContainerInstance(cluster, instanceARN string) error
+	PurgeContainerInstance(cluster, instanceARN string, version int64) error
 }
 
 type eventInstanceStore struct {
-	datastore   DataStore
-	etcdTXStore EtcdTXStore
+	datastore     DataStore
+	etcdTXStore   EtcdTXStore
+	recordApplier RecordApplier
+	codec         Codec
+	// view, when set and seeded, serves reads instead of the datastore
+	view          *View
+	historyLimits HistoryLimits
 }
 
 // NewContainerInstanceStore inistializes the eventInstanceStore struct
-func NewContainerInstanceStore(ds DataStore, ts EtcdTXStore) (ContainerInstanceStore, error) {
+func NewContainerInstanceStore(ds DataStore, ts EtcdTXStore, recordApplier RecordApplier, codec Codec, view *View, historyLimits HistoryLimits) (ContainerInstanceStore, error) {
 	if ds == nil {
 		return nil, errors.New("Datastore is not initialized")
 	}
 	if ts == nil {
 		return nil, errors.New("Etcd transactional store is not initialized")
 	}
+	if recordApplier == nil {
+		return nil, errors.New("Record applier is not initialized")
+	}
+	if codec == nil {
+		return nil, errors.New("Codec is not initialized")
+	}
+
+	if err := historyLimits.validate(); err != nil {
+		return nil, err
+	}
 
 	return eventInstanceStore{
-		datastore:   ds,
-		etcdTXStore: ts,
+		datastore:     ds,
+		etcdTXStore:   ts,
+		recordApplier: recordApplier,
+		codec:         codec,
+		view:          view,
+		historyLimits: historyLimits,
 	}, nil
 }
 
@@ -76,15 +100,22 @@ func (instanceStore eventInstanceStore) AddContainerInstance(instanceJSON string
 	log.Debugf("Instance store unmarshalled instance: %s, trying to add it to the store", instance.Detail.String())
 
 	applier := &STMApplier{
-		record:     types.ContainerInstance{},
-		recordKey:  key,
-		recordJSON: instanceJSON,
+		record:       types.ContainerInstance{},
+		recordKey:    key,
+		recordJSON:   instanceJSON,
+		tombstoneKey: tombstoneKey(key),
+		codec:        instanceStore.codec,
+	}
+	if instanceStore.historyLimits.IsEnabled() {
+		entry, err := newHistoryEntry(aws.Int64Value(instance.Detail.Version), time.Now(), instance.Detail.State())
+		if err != nil {
+			return err
+		}
+		applier.historyKey = historyKey(key)
+		applier.historyEntry = entry
+		applier.historyLimits = instanceStore.historyLimits
 	}
-	// TODO: NewSTMRepeatble panics if there's any error from the etcd
-	// client. We should find a better way to handle that
-	_, err = instanceStore.etcdTXStore.NewSTMRepeatable(context.TODO(),
-		instanceStore.etcdTXStore.GetV3Client(),
-		applier.applyRecord)
+	err = instanceStore.recordApplier.ApplyRecord(applier)
 
 	return err
 }
@@ -98,15 +129,74 @@ func (instanceStore eventInstanceStore) GetContainerInstance(cluster string, ins
 	return instanceStore.getInstanceByKey(key)
 }
 
+// GetContainerInstanceHistory gets the recorded states of the container instance with ARN 'instanceARN'
+// belonging to cluster 'cluster', ordered from oldest to newest
+func (instanceStore eventInstanceStore) GetContainerInstanceHistory(cluster string, instanceARN string) ([]storetypes.ContainerInstanceHistoryEntry, error) {
+	key, err := instanceStore.getInstanceKey(cluster, instanceARN)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not generate instance key for cluster '%s' and instance '%s'", cluster, instanceARN)
+	}
+
+	entries, err := getHistory(instanceStore.datastore, historyKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	instanceHistory := make([]storetypes.ContainerInstanceHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		var state types.InstanceState
+		err = json.Unmarshal(entry.State, &state)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error unmarshaling history of instance '%s'", instanceARN)
+		}
+		instanceHistory = append(instanceHistory, storetypes.ContainerInstanceHistoryEntry{
+			Version:    entry.Version,
+			RecordedAt: entry.RecordedAt,
+			State:      state,
+		})
+	}
+	return instanceHistory, nil
+}
+
 // ListContainerInstances lists all container instances existing in the datastore
 func (instanceStore eventInstanceStore) ListContainerInstances() ([]storetypes.VersionedContainerInstance, error) {
-	return instanceStore.getInstancesByKeyPrefix(instanceKeyPrefix)
+	instances, _, err := instanceStore.getInstancesByKeyPrefix(instanceKeyPrefix, anyRevision)
+	return instances, err
 }
 
 // FilterContainerInstances returns all container instances from the datastore that match the provided filters
 func (instanceStore eventInstanceStore) FilterContainerInstances(filterMap map[string]string) ([]storetypes.VersionedContainerInstance, error) {
+	instances, _, err := instanceStore.filterContainerInstancesAtRevision(filterMap, anyRevision)
+	return instances, err
+}
+
+// ListContainerInstancesAtVersion lists all container instances as they were
+// at 'entityVersion' and returns the etcd revision they were read at. Entity
+// version 0 reads the latest container instances.
+func (instanceStore eventInstanceStore) ListContainerInstancesAtVersion(entityVersion string) ([]storetypes.VersionedContainerInstance, int64, error) {
+	revision, err := regex.GetEntityVersion(entityVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	return instanceStore.getInstancesByKeyPrefix(instanceKeyPrefix, revision)
+}
+
+// FilterContainerInstancesAtVersion returns all container instances that
+// matched the provided filters at 'entityVersion' and the etcd revision they
+// were read at. Entity version 0 reads the latest container instances.
+func (instanceStore eventInstanceStore) FilterContainerInstancesAtVersion(filterMap map[string]string, entityVersion string) ([]storetypes.VersionedContainerInstance, int64, error) {
+	revision, err := regex.GetEntityVersion(entityVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	return instanceStore.filterContainerInstancesAtRevision(filterMap, revision)
+}
+
+// filterContainerInstancesAtRevision filters the container instances read
+// with a single read at 'revision', as getInstancesByKeyPrefix reads them
+func (instanceStore eventInstanceStore) filterContainerInstancesAtRevision(filterMap map[string]string, revision int64) ([]storetypes.VersionedContainerInstance, int64, error) {
 	if len(filterMap) == 0 {
-		return nil, errors.New("There has to be at least one filter")
+		return nil, 0, errors.New("There has to be at least one filter")
 	}
 
 	filters := make([]string, 0, len(filterMap))
@@ -115,12 +205,12 @@ func (instanceStore eventInstanceStore) FilterContainerInstances(filterMap map[s
 	}
 
 	if !instanceStore.areFiltersValid(filters) {
-		return nil, errors.Errorf("At least one of the provided filters '%v' is not supported.", filters)
+		return nil, 0, errors.Errorf("At least one of the provided filters '%v' is not supported.", filters)
 	}
 
 	for key, val := range filterMap {
 		if val == "" {
-			return nil, errors.Errorf("Filter value for filter '%s' is empty", key)
+			return nil, 0, errors.Errorf("Filter value for filter '%s' is empty", key)
 		}
 	}
 
@@ -128,13 +218,13 @@ func (instanceStore eventInstanceStore) FilterContainerInstances(filterMap map[s
 	cluster, clusterFilterExists := filterMap[instanceClusterFilter]
 	switch {
 	case statusFilterExists && clusterFilterExists:
-		return instanceStore.filterContainerInstancesByStatusAndCluster(status, cluster)
+		return instanceStore.filterContainerInstancesByStatusAndCluster(status, cluster, revision)
 	case statusFilterExists:
-		return instanceStore.filterContainerInstancesByStatus(status)
+		return instanceStore.filterContainerInstancesByStatus(status, revision)
 	case clusterFilterExists:
-		return instanceStore.filterContainerInstancesByCluster(cluster)
+		return instanceStore.filterContainerInstancesByCluster(cluster, revision)
 	default:
-		return nil, errors.Errorf("Unsupported filter combination '%v'", filters)
+		return nil, 0, errors.Errorf("Unsupported filter combination '%v'", filters)
 	}
 }
 
@@ -164,6 +254,34 @@ func (instanceStore eventInstanceStore) DeleteContainerInstance(cluster string,
 	log.Debugf("Deleted '%d' key(s) from the store for container instance '%s', belonging to cluster '%s'",
 		numKeysDeleted, instanceARN, cluster)
 	// TODO: Should numKeysDeleted != 1 cause an error as well?
+	if err != nil {
+		return err
+	}
+
+	_, err = instanceStore.datastore.Delete(historyKey(key))
+	return err
+}
+
+// PurgeContainerInstance replaces the instance key in the data store with a
+// tombstone, which prevents events with the same or an older version from
+// adding the instance back. The instance is left untouched if its version is
+// no longer 'version'.
+func (instanceStore eventInstanceStore) PurgeContainerInstance(cluster string, instanceARN string, version int64) error {
+	key, err := instanceStore.getInstanceKey(cluster, instanceARN)
+	if err != nil {
+		return errors.Wrapf(err, "Could not generate instance key for cluster '%s' and instance '%s'",
+			cluster, instanceARN)
+	}
+
+	purger := &STMPurger{
+		record:    types.ContainerInstance{},
+		recordKey: key,
+		version:   version,
+		purgedAt:  time.Now(),
+	}
+	_, err = instanceStore.etcdTXStore.NewSTMRepeatable(context.TODO(),
+		instanceStore.etcdTXStore.GetV3Client(),
+		purger.purgeRecord)
 	return err
 }
 
@@ -188,12 +306,12 @@ func (instanceStore eventInstanceStore) unmarshalInstanceAndGenerateKey(instance
 	}
 
 	clusterARN := aws.StringValue(instance.Detail.ClusterARN)
-	clusterName, err := regex.GetClusterNameFromARN(clusterARN)
+	cluster, err := regex.GetClusterFromARN(clusterARN)
 	if err != nil {
-		return nil, "", errors.Wrapf(err, "Error retrieving cluster name from ARN '%s' for instance", clusterARN)
+		return nil, "", errors.Wrapf(err, "Error retrieving cluster from ARN '%s' for instance", clusterARN)
 	}
 
-	key, err := generateInstanceKey(clusterName, aws.StringValue(instance.Detail.ContainerInstanceARN))
+	key, err := generateInstanceKey(cluster, aws.StringValue(instance.Detail.ContainerInstanceARN))
 	if err != nil {
 		return nil, "", err
 	}
@@ -214,12 +332,12 @@ func (instanceStore eventInstanceStore) areFiltersValid(filters []string) bool {
 	return true
 }
 
-func (instanceStore eventInstanceStore) filterContainerInstancesByStatus(status string) ([]storetypes.VersionedContainerInstance, error) {
-	instances, err := instanceStore.ListContainerInstances()
+func (instanceStore eventInstanceStore) filterContainerInstancesByStatus(status string, revision int64) ([]storetypes.VersionedContainerInstance, int64, error) {
+	instances, revision, err := instanceStore.getInstancesByKeyPrefix(instanceKeyPrefix, revision)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return instanceStore.filterContainerInstancesByStatusFromList(status, instances), nil
+	return instanceStore.filterContainerInstancesByStatusFromList(status, instances), revision, nil
 }
 
 func (instanceStore eventInstanceStore) filterContainerInstancesByStatusFromList(status string, instances []storetypes.VersionedContainerInstance) []storetypes.VersionedContainerInstance {
@@ -232,26 +350,37 @@ func (instanceStore eventInstanceStore) filterContainerInstancesByStatusFromList
 	return filteredInstances
 }
 
-func (instanceStore eventInstanceStore) filterContainerInstancesByCluster(cluster string) ([]storetypes.VersionedContainerInstance, error) {
-	clusterName := cluster
-	var err error
-	if regex.IsClusterARN(cluster) {
-		clusterName, err = regex.GetClusterNameFromARN(cluster)
-		if err != nil {
-			return nil, err
-		}
+func (instanceStore eventInstanceStore) filterContainerInstancesByCluster(cluster string, revision int64) ([]storetypes.VersionedContainerInstance, int64, error) {
+	identifier, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Cluster ARNs identify a single cluster, whose instances share a key prefix
+	if identifier.Account != "" {
+		return instanceStore.getInstancesByKeyPrefix(clusterKeyPrefix(instanceKeyPrefix, identifier), revision)
 	}
 
-	instancesForClusterPrefix := instanceKeyPrefix + clusterName + "/"
-	return instanceStore.getInstancesByKeyPrefix(instancesForClusterPrefix)
+	instances, revision, err := instanceStore.getInstancesByKeyPrefix(instanceKeyPrefix, revision)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filteredInstances := make([]storetypes.VersionedContainerInstance, 0, len(instances))
+	for _, instance := range instances {
+		if identifier.Matches(aws.StringValue(instance.ContainerInstance.Detail.ClusterARN)) {
+			filteredInstances = append(filteredInstances, instance)
+		}
+	}
+	return filteredInstances, revision, nil
 }
 
-func (instanceStore eventInstanceStore) filterContainerInstancesByStatusAndCluster(status string, cluster string) ([]storetypes.VersionedContainerInstance, error) {
-	instancesFilteredByCluster, err := instanceStore.filterContainerInstancesByCluster(cluster)
+func (instanceStore eventInstanceStore) filterContainerInstancesByStatusAndCluster(status string, cluster string, revision int64) ([]storetypes.VersionedContainerInstance, int64, error) {
+	instancesFilteredByCluster, revision, err := instanceStore.filterContainerInstancesByCluster(cluster, revision)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return instanceStore.filterContainerInstancesByStatusFromList(status, instancesFilteredByCluster), nil
+	return instanceStore.filterContainerInstancesByStatusFromList(status, instancesFilteredByCluster), revision, nil
 }
 
 func (instanceStore eventInstanceStore) getInstanceByKey(key string) (*storetypes.VersionedContainerInstance, error) {
@@ -259,6 +388,10 @@ func (instanceStore eventInstanceStore) getInstanceByKey(key string) (*storetype
 		return nil, errors.New("Key cannot be empty")
 	}
 
+	if record, ok := instanceStore.view.getInstance(key); ok {
+		return record, nil
+	}
+
 	resp, err := instanceStore.datastore.Get(key)
 	if err != nil {
 		return nil, err
@@ -284,18 +417,33 @@ func (instanceStore eventInstanceStore) getInstanceByKey(key string) (*storetype
 	return &versionedInstance, nil
 }
 
-func (instanceStore eventInstanceStore) getInstancesByKeyPrefix(key string) ([]storetypes.VersionedContainerInstance, error) {
+// getInstancesByKeyPrefix returns the container instances whose keys start
+// with 'key' at etcd revision 'revision', or the latest ones if it is 0, and
+// the revision they were read at. With anyRevision, the latest container
+// instances are read from the view when it is seeded and the returned
+// revision is 0.
+func (instanceStore eventInstanceStore) getInstancesByKeyPrefix(key string, revision int64) ([]storetypes.VersionedContainerInstance, int64, error) {
 	if len(key) == 0 {
-		return nil, errors.New("Key cannot be empty")
+		return nil, 0, errors.New("Key cannot be empty")
 	}
 
-	resp, err := instanceStore.datastore.GetWithPrefix(key)
+	var resp map[string]storetypes.Entity
+	var err error
+	if revision == anyRevision {
+		if records, ok := instanceStore.view.getInstancesWithPrefix(key); ok {
+			return records, 0, nil
+		}
+		resp, err = instanceStore.datastore.GetWithPrefix(key)
+		revision = 0
+	} else {
+		resp, revision, err = instanceStore.datastore.GetWithPrefixAtRevision(key, revision)
+	}
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if len(resp) == 0 {
-		return make([]storetypes.VersionedContainerInstance, 0), nil
+		return make([]storetypes.VersionedContainerInstance, 0), revision, nil
 	}
 
 	versionedInstances := []storetypes.VersionedContainerInstance{}
@@ -304,11 +452,11 @@ func (instanceStore eventInstanceStore) getInstancesByKeyPrefix(key string) ([]s
 		versionedInstance.ContainerInstance, err = instanceStore.unmarshalInstance(entity.Value)
 		versionedInstance.Version = entity.Version
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		versionedInstances = append(versionedInstances, versionedInstance)
 	}
-	return versionedInstances, nil
+	return versionedInstances, revision, nil
 }
 
 func (instanceStore eventInstanceStore) getInstanceKey(cluster string, instanceARN string) (string, error) {
@@ -319,16 +467,12 @@ func (instanceStore eventInstanceStore) getInstanceKey(cluster string, instanceA
 		return "", errors.New("Instance ARN should not be empty")
 	}
 
-	clusterName := cluster
-	var err error
-	if regex.IsClusterARN(cluster) {
-		clusterName, err = regex.GetClusterNameFromARN(cluster)
-		if err != nil {
-			return "", err
-		}
+	identifier, err := resolveCluster(cluster, instanceARN)
+	if err != nil {
+		return "", err
 	}
 
-	return generateInstanceKey(clusterName, instanceARN)
+	return generateInstanceKey(identifier, instanceARN)
 }
 
 func (instanceStore eventInstanceStore) pipeBetweenChannels(ctx context.Context, cancel context.CancelFunc, dsChan chan map[string]storetypes.Entity, instanceRespChan chan storetypes.VersionedContainerInstance) {
@@ -370,12 +514,18 @@ func (instanceStore eventInstanceStore) unmarshalInstance(val string) (types.Con
 	return instance, nil
 }
 
-func generateInstanceKey(clusterName string, instanceARN string) (string, error) {
-	if !regex.IsClusterName(clusterName) {
-		return "", errors.Errorf("Error generating instance key. Cluster name '%s' does not match expected regex", clusterName)
-	}
+func generateInstanceKey(cluster regex.ClusterIdentifier, instanceARN string) (string, error) {
 	if !regex.IsInstanceARN(instanceARN) {
 		return "", errors.Errorf("Error generating instance key. Instance ARN '%s' does not match expected regex", instanceARN)
 	}
-	return instanceKeyPrefix + clusterName + "/" + instanceARN, nil
+	// ARNs in the long format carry the name of the cluster, which has to
+	// match the cluster the instance is stored under
+	if clusterName, err := regex.GetClusterNameFromInstanceARN(instanceARN); err == nil && clusterName != cluster.Name {
+		return "", errors.Errorf("Error generating instance key. Instance ARN '%s' does not belong to cluster '%s'", instanceARN, cluster.Name)
+	}
+	key, err := generateEntityKey(instanceKeyPrefix, cluster, instanceARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating instance key")
+	}
+	return key, nil
 }
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
 	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
@@ -64,7 +65,7 @@ func NewContainerInstanceStoreMockContext(t *testing.T) *instanceStoreMockContex
 		},
 	}
 	context.instanceJSON1 = marshalInstance(t, context.instance1)
-	context.instanceKey1 = instanceKeyPrefix + clusterName1 + "/" + containerInstanceARN1
+	context.instanceKey1 = instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + containerInstanceARN1
 	context.instanceEntity1 = setupEntity(context.instanceKey1, context.instanceJSON1, entityVersion)
 
 	context.instance2 = types.ContainerInstance{
@@ -76,7 +77,7 @@ func NewContainerInstanceStoreMockContext(t *testing.T) *instanceStoreMockContex
 		},
 	}
 	context.instanceJSON2 = marshalInstance(t, context.instance2)
-	context.instanceKey2 = instanceKeyPrefix + clusterName2 + "/" + containerInstanceARN2
+	context.instanceKey2 = instanceKeyPrefix + accountID + "/" + region + "/" + clusterName2 + "/" + containerInstanceARN2
 	context.instanceEntity2 = setupEntity(context.instanceKey2, context.instanceJSON2, entityVersion)
 
 	return &context
@@ -85,7 +86,7 @@ func NewContainerInstanceStoreMockContext(t *testing.T) *instanceStoreMockContex
 func TestInstanceStoreNilDatastore(t *testing.T) {
 	context := NewContainerInstanceStoreMockContext(t)
 	defer context.mockCtrl.Finish()
-	_, err := NewContainerInstanceStore(nil, context.etcdTxStore)
+	_, err := NewContainerInstanceStore(nil, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 
 	if err == nil {
 		t.Error("Expected an error when datastore is nil")
@@ -95,13 +96,33 @@ func TestInstanceStoreNilDatastore(t *testing.T) {
 func TestInstanceStoreNilEtcdTxStore(t *testing.T) {
 	context := NewContainerInstanceStoreMockContext(t)
 	defer context.mockCtrl.Finish()
-	_, err := NewContainerInstanceStore(context.datastore, nil)
+	_, err := NewContainerInstanceStore(context.datastore, nil, stmRecordApplier{}, jsonCodec{}, nil, testHistoryLimits)
 
 	if err == nil {
 		t.Error("Expected an error when etcd transactional store is nil")
 	}
 }
 
+func TestInstanceStoreNilRecordApplier(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	_, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, nil, jsonCodec{}, nil, testHistoryLimits)
+
+	if err == nil {
+		t.Error("Expected an error when record applier is nil")
+	}
+}
+
+func TestInstanceStoreNilCodec(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	_, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, nil, nil, testHistoryLimits)
+
+	if err == nil {
+		t.Error("Expected an error when codec is nil")
+	}
+}
+
 func TestInstanceStore(t *testing.T) {
 	context := NewContainerInstanceStoreMockContext(t)
 	defer context.mockCtrl.Finish()
@@ -285,6 +306,53 @@ func TestGetContainerInstanceGetFails(t *testing.T) {
 	}
 }
 
+func TestGetContainerInstanceHistoryGetFails(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	context.datastore.EXPECT().Get(historyKey(context.instanceKey1)).Return(nil, errors.New("Error when getting key"))
+	_, err := instanceStore.GetContainerInstanceHistory(clusterName1, containerInstanceARN1)
+	if err == nil {
+		t.Error("Expected an error when datastore get fails")
+	}
+}
+
+func TestGetContainerInstanceHistoryGetNoResults(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	context.datastore.EXPECT().Get(historyKey(context.instanceKey1)).Return(make(map[string]storetypes.Entity), nil)
+	history, err := instanceStore.GetContainerInstanceHistory(clusterName1, containerInstanceARN1)
+	if err != nil {
+		t.Error("Unexpected error when datastore get returns empty results")
+	}
+	if len(history) != 0 {
+		t.Error("Expected GetContainerInstanceHistory to return empty history when get from datastore is empty")
+	}
+}
+
+func TestGetContainerInstanceHistoryWithClusterARNAndInstanceARN(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	entry, err := newHistoryEntry(containerInstanceVersion, time.Now(), context.instance1.Detail.State())
+	assert.NoError(t, err, "Unexpected error creating history entry")
+	historyJSON, err := appendHistory("", entry, testHistoryLimits, time.Now())
+	assert.NoError(t, err, "Unexpected error creating history")
+	resp := map[string]storetypes.Entity{
+		containerInstanceARN1: {Key: historyKey(context.instanceKey1), Value: historyJSON},
+	}
+	context.datastore.EXPECT().Get(historyKey(context.instanceKey1)).Return(resp, nil)
+	history, err := instanceStore.GetContainerInstanceHistory(clusterARN1, containerInstanceARN1)
+	assert.NoError(t, err, "Unexpected error when getting instance history")
+	if len(history) != 1 || !reflect.DeepEqual(history[0].State, context.instance1.Detail.State()) {
+		t.Error("Expected the returned history to match the one returned from the datastore")
+	}
+}
+
 func TestGetContainerInstanceGetNoResults(t *testing.T) {
 	context := NewContainerInstanceStoreMockContext(t)
 	defer context.mockCtrl.Finish()
@@ -597,7 +665,7 @@ func TestFilterContainerInstancesClusterNameFilter(t *testing.T) {
 		containerInstanceARN1: context.instanceEntity1,
 	}
 
-	instancesForClusterPrefix := instanceKeyPrefix + clusterName1 + "/"
+	instancesForClusterPrefix := instanceKeyPrefix
 	context.datastore.EXPECT().GetWithPrefix(instancesForClusterPrefix).Return(resp, nil)
 
 	instanceStore := instanceStore(t, context)
@@ -617,7 +685,7 @@ func TestFilterContainerInstancesClusterARNFilter(t *testing.T) {
 	resp := map[string]storetypes.Entity{
 		containerInstanceARN1: context.instanceEntity1,
 	}
-	instancesForClusterPrefix := instanceKeyPrefix + clusterName1 + "/"
+	instancesForClusterPrefix := instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	context.datastore.EXPECT().GetWithPrefix(instancesForClusterPrefix).Return(resp, nil)
 
 	instanceStore := instanceStore(t, context)
@@ -648,7 +716,7 @@ func TestFilterContainerInstancesStatusAndClusterARNFilter(t *testing.T) {
 		containerInstanceARN1: context.instanceEntity1, // clusterARN1, status1
 		containerInstanceARN2: setupEntity(containerInstanceARN2, instanceJSON, entityVersion),
 	}
-	instancesForClusterPrefix := instanceKeyPrefix + clusterName1 + "/"
+	instancesForClusterPrefix := instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	context.datastore.EXPECT().GetWithPrefix(instancesForClusterPrefix).Return(resp, nil)
 
 	instanceStore := instanceStore(t, context)
@@ -855,6 +923,7 @@ func TestDeleteContainerInstanceDeleteNoError(t *testing.T) {
 
 	instanceStore := instanceStore(t, context)
 	context.datastore.EXPECT().Delete(context.instanceKey1).Return(int64(1), nil)
+	context.datastore.EXPECT().Delete(historyKey(context.instanceKey1)).Return(int64(1), nil)
 	err := instanceStore.DeleteContainerInstance(clusterName1, containerInstanceARN1)
 	if err != nil {
 		t.Errorf("Error deleting container instance from data store: %v", err)
@@ -867,14 +936,159 @@ func TestDeleteContainerInstanceWithClusterARNAndInstanceARN(t *testing.T) {
 
 	instanceStore := instanceStore(t, context)
 	context.datastore.EXPECT().Delete(context.instanceKey1).Return(int64(1), nil)
+	context.datastore.EXPECT().Delete(historyKey(context.instanceKey1)).Return(int64(1), nil)
 	err := instanceStore.DeleteContainerInstance(clusterARN1, containerInstanceARN1)
 	if err != nil {
 		t.Errorf("Error deleting container instance from data store: %v", err)
 	}
 }
 
+func TestPurgeContainerInstanceEmptyInstanceARN(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	err := instanceStore.PurgeContainerInstance(clusterName1, "", 1)
+	if err == nil {
+		t.Error("Expected an error when instance ARN is empty in PurgeContainerInstance")
+	}
+}
+
+func TestPurgeContainerInstanceSTMRepeatableFails(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	context.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	context.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("Error when getting key"))
+	err := instanceStore.PurgeContainerInstance(clusterARN1, containerInstanceARN1, 1)
+	if err == nil {
+		t.Error("Expected an error when STM repeatable fails to execute with an error")
+	}
+}
+
+func TestPurgeContainerInstance(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	instanceStore := instanceStore(t, context)
+	context.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	context.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	err := instanceStore.PurgeContainerInstance(clusterARN1, containerInstanceARN1, 1)
+	if err != nil {
+		t.Errorf("Error purging container instance from data store: %v", err)
+	}
+}
+
+func TestGetContainerInstanceFromView(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	view := newSeededView(t, 10, viewKV(context.instanceKey1, context.instanceJSON1, 9))
+	instanceStore, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(t, err, "Unexpected error when calling NewContainerInstanceStore")
+
+	instance, err := instanceStore.GetContainerInstance(clusterARN1, containerInstanceARN1)
+	assert.Nil(t, err, "Unexpected error when calling GetContainerInstance")
+	assert.NotNil(t, instance, "Expected the instance in the view")
+	assert.Exactly(t, context.instance1, instance.ContainerInstance, "Expected the instance in the view")
+	assert.Equal(t, "9", instance.Version, "Expected the version of the instance in the view")
+}
+
+func TestFilterContainerInstancesClusterARNFilterFromView(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	view := newSeededView(t, 10,
+		viewKV(context.instanceKey1, context.instanceJSON1, 9),
+		viewKV(context.instanceKey2, context.instanceJSON2, 10),
+	)
+	instanceStore, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(t, err, "Unexpected error when calling NewContainerInstanceStore")
+
+	instances, err := instanceStore.FilterContainerInstances(map[string]string{instanceClusterFilter: clusterARN2})
+	assert.Nil(t, err, "Unexpected error when calling FilterContainerInstances")
+	assert.Equal(t, 1, len(instances), "Expected the instances of the cluster in the view")
+	assert.Exactly(t, context.instance2, instances[0].ContainerInstance, "Expected the instance of the cluster in the view")
+}
+
+func TestListContainerInstancesAtVersionInvalidEntityVersion(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, _, err := instanceStore(t, context).ListContainerInstancesAtVersion("invalidEntityVersion")
+	assert.Error(t, err, "Expected an error when the entity version is invalid")
+}
+
+func TestListContainerInstancesAtVersionOutOfRange(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	context.datastore.EXPECT().GetWithPrefixAtRevision(instanceKeyPrefix, int64(123)).Return(nil, int64(0), types.NewOutOfRangeEntityVersion(errors.New("compacted")))
+
+	_, _, err := instanceStore(t, context).ListContainerInstancesAtVersion(entityVersion)
+	assert.Error(t, err, "Expected an error when the entity version is out of range")
+	assert.IsType(t, types.OutOfRangeEntityVersion{}, err, "Expected the error to be of type OutOfRangeEntityVersion")
+}
+
+func TestListContainerInstancesAtVersion(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	resp := map[string]storetypes.Entity{
+		containerInstanceARN1: context.instanceEntity1,
+	}
+	context.datastore.EXPECT().GetWithPrefixAtRevision(instanceKeyPrefix, int64(123)).Return(resp, int64(123), nil)
+
+	instances, revision, err := instanceStore(t, context).ListContainerInstancesAtVersion(entityVersion)
+	assert.Nil(t, err, "Unexpected error when listing instances at a version")
+	assert.Equal(t, int64(123), revision, "Expected the revision the instances were read at")
+	validateFilterContainerInstancesResultsMatchDatastoreResponse(t, instances, resp)
+}
+
+func TestListContainerInstancesAtVersionBypassesView(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	view := newSeededView(t, 130, viewKV(context.instanceKey1, context.instanceJSON1, 129))
+	instanceStore, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(t, err, "Unexpected error when calling NewContainerInstanceStore")
+	context.datastore.EXPECT().GetWithPrefixAtRevision(instanceKeyPrefix, int64(0)).Return(map[string]storetypes.Entity{}, int64(131), nil)
+
+	instances, revision, err := instanceStore.ListContainerInstancesAtVersion("0")
+	assert.Nil(t, err, "Unexpected error when listing the latest instances")
+	assert.Equal(t, int64(131), revision, "Expected the revision the latest instances were read at in etcd")
+	assert.Empty(t, instances, "Expected the instances read from etcd")
+}
+
+func TestFilterContainerInstancesAtVersionStatusAndClusterARNFilter(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	resp := map[string]storetypes.Entity{
+		containerInstanceARN1: context.instanceEntity1,
+	}
+	instancesForClusterPrefix := instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
+	context.datastore.EXPECT().GetWithPrefixAtRevision(instancesForClusterPrefix, int64(123)).Return(resp, int64(123), nil)
+
+	filters := map[string]string{instanceStatusFilter: status1, instanceClusterFilter: clusterARN1}
+	instances, revision, err := instanceStore(t, context).FilterContainerInstancesAtVersion(filters, entityVersion)
+	assert.Nil(t, err, "Unexpected error when filtering instances at a version")
+	assert.Equal(t, int64(123), revision, "Expected the revision the instances were read at")
+	validateFilterContainerInstancesResultsMatchDatastoreResponse(t, instances, resp)
+}
+
+func TestFilterContainerInstancesAtVersionStatusFilter(t *testing.T) {
+	context := NewContainerInstanceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+	resp := map[string]storetypes.Entity{
+		containerInstanceARN1: context.instanceEntity1,
+	}
+	context.datastore.EXPECT().GetWithPrefixAtRevision(instanceKeyPrefix, int64(123)).Return(resp, int64(123), nil)
+
+	filters := map[string]string{instanceStatusFilter: status1}
+	instances, revision, err := instanceStore(t, context).FilterContainerInstancesAtVersion(filters, entityVersion)
+	assert.Nil(t, err, "Unexpected error when filtering instances at a version")
+	assert.Equal(t, int64(123), revision, "Expected the revision the instances were read at")
+	validateFilterContainerInstancesResultsMatchDatastoreResponse(t, instances, resp)
+}
+
 func instanceStore(t *testing.T, context *instanceStoreMockContext) ContainerInstanceStore {
-	instanceStore, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore)
+	instanceStore, err := NewContainerInstanceStore(context.datastore, context.etcdTxStore, stmRecordApplier{etcdTXStore: context.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	if err != nil {
 		t.Error("Unexpected error when calling NewContainerInstanceStore")
 	}
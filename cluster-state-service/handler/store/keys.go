@@ -0,0 +1,76 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"strings"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/pkg/errors"
+)
+
+// Entities are stored under keys namespaced by the account and region of their
+// cluster, in the form '<kind prefix><account>/<region>/<cluster name>/<ARN>',
+// so that clusters with the same name in different accounts or regions do not
+// overwrite each other's entities.
+
+// generateEntityKey returns the key of the entity with ARN 'arn' of the kind
+// stored under keyPrefix
+func generateEntityKey(keyPrefix string, cluster regex.ClusterIdentifier, arn string) (string, error) {
+	if cluster.Account == "" || cluster.Region == "" {
+		return "", errors.Errorf("Account and region of cluster '%s' should not be empty", cluster.Name)
+	}
+	if !regex.IsClusterName(cluster.Name) {
+		return "", errors.Errorf("Cluster name '%s' does not match expected regex", cluster.Name)
+	}
+	return clusterKeyPrefix(keyPrefix, cluster) + arn, nil
+}
+
+// clusterKeyPrefix returns the prefix of the keys of the entities of the kind
+// stored under keyPrefix that belong to cluster
+func clusterKeyPrefix(keyPrefix string, cluster regex.ClusterIdentifier) string {
+	return keyPrefix + cluster.Account + "/" + cluster.Region + "/" + cluster.Name + "/"
+}
+
+// resolveCluster returns the identifier of the cluster specified as 'cluster'
+// that the entity with ARN 'arn' belongs to. The entity ARN provides the
+// account and region when 'cluster' does not.
+func resolveCluster(cluster string, arn string) (regex.ClusterIdentifier, error) {
+	identifier, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return regex.ClusterIdentifier{}, err
+	}
+
+	account, region, err := regex.GetAccountAndRegionFromARN(arn)
+	if err != nil {
+		return regex.ClusterIdentifier{}, err
+	}
+	if identifier.Account == "" {
+		identifier.Account = account
+	}
+	if identifier.Region == "" {
+		identifier.Region = region
+	}
+	return identifier, nil
+}
+
+// isLegacyEntityKey returns true if the key of an entity stored under keyPrefix
+// uses the layout without account and region, '<kind prefix><cluster name>/<ARN>'
+func isLegacyEntityKey(keyPrefix string, key string) bool {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, keyPrefix), "/", 2)
+	return len(parts) == 2 && strings.HasPrefix(parts[1], "arn:")
+}
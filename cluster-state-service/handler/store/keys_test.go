@@ -0,0 +1,65 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	legacyTaskKey  = taskKeyPrefix + clusterName1 + "/" + keyTestTaskARN
+	currentTaskKey = taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + keyTestTaskARN
+
+	keyTestTaskARN = "arn:aws:ecs:us-east-1:123456789123:task/271022c0-f894-4aa2-b063-25bae55088d5"
+)
+
+func TestGenerateEntityKey(t *testing.T) {
+	cluster := regex.ClusterIdentifier{Account: accountID, Region: region, Name: clusterName1}
+	key, err := generateEntityKey(taskKeyPrefix, cluster, keyTestTaskARN)
+	assert.NoError(t, err, "Unexpected error generating entity key")
+	assert.Equal(t, currentTaskKey, key, "Unexpected entity key")
+}
+
+func TestGenerateEntityKeyWithoutAccountAndRegion(t *testing.T) {
+	_, err := generateEntityKey(taskKeyPrefix, regex.ClusterIdentifier{Name: clusterName1}, keyTestTaskARN)
+	assert.Error(t, err, "Expected an error generating entity key without account and region")
+}
+
+func TestResolveClusterFromClusterName(t *testing.T) {
+	cluster, err := resolveCluster(clusterName1, keyTestTaskARN)
+	assert.NoError(t, err, "Unexpected error resolving cluster")
+	assert.Equal(t, regex.ClusterIdentifier{Account: accountID, Region: region, Name: clusterName1}, cluster,
+		"Expected account and region to be taken from the entity ARN")
+}
+
+func TestResolveClusterFromRegionQualifiedClusterName(t *testing.T) {
+	cluster, err := resolveCluster("us-west-2:"+clusterName1, keyTestTaskARN)
+	assert.NoError(t, err, "Unexpected error resolving cluster")
+	assert.Equal(t, regex.ClusterIdentifier{Account: accountID, Region: "us-west-2", Name: clusterName1}, cluster,
+		"Expected region to be taken from the cluster")
+}
+
+func TestResolveClusterInvalidCluster(t *testing.T) {
+	_, err := resolveCluster("invalid/cluster", keyTestTaskARN)
+	assert.Error(t, err, "Expected an error resolving an invalid cluster")
+}
+
+func TestIsLegacyEntityKey(t *testing.T) {
+	assert.True(t, isLegacyEntityKey(taskKeyPrefix, legacyTaskKey), "Expected key without account and region to be legacy")
+	assert.False(t, isLegacyEntityKey(taskKeyPrefix, currentTaskKey), "Expected key with account and region not to be legacy")
+	assert.False(t, isLegacyEntityKey(instanceKeyPrefix, legacyTaskKey), "Expected key with another prefix not to be legacy")
+}
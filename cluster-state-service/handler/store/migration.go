@@ -0,0 +1,117 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	"github.com/pkg/errors"
+)
+
+// legacyKeyPrefixes are the prefixes under which entities were stored without
+// the account and region of their cluster in the key
+var legacyKeyPrefixes = []string{
+	taskKeyPrefix,
+	instanceKeyPrefix,
+	tombstoneKey(taskKeyPrefix),
+	tombstoneKey(instanceKeyPrefix),
+	historyKey(taskKeyPrefix),
+	historyKey(instanceKeyPrefix),
+}
+
+// MigrateLegacyKeys moves entities stored under keys of the form
+// '<kind prefix><cluster name>/<ARN>' to keys that include the account and
+// region of their cluster, '<kind prefix><account>/<region>/<cluster name>/<ARN>'.
+// The account and region are read from the ARN of the entity. It returns the
+// number of keys that were migrated and is safe to run more than once.
+func MigrateLegacyKeys(datastore DataStore, etcdTXStore EtcdTXStore) (int, error) {
+	if datastore == nil {
+		return 0, errors.New("Datastore is not initialized")
+	}
+	if etcdTXStore == nil {
+		return 0, errors.New("Etcd transactional store is not initialized")
+	}
+
+	migrated := 0
+	for _, keyPrefix := range legacyKeyPrefixes {
+		resp, err := datastore.GetWithPrefix(keyPrefix)
+		if err != nil {
+			return migrated, err
+		}
+
+		for key := range resp {
+			if !isLegacyEntityKey(keyPrefix, key) {
+				continue
+			}
+
+			newKey, err := migratedKey(keyPrefix, key)
+			if err != nil {
+				log.Warnf("Not migrating key '%s': %v", key, err)
+				continue
+			}
+
+			mover := &stmMover{
+				fromKey: key,
+				toKey:   newKey,
+			}
+			_, err = etcdTXStore.NewSTMRepeatable(context.TODO(), etcdTXStore.GetV3Client(), mover.moveRecord)
+			if err != nil {
+				return migrated, errors.Wrapf(err, "Could not migrate key '%s' to '%s'", key, newKey)
+			}
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+// migratedKey returns the key that the entity stored at the legacy key 'key'
+// is moved to
+func migratedKey(keyPrefix string, key string) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(key, keyPrefix), "/", 2)
+	clusterName, arn := parts[0], parts[1]
+
+	account, region, err := regex.GetAccountAndRegionFromARN(arn)
+	if err != nil {
+		return "", err
+	}
+	cluster := regex.ClusterIdentifier{
+		Account: account,
+		Region:  region,
+		Name:    clusterName,
+	}
+	return generateEntityKey(keyPrefix, cluster, arn)
+}
+
+type stmMover struct {
+	fromKey string
+	toKey   string
+}
+
+// moveRecord moves the record at fromKey to toKey. A record already at toKey
+// has been written with the new key layout and is kept.
+func (mover stmMover) moveRecord(stm concurrency.STM) error {
+	record := stm.Get(mover.fromKey)
+	if record == "" {
+		return nil
+	}
+	if stm.Get(mover.toKey) == "" {
+		stm.Put(mover.toKey, record)
+	}
+	stm.Del(mover.fromKey)
+	return nil
+}
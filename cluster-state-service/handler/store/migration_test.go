@@ -0,0 +1,109 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateLegacyKeysNilDatastore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	_, err := MigrateLegacyKeys(nil, mocks.NewMockEtcdTXStore(mockCtrl))
+	assert.Error(t, err, "Expected an error when datastore is nil")
+}
+
+func TestMigrateLegacyKeysGetWithPrefixFails(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	datastore := mocks.NewMockDataStore(mockCtrl)
+	etcdTxStore := mocks.NewMockEtcdTXStore(mockCtrl)
+
+	datastore.EXPECT().GetWithPrefix(taskKeyPrefix).Return(nil, errors.New("Error when getting keys"))
+
+	_, err := MigrateLegacyKeys(datastore, etcdTxStore)
+	assert.Error(t, err, "Expected an error when GetWithPrefix fails")
+}
+
+func TestMigrateLegacyKeys(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	datastore := mocks.NewMockDataStore(mockCtrl)
+	etcdTxStore := mocks.NewMockEtcdTXStore(mockCtrl)
+
+	resp := map[string]storetypes.Entity{
+		legacyTaskKey:  {Key: legacyTaskKey, Value: "legacy"},
+		currentTaskKey: {Key: currentTaskKey, Value: "current"},
+	}
+	datastore.EXPECT().GetWithPrefix(taskKeyPrefix).Return(resp, nil)
+	datastore.EXPECT().GetWithPrefix(gomock.Any()).Return(map[string]storetypes.Entity{}, nil).Times(len(legacyKeyPrefixes) - 1)
+
+	puts := map[string]string{}
+	deleted := []string{}
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == legacyTaskKey {
+				return "legacy"
+			}
+			return ""
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			puts[key] = val
+		},
+		delFunc: func(key string) {
+			deleted = append(deleted, key)
+		},
+	}
+	etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(_ interface{}, _ *clientv3.Client, apply func(concurrency.STM) error) {
+			assert.NoError(t, apply(mockSTM), "Unexpected error moving record")
+		}).Return(nil, nil)
+
+	migrated, err := MigrateLegacyKeys(datastore, etcdTxStore)
+	assert.NoError(t, err, "Unexpected error migrating legacy keys")
+	assert.Equal(t, 1, migrated, "Expected only the legacy key to be migrated")
+	assert.Equal(t, map[string]string{currentTaskKey: "legacy"}, puts, "Expected the record to be moved to the current key")
+	assert.Equal(t, []string{legacyTaskKey}, deleted, "Expected the legacy key to be deleted")
+}
+
+func TestMoveRecordKeepsExistingRecord(t *testing.T) {
+	deleted := false
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return "record"
+		},
+		delFunc: func(key string) {
+			assert.Equal(t, "from", key, "Unexpected key in Del")
+			deleted = true
+		},
+	}
+
+	mover := &stmMover{
+		fromKey: "from",
+		toKey:   "to",
+	}
+	err := mover.moveRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error moving record")
+	assert.True(t, deleted, "Expected the record at the legacy key to be deleted")
+}
@@ -30,6 +30,9 @@ type mockSTM struct {
 	// putFunc is the interceptor for the Put() method in the STM
 	// interface
 	putFunc func(key string, val string, opts ...clientv3.OpOption)
+	// delFunc is the interceptor for the Del() method in the STM
+	// interface
+	delFunc func(key string)
 }
 
 // Get implements the STM.Get() method by invoking the custom interceptor
@@ -43,3 +46,9 @@ func (stm *mockSTM) Get(key string) string {
 func (stm *mockSTM) Put(key string, val string, opts ...clientv3.OpOption) {
 	stm.putFunc(key, val, opts...)
 }
+
+// Del implements the STM.Del() method by invoking the custom interceptor
+// method
+func (stm *mockSTM) Del(key string) {
+	stm.delFunc(key)
+}
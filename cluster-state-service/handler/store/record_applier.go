@@ -0,0 +1,53 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/pkg/errors"
+)
+
+// RecordApplier defines methods to apply records to the store
+type RecordApplier interface {
+	// ApplyRecord adds the record of 'applier' to the store unless a
+	// record with the same or a higher version exists
+	ApplyRecord(applier *STMApplier) error
+}
+
+// stmRecordApplier applies each record in a transaction of its own
+type stmRecordApplier struct {
+	etcdTXStore EtcdTXStore
+}
+
+// NewRecordApplier initializes a record applier that applies each record in
+// a software transactional memory transaction of its own
+func NewRecordApplier(ts EtcdTXStore) (RecordApplier, error) {
+	if ts == nil {
+		return nil, errors.New("Etcd transactional store is not initialized")
+	}
+	return stmRecordApplier{
+		etcdTXStore: ts,
+	}, nil
+}
+
+func (recordApplier stmRecordApplier) ApplyRecord(applier *STMApplier) error {
+	_, err := recordApplier.etcdTXStore.NewSTMRepeatable(context.TODO(),
+		recordApplier.etcdTXStore.GetV3Client(),
+		func(stm concurrency.STM) error {
+			return applier.applyRecord(stm)
+		})
+	return err
+}
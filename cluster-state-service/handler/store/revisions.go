@@ -0,0 +1,216 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/goguardian/blox/cluster-state-service/handler/clients"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// keyspaceStart is the first key of the etcd keyspace. Watching every key
+	// from it makes every revision show up in the history, so that reading
+	// the history up to a revision never waits for changes that will not come.
+	keyspaceStart = "\x00"
+)
+
+// RevisionStore defines methods to inspect the etcd revisions that entity
+// versions refer to and the history of changes between them
+type RevisionStore interface {
+	GetRevisionRange() (storetypes.RevisionRange, error)
+	GetChanges(since int64, limit int) (storetypes.ChangePage, error)
+}
+
+type etcdRevisionStore struct {
+	etcd           clients.EtcdInterface
+	requestTimeout time.Duration
+}
+
+// NewRevisionStore initializes the etcdRevisionStore struct
+func NewRevisionStore(etcd clients.EtcdInterface, requestTimeout time.Duration) (RevisionStore, error) {
+	if etcd == nil {
+		return nil, errors.New("Etcd client is not initialized")
+	}
+	if requestTimeout <= 0 {
+		return nil, errors.New("Etcd request timeout has to be positive")
+	}
+	return etcdRevisionStore{
+		etcd:           etcd,
+		requestTimeout: requestTimeout,
+	}, nil
+}
+
+// GetRevisionRange returns the oldest revision that has not been compacted
+// and the current revision of etcd. Entities can be streamed from any entity
+// version in that range.
+func (revisionStore etcdRevisionStore) GetRevisionRange() (storetypes.RevisionRange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), revisionStore.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := revisionStore.etcd.Get(ctx, entityKeyPrefix, clientv3.WithCountOnly())
+	metrics.ObserveEtcdRequest(getOperation, start, err)
+	if err != nil {
+		return storetypes.RevisionRange{}, errors.Wrapf(err, "Could not read the current revision")
+	}
+	current := resp.Header.Revision
+
+	// Reading the first revision fails once any revision has been compacted
+	start = time.Now()
+	_, err = revisionStore.etcd.Get(ctx, entityKeyPrefix, clientv3.WithRev(1), clientv3.WithCountOnly())
+	metrics.ObserveEtcdRequest(getOperation, start, err)
+	if err == nil {
+		return storetypes.RevisionRange{Oldest: 1, Current: current}, nil
+	}
+	if err != rpctypes.ErrCompacted {
+		return storetypes.RevisionRange{}, errors.Wrapf(err, "Could not read the first revision")
+	}
+
+	// Watching from a compacted revision is cancelled with the revision that
+	// etcd has been compacted to
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	select {
+	case watchResp, ok := <-revisionStore.etcd.Watch(watchCtx, entityKeyPrefix, clientv3.WithRev(1)):
+		if !ok || watchResp.CompactRevision == 0 {
+			return storetypes.RevisionRange{}, errors.New("Could not read the revision that etcd has been compacted to")
+		}
+		return storetypes.RevisionRange{Oldest: watchResp.CompactRevision, Current: current}, nil
+	case <-ctx.Done():
+		return storetypes.RevisionRange{}, errors.Wrapf(ctx.Err(), "Could not read the revision that etcd has been compacted to")
+	}
+}
+
+// GetChanges returns the changes of tasks and container instances after
+// revision 'since' up to the current revision, in the order they were made.
+// Once the page holds 'limit' changes, it ends with the revision of the last
+// of them, so that the changes of a revision are never split across pages.
+// If the history after 'since' has been compacted, a CompactedEntityVersion
+// error is returned; if 'since' is newer than etcd, an
+// OutOfRangeEntityVersion error is.
+func (revisionStore etcdRevisionStore) GetChanges(since int64, limit int) (storetypes.ChangePage, error) {
+	if since < 0 {
+		return storetypes.ChangePage{}, errors.Errorf("Invalid revision %d to get changes since", since)
+	}
+	if limit <= 0 {
+		return storetypes.ChangePage{}, errors.Errorf("Invalid limit %d of changes", limit)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), revisionStore.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := revisionStore.etcd.Get(ctx, entityKeyPrefix, clientv3.WithCountOnly())
+	metrics.ObserveEtcdRequest(getOperation, start, err)
+	if err != nil {
+		return storetypes.ChangePage{}, errors.Wrapf(err, "Could not read the current revision")
+	}
+	current := resp.Header.Revision
+	if since > current {
+		return storetypes.ChangePage{}, types.NewOutOfRangeEntityVersion(errors.Errorf("Revision %d is newer than the current revision %d", since, current))
+	}
+
+	page := storetypes.ChangePage{Changes: []storetypes.Change{}, Next: since}
+	if since == current {
+		return page, nil
+	}
+
+	watchChan := revisionStore.etcd.Watch(ctx, keyspaceStart, clientv3.WithFromKey(), clientv3.WithRev(since+1), clientv3.WithPrevKV())
+	for {
+		select {
+		case watchResp, ok := <-watchChan:
+			if !ok {
+				return storetypes.ChangePage{}, errors.Errorf("Watching the changes since revision %d was closed", since)
+			}
+			if watchResp.CompactRevision != 0 {
+				return storetypes.ChangePage{}, types.NewCompactedEntityVersion(errors.Errorf("The changes since revision %d have been compacted to revision %d", since, watchResp.CompactRevision))
+			}
+			if err := watchResp.Err(); err != nil {
+				return storetypes.ChangePage{}, errors.Wrapf(err, "Could not watch the changes since revision %d", since)
+			}
+			// Etcd sends all events of a revision in the same response
+			for _, event := range watchResp.Events {
+				revision := event.Kv.ModRevision
+				if revision > page.Next && len(page.Changes) >= limit {
+					return page, nil
+				}
+				page.Next = revision
+				change, ok, err := toChange(event)
+				if err != nil {
+					return storetypes.ChangePage{}, err
+				}
+				if ok {
+					page.Changes = append(page.Changes, change)
+				}
+			}
+			if page.Next >= current {
+				return page, nil
+			}
+		case <-ctx.Done():
+			return storetypes.ChangePage{}, errors.Wrapf(ctx.Err(), "Could not read the changes since revision %d", since)
+		}
+	}
+}
+
+// toChange returns the change of the task or container instance that 'event'
+// puts or deletes. It returns false if the event changes any other key.
+func toChange(event *clientv3.Event) (storetypes.Change, bool, error) {
+	key := string(event.Kv.Key)
+	isTask := strings.HasPrefix(key, taskKeyPrefix)
+	if !isTask && !strings.HasPrefix(key, instanceKeyPrefix) {
+		return storetypes.Change{}, false, nil
+	}
+
+	change := storetypes.Change{
+		Deleted: event.Type == clientv3.EventTypeDelete,
+		Version: strconv.FormatInt(event.Kv.ModRevision, 10),
+	}
+	value := event.Kv.Value
+	if change.Deleted {
+		if event.PrevKv == nil {
+			return storetypes.Change{}, false, errors.Errorf("Could not read the value of key %s before it was deleted", key)
+		}
+		value = event.PrevKv.Value
+	}
+	record, err := decodeRecord(string(value))
+	if err != nil {
+		return storetypes.Change{}, false, errors.Wrapf(err, "Could not decode the value of key %s", key)
+	}
+
+	if isTask {
+		var task types.Task
+		if err := json.Unmarshal([]byte(record), &task); err != nil {
+			return storetypes.Change{}, false, errors.Wrapf(err, "Could not unmarshal the task of key %s", key)
+		}
+		change.Task = &task
+	} else {
+		var instance types.ContainerInstance
+		if err := json.Unmarshal([]byte(record), &instance); err != nil {
+			return storetypes.Change{}, false, errors.Wrapf(err, "Could not unmarshal the container instance of key %s", key)
+		}
+		change.ContainerInstance = &instance
+	}
+	return change, true, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRevisionStoreNilEtcd(t *testing.T) {
+	_, err := NewRevisionStore(nil, time.Minute)
+	assert.Error(t, err, "Expected an error when etcd is nil")
+}
+
+func TestGetRevisionRangeNotCompacted(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	gomock.InOrder(
+		etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil),
+		etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(viewGetResponse(42), nil),
+	)
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	revisions, err := revisionStore.GetRevisionRange()
+	assert.Nil(t, err, "Unexpected error getting the revision range")
+	assert.Equal(t, storetypes.RevisionRange{Oldest: 1, Current: 42}, revisions, "Expected every revision to be in range")
+}
+
+func TestGetRevisionRangeCompacted(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	watchChan := make(chan etcd.WatchResponse, 1)
+	watchChan <- etcd.WatchResponse{CompactRevision: 30}
+	gomock.InOrder(
+		etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil),
+		etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(nil, rpctypes.ErrCompacted),
+		etcdInterface.EXPECT().Watch(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(etcd.WatchChan(watchChan)),
+	)
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	revisions, err := revisionStore.GetRevisionRange()
+	assert.Nil(t, err, "Unexpected error getting the revision range")
+	assert.Equal(t, storetypes.RevisionRange{Oldest: 30, Current: 42}, revisions, "Expected the range to start at the compacted revision")
+}
+
+func TestGetRevisionRangeWatchClosed(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	watchChan := make(chan etcd.WatchResponse)
+	close(watchChan)
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(nil, rpctypes.ErrCompacted)
+	etcdInterface.EXPECT().Watch(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(etcd.WatchChan(watchChan))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetRevisionRange()
+	assert.Error(t, err, "Expected an error when the compacted revision cannot be read")
+}
+
+func TestGetRevisionRangeGetFails(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(nil, errors.New("Get failed"))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetRevisionRange()
+	assert.Error(t, err, "Expected an error when etcd get fails")
+}
+
+func TestGetChangesInvalidArguments(t *testing.T) {
+	revisionStore, err := NewRevisionStore(mocks.NewMockEtcdInterface(gomock.NewController(t)), time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetChanges(-1, 10)
+	assert.Error(t, err, "Expected an error when the revision is negative")
+	_, err = revisionStore.GetChanges(10, 0)
+	assert.Error(t, err, "Expected an error when the limit is not positive")
+}
+
+func TestGetChangesFutureRevision(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil)
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetChanges(43, 10)
+	assert.IsType(t, types.OutOfRangeEntityVersion{}, err, "Expected an out of range error when the revision is newer than etcd")
+}
+
+func TestGetChangesUpToDate(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil)
+	etcdInterface.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	page, err := revisionStore.GetChanges(42, 10)
+	assert.Nil(t, err, "Unexpected error getting the changes")
+	assert.Equal(t, storetypes.ChangePage{Changes: []storetypes.Change{}, Next: 42}, page, "Expected no changes after the current revision")
+}
+
+func TestGetChanges(t *testing.T) {
+	taskKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + taskARN1
+	instanceKey := instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + containerInstanceARN1
+	taskJSON := viewTaskJSON(t, taskARN1, clusterARN1)
+	instanceJSON := viewInstanceJSON(t, containerInstanceARN1, clusterARN1)
+	watchChan := make(chan etcd.WatchResponse, 2)
+	watchChan <- etcd.WatchResponse{Events: []*etcd.Event{
+		{Type: mvccpb.PUT, Kv: viewKV(taskKey, taskJSON, 11)},
+		{Type: mvccpb.PUT, Kv: viewKV(historyKeyPrefix+"task", taskJSON, 11)},
+		{Type: mvccpb.DELETE, Kv: viewKV(instanceKey, "", 12), PrevKv: viewKV(instanceKey, instanceJSON, 10)},
+	}}
+	watchChan <- etcd.WatchResponse{Events: []*etcd.Event{
+		{Type: mvccpb.PUT, Kv: viewKV("css/compaction/leader", "leader", 13)},
+	}}
+
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(13), nil)
+	etcdInterface.EXPECT().Watch(gomock.Any(), keyspaceStart, gomock.Any(), gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	page, err := revisionStore.GetChanges(10, 10)
+	assert.Nil(t, err, "Unexpected error getting the changes")
+	assert.Equal(t, int64(13), page.Next, "Expected the next page to start after the current revision")
+	assert.Equal(t, 2, len(page.Changes), "Expected the changes of the task and the instance")
+	assert.False(t, page.Changes[0].Deleted, "Expected the task to be put")
+	assert.Equal(t, "11", page.Changes[0].Version, "Expected the revision the task was put at")
+	assert.Equal(t, taskARN1, *page.Changes[0].Task.Detail.TaskARN, "Expected the task that was put")
+	assert.True(t, page.Changes[1].Deleted, "Expected the instance to be deleted")
+	assert.Equal(t, "12", page.Changes[1].Version, "Expected the revision the instance was deleted at")
+	assert.Equal(t, containerInstanceARN1, *page.Changes[1].ContainerInstance.Detail.ContainerInstanceARN, "Expected the instance as it was before it was deleted")
+}
+
+func TestGetChangesLimitDoesNotSplitRevisions(t *testing.T) {
+	taskKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
+	watchChan := make(chan etcd.WatchResponse, 1)
+	watchChan <- etcd.WatchResponse{Events: []*etcd.Event{
+		{Type: mvccpb.PUT, Kv: viewKV(taskKey+taskARN1, viewTaskJSON(t, taskARN1, clusterARN1), 11)},
+		{Type: mvccpb.PUT, Kv: viewKV(taskKey+taskARN2, viewTaskJSON(t, taskARN2, clusterARN1), 11)},
+		{Type: mvccpb.PUT, Kv: viewKV(taskKey+taskARN3, viewTaskJSON(t, taskARN3, clusterARN1), 12)},
+	}}
+
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(12), nil)
+	etcdInterface.EXPECT().Watch(gomock.Any(), keyspaceStart, gomock.Any(), gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	page, err := revisionStore.GetChanges(10, 1)
+	assert.Nil(t, err, "Unexpected error getting the changes")
+	assert.Equal(t, int64(11), page.Next, "Expected the next page to start after the last revision in the page")
+	assert.Equal(t, 2, len(page.Changes), "Expected every change of the revision in the page")
+}
+
+func TestGetChangesCompacted(t *testing.T) {
+	watchChan := make(chan etcd.WatchResponse, 1)
+	watchChan <- etcd.WatchResponse{CompactRevision: 30}
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil)
+	etcdInterface.EXPECT().Watch(gomock.Any(), keyspaceStart, gomock.Any(), gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetChanges(10, 10)
+	assert.IsType(t, types.CompactedEntityVersion{}, err, "Expected a compacted error when the changes have been compacted")
+}
+
+func TestGetChangesWatchClosed(t *testing.T) {
+	watchChan := make(chan etcd.WatchResponse)
+	close(watchChan)
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), entityKeyPrefix, gomock.Any()).Return(viewGetResponse(42), nil)
+	etcdInterface.EXPECT().Watch(gomock.Any(), keyspaceStart, gomock.Any(), gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+	revisionStore, err := NewRevisionStore(etcdInterface, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the revision store")
+
+	_, err = revisionStore.GetChanges(10, 10)
+	assert.Error(t, err, "Expected an error when watching the changes is closed")
+}
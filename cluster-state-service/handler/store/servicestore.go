@@ -0,0 +1,544 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	serviceKeyPrefix     = "ecs/service/"
+	serviceStatusFilter  = "status"
+	serviceClusterFilter = "cluster"
+	serviceNameFilter    = "serviceName"
+)
+
+var (
+	supportedServiceFilters = map[string]string{serviceStatusFilter: "", serviceClusterFilter: "", serviceNameFilter: ""}
+)
+
+// ServiceStore defines methods to access services from the datastore
+type ServiceStore interface {
+	AddService(service string) error
+	AddServiceActionEvent(event string) error
+	AddDeploymentStateChangeEvent(event string) error
+	GetService(cluster string, serviceARN string) (*storetypes.VersionedService, error)
+	ListServices() ([]storetypes.VersionedService, error)
+	FilterServices(filterMap map[string]string) ([]storetypes.VersionedService, error)
+	StreamServices(ctx context.Context, entityVersion string) (chan storetypes.VersionedService, error)
+	DeleteService(cluster, serviceARN string) error
+}
+
+type eventServiceStore struct {
+	datastore   DataStore
+	etcdTXStore EtcdTXStore
+}
+
+// NewServiceStore initializes the eventServiceStore struct
+func NewServiceStore(ds DataStore, ts EtcdTXStore) (ServiceStore, error) {
+	if ds == nil {
+		return nil, errors.New("Datastore is not initialized")
+	}
+	if ts == nil {
+		return nil, errors.New("Etcd transactional store is not initialized")
+	}
+
+	return eventServiceStore{
+		datastore:   ds,
+		etcdTXStore: ts,
+	}, nil
+}
+
+// AddService adds the service described by ECS represented in the serviceJSON
+// to the datastore. Events already recorded for the service are kept.
+func (serviceStore eventServiceStore) AddService(serviceJSON string) error {
+	if len(serviceJSON) == 0 {
+		return errors.New("Service JSON should not be empty")
+	}
+
+	service, err := serviceStore.unmarshalService(serviceJSON)
+	if err != nil {
+		return err
+	}
+	if service.Detail == nil {
+		return errors.New("Service detail not initialized in JSON")
+	}
+	if aws.StringValue(service.Detail.ClusterARN) == "" {
+		return errors.New("Cluster ARN should not be empty in service JSON")
+	}
+
+	key, err := serviceStore.getServiceKeyFromClusterARN(aws.StringValue(service.Detail.ClusterARN),
+		aws.StringValue(service.Detail.ServiceARN))
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Service store unmarshalled service: %s, trying to add it to the store", service.Detail.String())
+
+	return serviceStore.mergeService(key, func(existing *types.Service) {
+		existing.Detail.MergeDescribed(*service.Detail)
+	})
+}
+
+// AddServiceActionEvent records the service action event represented in the
+// eventJSON in the service it is about
+func (serviceStore eventServiceStore) AddServiceActionEvent(eventJSON string) error {
+	if len(eventJSON) == 0 {
+		return errors.New("Service action event JSON should not be empty")
+	}
+
+	var event types.ServiceActionEvent
+	err := json.Unmarshal([]byte(eventJSON), &event)
+	if err != nil {
+		return errors.Wrapf(err, "Error unmarshaling service action event '%s'", eventJSON)
+	}
+	if event.Detail == nil {
+		return errors.New("Service action event detail not initialized in JSON")
+	}
+	if aws.StringValue(event.Detail.ClusterARN) == "" {
+		return errors.New("Cluster ARN should not be empty in service action event JSON")
+	}
+
+	key, err := serviceStore.getServiceKeyFromClusterARN(aws.StringValue(event.Detail.ClusterARN), event.ServiceARN())
+	if err != nil {
+		return err
+	}
+
+	return serviceStore.mergeService(key, func(existing *types.Service) {
+		existing.Detail.ApplyAction(event)
+	})
+}
+
+// AddDeploymentStateChangeEvent records the deployment state change event
+// represented in the eventJSON in the service whose deployment changed state.
+// Deployment state change events do not carry the cluster of the service, so
+// events for services in the short ARN format that are not in the datastore
+// yet are dropped. The reconciler adds the deployment when it loads the service.
+func (serviceStore eventServiceStore) AddDeploymentStateChangeEvent(eventJSON string) error {
+	if len(eventJSON) == 0 {
+		return errors.New("Deployment state change event JSON should not be empty")
+	}
+
+	var event types.DeploymentStateChangeEvent
+	err := json.Unmarshal([]byte(eventJSON), &event)
+	if err != nil {
+		return errors.Wrapf(err, "Error unmarshaling deployment state change event '%s'", eventJSON)
+	}
+	if event.Detail == nil {
+		return errors.New("Deployment state change event detail not initialized in JSON")
+	}
+	if aws.StringValue(event.Detail.DeploymentID) == "" {
+		return errors.New("Deployment ID should not be empty in deployment state change event JSON")
+	}
+
+	key, err := serviceStore.findServiceKey(event.ServiceARN())
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		log.Infof("Dropping deployment state change event for unknown service '%s'", event.ServiceARN())
+		return nil
+	}
+
+	return serviceStore.mergeService(key, func(existing *types.Service) {
+		existing.Detail.ApplyDeploymentStateChange(event)
+	})
+}
+
+// GetService gets a service with ARN 'serviceARN' belonging to cluster 'cluster'
+func (serviceStore eventServiceStore) GetService(cluster string, serviceARN string) (*storetypes.VersionedService, error) {
+	key, err := serviceStore.getServiceKey(cluster, serviceARN)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not generate service key for cluster '%s' and service '%s'", cluster, serviceARN)
+	}
+	return serviceStore.getServiceByKey(key)
+}
+
+// ListServices lists all services existing in the datastore
+func (serviceStore eventServiceStore) ListServices() ([]storetypes.VersionedService, error) {
+	return serviceStore.getServicesByKeyPrefix(serviceKeyPrefix)
+}
+
+// FilterServices returns all services from the datastore that match the provided filters
+func (serviceStore eventServiceStore) FilterServices(filterMap map[string]string) ([]storetypes.VersionedService, error) {
+	if len(filterMap) == 0 {
+		return nil, errors.New("There has to be at least one filter")
+	}
+
+	filters := make([]string, 0, len(filterMap))
+	for k, v := range filterMap {
+		if v != "" {
+			filters = append(filters, k)
+		}
+	}
+	if len(filters) == 0 {
+		return nil, errors.New("There has to be at least one filter with a filter value set")
+	}
+
+	if !serviceStore.areFiltersValid(filters) {
+		return nil, errors.Errorf("At least one of the provided filters '%v' is not supported.", filters)
+	}
+
+	var result []storetypes.VersionedService
+	var err error
+	if cluster := filterMap[serviceClusterFilter]; cluster != "" {
+		result, err = serviceStore.filterServicesByCluster(cluster)
+	} else {
+		result, err = serviceStore.ListServices()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range filterMap {
+		if k == serviceClusterFilter || v == "" {
+			continue
+		}
+		serviceFilter, err := serviceStore.getServiceFilter(k)
+		if err != nil {
+			return nil, err
+		}
+		result = serviceStore.filterServices(result, serviceFilter, v)
+	}
+
+	return result, nil
+}
+
+// StreamServices streams all changes in the service keyspace into a channel
+func (serviceStore eventServiceStore) StreamServices(ctx context.Context, entityVersion string) (chan storetypes.VersionedService, error) {
+	serviceStoreCtx, cancel := context.WithCancel(ctx) // go routine serviceStore.pipeBetweenChannels() handles canceling this context
+
+	dsChan, err := serviceStore.datastore.StreamWithPrefix(serviceStoreCtx, serviceKeyPrefix, entityVersion)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	serviceRespChan := make(chan storetypes.VersionedService) // go routine serviceStore.pipeBetweenChannels() handles closing of this channel
+	go serviceStore.pipeBetweenChannels(serviceStoreCtx, cancel, dsChan, serviceRespChan)
+	return serviceRespChan, nil
+}
+
+// DeleteService deletes the service record from the data store
+func (serviceStore eventServiceStore) DeleteService(cluster string, serviceARN string) error {
+	key, err := serviceStore.getServiceKey(cluster, serviceARN)
+	if err != nil {
+		return errors.Wrapf(err, "Could not generate service key for cluster '%s' and service '%s'",
+			cluster, serviceARN)
+	}
+	numKeysDeleted, err := serviceStore.datastore.Delete(key)
+	log.Debugf("Deleted '%d' key(s) from the store for service '%s', belonging to cluster '%s'",
+		numKeysDeleted, serviceARN, cluster)
+	return err
+}
+
+// mergeService applies change to the service stored at key, creating the
+// service from its key if it does not exist yet
+func (serviceStore eventServiceStore) mergeService(key string, change func(*types.Service)) error {
+	merger := &STMMerger{
+		recordKey: key,
+		merge: func(existingJSON string) (string, error) {
+			service, err := serviceStore.newServiceFromKey(key)
+			if err != nil {
+				return "", err
+			}
+			if existingJSON != "" {
+				service, err = serviceStore.unmarshalService(existingJSON)
+				if err != nil {
+					return "", err
+				}
+				if service.Detail == nil {
+					return "", errors.Errorf("Service detail not initialized in existing service '%s'", existingJSON)
+				}
+			}
+
+			change(&service)
+
+			mergedJSON, err := json.Marshal(service)
+			if err != nil {
+				return "", errors.Wrapf(err, "Error marshaling service")
+			}
+			return string(mergedJSON), nil
+		},
+	}
+	_, err := serviceStore.etcdTXStore.NewSTMRepeatable(context.TODO(),
+		serviceStore.etcdTXStore.GetV3Client(),
+		merger.mergeRecord)
+	return err
+}
+
+// newServiceFromKey returns a service that only knows its ARN, name and
+// cluster, which are recovered from the key it is stored under
+func (serviceStore eventServiceStore) newServiceFromKey(key string) (types.Service, error) {
+	// Keys are in the form 'ecs/service/<account>/<region>/<cluster name>/<ARN>'
+	parts := strings.SplitN(strings.TrimPrefix(key, serviceKeyPrefix), "/", 4)
+	if len(parts) != 4 {
+		return types.Service{}, errors.Errorf("Invalid service key '%s'", key)
+	}
+	account, region, clusterName, serviceARN := parts[0], parts[1], parts[2], parts[3]
+
+	serviceName, err := regex.GetServiceNameFromARN(serviceARN)
+	if err != nil {
+		return types.Service{}, err
+	}
+	partition := strings.SplitN(serviceARN, ":", 3)[1]
+	clusterARN := "arn:" + partition + ":ecs:" + region + ":" + account + ":cluster/" + clusterName
+
+	return types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN:  aws.String(clusterARN),
+			ServiceARN:  aws.String(serviceARN),
+			ServiceName: aws.String(serviceName),
+		},
+	}, nil
+}
+
+// findServiceKey returns the key of the service with ARN 'serviceARN'. The
+// cluster of services in the short ARN format is only known from the
+// datastore. An empty key is returned if such a service is not in the
+// datastore.
+func (serviceStore eventServiceStore) findServiceKey(serviceARN string) (string, error) {
+	if !regex.IsServiceARN(serviceARN) {
+		return "", errors.Errorf("Service ARN '%s' does not match expected regex", serviceARN)
+	}
+	account, region, err := regex.GetAccountAndRegionFromARN(serviceARN)
+	if err != nil {
+		return "", err
+	}
+
+	if clusterName, err := regex.GetClusterNameFromServiceARN(serviceARN); err == nil {
+		identifier := regex.ClusterIdentifier{Account: account, Region: region, Name: clusterName}
+		return generateServiceKey(identifier, serviceARN)
+	}
+
+	resp, err := serviceStore.datastore.GetWithPrefix(serviceKeyPrefix + account + "/" + region + "/")
+	if err != nil {
+		return "", err
+	}
+	for key := range resp {
+		if strings.HasSuffix(key, "/"+serviceARN) {
+			return key, nil
+		}
+	}
+	return "", nil
+}
+
+func (serviceStore eventServiceStore) areFiltersValid(filters []string) bool {
+	if len(filters) > len(supportedServiceFilters) {
+		return false
+	}
+	for _, f := range filters {
+		_, ok := supportedServiceFilters[f]
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type serviceFilter func(string, types.Service) bool
+
+func isServiceStatus(status string, service types.Service) bool {
+	return strings.ToLower(status) == strings.ToLower(service.Detail.Status)
+}
+
+func isServiceName(serviceName string, service types.Service) bool {
+	return serviceName == aws.StringValue(service.Detail.ServiceName)
+}
+
+func (serviceStore eventServiceStore) getServiceFilter(filterName string) (serviceFilter, error) {
+	switch filterName {
+	case serviceStatusFilter:
+		return isServiceStatus, nil
+	case serviceNameFilter:
+		return isServiceName, nil
+	}
+	return nil, errors.Errorf("Unsupported service filter: %v", filterName)
+}
+
+func (serviceStore eventServiceStore) filterServices(services []storetypes.VersionedService, filter serviceFilter, filterValue string) []storetypes.VersionedService {
+	filteredServices := []storetypes.VersionedService{}
+	for _, versionedService := range services {
+		if filter(filterValue, versionedService.Service) {
+			filteredServices = append(filteredServices, versionedService)
+		}
+	}
+	return filteredServices
+}
+
+func (serviceStore eventServiceStore) filterServicesByCluster(cluster string) ([]storetypes.VersionedService, error) {
+	identifier, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cluster ARNs identify a single cluster, whose services share a key prefix
+	if identifier.Account != "" {
+		return serviceStore.getServicesByKeyPrefix(clusterKeyPrefix(serviceKeyPrefix, identifier))
+	}
+
+	services, err := serviceStore.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	filteredServices := []storetypes.VersionedService{}
+	for _, versionedService := range services {
+		if identifier.Matches(aws.StringValue(versionedService.Service.Detail.ClusterARN)) {
+			filteredServices = append(filteredServices, versionedService)
+		}
+	}
+	return filteredServices, nil
+}
+
+func (serviceStore eventServiceStore) getServiceKeyFromClusterARN(clusterARN string, serviceARN string) (string, error) {
+	cluster, err := regex.GetClusterFromARN(clusterARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error retrieving cluster from ARN '%s' for service", clusterARN)
+	}
+	return generateServiceKey(cluster, serviceARN)
+}
+
+func (serviceStore eventServiceStore) getServiceKey(cluster string, serviceARN string) (string, error) {
+	if len(cluster) == 0 {
+		return "", errors.New("Cluster should not be empty")
+	}
+	if len(serviceARN) == 0 {
+		return "", errors.New("Service ARN should not be empty")
+	}
+
+	identifier, err := resolveCluster(cluster, serviceARN)
+	if err != nil {
+		return "", err
+	}
+
+	return generateServiceKey(identifier, serviceARN)
+}
+
+func (serviceStore eventServiceStore) pipeBetweenChannels(ctx context.Context, cancel context.CancelFunc, dsChan chan map[string]storetypes.Entity, serviceRespChan chan storetypes.VersionedService) {
+	defer close(serviceRespChan)
+	defer cancel()
+
+	for {
+		select {
+		case resp, ok := <-dsChan:
+			if !ok {
+				return
+			}
+			for _, entity := range resp {
+				var versionedService storetypes.VersionedService
+				service, err := serviceStore.unmarshalService(entity.Value)
+				if err != nil {
+					versionedService.Err = err
+					serviceRespChan <- versionedService
+					return
+				}
+				versionedService.Service = service
+				versionedService.Version = entity.Version
+				serviceRespChan <- versionedService
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (serviceStore eventServiceStore) getServiceByKey(key string) (*storetypes.VersionedService, error) {
+	if len(key) == 0 {
+		return nil, errors.New("Key cannot be empty")
+	}
+
+	resp, err := serviceStore.datastore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) == 0 {
+		return nil, nil
+	}
+
+	if len(resp) > 1 {
+		return nil, errors.Errorf("Multiple entries exist in the datastore with key %v", key)
+	}
+
+	var versionedService storetypes.VersionedService
+	for _, entity := range resp {
+		versionedService.Service, err = serviceStore.unmarshalService(entity.Value)
+		versionedService.Version = entity.Version
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	return &versionedService, nil
+}
+
+func (serviceStore eventServiceStore) getServicesByKeyPrefix(key string) ([]storetypes.VersionedService, error) {
+	if len(key) == 0 {
+		return nil, errors.New("Key cannot be empty")
+	}
+
+	resp, err := serviceStore.datastore.GetWithPrefix(key)
+	if err != nil {
+		return nil, err
+	}
+
+	versionedServices := make([]storetypes.VersionedService, 0, len(resp))
+	for _, entity := range resp {
+		var versionedService storetypes.VersionedService
+		versionedService.Service, err = serviceStore.unmarshalService(entity.Value)
+		versionedService.Version = entity.Version
+		if err != nil {
+			return nil, err
+		}
+		versionedServices = append(versionedServices, versionedService)
+	}
+	return versionedServices, nil
+}
+
+func (serviceStore eventServiceStore) unmarshalService(val string) (types.Service, error) {
+	var service types.Service
+	err := json.Unmarshal([]byte(val), &service)
+	if err != nil {
+		return service, errors.Wrapf(err, "Error unmarshaling service '%s'", val)
+	}
+
+	return service, nil
+}
+
+func generateServiceKey(cluster regex.ClusterIdentifier, serviceARN string) (string, error) {
+	if !regex.IsServiceARN(serviceARN) {
+		return "", errors.Errorf("Error generating service key. Service ARN '%s' does not match expected regex", serviceARN)
+	}
+	// ARNs in the long format carry the name of the cluster, which has to
+	// match the cluster the service is stored under
+	if clusterName, err := regex.GetClusterNameFromServiceARN(serviceARN); err == nil && clusterName != cluster.Name {
+		return "", errors.Errorf("Error generating service key. Service ARN '%s' does not belong to cluster '%s'", serviceARN, cluster.Name)
+	}
+	key, err := generateEntityKey(serviceKeyPrefix, cluster, serviceARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating service key")
+	}
+	return key, nil
+}
@@ -0,0 +1,460 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	serviceName1    = "web"
+	serviceName2    = "worker"
+	serviceARN1     = "arn:aws:ecs:us-east-1:123456789123:service/" + serviceName1
+	serviceARN2     = "arn:aws:ecs:us-east-1:123456789123:service/" + clusterName2 + "/" + serviceName2
+	serviceStatus1  = "ACTIVE"
+	serviceStatus2  = "DRAINING"
+	deploymentID1   = "ecs-svc/9223370564341623665"
+	serviceUpdated1 = "2017-11-21T00:00:00Z"
+	serviceUpdated2 = "2017-11-21T00:05:00Z"
+)
+
+type serviceStoreMockContext struct {
+	mockCtrl       *gomock.Controller
+	datastore      *mocks.MockDataStore
+	etcdTxStore    *mocks.MockEtcdTXStore
+	service1       types.Service
+	service2       types.Service
+	serviceEntity1 storetypes.Entity
+	serviceEntity2 storetypes.Entity
+	serviceJSON1   string
+	serviceJSON2   string
+	serviceKey1    string
+	serviceKey2    string
+}
+
+func NewServiceStoreMockContext(t *testing.T) *serviceStoreMockContext {
+	context := serviceStoreMockContext{}
+	context.mockCtrl = gomock.NewController(t)
+	context.datastore = mocks.NewMockDataStore(context.mockCtrl)
+	context.etcdTxStore = mocks.NewMockEtcdTXStore(context.mockCtrl)
+
+	context.service1 = types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN:  &clusterARN1,
+			ServiceARN:  &serviceARN1,
+			ServiceName: &serviceName1,
+			Status:      serviceStatus1,
+			Deployments: []*types.Deployment{{ID: &deploymentID1, Status: "PRIMARY"}},
+			UpdatedAt:   &serviceUpdated1,
+		},
+	}
+	context.serviceJSON1 = marshalService(t, context.service1)
+	context.serviceKey1 = serviceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + serviceARN1
+	context.serviceEntity1 = setupEntity(context.serviceKey1, context.serviceJSON1, entityVersion)
+
+	context.service2 = types.Service{
+		Detail: &types.ServiceDetail{
+			ClusterARN:  &clusterARN2,
+			ServiceARN:  &serviceARN2,
+			ServiceName: &serviceName2,
+			Status:      serviceStatus2,
+			UpdatedAt:   &serviceUpdated1,
+		},
+	}
+	context.serviceJSON2 = marshalService(t, context.service2)
+	context.serviceKey2 = serviceKeyPrefix + accountID + "/" + region + "/" + clusterName2 + "/" + serviceARN2
+	context.serviceEntity2 = setupEntity(context.serviceKey2, context.serviceJSON2, entityVersion)
+
+	return &context
+}
+
+func TestServiceStoreNilDatastore(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := NewServiceStore(nil, context.etcdTxStore)
+	assert.Error(t, err, "Expected an error when datastore is nil")
+}
+
+func TestServiceStoreNilEtcdTxStore(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := NewServiceStore(context.datastore, nil)
+	assert.Error(t, err, "Expected an error when etcd transactional store is nil")
+}
+
+func TestAddServiceEmptyServiceJSON(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := serviceStore(t, context).AddService("")
+	assert.Error(t, err, "Expected an error when service JSON is empty in AddService")
+}
+
+func TestAddServiceJSONUnmarshalError(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := serviceStore(t, context).AddService("invalidJSON")
+	assert.Error(t, err, "Expected an error when service JSON is invalid in AddService")
+}
+
+func TestAddServiceEmptyClusterARN(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	service := types.Service{
+		Detail: &types.ServiceDetail{
+			ServiceARN: &serviceARN1,
+		},
+	}
+	err := serviceStore(t, context).AddService(marshalService(t, service))
+	assert.Error(t, err, "Expected an error when cluster ARN is empty in AddService")
+}
+
+func TestAddServiceKeepsStreamEventsAndRolloutState(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	existing := context.service1
+	existingDetail := *existing.Detail
+	existingDetail.Deployments = []*types.Deployment{{ID: &deploymentID1, RolloutState: "IN_PROGRESS"}}
+	existingDetail.Events = []*types.ServiceEvent{{ID: aws.String("event"), EventName: "SERVICE_STEADY_STATE", CreatedAt: &serviceUpdated1}}
+	existing.Detail = &existingDetail
+
+	var merged types.Service
+	context.expectMerge(t, context.serviceKey1, marshalService(t, existing), &merged)
+
+	err := serviceStore(t, context).AddService(context.serviceJSON1)
+	assert.NoError(t, err, "Unexpected error when adding service")
+	assert.Equal(t, "PRIMARY", merged.Detail.Deployments[0].Status, "Expected deployment to be replaced by the described one")
+	assert.Equal(t, "IN_PROGRESS", merged.Detail.Deployments[0].RolloutState, "Expected rollout state to be kept")
+	assert.Len(t, merged.Detail.Events, 1, "Expected events from the event stream to be kept")
+}
+
+func TestAddServiceSTMRepeatableFails(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	context.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("Error when getting key"))
+
+	err := serviceStore(t, context).AddService(context.serviceJSON1)
+	assert.Error(t, err, "Expected error when STM repeatable fails to execute with an error")
+}
+
+func TestAddServiceActionEventCreatesService(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	var merged types.Service
+	context.expectMerge(t, context.serviceKey1, "", &merged)
+
+	err := serviceStore(t, context).AddServiceActionEvent(serviceActionEventJSON(t, serviceARN1, clusterARN1))
+	assert.NoError(t, err, "Unexpected error when adding service action event")
+	assert.Equal(t, clusterARN1, aws.StringValue(merged.Detail.ClusterARN), "Unexpected cluster ARN of new service")
+	assert.Equal(t, serviceName1, aws.StringValue(merged.Detail.ServiceName), "Unexpected name of new service")
+	assert.Len(t, merged.Detail.Events, 1, "Expected service action event to be recorded")
+	assert.Equal(t, serviceUpdated2, aws.StringValue(merged.Detail.UpdatedAt), "Expected updated at to move forward")
+}
+
+func TestAddServiceActionEventServiceInAnotherCluster(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := serviceStore(t, context).AddServiceActionEvent(serviceActionEventJSON(t, serviceARN2, clusterARN1))
+	assert.Error(t, err, "Expected an error when the service ARN does not belong to the cluster of the event")
+}
+
+func TestAddServiceActionEventNoDetail(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := serviceStore(t, context).AddServiceActionEvent(`{"resources":["` + serviceARN1 + `"]}`)
+	assert.Error(t, err, "Expected an error when service action event detail is empty")
+}
+
+func TestAddDeploymentStateChangeEventShortARN(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.serviceKey1: context.serviceEntity1}
+	context.datastore.EXPECT().GetWithPrefix(serviceKeyPrefix+accountID+"/"+region+"/").Return(resp, nil)
+	var merged types.Service
+	context.expectMerge(t, context.serviceKey1, context.serviceJSON1, &merged)
+
+	err := serviceStore(t, context).AddDeploymentStateChangeEvent(deploymentStateChangeEventJSON(t, serviceARN1))
+	assert.NoError(t, err, "Unexpected error when adding deployment state change event")
+	assert.Equal(t, "COMPLETED", merged.Detail.Deployments[0].RolloutState, "Unexpected rollout state of deployment")
+}
+
+func TestAddDeploymentStateChangeEventLongARN(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(gomock.Any()).Times(0)
+	var merged types.Service
+	context.expectMerge(t, context.serviceKey2, "", &merged)
+
+	err := serviceStore(t, context).AddDeploymentStateChangeEvent(deploymentStateChangeEventJSON(t, serviceARN2))
+	assert.NoError(t, err, "Unexpected error when adding deployment state change event")
+	assert.Equal(t, clusterARN2, aws.StringValue(merged.Detail.ClusterARN), "Unexpected cluster ARN of new service")
+	assert.Len(t, merged.Detail.Deployments, 1, "Expected deployment to be created")
+}
+
+func TestAddDeploymentStateChangeEventUnknownService(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(gomock.Any()).Return(map[string]storetypes.Entity{}, nil)
+	context.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := serviceStore(t, context).AddDeploymentStateChangeEvent(deploymentStateChangeEventJSON(t, serviceARN1))
+	assert.NoError(t, err, "Unexpected error when dropping deployment state change event for unknown service")
+}
+
+func TestGetService(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.serviceKey1: context.serviceEntity1}
+	context.datastore.EXPECT().Get(context.serviceKey1).Return(resp, nil)
+
+	service, err := serviceStore(t, context).GetService(clusterARN1, serviceARN1)
+	assert.NoError(t, err, "Unexpected error when getting service")
+	assert.Equal(t, context.service1, service.Service, "Unexpected service")
+	assert.Equal(t, entityVersion, service.Version, "Unexpected service version")
+}
+
+func TestGetServiceNotFound(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Get(context.serviceKey1).Return(map[string]storetypes.Entity{}, nil)
+
+	service, err := serviceStore(t, context).GetService(clusterARN1, serviceARN1)
+	assert.NoError(t, err, "Unexpected error when getting service")
+	assert.Nil(t, service, "Expected no service")
+}
+
+func TestGetServiceInvalidServiceARN(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := serviceStore(t, context).GetService(clusterARN1, "invalidARN")
+	assert.Error(t, err, "Expected an error when service ARN is invalid")
+}
+
+func TestListServices(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{
+		context.serviceKey1: context.serviceEntity1,
+		context.serviceKey2: context.serviceEntity2,
+	}
+	context.datastore.EXPECT().GetWithPrefix(serviceKeyPrefix).Return(resp, nil)
+
+	services, err := serviceStore(t, context).ListServices()
+	assert.NoError(t, err, "Unexpected error when listing services")
+	assert.Len(t, services, 2, "Unexpected number of services")
+}
+
+func TestFilterServicesNoFilters(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := serviceStore(t, context).FilterServices(map[string]string{})
+	assert.Error(t, err, "Expected an error when no filters are provided")
+}
+
+func TestFilterServicesUnsupportedFilter(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := serviceStore(t, context).FilterServices(map[string]string{"unsupportedFilter": "value"})
+	assert.Error(t, err, "Expected an error when an unsupported filter is provided")
+}
+
+func TestFilterServicesByClusterARN(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.serviceKey1: context.serviceEntity1}
+	context.datastore.EXPECT().GetWithPrefix(serviceKeyPrefix+accountID+"/"+region+"/"+clusterName1+"/").Return(resp, nil)
+
+	services, err := serviceStore(t, context).FilterServices(map[string]string{serviceClusterFilter: clusterARN1})
+	assert.NoError(t, err, "Unexpected error when filtering services by cluster")
+	assert.Len(t, services, 1, "Unexpected number of services")
+	assert.Equal(t, context.service1, services[0].Service, "Unexpected service")
+}
+
+func TestFilterServicesByStatusAndName(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{
+		context.serviceKey1: context.serviceEntity1,
+		context.serviceKey2: context.serviceEntity2,
+	}
+	context.datastore.EXPECT().GetWithPrefix(serviceKeyPrefix).Return(resp, nil).Times(2)
+
+	store := serviceStore(t, context)
+
+	services, err := store.FilterServices(map[string]string{serviceStatusFilter: "draining"})
+	assert.NoError(t, err, "Unexpected error when filtering services by status")
+	assert.Len(t, services, 1, "Unexpected number of services")
+	assert.Equal(t, context.service2, services[0].Service, "Unexpected service")
+
+	services, err = store.FilterServices(map[string]string{serviceStatusFilter: "draining", serviceNameFilter: serviceName1})
+	assert.NoError(t, err, "Unexpected error when filtering services by status and name")
+	assert.Empty(t, services, "Expected no services")
+}
+
+func TestStreamServicesDataStoreStreamReturnsError(t *testing.T) {
+	ctx := NewServiceStoreMockContext(t)
+	defer ctx.mockCtrl.Finish()
+
+	tstCtx := context.Background()
+	ctx.datastore.EXPECT().StreamWithPrefix(gomock.Any(), serviceKeyPrefix, entityVersion).Return(nil, errors.New("StreamWithPrefix failed"))
+
+	_, err := serviceStore(t, ctx).StreamServices(tstCtx, entityVersion)
+	assert.Error(t, err, "Expected an error when datastore StreamWithPrefix returns an error")
+}
+
+func TestStreamServices(t *testing.T) {
+	ctx := NewServiceStoreMockContext(t)
+	defer ctx.mockCtrl.Finish()
+
+	tstCtx := context.Background()
+	dsChan := make(chan map[string]storetypes.Entity)
+	defer close(dsChan)
+	ctx.datastore.EXPECT().StreamWithPrefix(gomock.Any(), serviceKeyPrefix, "").Return(dsChan, nil)
+
+	serviceRespChan, err := serviceStore(t, ctx).StreamServices(tstCtx, "")
+	assert.NoError(t, err, "Unexpected error when calling stream services")
+
+	go func() {
+		dsChan <- map[string]storetypes.Entity{ctx.serviceKey1: ctx.serviceEntity1}
+	}()
+	serviceResp := <-serviceRespChan
+	assert.NoError(t, serviceResp.Err, "Unexpected error in service response")
+	assert.Equal(t, ctx.service1, serviceResp.Service, "Unexpected service in response")
+	assert.Equal(t, entityVersion, serviceResp.Version, "Unexpected service version in response")
+}
+
+func TestDeleteService(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Delete(context.serviceKey2).Return(int64(1), nil)
+
+	err := serviceStore(t, context).DeleteService(clusterARN2, serviceARN2)
+	assert.NoError(t, err, "Unexpected error when deleting service")
+}
+
+func TestDeleteServiceInAnotherCluster(t *testing.T) {
+	context := NewServiceStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := serviceStore(t, context).DeleteService(clusterARN1, serviceARN2)
+	assert.Error(t, err, "Expected an error when the service ARN does not belong to the cluster")
+}
+
+// expectMerge runs the STM function passed to the transactional store against
+// existingJSON stored at key and decodes the merged service into merged
+func (context *serviceStoreMockContext) expectMerge(t *testing.T, key string, existingJSON string, merged *types.Service) {
+	context.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	context.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(_ interface{}, _ interface{}, apply func(concurrency.STM) error) {
+			stm := &mockSTM{
+				getFunc: func(k string) string {
+					assert.Equal(t, key, k, "Unexpected key for Get")
+					return existingJSON
+				},
+				putFunc: func(k string, val string, opts ...clientv3.OpOption) {
+					assert.Equal(t, key, k, "Unexpected key for Put")
+					err := json.Unmarshal([]byte(val), merged)
+					assert.NoError(t, err, "Unexpected error unmarshaling merged service")
+				},
+			}
+			err := apply(stm)
+			assert.NoError(t, err, "Unexpected error merging service")
+		}).Return(nil, nil)
+}
+
+func serviceStore(t *testing.T, context *serviceStoreMockContext) ServiceStore {
+	serviceStore, err := NewServiceStore(context.datastore, context.etcdTxStore)
+	if err != nil {
+		t.Error("Unexpected error when calling NewServiceStore")
+	}
+	return serviceStore
+}
+
+func marshalService(t *testing.T, service types.Service) string {
+	serviceJSON, err := json.Marshal(service)
+	if err != nil {
+		t.Error("Failed to marshal service: ", err)
+	}
+	return string(serviceJSON)
+}
+
+func serviceActionEventJSON(t *testing.T, serviceARN string, clusterARN string) string {
+	event := types.ServiceActionEvent{
+		ID:        aws.String("action"),
+		Resources: []string{serviceARN},
+		Detail: &types.ServiceActionDetail{
+			ClusterARN: aws.String(clusterARN),
+			CreatedAt:  aws.String(serviceUpdated2),
+			EventName:  aws.String("SERVICE_STEADY_STATE"),
+			EventType:  aws.String("INFO"),
+		},
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		t.Error("Failed to marshal service action event: ", err)
+	}
+	return string(eventJSON)
+}
+
+func deploymentStateChangeEventJSON(t *testing.T, serviceARN string) string {
+	event := types.DeploymentStateChangeEvent{
+		ID:        aws.String("deployment"),
+		Resources: []string{serviceARN},
+		Detail: &types.DeploymentStateChangeDetail{
+			DeploymentID: aws.String(deploymentID1),
+			EventName:    aws.String("SERVICE_DEPLOYMENT_COMPLETED"),
+			EventType:    aws.String("INFO"),
+			UpdatedAt:    aws.String(serviceUpdated2),
+		},
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		t.Error("Failed to marshal deployment state change event: ", err)
+	}
+	return string(eventJSON)
+}
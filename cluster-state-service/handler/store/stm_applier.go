@@ -14,21 +14,48 @@
 package store
 
 import (
+	"time"
+
 	"github.com/goguardian/blox/cluster-state-service/handler/types"
 	log "github.com/cihub/seelog"
-	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/coreos/etcd/clientv3"
 	"github.com/pkg/errors"
 )
 
+// stmReadWriter is the part of concurrency.STM that appliers read and write
+// records with, so that they can be applied within other transactions as well
+type stmReadWriter interface {
+	Get(key string) string
+	Put(key, val string, opts ...clientv3.OpOption)
+	Del(key string)
+}
+
 type STMApplier struct {
 	record     types.Record
 	recordKey  string
 	recordJSON string
+	// tombstoneKey, when set, is checked before adding a record that does
+	// not exist in the store so that purged records are not recreated by
+	// late events
+	tombstoneKey string
+	// historyKey, when set, is where historyEntry is recorded every time the
+	// record is added. The history is trimmed to historyLimits.
+	historyKey    string
+	historyEntry  historyEntry
+	historyLimits HistoryLimits
+	// supersededHistory are the history entries of older versions of the
+	// record that were superseded by this one before being applied. Those
+	// with a version higher than the existing record's are recorded before
+	// historyEntry.
+	supersededHistory []historyEntry
+	// codec, when set, encodes the record before it is stored. Otherwise
+	// the record is stored as recordJSON.
+	codec Codec
 }
 
 // applyRecord adds a new record to the store if the version number
 // in the record is higher than the one that exists in the store
-func (applier STMApplier) applyRecord(stm concurrency.STM) error {
+func (applier STMApplier) applyRecord(stm stmReadWriter) error {
 	err := applier.validateApplier()
 	if err != nil {
 		return err
@@ -36,10 +63,11 @@ func (applier STMApplier) applyRecord(stm concurrency.STM) error {
 
 	// Get existing record
 	existingRecord := stm.Get(applier.recordKey)
+	existingRecordVersion := int64(0)
 
 	// A record already exists. Add new record only of the newer record has a higher version.
 	if existingRecord != "" {
-		existingRecordVersion, err := applier.record.GetVersion(existingRecord)
+		existingRecordVersion, err = decodeRecordVersion(applier.record, existingRecord)
 		if err != nil {
 			return errors.Wrapf(err,
 				"Error retrieving the version of the existing record in the STM applier")
@@ -56,13 +84,102 @@ func (applier STMApplier) applyRecord(stm concurrency.STM) error {
 			// Higher or equivalent version of the event has already been stored.
 			return nil
 		}
+	} else if applier.tombstoneKey != "" {
+		purged, err := applier.isPurged(stm)
+		if err != nil {
+			return err
+		}
+		if purged {
+			return nil
+		}
 	}
 
 	// New record has a higher version. Add it.
-	stm.Put(applier.recordKey, applier.recordJSON)
+	recordValue, err := applier.encodeRecord()
+	if err != nil {
+		return err
+	}
+	stm.Put(applier.recordKey, recordValue)
+
+	if applier.historyKey != "" {
+		return applier.recordHistory(stm, existingRecordVersion)
+	}
+	return nil
+}
+
+// recordHistory appends the history entry of the new record, preceded by the
+// entries of superseded versions newer than 'existingRecordVersion', to the
+// history of the record
+func (applier STMApplier) recordHistory(stm stmReadWriter, existingRecordVersion int64) error {
+	now := time.Now()
+	historyJSON := stm.Get(applier.historyKey)
+	entries := make([]historyEntry, 0, len(applier.supersededHistory)+1)
+	for _, entry := range applier.supersededHistory {
+		if entry.Version > existingRecordVersion {
+			entries = append(entries, entry)
+		}
+	}
+	entries = append(entries, applier.historyEntry)
+
+	for _, entry := range entries {
+		var err error
+		historyJSON, err = appendHistory(historyJSON, entry, applier.historyLimits, now)
+		if err != nil {
+			return errors.Wrapf(err,
+				"Error recording the history of the record in the STM applier")
+		}
+	}
+	stm.Put(applier.historyKey, historyJSON)
 	return nil
 }
 
+// encodeRecord returns the new record as it is stored
+func (applier STMApplier) encodeRecord() (string, error) {
+	if applier.codec == nil {
+		return applier.recordJSON, nil
+	}
+	version, err := applier.record.GetVersion(applier.recordJSON)
+	if err != nil {
+		return "", errors.Wrapf(err,
+			"Error retrieving the version of the new record in the STM applier")
+	}
+	recordValue, err := applier.codec.Encode(applier.recordJSON, version)
+	if err != nil {
+		return "", errors.Wrapf(err,
+			"Error encoding the new record in the STM applier")
+	}
+	return recordValue, nil
+}
+
+// isPurged returns true if a tombstone with a version at least as high as the
+// new record's version exists. A tombstone with a lower version is deleted,
+// since the new record supersedes it.
+func (applier STMApplier) isPurged(stm stmReadWriter) (bool, error) {
+	existingTombstone := stm.Get(applier.tombstoneKey)
+	if existingTombstone == "" {
+		return false, nil
+	}
+
+	t, err := unmarshalTombstone(existingTombstone)
+	if err != nil {
+		return false, errors.Wrapf(err,
+			"Error retrieving the tombstone of the record in the STM applier")
+	}
+	newRecordVersion, err := applier.record.GetVersion(applier.recordJSON)
+	if err != nil {
+		return false, errors.Wrapf(err,
+			"Error retrieving the version of the new record in the STM applier")
+	}
+	if t.Version >= newRecordVersion {
+		log.Debugf("Not adding record for key %s with version %d as it was purged at version %d",
+			applier.recordKey, newRecordVersion, t.Version)
+		return true, nil
+	}
+
+	stm.Del(applier.tombstoneKey)
+	return false, nil
+}
+
 func (applier STMApplier) validateApplier() error {
 	if applier.record == nil {
 		return errors.New("Record has to be initialized for the STM applier")
@@ -73,5 +190,8 @@ func (applier STMApplier) validateApplier() error {
 	if applier.recordJSON == "" {
 		return errors.New("Record JSON cannot be ampty for the STM applier")
 	}
+	if applier.historyKey != "" && !applier.historyLimits.IsEnabled() {
+		return errors.New("History limits have to be set to record history in the STM applier")
+	}
 	return nil
 }
@@ -16,6 +16,7 @@ package store
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/coreos/etcd/clientv3"
@@ -237,6 +238,264 @@ func TestAddRecordWhenNewerRecordHasNoVersion(t *testing.T) {
 	assert.Error(t, err, "Expected an error while adding a record when new record has no version")
 }
 
+func TestAddRecordWhenTombstoneWithSameVersionExists(t *testing.T) {
+	newRecord := generateRecordWithVersion(t, 1)
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "tombstone" {
+				return generateTombstone(t, 1)
+			}
+			return ""
+		},
+	}
+
+	applier := &STMApplier{
+		record:       SampleRecord{},
+		recordKey:    "key",
+		recordJSON:   newRecord,
+		tombstoneKey: "tombstone",
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record when a tombstone with the same version exists")
+}
+
+func TestAddRecordWhenTombstoneWithLowerVersionExists(t *testing.T) {
+	newRecord := generateRecordWithVersion(t, 2)
+	deleted := false
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "tombstone" {
+				return generateTombstone(t, 1)
+			}
+			return ""
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			assert.Equal(t, "key", key, "Unexpected key in Put")
+			assert.Equal(t, val, newRecord, "Unexpected record in Put")
+		},
+		delFunc: func(key string) {
+			assert.Equal(t, "tombstone", key, "Unexpected key in Del")
+			deleted = true
+		},
+	}
+
+	applier := &STMApplier{
+		record:       SampleRecord{},
+		recordKey:    "key",
+		recordJSON:   newRecord,
+		tombstoneKey: "tombstone",
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record when a tombstone with a lower version exists")
+	assert.True(t, deleted, "Expected the tombstone to be deleted")
+}
+
+func TestAddRecordWhenTombstoneIsInvalid(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "tombstone" {
+				return "invalidJSON"
+			}
+			return ""
+		},
+	}
+
+	applier := &STMApplier{
+		record:       SampleRecord{},
+		recordKey:    "key",
+		recordJSON:   generateRecordWithVersion(t, 1),
+		tombstoneKey: "tombstone",
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.Error(t, err, "Expected an error while adding a record when the tombstone is invalid")
+}
+
+func TestAddRecordRecordsHistory(t *testing.T) {
+	newRecord := generateRecordWithVersion(t, 2)
+	existingHistory := generateHistory(t, 1)
+	puts := map[string]string{}
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "history" {
+				return existingHistory
+			}
+			return generateRecordWithVersion(t, 1)
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			puts[key] = val
+		},
+	}
+
+	applier := &STMApplier{
+		record:        SampleRecord{},
+		recordKey:     "key",
+		recordJSON:    newRecord,
+		historyKey:    "history",
+		historyEntry:  generateHistoryEntry(t, 2),
+		historyLimits: testHistoryLimits,
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record with history")
+	assert.Equal(t, newRecord, puts["key"], "Unexpected record in Put")
+
+	entries, err := unmarshalHistory(puts["history"])
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, 2, "Expected the history entry to be appended")
+	assert.Equal(t, int64(1), entries[0].Version, "Unexpected version of the first history entry")
+	assert.Equal(t, int64(2), entries[1].Version, "Unexpected version of the second history entry")
+}
+
+func TestAddRecordWithCodec(t *testing.T) {
+	existingRecord, err := flateCodec{}.Encode(generateRecordWithVersion(t, 1), 1)
+	assert.NoError(t, err, "Unexpected error encoding the existing record")
+	puts := map[string]string{}
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return existingRecord
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			puts[key] = val
+		},
+	}
+
+	newRecord := generateRecordWithVersion(t, 2)
+	applier := &STMApplier{
+		record:     SampleRecord{},
+		recordKey:  "key",
+		recordJSON: newRecord,
+		codec:      flateCodec{},
+	}
+
+	err = applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding an encoded record")
+	version, err := decodeRecordVersion(SampleRecord{}, puts["key"])
+	assert.NoError(t, err, "Unexpected error decoding the version of the stored record")
+	assert.Equal(t, int64(2), version, "Unexpected version of the stored record")
+	decoded, err := decodeRecord(puts["key"])
+	assert.NoError(t, err, "Unexpected error decoding the stored record")
+	assert.Equal(t, newRecord, decoded, "Unexpected stored record")
+}
+
+func TestAddRecordWhenEncodedRecordWithHigherVersionExists(t *testing.T) {
+	existingRecord, err := flateCodec{}.Encode(generateRecordWithVersion(t, 3), 3)
+	assert.NoError(t, err, "Unexpected error encoding the existing record")
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return existingRecord
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			t.Errorf("Unexpected Put of key %s", key)
+		},
+	}
+
+	applier := &STMApplier{
+		record:     SampleRecord{},
+		recordKey:  "key",
+		recordJSON: generateRecordWithVersion(t, 2),
+		codec:      flateCodec{},
+	}
+
+	err = applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record older than the encoded one")
+}
+
+func TestAddRecordRecordsSupersededHistory(t *testing.T) {
+	puts := map[string]string{}
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "history" {
+				return generateHistory(t, 2)
+			}
+			return generateRecordWithVersion(t, 2)
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			puts[key] = val
+		},
+	}
+
+	applier := &STMApplier{
+		record:            SampleRecord{},
+		recordKey:         "key",
+		recordJSON:        generateRecordWithVersion(t, 4),
+		historyKey:        "history",
+		historyEntry:      generateHistoryEntry(t, 4),
+		historyLimits:     testHistoryLimits,
+		supersededHistory: []historyEntry{generateHistoryEntry(t, 1), generateHistoryEntry(t, 3)},
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record with superseded history")
+
+	entries, err := unmarshalHistory(puts["history"])
+	assert.NoError(t, err, "Unexpected error unmarshaling history")
+	assert.Len(t, entries, 3, "Expected only superseded entries newer than the existing record to be appended")
+	assert.Equal(t, int64(2), entries[0].Version, "Unexpected version of the first history entry")
+	assert.Equal(t, int64(3), entries[1].Version, "Unexpected version of the superseded history entry")
+	assert.Equal(t, int64(4), entries[2].Version, "Unexpected version of the last history entry")
+}
+
+func TestAddRecordDoesNotRecordHistoryForOlderVersion(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return generateRecordWithVersion(t, 2)
+		},
+	}
+
+	applier := &STMApplier{
+		record:        SampleRecord{},
+		recordKey:     "key",
+		recordJSON:    generateRecordWithVersion(t, 1),
+		historyKey:    "history",
+		historyEntry:  generateHistoryEntry(t, 1),
+		historyLimits: testHistoryLimits,
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error adding a record with an older version")
+}
+
+func TestAddRecordWhenHistoryIsInvalid(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			if key == "history" {
+				return "invalidJSON"
+			}
+			return ""
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			assert.Equal(t, "key", key, "Unexpected key in Put")
+		},
+	}
+
+	applier := &STMApplier{
+		record:        SampleRecord{},
+		recordKey:     "key",
+		recordJSON:    generateRecordWithVersion(t, 1),
+		historyKey:    "history",
+		historyEntry:  generateHistoryEntry(t, 1),
+		historyLimits: testHistoryLimits,
+	}
+
+	err := applier.applyRecord(mockSTM)
+	assert.Error(t, err, "Expected an error adding a record when its history is invalid")
+}
+
+func generateTombstone(t *testing.T, version int64) string {
+	tombstoneJSON, err := newTombstoneJSON(version, time.Now())
+	assert.NoError(t, err, "Error generating a json string for tombstone")
+	return tombstoneJSON
+}
+
 func generateRecordWithVersion(t *testing.T, version int64) string {
 	rec, err := json.Marshal(
 		&SampleRecord{
@@ -0,0 +1,62 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/pkg/errors"
+)
+
+// STMMerger updates records that are not versioned by ECS. Such records are
+// changed piecemeal by events instead of being replaced by newer versions.
+type STMMerger struct {
+	recordKey string
+	// merge returns the JSON of the record after the change has been merged
+	// into existingRecordJSON, which is empty if the record does not exist.
+	// An empty result leaves the store untouched.
+	merge func(existingRecordJSON string) (string, error)
+}
+
+// mergeRecord merges the change into the record stored at recordKey
+func (merger STMMerger) mergeRecord(stm concurrency.STM) error {
+	err := merger.validateMerger()
+	if err != nil {
+		return err
+	}
+
+	existingRecord, err := decodeRecord(stm.Get(merger.recordKey))
+	if err != nil {
+		return errors.Wrapf(err, "Error decoding the existing record in the STM merger")
+	}
+	mergedRecord, err := merger.merge(existingRecord)
+	if err != nil {
+		return errors.Wrapf(err, "Error merging the record in the STM merger")
+	}
+	if mergedRecord == "" || mergedRecord == existingRecord {
+		return nil
+	}
+
+	stm.Put(merger.recordKey, mergedRecord)
+	return nil
+}
+
+func (merger STMMerger) validateMerger() error {
+	if merger.recordKey == "" {
+		return errors.New("Record key cannot be empty for the STM merger")
+	}
+	if merger.merge == nil {
+		return errors.New("Merge function has to be initialized for the STM merger")
+	}
+	return nil
+}
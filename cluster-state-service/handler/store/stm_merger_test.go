@@ -0,0 +1,129 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMergerNoRecordKey(t *testing.T) {
+	merger := &STMMerger{
+		merge: func(string) (string, error) { return "", nil },
+	}
+
+	err := merger.validateMerger()
+	assert.Error(t, err, "Expected error when record key is not set in merger")
+}
+
+func TestValidateMergerNoMergeFunction(t *testing.T) {
+	merger := &STMMerger{
+		recordKey: "key",
+	}
+
+	err := merger.validateMerger()
+	assert.Error(t, err, "Expected error when merge function is not set in merger")
+}
+
+func TestMergeRecordPutsMergedRecord(t *testing.T) {
+	putCalled := false
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			assert.Equal(t, "ecs/service/key", key, "Unexpected key for Get")
+			return "existing"
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			putCalled = true
+			assert.Equal(t, "ecs/service/key", key, "Unexpected key for Put")
+			assert.Equal(t, "existing+change", val, "Unexpected value for Put")
+		},
+	}
+
+	merger := &STMMerger{
+		recordKey: "ecs/service/key",
+		merge: func(existing string) (string, error) {
+			return existing + "+change", nil
+		},
+	}
+
+	err := merger.mergeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error merging a record")
+	assert.True(t, putCalled, "Expected the merged record to be put")
+}
+
+func TestMergeRecordWhenRecordIsUnchanged(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return "existing"
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			t.Error("Unexpected Put when the record is unchanged")
+		},
+	}
+
+	merger := &STMMerger{
+		recordKey: "ecs/service/key",
+		merge: func(existing string) (string, error) {
+			return existing, nil
+		},
+	}
+
+	err := merger.mergeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error merging an unchanged record")
+}
+
+func TestMergeRecordWhenMergeReturnsEmptyRecord(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return ""
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			t.Error("Unexpected Put when the merged record is empty")
+		},
+	}
+
+	merger := &STMMerger{
+		recordKey: "ecs/service/key",
+		merge: func(existing string) (string, error) {
+			return "", nil
+		},
+	}
+
+	err := merger.mergeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error when the merged record is empty")
+}
+
+func TestMergeRecordWhenMergeReturnsError(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return "existing"
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			t.Error("Unexpected Put when merge fails")
+		},
+	}
+
+	merger := &STMMerger{
+		recordKey: "ecs/service/key",
+		merge: func(existing string) (string, error) {
+			return "", errors.New("Merge failed")
+		},
+	}
+
+	err := merger.mergeRecord(mockSTM)
+	assert.Error(t, err, "Expected error when merge fails")
+}
@@ -0,0 +1,78 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"time"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/pkg/errors"
+)
+
+type STMPurger struct {
+	record    types.Record
+	recordKey string
+	// version is the version of the record that is expected to be in the
+	// store. The record is not purged if it has changed since.
+	version  int64
+	purgedAt time.Time
+}
+
+// purgeRecord replaces the record in the store with a tombstone if the
+// version of the stored record matches the version the purger expects. The
+// history of the record is purged along with it.
+func (purger STMPurger) purgeRecord(stm concurrency.STM) error {
+	err := purger.validatePurger()
+	if err != nil {
+		return err
+	}
+
+	existingRecord := stm.Get(purger.recordKey)
+	if existingRecord == "" {
+		// Record has already been deleted.
+		return nil
+	}
+
+	existingRecordVersion, err := decodeRecordVersion(purger.record, existingRecord)
+	if err != nil {
+		return errors.Wrapf(err,
+			"Error retrieving the version of the existing record in the STM purger")
+	}
+	if existingRecordVersion != purger.version {
+		log.Debugf("Not purging record for key %s with version %d as version %d exists",
+			purger.recordKey, purger.version, existingRecordVersion)
+		return nil
+	}
+
+	tombstoneJSON, err := newTombstoneJSON(existingRecordVersion, purger.purgedAt)
+	if err != nil {
+		return err
+	}
+	stm.Put(tombstoneKey(purger.recordKey), tombstoneJSON)
+	stm.Del(purger.recordKey)
+	stm.Del(historyKey(purger.recordKey))
+	return nil
+}
+
+func (purger STMPurger) validatePurger() error {
+	if purger.record == nil {
+		return errors.New("Record has to be initialized for the STM purger")
+	}
+	if purger.recordKey == "" {
+		return errors.New("Record key cannot be empty for the STM purger")
+	}
+	return nil
+}
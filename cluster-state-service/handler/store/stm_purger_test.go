@@ -0,0 +1,128 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePurgerNoRecord(t *testing.T) {
+	purger := &STMPurger{
+		recordKey: "key",
+	}
+
+	err := purger.validatePurger()
+	assert.Error(t, err, "Expected error when record is not set in purger")
+}
+
+func TestValidatePurgerNoRecordKey(t *testing.T) {
+	purger := &STMPurger{
+		record: SampleRecord{},
+	}
+
+	err := purger.validatePurger()
+	assert.Error(t, err, "Expected error when record key is not set in purger")
+}
+
+func TestPurgeRecordWhenRecordDoesNotExist(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			assert.Equal(t, "ecs/task/key", key, "Unexpected key for Get")
+			return ""
+		},
+	}
+
+	purger := &STMPurger{
+		record:    SampleRecord{},
+		recordKey: "ecs/task/key",
+		version:   1,
+		purgedAt:  time.Now(),
+	}
+
+	err := purger.purgeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error purging a record that does not exist")
+}
+
+func TestPurgeRecordWhenVersionChanged(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return generateRecordWithVersion(t, 2)
+		},
+	}
+
+	purger := &STMPurger{
+		record:    SampleRecord{},
+		recordKey: "ecs/task/key",
+		version:   1,
+		purgedAt:  time.Now(),
+	}
+
+	err := purger.purgeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error purging a record whose version changed")
+}
+
+func TestPurgeRecordWhenRecordIsInvalid(t *testing.T) {
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return "invalidJSON"
+		},
+	}
+
+	purger := &STMPurger{
+		record:    SampleRecord{},
+		recordKey: "ecs/task/key",
+		version:   1,
+		purgedAt:  time.Now(),
+	}
+
+	err := purger.purgeRecord(mockSTM)
+	assert.Error(t, err, "Expected an error purging a record that is invalid")
+}
+
+func TestPurgeRecord(t *testing.T) {
+	purgedAt := time.Now()
+	deleted := []string{}
+
+	mockSTM := &mockSTM{
+		getFunc: func(key string) string {
+			return generateRecordWithVersion(t, 1)
+		},
+		putFunc: func(key string, val string, opts ...clientv3.OpOption) {
+			assert.Equal(t, "ecs/tombstone/task/key", key, "Unexpected key in Put")
+			tombstone, err := unmarshalTombstone(val)
+			assert.NoError(t, err, "Unexpected error unmarshaling tombstone")
+			assert.Equal(t, int64(1), tombstone.Version, "Unexpected tombstone version")
+			assert.Equal(t, purgedAt.UTC().Format(time.RFC3339), tombstone.PurgedAt, "Unexpected tombstone purge time")
+		},
+		delFunc: func(key string) {
+			deleted = append(deleted, key)
+		},
+	}
+
+	purger := &STMPurger{
+		record:    SampleRecord{},
+		recordKey: "ecs/task/key",
+		version:   1,
+		purgedAt:  purgedAt,
+	}
+
+	err := purger.purgeRecord(mockSTM)
+	assert.NoError(t, err, "Unexpected error purging a record")
+	assert.Equal(t, []string{"ecs/task/key", "ecs/history/task/key"}, deleted,
+		"Expected the record and its history to be deleted")
+}
@@ -16,15 +16,41 @@ package store
 type Stores struct {
 	TaskStore              TaskStore
 	ContainerInstanceStore ContainerInstanceStore
+	ServiceStore           ServiceStore
+	TaskDefinitionStore    TaskDefinitionStore
+	ClusterStore           ClusterStore
+	TombstoneStore         TombstoneStore
+	// View, when set, serves task and container instance reads
+	View *View
 }
 
-func NewStores(datastore DataStore, etcdTXStore EtcdTXStore) (Stores, error) {
-	taskStore, err := NewTaskStore(datastore, etcdTXStore)
+func NewStores(datastore DataStore, etcdTXStore EtcdTXStore, recordApplier RecordApplier, codec Codec, view *View, historyLimits HistoryLimits) (Stores, error) {
+	taskStore, err := NewTaskStore(datastore, etcdTXStore, recordApplier, codec, view, historyLimits)
 	if err != nil {
 		return Stores{}, err
 	}
 
-	containerInstanceStore, err := NewContainerInstanceStore(datastore, etcdTXStore)
+	containerInstanceStore, err := NewContainerInstanceStore(datastore, etcdTXStore, recordApplier, codec, view, historyLimits)
+	if err != nil {
+		return Stores{}, err
+	}
+
+	serviceStore, err := NewServiceStore(datastore, etcdTXStore)
+	if err != nil {
+		return Stores{}, err
+	}
+
+	taskDefinitionStore, err := NewTaskDefinitionStore(datastore)
+	if err != nil {
+		return Stores{}, err
+	}
+
+	clusterStore, err := NewClusterStore(datastore)
+	if err != nil {
+		return Stores{}, err
+	}
+
+	tombstoneStore, err := NewTombstoneStore(datastore)
 	if err != nil {
 		return Stores{}, err
 	}
@@ -32,5 +58,10 @@ func NewStores(datastore DataStore, etcdTXStore EtcdTXStore) (Stores, error) {
 	return Stores{
 		TaskStore:              taskStore,
 		ContainerInstanceStore: containerInstanceStore,
+		ServiceStore:           serviceStore,
+		TaskDefinitionStore:    taskDefinitionStore,
+		ClusterStore:           clusterStore,
+		TombstoneStore:         tombstoneStore,
+		View:                   view,
 	}, nil
 }
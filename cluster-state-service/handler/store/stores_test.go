@@ -39,19 +39,23 @@ func TestStoreTestSuite(t *testing.T) {
 }
 
 func (testSuite *StoreTestSuite) TestNewStoresDatastoreNil() {
-	_, err := NewStores(nil, testSuite.etcdTxStore)
+	_, err := NewStores(nil, testSuite.etcdTxStore, stmRecordApplier{etcdTXStore: testSuite.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Error(testSuite.T(), err, "Expected an error when NewStores is initialized with nil datastore")
 }
 
 func (testSuite *StoreTestSuite) TestNewStoresEtcdTxStoreNil() {
-	_, err := NewStores(testSuite.datastore, nil)
+	_, err := NewStores(testSuite.datastore, nil, stmRecordApplier{}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Error(testSuite.T(), err, "Expected an error when NewStores is initialized with nil etcd transaction store")
 }
 
 func (testSuite *StoreTestSuite) TestNewStores() {
-	stores, err := NewStores(testSuite.datastore, testSuite.etcdTxStore)
+	stores, err := NewStores(testSuite.datastore, testSuite.etcdTxStore, stmRecordApplier{etcdTXStore: testSuite.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Nil(testSuite.T(), err, "Unexpected error when calling NewStores")
 	assert.NotNil(testSuite.T(), stores, "Stores should not be nil")
 	assert.NotNil(testSuite.T(), stores.TaskStore, "TaskStore should not be nil")
 	assert.NotNil(testSuite.T(), stores.ContainerInstanceStore, "ContainerInstanceStores should not be nil")
+	assert.NotNil(testSuite.T(), stores.ServiceStore, "ServiceStore should not be nil")
+	assert.NotNil(testSuite.T(), stores.TaskDefinitionStore, "TaskDefinitionStore should not be nil")
+	assert.NotNil(testSuite.T(), stores.ClusterStore, "ClusterStore should not be nil")
+	assert.NotNil(testSuite.T(), stores.TombstoneStore, "TombstoneStore should not be nil")
 }
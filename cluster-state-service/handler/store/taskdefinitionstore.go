@@ -0,0 +1,201 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/regex"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	taskDefinitionKeyPrefix = "ecs/taskdefinition/"
+)
+
+// TaskDefinitionStore defines methods to access the task definitions cached in the datastore
+type TaskDefinitionStore interface {
+	AddTaskDefinition(taskDefinition string) error
+	GetTaskDefinition(taskDefinitionARN string) (*storetypes.VersionedTaskDefinition, error)
+	ListTaskDefinitions() ([]storetypes.VersionedTaskDefinition, error)
+	ListTaskDefinitionRevisions(family string) ([]storetypes.VersionedTaskDefinition, error)
+	DeleteTaskDefinition(taskDefinitionARN string) error
+}
+
+type taskDefinitionCacheStore struct {
+	datastore DataStore
+}
+
+// NewTaskDefinitionStore initializes the taskDefinitionCacheStore struct
+func NewTaskDefinitionStore(ds DataStore) (TaskDefinitionStore, error) {
+	if ds == nil {
+		return nil, errors.New("Datastore is not initialized")
+	}
+	return taskDefinitionCacheStore{
+		datastore: ds,
+	}, nil
+}
+
+// AddTaskDefinition adds the task definition represented in the
+// taskDefinitionJSON to the datastore. Task definitions are immutable, so the
+// cached copy is simply replaced.
+func (taskDefinitionStore taskDefinitionCacheStore) AddTaskDefinition(taskDefinitionJSON string) error {
+	if len(taskDefinitionJSON) == 0 {
+		return errors.New("Task definition JSON should not be empty")
+	}
+
+	taskDefinition, err := taskDefinitionStore.unmarshalTaskDefinition(taskDefinitionJSON)
+	if err != nil {
+		return err
+	}
+	if taskDefinition.Detail == nil {
+		return errors.New("Task definition detail not initialized in JSON")
+	}
+
+	key, err := generateTaskDefinitionKey(aws.StringValue(taskDefinition.Detail.TaskDefinitionARN))
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Task definition store unmarshalled task definition: %s, trying to add it to the store", taskDefinition.Detail.String())
+
+	return taskDefinitionStore.datastore.Add(key, taskDefinitionJSON)
+}
+
+// GetTaskDefinition gets the task definition with ARN 'taskDefinitionARN'
+func (taskDefinitionStore taskDefinitionCacheStore) GetTaskDefinition(taskDefinitionARN string) (*storetypes.VersionedTaskDefinition, error) {
+	key, err := generateTaskDefinitionKey(taskDefinitionARN)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := taskDefinitionStore.datastore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) == 0 {
+		return nil, nil
+	}
+
+	if len(resp) > 1 {
+		return nil, errors.Errorf("Multiple entries exist in the datastore with key %v", key)
+	}
+
+	var versionedTaskDefinition storetypes.VersionedTaskDefinition
+	for _, entity := range resp {
+		versionedTaskDefinition.TaskDefinition, err = taskDefinitionStore.unmarshalTaskDefinition(entity.Value)
+		versionedTaskDefinition.Version = entity.Version
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	return &versionedTaskDefinition, nil
+}
+
+// ListTaskDefinitions lists all task definitions cached in the datastore,
+// ordered by family and revision
+func (taskDefinitionStore taskDefinitionCacheStore) ListTaskDefinitions() ([]storetypes.VersionedTaskDefinition, error) {
+	return taskDefinitionStore.filterTaskDefinitions(func(types.TaskDefinition) bool {
+		return true
+	})
+}
+
+// ListTaskDefinitionRevisions lists the cached revisions of the task
+// definition family 'family' across all accounts and regions, ordered by revision
+func (taskDefinitionStore taskDefinitionCacheStore) ListTaskDefinitionRevisions(family string) ([]storetypes.VersionedTaskDefinition, error) {
+	if !regex.IsTaskDefinitionFamily(family) {
+		return nil, errors.Errorf("Task definition family '%s' does not match expected regex", family)
+	}
+	return taskDefinitionStore.filterTaskDefinitions(func(taskDefinition types.TaskDefinition) bool {
+		return aws.StringValue(taskDefinition.Detail.Family) == family
+	})
+}
+
+// DeleteTaskDefinition deletes the task definition with ARN 'taskDefinitionARN' from the datastore
+func (taskDefinitionStore taskDefinitionCacheStore) DeleteTaskDefinition(taskDefinitionARN string) error {
+	key, err := generateTaskDefinitionKey(taskDefinitionARN)
+	if err != nil {
+		return err
+	}
+	numKeysDeleted, err := taskDefinitionStore.datastore.Delete(key)
+	log.Debugf("Deleted '%d' key(s) from the store for task definition '%s'", numKeysDeleted, taskDefinitionARN)
+	return err
+}
+
+func (taskDefinitionStore taskDefinitionCacheStore) filterTaskDefinitions(filter func(types.TaskDefinition) bool) ([]storetypes.VersionedTaskDefinition, error) {
+	resp, err := taskDefinitionStore.datastore.GetWithPrefix(taskDefinitionKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	versionedTaskDefinitions := make([]storetypes.VersionedTaskDefinition, 0, len(resp))
+	for _, entity := range resp {
+		taskDefinition, err := taskDefinitionStore.unmarshalTaskDefinition(entity.Value)
+		if err != nil {
+			return nil, err
+		}
+		if taskDefinition.Detail == nil || !filter(taskDefinition) {
+			continue
+		}
+		versionedTaskDefinitions = append(versionedTaskDefinitions, storetypes.VersionedTaskDefinition{
+			TaskDefinition: taskDefinition,
+			Version:        entity.Version,
+		})
+	}
+
+	sort.Slice(versionedTaskDefinitions, func(i, j int) bool {
+		a := versionedTaskDefinitions[i].TaskDefinition.Detail
+		b := versionedTaskDefinitions[j].TaskDefinition.Detail
+		if aws.StringValue(a.Family) != aws.StringValue(b.Family) {
+			return aws.StringValue(a.Family) < aws.StringValue(b.Family)
+		}
+		if aws.Int64Value(a.Revision) != aws.Int64Value(b.Revision) {
+			return aws.Int64Value(a.Revision) < aws.Int64Value(b.Revision)
+		}
+		return aws.StringValue(a.TaskDefinitionARN) < aws.StringValue(b.TaskDefinitionARN)
+	})
+	return versionedTaskDefinitions, nil
+}
+
+func (taskDefinitionStore taskDefinitionCacheStore) unmarshalTaskDefinition(val string) (types.TaskDefinition, error) {
+	var taskDefinition types.TaskDefinition
+	err := json.Unmarshal([]byte(val), &taskDefinition)
+	if err != nil {
+		return taskDefinition, errors.Wrapf(err, "Error unmarshaling task definition '%s'", val)
+	}
+
+	return taskDefinition, nil
+}
+
+// generateTaskDefinitionKey returns the key of the task definition with ARN
+// 'taskDefinitionARN'. Task definitions do not belong to a cluster, so they are
+// stored under keys in the form '<prefix><account>/<region>/<family>/<ARN>'.
+func generateTaskDefinitionKey(taskDefinitionARN string) (string, error) {
+	family, _, err := regex.GetFamilyAndRevisionFromTaskDefinitionARN(taskDefinitionARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating task definition key")
+	}
+	account, region, err := regex.GetAccountAndRegionFromARN(taskDefinitionARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating task definition key")
+	}
+	return taskDefinitionKeyPrefix + account + "/" + region + "/" + family + "/" + taskDefinitionARN, nil
+}
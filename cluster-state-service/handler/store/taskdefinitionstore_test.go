@@ -0,0 +1,238 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	taskDefinitionFamily1   = "web"
+	taskDefinitionFamily2   = "worker"
+	taskDefinitionRevision1 = int64(9)
+	taskDefinitionRevision2 = int64(10)
+	taskDefinitionARNOfWeb1 = "arn:aws:ecs:" + region + ":" + accountID + ":task-definition/web:9"
+	taskDefinitionARNOfWeb2 = "arn:aws:ecs:" + region + ":" + accountID + ":task-definition/web:10"
+	taskDefinitionARNOfJob1 = "arn:aws:ecs:" + region + ":" + accountID + ":task-definition/worker:9"
+)
+
+type taskDefinitionStoreMockContext struct {
+	mockCtrl               *gomock.Controller
+	datastore              *mocks.MockDataStore
+	taskDefinitionWeb1     types.TaskDefinition
+	taskDefinitionJSONWeb1 string
+	taskDefinitionKeyWeb1  string
+	entities               map[string]storetypes.Entity
+}
+
+func NewTaskDefinitionStoreMockContext(t *testing.T) *taskDefinitionStoreMockContext {
+	context := taskDefinitionStoreMockContext{}
+	context.mockCtrl = gomock.NewController(t)
+	context.datastore = mocks.NewMockDataStore(context.mockCtrl)
+
+	context.taskDefinitionWeb1 = taskDefinition(taskDefinitionFamily1, taskDefinitionRevision1, taskDefinitionARNOfWeb1)
+	context.taskDefinitionJSONWeb1 = marshalTaskDefinition(t, context.taskDefinitionWeb1)
+	context.taskDefinitionKeyWeb1 = taskDefinitionKeyPrefix + accountID + "/" + region + "/" + taskDefinitionFamily1 + "/" + taskDefinitionARNOfWeb1
+
+	taskDefinitionJSONWeb2 := marshalTaskDefinition(t, taskDefinition(taskDefinitionFamily1, taskDefinitionRevision2, taskDefinitionARNOfWeb2))
+	taskDefinitionKeyWeb2 := taskDefinitionKeyPrefix + accountID + "/" + region + "/" + taskDefinitionFamily1 + "/" + taskDefinitionARNOfWeb2
+	taskDefinitionJSONJob1 := marshalTaskDefinition(t, taskDefinition(taskDefinitionFamily2, taskDefinitionRevision1, taskDefinitionARNOfJob1))
+	taskDefinitionKeyJob1 := taskDefinitionKeyPrefix + accountID + "/" + region + "/" + taskDefinitionFamily2 + "/" + taskDefinitionARNOfJob1
+
+	context.entities = map[string]storetypes.Entity{
+		taskDefinitionKeyWeb2:         setupEntity(taskDefinitionKeyWeb2, taskDefinitionJSONWeb2, entityVersion),
+		taskDefinitionKeyJob1:         setupEntity(taskDefinitionKeyJob1, taskDefinitionJSONJob1, entityVersion),
+		context.taskDefinitionKeyWeb1: setupEntity(context.taskDefinitionKeyWeb1, context.taskDefinitionJSONWeb1, entityVersion),
+	}
+
+	return &context
+}
+
+func TestTaskDefinitionStoreNilDatastore(t *testing.T) {
+	_, err := NewTaskDefinitionStore(nil)
+	assert.Error(t, err, "Expected an error when datastore is nil")
+}
+
+func TestAddTaskDefinitionEmptyTaskDefinitionJSON(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := taskDefinitionStore(t, context).AddTaskDefinition("")
+	assert.Error(t, err, "Expected an error when task definition JSON is empty in AddTaskDefinition")
+}
+
+func TestAddTaskDefinitionJSONUnmarshalError(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	err := taskDefinitionStore(t, context).AddTaskDefinition("invalidJSON")
+	assert.Error(t, err, "Expected an error when task definition JSON is invalid in AddTaskDefinition")
+}
+
+func TestAddTaskDefinitionInvalidTaskDefinitionARN(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	taskDefinitionJSON := marshalTaskDefinition(t, taskDefinition(taskDefinitionFamily1, taskDefinitionRevision1, "invalidARN"))
+	err := taskDefinitionStore(t, context).AddTaskDefinition(taskDefinitionJSON)
+	assert.Error(t, err, "Expected an error when task definition ARN is invalid in AddTaskDefinition")
+}
+
+func TestAddTaskDefinitionDataStoreAddReturnsError(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Add(context.taskDefinitionKeyWeb1, context.taskDefinitionJSONWeb1).Return(errors.New("Add failed"))
+
+	err := taskDefinitionStore(t, context).AddTaskDefinition(context.taskDefinitionJSONWeb1)
+	assert.Error(t, err, "Expected an error when datastore add fails")
+}
+
+func TestAddTaskDefinition(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Add(context.taskDefinitionKeyWeb1, context.taskDefinitionJSONWeb1).Return(nil)
+
+	err := taskDefinitionStore(t, context).AddTaskDefinition(context.taskDefinitionJSONWeb1)
+	assert.NoError(t, err, "Unexpected error when adding task definition")
+}
+
+func TestGetTaskDefinition(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	resp := map[string]storetypes.Entity{context.taskDefinitionKeyWeb1: context.entities[context.taskDefinitionKeyWeb1]}
+	context.datastore.EXPECT().Get(context.taskDefinitionKeyWeb1).Return(resp, nil)
+
+	taskDefinition, err := taskDefinitionStore(t, context).GetTaskDefinition(taskDefinitionARNOfWeb1)
+	assert.NoError(t, err, "Unexpected error when getting task definition")
+	assert.Equal(t, context.taskDefinitionWeb1, taskDefinition.TaskDefinition, "Unexpected task definition")
+	assert.Equal(t, entityVersion, taskDefinition.Version, "Unexpected task definition version")
+}
+
+func TestGetTaskDefinitionNotCached(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Get(context.taskDefinitionKeyWeb1).Return(map[string]storetypes.Entity{}, nil)
+
+	taskDefinition, err := taskDefinitionStore(t, context).GetTaskDefinition(taskDefinitionARNOfWeb1)
+	assert.NoError(t, err, "Unexpected error when getting task definition")
+	assert.Nil(t, taskDefinition, "Expected no task definition")
+}
+
+func TestGetTaskDefinitionInvalidTaskDefinitionARN(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := taskDefinitionStore(t, context).GetTaskDefinition("arn:aws:ecs:us-east-1:123456789123:task-definition/web")
+	assert.Error(t, err, "Expected an error when task definition ARN has no revision")
+}
+
+func TestListTaskDefinitionsOrderedByFamilyAndRevision(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(taskDefinitionKeyPrefix).Return(context.entities, nil)
+
+	taskDefinitions, err := taskDefinitionStore(t, context).ListTaskDefinitions()
+	assert.NoError(t, err, "Unexpected error when listing task definitions")
+	assert.Equal(t, []string{taskDefinitionARNOfWeb1, taskDefinitionARNOfWeb2, taskDefinitionARNOfJob1},
+		taskDefinitionARNs(taskDefinitions), "Expected task definitions ordered by family and revision")
+}
+
+func TestListTaskDefinitionsDataStoreReturnsError(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(taskDefinitionKeyPrefix).Return(nil, errors.New("GetWithPrefix failed"))
+
+	_, err := taskDefinitionStore(t, context).ListTaskDefinitions()
+	assert.Error(t, err, "Expected an error when datastore GetWithPrefix fails")
+}
+
+func TestListTaskDefinitionRevisions(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().GetWithPrefix(taskDefinitionKeyPrefix).Return(context.entities, nil)
+
+	taskDefinitions, err := taskDefinitionStore(t, context).ListTaskDefinitionRevisions(taskDefinitionFamily1)
+	assert.NoError(t, err, "Unexpected error when listing task definition revisions")
+	assert.Equal(t, []string{taskDefinitionARNOfWeb1, taskDefinitionARNOfWeb2},
+		taskDefinitionARNs(taskDefinitions), "Expected revisions of the family ordered by revision")
+}
+
+func TestListTaskDefinitionRevisionsInvalidFamily(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	_, err := taskDefinitionStore(t, context).ListTaskDefinitionRevisions("web:9")
+	assert.Error(t, err, "Expected an error when task definition family is invalid")
+}
+
+func TestDeleteTaskDefinition(t *testing.T) {
+	context := NewTaskDefinitionStoreMockContext(t)
+	defer context.mockCtrl.Finish()
+
+	context.datastore.EXPECT().Delete(context.taskDefinitionKeyWeb1).Return(int64(1), nil)
+
+	err := taskDefinitionStore(t, context).DeleteTaskDefinition(taskDefinitionARNOfWeb1)
+	assert.NoError(t, err, "Unexpected error when deleting task definition")
+}
+
+func taskDefinitionStore(t *testing.T, context *taskDefinitionStoreMockContext) TaskDefinitionStore {
+	taskDefinitionStore, err := NewTaskDefinitionStore(context.datastore)
+	if err != nil {
+		t.Error("Unexpected error when calling NewTaskDefinitionStore")
+	}
+	return taskDefinitionStore
+}
+
+func taskDefinition(family string, revision int64, taskDefinitionARN string) types.TaskDefinition {
+	return types.TaskDefinition{
+		Detail: &types.TaskDefinitionDetail{
+			ContainerDefinitions: []*types.ContainerDefinition{},
+			Family:               aws.String(family),
+			Revision:             aws.Int64(revision),
+			TaskDefinitionARN:    aws.String(taskDefinitionARN),
+		},
+	}
+}
+
+func marshalTaskDefinition(t *testing.T, taskDefinition types.TaskDefinition) string {
+	taskDefinitionJSON, err := json.Marshal(taskDefinition)
+	if err != nil {
+		t.Error("Failed to marshal task definition: ", err)
+	}
+	return string(taskDefinitionJSON)
+}
+
+func taskDefinitionARNs(taskDefinitions []storetypes.VersionedTaskDefinition) []string {
+	arns := make([]string, len(taskDefinitions))
+	for i := range taskDefinitions {
+		arns[i] = aws.StringValue(taskDefinitions[i].TaskDefinition.Detail.TaskDefinitionARN)
+	}
+	return arns
+}
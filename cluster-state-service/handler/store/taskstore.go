@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/goguardian/blox/cluster-state-service/handler/regex"
@@ -27,43 +28,70 @@ import (
 )
 
 const (
-	taskKeyPrefix       = "ecs/task/"
-	taskStatusFilter    = "status"
-	taskStartedByFilter = "startedBy"
-	taskClusterFilter   = "cluster"
+	taskKeyPrefix         = "ecs/task/"
+	taskStatusFilter      = "status"
+	taskStartedByFilter   = "startedBy"
+	taskClusterFilter     = "cluster"
+	taskLaunchTypeFilter  = "launchType"
+	taskGroupFilter       = "group"
+	defaultTaskLaunchType = "ec2"
 )
 
 var (
-	supportedTaskFilters = map[string]string{taskStatusFilter: "", taskStartedByFilter: "", taskClusterFilter: ""}
+	supportedTaskFilters = map[string]string{taskStatusFilter: "", taskStartedByFilter: "", taskClusterFilter: "",
+		taskLaunchTypeFilter: "", taskGroupFilter: ""}
 )
 
 // TaskStore defines methods to access tasks from the datastore
 type TaskStore interface {
 	AddTask(task string) error
 	GetTask(cluster string, taskARN string) (*storetypes.VersionedTask, error)
+	GetTaskHistory(cluster string, taskARN string) ([]storetypes.TaskHistoryEntry, error)
 	ListTasks() ([]storetypes.VersionedTask, error)
 	FilterTasks(filterMap map[string]string) ([]storetypes.VersionedTask, error)
+	ListTasksAtVersion(entityVersion string) ([]storetypes.VersionedTask, int64, error)
+	FilterTasksAtVersion(filterMap map[string]string, entityVersion string) ([]storetypes.VersionedTask, int64, error)
 	StreamTasks(ctx context.Context, entityVersion string) (chan storetypes.VersionedTask, error)
 	DeleteTask(cluster, taskARN string) error
+	PurgeTask(cluster, taskARN string, version int64) error
 }
 
 type eventTaskStore struct {
-	datastore   DataStore
-	etcdTXStore EtcdTXStore
+	datastore     DataStore
+	etcdTXStore   EtcdTXStore
+	recordApplier RecordApplier
+	codec         Codec
+	// view, when set and seeded, serves reads instead of the datastore
+	view          *View
+	historyLimits HistoryLimits
 }
 
 // NewTaskStore initializes the eventTaskStore struct
-func NewTaskStore(ds DataStore, ts EtcdTXStore) (TaskStore, error) {
+func NewTaskStore(ds DataStore, ts EtcdTXStore, recordApplier RecordApplier, codec Codec, view *View, historyLimits HistoryLimits) (TaskStore, error) {
 	if ds == nil {
 		return nil, errors.Errorf("Datastore is not initialized")
 	}
 	if ts == nil {
 		return nil, errors.Errorf("Etcd transactional store is not initialized")
 	}
+	if recordApplier == nil {
+		return nil, errors.Errorf("Record applier is not initialized")
+	}
+	if codec == nil {
+		return nil, errors.Errorf("Codec is not initialized")
+	}
+
+	if err := historyLimits.validate(); err != nil {
+		return nil, err
+	}
 
 	return eventTaskStore{
-		datastore:   ds,
-		etcdTXStore: ts,
+		datastore:     ds,
+		etcdTXStore:   ts,
+		recordApplier: recordApplier,
+		codec:         codec,
+		view:          view,
+		historyLimits: historyLimits,
 	}, nil
 }
 
@@ -77,15 +105,22 @@ func (taskStore eventTaskStore) AddTask(taskJSON string) error {
 	log.Debugf("Task store unmarshalled task: %s, trying to add it to the store", task.Detail.String())
 
 	applier := &STMApplier{
-		record:     types.Task{},
-		recordKey:  key,
-		recordJSON: taskJSON,
+		record:       types.Task{},
+		recordKey:    key,
+		recordJSON:   taskJSON,
+		tombstoneKey: tombstoneKey(key),
+		codec:        taskStore.codec,
+	}
+	if taskStore.historyLimits.IsEnabled() {
+		entry, err := newHistoryEntry(aws.Int64Value(task.Detail.Version), time.Now(), task.Detail.State())
+		if err != nil {
+			return err
+		}
+		applier.historyKey = historyKey(key)
+		applier.historyEntry = entry
+		applier.historyLimits = taskStore.historyLimits
 	}
-	// TODO: NewSTMRepeatble panics if there's any error from the etcd
-	// client. We should find a better way to handle that
-	_, err = taskStore.etcdTXStore.NewSTMRepeatable(context.TODO(),
-		taskStore.etcdTXStore.GetV3Client(),
-		applier.applyRecord)
+	err = taskStore.recordApplier.ApplyRecord(applier)
 	return err
 }
 
@@ -100,15 +135,75 @@ func (taskStore eventTaskStore) GetTask(cluster string, taskARN string) (*storet
 	return taskStore.getTaskByKey(key)
 }
 
+// GetTaskHistory gets the recorded states of the task with ARN 'taskARN'
+// belonging to cluster 'cluster', ordered from oldest to newest
+func (taskStore eventTaskStore) GetTaskHistory(cluster string, taskARN string) ([]storetypes.TaskHistoryEntry, error) {
+	key, err := taskStore.getTaskKey(cluster, taskARN)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not generate task key for cluster '%s' and task '%s'",
+			cluster, taskARN)
+	}
+
+	entries, err := getHistory(taskStore.datastore, historyKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	taskHistory := make([]storetypes.TaskHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		var state types.TaskState
+		err = json.Unmarshal(entry.State, &state)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error unmarshaling history of task '%s'", taskARN)
+		}
+		taskHistory = append(taskHistory, storetypes.TaskHistoryEntry{
+			Version:    entry.Version,
+			RecordedAt: entry.RecordedAt,
+			State:      state,
+		})
+	}
+	return taskHistory, nil
+}
+
 // ListTasks lists all the tasks existing in the datastore
 func (taskStore eventTaskStore) ListTasks() ([]storetypes.VersionedTask, error) {
-	return taskStore.getTasksByKeyPrefix(taskKeyPrefix)
+	tasks, _, err := taskStore.getTasksByKeyPrefix(taskKeyPrefix, anyRevision)
+	return tasks, err
 }
 
 // FilterTasks returns all the tasks from the datastore that match the provided filters
 func (taskStore eventTaskStore) FilterTasks(filterMap map[string]string) ([]storetypes.VersionedTask, error) {
+	tasks, _, err := taskStore.filterTasksAtRevision(filterMap, anyRevision)
+	return tasks, err
+}
+
+// ListTasksAtVersion lists all the tasks as they were at 'entityVersion' and
+// returns the etcd revision they were read at. Entity version 0 reads the
+// latest tasks.
+func (taskStore eventTaskStore) ListTasksAtVersion(entityVersion string) ([]storetypes.VersionedTask, int64, error) {
+	revision, err := regex.GetEntityVersion(entityVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	return taskStore.getTasksByKeyPrefix(taskKeyPrefix, revision)
+}
+
+// FilterTasksAtVersion returns all the tasks that matched the provided filters
+// at 'entityVersion' and the etcd revision they were read at. Entity version 0
+// reads the latest tasks.
+func (taskStore eventTaskStore) FilterTasksAtVersion(filterMap map[string]string, entityVersion string) ([]storetypes.VersionedTask, int64, error) {
+	revision, err := regex.GetEntityVersion(entityVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	return taskStore.filterTasksAtRevision(filterMap, revision)
+}
+
+// filterTasksAtRevision filters the tasks read with a single read at
+// 'revision', as getTasksByKeyPrefix reads them
+func (taskStore eventTaskStore) filterTasksAtRevision(filterMap map[string]string, revision int64) ([]storetypes.VersionedTask, int64, error) {
 	if len(filterMap) == 0 {
-		return nil, errors.New("There has to be at least one filter")
+		return nil, 0, errors.New("There has to be at least one filter")
 	}
 
 	filters := make([]string, 0, len(filterMap))
@@ -118,11 +213,11 @@ func (taskStore eventTaskStore) FilterTasks(filterMap map[string]string) ([]stor
 		}
 	}
 	if len(filters) == 0 {
-		return nil, errors.New("There has to be at least one filter with a filter value set")
+		return nil, 0, errors.New("There has to be at least one filter with a filter value set")
 	}
 
 	if !taskStore.areFiltersValid(filters) {
-		return nil, errors.Errorf("At least one of the provided filters '%v' is not supported.", filters)
+		return nil, 0, errors.Errorf("At least one of the provided filters '%v' is not supported.", filters)
 	}
 
 	var result []storetypes.VersionedTask
@@ -130,14 +225,14 @@ func (taskStore eventTaskStore) FilterTasks(filterMap map[string]string) ([]stor
 	// filterTasksByCluster does an etcd list by cluster prefix
 	// so it can't be combined with other task filters.
 	if cluster := filterMap[taskClusterFilter]; cluster != "" {
-		result, err = taskStore.filterTasksByCluster(cluster)
+		result, revision, err = taskStore.filterTasksByCluster(cluster, revision)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	} else {
-		result, err = taskStore.ListTasks()
+		result, revision, err = taskStore.getTasksByKeyPrefix(taskKeyPrefix, revision)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
 
@@ -147,12 +242,12 @@ func (taskStore eventTaskStore) FilterTasks(filterMap map[string]string) ([]stor
 		}
 		taskFilter, err := taskStore.getTaskFilter(k)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		result = taskStore.filterTasks(result, taskFilter, v)
 	}
 
-	return result, nil
+	return result, revision, nil
 }
 
 // StreamTasks streams all changes in the task keyspace into a channel
@@ -182,6 +277,33 @@ func (taskStore eventTaskStore) DeleteTask(cluster string, taskARN string) error
 	log.Debugf("Deleted '%d' key(s) from the store for task '%s', belonging to cluster '%s'",
 		numKeysDeleted, taskARN, cluster)
 	// TODO: Should numKeysDeleted != 1 cause an error as well?
+	if err != nil {
+		return err
+	}
+
+	_, err = taskStore.datastore.Delete(historyKey(key))
+	return err
+}
+
+// PurgeTask replaces the task key in the data store with a tombstone, which
+// prevents events with the same or an older version from adding the task back.
+// The task is left untouched if its version is no longer 'version'.
+func (taskStore eventTaskStore) PurgeTask(cluster string, taskARN string, version int64) error {
+	key, err := taskStore.getTaskKey(cluster, taskARN)
+	if err != nil {
+		return errors.Wrapf(err, "Could not generate task key for cluster '%s' and task '%s'",
+			cluster, taskARN)
+	}
+
+	purger := &STMPurger{
+		record:    types.Task{},
+		recordKey: key,
+		version:   version,
+		purgedAt:  time.Now(),
+	}
+	_, err = taskStore.etcdTXStore.NewSTMRepeatable(context.TODO(),
+		taskStore.etcdTXStore.GetV3Client(),
+		purger.purgeRecord)
 	return err
 }
 
@@ -200,12 +322,12 @@ func (taskStore eventTaskStore) unmarshalTaskAndGenerateKey(taskJSON string) (*t
 	}
 
 	clusterARN := aws.StringValue(task.Detail.ClusterARN)
-	clusterName, err := regex.GetClusterNameFromARN(clusterARN)
+	cluster, err := regex.GetClusterFromARN(clusterARN)
 	if err != nil {
-		return nil, "", errors.Wrapf(err, "Error retrieving cluster name from ARN '%s' for task", clusterARN)
+		return nil, "", errors.Wrapf(err, "Error retrieving cluster from ARN '%s' for task", clusterARN)
 	}
 
-	key, err := generateTaskKey(clusterName, aws.StringValue(task.Detail.TaskARN))
+	key, err := generateTaskKey(cluster, aws.StringValue(task.Detail.TaskARN))
 	if err != nil {
 		return nil, "", err
 	}
@@ -236,12 +358,30 @@ func isTaskStartedBy(startedBy string, task types.Task) bool {
 	return startedBy == task.Detail.StartedBy
 }
 
+// isTaskLaunchType matches tasks without a launch type as EC2 tasks because
+// events for tasks launched before Fargate was introduced do not have one
+func isTaskLaunchType(launchType string, task types.Task) bool {
+	taskLaunchType := task.Detail.LaunchType
+	if taskLaunchType == "" {
+		taskLaunchType = defaultTaskLaunchType
+	}
+	return strings.ToLower(launchType) == strings.ToLower(taskLaunchType)
+}
+
+func isTaskGroup(group string, task types.Task) bool {
+	return group == task.Detail.Group
+}
+
 func (taskStore eventTaskStore) getTaskFilter(filterName string) (taskFilter, error) {
 	switch filterName {
-	case "status":
+	case taskStatusFilter:
 		return isTaskStatus, nil
-	case "startedBy":
+	case taskStartedByFilter:
 		return isTaskStartedBy, nil
+	case taskLaunchTypeFilter:
+		return isTaskLaunchType, nil
+	case taskGroupFilter:
+		return isTaskGroup, nil
 	}
 	return nil, errors.Errorf("Unsupported task filter: %v", filterName)
 }
@@ -257,18 +397,29 @@ func (taskStore eventTaskStore) filterTasks(tasks []storetypes.VersionedTask, fi
 	return filteredTasks
 }
 
-func (taskStore eventTaskStore) filterTasksByCluster(cluster string) ([]storetypes.VersionedTask, error) {
-	clusterName := cluster
-	var err error
-	if regex.IsClusterARN(cluster) {
-		clusterName, err = regex.GetClusterNameFromARN(cluster)
-		if err != nil {
-			return nil, err
-		}
+func (taskStore eventTaskStore) filterTasksByCluster(cluster string, revision int64) ([]storetypes.VersionedTask, int64, error) {
+	identifier, err := regex.ParseCluster(cluster)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Cluster ARNs identify a single cluster, whose tasks share a key prefix
+	if identifier.Account != "" {
+		return taskStore.getTasksByKeyPrefix(clusterKeyPrefix(taskKeyPrefix, identifier), revision)
+	}
+
+	tasks, revision, err := taskStore.getTasksByKeyPrefix(taskKeyPrefix, revision)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	tasksForClusterPrefix := taskKeyPrefix + clusterName + "/"
-	return taskStore.getTasksByKeyPrefix(tasksForClusterPrefix)
+	filteredTasks := []storetypes.VersionedTask{}
+	for _, versionedTask := range tasks {
+		if identifier.Matches(aws.StringValue(versionedTask.Task.Detail.ClusterARN)) {
+			filteredTasks = append(filteredTasks, versionedTask)
+		}
+	}
+	return filteredTasks, revision, nil
 }
 
 func (taskStore eventTaskStore) getTaskKey(cluster string, taskARN string) (string, error) {
@@ -280,16 +431,12 @@ func (taskStore eventTaskStore) getTaskKey(cluster string, taskARN string) (stri
 		return "", errors.New("Task ARN should not be empty")
 	}
 
-	clusterName := cluster
-	var err error
-	if regex.IsClusterARN(cluster) {
-		clusterName, err = regex.GetClusterNameFromARN(cluster)
-		if err != nil {
-			return "", err
-		}
+	identifier, err := resolveCluster(cluster, taskARN)
+	if err != nil {
+		return "", err
 	}
 
-	return generateTaskKey(clusterName, taskARN)
+	return generateTaskKey(identifier, taskARN)
 }
 
 func (taskStore eventTaskStore) pipeBetweenChannels(ctx context.Context, cancel context.CancelFunc, dsChan chan map[string]storetypes.Entity, taskRespChan chan storetypes.VersionedTask) {
@@ -326,6 +473,10 @@ func (taskStore eventTaskStore) getTaskByKey(key string) (*storetypes.VersionedT
 		return nil, errors.New("Key cannot be empty")
 	}
 
+	if record, ok := taskStore.view.getTask(key); ok {
+		return record, nil
+	}
+
 	resp, err := taskStore.datastore.Get(key)
 	if err != nil {
 		return nil, err
@@ -351,18 +502,32 @@ func (taskStore eventTaskStore) getTaskByKey(key string) (*storetypes.VersionedT
 	return &versionedTask, nil
 }
 
-func (taskStore eventTaskStore) getTasksByKeyPrefix(key string) ([]storetypes.VersionedTask, error) {
+// getTasksByKeyPrefix returns the tasks whose keys start with 'key' at etcd
+// revision 'revision', or the latest ones if it is 0, and the revision they
+// were read at. With anyRevision, the latest tasks are read from the view
+// when it is seeded and the returned revision is 0.
+func (taskStore eventTaskStore) getTasksByKeyPrefix(key string, revision int64) ([]storetypes.VersionedTask, int64, error) {
 	if len(key) == 0 {
-		return nil, errors.New("Key cannot be empty")
+		return nil, 0, errors.New("Key cannot be empty")
 	}
 
-	resp, err := taskStore.datastore.GetWithPrefix(key)
+	var resp map[string]storetypes.Entity
+	var err error
+	if revision == anyRevision {
+		if records, ok := taskStore.view.getTasksWithPrefix(key); ok {
+			return records, 0, nil
+		}
+		resp, err = taskStore.datastore.GetWithPrefix(key)
+		revision = 0
+	} else {
+		resp, revision, err = taskStore.datastore.GetWithPrefixAtRevision(key, revision)
+	}
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if len(resp) == 0 {
-		return make([]storetypes.VersionedTask, 0), nil
+		return make([]storetypes.VersionedTask, 0), revision, nil
 	}
 
 	versionedTasks := []storetypes.VersionedTask{}
@@ -371,12 +536,12 @@ func (taskStore eventTaskStore) getTasksByKeyPrefix(key string) ([]storetypes.Ve
 		versionedTask.Task, err = taskStore.unmarshalString(entity.Value)
 		versionedTask.Version = entity.Version
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		versionedTasks = append(versionedTasks, versionedTask)
 	}
-	return versionedTasks, nil
+	return versionedTasks, revision, nil
 }
 
 func (taskStore eventTaskStore) unmarshalString(val string) (types.Task, error) {
@@ -389,12 +554,18 @@ func (taskStore eventTaskStore) unmarshalString(val string) (types.Task, error)
 	return task, nil
 }
 
-func generateTaskKey(clusterName string, taskARN string) (string, error) {
-	if !regex.IsClusterName(clusterName) {
-		return "", errors.Errorf("Error generating task key. Cluster name '%s' does not match expected regex", clusterName)
-	}
+func generateTaskKey(cluster regex.ClusterIdentifier, taskARN string) (string, error) {
 	if !regex.IsTaskARN(taskARN) {
 		return "", errors.Errorf("Error generating task key. Task ARN '%s' does not match expected regex", taskARN)
 	}
-	return taskKeyPrefix + clusterName + "/" + taskARN, nil
+	// ARNs in the long format carry the name of the cluster, which has to
+	// match the cluster the task is stored under
+	if clusterName, err := regex.GetClusterNameFromTaskARN(taskARN); err == nil && clusterName != cluster.Name {
+		return "", errors.Errorf("Error generating task key. Task ARN '%s' does not belong to cluster '%s'", taskARN, cluster.Name)
+	}
+	key, err := generateEntityKey(taskKeyPrefix, cluster, taskARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error generating task key")
+	}
+	return key, nil
 }
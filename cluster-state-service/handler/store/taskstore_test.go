@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
 	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
@@ -28,9 +29,9 @@ import (
 )
 
 var (
-	taskARN1      = "arn:aws:ecs:us-east-1:123456789012:task/271022c0-f894-4aa2-b063-25bae55088d5"
-	taskARN2      = "arn:aws:ecs:us-east-1:123456789012:task/345022c0-f894-4aa2-b063-25bae55088d5"
-	taskARN3      = "arn:aws:ecs:us-east-1:123456789012:task/345022c0-f894-4aa2-b063-25bae55088dd"
+	taskARN1      = "arn:aws:ecs:us-east-1:123456789123:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	taskARN2      = "arn:aws:ecs:us-east-1:123456789123:task/345022c0-f894-4aa2-b063-25bae55088d5"
+	taskARN3      = "arn:aws:ecs:us-east-1:123456789123:task/345022c0-f894-4aa2-b063-25bae55088dd"
 	pendingStatus = "pending"
 	runningStatus = "running"
 	someoneElse   = "someone-else"
@@ -67,10 +68,10 @@ func (suite *TaskStoreTestSuite) SetupTest() {
 	suite.datastore = mocks.NewMockDataStore(mockCtrl)
 	suite.etcdTxStore = mocks.NewMockEtcdTXStore(mockCtrl)
 
-	suite.taskKey1 = taskKeyPrefix + clusterName1 + "/" + taskARN1
+	suite.taskKey1 = taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + taskARN1
 
 	var err error
-	suite.taskStore, err = NewTaskStore(suite.datastore, suite.etcdTxStore)
+	suite.taskStore, err = NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Nil(suite.T(), err, "Cannot setup testSuite: Unexpected error when calling NewTaskStore")
 
 	version1 := int64(1)
@@ -161,17 +162,27 @@ func TestTaskStoreTestSuite(t *testing.T) {
 }
 
 func (suite *TaskStoreTestSuite) TestNewTaskStoreNilDatastore() {
-	_, err := NewTaskStore(nil, suite.etcdTxStore)
+	_, err := NewTaskStore(nil, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Error(suite.T(), err, "Expected an error when datastore is nil")
 }
 
 func (suite *TaskStoreTestSuite) TestNewTaskStoreNilEtcdTXStore() {
-	_, err := NewTaskStore(suite.datastore, nil)
+	_, err := NewTaskStore(suite.datastore, nil, stmRecordApplier{}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Error(suite.T(), err, "Expected an error when etcd transactional store is nil")
 }
 
+func (suite *TaskStoreTestSuite) TestNewTaskStoreNilRecordApplier() {
+	_, err := NewTaskStore(suite.datastore, suite.etcdTxStore, nil, jsonCodec{}, nil, testHistoryLimits)
+	assert.Error(suite.T(), err, "Expected an error when record applier is nil")
+}
+
+func (suite *TaskStoreTestSuite) TestNewTaskStoreNilCodec() {
+	_, err := NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, nil, nil, testHistoryLimits)
+	assert.Error(suite.T(), err, "Expected an error when codec is nil")
+}
+
 func (suite *TaskStoreTestSuite) TestNewTaskStore() {
-	taskStore, err := NewTaskStore(suite.datastore, suite.etcdTxStore)
+	taskStore, err := NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, nil, testHistoryLimits)
 	assert.Nil(suite.T(), err, "Unexpected error when calling NewTaskStore")
 	assert.NotNil(suite.T(), taskStore, "TaskStore should not be nil")
 }
@@ -283,6 +294,72 @@ func (suite *TaskStoreTestSuite) TestGetTaskEmptyTaskARN() {
 	assert.Error(suite.T(), err, "Expected an error when task ARN is empty in GetTask")
 }
 
+func (suite *TaskStoreTestSuite) TestGetTaskLongTaskARNInOtherCluster() {
+	longTaskARN := "arn:aws:ecs:us-east-1:123456789123:task/" + clusterName2 + "/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	_, err := suite.taskStore.GetTask(clusterName1, longTaskARN)
+	assert.Error(suite.T(), err, "Expected an error when the task ARN names another cluster in GetTask")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskWithLongTaskARN() {
+	longTaskARN := "arn:aws:ecs:us-east-1:123456789123:task/" + clusterName1 + "/e8c6b1a7d3b14a4f9b1e4c9e2a6d3c1f"
+	key := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + longTaskARN
+	resp := map[string]storetypes.Entity{
+		longTaskARN: suite.firstPendingTaskEntity,
+	}
+	suite.datastore.EXPECT().Get(key).Return(resp, nil)
+
+	task, err := suite.taskStore.GetTask(clusterName1, longTaskARN)
+	assert.Nil(suite.T(), err, "Unexpected error when getting task with a long task ARN")
+	assert.NotNil(suite.T(), task, "Expected a non-nil task when calling GetTask")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskHistoryEmptyTaskARN() {
+	_, err := suite.taskStore.GetTaskHistory(clusterName1, "")
+	assert.Error(suite.T(), err, "Expected an error when task ARN is empty in GetTaskHistory")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskHistoryGetFails() {
+	suite.datastore.EXPECT().Get(historyKey(suite.taskKey1)).Return(nil, errors.New("Error when getting key"))
+
+	_, err := suite.taskStore.GetTaskHistory(clusterName1, taskARN1)
+	assert.Error(suite.T(), err, "Expected an error when get task history fails")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskHistoryNoResults() {
+	suite.datastore.EXPECT().Get(historyKey(suite.taskKey1)).Return(make(map[string]storetypes.Entity), nil)
+
+	history, err := suite.taskStore.GetTaskHistory(clusterName1, taskARN1)
+	assert.Nil(suite.T(), err, "Unexpected error when datastore returns empty results")
+	assert.Empty(suite.T(), history, "Expected empty history when datastore returns empty results")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskHistoryInvalidJSONResult() {
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.setupEntity(taskARN1, "invalidJSON", entityVersion),
+	}
+	suite.datastore.EXPECT().Get(historyKey(suite.taskKey1)).Return(resp, nil)
+
+	_, err := suite.taskStore.GetTaskHistory(clusterName1, taskARN1)
+	assert.Error(suite.T(), err, "Expected an error when datastore returns invalid json results")
+}
+
+func (suite *TaskStoreTestSuite) TestGetTaskHistoryWithClusterARN() {
+	entry, err := newHistoryEntry(1, time.Now(), suite.firstPendingTask.Detail.State())
+	assert.Nil(suite.T(), err, "Unexpected error creating history entry")
+	historyJSON, err := appendHistory("", entry, testHistoryLimits, time.Now())
+	assert.Nil(suite.T(), err, "Unexpected error creating history")
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.setupEntity(taskARN1, historyJSON, entityVersion),
+	}
+	suite.datastore.EXPECT().Get(historyKey(suite.taskKey1)).Return(resp, nil)
+
+	history, err := suite.taskStore.GetTaskHistory(clusterARN1, taskARN1)
+	assert.Nil(suite.T(), err, "Unexpected error when getting task history")
+	assert.Len(suite.T(), history, 1, "Unexpected number of history entries")
+	assert.Equal(suite.T(), int64(1), history[0].Version, "Unexpected history entry version")
+	assert.Equal(suite.T(), suite.firstPendingTask.Detail.State(), history[0].State, "Unexpected history entry state")
+}
+
 func (suite *TaskStoreTestSuite) TestGetTaskGetTaskFails() {
 	suite.datastore.EXPECT().Get(suite.taskKey1).Return(nil, errors.New("Error when getting key"))
 
@@ -344,6 +421,32 @@ func (suite *TaskStoreTestSuite) TestGetTaskWithClusterARNAndTaskARN() {
 	assert.Exactly(suite.T(), suite.firstPendingTask, task.Task, "Expected the returned task to match the one returned from the datastore")
 }
 
+func (suite *TaskStoreTestSuite) TestGetTaskFromView() {
+	view := newSeededView(suite.T(), 10, viewKV(suite.taskKey1, suite.firstPendingTaskJSON, 9))
+	taskStore, err := NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(suite.T(), err, "Unexpected error when calling NewTaskStore")
+
+	task, err := taskStore.GetTask(clusterARN1, taskARN1)
+	assert.Nil(suite.T(), err, "Unexpected error when getting task")
+	assert.NotNil(suite.T(), task, "Expected a non-nil task when calling GetTask")
+	assert.Exactly(suite.T(), suite.firstPendingTask, task.Task, "Expected the returned task to match the one in the view")
+	assert.Equal(suite.T(), "9", task.Version, "Expected the version of the task in the view")
+}
+
+func (suite *TaskStoreTestSuite) TestFilterTasksByClusterARNFromView() {
+	view := newSeededView(suite.T(), 10,
+		viewKV(suite.taskKey1, suite.firstTaskOfFirstClusterJSON, 9),
+		viewKV(taskKeyPrefix+accountID+"/"+region+"/"+clusterName2+"/"+taskARN3, suite.setupTask(suite.firstPendingTask), 10),
+	)
+	taskStore, err := NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(suite.T(), err, "Unexpected error when calling NewTaskStore")
+
+	tasks, err := taskStore.FilterTasks(map[string]string{taskClusterFilter: clusterARN1})
+	assert.Nil(suite.T(), err, "Unexpected error when calling filter tasks")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected the tasks of the cluster in the view")
+	assert.Exactly(suite.T(), suite.firstTaskOfFirstCluster, tasks[0].Task, "Expected the task of the cluster in the view")
+}
+
 func (suite *TaskStoreTestSuite) TestListTasksGetWithPrefixInvalidJSON() {
 	resp := map[string]storetypes.Entity{
 		taskARN1: suite.setupEntity(taskARN1, "invalidJSON", entityVersion),
@@ -398,6 +501,78 @@ func (suite *TaskStoreTestSuite) TestListTasksGetWithPrefixReturnsMultipleResult
 	}
 }
 
+func (suite *TaskStoreTestSuite) TestListTasksAtVersionInvalidEntityVersion() {
+	_, _, err := suite.taskStore.ListTasksAtVersion("invalidEntityVersion")
+	assert.Error(suite.T(), err, "Expected an error when the entity version is invalid")
+}
+
+func (suite *TaskStoreTestSuite) TestListTasksAtVersionOutOfRange() {
+	suite.datastore.EXPECT().GetWithPrefixAtRevision(taskKeyPrefix, int64(123)).Return(nil, int64(0), types.NewOutOfRangeEntityVersion(errors.New("compacted")))
+
+	_, _, err := suite.taskStore.ListTasksAtVersion(entityVersion)
+	assert.Error(suite.T(), err, "Expected an error when the entity version is out of range")
+	assert.IsType(suite.T(), types.OutOfRangeEntityVersion{}, err, "Expected the error to be of type OutOfRangeEntityVersion")
+}
+
+func (suite *TaskStoreTestSuite) TestListTasksAtVersion() {
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstPendingTaskEntity,
+	}
+	suite.datastore.EXPECT().GetWithPrefixAtRevision(taskKeyPrefix, int64(123)).Return(resp, int64(123), nil)
+
+	tasks, revision, err := suite.taskStore.ListTasksAtVersion(entityVersion)
+	assert.Nil(suite.T(), err, "Unexpected error when listing tasks at a version")
+	assert.Equal(suite.T(), int64(123), revision, "Expected the revision the tasks were read at")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected the tasks at the version")
+	assert.Exactly(suite.T(), suite.firstPendingTask, tasks[0].Task, "Expected the task at the version")
+}
+
+func (suite *TaskStoreTestSuite) TestListTasksAtVersionBypassesView() {
+	view := newSeededView(suite.T(), 130, viewKV(suite.taskKey1, suite.firstTaskOfFirstClusterJSON, 129))
+	taskStore, err := NewTaskStore(suite.datastore, suite.etcdTxStore, stmRecordApplier{etcdTXStore: suite.etcdTxStore}, jsonCodec{}, view, testHistoryLimits)
+	assert.Nil(suite.T(), err, "Unexpected error when calling NewTaskStore")
+	suite.datastore.EXPECT().GetWithPrefixAtRevision(taskKeyPrefix, int64(0)).Return(map[string]storetypes.Entity{}, int64(131), nil)
+
+	tasks, revision, err := taskStore.ListTasksAtVersion("0")
+	assert.Nil(suite.T(), err, "Unexpected error when listing the latest tasks")
+	assert.Equal(suite.T(), int64(131), revision, "Expected the revision the latest tasks were read at in etcd")
+	assert.Empty(suite.T(), tasks, "Expected the tasks read from etcd")
+}
+
+func (suite *TaskStoreTestSuite) TestFilterTasksAtVersionByClusterARNAndStatus() {
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstTaskOfFirstClusterEntity,
+		taskARN2: suite.setupEntity(taskARN2, suite.setupTask(types.Task{
+			Detail: &types.TaskDetail{
+				TaskARN:    &taskARN2,
+				ClusterARN: &clusterARN1,
+				LastStatus: &runningStatus,
+			},
+		}), entityVersion),
+	}
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
+	suite.datastore.EXPECT().GetWithPrefixAtRevision(clusterKey, int64(123)).Return(resp, int64(123), nil)
+
+	tasks, revision, err := suite.taskStore.FilterTasksAtVersion(
+		map[string]string{taskClusterFilter: clusterARN1, taskStatusFilter: pendingStatus}, entityVersion)
+	assert.Nil(suite.T(), err, "Unexpected error when filtering tasks at a version")
+	assert.Equal(suite.T(), int64(123), revision, "Expected the revision the tasks were read at")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected the tasks matching the filters at the version")
+	assert.Exactly(suite.T(), suite.firstTaskOfFirstCluster, tasks[0].Task, "Expected the task matching the filters at the version")
+}
+
+func (suite *TaskStoreTestSuite) TestFilterTasksAtVersionByClusterName() {
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstTaskOfFirstClusterEntity,
+	}
+	suite.datastore.EXPECT().GetWithPrefixAtRevision(taskKeyPrefix, int64(123)).Return(resp, int64(123), nil)
+
+	tasks, revision, err := suite.taskStore.FilterTasksAtVersion(map[string]string{taskClusterFilter: clusterName1}, entityVersion)
+	assert.Nil(suite.T(), err, "Unexpected error when filtering tasks at a version")
+	assert.Equal(suite.T(), int64(123), revision, "Expected the revision the tasks were read at")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected the tasks of the cluster at the version")
+}
+
 func (suite *TaskStoreTestSuite) TestFilterTasksNoFilters() {
 	var filters map[string]string
 	_, err := suite.taskStore.FilterTasks(filters)
@@ -547,6 +722,59 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByStartedByListTasksReturnsMulti
 	assert.Exactly(suite.T(), taskMatchingStartedBy, tasks[0].Task, "Expected one result when one matches filter")
 }
 
+func (suite *TaskStoreTestSuite) TestFilterTasksByLaunchType() {
+	version1 := int64(1)
+	fargateTask := types.Task{
+		Detail: &types.TaskDetail{
+			TaskARN:    &taskARN2,
+			ClusterARN: &clusterARN1,
+			LastStatus: &pendingStatus,
+			LaunchType: "FARGATE",
+			Version:    &version1,
+		},
+	}
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstTaskStartedBySomeoneElseEntity,
+		taskARN2: suite.setupEntity(taskARN2, suite.setupTask(fargateTask), entityVersion),
+	}
+	suite.datastore.EXPECT().GetWithPrefix(taskKeyPrefix).Return(resp, nil).Times(2)
+
+	tasks, err := suite.taskStore.FilterTasks(map[string]string{taskLaunchTypeFilter: "fargate"})
+	assert.Nil(suite.T(), err, "Unexpected error when filtering tasks by launch type")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected one Fargate task")
+	assert.Exactly(suite.T(), fargateTask, tasks[0].Task, "Expected the Fargate task to match the filter")
+
+	// Tasks without a launch type are EC2 tasks
+	tasks, err = suite.taskStore.FilterTasks(map[string]string{taskLaunchTypeFilter: "EC2"})
+	assert.Nil(suite.T(), err, "Unexpected error when filtering tasks by launch type")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected one EC2 task")
+	assert.Exactly(suite.T(), suite.firstTaskStartedBySomeoneElse, tasks[0].Task, "Expected the task without a launch type to match the EC2 filter")
+}
+
+func (suite *TaskStoreTestSuite) TestFilterTasksByGroup() {
+	version1 := int64(1)
+	group := "service:web"
+	serviceTask := types.Task{
+		Detail: &types.TaskDetail{
+			TaskARN:    &taskARN2,
+			ClusterARN: &clusterARN1,
+			Group:      group,
+			LastStatus: &pendingStatus,
+			Version:    &version1,
+		},
+	}
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstTaskStartedBySomeoneElseEntity,
+		taskARN2: suite.setupEntity(taskARN2, suite.setupTask(serviceTask), entityVersion),
+	}
+	suite.datastore.EXPECT().GetWithPrefix(taskKeyPrefix).Return(resp, nil)
+
+	tasks, err := suite.taskStore.FilterTasks(map[string]string{taskGroupFilter: group})
+	assert.Nil(suite.T(), err, "Unexpected error when filtering tasks by group")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected one task in the group")
+	assert.Exactly(suite.T(), serviceTask, tasks[0].Task, "Expected the task in the group to match the filter")
+}
+
 func (suite *TaskStoreTestSuite) TestFilterTasksByStartedByListTasksReturnsMultipleResultsMultipleMatchFilter() {
 	resp := map[string]storetypes.Entity{
 		taskARN1: suite.firstTaskStartedBySomeoneElseEntity,
@@ -574,7 +802,7 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByStartedByListTasksReturnsMulti
 }
 
 func (suite *TaskStoreTestSuite) TestFilterTasksByClusterNameGetWithPrefixFails() {
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(nil, errors.New("GetTasksByKeyPrefix failed"))
 
 	_, err := suite.taskStore.FilterTasks(map[string]string{taskClusterFilter: clusterName1})
@@ -582,20 +810,43 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByClusterNameGetWithPrefixFails(
 }
 
 func (suite *TaskStoreTestSuite) TestFilterTasksByClusterARNGetWithPrefixFails() {
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(nil, errors.New("GetTasksByKeyPrefix failed"))
 
 	_, err := suite.taskStore.FilterTasks(map[string]string{taskClusterFilter: clusterARN1})
 	assert.Error(suite.T(), err, "Expected an error when GetWithPrefix fails")
 }
 
+func (suite *TaskStoreTestSuite) TestFilterTasksByRegionQualifiedClusterNameSkipsOtherRegions() {
+	otherRegionClusterARN := "arn:aws:ecs:us-west-2:" + accountID + ":cluster/" + clusterName1
+	otherRegionTask := types.Task{
+		Detail: &types.TaskDetail{
+			TaskARN:    &taskARN3,
+			ClusterARN: &otherRegionClusterARN,
+			LastStatus: &pendingStatus,
+			Version:    suite.firstTaskOfFirstCluster.Detail.Version,
+		},
+	}
+	resp := map[string]storetypes.Entity{
+		taskARN1: suite.firstTaskOfFirstClusterEntity,
+		taskARN3: suite.setupEntity(taskARN3, suite.setupTask(otherRegionTask), entityVersion),
+	}
+	suite.datastore.EXPECT().GetWithPrefix(taskKeyPrefix).Return(resp, nil)
+
+	tasks, err := suite.taskStore.FilterTasks(map[string]string{taskClusterFilter: region + ":" + clusterName1})
+
+	assert.Nil(suite.T(), err, "Unexpected error when calling filter tasks")
+	assert.Equal(suite.T(), 1, len(tasks), "Expected only the task in the region of the cluster to match filter")
+	assert.Exactly(suite.T(), suite.firstTaskOfFirstCluster, tasks[0].Task, "Unexpected task matching filter")
+}
+
 func (suite *TaskStoreTestSuite) TestFilterTasksByClusterNameGetWithPrefixReturnsTasks() {
 	resp := map[string]storetypes.Entity{
 		taskARN1: suite.firstTaskOfFirstClusterEntity,
 		taskARN2: suite.secondTaskOfFirstClusterEntity,
 	}
 
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(resp, nil)
 
 	tasks, err := suite.taskStore.FilterTasks(map[string]string{taskClusterFilter: clusterName1})
@@ -622,7 +873,7 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByClusterARNGetWithPrefixReturns
 		taskARN2: suite.secondTaskOfFirstClusterEntity,
 	}
 
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(resp, nil)
 
 	tasks, err := suite.taskStore.FilterTasks(map[string]string{taskClusterFilter: clusterARN1})
@@ -668,7 +919,7 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByClusterAndStartedBy() {
 		taskARN2: suite.setupEntity(taskARN2, cluster1RandomTaskJSON, entityVersion),
 	}
 
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(resp, nil)
 
 	tasks, err := suite.taskStore.FilterTasks(
@@ -702,7 +953,7 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByClusterAndStatus() {
 		taskARN2: suite.setupEntity(taskARN2, cluster1RunningTaskJSON, entityVersion),
 	}
 
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(resp, nil)
 
 	tasks, err := suite.taskStore.FilterTasks(
@@ -797,7 +1048,7 @@ func (suite *TaskStoreTestSuite) TestFilterTasksByStartedByAndClusterAndStatus()
 		taskARN3: suite.setupEntity(taskARN3, cluster1PendingRandomTaskJSON, entityVersion),
 	}
 
-	clusterKey := taskKeyPrefix + clusterName1 + "/"
+	clusterKey := taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
 	suite.datastore.EXPECT().GetWithPrefix(clusterKey).Return(resp, nil)
 
 	tasks, err := suite.taskStore.FilterTasks(
@@ -903,8 +1154,17 @@ func (suite *TaskStoreTestSuite) TestDeleteTaskDeleteTaskFails() {
 	assert.Error(suite.T(), err, "Expected an error when delete task fails")
 }
 
+func (suite *TaskStoreTestSuite) TestDeleteTaskDeleteHistoryFails() {
+	suite.datastore.EXPECT().Delete(suite.taskKey1).Return(int64(1), nil)
+	suite.datastore.EXPECT().Delete(historyKey(suite.taskKey1)).Return(int64(0), errors.New("Error when deleting key"))
+
+	err := suite.taskStore.DeleteTask(clusterName1, taskARN1)
+	assert.Error(suite.T(), err, "Expected an error when deleting task history fails")
+}
+
 func (suite *TaskStoreTestSuite) TestDeleteTaskDeleteNoError() {
 	suite.datastore.EXPECT().Delete(suite.taskKey1).Return(int64(1), nil)
+	suite.datastore.EXPECT().Delete(historyKey(suite.taskKey1)).Return(int64(1), nil)
 
 	err := suite.taskStore.DeleteTask(clusterName1, taskARN1)
 	assert.NoError(suite.T(), err, "Error when deleting task")
@@ -912,11 +1172,36 @@ func (suite *TaskStoreTestSuite) TestDeleteTaskDeleteNoError() {
 
 func (suite *TaskStoreTestSuite) TestDeleteTaskDeleteWithClusterNameAndTaskARN() {
 	suite.datastore.EXPECT().Delete(suite.taskKey1).Return(int64(1), nil)
+	suite.datastore.EXPECT().Delete(historyKey(suite.taskKey1)).Return(int64(1), nil)
 
 	err := suite.taskStore.DeleteTask(clusterARN1, taskARN1)
 	assert.NoError(suite.T(), err, "Error when deleting task")
 }
 
+func (suite *TaskStoreTestSuite) TestPurgeTaskEmptyClusterName() {
+	err := suite.taskStore.PurgeTask("", taskARN1, 1)
+	assert.Error(suite.T(), err, "Expected an error when cluster name is empty in PurgeTask")
+}
+
+func (suite *TaskStoreTestSuite) TestPurgeTaskEmptyTaskARN() {
+	err := suite.taskStore.PurgeTask(clusterName1, "", 1)
+	assert.Error(suite.T(), err, "Expected an error when task ARN is empty in PurgeTask")
+}
+
+func (suite *TaskStoreTestSuite) TestPurgeTaskSTMRepeatableFails() {
+	suite.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	suite.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("Error when getting key"))
+	err := suite.taskStore.PurgeTask(clusterARN1, taskARN1, 1)
+	assert.Error(suite.T(), err, "Expected error when STM repeatable fails to execute with an error")
+}
+
+func (suite *TaskStoreTestSuite) TestPurgeTask() {
+	suite.etcdTxStore.EXPECT().GetV3Client().Return(nil)
+	suite.etcdTxStore.EXPECT().NewSTMRepeatable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	err := suite.taskStore.PurgeTask(clusterARN1, taskARN1, 1)
+	assert.NoError(suite.T(), err, "Unexpected error when purging task")
+}
+
 func addTaskToDSChanAndReadFromTaskRespChan(taskToAdd storetypes.Entity, dsChan chan map[string]storetypes.Entity, taskRespChan chan storetypes.VersionedTask) storetypes.VersionedTask {
 	var taskResp storetypes.VersionedTask
 
@@ -0,0 +1,116 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	entityKeyPrefix    = "ecs/"
+	tombstoneKeyPrefix = entityKeyPrefix + "tombstone/"
+)
+
+// tombstone is stored in place of a purged record. It remembers the version
+// of the record at the time it was purged so that events carrying the same
+// or an older version do not recreate the record.
+type tombstone struct {
+	Version  int64  `json:"version"`
+	PurgedAt string `json:"purgedAt"`
+}
+
+// TombstoneStore defines methods to manage the tombstones left behind by
+// purged tasks and container instances
+type TombstoneStore interface {
+	DeleteTombstonesPurgedBefore(before time.Time) (int, error)
+}
+
+type etcdTombstoneStore struct {
+	datastore DataStore
+}
+
+// NewTombstoneStore initializes the etcdTombstoneStore struct
+func NewTombstoneStore(ds DataStore) (TombstoneStore, error) {
+	if ds == nil {
+		return nil, errors.New("Datastore is not initialized")
+	}
+	return etcdTombstoneStore{
+		datastore: ds,
+	}, nil
+}
+
+// DeleteTombstonesPurgedBefore deletes all tombstones for entities that were
+// purged before the provided time and returns the number of deleted tombstones
+func (tombstoneStore etcdTombstoneStore) DeleteTombstonesPurgedBefore(before time.Time) (int, error) {
+	resp, err := tombstoneStore.datastore.GetWithPrefix(tombstoneKeyPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for key, entity := range resp {
+		t, err := unmarshalTombstone(entity.Value)
+		if err != nil {
+			log.Warnf("Deleting unreadable tombstone '%s': %v", key, err)
+		} else if purgedAt, err := time.Parse(time.RFC3339, t.PurgedAt); err != nil {
+			log.Warnf("Deleting tombstone '%s' with unreadable purge time '%s': %v", key, t.PurgedAt, err)
+		} else if !purgedAt.Before(before) {
+			continue
+		}
+
+		// A tombstone that was rewritten since it was listed guards a more
+		// recent purge, so it is only deleted if it is unmodified
+		ok, err := tombstoneStore.datastore.DeleteIfUnmodified(key, entity.Version)
+		if err != nil {
+			return deleted, errors.Wrapf(err, "Could not delete tombstone '%s'", key)
+		}
+		if ok {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// tombstoneKey returns the key of the tombstone for the record stored at
+// recordKey. For example, the tombstone of 'ecs/task/cluster/arn' is stored
+// at 'ecs/tombstone/task/cluster/arn'.
+func tombstoneKey(recordKey string) string {
+	return tombstoneKeyPrefix + strings.TrimPrefix(recordKey, entityKeyPrefix)
+}
+
+func newTombstoneJSON(version int64, purgedAt time.Time) (string, error) {
+	t := tombstone{
+		Version:  version,
+		PurgedAt: purgedAt.UTC().Format(time.RFC3339),
+	}
+	tJSON, err := json.Marshal(t)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error marshaling tombstone")
+	}
+	return string(tJSON), nil
+}
+
+func unmarshalTombstone(tombstoneJSON string) (tombstone, error) {
+	var t tombstone
+	err := json.Unmarshal([]byte(tombstoneJSON), &t)
+	if err != nil {
+		return t, errors.Wrapf(err, "Error unmarshaling tombstone '%s'", tombstoneJSON)
+	}
+	return t, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TombstoneStoreTestSuite struct {
+	suite.Suite
+	datastore      *mocks.MockDataStore
+	tombstoneStore TombstoneStore
+}
+
+func (suite *TombstoneStoreTestSuite) SetupTest() {
+	mockCtrl := gomock.NewController(suite.T())
+	suite.datastore = mocks.NewMockDataStore(mockCtrl)
+
+	var err error
+	suite.tombstoneStore, err = NewTombstoneStore(suite.datastore)
+	assert.Nil(suite.T(), err, "Cannot setup testSuite: Unexpected error when calling NewTombstoneStore")
+}
+
+func TestTombstoneStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(TombstoneStoreTestSuite))
+}
+
+func (suite *TombstoneStoreTestSuite) TestNewTombstoneStoreNilDatastore() {
+	_, err := NewTombstoneStore(nil)
+	assert.Error(suite.T(), err, "Expected an error when datastore is nil")
+}
+
+func (suite *TombstoneStoreTestSuite) TestTombstoneKey() {
+	assert.Equal(suite.T(), "ecs/tombstone/task/cluster/arn", tombstoneKey("ecs/task/cluster/arn"),
+		"Unexpected tombstone key")
+}
+
+func (suite *TombstoneStoreTestSuite) TestDeleteTombstonesGetWithPrefixFails() {
+	suite.datastore.EXPECT().GetWithPrefix(tombstoneKeyPrefix).Return(nil, errors.New("GetWithPrefix failed"))
+
+	_, err := suite.tombstoneStore.DeleteTombstonesPurgedBefore(time.Now())
+	assert.Error(suite.T(), err, "Expected an error when GetWithPrefix fails")
+}
+
+func (suite *TombstoneStoreTestSuite) TestDeleteTombstonesPurgedBefore() {
+	now := time.Now()
+	expiredKey := tombstoneKeyPrefix + "task/cluster/expired"
+	currentKey := tombstoneKeyPrefix + "task/cluster/current"
+	invalidKey := tombstoneKeyPrefix + "task/cluster/invalid"
+	invalidTimeKey := tombstoneKeyPrefix + "task/cluster/invalidTime"
+	rewrittenKey := tombstoneKeyPrefix + "task/cluster/rewritten"
+
+	expired, err := newTombstoneJSON(1, now.Add(-2*time.Hour))
+	assert.NoError(suite.T(), err, "Unexpected error generating tombstone")
+	current, err := newTombstoneJSON(1, now)
+	assert.NoError(suite.T(), err, "Unexpected error generating tombstone")
+
+	resp := map[string]storetypes.Entity{
+		expiredKey:     {Key: expiredKey, Value: expired, Version: "11"},
+		currentKey:     {Key: currentKey, Value: current, Version: "12"},
+		invalidKey:     {Key: invalidKey, Value: "invalidJSON", Version: "13"},
+		invalidTimeKey: {Key: invalidTimeKey, Value: `{"version":1,"purgedAt":"yesterday"}`, Version: "14"},
+		rewrittenKey:   {Key: rewrittenKey, Value: expired, Version: "15"},
+	}
+	suite.datastore.EXPECT().GetWithPrefix(tombstoneKeyPrefix).Return(resp, nil)
+	suite.datastore.EXPECT().DeleteIfUnmodified(expiredKey, "11").Return(true, nil)
+	suite.datastore.EXPECT().DeleteIfUnmodified(invalidKey, "13").Return(true, nil)
+	suite.datastore.EXPECT().DeleteIfUnmodified(invalidTimeKey, "14").Return(true, nil)
+	// rewritten by another purge since it was listed
+	suite.datastore.EXPECT().DeleteIfUnmodified(rewrittenKey, "15").Return(false, nil)
+
+	deleted, err := suite.tombstoneStore.DeleteTombstonesPurgedBefore(now.Add(-time.Hour))
+	assert.NoError(suite.T(), err, "Unexpected error deleting tombstones")
+	assert.Equal(suite.T(), 3, deleted, "Unexpected number of deleted tombstones")
+}
+
+func (suite *TombstoneStoreTestSuite) TestDeleteTombstonesDeleteFails() {
+	expired, err := newTombstoneJSON(1, time.Now().Add(-2*time.Hour))
+	assert.NoError(suite.T(), err, "Unexpected error generating tombstone")
+	resp := map[string]storetypes.Entity{key: {Key: key, Value: expired, Version: "1"}}
+	suite.datastore.EXPECT().GetWithPrefix(tombstoneKeyPrefix).Return(resp, nil)
+	suite.datastore.EXPECT().DeleteIfUnmodified(key, "1").Return(false, errors.New("Delete failed"))
+
+	_, err = suite.tombstoneStore.DeleteTombstonesPurgedBefore(time.Now())
+	assert.Error(suite.T(), err, "Expected an error when Delete fails")
+}
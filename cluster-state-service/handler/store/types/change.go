@@ -0,0 +1,34 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package types
+
+import (
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+)
+
+// Change is a put or delete of a task or container instance. Deletes hold the
+// entity as it was before it was deleted.
+type Change struct {
+	Deleted           bool
+	Version           string
+	Task              *types.Task
+	ContainerInstance *types.ContainerInstance
+}
+
+// ChangePage is a page of changes in the order they were made. Next is the
+// revision that the following page starts after.
+type ChangePage struct {
+	Changes []Change
+	Next    int64
+}
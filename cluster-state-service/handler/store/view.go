@@ -0,0 +1,469 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/goguardian/blox/cluster-state-service/handler/clients"
+	"github.com/goguardian/blox/cluster-state-service/handler/metrics"
+	storetypes "github.com/goguardian/blox/cluster-state-service/handler/store/types"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// viewRetryInterval is how long the view waits before it is seeded again
+	// after following etcd failed
+	viewRetryInterval = time.Second
+
+	// anyRevision has list reads served from the view when it is seeded, and
+	// otherwise from the latest state in etcd, without reporting their revision
+	anyRevision = -1
+)
+
+// View is an in-memory copy of the tasks and container instances stored in
+// etcd. It is seeded by reading them at a single revision and kept current
+// by watching the entity keyspace from that revision on. Records are kept
+// unmarshaled and indexed by cluster, so that reads served from the view
+// neither go to etcd nor unmarshal JSON.
+//
+// The view periodically checks that it matches etcd by reading the keys of
+// its records at the revision it reflects, and is seeded again if it does not.
+type View struct {
+	etcd           clients.EtcdInterface
+	requestTimeout time.Duration
+	checkInterval  time.Duration
+
+	lock      sync.RWMutex
+	synced    bool
+	revision  int64
+	tasks     *viewCollection
+	instances *viewCollection
+}
+
+// NewView initializes a view that reads from 'etcd', waiting up to
+// 'requestTimeout' for each read and checking its consistency with etcd
+// every 'checkInterval'. Records are only kept current while Run is running.
+func NewView(etcd clients.EtcdInterface, requestTimeout time.Duration, checkInterval time.Duration) (*View, error) {
+	if etcd == nil {
+		return nil, errors.New("Etcd client is not initialized")
+	}
+	if requestTimeout <= 0 {
+		return nil, errors.New("Etcd request timeout has to be positive")
+	}
+	if checkInterval <= 0 {
+		return nil, errors.New("View consistency check interval has to be positive")
+	}
+	return &View{
+		etcd:           etcd,
+		requestTimeout: requestTimeout,
+		checkInterval:  checkInterval,
+		tasks:          newViewCollection(taskKeyPrefix),
+		instances:      newViewCollection(instanceKeyPrefix),
+	}, nil
+}
+
+// Revision returns the etcd revision that the view reflects. It returns false
+// until the view is first seeded, while reads are served from etcd.
+func (view *View) Revision() (int64, bool) {
+	if view == nil {
+		return 0, false
+	}
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	return view.revision, view.synced
+}
+
+// Run seeds the view and keeps it current until 'ctx' is done. The view is
+// seeded again whenever following etcd fails or the view is found to be
+// inconsistent with etcd.
+func (view *View) Run(ctx context.Context) {
+	checks := time.NewTicker(view.checkInterval)
+	defer checks.Stop()
+	for {
+		err := view.seed(ctx)
+		if err == nil {
+			err = view.follow(ctx, checks.C)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("Seeding the view again: %+v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(viewRetryInterval):
+		}
+	}
+}
+
+// seed replaces the records of the view with those read from etcd at a
+// single revision
+func (view *View) seed(ctx context.Context) error {
+	tasks := newViewCollection(taskKeyPrefix)
+	revision, err := view.read(ctx, tasks, 0)
+	if err != nil {
+		return err
+	}
+	instances := newViewCollection(instanceKeyPrefix)
+	if _, err = view.read(ctx, instances, revision); err != nil {
+		return err
+	}
+
+	view.lock.Lock()
+	defer view.lock.Unlock()
+	view.tasks = tasks
+	view.instances = instances
+	view.revision = revision
+	view.synced = true
+	metrics.SetViewRevision(revision)
+	log.Infof("Seeded the view with %d tasks and %d container instances at revision %d",
+		len(tasks.records), len(instances.records), revision)
+	return nil
+}
+
+// read reads the records of 'collection' from etcd at 'revision', or at the
+// current revision if it is 0, returning the revision they were read at
+func (view *View) read(ctx context.Context, collection *viewCollection, revision int64) (int64, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, view.requestTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := view.etcd.Get(reqCtx, collection.keyPrefix, clientv3.WithPrefix(), clientv3.WithRev(revision))
+	metrics.ObserveEtcdRequest(getWithPrefixOperation, start, err)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not read the records with prefix '%s' to seed the view", collection.keyPrefix)
+	}
+
+	for _, kv := range resp.Kvs {
+		collection.put(kv)
+	}
+	return resp.Header.Revision, nil
+}
+
+// follow applies the changes to the entity keyspace after the revision the
+// view reflects, and checks the view whenever 'checks' ticks, until 'ctx' is
+// done or following fails
+func (view *View) follow(ctx context.Context, checks <-chan time.Time) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	revision, _ := view.Revision()
+	watchChan := view.etcd.Watch(watchCtx, entityKeyPrefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+	for {
+		select {
+		case resp, ok := <-watchChan:
+			if !ok {
+				return errors.New("The watch of the view was closed")
+			}
+			if err := resp.Err(); err != nil {
+				return errors.Wrapf(err, "Could not watch changes to the view")
+			}
+			view.apply(resp)
+
+		case <-checks:
+			inconsistencies, err := view.check(ctx)
+			if err != nil {
+				log.Warnf("Could not check the consistency of the view: %+v", err)
+				continue
+			}
+			metrics.ObserveViewCheck(inconsistencies)
+			if inconsistencies > 0 {
+				return errors.Errorf("The view has %d records that differ from etcd", inconsistencies)
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// apply applies the changes of a watch response to the view
+func (view *View) apply(resp clientv3.WatchResponse) {
+	view.lock.Lock()
+	defer view.lock.Unlock()
+	for _, event := range resp.Events {
+		for _, collection := range []*viewCollection{view.tasks, view.instances} {
+			if !strings.HasPrefix(string(event.Kv.Key), collection.keyPrefix) {
+				continue
+			}
+			if event.Type == mvccpb.DELETE {
+				collection.delete(string(event.Kv.Key))
+			} else {
+				collection.put(event.Kv)
+			}
+		}
+	}
+	if resp.Header.Revision > view.revision {
+		view.revision = resp.Header.Revision
+		metrics.SetViewRevision(view.revision)
+	}
+}
+
+// check compares the records of the view with the keys read from etcd at the
+// revision the view reflects, returning the number of records that differ
+func (view *View) check(ctx context.Context) (int, error) {
+	view.lock.RLock()
+	revision := view.revision
+	versions := make(map[string]string, len(view.tasks.records)+len(view.instances.records))
+	keyPrefixes := []string{view.tasks.keyPrefix, view.instances.keyPrefix}
+	for _, collection := range []*viewCollection{view.tasks, view.instances} {
+		for key, record := range collection.records {
+			versions[key] = record.version
+		}
+		for key, version := range collection.skipped {
+			versions[key] = version
+		}
+	}
+	view.lock.RUnlock()
+
+	inconsistencies := 0
+	for _, keyPrefix := range keyPrefixes {
+		reqCtx, cancel := context.WithTimeout(ctx, view.requestTimeout)
+		start := time.Now()
+		resp, err := view.etcd.Get(reqCtx, keyPrefix, clientv3.WithPrefix(), clientv3.WithRev(revision), clientv3.WithKeysOnly())
+		metrics.ObserveEtcdRequest(getWithPrefixOperation, start, err)
+		cancel()
+		if err != nil {
+			return 0, errors.Wrapf(err, "Could not read the keys with prefix '%s' at revision %d", keyPrefix, revision)
+		}
+
+		for _, kv := range resp.Kvs {
+			key := string(kv.Key)
+			version, ok := versions[key]
+			if !ok || version != strconv.FormatInt(kv.ModRevision, 10) {
+				log.Warnf("Record '%s' of the view differs from etcd at revision %d", key, revision)
+				inconsistencies++
+			}
+			delete(versions, key)
+		}
+	}
+	for key := range versions {
+		log.Warnf("Record '%s' of the view does not exist in etcd at revision %d", key, revision)
+		inconsistencies++
+	}
+	return inconsistencies, nil
+}
+
+// getTask returns the task stored at 'key', or nil if there is none. It
+// returns false if there is no view or it is not seeded yet.
+func (view *View) getTask(key string) (*storetypes.VersionedTask, bool) {
+	if view == nil {
+		return nil, false
+	}
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	if !view.synced {
+		return nil, false
+	}
+	record, ok := view.tasks.records[key]
+	if !ok {
+		return nil, true
+	}
+	task := storetypes.VersionedTask{
+		Task:    record.value.(types.Task),
+		Version: record.version,
+	}
+	return &task, true
+}
+
+// getTasksWithPrefix returns the tasks stored at keys starting with
+// 'keyPrefix'. It returns false if there is no view or it is not seeded yet.
+func (view *View) getTasksWithPrefix(keyPrefix string) ([]storetypes.VersionedTask, bool) {
+	if view == nil {
+		return nil, false
+	}
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	if !view.synced {
+		return nil, false
+	}
+	records := view.tasks.withPrefix(keyPrefix)
+	tasks := make([]storetypes.VersionedTask, len(records))
+	for i, record := range records {
+		tasks[i] = storetypes.VersionedTask{
+			Task:    record.value.(types.Task),
+			Version: record.version,
+		}
+	}
+	return tasks, true
+}
+
+// getInstance returns the container instance stored at 'key', or nil if there
+// is none. It returns false if there is no view or it is not seeded yet.
+func (view *View) getInstance(key string) (*storetypes.VersionedContainerInstance, bool) {
+	if view == nil {
+		return nil, false
+	}
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	if !view.synced {
+		return nil, false
+	}
+	record, ok := view.instances.records[key]
+	if !ok {
+		return nil, true
+	}
+	instance := storetypes.VersionedContainerInstance{
+		ContainerInstance: record.value.(types.ContainerInstance),
+		Version:           record.version,
+	}
+	return &instance, true
+}
+
+// getInstancesWithPrefix returns the container instances stored at keys
+// starting with 'keyPrefix'. It returns false if there is no view or it is not
+// seeded yet.
+func (view *View) getInstancesWithPrefix(keyPrefix string) ([]storetypes.VersionedContainerInstance, bool) {
+	if view == nil {
+		return nil, false
+	}
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	if !view.synced {
+		return nil, false
+	}
+	records := view.instances.withPrefix(keyPrefix)
+	instances := make([]storetypes.VersionedContainerInstance, len(records))
+	for i, record := range records {
+		instances[i] = storetypes.VersionedContainerInstance{
+			ContainerInstance: record.value.(types.ContainerInstance),
+			Version:           record.version,
+		}
+	}
+	return instances, true
+}
+
+// viewRecord is an unmarshaled record of the view and the etcd revision it
+// was last modified at
+type viewRecord struct {
+	value   interface{}
+	version string
+}
+
+// viewCollection holds the records of one kind stored under keyPrefix,
+// indexed by the key prefix of their cluster. The revisions of the records
+// that were left out because they cannot be decoded are kept in skipped, so
+// that checks do not count them as missing.
+type viewCollection struct {
+	keyPrefix string
+	records   map[string]viewRecord
+	clusters  map[string]map[string]struct{}
+	skipped   map[string]string
+}
+
+func newViewCollection(keyPrefix string) *viewCollection {
+	return &viewCollection{
+		keyPrefix: keyPrefix,
+		records:   make(map[string]viewRecord),
+		clusters:  make(map[string]map[string]struct{}),
+		skipped:   make(map[string]string),
+	}
+}
+
+// put adds or replaces the record in 'kv'. Records that cannot be decoded are
+// left out of the view, just like they are skipped by streams.
+func (collection *viewCollection) put(kv *mvccpb.KeyValue) {
+	key := string(kv.Key)
+	version := strconv.FormatInt(kv.ModRevision, 10)
+	value, err := collection.unmarshal(string(kv.Value))
+	if err != nil {
+		log.Errorf("Leaving record '%s' out of the view: %+v", key, err)
+		collection.delete(key)
+		collection.skipped[key] = version
+		return
+	}
+
+	delete(collection.skipped, key)
+	collection.records[key] = viewRecord{
+		value:   value,
+		version: version,
+	}
+	clusterPrefix := collection.clusterPrefix(key)
+	if collection.clusters[clusterPrefix] == nil {
+		collection.clusters[clusterPrefix] = make(map[string]struct{})
+	}
+	collection.clusters[clusterPrefix][key] = struct{}{}
+}
+
+func (collection *viewCollection) delete(key string) {
+	delete(collection.records, key)
+	delete(collection.skipped, key)
+	clusterPrefix := collection.clusterPrefix(key)
+	delete(collection.clusters[clusterPrefix], key)
+	if len(collection.clusters[clusterPrefix]) == 0 {
+		delete(collection.clusters, clusterPrefix)
+	}
+}
+
+// withPrefix returns the records stored at keys starting with 'keyPrefix' in
+// key order. Cluster key prefixes are looked up in the index.
+func (collection *viewCollection) withPrefix(keyPrefix string) []viewRecord {
+	var keys []string
+	if cluster, ok := collection.clusters[keyPrefix]; ok {
+		for key := range cluster {
+			keys = append(keys, key)
+		}
+	} else {
+		for key := range collection.records {
+			if strings.HasPrefix(key, keyPrefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	records := make([]viewRecord, len(keys))
+	for i, key := range keys {
+		records[i] = collection.records[key]
+	}
+	return records
+}
+
+// clusterPrefix returns the key prefix of the cluster of the record stored
+// at 'key', '<kind prefix><account>/<region>/<cluster name>/'
+func (collection *viewCollection) clusterPrefix(key string) string {
+	parts := strings.SplitN(strings.TrimPrefix(key, collection.keyPrefix), "/", 4)
+	if len(parts) < 4 {
+		return collection.keyPrefix
+	}
+	return collection.keyPrefix + strings.Join(parts[:3], "/") + "/"
+}
+
+func (collection *viewCollection) unmarshal(value string) (interface{}, error) {
+	recordJSON, err := decodeRecord(value)
+	if err != nil {
+		return nil, err
+	}
+	switch collection.keyPrefix {
+	case taskKeyPrefix:
+		var task types.Task
+		err = json.Unmarshal([]byte(recordJSON), &task)
+		return task, errors.Wrapf(err, "Error unmarshaling task")
+	default:
+		var instance types.ContainerInstance
+		err = json.Unmarshal([]byte(recordJSON), &instance)
+		return instance, errors.Wrapf(err, "Error unmarshaling instance")
+	}
+}
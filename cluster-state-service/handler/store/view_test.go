@@ -0,0 +1,345 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	etcd "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/goguardian/blox/cluster-state-service/handler/mocks"
+	"github.com/goguardian/blox/cluster-state-service/handler/types"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	viewTaskARN1     = "arn:aws:ecs:us-east-1:123456789123:task/271022c0-f894-4aa2-b063-25bae55088d5"
+	viewTaskARN2     = "arn:aws:ecs:us-east-1:123456789123:task/345022c0-f894-4aa2-b063-25bae55088d5"
+	viewTaskARN3     = "arn:aws:ecs:us-east-1:123456789123:task/345022c0-f894-4aa2-b063-25bae55088dd"
+	viewInstanceARN1 = "arn:aws:ecs:us-east-1:123456789123:container-instance/4b6d45ea-a4b4-4269-9d04-3af6ddfdc597"
+	viewTaskKey1     = taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + viewTaskARN1
+	viewTaskKey2     = taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + viewTaskARN2
+	viewTaskKey3     = taskKeyPrefix + accountID + "/" + region + "/" + clusterName2 + "/" + viewTaskARN3
+	viewInstanceKey1 = instanceKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/" + viewInstanceARN1
+	viewCluster1     = taskKeyPrefix + accountID + "/" + region + "/" + clusterName1 + "/"
+)
+
+func TestNewViewNilEtcd(t *testing.T) {
+	_, err := NewView(nil, time.Minute, time.Minute)
+	assert.Error(t, err, "Expected an error when etcd is nil")
+}
+
+func TestNewViewInvalidIntervals(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+
+	_, err := NewView(etcdInterface, 0, time.Minute)
+	assert.Error(t, err, "Expected an error when the request timeout is not set")
+	_, err = NewView(etcdInterface, time.Minute, 0)
+	assert.Error(t, err, "Expected an error when the check interval is not set")
+}
+
+func TestViewNotSeeded(t *testing.T) {
+	var nilView *View
+	_, ok := nilView.Revision()
+	assert.False(t, ok, "Expected no revision without a view")
+	_, ok = nilView.getTask(viewTaskKey1)
+	assert.False(t, ok, "Expected tasks not to be read from a missing view")
+
+	view, err := NewView(mocks.NewMockEtcdInterface(gomock.NewController(t)), time.Minute, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the view")
+	_, ok = view.Revision()
+	assert.False(t, ok, "Expected no revision before the view is seeded")
+	_, ok = view.getTasksWithPrefix(taskKeyPrefix)
+	assert.False(t, ok, "Expected tasks not to be read before the view is seeded")
+	_, ok = view.getInstance(viewInstanceKey1)
+	assert.False(t, ok, "Expected instances not to be read before the view is seeded")
+}
+
+func TestViewSeed(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	encodedTask, err := flateCodec{}.Encode(viewTaskJSON(t, viewTaskARN2, clusterARN1), 1)
+	assert.Nil(t, err, "Unexpected error encoding a task")
+	gomock.InOrder(
+		etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+			viewKV(viewTaskKey3, viewTaskJSON(t, viewTaskARN3, clusterARN2), 7),
+			viewKV(viewTaskKey2, encodedTask, 8),
+			viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+			viewKV(taskKeyPrefix+"undecodable", "{", 3),
+		), nil),
+		etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+			viewKV(viewInstanceKey1, viewInstanceJSON(t, viewInstanceARN1, clusterARN1), 5),
+		), nil),
+	)
+	view, err := NewView(etcdInterface, time.Minute, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the view")
+
+	err = view.seed(context.Background())
+	assert.Nil(t, err, "Unexpected error seeding the view")
+
+	revision, ok := view.Revision()
+	assert.True(t, ok, "Expected the view to be seeded")
+	assert.Equal(t, int64(10), revision, "Expected the revision that the view was seeded at")
+
+	task, ok := view.getTask(viewTaskKey2)
+	assert.True(t, ok, "Expected the task to be read from the view")
+	assert.Equal(t, viewTaskARN2, *task.Task.Detail.TaskARN, "Expected the encoded task to be decoded")
+	assert.Equal(t, "8", task.Version, "Expected the task version to be its modified revision")
+	task, ok = view.getTask(taskKeyPrefix + "undecodable")
+	assert.True(t, ok, "Expected the task to be read from the view")
+	assert.Nil(t, task, "Expected the undecodable task to be left out of the view")
+
+	tasks, _ := view.getTasksWithPrefix(viewCluster1)
+	assert.Equal(t, 2, len(tasks), "Expected the tasks of the cluster")
+	assert.Equal(t, viewTaskARN1, *tasks[0].Task.Detail.TaskARN, "Expected tasks in key order")
+	assert.Equal(t, viewTaskARN2, *tasks[1].Task.Detail.TaskARN, "Expected tasks in key order")
+	tasks, _ = view.getTasksWithPrefix(taskKeyPrefix)
+	assert.Equal(t, 3, len(tasks), "Expected all tasks")
+
+	instance, ok := view.getInstance(viewInstanceKey1)
+	assert.True(t, ok, "Expected the instance to be read from the view")
+	assert.Equal(t, viewInstanceARN1, *instance.ContainerInstance.Detail.ContainerInstanceARN, "Expected the instance")
+	assert.Equal(t, "5", instance.Version, "Expected the instance version to be its modified revision")
+}
+
+func TestViewSeedGetFails(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any()).Return(nil, errors.New("Get failed"))
+	view, err := NewView(etcdInterface, time.Minute, time.Minute)
+	assert.Nil(t, err, "Unexpected error creating the view")
+
+	err = view.seed(context.Background())
+	assert.Error(t, err, "Expected an error when etcd get fails")
+	_, ok := view.Revision()
+	assert.False(t, ok, "Expected the view not to be seeded")
+}
+
+func TestViewApply(t *testing.T) {
+	view := newSeededView(t, 10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+		viewKV(viewTaskKey2, viewTaskJSON(t, viewTaskARN2, clusterARN1), 10),
+	)
+
+	view.apply(etcd.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 13},
+		Events: []*etcd.Event{
+			{Type: mvccpb.PUT, Kv: viewKV(viewTaskKey3, viewTaskJSON(t, viewTaskARN3, clusterARN2), 11)},
+			{Type: mvccpb.DELETE, Kv: viewKV(viewTaskKey1, "", 12)},
+			{Type: mvccpb.PUT, Kv: viewKV(tombstoneKeyPrefix+"task", "{}", 13)},
+		},
+	})
+
+	revision, _ := view.Revision()
+	assert.Equal(t, int64(13), revision, "Expected the revision of the watch response")
+	task, _ := view.getTask(viewTaskKey1)
+	assert.Nil(t, task, "Expected the deleted task to be removed from the view")
+	tasks, _ := view.getTasksWithPrefix(viewCluster1)
+	assert.Equal(t, 1, len(tasks), "Expected the deleted task to be removed from the cluster")
+	task, _ = view.getTask(viewTaskKey3)
+	assert.NotNil(t, task, "Expected the added task to be in the view")
+	tasks, _ = view.getTasksWithPrefix(taskKeyPrefix)
+	assert.Equal(t, 2, len(tasks), "Expected only tasks in the view")
+}
+
+func TestViewCheck(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	view := newSeededView(t, 10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+		viewKV(viewInstanceKey1, viewInstanceJSON(t, viewInstanceARN1, clusterARN1), 5),
+	)
+	view.etcd = etcdInterface
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+		viewKV(viewTaskKey1, "", 9),
+	), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+		viewKV(viewInstanceKey1, "", 5),
+	), nil)
+
+	inconsistencies, err := view.check(context.Background())
+	assert.Nil(t, err, "Unexpected error checking the view")
+	assert.Equal(t, 0, inconsistencies, "Expected the view to match etcd")
+}
+
+func TestViewCheckIgnoresUndecodableRecords(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	view := newSeededView(t, 10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+		viewKV(viewTaskKey2, "{", 10),
+	)
+	view.etcd = etcdInterface
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+		viewKV(viewTaskKey1, "", 9),
+		viewKV(viewTaskKey2, "", 10),
+	), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10), nil)
+
+	task, _ := view.getTask(viewTaskKey2)
+	assert.Nil(t, task, "Expected the undecodable task to be left out of the view")
+	inconsistencies, err := view.check(context.Background())
+	assert.Nil(t, err, "Unexpected error checking the view")
+	assert.Equal(t, 0, inconsistencies, "Expected the undecodable task not to count as missing from the view")
+
+	view.apply(etcd.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 11},
+		Events: []*etcd.Event{{Type: mvccpb.DELETE, Kv: viewKV(viewTaskKey2, "", 11)}},
+	})
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(11,
+		viewKV(viewTaskKey1, "", 9),
+	), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(11), nil)
+
+	inconsistencies, err = view.check(context.Background())
+	assert.Nil(t, err, "Unexpected error checking the view")
+	assert.Equal(t, 0, inconsistencies, "Expected the deleted undecodable task to be forgotten")
+}
+
+func TestViewCheckFindsInconsistencies(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	view := newSeededView(t, 10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+		viewKV(viewTaskKey2, viewTaskJSON(t, viewTaskARN2, clusterARN1), 10),
+	)
+	view.etcd = etcdInterface
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+		viewKV(viewTaskKey1, "", 8),
+		viewKV(viewTaskKey3, "", 7),
+	), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10), nil)
+
+	inconsistencies, err := view.check(context.Background())
+	assert.Nil(t, err, "Unexpected error checking the view")
+	assert.Equal(t, 3, inconsistencies, "Expected a changed, a missing and an extra task")
+}
+
+func TestViewFollowWatchClosed(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	view := newSeededView(t, 10)
+	view.etcd = etcdInterface
+	watchChan := make(chan etcd.WatchResponse)
+	close(watchChan)
+	etcdInterface.EXPECT().Watch(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+
+	err := view.follow(context.Background(), nil)
+	assert.Error(t, err, "Expected an error when the watch is closed")
+}
+
+func TestViewFollowInconsistent(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	view := newSeededView(t, 10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+	)
+	view.etcd = etcdInterface
+	etcdInterface.EXPECT().Watch(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(etcd.WatchChan(make(chan etcd.WatchResponse)))
+	etcdInterface.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(viewGetResponse(10), nil).Times(2)
+	checks := make(chan time.Time, 1)
+	checks <- time.Now()
+
+	err := view.follow(context.Background(), checks)
+	assert.Error(t, err, "Expected an error when the view differs from etcd")
+}
+
+func TestViewRun(t *testing.T) {
+	etcdInterface := mocks.NewMockEtcdInterface(gomock.NewController(t))
+	etcdInterface.EXPECT().Get(gomock.Any(), taskKeyPrefix, gomock.Any(), gomock.Any()).Return(viewGetResponse(10,
+		viewKV(viewTaskKey1, viewTaskJSON(t, viewTaskARN1, clusterARN1), 9),
+	), nil)
+	etcdInterface.EXPECT().Get(gomock.Any(), instanceKeyPrefix, gomock.Any(), gomock.Any()).Return(viewGetResponse(10), nil)
+	watchChan := make(chan etcd.WatchResponse)
+	etcdInterface.EXPECT().Watch(gomock.Any(), entityKeyPrefix, gomock.Any(), gomock.Any()).Return(etcd.WatchChan(watchChan))
+	view, err := NewView(etcdInterface, time.Minute, time.Hour)
+	assert.Nil(t, err, "Unexpected error creating the view")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		view.Run(ctx)
+		close(done)
+	}()
+	watchChan <- etcd.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 11},
+		Events: []*etcd.Event{{Type: mvccpb.DELETE, Kv: viewKV(viewTaskKey1, "", 11)}},
+	}
+	cancel()
+	<-done
+
+	revision, ok := view.Revision()
+	assert.True(t, ok, "Expected the view to be seeded")
+	assert.Equal(t, int64(11), revision, "Expected the revision of the watched change")
+	task, _ := view.getTask(viewTaskKey1)
+	assert.Nil(t, task, "Expected the watched deletion to be applied")
+}
+
+// newSeededView returns a view seeded with 'kvs' at 'revision'
+func newSeededView(t *testing.T, revision int64, kvs ...*mvccpb.KeyValue) *View {
+	view := &View{
+		requestTimeout: time.Minute,
+		checkInterval:  time.Minute,
+		synced:         true,
+		revision:       revision,
+		tasks:          newViewCollection(taskKeyPrefix),
+		instances:      newViewCollection(instanceKeyPrefix),
+	}
+	view.apply(etcd.WatchResponse{Header: etcdserverpb.ResponseHeader{Revision: revision}, Events: putEvents(kvs)})
+	return view
+}
+
+func putEvents(kvs []*mvccpb.KeyValue) []*etcd.Event {
+	events := make([]*etcd.Event, len(kvs))
+	for i, kv := range kvs {
+		events[i] = &etcd.Event{Type: mvccpb.PUT, Kv: kv}
+	}
+	return events
+}
+
+func viewKV(key string, value string, modRevision int64) *mvccpb.KeyValue {
+	return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: modRevision}
+}
+
+func viewGetResponse(revision int64, kvs ...*mvccpb.KeyValue) *etcd.GetResponse {
+	return &etcd.GetResponse{
+		Header: &etcdserverpb.ResponseHeader{Revision: revision},
+		Kvs:    kvs,
+	}
+}
+
+func viewTaskJSON(t *testing.T, taskARN string, clusterARN string) string {
+	version := int64(1)
+	taskJSON, err := json.Marshal(types.Task{
+		Detail: &types.TaskDetail{
+			TaskARN:    &taskARN,
+			ClusterARN: &clusterARN,
+			Version:    &version,
+		},
+	})
+	assert.Nil(t, err, "Unexpected error marshaling task")
+	return string(taskJSON)
+}
+
+func viewInstanceJSON(t *testing.T, instanceARN string, clusterARN string) string {
+	version := int64(1)
+	instanceJSON, err := json.Marshal(types.ContainerInstance{
+		Detail: &types.InstanceDetail{
+			ContainerInstanceARN: &instanceARN,
+			ClusterARN:           &clusterARN,
+			Version:              &version,
+		},
+	})
+	assert.Nil(t, err, "Unexpected error marshaling instance")
+	return string(instanceJSON)
+}
@@ -0,0 +1,39 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Cluster defines the structure of an ECS cluster. Clusters are listed by the
+// reconciler and recorded the first time an event of one of their tasks or
+// container instances is received. The state of a cluster is summarized from
+// its tasks and container instances when it is read.
+type Cluster struct {
+	Detail *ClusterDetail `json:"detail"`
+}
+
+type ClusterDetail struct {
+	ClusterARN  *string `json:"clusterArn"`
+	ClusterName *string `json:"clusterName"`
+}
+
+func (clusterDetail *ClusterDetail) String() string {
+	return fmt.Sprintf("Cluster %s; Name: %s",
+		aws.StringValue(clusterDetail.ClusterARN),
+		aws.StringValue(clusterDetail.ClusterName))
+}
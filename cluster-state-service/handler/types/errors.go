@@ -21,14 +21,49 @@ type UnsupportedFilterCombination struct {
 	error
 }
 
+// CompactedEntityVersion is returned when the history after an entity version
+// has been compacted, so that the changes after it can no longer be read
+type CompactedEntityVersion struct {
+	error
+}
+
+// NotFound is returned when ECS does not know the requested resource
+type NotFound struct {
+	error
+}
+
+// AmbiguousCluster is returned when a cluster name identifies clusters in
+// more than one account or region
+type AmbiguousCluster struct {
+	error
+}
+
 func NewOutOfRangeEntityVersion(err error) OutOfRangeEntityVersion {
 	return OutOfRangeEntityVersion{
 		err,
 	}
 }
 
+func NewCompactedEntityVersion(err error) CompactedEntityVersion {
+	return CompactedEntityVersion{
+		err,
+	}
+}
+
 func NewUnsupportedFilterCombination(err error) UnsupportedFilterCombination {
 	return UnsupportedFilterCombination{
 		err,
 	}
 }
+
+func NewNotFound(err error) NotFound {
+	return NotFound{
+		err,
+	}
+}
+
+func NewAmbiguousCluster(err error) AmbiguousCluster {
+	return AmbiguousCluster{
+		err,
+	}
+}
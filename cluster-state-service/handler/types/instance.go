@@ -59,6 +59,27 @@ func (instanceDetail *InstanceDetail) String() string {
 		aws.StringValue(instanceDetail.UpdatedAt))
 }
 
+// InstanceState is the part of a container instance that is recorded in the
+// instance's history
+type InstanceState struct {
+	AgentConnected      *bool       `json:"agentConnected"`
+	RegisteredResources []*Resource `json:"registeredResources"`
+	RemainingResources  []*Resource `json:"remainingResources"`
+	Status              *string     `json:"status"`
+	UpdatedAt           *string     `json:"updatedAt"`
+}
+
+// State returns the state of the instance that is recorded in its history
+func (instanceDetail *InstanceDetail) State() InstanceState {
+	return InstanceState{
+		AgentConnected:      instanceDetail.AgentConnected,
+		RegisteredResources: instanceDetail.RegisteredResources,
+		RemainingResources:  instanceDetail.RemainingResources,
+		Status:              instanceDetail.Status,
+		UpdatedAt:           instanceDetail.UpdatedAt,
+	}
+}
+
 type Attribute struct {
 	Name  *string `json:"name`
 	Value *string `json: "value"`
@@ -0,0 +1,262 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	// ServiceGroupPrefix prefixes the name of a service in the group of the
+	// tasks started by the service, for example 'service:web'
+	ServiceGroupPrefix = "service:"
+
+	// MaxServiceEvents is the number of most recent events kept for a service
+	MaxServiceEvents = 100
+
+	deploymentEventNamePrefix = "SERVICE_DEPLOYMENT_"
+)
+
+// Service defines the structure of an ECS service. Unlike tasks and container
+// instances, services are not sent in full on the event stream. They are
+// described by the reconciler and updated in place by service action and
+// deployment state change events.
+type Service struct {
+	Detail *ServiceDetail `json:"detail"`
+}
+
+type ServiceDetail struct {
+	ClusterARN     *string         `json:"clusterArn"`
+	CreatedAt      string          `json:"createdAt,omitempty"`
+	Deployments    []*Deployment   `json:"deployments,omitempty"`
+	DesiredCount   *int64          `json:"desiredCount,omitempty"`
+	Events         []*ServiceEvent `json:"events,omitempty"`
+	PendingCount   *int64          `json:"pendingCount,omitempty"`
+	RunningCount   *int64          `json:"runningCount,omitempty"`
+	ServiceARN     *string         `json:"serviceArn"`
+	ServiceName    *string         `json:"serviceName"`
+	Status         string          `json:"status,omitempty"`
+	TaskDefinition string          `json:"taskDefinition,omitempty"`
+	UpdatedAt      *string         `json:"updatedAt"`
+}
+
+type Deployment struct {
+	CreatedAt          string  `json:"createdAt,omitempty"`
+	DesiredCount       *int64  `json:"desiredCount,omitempty"`
+	ID                 *string `json:"id"`
+	PendingCount       *int64  `json:"pendingCount,omitempty"`
+	RolloutState       string  `json:"rolloutState,omitempty"`
+	RolloutStateReason string  `json:"rolloutStateReason,omitempty"`
+	RunningCount       *int64  `json:"runningCount,omitempty"`
+	Status             string  `json:"status,omitempty"`
+	TaskDefinition     string  `json:"taskDefinition,omitempty"`
+	UpdatedAt          string  `json:"updatedAt,omitempty"`
+}
+
+// ServiceEvent is either a service event described by ECS, which has an ID
+// and a message, or a service action or deployment state change event
+// received from the event stream, which has an event type and name
+type ServiceEvent struct {
+	CreatedAt *string `json:"createdAt"`
+	EventName string  `json:"eventName,omitempty"`
+	EventType string  `json:"eventType,omitempty"`
+	ID        *string `json:"id"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// ServiceActionEvent defines the structure of the service action json received from the event stream
+type ServiceActionEvent struct {
+	ID        *string              `json:"id"`
+	Account   *string              `json:"account"`
+	Time      *string              `json:"time"`
+	Region    *string              `json:"region"`
+	Resources []string             `json:"resources"`
+	Detail    *ServiceActionDetail `json:"detail"`
+}
+
+type ServiceActionDetail struct {
+	ClusterARN *string `json:"clusterArn"`
+	CreatedAt  *string `json:"createdAt"`
+	EventName  *string `json:"eventName"`
+	EventType  *string `json:"eventType"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// DeploymentStateChangeEvent defines the structure of the deployment state change json received from the event stream
+type DeploymentStateChangeEvent struct {
+	ID        *string                      `json:"id"`
+	Account   *string                      `json:"account"`
+	Time      *string                      `json:"time"`
+	Region    *string                      `json:"region"`
+	Resources []string                     `json:"resources"`
+	Detail    *DeploymentStateChangeDetail `json:"detail"`
+}
+
+type DeploymentStateChangeDetail struct {
+	DeploymentID *string `json:"deploymentId"`
+	EventName    *string `json:"eventName"`
+	EventType    *string `json:"eventType"`
+	Reason       string  `json:"reason,omitempty"`
+	UpdatedAt    *string `json:"updatedAt"`
+}
+
+func (serviceDetail *ServiceDetail) String() string {
+	return fmt.Sprintf("Service %s; Cluster: %s; Status: %s; Desired: %d; Running: %d; Pending: %d; Updated at: %s",
+		aws.StringValue(serviceDetail.ServiceARN),
+		aws.StringValue(serviceDetail.ClusterARN),
+		serviceDetail.Status,
+		aws.Int64Value(serviceDetail.DesiredCount),
+		aws.Int64Value(serviceDetail.RunningCount),
+		aws.Int64Value(serviceDetail.PendingCount),
+		aws.StringValue(serviceDetail.UpdatedAt))
+}
+
+// ServiceARN returns the ARN of the service the event is about
+func (event ServiceActionEvent) ServiceARN() string {
+	return firstResource(event.Resources)
+}
+
+// ServiceARN returns the ARN of the service whose deployment changed state
+func (event DeploymentStateChangeEvent) ServiceARN() string {
+	return firstResource(event.Resources)
+}
+
+// ApplyAction records a service action event in the service
+func (serviceDetail *ServiceDetail) ApplyAction(event ServiceActionEvent) {
+	serviceDetail.addEvent(&ServiceEvent{
+		CreatedAt: event.Detail.CreatedAt,
+		EventName: aws.StringValue(event.Detail.EventName),
+		EventType: aws.StringValue(event.Detail.EventType),
+		ID:        event.ID,
+		Message:   event.Detail.Reason,
+	})
+	serviceDetail.touch(aws.StringValue(event.Detail.CreatedAt))
+}
+
+// ApplyDeploymentStateChange records a deployment state change event in the
+// service and updates the rollout state of the deployment. Events older than
+// the last update of the deployment do not change its rollout state.
+func (serviceDetail *ServiceDetail) ApplyDeploymentStateChange(event DeploymentStateChangeEvent) {
+	updatedAt := aws.StringValue(event.Detail.UpdatedAt)
+	serviceDetail.addEvent(&ServiceEvent{
+		CreatedAt: event.Detail.UpdatedAt,
+		EventName: aws.StringValue(event.Detail.EventName),
+		EventType: aws.StringValue(event.Detail.EventType),
+		ID:        event.ID,
+		Message:   event.Detail.Reason,
+	})
+	serviceDetail.touch(updatedAt)
+
+	deployment := serviceDetail.deployment(aws.StringValue(event.Detail.DeploymentID))
+	if deployment == nil {
+		deployment = &Deployment{
+			ID: event.Detail.DeploymentID,
+		}
+		serviceDetail.Deployments = append(serviceDetail.Deployments, deployment)
+	} else if isBefore(updatedAt, deployment.UpdatedAt) {
+		return
+	}
+	deployment.RolloutState = strings.TrimPrefix(aws.StringValue(event.Detail.EventName), deploymentEventNamePrefix)
+	deployment.RolloutStateReason = event.Detail.Reason
+	deployment.UpdatedAt = updatedAt
+}
+
+// MergeDescribed replaces the service with the service described by ECS,
+// keeping what only the event stream knows: the events received from the
+// stream and the rollout state of deployments
+func (serviceDetail *ServiceDetail) MergeDescribed(described ServiceDetail) {
+	streamEvents := make([]*ServiceEvent, 0, len(serviceDetail.Events))
+	for _, event := range serviceDetail.Events {
+		if event.EventName != "" {
+			streamEvents = append(streamEvents, event)
+		}
+	}
+	rolloutStates := make(map[string]*Deployment, len(serviceDetail.Deployments))
+	for _, deployment := range serviceDetail.Deployments {
+		rolloutStates[aws.StringValue(deployment.ID)] = deployment
+	}
+
+	*serviceDetail = described
+	for _, deployment := range serviceDetail.Deployments {
+		existing, ok := rolloutStates[aws.StringValue(deployment.ID)]
+		if ok && deployment.RolloutState == "" {
+			deployment.RolloutState = existing.RolloutState
+			deployment.RolloutStateReason = existing.RolloutStateReason
+		}
+	}
+	for _, event := range streamEvents {
+		serviceDetail.addEvent(event)
+	}
+}
+
+// addEvent adds event to the events of the service, which are kept ordered
+// from newest to oldest and trimmed to MaxServiceEvents. Events that are
+// already recorded are ignored.
+func (serviceDetail *ServiceDetail) addEvent(event *ServiceEvent) {
+	for _, existing := range serviceDetail.Events {
+		if aws.StringValue(existing.ID) == aws.StringValue(event.ID) {
+			return
+		}
+	}
+	serviceDetail.Events = append(serviceDetail.Events, event)
+	sort.SliceStable(serviceDetail.Events, func(i, j int) bool {
+		return isBefore(aws.StringValue(serviceDetail.Events[j].CreatedAt),
+			aws.StringValue(serviceDetail.Events[i].CreatedAt))
+	})
+	if len(serviceDetail.Events) > MaxServiceEvents {
+		serviceDetail.Events = serviceDetail.Events[:MaxServiceEvents]
+	}
+}
+
+func (serviceDetail *ServiceDetail) deployment(id string) *Deployment {
+	for _, deployment := range serviceDetail.Deployments {
+		if aws.StringValue(deployment.ID) == id {
+			return deployment
+		}
+	}
+	return nil
+}
+
+// touch moves the updated at time of the service forward to updatedAt
+func (serviceDetail *ServiceDetail) touch(updatedAt string) {
+	if updatedAt == "" || isBefore(updatedAt, aws.StringValue(serviceDetail.UpdatedAt)) {
+		return
+	}
+	serviceDetail.UpdatedAt = aws.String(updatedAt)
+}
+
+func firstResource(resources []string) string {
+	if len(resources) == 0 {
+		return ""
+	}
+	return resources[0]
+}
+
+// isBefore returns true if both timestamps parse and a is before b
+func isBefore(a string, b string) bool {
+	timeA, err := time.Parse(time.RFC3339Nano, a)
+	if err != nil {
+		return false
+	}
+	timeB, err := time.Parse(time.RFC3339Nano, b)
+	if err != nil {
+		return false
+	}
+	return timeA.Before(timeB)
+}
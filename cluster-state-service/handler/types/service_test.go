@@ -0,0 +1,143 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package types
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	serviceARN    = "arn:aws:ecs:us-east-1:123456789012:service/web"
+	deploymentID  = "ecs-svc/9223370564341623665"
+	serviceTime1  = "2017-11-21T00:00:00Z"
+	serviceTime2  = "2017-11-21T00:05:00Z"
+	serviceTime3  = "2017-11-21T00:10:00Z"
+	steadyState   = "SERVICE_STEADY_STATE"
+	deployStarted = "SERVICE_DEPLOYMENT_IN_PROGRESS"
+	deployDone    = "SERVICE_DEPLOYMENT_COMPLETED"
+)
+
+func TestServiceARNOfEvents(t *testing.T) {
+	action := ServiceActionEvent{Resources: []string{serviceARN}}
+	assert.Equal(t, serviceARN, action.ServiceARN(), "Unexpected service ARN of service action event")
+
+	deployment := DeploymentStateChangeEvent{}
+	assert.Equal(t, "", deployment.ServiceARN(), "Expected no service ARN when the event has no resources")
+}
+
+func TestApplyActionRecordsEvent(t *testing.T) {
+	detail := ServiceDetail{UpdatedAt: aws.String(serviceTime2)}
+
+	detail.ApplyAction(serviceActionEvent("1", serviceTime1))
+	assert.Len(t, detail.Events, 1, "Expected service action event to be recorded")
+	assert.Equal(t, steadyState, detail.Events[0].EventName, "Unexpected event name")
+	assert.Equal(t, serviceTime2, aws.StringValue(detail.UpdatedAt), "Expected updated at not to move back")
+
+	detail.ApplyAction(serviceActionEvent("2", serviceTime3))
+	assert.Len(t, detail.Events, 2, "Expected service action event to be recorded")
+	assert.Equal(t, "2", aws.StringValue(detail.Events[0].ID), "Expected newest event first")
+	assert.Equal(t, serviceTime3, aws.StringValue(detail.UpdatedAt), "Expected updated at to move forward")
+}
+
+func TestApplyActionIgnoresDuplicateEvent(t *testing.T) {
+	detail := ServiceDetail{}
+
+	detail.ApplyAction(serviceActionEvent("1", serviceTime1))
+	detail.ApplyAction(serviceActionEvent("1", serviceTime1))
+	assert.Len(t, detail.Events, 1, "Expected duplicate event to be ignored")
+}
+
+func TestApplyActionKeepsMostRecentEvents(t *testing.T) {
+	detail := ServiceDetail{}
+
+	for i := 0; i < MaxServiceEvents+1; i++ {
+		detail.ApplyAction(serviceActionEvent(strconv.Itoa(i), serviceTime1))
+	}
+	assert.Len(t, detail.Events, MaxServiceEvents, "Expected events to be trimmed")
+}
+
+func TestApplyDeploymentStateChangeCreatesDeployment(t *testing.T) {
+	detail := ServiceDetail{}
+
+	detail.ApplyDeploymentStateChange(deploymentStateChangeEvent("1", deployStarted, serviceTime1))
+	assert.Len(t, detail.Deployments, 1, "Expected deployment to be created")
+	assert.Equal(t, deploymentID, aws.StringValue(detail.Deployments[0].ID), "Unexpected deployment ID")
+	assert.Equal(t, "IN_PROGRESS", detail.Deployments[0].RolloutState, "Unexpected rollout state")
+	assert.Len(t, detail.Events, 1, "Expected deployment state change event to be recorded")
+}
+
+func TestApplyDeploymentStateChangeIgnoresOlderEvent(t *testing.T) {
+	detail := ServiceDetail{}
+
+	detail.ApplyDeploymentStateChange(deploymentStateChangeEvent("2", deployDone, serviceTime2))
+	detail.ApplyDeploymentStateChange(deploymentStateChangeEvent("1", deployStarted, serviceTime1))
+	assert.Len(t, detail.Deployments, 1, "Expected a single deployment")
+	assert.Equal(t, "COMPLETED", detail.Deployments[0].RolloutState, "Expected older event not to change the rollout state")
+	assert.Equal(t, serviceTime2, detail.Deployments[0].UpdatedAt, "Expected older event not to change the update time")
+	assert.Len(t, detail.Events, 2, "Expected older event to be recorded")
+}
+
+func TestMergeDescribedKeepsStreamState(t *testing.T) {
+	detail := ServiceDetail{}
+	detail.ApplyDeploymentStateChange(deploymentStateChangeEvent("1", deployStarted, serviceTime1))
+
+	described := ServiceDetail{
+		ServiceARN:   aws.String(serviceARN),
+		DesiredCount: aws.Int64(2),
+		Deployments: []*Deployment{
+			{ID: aws.String(deploymentID), Status: "PRIMARY"},
+			{ID: aws.String("ecs-svc/other"), Status: "ACTIVE"},
+		},
+		Events: []*ServiceEvent{
+			{ID: aws.String("described"), CreatedAt: aws.String(serviceTime2), Message: "has reached a steady state."},
+		},
+	}
+	detail.MergeDescribed(described)
+
+	assert.Equal(t, int64(2), aws.Int64Value(detail.DesiredCount), "Expected described desired count")
+	assert.Equal(t, "PRIMARY", detail.Deployments[0].Status, "Expected described deployment status")
+	assert.Equal(t, "IN_PROGRESS", detail.Deployments[0].RolloutState, "Expected rollout state to be kept")
+	assert.Equal(t, "", detail.Deployments[1].RolloutState, "Expected no rollout state for a deployment without events")
+	assert.Len(t, detail.Events, 2, "Expected described and stream events")
+	assert.Equal(t, "described", aws.StringValue(detail.Events[0].ID), "Expected newest event first")
+}
+
+func serviceActionEvent(id string, createdAt string) ServiceActionEvent {
+	return ServiceActionEvent{
+		ID:        aws.String(id),
+		Resources: []string{serviceARN},
+		Detail: &ServiceActionDetail{
+			CreatedAt: aws.String(createdAt),
+			EventName: aws.String(steadyState),
+			EventType: aws.String("INFO"),
+		},
+	}
+}
+
+func deploymentStateChangeEvent(id string, eventName string, updatedAt string) DeploymentStateChangeEvent {
+	return DeploymentStateChangeEvent{
+		ID:        aws.String(id),
+		Resources: []string{serviceARN},
+		Detail: &DeploymentStateChangeDetail{
+			DeploymentID: aws.String(deploymentID),
+			EventName:    aws.String(eventName),
+			EventType:    aws.String("INFO"),
+			UpdatedAt:    aws.String(updatedAt),
+		},
+	}
+}
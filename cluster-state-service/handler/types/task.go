@@ -34,36 +34,75 @@ type Task struct {
 }
 
 type TaskDetail struct {
-	ClusterARN           *string      `json:"clusterArn"`
-	ContainerInstanceARN *string      `json:"containerInstanceArn"`
-	Containers           []*Container `json:"containers"`
-	CreatedAt            *string      `json:"createdAt"`
-	DesiredStatus        *string      `json:"desiredStatus"`
-	LastStatus           *string      `json:"lastStatus"`
-	Overrides            *Overrides   `json:"overrides"`
-	StartedAt            string       `json:"startedAt,omitempty"`
-	StartedBy            string       `json:"startedBy,omitempty"`
-	StoppedAt            string       `json:"stoppedAt,omitempty"`
-	StoppedReason        string       `json:"stoppedReason,omitempty"`
-	TaskARN              *string      `json:"taskArn"`
-	TaskDefinitionARN    *string      `json:"taskDefinitionArn"`
-	UpdatedAt            *string      `json:"updatedAt"`
-	Version              *int64       `json:"version"`
+	Attachments          []*Attachment `json:"attachments,omitempty"`
+	AvailabilityZone     string        `json:"availabilityZone,omitempty"`
+	ClusterARN           *string       `json:"clusterArn"`
+	Connectivity         string        `json:"connectivity,omitempty"`
+	ConnectivityAt       string        `json:"connectivityAt,omitempty"`
+	ContainerInstanceARN *string       `json:"containerInstanceArn"`
+	Containers           []*Container  `json:"containers"`
+	CPU                  string        `json:"cpu,omitempty"`
+	CreatedAt            *string       `json:"createdAt"`
+	DesiredStatus        *string       `json:"desiredStatus"`
+	Group                string        `json:"group,omitempty"`
+	HealthStatus         string        `json:"healthStatus,omitempty"`
+	LastStatus           *string       `json:"lastStatus"`
+	LaunchType           string        `json:"launchType,omitempty"`
+	Memory               string        `json:"memory,omitempty"`
+	Overrides            *Overrides    `json:"overrides"`
+	PlatformVersion      string        `json:"platformVersion,omitempty"`
+	PullStartedAt        string        `json:"pullStartedAt,omitempty"`
+	PullStoppedAt        string        `json:"pullStoppedAt,omitempty"`
+	StartedAt            string        `json:"startedAt,omitempty"`
+	StartedBy            string        `json:"startedBy,omitempty"`
+	StopCode             string        `json:"stopCode,omitempty"`
+	StoppedAt            string        `json:"stoppedAt,omitempty"`
+	StoppedReason        string        `json:"stoppedReason,omitempty"`
+	Tags                 []*Tag        `json:"tags,omitempty"`
+	TaskARN              *string       `json:"taskArn"`
+	TaskDefinitionARN    *string       `json:"taskDefinitionArn"`
+	UpdatedAt            *string       `json:"updatedAt"`
+	Version              *int64        `json:"version"`
 }
 
 func (taskDetail *TaskDetail) String() string {
-	return fmt.Sprintf("Task %s; Version: %d; Task Definition: %s; %s -> %s; Cluster: %s; Container Instance: %s; Started By: %s; Updated At: %s",
+	return fmt.Sprintf("Task %s; Version: %d; Task Definition: %s; %s -> %s; Cluster: %s; Launch Type: %s; Container Instance: %s; Started By: %s; Updated At: %s",
 		aws.StringValue(taskDetail.TaskARN),
 		aws.Int64Value(taskDetail.Version),
 		aws.StringValue(taskDetail.TaskDefinitionARN),
 		aws.StringValue(taskDetail.LastStatus),
 		aws.StringValue(taskDetail.DesiredStatus),
 		aws.StringValue(taskDetail.ClusterARN),
+		taskDetail.LaunchType,
 		aws.StringValue(taskDetail.ContainerInstanceARN),
 		taskDetail.StartedBy,
 		aws.StringValue(taskDetail.UpdatedAt))
 }
 
+// TaskState is the part of a task that is recorded in the task's history
+type TaskState struct {
+	DesiredStatus *string      `json:"desiredStatus"`
+	LastStatus    *string      `json:"lastStatus"`
+	Containers    []*Container `json:"containers"`
+	HealthStatus  string       `json:"healthStatus,omitempty"`
+	StopCode      string       `json:"stopCode,omitempty"`
+	StoppedReason string       `json:"stoppedReason,omitempty"`
+	UpdatedAt     *string      `json:"updatedAt"`
+}
+
+// State returns the state of the task that is recorded in its history
+func (taskDetail *TaskDetail) State() TaskState {
+	return TaskState{
+		DesiredStatus: taskDetail.DesiredStatus,
+		LastStatus:    taskDetail.LastStatus,
+		Containers:    taskDetail.Containers,
+		HealthStatus:  taskDetail.HealthStatus,
+		StopCode:      taskDetail.StopCode,
+		StoppedReason: taskDetail.StoppedReason,
+		UpdatedAt:     taskDetail.UpdatedAt,
+	}
+}
+
 type Container struct {
 	ContainerARN    *string           `json:"containerArn"`
 	ExitCode        int64             `json:"exitCode,omitempty"`
@@ -80,6 +119,25 @@ type NetworkBinding struct {
 	Protocol      string  `json: "protocol,omitempty"`
 }
 
+// Attachment is a resource attached to a task, such as the elastic network
+// interface of a task using the awsvpc network mode
+type Attachment struct {
+	Details []*KeyValuePair `json:"details,omitempty"`
+	ID      *string         `json:"id"`
+	Status  *string         `json:"status"`
+	Type    *string         `json:"type"`
+}
+
+type KeyValuePair struct {
+	Name  *string `json:"name"`
+	Value *string `json:"value"`
+}
+
+type Tag struct {
+	Key   *string `json:"key"`
+	Value *string `json:"value"`
+}
+
 type Overrides struct {
 	ContainerOverrides []*ContainerOverrides `json:"containerOverrides"`
 	TaskRoleArn        string                `json:"taskRoleArn,omitempty"`
@@ -0,0 +1,63 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// TaskDefinition defines the structure of an ECS task definition. Task
+// definitions are immutable, so they are described once and cached.
+type TaskDefinition struct {
+	Detail *TaskDefinitionDetail `json:"detail"`
+}
+
+type TaskDefinitionDetail struct {
+	ContainerDefinitions []*ContainerDefinition `json:"containerDefinitions"`
+	Family               *string                `json:"family"`
+	NetworkMode          string                 `json:"networkMode,omitempty"`
+	Revision             *int64                 `json:"revision"`
+	Status               string                 `json:"status,omitempty"`
+	TaskDefinitionARN    *string                `json:"taskDefinitionArn"`
+	TaskRoleARN          string                 `json:"taskRoleArn,omitempty"`
+	UpdatedAt            *string                `json:"updatedAt"`
+}
+
+type ContainerDefinition struct {
+	CPU               int64             `json:"cpu,omitempty"`
+	DockerLabels      map[string]string `json:"dockerLabels,omitempty"`
+	Essential         bool              `json:"essential"`
+	Image             *string           `json:"image"`
+	Memory            int64             `json:"memory,omitempty"`
+	MemoryReservation int64             `json:"memoryReservation,omitempty"`
+	Name              *string           `json:"name"`
+	PortMappings      []*PortMapping    `json:"portMappings,omitempty"`
+}
+
+type PortMapping struct {
+	ContainerPort *int64 `json:"containerPort"`
+	HostPort      int64  `json:"hostPort,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+func (taskDefinitionDetail *TaskDefinitionDetail) String() string {
+	return fmt.Sprintf("Task definition %s; Family: %s; Revision: %d; Status: %s; Containers: %d",
+		aws.StringValue(taskDefinitionDetail.TaskDefinitionARN),
+		aws.StringValue(taskDefinitionDetail.Family),
+		aws.Int64Value(taskDefinitionDetail.Revision),
+		taskDefinitionDetail.Status,
+		len(taskDefinitionDetail.ContainerDefinitions))
+}
@@ -64,6 +64,48 @@ func TestGerVersionInvalidTask(t *testing.T) {
 	assert.NotNil(t, err, "Expected an error getting task version for an invalid task")
 }
 
+func TestUnmarshalFargateTaskEvent(t *testing.T) {
+	detailJSON := `{
+		"attachments": [{
+			"id": "a1b2c3d4-5678-90ab-cdef-11111EXAMPLE",
+			"type": "eni",
+			"status": "ATTACHED",
+			"details": [{"name": "privateIPv4Address", "value": "10.0.1.23"}]
+		}],
+		"clusterArn": "arn:aws:ecs:us-east-1:123456789012:cluster/default",
+		"connectivity": "CONNECTED",
+		"cpu": "256",
+		"group": "service:web",
+		"healthStatus": "HEALTHY",
+		"lastStatus": "RUNNING",
+		"launchType": "FARGATE",
+		"memory": "512",
+		"platformVersion": "1.4.0",
+		"pullStartedAt": "2019-09-18T16:52:49.511Z",
+		"stopCode": "EssentialContainerExited",
+		"tags": [{"key": "team", "value": "web"}],
+		"version": 3
+	}`
+
+	detail := TaskDetail{}
+	err := json.Unmarshal([]byte(detailJSON), &detail)
+	assert.Nil(t, err, "Unexpected error unmarshaling Fargate task detail")
+	assert.Nil(t, detail.ContainerInstanceARN, "Fargate tasks should not have a container instance ARN")
+	assert.Equal(t, "FARGATE", detail.LaunchType, "Invalid launch type")
+	assert.Equal(t, "service:web", detail.Group, "Invalid group")
+	assert.Equal(t, "256", detail.CPU, "Invalid cpu")
+	assert.Equal(t, "512", detail.Memory, "Invalid memory")
+	assert.Equal(t, "1.4.0", detail.PlatformVersion, "Invalid platform version")
+	assert.Equal(t, "HEALTHY", detail.HealthStatus, "Invalid health status")
+	assert.Equal(t, "EssentialContainerExited", detail.StopCode, "Invalid stop code")
+	assert.Equal(t, "CONNECTED", detail.Connectivity, "Invalid connectivity")
+	assert.Equal(t, "2019-09-18T16:52:49.511Z", detail.PullStartedAt, "Invalid pull started at")
+	assert.Len(t, detail.Attachments, 1, "Expected one attachment")
+	assert.Equal(t, "10.0.1.23", *detail.Attachments[0].Details[0].Value, "Invalid attachment detail")
+	assert.Len(t, detail.Tags, 1, "Expected one tag")
+	assert.Equal(t, "team", *detail.Tags[0].Key, "Invalid tag key")
+}
+
 func marshalTask(t *testing.T, tsk Task) string {
 	tskJSON, err := json.Marshal(tsk)
 	assert.Nil(t, err, "Unexpected error marshaling task")
@@ -0,0 +1,322 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package wal implements a durable first-in, first-out log of records on local
+// disk. Appended records survive crashes once Append returns, and are read
+// back in the order they were appended until they are removed.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	logFileName    = "events.wal"
+	offsetFileName = "events.offset"
+
+	// headerLength is the length of the header preceding each record: the
+	// length of the record followed by its CRC-32 checksum
+	headerLength = 8
+
+	// maxRecordLength bounds the length of records, so that a corrupt header
+	// does not make Open allocate an arbitrary amount of memory
+	maxRecordLength = 16 * 1024 * 1024
+)
+
+// Log is a durable queue of records stored in a directory. Records are
+// appended to a log file, and removing the oldest record advances an offset
+// persisted in a separate file. The log file is truncated whenever it has
+// been consumed entirely. A crash while removing a record may leave it in the
+// log, so consumers have to tolerate reading a record more than once.
+type Log struct {
+	lock    sync.Mutex
+	dir     string
+	file    *os.File
+	offset  int64
+	size    int64
+	lengths []int64
+	closed  bool
+}
+
+// Open opens the log in directory 'dir', creating the directory and the log if
+// they do not exist. A record that was only partially written before a crash
+// is discarded.
+func Open(dir string) (*Log, error) {
+	if dir == "" {
+		return nil, errors.New("The log directory cannot be empty")
+	}
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not create log directory '%s'", dir)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open log in '%s'", dir)
+	}
+	err = syncDir(dir)
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "Could not open log in '%s'", dir)
+	}
+	l := &Log{
+		dir:  dir,
+		file: file,
+	}
+
+	l.offset, err = l.readOffset()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	err = l.scan()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Len returns the number of records in the log
+func (l *Log) Len() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.lengths)
+}
+
+// Append adds 'record' to the end of the log, returning once it is on disk
+func (l *Log) Append(record []byte) error {
+	if len(record) > maxRecordLength {
+		return errors.Errorf("Record of %d bytes is longer than the maximum of %d bytes", len(record), maxRecordLength)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return errors.New("The log is closed")
+	}
+
+	buf := make([]byte, headerLength+len(record))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(record))
+	copy(buf[headerLength:], record)
+
+	_, err := l.file.WriteAt(buf, l.size)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// cut off whatever part of the record was written, so that the
+		// next record is appended where this one started
+		l.file.Truncate(l.size)
+		return errors.Wrapf(err, "Could not append record to log")
+	}
+	l.size += int64(len(buf))
+	l.lengths = append(l.lengths, int64(len(record)))
+	return nil
+}
+
+// Peek returns the oldest record in the log without removing it, or false if
+// the log is empty
+func (l *Log) Peek() ([]byte, bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil, false, errors.New("The log is closed")
+	}
+	if len(l.lengths) == 0 {
+		return nil, false, nil
+	}
+
+	record := make([]byte, l.lengths[0])
+	_, err := l.file.ReadAt(record, l.offset+headerLength)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "Could not read record from log")
+	}
+	return record, true, nil
+}
+
+// Remove removes the oldest record from the log
+func (l *Log) Remove() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return errors.New("The log is closed")
+	}
+	if len(l.lengths) == 0 {
+		return errors.New("The log is empty")
+	}
+
+	offset := l.offset + headerLength + l.lengths[0]
+	if offset == l.size {
+		// consumed entirely, start over. The offset is reset before the
+		// log is truncated, so that a crash in between replays consumed
+		// records rather than leaving the offset past the end of the log.
+		err := l.writeOffset(0)
+		if err != nil {
+			return err
+		}
+		err = l.file.Truncate(0)
+		if err == nil {
+			err = l.file.Sync()
+		}
+		if err != nil {
+			l.writeOffset(l.offset)
+			return errors.Wrapf(err, "Could not truncate log")
+		}
+		l.size = 0
+		offset = 0
+	} else {
+		err := l.writeOffset(offset)
+		if err != nil {
+			return err
+		}
+	}
+	l.offset = offset
+	l.lengths = l.lengths[1:]
+	return nil
+}
+
+// Close closes the log
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return l.file.Close()
+}
+
+// scan finds the records following the offset, truncating the log after the
+// last complete record
+func (l *Log) scan() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "Could not read log")
+	}
+	size := info.Size()
+	if l.offset > size {
+		log.Warnf("Log offset %d in '%s' is past the end of the log, resetting it", l.offset, l.dir)
+		err = l.writeOffset(size)
+		if err != nil {
+			return err
+		}
+		l.offset = size
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(l.file, l.offset, size-l.offset))
+	end := l.offset
+	header := make([]byte, headerLength)
+	for {
+		_, err = io.ReadFull(reader, header)
+		if err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		if length > maxRecordLength {
+			err = errors.Errorf("record length %d is out of range", length)
+			break
+		}
+		record := make([]byte, length)
+		_, err = io.ReadFull(reader, record)
+		if err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:8]) {
+			err = errors.New("record checksum does not match")
+			break
+		}
+		l.lengths = append(l.lengths, length)
+		end += headerLength + length
+	}
+
+	if end < size {
+		log.Warnf("Discarding %d bytes at the end of the log in '%s' that are not a complete record: %v", size-end, l.dir, err)
+		err = l.file.Truncate(end)
+		if err == nil {
+			err = l.file.Sync()
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Could not truncate log")
+		}
+	}
+	l.size = end
+	return nil
+}
+
+func (l *Log) readOffset() (int64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(l.dir, offsetFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "Could not read log offset")
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, errors.Errorf("Log offset '%s' is invalid", strings.TrimSpace(string(data)))
+	}
+	return offset, nil
+}
+
+// writeOffset persists 'offset' by replacing the offset file, so that a crash
+// leaves either the previous or the new offset behind
+func (l *Log) writeOffset(offset int64) error {
+	path := filepath.Join(l.dir, offsetFileName)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "Could not write log offset")
+	}
+	_, err = file.WriteString(strconv.FormatInt(offset, 10))
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err == nil {
+		err = syncDir(l.dir)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Could not write log offset")
+	}
+	return nil
+}
+
+// syncDir makes the renames in 'dir' durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
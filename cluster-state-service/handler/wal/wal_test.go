@@ -0,0 +1,129 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func openTestLog(t *testing.T) (*Log, string) {
+	dir, err := ioutil.TempDir("", "wal")
+	assert.Nil(t, err, "Unexpected error creating log directory")
+	l, err := Open(dir)
+	assert.Nil(t, err, "Unexpected error opening log")
+	return l, dir
+}
+
+func assertNext(t *testing.T, l *Log, expected string) {
+	record, ok, err := l.Peek()
+	assert.Nil(t, err, "Unexpected error peeking at log")
+	assert.True(t, ok, "Expected a record in the log")
+	assert.Equal(t, expected, string(record), "Unexpected record")
+	assert.Nil(t, l.Remove(), "Unexpected error removing record")
+}
+
+func TestOpenEmptyDirectory(t *testing.T) {
+	_, err := Open("")
+	assert.Error(t, err, "Expected an error opening a log without a directory")
+}
+
+func TestAppendPeekRemove(t *testing.T) {
+	l, dir := openTestLog(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	_, ok, err := l.Peek()
+	assert.Nil(t, err, "Unexpected error peeking at an empty log")
+	assert.False(t, ok, "Unexpected record in an empty log")
+	assert.Error(t, l.Remove(), "Expected an error removing from an empty log")
+
+	for _, record := range []string{"first", "second", "third"} {
+		assert.Nil(t, l.Append([]byte(record)), "Unexpected error appending to log")
+	}
+	assert.Equal(t, 3, l.Len(), "Unexpected number of records")
+
+	assertNext(t, l, "first")
+	assertNext(t, l, "second")
+	assert.Nil(t, l.Append([]byte("fourth")), "Unexpected error appending to log")
+	assertNext(t, l, "third")
+	assertNext(t, l, "fourth")
+	assert.Equal(t, 0, l.Len(), "Expected log to be empty")
+
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	assert.Nil(t, err, "Unexpected error reading log file")
+	assert.Equal(t, int64(0), info.Size(), "Expected log file to be truncated once it is consumed")
+}
+
+func TestReopenKeepsRecordsNotRemoved(t *testing.T) {
+	l, dir := openTestLog(t)
+	defer os.RemoveAll(dir)
+
+	for _, record := range []string{"first", "second", "third"} {
+		assert.Nil(t, l.Append([]byte(record)), "Unexpected error appending to log")
+	}
+	assertNext(t, l, "first")
+	assert.Nil(t, l.Close(), "Unexpected error closing log")
+	assert.Error(t, l.Append([]byte("closed")), "Expected an error appending to a closed log")
+
+	l, err := Open(dir)
+	assert.Nil(t, err, "Unexpected error reopening log")
+	defer l.Close()
+	assert.Equal(t, 2, l.Len(), "Unexpected number of records after reopening")
+	assertNext(t, l, "second")
+	assertNext(t, l, "third")
+}
+
+func TestReopenDiscardsPartialRecord(t *testing.T) {
+	l, dir := openTestLog(t)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, l.Append([]byte("complete")), "Unexpected error appending to log")
+	assert.Nil(t, l.Append([]byte("partial")), "Unexpected error appending to log")
+	assert.Nil(t, l.Close(), "Unexpected error closing log")
+
+	path := filepath.Join(dir, logFileName)
+	info, err := os.Stat(path)
+	assert.Nil(t, err, "Unexpected error reading log file")
+	assert.Nil(t, os.Truncate(path, info.Size()-3), "Unexpected error truncating log file")
+
+	l, err = Open(dir)
+	assert.Nil(t, err, "Unexpected error reopening log")
+	defer l.Close()
+	assert.Equal(t, 1, l.Len(), "Expected the partial record to be discarded")
+	assert.Nil(t, l.Append([]byte("next")), "Unexpected error appending to log")
+	assertNext(t, l, "complete")
+	assertNext(t, l, "next")
+}
+
+func TestReopenWithOffsetPastEndOfLog(t *testing.T) {
+	l, dir := openTestLog(t)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, l.Close(), "Unexpected error closing log")
+
+	// an offset left behind by a log file that was removed
+	err := ioutil.WriteFile(filepath.Join(dir, offsetFileName), []byte("42"), 0600)
+	assert.Nil(t, err, "Unexpected error writing offset")
+
+	l, err = Open(dir)
+	assert.Nil(t, err, "Unexpected error reopening log")
+	defer l.Close()
+	assert.Equal(t, 0, l.Len(), "Unexpected records in log")
+	assert.Nil(t, l.Append([]byte("record")), "Unexpected error appending to log")
+	assertNext(t, l, "record")
+}
@@ -14,23 +14,29 @@
 package logger
 
 import (
-	"os"
-
 	log "github.com/cihub/seelog"
 	"github.com/pkg/errors"
 )
 
-const (
-	defaultLogFile    = "/var/output/logs/css.log"
-	logFileEnvVarName = "CSS_LOG_FILE"
+// levels maps the log levels the service supports to seelog levels
+var levels = map[string]string{
+	"debug": "debug",
+	"info":  "info",
+	"warn":  "warn",
+	"error": "error",
+	"crit":  "critical",
+	"none":  "off",
+}
 
-	defaultLogLevel    = "info"
-	logLevelEnvVarName = "CSS_LOG_LEVEL"
-)
+// InitLogger initializes and configures the logger to log messages of at least
+// 'level' to the console and to 'logFile'
+func InitLogger(logFile string, level string) error {
+	seelogLevel, ok := levels[level]
+	if !ok {
+		return errors.Errorf("Unknown log level '%s'", level)
+	}
 
-// InitLogger initializes and configures the logger
-func InitLogger() error {
-	logger, err := log.LoggerFromConfigAsString(loggerConfig())
+	logger, err := log.LoggerFromConfigAsString(loggerConfig(logFile, seelogLevel))
 	if err != nil {
 		return errors.Wrap(err, "Could not load logger config")
 	}
@@ -43,13 +49,19 @@ func InitLogger() error {
 	return nil
 }
 
-func loggerConfig() string {
+// IsValidLevel returns whether 'level' is one of the log levels the service supports
+func IsValidLevel(level string) bool {
+	_, ok := levels[level]
+	return ok
+}
+
+func loggerConfig(logFile string, level string) string {
 	return `
 	<!-- TODO: only errors go into the error.log -->
-	<seelog type="asyncloop" minlevel="` + logLevel() + `">
+	<seelog type="asyncloop" minlevel="` + level + `">
 		<outputs formatid="main">
 			<console/>
-		    	<rollingfile filename="` + logFile() + `" type="date"
+		    	<rollingfile filename="` + logFile + `" type="date"
 			     datepattern="2006-01-02-15" archivetype="none" maxrolls="72" />
 			-->
 	    </outputs>
@@ -59,27 +71,3 @@ func loggerConfig() string {
 	</seelog>
 	`
 }
-
-func logFile() string {
-	logFile := os.Getenv(logFileEnvVarName)
-	if logFile == "" {
-		logFile = defaultLogFile
-	}
-	return logFile
-}
-
-func logLevel() string {
-	levels := map[string]string{
-		"debug": "debug",
-		"info":  "info",
-		"warn":  "warn",
-		"error": "error",
-		"crit":  "critical",
-		"none":  "off",
-	}
-	level, ok := levels[os.Getenv(logLevelEnvVarName)]
-	if ok {
-		return level
-	}
-	return defaultLogLevel
-}
@@ -23,6 +23,7 @@ import (
 	"github.com/goguardian/blox/cluster-state-service/config"
 	"github.com/goguardian/blox/cluster-state-service/handler/run"
 	"github.com/goguardian/blox/cluster-state-service/versioning"
+	"github.com/pkg/errors"
 	"os"
 )
 
@@ -30,20 +31,27 @@ const errorCode = 1
 
 func main() {
 	defer log.Flush()
-	err := logger.InitLogger()
-	if err != nil {
-		fmt.Printf("Could not initialize logger: %+v", err)
-	}
-	if err := cmd.RootCmd.Execute(); err != nil {
+	if err := cmd.Execute(start); err != nil {
 		log.Criticalf("Error executing: %+v", err)
+		log.Flush()
 		os.Exit(errorCode)
 	}
+}
+
+// start starts the cluster state service once the configuration is loaded
+func start() error {
 	if config.PrintVersion {
 		versioning.PrintVersion()
-		os.Exit(0)
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := logger.InitLogger(config.LogFile, config.LogLevel); err != nil {
+		fmt.Printf("Could not initialize logger: %+v", err)
 	}
 	if err := run.StartClusterStateService(config.QueueNameURI, config.CSSBindAddr, config.EtcdEndpoints); err != nil {
-		log.Criticalf("Error starting event stream handler: %+v", err)
-		os.Exit(errorCode)
+		return errors.Wrapf(err, "Error starting event stream handler")
 	}
+	return nil
 }
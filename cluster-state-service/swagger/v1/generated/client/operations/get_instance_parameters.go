@@ -81,7 +81,7 @@ type GetInstanceParams struct {
 	*/
 	Arn string
 	/*Cluster
-	  Cluster name of the instance to fetch
+	  Cluster of the instance to fetch (cluster name, region:name or cluster ARN)
 
 	*/
 	Cluster string
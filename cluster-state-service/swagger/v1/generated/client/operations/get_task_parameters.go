@@ -81,7 +81,7 @@ type GetTaskParams struct {
 	*/
 	Arn string
 	/*Cluster
-	  Cluster name of the task to fetch
+	  Cluster of the task to fetch (cluster name, region:name or cluster ARN)
 
 	*/
 	Cluster string
@@ -76,7 +76,7 @@ for the list instances operation typically these are written to a http.Request
 type ListInstancesParams struct {
 
 	/*Cluster
-	  Cluster name or ARN to filter instances by
+	  Cluster name, region qualified cluster name (region:name) or cluster ARN to filter instances by
 
 	*/
 	Cluster *string
@@ -76,10 +76,25 @@ for the list tasks operation typically these are written to a http.Request
 type ListTasksParams struct {
 
 	/*Cluster
-	  Cluster name or ARN to filter tasks by
+	  Cluster name, region qualified cluster name (region:name) or cluster ARN to filter tasks by
 
 	*/
 	Cluster *string
+	/*Group
+	  Task group, such as service:<service name>, to filter tasks by
+
+	*/
+	Group *string
+	/*LaunchType
+	  Launch type (EC2 or FARGATE) to filter tasks by
+
+	*/
+	LaunchType *string
+	/*ServiceName
+	  Name of the service to filter tasks by. Shorthand for the group service:<name> and cannot be combined with the group filter
+
+	*/
+	ServiceName *string
 	/*StartedBy
 	  StartedBy to filter tasks by
 
@@ -140,6 +155,39 @@ func (o *ListTasksParams) SetCluster(cluster *string) {
 	o.Cluster = cluster
 }
 
+// WithGroup adds the group to the list tasks params
+func (o *ListTasksParams) WithGroup(group *string) *ListTasksParams {
+	o.SetGroup(group)
+	return o
+}
+
+// SetGroup adds the group to the list tasks params
+func (o *ListTasksParams) SetGroup(group *string) {
+	o.Group = group
+}
+
+// WithLaunchType adds the launchType to the list tasks params
+func (o *ListTasksParams) WithLaunchType(launchType *string) *ListTasksParams {
+	o.SetLaunchType(launchType)
+	return o
+}
+
+// SetLaunchType adds the launchType to the list tasks params
+func (o *ListTasksParams) SetLaunchType(launchType *string) {
+	o.LaunchType = launchType
+}
+
+// WithServiceName adds the serviceName to the list tasks params
+func (o *ListTasksParams) WithServiceName(serviceName *string) *ListTasksParams {
+	o.SetServiceName(serviceName)
+	return o
+}
+
+// SetServiceName adds the serviceName to the list tasks params
+func (o *ListTasksParams) SetServiceName(serviceName *string) {
+	o.ServiceName = serviceName
+}
+
 // WithStartedBy adds the startedBy to the list tasks params
 func (o *ListTasksParams) WithStartedBy(startedBy *string) *ListTasksParams {
 	o.SetStartedBy(startedBy)
@@ -186,6 +234,54 @@ func (o *ListTasksParams) WriteToRequest(r runtime.ClientRequest, reg strfmt.Reg
 
 	}
 
+	if o.Group != nil {
+
+		// query param group
+		var qrGroup string
+		if o.Group != nil {
+			qrGroup = *o.Group
+		}
+		qGroup := qrGroup
+		if qGroup != "" {
+			if err := r.SetQueryParam("group", qGroup); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	if o.LaunchType != nil {
+
+		// query param launchType
+		var qrLaunchType string
+		if o.LaunchType != nil {
+			qrLaunchType = *o.LaunchType
+		}
+		qLaunchType := qrLaunchType
+		if qLaunchType != "" {
+			if err := r.SetQueryParam("launchType", qLaunchType); err != nil {
+				return err
+			}
+		}
+
+	}
+
+	if o.ServiceName != nil {
+
+		// query param serviceName
+		var qrServiceName string
+		if o.ServiceName != nil {
+			qrServiceName = *o.ServiceName
+		}
+		qServiceName := qrServiceName
+		if qServiceName != "" {
+			if err := r.SetQueryParam("serviceName", qServiceName); err != nil {
+				return err
+			}
+		}
+
+	}
+
 	if o.StartedBy != nil {
 
 		// query param startedBy
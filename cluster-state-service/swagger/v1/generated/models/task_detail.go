@@ -30,18 +30,29 @@ import (
 // swagger:model TaskDetail
 type TaskDetail struct {
 
+	// attachments
+	Attachments TaskDetailAttachments `json:"attachments"`
+
 	// cluster a r n
 	// Required: true
 	ClusterARN *string `json:"clusterARN"`
 
-	// container instance a r n
-	// Required: true
-	ContainerInstanceARN *string `json:"containerInstanceARN"`
+	// connectivity
+	Connectivity string `json:"connectivity,omitempty"`
+
+	// connectivity at
+	ConnectivityAt string `json:"connectivityAt,omitempty"`
+
+	// ARN of the container instance the task is placed on. Not set for tasks using the Fargate launch type.
+	ContainerInstanceARN *string `json:"containerInstanceARN,omitempty"`
 
 	// containers
 	// Required: true
 	Containers TaskDetailContainers `json:"containers"`
 
+	// cpu
+	CPU string `json:"cpu,omitempty"`
+
 	// created at
 	// Required: true
 	CreatedAt *string `json:"createdAt"`
@@ -50,30 +61,60 @@ type TaskDetail struct {
 	// Required: true
 	DesiredStatus *string `json:"desiredStatus"`
 
+	// group
+	Group string `json:"group,omitempty"`
+
+	// health status
+	HealthStatus string `json:"healthStatus,omitempty"`
+
 	// last status
 	// Required: true
 	LastStatus *string `json:"lastStatus"`
 
+	// launch type
+	LaunchType string `json:"launchType,omitempty"`
+
+	// memory
+	Memory string `json:"memory,omitempty"`
+
 	// overrides
 	// Required: true
 	Overrides *TaskOverride `json:"overrides"`
 
+	// platform version
+	PlatformVersion string `json:"platformVersion,omitempty"`
+
+	// pull started at
+	PullStartedAt string `json:"pullStartedAt,omitempty"`
+
+	// pull stopped at
+	PullStoppedAt string `json:"pullStoppedAt,omitempty"`
+
 	// started at
 	StartedAt string `json:"startedAt,omitempty"`
 
 	// started by
 	StartedBy string `json:"startedBy,omitempty"`
 
+	// stop code
+	StopCode string `json:"stopCode,omitempty"`
+
 	// stopped at
 	StoppedAt string `json:"stoppedAt,omitempty"`
 
 	// stopped reason
 	StoppedReason string `json:"stoppedReason,omitempty"`
 
+	// tags
+	Tags TaskDetailTags `json:"tags"`
+
 	// task a r n
 	// Required: true
 	TaskARN *string `json:"taskARN"`
 
+	// task definition
+	TaskDefinition *TaskDefinitionSummary `json:"taskDefinition,omitempty"`
+
 	// task definition a r n
 	// Required: true
 	TaskDefinitionARN *string `json:"taskDefinitionARN"`
@@ -88,11 +129,6 @@ func (m *TaskDetail) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
-	if err := m.validateContainerInstanceARN(formats); err != nil {
-		// prop
-		res = append(res, err)
-	}
-
 	if err := m.validateContainers(formats); err != nil {
 		// prop
 		res = append(res, err)
@@ -123,6 +159,11 @@ func (m *TaskDetail) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateTaskDefinition(formats); err != nil {
+		// prop
+		res = append(res, err)
+	}
+
 	if err := m.validateTaskDefinitionARN(formats); err != nil {
 		// prop
 		res = append(res, err)
@@ -143,15 +184,6 @@ func (m *TaskDetail) validateClusterARN(formats strfmt.Registry) error {
 	return nil
 }
 
-func (m *TaskDetail) validateContainerInstanceARN(formats strfmt.Registry) error {
-
-	if err := validate.Required("containerInstanceARN", "body", m.ContainerInstanceARN); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (m *TaskDetail) validateContainers(formats strfmt.Registry) error {
 
 	if err := validate.Required("containers", "body", m.Containers); err != nil {
@@ -223,6 +255,25 @@ func (m *TaskDetail) validateTaskARN(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *TaskDetail) validateTaskDefinition(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.TaskDefinition) { // not required
+		return nil
+	}
+
+	if m.TaskDefinition != nil {
+
+		if err := m.TaskDefinition.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("taskDefinition")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *TaskDetail) validateTaskDefinitionARN(formats strfmt.Registry) error {
 
 	if err := validate.Required("taskDefinitionARN", "body", m.TaskDefinitionARN); err != nil {
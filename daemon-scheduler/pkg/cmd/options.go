@@ -14,6 +14,9 @@
 package cmd
 
 import (
+	"time"
+
+	"github.com/goguardian/blox/daemon-scheduler/pkg/clients"
 	"github.com/goguardian/blox/daemon-scheduler/pkg/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -46,6 +49,15 @@ it launches the task in those nodes as well.`,
 		},
 	}
 	rootCmd.PersistentFlags().StringArrayVar(&config.EtcdEndpoints, "etcd-endpoint", make([]string, 0), "Etcd node addresses")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdDialTimeout, "etcd-dial-timeout", clients.DefaultDialTimeout, "How long to wait for a connection to etcd")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdCAFile, "etcd-ca-file", "", "PEM certificate authorities to verify the certificates of the etcd nodes with. Connections to etcd use TLS if any etcd TLS flag is set")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdCertFile, "etcd-cert-file", "", "PEM client certificate to present to etcd")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdKeyFile, "etcd-key-file", "", "PEM private key of the etcd client certificate")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdUsername, "etcd-username", "", "Etcd user to authenticate as")
+	rootCmd.PersistentFlags().StringVar(&config.EtcdPassword, "etcd-password", "", "Password of the etcd user")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdAutoSyncInterval, "etcd-auto-sync-interval", 0, "How often to update the etcd endpoints with the members of the etcd cluster. 0 disables auto-sync")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdKeepAliveInterval, "etcd-keepalive-interval", 30*time.Second, "How often to probe the connection to etcd so that it is not dropped while idle and broken connections are redialed. 0 disables keepalive")
+	rootCmd.PersistentFlags().DurationVar(&config.EtcdKeepAliveTimeout, "etcd-keepalive-timeout", 10*time.Second, "How long to wait for a response to an etcd keepalive probe")
 	rootCmd.PersistentFlags().StringVar(&config.SchedulerBindAddr, "bind", "", "Scheduler bind address")
 	rootCmd.PersistentFlags().StringVar(&config.ClusterStateServiceEndpoint, "css-endpoint", "", "Cluster state service address")
 	rootCmd.PersistentFlags().BoolVar(&config.PrintVersion, "version", false, "Print version and exit")
@@ -13,9 +13,40 @@
 
 package config
 
+import "time"
+
 // EtcdEndpoints represents the etcd servers to connect to.
 var EtcdEndpoints []string
 
+// EtcdDialTimeout represents how long to wait for a connection to etcd.
+var EtcdDialTimeout time.Duration
+
+// EtcdCAFile represents the certificate authorities that the certificates of
+// the etcd nodes are verified with.
+var EtcdCAFile string
+
+// EtcdCertFile represents the client certificate presented to etcd.
+var EtcdCertFile string
+
+// EtcdKeyFile represents the private key of EtcdCertFile.
+var EtcdKeyFile string
+
+// EtcdUsername represents the etcd user to authenticate as.
+var EtcdUsername string
+
+// EtcdPassword represents the password of EtcdUsername.
+var EtcdPassword string
+
+// EtcdAutoSyncInterval represents how often the etcd endpoints are updated
+// with the members of the etcd cluster.
+var EtcdAutoSyncInterval time.Duration
+
+// EtcdKeepAliveInterval represents how often the connection to etcd is probed.
+var EtcdKeepAliveInterval time.Duration
+
+// EtcdKeepAliveTimeout represents how long to wait for a response to a probe.
+var EtcdKeepAliveTimeout time.Duration
+
 // SchedulerBindAddr represents the endpoint scheduler listens on.
 var SchedulerBindAddr string
 
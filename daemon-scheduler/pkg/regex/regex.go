@@ -14,8 +14,10 @@
 package regex
 
 const (
+	// partitionRegex matches the standard, China and GovCloud AWS partitions
+	partitionRegex               = "aws(-cn|-us-gov)?"
 	clusterNameRegexWithoutStart = "[a-zA-Z][a-zA-Z0-9_-]{1,254}$"
 	ClusterNameRegex             = "^" + clusterNameRegexWithoutStart
-	ClusterARNRegex              = "^(arn:aws:ecs:)([\\-\\w]+):[0-9]{12}:(cluster)/" + clusterNameRegexWithoutStart
+	ClusterARNRegex              = "^(arn:" + partitionRegex + ":ecs:)([\\-\\w]+):[0-9]{12}:(cluster)/" + clusterNameRegexWithoutStart
 	ClusterNameAsARNSuffixRegex  = "/" + clusterNameRegexWithoutStart
 )
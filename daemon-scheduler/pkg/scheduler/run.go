@@ -47,7 +47,18 @@ func Run(schedulerBindAddr string, clusterStateServiceEndpoint string) error {
 		return errors.Errorf("The address for cluster state service endpoint is not set")
 	}
 
-	etcdClient, err := clients.NewEtcdClient(config.EtcdEndpoints)
+	etcdClient, err := clients.NewEtcdClient(clients.EtcdConfig{
+		Endpoints:         config.EtcdEndpoints,
+		DialTimeout:       config.EtcdDialTimeout,
+		CAFile:            config.EtcdCAFile,
+		CertFile:          config.EtcdCertFile,
+		KeyFile:           config.EtcdKeyFile,
+		Username:          config.EtcdUsername,
+		Password:          config.EtcdPassword,
+		AutoSyncInterval:  config.EtcdAutoSyncInterval,
+		KeepAliveInterval: config.EtcdKeepAliveInterval,
+		KeepAliveTimeout:  config.EtcdKeepAliveTimeout,
+	})
 	if err != nil {
 		log.Criticalf("Could not start etcd: %+v", err)
 		return err
@@ -23,6 +23,10 @@ const (
 	validClusterName = "clust_er-1"
 	validClusterARN  = "arn:aws:ecs:us-east-1:123456789123:cluster/" + validClusterName
 
+	validChinaClusterARN       = "arn:aws-cn:ecs:cn-north-1:123456789123:cluster/" + validClusterName
+	validGovCloudClusterARN    = "arn:aws-us-gov:ecs:us-gov-west-1:123456789123:cluster/" + validClusterName
+	invalidPartitionClusterARN = "arn:aws-xx:ecs:us-east-1:123456789123:cluster/" + validClusterName
+
 	invalidClusterName                 = "cluster1/cluster1"
 	invalidClusterARNWithNoName        = "arn:aws:ecs:us-east-1:123456789123:cluster/"
 	invalidClusterARNWithInvalidName   = "arn:aws:ecs:us-east-1:123456789123:cluster/" + invalidClusterName
@@ -68,3 +72,18 @@ func TestIsClusterARN(t *testing.T) {
 	isValid := IsClusterARN(validClusterARN)
 	assert.True(t, isValid, "Valid cluster ARN should satisfy regex")
 }
+
+func TestIsClusterARNChinaPartition(t *testing.T) {
+	isValid := IsClusterARN(validChinaClusterARN)
+	assert.True(t, isValid, "Valid cluster ARN in the aws-cn partition should satisfy regex")
+}
+
+func TestIsClusterARNGovCloudPartition(t *testing.T) {
+	isValid := IsClusterARN(validGovCloudClusterARN)
+	assert.True(t, isValid, "Valid cluster ARN in the aws-us-gov partition should satisfy regex")
+}
+
+func TestIsClusterARNInvalidPartition(t *testing.T) {
+	isValid := IsClusterARN(invalidPartitionClusterARN)
+	assert.False(t, isValid, "Cluster ARN with an unknown partition should not satisfy regex")
+}